@@ -0,0 +1,144 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+)
+
+const (
+	maxRecentErrors   = 50  // Number of error responses kept in memory
+	summaryErrorCount = 10  // Number of recent errors shown on the dashboard
+	nearFullThreshold = 0.8 // Fill ratio at which a session counts as near full
+)
+
+// apiError is an error response remembered for the admin dashboard
+type apiError struct {
+	Time       string `json:"time"`
+	StatusCode int    `json:"statusCode"`
+	Message    string `json:"message"`
+}
+
+// sessionSummary describes a class on a single date
+type sessionSummary struct {
+	ClassID        int    `json:"classId"`
+	ClassName      string `json:"className"`
+	Date           Date   `json:"date"`
+	Capacity       int    `json:"capacity"`
+	Booked         int    `json:"booked"`
+	AvailableSlots int    `json:"availableSlots"`
+}
+
+var (
+	recentErrors []apiError // Ring of the latest error responses, oldest first
+	errorsMutex  sync.Mutex // Guards recentErrors independently of the data mutex
+)
+
+// recordError remembers an error response for the admin dashboard
+func recordError(statusCode int, message string) {
+	errorsMutex.Lock()
+	defer errorsMutex.Unlock()
+
+	recentErrors = append(recentErrors, apiError{
+		Time:       now().Format("02-01-2006 15:04:05"),
+		StatusCode: statusCode,
+		Message:    message,
+	})
+	if len(recentErrors) > maxRecentErrors {
+		recentErrors = recentErrors[len(recentErrors)-maxRecentErrors:]
+	}
+}
+
+// latestErrors returns up to n recorded errors, newest first
+func latestErrors(n int) []apiError {
+	errorsMutex.Lock()
+	defer errorsMutex.Unlock()
+
+	latest := []apiError{}
+	for i := len(recentErrors) - 1; i >= 0 && len(latest) < n; i-- {
+		latest = append(latest, recentErrors[i])
+	}
+	return latest
+}
+
+// Handler for the admin dashboard summary
+func adminSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	day := today()
+	date := Date{day}
+
+	mutex.Lock()
+	// Sessions running today, with their current bookings
+	todaysSessions := []sessionSummary{}
+	totalBookingsToday := 0
+	for _, class := range classes {
+		if class.DeletedAt != nil {
+			continue
+		}
+		if day.Before(class.StartDate.Time) || day.After(class.EndDate.Time) {
+			continue
+		}
+		booked := countBookings(sessionIDOn(class.ClassName, date))
+		capacity := sessionCapacity(&class, date)
+		totalBookingsToday += booked
+		todaysSessions = append(todaysSessions, sessionSummary{
+			ClassID:        class.ID,
+			ClassName:      class.ClassName,
+			Date:           date,
+			Capacity:       capacity,
+			Booked:         booked,
+			AvailableSlots: capacity - booked,
+		})
+	}
+
+	// Upcoming sessions that have reached the near-full threshold
+	nearFull := []sessionSummary{}
+	seen := map[sessionKey]bool{}
+	for _, booking := range bookings {
+		key := sessionKey{booking.ClassName, booking.Date}
+		if seen[key] || !bookingActive(booking) {
+			continue
+		}
+		seen[key] = true
+
+		if booking.Date.Before(day) {
+			continue
+		}
+		class := findClassOn(booking.ClassName, booking.Date.Time)
+		if class == nil {
+			continue
+		}
+		booked := countBookings(sessionIDOn(class.ClassName, booking.Date))
+		capacity := sessionCapacity(class, booking.Date)
+		if float64(booked) >= nearFullThreshold*float64(capacity) {
+			nearFull = append(nearFull, sessionSummary{
+				ClassID:        class.ID,
+				ClassName:      class.ClassName,
+				Date:           booking.Date,
+				Capacity:       capacity,
+				Booked:         booked,
+				AvailableSlots: capacity - booked,
+			})
+		}
+	}
+	mutex.Unlock()
+
+	// Soonest sessions first
+	sort.SliceStable(nearFull, func(i, j int) bool {
+		return nearFull[i].Date.Before(nearFull[j].Date.Time)
+	})
+
+	response := map[string]interface{}{
+		"date":               date,
+		"todaysSessions":     todaysSessions,
+		"totalBookingsToday": totalBookingsToday,
+		"nearFullClasses":    nearFull,
+		"recentErrors":       latestErrors(summaryErrorCount),
+	}
+	successResponse(w, http.StatusOK, "Summary retrieved successfully", response)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestAdminSummaryHandler verifies the aggregated dashboard payload.
+func TestAdminSummaryHandler(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2024, 12, 16, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	classes = []Class{
+		{ID: 1, ClassName: "Pilates", StartDate: testDate("15-12-2024"), EndDate: testDate("20-12-2024"), Capacity: 5},
+		{ID: 2, ClassName: "Yoga", StartDate: testDate("17-12-2024"), EndDate: testDate("31-12-2024"), Capacity: 2},
+	}
+	bookings = []Booking{
+		{ID: 1, MemberName: "A", Date: testDate("16-12-2024"), ClassName: "Pilates", Status: bookingStatusConfirmed},
+		{ID: 2, MemberName: "B", Date: testDate("16-12-2024"), ClassName: "Pilates", Status: bookingStatusCancelled},
+		{ID: 3, MemberName: "C", Date: testDate("18-12-2024"), ClassName: "Yoga", Status: bookingStatusConfirmed},
+		{ID: 4, MemberName: "D", Date: testDate("18-12-2024"), ClassName: "Yoga", Status: bookingStatusConfirmed},
+	}
+	migrateSessions()
+	errorResponse(httptest.NewRecorder(), http.StatusInternalServerError, "Failed to save booking data")
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/summary", nil)
+	rec := httptest.NewRecorder()
+	adminSummaryHandler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var response struct {
+		Data struct {
+			TodaysSessions     []sessionSummary `json:"todaysSessions"`
+			TotalBookingsToday int              `json:"totalBookingsToday"`
+			NearFullClasses    []sessionSummary `json:"nearFullClasses"`
+			RecentErrors       []apiError       `json:"recentErrors"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&response)
+
+	if len(response.Data.TodaysSessions) != 1 || response.Data.TodaysSessions[0].ClassName != "Pilates" {
+		t.Errorf("expected only Pilates running today, got %+v", response.Data.TodaysSessions)
+	}
+	if response.Data.TotalBookingsToday != 1 {
+		t.Errorf("expected 1 booking today, got %d", response.Data.TotalBookingsToday)
+	}
+	if len(response.Data.NearFullClasses) != 1 || response.Data.NearFullClasses[0].ClassName != "Yoga" {
+		t.Errorf("expected Yoga to be near full, got %+v", response.Data.NearFullClasses)
+	}
+	if len(response.Data.RecentErrors) != 1 || response.Data.RecentErrors[0].StatusCode != http.StatusInternalServerError {
+		t.Errorf("expected the recorded error, got %+v", response.Data.RecentErrors)
+	}
+}
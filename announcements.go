@@ -0,0 +1,134 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// notifyAnnouncement is a message from the studio to the members booked into
+// some sessions, e.g. "bring a mat tomorrow"
+const notifyAnnouncement = "announcement"
+
+// Announcement records a message broadcast to the members booked in a class,
+// or any class, over a date range
+type Announcement struct {
+	ID         int       `json:"id"`
+	ClassName  string    `json:"className,omitempty"` // Empty for every class
+	From       string    `json:"from"`                // DD-MM-YYYY
+	To         string    `json:"to"`                  // DD-MM-YYYY
+	Subject    string    `json:"subject"`
+	Message    string    `json:"message"`
+	Recipients []string  `json:"recipients"`
+	Actor      string    `json:"actor"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+var (
+	announcements  []Announcement // Temp Slice to hold announcements
+	announcementId = 1            // Incremental ID for announcements
+)
+
+// checkAnnouncement validates an announcement and fills in its end date
+func checkAnnouncement(announcement *Announcement) *requestRejection {
+	if announcement.Subject == "" || announcement.Message == "" {
+		return &requestRejection{http.StatusBadRequest, "Give the subject and message of the announcement"}
+	}
+	if announcement.To == "" {
+		announcement.To = announcement.From
+	}
+	from, fromErr := time.Parse(dateLayout, announcement.From)
+	to, toErr := time.Parse(dateLayout, announcement.To)
+	if fromErr != nil || toErr != nil {
+		return &requestRejection{http.StatusBadRequest, "Invalid date format, use DD-MM-YYYY"}
+	}
+	if to.Before(from) {
+		return &requestRejection{http.StatusBadRequest, "The end date must not be before the start date"}
+	}
+	return nil
+}
+
+// announcementRecipients returns the members with active bookings matching an
+// announcement, each once, in booking order. Callers must hold the mutex.
+func announcementRecipients(announcement Announcement) []string {
+	from, _ := time.Parse(dateLayout, announcement.From)
+	to, _ := time.Parse(dateLayout, announcement.To)
+	seen := map[string]bool{}
+	recipients := []string{}
+	for _, booking := range bookings {
+		if booking.Date.Before(from) || booking.Date.After(to) || !bookingActive(booking) || seen[booking.MemberName] {
+			continue
+		}
+		if announcement.ClassName != "" && booking.ClassName != announcement.ClassName {
+			continue
+		}
+		seen[booking.MemberName] = true
+		recipients = append(recipients, booking.MemberName)
+	}
+	return recipients
+}
+
+// Handler for listing announcements and broadcasting one. Each member booked
+// in the class and dates is sent it on the channels they want announcements on.
+func announcementHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		response := append([]Announcement{}, announcements...)
+		mutex.Unlock()
+		successResponse(w, http.StatusOK, "Announcements retrieved successfully", map[string]interface{}{"announcements": response})
+
+	case http.MethodPost:
+		var announcement Announcement
+		if err := decodeBody(r, &announcement); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if rejection := checkAnnouncement(&announcement); rejection != nil {
+			errorResponse(w, rejection.StatusCode, rejection.Message)
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if announcement.ClassName != "" {
+			found := false
+			for _, index := range classesNamed(announcement.ClassName) {
+				if classes[index].DeletedAt == nil {
+					found = true
+					break
+				}
+			}
+			if !found {
+				errorResponse(w, http.StatusNotFound, "Class not found")
+				return
+			}
+		}
+
+		announcement.ID = announcementId
+		announcement.Recipients = announcementRecipients(announcement)
+		announcement.Actor = actorFromRequest(r)
+		announcement.CreatedAt = now()
+		announcementId++
+		announcements = append(announcements, announcement)
+		if err := writeDataToJsonFile("announcements.json", announcements); err != nil {
+			announcements = announcements[:len(announcements)-1]
+			announcementId--
+			errorResponse(w, http.StatusInternalServerError, "Failed to save announcement data")
+			return
+		}
+		recordAudit(announcement.Actor, "create", "announcement", announcement.ID, nil, announcement)
+
+		data := map[string]string{"event": notifyAnnouncement, "announcementId": strconv.Itoa(announcement.ID)}
+		for _, memberName := range announcement.Recipients {
+			notifyMember(memberName, notifyAnnouncement, announcement.Subject, announcement.Message, data)
+		}
+
+		successResponse(w, http.StatusCreated, "Announcement sent successfully", announcement)
+		logData("Announcement sent successfully", announcement)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// TestAnnouncement verifies announcements reach each member booked in the class and dates once, on the channels they allow.
+func TestAnnouncement(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 5}}
+	members = []Member{
+		{Name: "Ann", Email: "ann@example.com", Phone: "+447700900123"},
+		{Name: "Ben", Email: "ben@example.com", Preferences: &NotificationPreferences{
+			Events: map[string]map[string]bool{notifyAnnouncement: {channelEmail: false}},
+		}},
+	}
+	bookings = []Booking{
+		{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed},
+		{ID: 2, MemberName: "Ann", ClassName: "Yoga", Date: testDate("03-12-2099"), Status: bookingStatusConfirmed},
+		{ID: 3, MemberName: "Ben", ClassName: "Yoga", Date: testDate("03-12-2099"), Status: bookingStatusConfirmed},
+		{ID: 4, MemberName: "Cat", ClassName: "Yoga", Date: testDate("03-12-2099"), Status: bookingStatusCancelled},
+		{ID: 5, MemberName: "Dan", ClassName: "Yoga", Date: testDate("05-12-2099"), Status: bookingStatusConfirmed},
+	}
+
+	var emails []string
+	texts := 0
+	defer func(send func(to, subject, body string) error) { sendEmail = send }(sendEmail)
+	defer func(send func(to, body string) error) { sendSMS = send }(sendSMS)
+	sendEmail = func(to, subject, body string) error { emails = append(emails, to); return nil }
+	sendSMS = func(to, body string) error { texts++; return nil }
+
+	announce := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/announcements", bytes.NewReader([]byte(body)))
+		rec := httptest.NewRecorder()
+		announcementHandler(rec, req)
+		return rec
+	}
+
+	for body, status := range map[string]int{
+		`{"className":"Yoga","from":"02-12-2099","subject":"Mats"}`:                                  http.StatusBadRequest,
+		`{"className":"Yoga","from":"03-12-2099","to":"02-12-2099","subject":"Mats","message":"Hi"}`: http.StatusBadRequest,
+		`{"className":"Pilates","from":"02-12-2099","subject":"Mats","message":"Hi"}`:                http.StatusNotFound,
+	} {
+		if rec := announce(body); rec.Code != status {
+			t.Errorf("expected %s to be refused with %d, got %d", body, status, rec.Code)
+		}
+	}
+
+	rec := announce(`{"className":"Yoga","from":"02-12-2099","to":"04-12-2099","subject":"Mats","message":"Bring a mat tomorrow"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the announcement to be sent, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(announcements) != 1 || !slices.Equal(announcements[0].Recipients, []string{"Ann", "Ben"}) {
+		t.Errorf("expected Ann and Ben to be reached, got %+v", announcements)
+	}
+	if !slices.Equal(emails, []string{"ann@example.com"}) || texts != 1 {
+		t.Errorf("expected one email and one text to Ann, got %v and %d", emails, texts)
+	}
+}
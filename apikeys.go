@@ -0,0 +1,424 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIKey authenticates a client of the API. Only a hash of the key is stored.
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Scope      string     `json:"scope"`
+	Prefix     string     `json:"prefix"` // First characters of the key, to tell keys apart
+	KeyHash    string     `json:"keyHash,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"` // Set when the key is rotated out
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	ReplacedBy int        `json:"replacedBy,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// API key scopes
+const (
+	apiKeyScopeReadOnly = "read-only"
+	apiKeyScopeBookings = "bookings"
+	apiKeyScopeAdmin    = "admin"
+)
+
+// apiKeyLastUsedPrecision bounds how often last-used times are written to disk
+const apiKeyLastUsedPrecision = time.Minute
+
+// apiKeyContextKey stores the authenticated key in a request's context
+type apiKeyContextKey struct{}
+
+var (
+	apiKeys  []APIKey // Temp Slice to hold API keys
+	apiKeyId = 1      // Incremental ID for API keys
+)
+
+// publicPaths are reachable without an API key so members can sign in
+var publicPaths = map[string]bool{
+	"/login":                  true,
+	"/logout":                 true,
+	"/me":                     true,
+	"/verify":                 true,
+	"/password-reset":         true,
+	"/password-reset/confirm": true,
+	"/auth/google":            true,
+	"/auth/google/callback":   true,
+}
+
+// memberBookingActions are the booking actions members signed in without a
+// key can take on their own bookings
+var memberBookingActions = map[string]bool{"cancel": true, "transfer": true, "reschedule": true, "review": true}
+
+// memberAccountPaths are the parts of /members/{name}/ members signed in
+// without a key can change for their own account, "" being the profile
+var memberAccountPaths = map[string]bool{"": true, "data": true, "password": true, "waiver": true, "preferences": true, "devices": true}
+
+// sessionAllows reports whether a member signed in without a key may make a
+// change: like the bookings scope they can book, hold and join the waitlist,
+// and they can manage their own bookings and account. Handlers book them
+// under their own name.
+func sessionAllows(member string, r *http.Request) bool {
+	path := r.URL.Path
+	switch {
+	case path == "/bookings" || path == "/bookings/group" || path == "/bookings/series" || path == "/holds" || path == "/waitlist":
+		return true
+	case strings.HasPrefix(path, "/waitlist/"):
+		return true
+	case strings.HasPrefix(path, "/bookings/"):
+		_, action, _ := strings.Cut(strings.TrimPrefix(path, "/bookings/"), "/")
+		return memberBookingActions[action]
+	case strings.HasPrefix(path, "/members/"):
+		name, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.EscapedPath(), "/members/"), "/")
+		section, _, _ := strings.Cut(rest, "/")
+		name, err := url.PathUnescape(name)
+		return err == nil && name == member && memberAccountPaths[section]
+	}
+	return false
+}
+
+// passwordPath reports whether a path sets a member's password, which its
+// handler authenticates with the current password or a verification link
+func passwordPath(path string) bool {
+	name, ok := strings.CutSuffix(strings.TrimPrefix(path, "/members/"), "/password")
+	return ok && strings.HasPrefix(path, "/members/") && name != "" && !strings.Contains(name, "/")
+}
+
+// sessionOnlyMember returns the member signed in with a bearer token on a
+// request sent without an API key, whose changes are limited to their own
+// bookings and account, or ""
+func sessionOnlyMember(r *http.Request) string {
+	if _, ok := requestAPIKey(r); ok {
+		return ""
+	}
+	return sessionMember(r)
+}
+
+// hashAPIKey returns the stored form of an API key
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiKeyValid reports whether a key can still authenticate
+func apiKeyValid(key APIKey) bool {
+	return key.RevokedAt == nil && (key.ExpiresAt == nil || key.ExpiresAt.After(now()))
+}
+
+// scopeAllows reports whether a key's scope covers a request. Requests
+// without a key, given the empty scope, and read-only keys can read anything
+// outside /admin, bookings keys can also manage bookings, holds and the
+// waitlist, and admin keys can do everything.
+func scopeAllows(scope string, r *http.Request) bool {
+	if scope == apiKeyScopeAdmin {
+		return true
+	}
+	if strings.HasPrefix(r.URL.Path, "/admin/") {
+		return false
+	}
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return true
+	}
+	if scope != apiKeyScopeBookings {
+		return false
+	}
+	for _, prefix := range []string{"/bookings", "/holds", "/waitlist", "/check-in"} {
+		if r.URL.Path == prefix || strings.HasPrefix(r.URL.Path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticateAPIKey returns the valid key matching a secret, recording its use
+func authenticateAPIKey(secret string) (APIKey, bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	hash := hashAPIKey(secret)
+	for i, key := range apiKeys {
+		if key.KeyHash != hash || !apiKeyValid(key) {
+			continue
+		}
+		// Only write the file once the stored time is noticeably stale
+		usedAt := now()
+		stale := key.LastUsedAt == nil || usedAt.Sub(*key.LastUsedAt) >= apiKeyLastUsedPrecision
+		apiKeys[i].LastUsedAt = &usedAt
+		if stale {
+			writeDataToJsonFile("api_keys.json", apiKeys)
+		}
+		return apiKeys[i], true
+	}
+	return APIKey{}, false
+}
+
+// requireAPIKey authenticates the X-API-Key header and enforces the key's
+// scope. Requests without a key may sign in, read outside /admin and, once
+// signed in as a member, make the changes sessionAllows; config.RequireAPIKey
+// limits them to signing in. The staff pages also take the key as a basic
+// auth password.
+func requireAPIKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret := r.Header.Get("X-API-Key")
+		// Browsers on the staff pages send the key as the basic auth password
+		staffPage := strings.HasPrefix(r.URL.Path, staffUIPath)
+		if _, password, ok := r.BasicAuth(); secret == "" && staffPage && ok {
+			secret = password
+		}
+		if secret == "" && r.Header.Get(impersonationHeader) != "" {
+			errorResponse(w, http.StatusForbidden, "Impersonation requires an admin API key")
+			return
+		}
+		if secret == "" {
+			allowed := publicPaths[r.URL.Path]
+			if !config.RequireAPIKey && !allowed {
+				member := sessionMember(r)
+				allowed = scopeAllows("", r) || passwordPath(r.URL.Path) || member != "" && sessionAllows(member, r)
+			}
+			if !allowed {
+				if staffPage {
+					w.Header().Set("WWW-Authenticate", `Basic realm="Staff"`)
+				}
+				errorResponse(w, http.StatusUnauthorized, "API key required")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, ok := authenticateAPIKey(secret)
+		if !ok {
+			errorResponse(w, http.StatusUnauthorized, "Invalid or expired API key")
+			return
+		}
+		if !scopeAllows(key.Scope, r) {
+			errorResponse(w, http.StatusForbidden, "API key scope "+key.Scope+" does not allow this request")
+			return
+		}
+		if r.Header.Get(impersonationHeader) != "" && key.Scope != apiKeyScopeAdmin {
+			errorResponse(w, http.StatusForbidden, "Impersonation requires an admin API key")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key)))
+	})
+}
+
+// requestAPIKey returns the key a request was authenticated with, if any
+func requestAPIKey(r *http.Request) (APIKey, bool) {
+	key, ok := r.Context().Value(apiKeyContextKey{}).(APIKey)
+	return key, ok
+}
+
+// issueAPIKey creates a key and returns it with its secret, which is never
+// stored. Callers must hold the mutex.
+func issueAPIKey(name, scope string) (APIKey, string) {
+	raw := make([]byte, 24)
+	rand.Read(raw)
+	secret := "gk_" + base64.RawURLEncoding.EncodeToString(raw)
+
+	key := APIKey{ID: apiKeyId, Name: name, Scope: scope, Prefix: secret[:8], KeyHash: hashAPIKey(secret), CreatedAt: now()}
+	apiKeyId++
+	apiKeys = append(apiKeys, key)
+	return key, secret
+}
+
+// createAdminKey issues and saves an admin key, for setting up a new
+// deployment whose /admin endpoints need a key from the start. It returns
+// the key's secret.
+func createAdminKey(name string) (string, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	_, secret := issueAPIKey(name, apiKeyScopeAdmin)
+	if err := writeDataToJsonFile("api_keys.json", apiKeys); err != nil {
+		apiKeys, apiKeyId = apiKeys[:len(apiKeys)-1], apiKeyId-1
+		return "", err
+	}
+	return secret, nil
+}
+
+// publicAPIKey strips the hash from a key before it is returned
+func publicAPIKey(key APIKey) APIKey {
+	key.KeyHash = ""
+	return key
+}
+
+// apiKeyIndex returns the position of the key with the given ID, or -1.
+// Callers must hold the mutex.
+func apiKeyIndex(id int) int {
+	for i, key := range apiKeys {
+		if key.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Handler for listing and creating API keys
+func apiKeyHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		keys := []APIKey{}
+		for _, key := range apiKeys {
+			keys = append(keys, publicAPIKey(key))
+		}
+		mutex.Unlock()
+		successResponse(w, http.StatusOK, "API keys retrieved successfully", map[string]interface{}{"keys": keys})
+
+	case http.MethodPost:
+		var request struct {
+			Name  string `json:"name"`
+			Scope string `json:"scope"`
+		}
+		if err := decodeBody(r, &request); err != nil || strings.TrimSpace(request.Name) == "" {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if request.Scope != apiKeyScopeReadOnly && request.Scope != apiKeyScopeBookings && request.Scope != apiKeyScopeAdmin {
+			errorResponse(w, http.StatusBadRequest, "Invalid scope, use read-only, bookings or admin")
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		key, secret := issueAPIKey(strings.TrimSpace(request.Name), request.Scope)
+		if err := writeDataToJsonFile("api_keys.json", apiKeys); err != nil {
+			apiKeys = apiKeys[:len(apiKeys)-1]
+			apiKeyId--
+			errorResponse(w, http.StatusInternalServerError, "Failed to save API key data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "create", "api-key", key.ID, nil, publicAPIKey(key))
+
+		// The secret is shown once and cannot be retrieved later
+		successResponse(w, http.StatusCreated, "API key created successfully", map[string]interface{}{"key": publicAPIKey(key), "secret": secret})
+		logData("API key created successfully", publicAPIKey(key))
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// Handler for revoking an API key immediately
+func apiKeyItemHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is DELETE
+	if r.Method != http.MethodDelete {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid API key id")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := apiKeyIndex(id)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "API key not found")
+		return
+	}
+	if apiKeys[index].RevokedAt != nil {
+		errorResponse(w, http.StatusBadRequest, "API key is already revoked")
+		return
+	}
+
+	before := apiKeys[index]
+	revokedAt := now()
+	apiKeys[index].RevokedAt = &revokedAt
+	if err := writeDataToJsonFile("api_keys.json", apiKeys); err != nil {
+		apiKeys[index] = before
+		errorResponse(w, http.StatusInternalServerError, "Failed to save API key data")
+		return
+	}
+	recordAudit(actorFromRequest(r), "revoke", "api-key", id, publicAPIKey(before), publicAPIKey(apiKeys[index]))
+
+	successResponse(w, http.StatusOK, "API key revoked successfully", publicAPIKey(apiKeys[index]))
+	logData("API key revoked successfully", publicAPIKey(apiKeys[index]))
+}
+
+// Handler for replacing an API key, keeping the old one valid for a grace period
+func rotateAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid API key id")
+		return
+	}
+	var request struct {
+		GraceMinutes *int `json:"graceMinutes"` // Defaults to apiKeyGraceMinutes from the config
+	}
+	if r.ContentLength != 0 {
+		if err := decodeBody(r, &request); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+	grace := config.APIKeyGraceMinutes
+	if request.GraceMinutes != nil {
+		grace = *request.GraceMinutes
+	}
+	if grace < 0 {
+		errorResponse(w, http.StatusBadRequest, "graceMinutes cannot be negative")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := apiKeyIndex(id)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "API key not found")
+		return
+	}
+	if !apiKeyValid(apiKeys[index]) || apiKeys[index].ReplacedBy != 0 {
+		errorResponse(w, http.StatusBadRequest, "Only a current API key can be rotated")
+		return
+	}
+
+	before := apiKeys[index]
+	replacement, secret := issueAPIKey(before.Name, before.Scope)
+	expiresAt := now().Add(time.Duration(grace) * time.Minute)
+	apiKeys[index].ExpiresAt = &expiresAt
+	apiKeys[index].ReplacedBy = replacement.ID
+
+	if err := writeDataToJsonFile("api_keys.json", apiKeys); err != nil {
+		apiKeys[index] = before
+		apiKeys = apiKeys[:len(apiKeys)-1]
+		apiKeyId--
+		errorResponse(w, http.StatusInternalServerError, "Failed to save API key data")
+		return
+	}
+	actor := actorFromRequest(r)
+	recordAudit(actor, "rotate", "api-key", id, publicAPIKey(before), publicAPIKey(apiKeys[index]))
+	recordAudit(actor, "create", "api-key", replacement.ID, nil, publicAPIKey(replacement))
+
+	response := map[string]interface{}{
+		"key":      publicAPIKey(replacement),
+		"secret":   secret,
+		"previous": publicAPIKey(apiKeys[index]),
+	}
+	successResponse(w, http.StatusCreated, "API key rotated successfully", response)
+	logData("API key rotated successfully", map[string]interface{}{"key": publicAPIKey(replacement), "previous": publicAPIKey(apiKeys[index])})
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// createTestAPIKey creates a key through the handler and returns its ID and secret
+func createTestAPIKey(t *testing.T, name, scope string) (int, string) {
+	rec := httptest.NewRecorder()
+	apiKeyHandler(rec, httptest.NewRequest(http.MethodPost, "/admin/keys", bytes.NewReader([]byte(`{"name":"`+name+`","scope":"`+scope+`"}`))))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the key to be created, got %d", rec.Code)
+	}
+	var response struct {
+		Data struct {
+			Key    APIKey `json:"key"`
+			Secret string `json:"secret"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&response)
+	return response.Data.Key.ID, response.Data.Secret
+}
+
+// TestAPIKeyScopes verifies each scope only reaches the requests it covers.
+func TestAPIKeyScopes(t *testing.T) {
+	setupTestEnvironment()
+	config.RequireAPIKey = true
+	_, readOnly := createTestAPIKey(t, "dashboard", apiKeyScopeReadOnly)
+	_, bookingsOnly := createTestAPIKey(t, "kiosk", apiKeyScopeBookings)
+	_, admin := createTestAPIKey(t, "ops", apiKeyScopeAdmin)
+
+	var actor string
+	handler := requireAPIKey(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor = actorFromRequest(r)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		key        string
+		statusCode int
+	}{
+		{name: "No Key", method: http.MethodGet, path: "/classes", statusCode: http.StatusUnauthorized},
+		{name: "Sign In Without Key", method: http.MethodPost, path: "/login", statusCode: http.StatusOK},
+		{name: "Unknown Key", method: http.MethodGet, path: "/classes", key: "gk_unknown", statusCode: http.StatusUnauthorized},
+		{name: "Read Only Reads", method: http.MethodGet, path: "/classes", key: readOnly, statusCode: http.StatusOK},
+		{name: "Read Only Books", method: http.MethodPost, path: "/bookings", key: readOnly, statusCode: http.StatusForbidden},
+		{name: "Read Only Admin", method: http.MethodGet, path: "/admin/audit", key: readOnly, statusCode: http.StatusForbidden},
+		{name: "Bookings Books", method: http.MethodPost, path: "/bookings", key: bookingsOnly, statusCode: http.StatusOK},
+		{name: "Bookings Cancels", method: http.MethodPost, path: "/bookings/1/cancel", key: bookingsOnly, statusCode: http.StatusOK},
+		{name: "Bookings Creates Class", method: http.MethodPost, path: "/classes", key: bookingsOnly, statusCode: http.StatusForbidden},
+		{name: "Admin Manages Keys", method: http.MethodPost, path: "/admin/keys", key: admin, statusCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+		})
+	}
+
+	if actor != "api-key:ops" {
+		t.Errorf("expected the key to be recorded as the actor, got %q", actor)
+	}
+	if apiKeys[2].LastUsedAt == nil {
+		t.Errorf("expected the key's last use to be tracked")
+	}
+}
+
+// TestAPIKeyRotation verifies rotated keys keep working until the grace period ends.
+func TestAPIKeyRotation(t *testing.T) {
+	setupTestEnvironment()
+	defer func() { now = time.Now }()
+	_, oldSecret := createTestAPIKey(t, "kiosk", apiKeyScopeBookings)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/keys/1/rotate", bytes.NewReader([]byte(`{"graceMinutes":30}`)))
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+	rotateAPIKeyHandler(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the key to be rotated, got %d", rec.Code)
+	}
+	var response struct {
+		Data struct {
+			Key    APIKey `json:"key"`
+			Secret string `json:"secret"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&response)
+	if response.Data.Key.Scope != apiKeyScopeBookings || response.Data.Secret == oldSecret || response.Data.Key.KeyHash != "" {
+		t.Fatalf("expected a new key with the same scope and no hash, got %+v", response.Data)
+	}
+
+	// Rotating the old key again is refused
+	req = httptest.NewRequest(http.MethodPost, "/admin/keys/1/rotate", nil)
+	req.SetPathValue("id", "1")
+	rec = httptest.NewRecorder()
+	rotateAPIKeyHandler(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected a second rotation to be refused, got %d", rec.Code)
+	}
+
+	if _, ok := authenticateAPIKey(oldSecret); !ok {
+		t.Errorf("expected the old key to work during the grace period")
+	}
+	now = func() time.Time { return time.Now().Add(31 * time.Minute) }
+	if _, ok := authenticateAPIKey(oldSecret); ok {
+		t.Errorf("expected the old key to stop working after the grace period")
+	}
+	if _, ok := authenticateAPIKey(response.Data.Secret); !ok {
+		t.Errorf("expected the new key to work")
+	}
+
+	// Revoking stops a key at once
+	req = httptest.NewRequest(http.MethodDelete, "/admin/keys/2", nil)
+	req.SetPathValue("id", "2")
+	rec = httptest.NewRecorder()
+	apiKeyItemHandler(rec, req)
+	if _, ok := authenticateAPIKey(response.Data.Secret); rec.Code != http.StatusOK || ok {
+		t.Errorf("expected the revoked key to stop working, got %d", rec.Code)
+	}
+}
+
+// TestKeylessRequests verifies requests without a key can read and act as a signed-in member, but never reach /admin or change data anonymously.
+func TestKeylessRequests(t *testing.T) {
+	setupTestEnvironment()
+	token, _, err := startSession("Ann")
+	if err != nil {
+		t.Fatalf("expected a session, got %v", err)
+	}
+	handler := requireAPIKey(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		signedIn   bool
+		statusCode int
+	}{
+		{name: "Reads Classes", method: http.MethodGet, path: "/classes", statusCode: http.StatusOK},
+		{name: "Signs In", method: http.MethodPost, path: "/login", statusCode: http.StatusOK},
+		{name: "Mints Admin Key", method: http.MethodPost, path: "/admin/keys", statusCode: http.StatusUnauthorized},
+		{name: "Reads Admin", method: http.MethodGet, path: "/admin/backup", statusCode: http.StatusUnauthorized},
+		{name: "Restores", method: http.MethodPost, path: "/admin/restore", statusCode: http.StatusUnauthorized},
+		{name: "Books Anonymously", method: http.MethodPost, path: "/bookings", statusCode: http.StatusUnauthorized},
+		{name: "Member Books", method: http.MethodPost, path: "/bookings", signedIn: true, statusCode: http.StatusOK},
+		{name: "Member Mints Admin Key", method: http.MethodPost, path: "/admin/keys", signedIn: true, statusCode: http.StatusUnauthorized},
+		{name: "Member Cancels", method: http.MethodPost, path: "/bookings/1/cancel", signedIn: true, statusCode: http.StatusOK},
+		{name: "Member Refunds", method: http.MethodPost, path: "/bookings/1/refund", signedIn: true, statusCode: http.StatusUnauthorized},
+		{name: "Member Saves Profile", method: http.MethodPut, path: "/members/Ann", signedIn: true, statusCode: http.StatusOK},
+		{name: "Member Saves Preferences", method: http.MethodPut, path: "/members/Ann/preferences", signedIn: true, statusCode: http.StatusOK},
+		{name: "Member Adds Credits", method: http.MethodPost, path: "/members/Ann/credits", signedIn: true, statusCode: http.StatusUnauthorized},
+		{name: "Member Blocks Another", method: http.MethodPut, path: "/members/Bob/block", signedIn: true, statusCode: http.StatusUnauthorized},
+		{name: "Member Changes Another", method: http.MethodPut, path: "/members/Bob", signedIn: true, statusCode: http.StatusUnauthorized},
+		{name: "Sets Password", method: http.MethodPut, path: "/members/Bob/password", statusCode: http.StatusOK},
+		{name: "Member Creates Class", method: http.MethodPost, path: "/classes", signedIn: true, statusCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.signedIn {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+		})
+	}
+
+	secret, err := createAdminKey("bootstrap")
+	if err != nil || apiKeys[0].Scope != apiKeyScopeAdmin {
+		t.Fatalf("expected an admin key to be created, got %v: %+v", err, apiKeys)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/admin/keys", nil)
+	req.Header.Set("X-API-Key", secret)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected the created key to reach /admin, got %d", rec.Code)
+	}
+}
+
+// TestSignedInMemberActsForThemselves verifies members signed in without a key book under their own name and only change their own bookings.
+func TestSignedInMemberActsForThemselves(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10}}
+	classId = 2
+	bookings = []Booking{{ID: 1, MemberName: "Bob", ClassName: "Yoga", Date: testDate("10-12-2099"), Status: bookingStatusConfirmed}}
+	bookingId = 2
+	token, _, err := startSession("Ann")
+	if err != nil {
+		t.Fatalf("expected a session, got %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/bookings", bookingHandler)
+	mux.HandleFunc("/bookings/{id}/{action}", bookingActionHandler)
+	handler := requireAPIKey(mux)
+	signedIn := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := signedIn(http.MethodPost, "/bookings", `{"memberName":"Bob","className":"Yoga","date":"11-12-2099"}`)
+	if rec.Code != http.StatusCreated || bookings[1].MemberName != "Ann" {
+		t.Errorf("expected the booking to be made for the signed-in member, got %d: %+v", rec.Code, bookings)
+	}
+	rec = signedIn(http.MethodPost, "/bookings/1/cancel", "")
+	if rec.Code != http.StatusForbidden || bookingStatus(bookings[0]) != bookingStatusConfirmed {
+		t.Errorf("expected another member's booking to be refused, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = signedIn(http.MethodPost, "/bookings/2/cancel", "")
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected the member to cancel their own booking, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
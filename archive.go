@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// classArchive holds classes that have ended, together with their sessions and bookings
+type classArchive struct {
+	Classes  []Class        `json:"classes"`
+	Sessions []ClassSession `json:"sessions,omitempty"`
+	Bookings []Booking      `json:"bookings"`
+}
+
+// archiveFile receives classes once their endDate has passed
+const archiveFile = "archive.json"
+
+// archived holds the contents of the archive, so reports and histories can
+// still count what was archived
+var archived classArchive
+
+// allBookings returns the archived bookings followed by the live ones, for
+// reads covering the past. Callers must hold the mutex.
+func allBookings() []Booking {
+	return append(append([]Booking{}, archived.Bookings...), bookings...)
+}
+
+// allClasses returns the archived classes followed by the live ones.
+// Callers must hold the mutex.
+func allClasses() []Class {
+	return append(append([]Class{}, archived.Classes...), classes...)
+}
+
+// findAnyBooking returns a booking, live or archived, by ID.
+// Callers must hold the mutex.
+func findAnyBooking(id int) (Booking, bool) {
+	if index := bookingIndex(id); index >= 0 {
+		return bookings[index], true
+	}
+	for _, booking := range archived.Bookings {
+		if booking.ID == id {
+			return booking, true
+		}
+	}
+	return Booking{}, false
+}
+
+// archivedSessionAt returns the archived session of a class on a date.
+// Callers must hold the mutex.
+func archivedSessionAt(classID int, date Date) (ClassSession, bool) {
+	for _, session := range archived.Sessions {
+		if session.ClassID == classID && session.Date == date {
+			return session, true
+		}
+	}
+	return ClassSession{}, false
+}
+
+// awaitingRefund reports whether a booking was cancelled after being paid for
+// and has not been refunded yet. Such bookings stay live when their class is
+// archived, so they can still be refunded.
+func awaitingRefund(booking Booking) bool {
+	paid := booking.Price > 0 || booking.CreditsUsed > 0 || booking.PointsUsed > 0
+	return paid && booking.RefundID == 0 && bookingStatus(booking) == bookingStatusCancelled
+}
+
+// classCovers reports whether a booking falls on one of the class's dates
+func classCovers(class Class, booking Booking) bool {
+	if class.ClassName != booking.ClassName {
+		return false
+	}
+	return !booking.Date.Before(class.StartDate.Time) && !booking.Date.After(class.EndDate.Time)
+}
+
+// archivePastClasses moves classes whose endDate has passed, and their
+// bookings, out of the live data files and into the archive.
+// It returns the number of classes and bookings archived.
+func archivePastClasses() (int, int, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	day := today()
+
+	// Soft-deleted classes are left for the retention cleanup so they stay restorable
+	var keptClasses, archivedClasses []Class
+	for _, class := range classes {
+		if class.DeletedAt == nil && class.EndDate.Before(day) {
+			archivedClasses = append(archivedClasses, class)
+		} else {
+			keptClasses = append(keptClasses, class)
+		}
+	}
+	if len(archivedClasses) == 0 {
+		return 0, 0, nil
+	}
+
+	var keptBookings, archivedBookings []Booking
+	for _, booking := range bookings {
+		moved := false
+		for _, class := range archivedClasses {
+			if classCovers(class, booking) && !awaitingRefund(booking) {
+				moved = true
+				break
+			}
+		}
+		if moved {
+			archivedBookings = append(archivedBookings, booking)
+		} else {
+			keptBookings = append(keptBookings, booking)
+		}
+	}
+
+	keptSessions, archivedSessions := splitSessions(archivedClasses)
+
+	// Write the archive before removing the records from the live files. A run
+	// that stopped before trimming them left records in both, which are not
+	// archived twice.
+	archive := archived
+	archive.Classes = appendUnarchived(archived.Classes, archivedClasses, func(class Class) int { return class.ID })
+	archive.Sessions = appendUnarchived(archived.Sessions, archivedSessions, func(session ClassSession) int { return session.ID })
+	archive.Bookings = appendUnarchived(archived.Bookings, archivedBookings, func(booking Booking) int { return booking.ID })
+	if err := writeDataToJsonFile(archiveFile, archive); err != nil {
+		return 0, 0, err
+	}
+
+	if keptClasses == nil {
+		keptClasses = []Class{}
+	}
+	if keptBookings == nil {
+		keptBookings = []Booking{}
+	}
+	for _, file := range []struct {
+		name string
+		data interface{}
+	}{{"classes.json", keptClasses}, {"class_sessions.json", keptSessions}, {"bookings.json", keptBookings}} {
+		if err := writeDataToJsonFile(file.name, file.data); err != nil {
+			// Put back the files already written, so the next run starts over
+			writeDataToJsonFile("classes.json", classes)
+			writeDataToJsonFile("class_sessions.json", classSessions)
+			writeDataToJsonFile(archiveFile, archived)
+			return 0, 0, err
+		}
+	}
+	archived = archive
+	classes, classSessions, bookings = keptClasses, keptSessions, keptBookings
+
+	counts := map[string]int{"classes": len(archivedClasses), "bookings": len(archivedBookings)}
+	recordAudit("system", "archive", "class", 0, nil, counts)
+	logData("Archival completed", counts)
+	return len(archivedClasses), len(archivedBookings), nil
+}
+
+// appendUnarchived appends the moved records whose IDs are not archived yet
+func appendUnarchived[T any](archived, moved []T, idOf func(T) int) []T {
+	ids := map[int]bool{}
+	for _, record := range archived {
+		ids[idOf(record)] = true
+	}
+	result := append([]T{}, archived...)
+	for _, record := range moved {
+		if !ids[idOf(record)] {
+			result = append(result, record)
+		}
+	}
+	return result
+}
+
+// runArchival is the scheduled entry point for archivePastClasses
+func runArchival() {
+	if _, _, err := archivePastClasses(); err != nil {
+		fmt.Println("Error archiving past classes:", err)
+	}
+}
+
+// Handler for browsing archived classes or bookings
+func archiveHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	page, err := paginationParams(r)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	mutex.Lock()
+	archive := classArchive{Classes: append([]Class{}, archived.Classes...), Bookings: append([]Booking{}, archived.Bookings...)}
+	mutex.Unlock()
+
+	response := map[string]interface{}{
+		"limit":  page.Limit,
+		"offset": page.Offset,
+	}
+	switch kind := r.URL.Query().Get("type"); kind {
+	case "", "classes":
+		// Classes are archived as they end, so restore ID order for paging
+		sort.Slice(archive.Classes, func(i, j int) bool { return archive.Classes[i].ID < archive.Classes[j].ID })
+		response["classes"], response["nextCursor"] = paginate(archive.Classes, page, classCursor, false)
+		response["total"] = len(archive.Classes)
+	case "bookings":
+		// Narrow the bookings down to one class when asked
+		className := r.URL.Query().Get("className")
+		matched := []Booking{}
+		for _, booking := range archive.Bookings {
+			if className == "" || booking.ClassName == className {
+				matched = append(matched, booking)
+			}
+		}
+		sort.Slice(matched, func(i, j int) bool { return matched[i].ID < matched[j].ID })
+		response["bookings"], response["nextCursor"] = paginate(matched, page, func(booking Booking) pageCursor {
+			return pageCursor{ID: booking.ID}
+		}, false)
+		response["total"] = len(matched)
+	default:
+		errorResponse(w, http.StatusBadRequest, "Invalid type, use classes or bookings")
+		return
+	}
+	successResponse(w, http.StatusOK, "Archive retrieved successfully", response)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestArchivePastClasses verifies ended classes and their bookings are moved to the archive.
+func TestArchivePastClasses(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2024, 12, 16, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	deletedAt := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	classes = []Class{
+		{ID: 1, ClassName: "Pilates", StartDate: testDate("01-11-2024"), EndDate: testDate("30-11-2024"), Capacity: 10},
+		{ID: 2, ClassName: "Pilates", StartDate: testDate("01-12-2024"), EndDate: testDate("31-12-2024"), Capacity: 10},
+		{ID: 3, ClassName: "Yoga", StartDate: testDate("01-11-2024"), EndDate: testDate("15-12-2024"), Capacity: 10, DeletedAt: &deletedAt},
+	}
+	bookings = []Booking{
+		{ID: 1, MemberName: "A", Date: testDate("15-11-2024"), ClassName: "Pilates"},
+		{ID: 2, MemberName: "B", Date: testDate("16-12-2024"), ClassName: "Pilates"},
+		{ID: 3, MemberName: "C", Date: testDate("15-11-2024"), ClassName: "Yoga"},
+	}
+
+	archivedClasses, archivedBookings, err := archivePastClasses()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if archivedClasses != 1 || archivedBookings != 1 {
+		t.Errorf("expected 1 class and 1 booking archived, got %d and %d", archivedClasses, archivedBookings)
+	}
+	if len(classes) != 2 || classIndex(1) >= 0 {
+		t.Errorf("expected class 1 to be archived, got %+v", classes)
+	}
+	if len(bookings) != 2 || bookingIndex(1) >= 0 {
+		t.Errorf("expected booking 1 to be archived, got %+v", bookings)
+	}
+
+	// IDs of archived records are not handed out again after a reload.
+	if err := loadData(); err != nil {
+		t.Fatalf("unexpected error reloading data: %v", err)
+	}
+	if classId != 4 || bookingId != 4 {
+		t.Errorf("expected counters past the archived IDs, got %d and %d", classId, bookingId)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		statusCode int
+		key        string
+		total      int
+	}{
+		{name: "Classes By Default", query: "", statusCode: http.StatusOK, key: "classes", total: 1},
+		{name: "Bookings", query: "?type=bookings", statusCode: http.StatusOK, key: "bookings", total: 1},
+		{name: "Bookings For Another Class", query: "?type=bookings&className=Yoga", statusCode: http.StatusOK, key: "bookings", total: 0},
+		{name: "Unknown Type", query: "?type=members", statusCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			archiveHandler(rec, httptest.NewRequest(http.MethodGet, "/archive"+tt.query, nil))
+
+			if rec.Code != tt.statusCode {
+				t.Fatalf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+			if tt.statusCode != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Data map[string]json.RawMessage `json:"data"`
+			}
+			json.NewDecoder(rec.Body).Decode(&response)
+			var total int
+			json.Unmarshal(response.Data["total"], &total)
+			if _, ok := response.Data[tt.key]; !ok || total != tt.total {
+				t.Errorf("expected %d %s, got %s", tt.total, tt.key, rec.Body.String())
+			}
+		})
+	}
+}
+
+// TestArchivedBookingsStayCounted verifies reports, histories and receipts still include archived bookings, and unrefunded cancellations stay live.
+func TestArchivedBookingsStayCounted(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2024, 12, 16, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	classes = []Class{{ID: 1, ClassName: "Pilates", StartDate: testDate("01-11-2024"), EndDate: testDate("30-11-2024"), Capacity: 10, Instructor: "Kim"}}
+	bookings = []Booking{
+		{ID: 1, MemberName: "Ann", Date: testDate("15-11-2024"), ClassName: "Pilates", Status: bookingStatusAttended, Price: 1000, Total: 1000, Currency: "EUR"},
+		{ID: 2, MemberName: "Ben", Date: testDate("15-11-2024"), ClassName: "Pilates", Status: bookingStatusCancelled, Price: 1000, Total: 1000, Currency: "EUR"},
+	}
+	if _, archivedBookings, err := archivePastClasses(); err != nil || archivedBookings != 1 {
+		t.Fatalf("expected one booking archived, got %d: %v", archivedBookings, err)
+	}
+	if len(bookings) != 1 || bookings[0].ID != 2 {
+		t.Errorf("expected the unrefunded cancellation to stay live, got %+v", bookings)
+	}
+
+	rec := httptest.NewRecorder()
+	revenueStatsHandler(rec, httptest.NewRequest(http.MethodGet, "/stats/revenue?from=01-11-2024&to=30-11-2024", nil))
+	var revenue struct {
+		Data struct {
+			Totals []revenueGroup `json:"totals"`
+		} `json:"data"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &revenue)
+	if len(revenue.Data.Totals) != 1 || revenue.Data.Totals[0].Gross != 2000 {
+		t.Errorf("expected the archived and live bookings' revenue, got %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	instructorStatsHandler(rec, httptest.NewRequest(http.MethodGet, "/stats/instructors?from=01-11-2024&to=30-11-2024", nil))
+	if !strings.Contains(rec.Body.String(), `"instructor":"Kim","sessions":30`) {
+		t.Errorf("expected the archived class's sessions, got %s", rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/members/Ann/bookings", nil)
+	req.SetPathValue("name", "Ann")
+	rec = httptest.NewRecorder()
+	memberBookingsHandler(rec, req)
+	if !strings.Contains(rec.Body.String(), `"id":1`) {
+		t.Errorf("expected the archived booking in the history, got %s", rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/bookings/1/receipt", nil)
+	req.SetPathValue("id", "1")
+	rec = httptest.NewRecorder()
+	bookingReceiptHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected a receipt for the archived booking, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestArchiveInterrupted verifies a run that fails or stops before trimming the live files does not archive records twice.
+func TestArchiveInterrupted(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2024, 12, 16, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	classes = []Class{{ID: 1, ClassName: "Pilates", StartDate: testDate("01-11-2024"), EndDate: testDate("30-11-2024"), Capacity: 10}}
+	bookings = []Booking{{ID: 1, MemberName: "A", Date: testDate("15-11-2024"), ClassName: "Pilates"}}
+	writeDataToJsonFile("classes.json", classes)
+	writeDataToJsonFile("bookings.json", bookings)
+
+	// A directory in its place makes the bookings file impossible to write
+	os.Remove("bookings.json")
+	os.Mkdir("bookings.json", 0755)
+	_, _, err := archivePastClasses()
+	os.Remove("bookings.json")
+	writeDataToJsonFile("bookings.json", bookings)
+	if err == nil {
+		t.Fatal("expected the failed trim to be reported")
+	}
+	if len(classes) != 1 || len(bookings) != 1 || len(archived.Classes) != 0 {
+		t.Fatalf("expected nothing to be archived, got %+v and %+v", classes, archived)
+	}
+	var stored classArchive
+	if err := dataFromJsonFile(archiveFile, &stored); err != nil || len(stored.Classes) != 0 {
+		t.Fatalf("expected the archive file to be rolled back, got %v: %+v", err, stored)
+	}
+	var storedClasses []Class
+	if err := dataFromJsonFile("classes.json", &storedClasses); err != nil || len(storedClasses) != 1 {
+		t.Fatalf("expected the classes file to be put back, got %v: %+v", err, storedClasses)
+	}
+
+	// A run that stopped after writing the archive left the records in both
+	archived = classArchive{Classes: classes, Bookings: bookings}
+	if archivedClasses, archivedBookings, err := archivePastClasses(); err != nil || archivedClasses != 1 || archivedBookings != 1 {
+		t.Fatalf("expected the class and booking to leave the live data, got %d, %d, %v", archivedClasses, archivedBookings, err)
+	}
+	if len(archived.Classes) != 1 || len(archived.Bookings) != 1 || len(classes) != 0 || len(bookings) != 0 {
+		t.Errorf("expected one archived copy of each, got %+v", archived)
+	}
+}
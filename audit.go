@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// AuditEntry records a single mutating operation
+type AuditEntry struct {
+	ID          int         `json:"id"`
+	Time        time.Time   `json:"time"`
+	Actor       string      `json:"actor"`
+	OnBehalfOf  string      `json:"onBehalfOf,omitempty"` // Member an admin impersonated
+	Action      string      `json:"action"`
+	Entity      string      `json:"entity"`
+	EntityID    int         `json:"entityId"`
+	Before      interface{} `json:"before,omitempty"`
+	After       interface{} `json:"after,omitempty"`
+	Unpublished bool        `json:"unpublished,omitempty"` // Its outbox records are not known to be saved, so they are queued again on load
+}
+
+var (
+	auditEntries []AuditEntry // Temp Slice to hold audit entries
+	auditId      = 1          // Incremental ID for audit entries
+)
+
+// actorFromRequest identifies who is performing a request. An impersonating
+// admin is reported as "X on behalf of Y".
+func actorFromRequest(r *http.Request) string {
+	if member := impersonatedMember(r); member != "" {
+		return staffActor(r) + onBehalfOf + escapeActor(member)
+	}
+	// A signed-in member acts as themselves
+	if member := sessionMember(r); member != "" {
+		return escapeActor(member)
+	}
+	return staffActor(r)
+}
+
+// staffActor identifies the staff member or client behind a request. Requests
+// with an API key are attributed to the key, as the X-Actor header is not
+// authenticated and is only trusted when keys are not required.
+func staffActor(r *http.Request) string {
+	if key, ok := requestAPIKey(r); ok {
+		return "api-key:" + escapeActor(key.Name)
+	}
+	if actor := r.Header.Get("X-Actor"); actor != "" {
+		return escapeActor(actor)
+	}
+	return "anonymous"
+}
+
+// recordAudit appends an audit entry, persists the audit store and queues the
+// entry's domain event, returning the entry's ID. Changes that publish an event
+// record it before saving their own data, so replayEvents can finish the change
+// if the process stops in between; retractAudit withdraws it if the save fails.
+// Callers must hold the mutex.
+func recordAudit(actor, action, entity string, entityID int, before, after interface{}) int {
+	// Impersonation is kept apart so the admin stays accountable
+	actor, member := splitActor(actor)
+	_, hasEvent := domainEvents[entity+"."+action]
+	auditEntries = append(auditEntries, AuditEntry{
+		ID:         auditId,
+		Time:       now(),
+		Actor:      actor,
+		OnBehalfOf: member,
+		Action:     action,
+		Entity:     entity,
+		EntityID:   entityID,
+		Before:     before,
+		After:      after,
+		// The entry is saved before its event, so it counts as unpublished
+		// until the outbox has the event
+		Unpublished: hasEvent,
+	})
+	auditId++
+
+	// A failed audit write must not undo the operation it describes
+	if err := writeDataToJsonFile("audit.json", auditEntries); err != nil {
+		fmt.Println("Error saving audit log:", err)
+	}
+	if hasEvent && publishEvent(auditEntries[len(auditEntries)-1]) == nil {
+		auditEntries[len(auditEntries)-1].Unpublished = false
+	}
+	return auditEntries[len(auditEntries)-1].ID
+}
+
+// retractAudit withdraws the audit entries from the given ID on, along with
+// their events, after the change they describe failed to save.
+// Callers must hold the mutex.
+func retractAudit(from int) {
+	kept := []AuditEntry{}
+	for _, entry := range auditEntries {
+		if entry.ID < from {
+			kept = append(kept, entry)
+		}
+	}
+	auditEntries = kept
+	auditId = from
+	if err := writeDataToJsonFile("audit.json", auditEntries); err != nil {
+		fmt.Println("Error saving audit log:", err)
+	}
+
+	// The dispatcher needs the mutex, so none of the records went out yet
+	records := []OutboxRecord{}
+	for _, record := range outbox {
+		if record.EventID < from {
+			records = append(records, record)
+		} else {
+			outboxId = min(outboxId, record.ID)
+		}
+	}
+	if len(records) == len(outbox) {
+		return
+	}
+	outbox = records
+	outboxEventMark = min(outboxEventMark, from-1)
+	if err := writeDataToJsonFile("outbox.json", outbox); err != nil {
+		fmt.Println("Error saving outbox:", err)
+	}
+}
+
+// Handler for querying the audit log
+func auditHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	query := r.URL.Query()
+	entity := query.Get("entity")
+	actor := query.Get("actor")
+	member := query.Get("onBehalfOf")
+
+	// from and to are inclusive DD-MM-YYYY dates
+	var from, to time.Time
+	if value := query.Get("from"); value != "" {
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid from format, use DD-MM-YYYY")
+			return
+		}
+		from = date
+	}
+	if value := query.Get("to"); value != "" {
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid to format, use DD-MM-YYYY")
+			return
+		}
+		to = date.AddDate(0, 0, 1)
+	}
+
+	page, err := paginationParams(r)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	mutex.Lock()
+	// Newest entries first
+	matches := []AuditEntry{}
+	for i := len(auditEntries) - 1; i >= 0; i-- {
+		entry := auditEntries[i]
+		if entity != "" && entry.Entity != entity {
+			continue
+		}
+		if actor != "" && entry.Actor != actor {
+			continue
+		}
+		if member != "" && entry.OnBehalfOf != member {
+			continue
+		}
+		if !from.IsZero() && entry.Time.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !entry.Time.Before(to) {
+			continue
+		}
+		matches = append(matches, entry)
+	}
+	mutex.Unlock()
+
+	items, nextCursor := paginate(matches, page, func(entry AuditEntry) pageCursor {
+		return pageCursor{ID: entry.ID}
+	}, true)
+	response := map[string]interface{}{
+		"entries":    items,
+		"total":      len(matches),
+		"limit":      page.Limit,
+		"offset":     page.Offset,
+		"nextCursor": nextCursor,
+	}
+	successResponse(w, http.StatusOK, "Audit log retrieved successfully", response)
+}
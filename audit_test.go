@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestAuditHandler verifies that mutations are audited and can be filtered.
+func TestAuditHandler(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2024, 12, 16, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	// Create a class as an instructor and book it as a member.
+	body, _ := json.Marshal(Class{ClassName: "Pilates", StartDate: testDate("15-12-2024"), EndDate: testDate("20-12-2024"), Capacity: 10})
+	req := httptest.NewRequest(http.MethodPost, "/classes", bytes.NewReader(body))
+	req.Header.Set("X-Actor", "instructor")
+	classHandler(httptest.NewRecorder(), req)
+
+	body, _ = json.Marshal(Booking{MemberName: "John Doe", Date: testDate("16-12-2024"), ClassName: "Pilates"})
+	req = httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader(body))
+	req.Header.Set("X-Actor", "John Doe")
+	bookingHandler(httptest.NewRecorder(), req)
+
+	tests := []struct {
+		name       string
+		query      string
+		statusCode int
+		wantIDs    []int
+	}{
+		{name: "All Entries Newest First", query: "", statusCode: http.StatusOK, wantIDs: []int{2, 1}},
+		{name: "Filter By Entity", query: "?entity=class", statusCode: http.StatusOK, wantIDs: []int{1}},
+		{name: "Filter By Actor", query: "?actor=John%20Doe", statusCode: http.StatusOK, wantIDs: []int{2}},
+		{name: "Filter By Time Range", query: "?from=16-12-2024&to=16-12-2024", statusCode: http.StatusOK, wantIDs: []int{2, 1}},
+		{name: "Time Range Excludes Entries", query: "?from=17-12-2024", statusCode: http.StatusOK, wantIDs: []int{}},
+		{name: "Invalid From", query: "?from=2024-12-16", statusCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/audit"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			auditHandler(rec, req)
+
+			if rec.Code != tt.statusCode {
+				t.Fatalf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+			if tt.statusCode != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Data struct {
+					Entries []AuditEntry `json:"entries"`
+				} `json:"data"`
+			}
+			json.NewDecoder(rec.Body).Decode(&response)
+
+			if len(response.Data.Entries) != len(tt.wantIDs) {
+				t.Fatalf("expected %d entries, got %d", len(tt.wantIDs), len(response.Data.Entries))
+			}
+			for i, id := range tt.wantIDs {
+				if response.Data.Entries[i].ID != id {
+					t.Errorf("expected entry %d at position %d, got %d", id, i, response.Data.Entries[i].ID)
+				}
+			}
+		})
+	}
+
+	if auditEntries[1].After == nil || auditEntries[1].Before != nil {
+		t.Errorf("expected a create entry with only an after state, got %+v", auditEntries[1])
+	}
+}
+
+// TestStaffActor verifies requests with a key are attributed to the key and
+// that the header cannot fake an impersonation.
+func TestStaffActor(t *testing.T) {
+	setupTestEnvironment()
+	_, secret := createTestAPIKey(t, "front-desk", apiKeyScopeAdmin)
+	var actor string
+	handler := requireAPIKey(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor = actorFromRequest(r)
+	}))
+
+	tests := []struct {
+		name   string
+		key    string
+		header string
+		actor  string
+	}{
+		{name: "Header Without Key", header: "Sam", actor: "Sam"},
+		{name: "Key Overrides Header", key: secret, header: "Sam", actor: "api-key:front-desk"},
+		{name: "Forged Impersonation", header: "Sam on behalf of Ann", actor: "Sam (on behalf of) Ann"},
+		{name: "Nobody", actor: "anonymous"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/bookings", nil)
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			if tt.header != "" {
+				req.Header.Set("X-Actor", tt.header)
+			}
+			actor = actorFromRequest(req)
+			if tt.key != "" {
+				handler.ServeHTTP(httptest.NewRecorder(), req)
+			}
+			if actor != tt.actor {
+				t.Errorf("expected actor %q, got %q", tt.actor, actor)
+			}
+			if _, member := splitActor(actor); member != "" {
+				t.Errorf("expected no impersonated member, got %q", member)
+			}
+		})
+	}
+}
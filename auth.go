@@ -0,0 +1,471 @@
+package main
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credential holds a member's hashed password
+type Credential struct {
+	MemberName   string    `json:"memberName"`
+	PasswordHash string    `json:"passwordHash"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// Session is a signed-in member. Only a hash of the token is stored.
+type Session struct {
+	TokenHash  string    `json:"tokenHash"`
+	MemberName string    `json:"memberName"`
+	CreatedAt  time.Time `json:"createdAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// minPasswordLength is the shortest password accepted
+const minPasswordLength = 8
+
+// passwordIterations is the PBKDF2-SHA256 work factor for new password hashes
+var passwordIterations = 600000
+
+// tokenPurposeReset marks tokens that reset a member's password
+const tokenPurposeReset = "reset"
+
+var (
+	credentials  []Credential // Temp Slice to hold member credentials
+	sessions     []Session    // Temp Slice to hold member sessions
+	sessionMutex sync.Mutex   // Guards sessions, which are read while the main mutex is held
+)
+
+// hashPassword derives a salted PBKDF2 hash, recording the parameters used
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	rand.Read(salt)
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, 32)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", passwordIterations, base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// checkPassword reports whether a password matches a hash from hashPassword
+func checkPassword(hash, password string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	salt, saltErr := base64.RawStdEncoding.DecodeString(parts[2])
+	want, wantErr := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || saltErr != nil || wantErr != nil {
+		return false
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	return err == nil && subtle.ConstantTimeCompare(key, want) == 1
+}
+
+// credentialIndex returns the position of a member's credential, or -1.
+// Callers must hold the mutex.
+func credentialIndex(memberName string) int {
+	for i, credential := range credentials {
+		if credential.MemberName == memberName {
+			return i
+		}
+	}
+	return -1
+}
+
+// passwordFingerprint identifies a password hash without revealing it, so reset
+// tokens stop working once the password they were issued for changes
+func passwordFingerprint(hash string) string {
+	sum := sha256.Sum256([]byte(hash))
+	return hex.EncodeToString(sum[:8])
+}
+
+// hashSessionToken returns the stored form of a session token
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// bearerToken returns the token in a request's Authorization header
+func bearerToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// sessionMember returns the member signed in on a request, or "".
+// Safe to call with the main mutex held.
+func sessionMember(r *http.Request) string {
+	token := bearerToken(r)
+	if token == "" {
+		return ""
+	}
+	tokenHash := hashSessionToken(token)
+
+	sessionMutex.Lock()
+	defer sessionMutex.Unlock()
+	for _, session := range sessions {
+		if subtle.ConstantTimeCompare([]byte(session.TokenHash), []byte(tokenHash)) == 1 && session.ExpiresAt.After(now()) {
+			return session.MemberName
+		}
+	}
+	return ""
+}
+
+// startSession signs a member in and returns the new session's token
+func startSession(memberName string) (string, Session, error) {
+	raw := make([]byte, 32)
+	rand.Read(raw)
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	session := Session{
+		TokenHash:  hashSessionToken(token),
+		MemberName: memberName,
+		CreatedAt:  now(),
+		ExpiresAt:  now().Add(time.Duration(config.SessionHours) * time.Hour),
+	}
+
+	sessionMutex.Lock()
+	defer sessionMutex.Unlock()
+
+	// Drop expired sessions while the file is being rewritten anyway
+	kept := []Session{}
+	for _, existing := range sessions {
+		if existing.ExpiresAt.After(now()) {
+			kept = append(kept, existing)
+		}
+	}
+	kept = append(kept, session)
+	if err := writeDataToJsonFile("sessions.json", kept); err != nil {
+		return "", Session{}, err
+	}
+	sessions = kept
+	return token, session, nil
+}
+
+// endSessions signs a member out everywhere, or only of the session with the
+// given token hash when one is given
+func endSessions(memberName, tokenHash string) error {
+	sessionMutex.Lock()
+	defer sessionMutex.Unlock()
+
+	kept := []Session{}
+	for _, session := range sessions {
+		if session.MemberName != memberName || (tokenHash != "" && session.TokenHash != tokenHash) {
+			kept = append(kept, session)
+		}
+	}
+	if len(kept) == len(sessions) {
+		return nil
+	}
+	if err := writeDataToJsonFile("sessions.json", kept); err != nil {
+		return err
+	}
+	sessions = kept
+	return nil
+}
+
+// setPassword stores a new password hash from hashPassword for a member,
+// signing them out of existing sessions when it replaces an old one. Hashing
+// is slow, so callers hash before taking the mutex. Callers must hold the mutex.
+func setPassword(memberName, hash string) error {
+	credential := Credential{MemberName: memberName, PasswordHash: hash, UpdatedAt: now()}
+	var before Credential
+	index := credentialIndex(memberName)
+	if index < 0 {
+		credentials = append(credentials, credential)
+	} else {
+		before = credentials[index]
+		credentials[index] = credential
+	}
+	if err := writeDataToJsonFile("credentials.json", credentials); err != nil {
+		if index < 0 {
+			credentials = credentials[:len(credentials)-1]
+		} else {
+			credentials[index] = before
+		}
+		return err
+	}
+	if index >= 0 {
+		if err := endSessions(memberName, ""); err != nil {
+			fmt.Println("Error ending sessions:", err)
+		}
+	}
+	return nil
+}
+
+// mayRegisterPassword reports whether a request may set a member's first
+// password: from the member's own session, with an admin key, or with the
+// token of the member's verification email. Callers must hold the mutex.
+func mayRegisterPassword(r *http.Request, memberName, token string) bool {
+	if sessionMember(r) == memberName {
+		return true
+	}
+	if key, ok := requestAPIKey(r); ok && key.Scope == apiKeyScopeAdmin {
+		return true
+	}
+	claims, err := verifyToken(token, tokenPurposeVerify)
+	if err != nil || claims.Subject != memberName {
+		return false
+	}
+	index := memberIndex(memberName)
+	return index >= 0 && members[index].Email == claims.Data["email"]
+}
+
+// Handler for setting or changing a member's password
+func memberPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is PUT
+	if r.Method != http.MethodPut {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	memberName := r.PathValue("name")
+	var request struct {
+		Password        string `json:"password"`
+		CurrentPassword string `json:"currentPassword"`
+		Token           string `json:"token"` // From the member's verification email, for a first password
+	}
+	if err := decodeBody(r, &request); err != nil || memberName == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(request.Password) < minPasswordLength {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Password must be at least %d characters", minPasswordLength))
+		return
+	}
+	// Hashing is slow, so it is done without holding the mutex
+	hash, err := hashPassword(request.Password)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to save credentials")
+		return
+	}
+	mutex.Lock()
+	current := ""
+	if index := credentialIndex(memberName); index >= 0 {
+		current = credentials[index].PasswordHash
+	}
+	mutex.Unlock()
+	if current != "" && !checkPassword(current, request.CurrentPassword) {
+		errorResponse(w, http.StatusUnauthorized, "Current password is incorrect")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// Members register by setting their first password; changing it needs the
+	// current one, checked above against the password still stored
+	index := credentialIndex(memberName)
+	if index < 0 && !mayRegisterPassword(r, memberName, request.Token) {
+		errorResponse(w, http.StatusForbidden, "Setting a first password needs the member's session, an admin key or their verification link")
+		return
+	}
+	if index >= 0 && credentials[index].PasswordHash != current {
+		errorResponse(w, http.StatusUnauthorized, "Current password is incorrect")
+		return
+	}
+	if err := setPassword(memberName, hash); err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to save credentials")
+		return
+	}
+	recordAudit(actorFromRequest(r), "password", "member", 0, nil, nil)
+
+	successResponse(w, http.StatusOK, "Password saved successfully", nil)
+	logData("Password saved successfully", map[string]string{"memberName": memberName})
+}
+
+// Handler for signing a member in with their password
+func loginHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	var request struct {
+		MemberName string `json:"memberName"`
+		Password   string `json:"password"`
+	}
+	if err := decodeBody(r, &request); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// The hash is compared without holding the mutex, as hashing is slow
+	mutex.Lock()
+	stored := ""
+	if index := credentialIndex(request.MemberName); index >= 0 {
+		stored = credentials[index].PasswordHash
+	}
+	mutex.Unlock()
+	valid := stored != "" && checkPassword(stored, request.Password)
+	// The same answer for unknown members and wrong passwords
+	if !valid {
+		errorResponse(w, http.StatusUnauthorized, "Invalid member name or password")
+		return
+	}
+
+	token, session, err := startSession(request.MemberName)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to save session data")
+		return
+	}
+
+	successResponse(w, http.StatusOK, "Signed in successfully", map[string]interface{}{
+		"token":      token,
+		"memberName": session.MemberName,
+		"expiresAt":  session.ExpiresAt,
+	})
+	logData("Signed in successfully", map[string]string{"memberName": session.MemberName})
+}
+
+// Handler for signing out of the session making the request
+func logoutHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	memberName := sessionMember(r)
+	if memberName == "" {
+		errorResponse(w, http.StatusUnauthorized, "Not signed in")
+		return
+	}
+	if err := endSessions(memberName, hashSessionToken(bearerToken(r))); err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to save session data")
+		return
+	}
+
+	successResponse(w, http.StatusOK, "Signed out successfully", nil)
+	logData("Signed out successfully", map[string]string{"memberName": memberName})
+}
+
+// Handler for requesting a password reset link by email
+func passwordResetHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	var request struct {
+		MemberName string `json:"memberName"`
+	}
+	if err := decodeBody(r, &request); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	mutex.Lock()
+	credential, profile := credentialIndex(request.MemberName), memberIndex(request.MemberName)
+	var member Member
+	var fingerprint string
+	if credential >= 0 && profile >= 0 && members[profile].Email != "" {
+		member, fingerprint = members[profile], passwordFingerprint(credentials[credential].PasswordHash)
+	}
+	mutex.Unlock()
+
+	// Only members with a password and an email get a link, but every caller
+	// gets the same answer so member names cannot be probed
+	if fingerprint != "" && notificationAllowed(member, notifyAccount, channelEmail, now()) {
+		token := signToken(tokenPurposeReset, member.Name, map[string]string{"pw": fingerprint}, time.Duration(config.ResetTokenMinutes)*time.Minute)
+		link := config.PublicBaseURL + "/password-reset/confirm?token=" + url.QueryEscape(token)
+		if err := sendEmail(member.Email, "Reset your password", "Choose a new password by opening "+link); err != nil {
+			fmt.Println("Error sending password reset email:", err)
+		}
+	}
+	successResponse(w, http.StatusAccepted, "If the member has an email address, a reset link has been sent", nil)
+}
+
+// Handler for choosing a new password with an emailed reset token
+func passwordResetConfirmHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	var request struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := decodeBody(r, &request); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	// The emailed link carries the token in its query string
+	if request.Token == "" {
+		request.Token = r.URL.Query().Get("token")
+	}
+	if len(request.Password) < minPasswordLength {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Password must be at least %d characters", minPasswordLength))
+		return
+	}
+	claims, err := verifyToken(request.Token, tokenPurposeReset)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid or expired reset link")
+		return
+	}
+	// Hashing is slow, so it is done without holding the mutex
+	hash, err := hashPassword(request.Password)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to save credentials")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// A token works once: the new password changes the fingerprint
+	index := credentialIndex(claims.Subject)
+	if index < 0 || passwordFingerprint(credentials[index].PasswordHash) != claims.Data["pw"] {
+		errorResponse(w, http.StatusBadRequest, "Invalid or expired reset link")
+		return
+	}
+	if err := setPassword(claims.Subject, hash); err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to save credentials")
+		return
+	}
+	recordAudit(claims.Subject, "password-reset", "member", 0, nil, nil)
+
+	successResponse(w, http.StatusOK, "Password reset successfully", nil)
+	logData("Password reset successfully", map[string]string{"memberName": claims.Subject})
+}
+
+// Handler for the signed-in member's own profile
+func meHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	memberName := requestMember(r)
+	if memberName == "" {
+		errorResponse(w, http.StatusUnauthorized, "Not signed in")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	profile := Member{Name: memberName}
+	if index := memberIndex(memberName); index >= 0 {
+		profile = members[index]
+	}
+	successResponse(w, http.StatusOK, "Member retrieved successfully", profile)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPasswordHashing verifies hashes are salted and only match their password.
+func TestPasswordHashing(t *testing.T) {
+	first, _ := hashPassword("correct horse")
+	second, _ := hashPassword("correct horse")
+	if first == second {
+		t.Errorf("expected salted hashes to differ")
+	}
+	if !checkPassword(first, "correct horse") || checkPassword(first, "wrong horse") || checkPassword("garbage", "correct horse") {
+		t.Errorf("expected only the original password to match")
+	}
+}
+
+// TestMemberLogin verifies registering a password, signing in and out, and
+// resetting a forgotten password.
+func TestMemberLogin(t *testing.T) {
+	setupTestEnvironment()
+	defer func(iterations int) { passwordIterations = iterations }(passwordIterations)
+	passwordIterations = 1000
+	members = []Member{{Name: "Ann", Email: "ann@example.com"}}
+
+	var sent []string
+	defer func(send func(to, subject, body string) error) { sendEmail = send }(sendEmail)
+	sendEmail = func(to, subject, body string) error {
+		sent = append(sent, body)
+		return nil
+	}
+
+	call := func(handler http.HandlerFunc, method, target, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewReader([]byte(body)))
+		req.SetPathValue("name", "Ann")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+	login := func(password string) (int, string) {
+		rec := call(loginHandler, http.MethodPost, "/login", `{"memberName":"Ann","password":"`+password+`"}`, "")
+		var response struct {
+			Data struct {
+				Token string `json:"token"`
+			} `json:"data"`
+		}
+		json.NewDecoder(rec.Body).Decode(&response)
+		return rec.Code, response.Data.Token
+	}
+
+	passwordTests := []struct {
+		name       string
+		body       string
+		statusCode int
+	}{
+		{name: "Too Short", body: `{"password":"short"}`, statusCode: http.StatusBadRequest},
+		{name: "Register Without Proof", body: `{"password":"first-password"}`, statusCode: http.StatusForbidden},
+		{name: "Register With Another Member's Link", body: `{"password":"first-password","token":"` + signToken(tokenPurposeVerify, "Bob", map[string]string{"email": "ann@example.com"}, time.Hour) + `"}`, statusCode: http.StatusForbidden},
+		{name: "Register", body: `{"password":"first-password","token":"` + signToken(tokenPurposeVerify, "Ann", map[string]string{"email": "ann@example.com"}, time.Hour) + `"}`, statusCode: http.StatusOK},
+		{name: "Change Without Current", body: `{"password":"second-password"}`, statusCode: http.StatusUnauthorized},
+	}
+	for _, tt := range passwordTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := call(memberPasswordHandler, http.MethodPut, "/members/Ann/password", tt.body, ""); rec.Code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+		})
+	}
+	if stored, _ := os.ReadFile("credentials.json"); strings.Contains(string(stored), "first-password") {
+		t.Fatalf("expected the password to be stored hashed")
+	}
+
+	if code, _ := login("wrong-password"); code != http.StatusUnauthorized {
+		t.Errorf("expected a wrong password to be rejected, got %d", code)
+	}
+	code, token := login("first-password")
+	if code != http.StatusOK || token == "" {
+		t.Fatalf("expected a session token, got %d", code)
+	}
+
+	// The session personalizes requests
+	if rec := call(meHandler, http.MethodGet, "/me", "", token); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "ann@example.com") {
+		t.Errorf("expected the member's own profile, got %d %s", rec.Code, rec.Body.String())
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if actor := actorFromRequest(req); actor != "Ann" {
+		t.Errorf("expected the member to be the actor, got %q", actor)
+	}
+
+	if rec := call(logoutHandler, http.MethodPost, "/logout", "", token); rec.Code != http.StatusOK {
+		t.Errorf("expected to sign out, got %d", rec.Code)
+	}
+	if rec := call(meHandler, http.MethodGet, "/me", "", token); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected the session to end, got %d", rec.Code)
+	}
+
+	// Reset a forgotten password with the emailed token
+	_, token = login("first-password")
+	if rec := call(passwordResetHandler, http.MethodPost, "/password-reset", `{"memberName":"Ann"}`, ""); rec.Code != http.StatusAccepted || len(sent) != 1 {
+		t.Fatalf("expected a reset email, got %d and %d emails", rec.Code, len(sent))
+	}
+	if rec := call(passwordResetHandler, http.MethodPost, "/password-reset", `{"memberName":"Nobody"}`, ""); rec.Code != http.StatusAccepted || len(sent) != 1 {
+		t.Errorf("expected unknown members to get the same answer and no email, got %d", rec.Code)
+	}
+	link, _ := url.Parse(sent[0][strings.Index(sent[0], "http"):])
+	resetToken := link.Query().Get("token")
+
+	resetTests := []struct {
+		name       string
+		token      string
+		statusCode int
+	}{
+		{name: "Tampered Token", token: resetToken + "x", statusCode: http.StatusBadRequest},
+		{name: "Valid Token", token: resetToken, statusCode: http.StatusOK},
+		{name: "Token Used Twice", token: resetToken, statusCode: http.StatusBadRequest},
+	}
+	for _, tt := range resetTests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"token":"` + tt.token + `","password":"reset-password"}`
+			if rec := call(passwordResetConfirmHandler, http.MethodPost, "/password-reset/confirm", body, ""); rec.Code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+		})
+	}
+
+	// Resetting signs the member out everywhere
+	if rec := call(meHandler, http.MethodGet, "/me", "", token); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected existing sessions to end, got %d", rec.Code)
+	}
+	if code, _ := login("reset-password"); code != http.StatusOK {
+		t.Errorf("expected the new password to work, got %d", code)
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// notifyExtraSession tells waitlisted members an extra session of a full class opened
+const notifyExtraSession = "extraSession"
+
+// AutoScaleRule opts a class in to extra sessions when members pile up on the
+// waitlist of one of its sessions
+type AutoScaleRule struct {
+	WaitlistThreshold int  `json:"waitlistThreshold"`         // Members waiting for a session that trigger an extra one
+	OffsetMinutes     int  `json:"offsetMinutes,omitempty"`   // Start of the extra session from the full one's, the class's duration or an hour when unset
+	RequireApproval   bool `json:"requireApproval,omitempty"` // Flag extra sessions for staff to approve instead of opening them
+}
+
+// Extra session states
+const (
+	extraSessionPending  = "pending" // Waiting for staff to approve it
+	extraSessionOpened   = "opened"
+	extraSessionRejected = "rejected"
+)
+
+// extraSessionDecisions maps each staff decision to the state it leaves an
+// extra session in
+var extraSessionDecisions = map[string]string{
+	"approve": extraSessionOpened,
+	"reject":  extraSessionRejected,
+}
+
+// ExtraSession is a session proposed, and possibly opened, next to a full
+// one whose waitlist passed its class's threshold. It opens as a class of its
+// own on that date, named after the class and its start time, e.g. "Yoga 10:00".
+type ExtraSession struct {
+	ID             int        `json:"id"`
+	ClassID        int        `json:"classId"` // Class whose session is full
+	ClassName      string     `json:"className"`
+	Date           Date       `json:"date"`      // DD-MM-YYYY on the wire
+	StartTime      string     `json:"startTime"` // HH:MM of the extra session
+	Waitlisted     int        `json:"waitlisted"`
+	Status         string     `json:"status"`
+	ExtraClassName string     `json:"extraClassName"`
+	ExtraClassID   int        `json:"extraClassId,omitempty"` // Set once opened
+	Note           string     `json:"note,omitempty"`         // Why it could not be opened automatically
+	CreatedAt      time.Time  `json:"createdAt"`
+	DecidedBy      string     `json:"decidedBy,omitempty"`
+	DecidedAt      *time.Time `json:"decidedAt,omitempty"`
+}
+
+var (
+	extraSessions  []ExtraSession // Temp Slice to hold extra sessions
+	extraSessionId = 1            // Incremental ID for extra sessions
+)
+
+// checkAutoScaleRule validates a class's auto-scaling rule, if it has one
+func checkAutoScaleRule(newClass Class) *requestRejection {
+	if newClass.AutoScale == nil {
+		return nil
+	}
+	if newClass.AutoScale.WaitlistThreshold <= 0 {
+		return &requestRejection{http.StatusBadRequest, "Invalid autoScale, give a positive waitlistThreshold"}
+	}
+	if newClass.StartTime == "" {
+		return &requestRejection{http.StatusBadRequest, "Invalid autoScale, the class needs a startTime to place extra sessions next to"}
+	}
+	return nil
+}
+
+// extraSessionIndex returns the position of the extra session with the given ID, or -1.
+// Callers must hold the mutex.
+func extraSessionIndex(id int) int {
+	for i, extra := range extraSessions {
+		if extra.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// extraSessionStart returns the start time of an extra session of a class,
+// or false when it would fall on another day
+func extraSessionStart(class Class) (string, bool) {
+	start, err := time.Parse(timeLayout, class.StartTime)
+	if err != nil {
+		return "", false
+	}
+	offset := class.AutoScale.OffsetMinutes
+	if offset == 0 {
+		offset = class.DurationMinutes
+	}
+	if offset == 0 {
+		offset = 60
+	}
+	extraStart := start.Add(time.Duration(offset) * time.Minute)
+	if extraStart.Day() != start.Day() {
+		return "", false
+	}
+	return extraStart.Format(timeLayout), true
+}
+
+// openExtraSession creates the class of an extra session and tells the
+// members on the full session's waitlist about it. Callers must hold the mutex.
+func openExtraSession(index int, actor string) *requestRejection {
+	extra := extraSessions[index]
+	classIdx := classIndex(extra.ClassID)
+	if classIdx < 0 || classes[classIdx].DeletedAt != nil {
+		return &requestRejection{http.StatusConflict, "The full session's class no longer exists"}
+	}
+	date := extra.Date
+	if findClassOn(extra.ExtraClassName, date.Time) != nil {
+		return &requestRejection{http.StatusConflict, "A class named " + extra.ExtraClassName + " already runs on " + date.String()}
+	}
+
+	// The extra session copies its class for one date, and does not scale itself
+	class := classes[classIdx]
+	class.ID, class.TemplateID, class.AutoScale = 0, 0, nil
+	class.ClassName, class.StartTime = extra.ExtraClassName, extra.StartTime
+	class.StartDate, class.EndDate = date, date
+	if rejection := checkClass(class); rejection != nil {
+		return rejection
+	}
+	if conflict := checkRoomCapacity(class.Room, class.Capacity); conflict != nil {
+		return &requestRejection{http.StatusConflict, fmt.Sprintf("Capacity exceeds the %d places of room %s", conflict.RoomCapacity, conflict.Room)}
+	}
+	addClass(&class)
+	audit := recordAudit(actor, "create", "class", class.ID, nil, class)
+	if err := writeDataToJsonFile("classes.json", classes); err != nil {
+		classes = classes[:len(classes)-1]
+		classId--
+		retractAudit(audit)
+		return &requestRejection{http.StatusInternalServerError, "Failed to save class data"}
+	}
+	ensureSessions(class)
+
+	before := extra
+	decidedAt := now()
+	extraSessions[index].Status = extraSessionOpened
+	extraSessions[index].ExtraClassID = class.ID
+	extraSessions[index].Note = ""
+	extraSessions[index].DecidedBy = actor
+	extraSessions[index].DecidedAt = &decidedAt
+	if err := writeDataToJsonFile("extra_sessions.json", extraSessions); err != nil {
+		fmt.Println("Error saving extra sessions:", err)
+	}
+	recordAudit(actor, "open", "extra-session", extra.ID, before, extraSessions[index])
+
+	subject := "Extra " + extra.ClassName + " session on " + extra.Date.String()
+	body := fmt.Sprintf("%s on %s is full, so an extra session opens at %s. Book %s to take a place; you keep your place on the waitlist until you do.", extra.ClassName, extra.Date, extra.StartTime, extra.ExtraClassName)
+	for _, waiting := range waitlistQueue(extra.ClassName, extra.Date) {
+		notifyMember(waiting.MemberName, notifyExtraSession, subject, body, map[string]string{"event": notifyExtraSession, "classId": strconv.Itoa(class.ID)})
+	}
+	return nil
+}
+
+// autoScaleSession proposes an extra session once the waitlist of a session
+// reaches its class's threshold, and opens it unless the class wants staff to
+// approve it first. Each session gets at most one. Callers must hold the mutex.
+func autoScaleSession(class *Class, date Date) {
+	if class.AutoScale == nil {
+		return
+	}
+	waiting := len(waitlistQueue(class.ClassName, date))
+	if waiting < class.AutoScale.WaitlistThreshold {
+		return
+	}
+	for _, extra := range extraSessions {
+		if extra.ClassID == class.ID && extra.Date == date {
+			return
+		}
+	}
+	startTime, ok := extraSessionStart(*class)
+	if !ok {
+		return
+	}
+
+	extra := ExtraSession{
+		ID:             extraSessionId,
+		ClassID:        class.ID,
+		ClassName:      class.ClassName,
+		Date:           date,
+		StartTime:      startTime,
+		Waitlisted:     waiting,
+		Status:         extraSessionPending,
+		ExtraClassName: class.ClassName + " " + startTime,
+		CreatedAt:      now(),
+	}
+	extraSessionId++
+	extraSessions = append(extraSessions, extra)
+	if err := writeDataToJsonFile("extra_sessions.json", extraSessions); err != nil {
+		fmt.Println("Error saving extra sessions:", err)
+	}
+	recordAudit("system", "create", "extra-session", extra.ID, nil, extra)
+
+	if !class.AutoScale.RequireApproval {
+		rejection := openExtraSession(len(extraSessions)-1, "system")
+		if rejection == nil {
+			alertOps(opsExtraSession, fmt.Sprintf("%s on %s has %d members waiting, so %s was opened", extra.ClassName, date, waiting, extra.ExtraClassName))
+			return
+		}
+		// Sessions that cannot open by themselves are left to staff
+		extraSessions[len(extraSessions)-1].Note = rejection.Message
+		if err := writeDataToJsonFile("extra_sessions.json", extraSessions); err != nil {
+			fmt.Println("Error saving extra sessions:", err)
+		}
+	}
+	alertOps(opsExtraSession, fmt.Sprintf("%s on %s has %d members waiting; extra session %s at %s awaits approval", extra.ClassName, date, waiting, extra.ExtraClassName, startTime))
+}
+
+// Handler for listing extra sessions, optionally narrowed to a ?status=
+func extraSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && status != extraSessionPending && status != extraSessionOpened && status != extraSessionRejected {
+		errorResponse(w, http.StatusBadRequest, "Invalid status, use pending, opened or rejected")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	matching := []ExtraSession{}
+	for _, extra := range extraSessions {
+		if status == "" || extra.Status == status {
+			matching = append(matching, extra)
+		}
+	}
+	successResponse(w, http.StatusOK, "Extra sessions retrieved successfully", map[string]interface{}{"extraSessions": matching})
+}
+
+// Handler for approving a pending extra session, or rejecting it with an
+// optional note
+func decideExtraSessionHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	action := r.PathValue("action")
+	status, ok := extraSessionDecisions[action]
+	if !ok {
+		errorResponse(w, http.StatusNotFound, "Not found")
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid extra session id")
+		return
+	}
+	var request struct {
+		Note string `json:"note"`
+	}
+	// The note is optional, so an empty body is accepted
+	if err := decodeBody(r, &request); err != nil && err != io.EOF {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := extraSessionIndex(id)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Extra session not found")
+		return
+	}
+	if extraSessions[index].Status != extraSessionPending {
+		errorResponse(w, http.StatusConflict, "Extra session is already "+extraSessions[index].Status)
+		return
+	}
+
+	actor := actorFromRequest(r)
+	if status == extraSessionOpened {
+		if rejection := openExtraSession(index, actor); rejection != nil {
+			errorResponse(w, rejection.StatusCode, rejection.Message)
+			return
+		}
+	} else {
+		before := extraSessions[index]
+		decidedAt := now()
+		extraSessions[index].Status = status
+		extraSessions[index].Note = strings.TrimSpace(request.Note)
+		extraSessions[index].DecidedBy = actor
+		extraSessions[index].DecidedAt = &decidedAt
+		if err := writeDataToJsonFile("extra_sessions.json", extraSessions); err != nil {
+			extraSessions[index] = before
+			errorResponse(w, http.StatusInternalServerError, "Failed to save extra session data")
+			return
+		}
+		recordAudit(actor, action, "extra-session", id, before, extraSessions[index])
+	}
+
+	successResponse(w, http.StatusOK, "Extra session "+status+" successfully", extraSessions[index])
+	logData("Extra session "+status+" successfully", extraSessions[index])
+}
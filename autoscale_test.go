@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// joinWaitlist adds a member to a session's waitlist through the handler
+func joinWaitlist(t *testing.T, member, className, date string) {
+	rec := httptest.NewRecorder()
+	waitlistHandler(rec, httptest.NewRequest(http.MethodPost, "/waitlist", bytes.NewReader([]byte(`{"memberName":"`+member+`","className":"`+className+`","date":"`+date+`"}`))))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected %s to join the waitlist, got %d: %s", member, rec.Code, rec.Body.String())
+	}
+}
+
+// TestAutoScaleOpensExtraSession verifies a long waitlist opens an extra session and tells the waiting members.
+func TestAutoScaleOpensExtraSession(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), StartTime: "09:00", DurationMinutes: 45, Capacity: 1, AutoScale: &AutoScaleRule{WaitlistThreshold: 2}}}
+	classId = 2
+	bookings = []Booking{{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("15-12-2099"), Status: bookingStatusConfirmed}}
+	bookingId = 2
+	migrateSessions()
+	members = []Member{{Name: "Ben", Email: "ben@example.com"}, {Name: "Cat", Email: "cat@example.com"}}
+
+	var notified []string
+	defer func(send func(to, subject, body string) error) { sendEmail = send }(sendEmail)
+	sendEmail = func(to, subject, body string) error {
+		if strings.Contains(body, "Yoga 09:45") {
+			notified = append(notified, to)
+		}
+		return nil
+	}
+
+	joinWaitlist(t, "Ben", "Yoga", "15-12-2099")
+	if len(extraSessions) != 0 {
+		t.Fatalf("expected no extra session below the threshold, got %+v", extraSessions)
+	}
+	joinWaitlist(t, "Cat", "Yoga", "15-12-2099")
+	if len(extraSessions) != 1 || extraSessions[0].Status != extraSessionOpened || extraSessions[0].StartTime != "09:45" || extraSessions[0].Waitlisted != 2 {
+		t.Fatalf("expected an extra session to open after the class, got %+v", extraSessions)
+	}
+	extra := classes[classIndex(extraSessions[0].ExtraClassID)]
+	if extra.ClassName != "Yoga 09:45" || extra.StartDate.String() != "15-12-2099" || extra.EndDate.String() != "15-12-2099" || extra.Capacity != 1 || extra.AutoScale != nil {
+		t.Errorf("expected a one-day copy of the class, got %+v", extra)
+	}
+	if len(notified) != 2 {
+		t.Errorf("expected both waiting members to be told, got %v", notified)
+	}
+
+	// Each session gets one extra session
+	members = append(members, Member{Name: "Dan"})
+	joinWaitlist(t, "Dan", "Yoga", "15-12-2099")
+	if len(extraSessions) != 1 || len(classes) != 2 {
+		t.Errorf("expected no second extra session, got %+v", extraSessions)
+	}
+}
+
+// TestAutoScaleApproval verifies extra sessions needing approval wait for staff to approve or reject them.
+func TestAutoScaleApproval(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Spin", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), StartTime: "18:00", Capacity: 1, AutoScale: &AutoScaleRule{WaitlistThreshold: 1, OffsetMinutes: -90, RequireApproval: true}}}
+	classId = 2
+	bookings = []Booking{
+		{ID: 1, MemberName: "Ann", ClassName: "Spin", Date: testDate("15-12-2099"), Status: bookingStatusConfirmed},
+		{ID: 2, MemberName: "Ann", ClassName: "Spin", Date: testDate("16-12-2099"), Status: bookingStatusConfirmed},
+	}
+	bookingId = 3
+	migrateSessions()
+
+	joinWaitlist(t, "Ben", "Spin", "15-12-2099")
+	joinWaitlist(t, "Ben", "Spin", "16-12-2099")
+	if len(extraSessions) != 2 || extraSessions[0].Status != extraSessionPending || extraSessions[0].ExtraClassName != "Spin 16:30" || len(classes) != 1 {
+		t.Fatalf("expected two pending extra sessions and no new class, got %+v", extraSessions)
+	}
+
+	decide := func(id, action, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/extra-sessions/"+id+"/"+action, bytes.NewReader([]byte(body)))
+		req.SetPathValue("id", id)
+		req.SetPathValue("action", action)
+		rec := httptest.NewRecorder()
+		decideExtraSessionHandler(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name       string
+		id         string
+		action     string
+		body       string
+		statusCode int
+		status     string
+	}{
+		{name: "Unknown Action", id: "1", action: "open", statusCode: http.StatusNotFound},
+		{name: "Unknown Session", id: "9", action: "approve", statusCode: http.StatusNotFound},
+		{name: "Approve", id: "1", action: "approve", statusCode: http.StatusOK, status: extraSessionOpened},
+		{name: "Approve Again", id: "1", action: "approve", statusCode: http.StatusConflict},
+		{name: "Reject", id: "2", action: "reject", body: `{"note":"No instructor"}`, statusCode: http.StatusOK, status: extraSessionRejected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := decide(tt.id, tt.action, tt.body)
+			if rec.Code != tt.statusCode {
+				t.Fatalf("expected status code %d, got %d: %s", tt.statusCode, rec.Code, rec.Body.String())
+			}
+			if id, _ := strconv.Atoi(tt.id); tt.status != "" && extraSessions[extraSessionIndex(id)].Status != tt.status {
+				t.Errorf("expected the extra session to be %s, got %+v", tt.status, extraSessions[extraSessionIndex(id)])
+			}
+		})
+	}
+	if extraSessions[0].ExtraClassID != 2 || classes[1].ClassName != "Spin 16:30" || extraSessions[1].Note != "No instructor" || extraSessions[1].Status != extraSessionRejected {
+		t.Errorf("expected the first to open and the second to be rejected, got %+v", extraSessions)
+	}
+
+	rec := httptest.NewRecorder()
+	extraSessionsHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/extra-sessions?status=rejected", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "No instructor") || strings.Contains(rec.Body.String(), `"id":1,`) {
+		t.Errorf("expected only the rejected session, got %s", rec.Body.String())
+	}
+}
+
+// TestAutoScaleRuleValidation verifies classes only take auto-scaling rules they can apply.
+func TestAutoScaleRuleValidation(t *testing.T) {
+	base := Class{ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 5, StartTime: "09:00"}
+	noThreshold, noStartTime := base, base
+	noThreshold.AutoScale = &AutoScaleRule{}
+	noStartTime.AutoScale = &AutoScaleRule{WaitlistThreshold: 3}
+	noStartTime.StartTime = ""
+	for _, class := range []Class{noThreshold, noStartTime} {
+		if rejection := checkAutoScaleRule(class); rejection == nil || rejection.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected %+v to be refused, got %v", class.AutoScale, rejection)
+		}
+	}
+
+	late := base
+	late.StartTime = "23:30"
+	late.AutoScale = &AutoScaleRule{WaitlistThreshold: 3}
+	if _, ok := extraSessionStart(late); ok {
+		t.Error("expected no extra session past midnight")
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dataFiles lists every file that holds service state
+var dataFiles = []string{"classes.json", "bookings.json", "audit.json", "booking_events.json", "templates.json", "holds.json", "waitlist.json", "instructor_absences.json", "taxonomy.json", "members.json", "credentials.json", "sessions.json", "api_keys.json", "blocks.json", "waivers.json", "devices.json", "webhooks.json", "outbox.json", outboxMarkFile, "consumed_events.json", "class_sessions.json", "rooms.json", "resources.json", "studios.json", "credits.json", "refunds.json", "giftcards.json", "referrals.json", "points.json", "pricing_rules.json", "promos.json", "promo_redemptions.json", "report_subscriptions.json", "closures.json", "announcements.json", "reviews.json", "instructors.json", "extra_sessions.json", "waitlist_history.json", archiveFile, retentionArchiveFile}
+
+// backupManifest describes the contents of a backup archive
+type backupManifest struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Files     []string  `json:"files"`
+}
+
+// backupManifestName is the archive entry holding the manifest
+const backupManifestName = "manifest.json"
+
+// backupFileName returns a timestamped name for a backup archive
+func backupFileName(at time.Time) string {
+	return "backup-" + at.Format("20060102-150405") + ".tar.gz"
+}
+
+// snapshotDataFiles reads every data file that exists, under the mutex so
+// the snapshot is consistent
+func snapshotDataFiles() (backupManifest, map[string][]byte, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	manifest := backupManifest{CreatedAt: now()}
+	files := map[string][]byte{}
+	for _, fileName := range dataFiles {
+		data, err := os.ReadFile(fileName)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return manifest, nil, err
+		}
+		files[fileName] = data
+		manifest.Files = append(manifest.Files, fileName)
+	}
+	return manifest, files, nil
+}
+
+// writeBackup writes a gzipped tar archive of all data files to w. The files
+// are read under the mutex, which is released before the archive is written,
+// so a slow download does not hold up other requests.
+func writeBackup(w io.Writer) error {
+	manifest, files, err := snapshotDataFiles()
+	if err != nil {
+		return err
+	}
+
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, fileName := range manifest.Files {
+		if err := writeTarEntry(tarWriter, fileName, files[fileName], manifest.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", " ")
+	if err != nil {
+		return err
+	}
+	if err := writeTarEntry(tarWriter, backupManifestName, manifestData, manifest.CreatedAt); err != nil {
+		return err
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+// writeTarEntry adds a single file to a tar archive
+func writeTarEntry(tarWriter *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{Name: name, Mode: 0666, Size: int64(len(data)), ModTime: modTime}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tarWriter.Write(data)
+	return err
+}
+
+// Handler for downloading a backup snapshot
+func backupHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	fileName := backupFileName(now())
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+
+	// Headers are already sent once streaming starts, so failures can only be logged
+	if err := writeBackup(w); err != nil {
+		fmt.Println("Error streaming backup:", err)
+		return
+	}
+	logData("Backup downloaded", fileName)
+}
+
+// backupStore returns where automatic backups are kept and the key prefix of
+// their archives: the backup directory, or the S3 bucket when uploaded files
+// are kept there, so backups outlive an ephemeral disk
+func backupStore() (BlobStore, string) {
+	if config.BlobStore == "s3" {
+		return blobStore, "backups/"
+	}
+	return diskBlobStore{dir: config.BackupDir}, ""
+}
+
+// backupArchives returns the keys of the automatic backups, oldest first
+func backupArchives() ([]string, error) {
+	store, prefix := backupStore()
+	keys, err := store.List(prefix)
+	if err != nil {
+		return nil, err
+	}
+	var archives []string
+	for _, key := range keys {
+		name := strings.TrimPrefix(key, prefix)
+		if strings.HasPrefix(name, "backup-") && strings.HasSuffix(name, ".tar.gz") && !strings.Contains(name, "/") {
+			archives = append(archives, key)
+		}
+	}
+	// Timestamped names sort oldest first
+	sort.Strings(archives)
+	return archives, nil
+}
+
+// createBackupFile writes a backup archive into the backup store and removes
+// the oldest archives beyond the retained count
+func createBackupFile() error {
+	var archive bytes.Buffer
+	if err := writeBackup(&archive); err != nil {
+		return err
+	}
+	store, prefix := backupStore()
+	key := prefix + backupFileName(now())
+	if err := store.Put(key, "application/gzip", archive.Bytes()); err != nil {
+		return err
+	}
+	logData("Backup created", key)
+	return rotateBackups()
+}
+
+// rotateBackups keeps only the newest BackupRetain archives
+func rotateBackups() error {
+	archives, err := backupArchives()
+	if err != nil {
+		return err
+	}
+	store, _ := backupStore()
+	for len(archives) > config.BackupRetain && config.BackupRetain > 0 {
+		if err := store.Delete(archives[0]); err != nil {
+			return err
+		}
+		archives = archives[1:]
+	}
+	return nil
+}
+
+// latestBackup returns the newest automatic backup archive
+func latestBackup() ([]byte, error) {
+	archives, err := backupArchives()
+	if err != nil {
+		return nil, err
+	}
+	if len(archives) == 0 {
+		return nil, fmt.Errorf("no backups found")
+	}
+	store, _ := backupStore()
+	return store.Get(archives[len(archives)-1])
+}
+
+// runScheduledBackup is the scheduled entry point for createBackupFile
+func runScheduledBackup() {
+	if err := createBackupFile(); err != nil {
+		fmt.Println("Error creating backup:", err)
+	}
+}
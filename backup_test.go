@@ -0,0 +1,115 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readBackupEntries returns the file names and contents in a backup archive
+func readBackupEntries(t *testing.T, r io.Reader) map[string]string {
+	t.Helper()
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatalf("unexpected error opening archive: %v", err)
+	}
+	entries := map[string]string{}
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatalf("unexpected error reading archive: %v", err)
+		}
+		data, _ := io.ReadAll(tarReader)
+		entries[header.Name] = string(data)
+	}
+}
+
+// TestBackupHandler verifies the backup endpoint streams every data file.
+func TestBackupHandler(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2024, 12, 16, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	writeDataToJsonFile("classes.json", []Class{{ID: 1, ClassName: "Pilates"}})
+
+	rec := httptest.NewRecorder()
+	backupHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/backup", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, rec.Code)
+	}
+	if disposition := rec.Header().Get("Content-Disposition"); !strings.Contains(disposition, "backup-20241216-100000.tar.gz") {
+		t.Errorf("expected a timestamped file name, got %q", disposition)
+	}
+
+	entries := readBackupEntries(t, rec.Body)
+	if !strings.Contains(entries["classes.json"], "Pilates") {
+		t.Errorf("expected classes.json in the backup, got %v", entries)
+	}
+	if _, ok := entries["bookings.json"]; !ok {
+		t.Errorf("expected bookings.json in the backup")
+	}
+	if !strings.Contains(entries[backupManifestName], "classes.json") {
+		t.Errorf("expected a manifest listing the files, got %q", entries[backupManifestName])
+	}
+}
+
+// lockCheckingWriter records whether the mutex was held while it was written to
+type lockCheckingWriter struct {
+	locked bool
+}
+
+func (w *lockCheckingWriter) Write(p []byte) (int, error) {
+	if mutex.TryLock() {
+		mutex.Unlock()
+	} else {
+		w.locked = true
+	}
+	return len(p), nil
+}
+
+// TestBackupReleasesMutex verifies the archive is written without holding the mutex.
+func TestBackupReleasesMutex(t *testing.T) {
+	setupTestEnvironment()
+	writeDataToJsonFile("classes.json", []Class{{ID: 1, ClassName: "Pilates"}})
+
+	writer := &lockCheckingWriter{}
+	if err := writeBackup(writer); err != nil {
+		t.Fatalf("unexpected error writing backup: %v", err)
+	}
+	if writer.locked {
+		t.Errorf("expected the mutex to be released while the archive is written")
+	}
+}
+
+// TestCreateBackupFileRotation verifies scheduled backups keep only the newest archives.
+func TestCreateBackupFileRotation(t *testing.T) {
+	setupTestEnvironment()
+	config.BackupDir = t.TempDir()
+	config.BackupRetain = 2
+	defer func() { now = time.Now }()
+
+	for day := 1; day <= 3; day++ {
+		now = func() time.Time { return time.Date(2024, 12, day, 10, 0, 0, 0, time.UTC) }
+		if err := createBackupFile(); err != nil {
+			t.Fatalf("unexpected error creating backup: %v", err)
+		}
+	}
+
+	entries, _ := os.ReadDir(config.BackupDir)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 backups to be retained, got %d", len(entries))
+	}
+	if entries[0].Name() != "backup-20241202-100000.tar.gz" {
+		t.Errorf("expected the oldest backup to be rotated out, got %s", entries[0].Name())
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// BlobStore keeps files that do not belong in the JSON data files, such as
+// uploaded images, under slash-separated keys
+type BlobStore interface {
+	Put(key, contentType string, data []byte) error
+	// Get returns errBlobNotFound for a key that was never stored or was deleted
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+	// List returns the keys starting with a prefix
+	List(prefix string) ([]string, error)
+}
+
+// errBlobNotFound reports a key the blob store does not hold
+var errBlobNotFound = errors.New("blob not found")
+
+// diskBlobStore keeps blobs as files under a directory
+type diskBlobStore struct {
+	dir string
+}
+
+// path returns the file of a key, refusing keys that would escape the directory
+func (d diskBlobStore) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(d.dir, clean), nil
+}
+
+// Put writes the blob to its file, creating directories as needed
+func (d diskBlobStore) Put(key, contentType string, data []byte) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Get reads the blob's file
+func (d diskBlobStore) Get(key string) ([]byte, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errBlobNotFound
+	}
+	return data, err
+}
+
+// Delete removes the blob's file, ignoring blobs that are already gone
+func (d diskBlobStore) Delete(key string) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List walks the directory for files whose keys start with the prefix
+func (d diskBlobStore) List(prefix string) ([]string, error) {
+	keys := []string{}
+	err := filepath.WalkDir(d.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relative, err := filepath.Rel(d.dir, path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(relative); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return keys, nil
+	}
+	return keys, err
+}
+
+// blobStore holds uploaded files, and automatic backups when it is S3
+var blobStore BlobStore = diskBlobStore{dir: "uploads"}
+
+// configureBlobs selects the blob store holding uploaded files
+func configureBlobs() error {
+	switch config.BlobStore {
+	case "", "disk":
+		blobStore = diskBlobStore{dir: config.BlobDir}
+		return nil
+	case "s3":
+		if config.S3Endpoint == "" || config.S3Bucket == "" || config.S3AccessKey == "" || config.S3SecretKey == "" {
+			return fmt.Errorf("s3Endpoint, s3Bucket, s3AccessKey and s3SecretKey are required")
+		}
+		blobStore = &s3BlobStore{
+			endpoint:  strings.TrimSuffix(config.S3Endpoint, "/"),
+			region:    config.S3Region,
+			bucket:    config.S3Bucket,
+			accessKey: config.S3AccessKey,
+			secretKey: config.S3SecretKey,
+			client:    &http.Client{Timeout: 30 * time.Second},
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid blobStore %q, use disk or s3", config.BlobStore)
+	}
+}
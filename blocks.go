@@ -0,0 +1,148 @@
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+// MemberBlock stops a member from booking, until a date or indefinitely
+type MemberBlock struct {
+	MemberName string    `json:"memberName"`
+	Reason     string    `json:"reason"`
+	Until      string    `json:"until,omitempty"` // Last blocked day, DD-MM-YYYY; empty blocks indefinitely
+	BlockedBy  string    `json:"blockedBy"`
+	BlockedAt  time.Time `json:"blockedAt"`
+}
+
+var blocks []MemberBlock // Temp Slice to hold member blocks
+
+// blockIndex returns the position of a member's block, or -1.
+// Callers must hold the mutex.
+func blockIndex(memberName string) int {
+	for i, block := range blocks {
+		if block.MemberName == memberName {
+			return i
+		}
+	}
+	return -1
+}
+
+// blockActive reports whether a block still applies today
+func blockActive(block MemberBlock) bool {
+	if block.Until == "" {
+		return true
+	}
+	until, err := time.Parse(dateLayout, block.Until)
+	return err == nil && !until.Before(today())
+}
+
+// checkBlocked rejects a member who is blocked from booking.
+// Callers must hold the mutex.
+func checkBlocked(memberName string) *requestRejection {
+	index := blockIndex(memberName)
+	if index < 0 || !blockActive(blocks[index]) {
+		return nil
+	}
+	message := "Member is blocked from booking: " + blocks[index].Reason
+	if blocks[index].Until != "" {
+		message += " (until " + blocks[index].Until + ")"
+	}
+	return &requestRejection{http.StatusForbidden, message}
+}
+
+// Handler for blocking a member and lifting the block
+func memberBlockHandler(w http.ResponseWriter, r *http.Request) {
+	memberName := r.PathValue("name")
+	if memberName == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid member name")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		var block MemberBlock
+		if err := decodeBody(r, &block); err != nil || block.Reason == "" {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body, a reason is required")
+			return
+		}
+		if block.Until != "" {
+			until, err := time.Parse(dateLayout, block.Until)
+			if err != nil || until.Before(today()) {
+				errorResponse(w, http.StatusBadRequest, "Invalid until, use a DD-MM-YYYY date from today on")
+				return
+			}
+		}
+		block.MemberName = memberName
+		block.BlockedBy = actorFromRequest(r)
+		block.BlockedAt = now()
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		// Blocking again replaces the reason and expiry
+		var before interface{}
+		index := blockIndex(memberName)
+		if index < 0 {
+			blocks = append(blocks, block)
+		} else {
+			before = blocks[index]
+			blocks[index] = block
+		}
+		if err := writeDataToJsonFile("blocks.json", blocks); err != nil {
+			if index < 0 {
+				blocks = blocks[:len(blocks)-1]
+			} else {
+				blocks[index] = before.(MemberBlock)
+			}
+			errorResponse(w, http.StatusInternalServerError, "Failed to save block data")
+			return
+		}
+		recordAudit(block.BlockedBy, "block", "member", 0, before, block)
+
+		successResponse(w, http.StatusOK, "Member blocked successfully", block)
+		logData("Member blocked successfully", block)
+
+	case http.MethodDelete:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := blockIndex(memberName)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Member is not blocked")
+			return
+		}
+		block := blocks[index]
+		blocks = append(blocks[:index], blocks[index+1:]...)
+		if err := writeDataToJsonFile("blocks.json", blocks); err != nil {
+			blocks = append(blocks[:index], append([]MemberBlock{block}, blocks[index:]...)...)
+			errorResponse(w, http.StatusInternalServerError, "Failed to save block data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "unblock", "member", 0, block, nil)
+
+		successResponse(w, http.StatusOK, "Member unblocked successfully", block)
+		logData("Member unblocked successfully", block)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// Handler for listing the members currently blocked
+func blocksHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	mutex.Lock()
+	active := []MemberBlock{}
+	for _, block := range blocks {
+		if blockActive(block) {
+			active = append(active, block)
+		}
+	}
+	mutex.Unlock()
+	successResponse(w, http.StatusOK, "Blocked members retrieved successfully", map[string]interface{}{"blocks": active})
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMemberBlocks verifies blocked members cannot book until the block ends.
+func TestMemberBlocks(t *testing.T) {
+	setupTestEnvironment()
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2099, 12, 1, 12, 0, 0, 0, time.UTC) }
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10}}
+	classId = 2
+
+	block := func(method, body string) int {
+		req := httptest.NewRequest(method, "/members/Ann/block", strings.NewReader(body))
+		req.SetPathValue("name", "Ann")
+		rec := httptest.NewRecorder()
+		memberBlockHandler(rec, req)
+		return rec.Code
+	}
+	book := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"Ann","className":"Yoga","date":"15-12-2099"}`))))
+		return rec
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		statusCode int
+	}{
+		{name: "Missing Reason", body: `{}`, statusCode: http.StatusBadRequest},
+		{name: "Past Expiry", body: `{"reason":"No-shows","until":"30-11-2099"}`, statusCode: http.StatusBadRequest},
+		{name: "Valid Block", body: `{"reason":"Repeated no-shows","until":"05-12-2099"}`, statusCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := block(http.MethodPut, tt.body); code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, code)
+			}
+		})
+	}
+
+	rec := book()
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "Repeated no-shows") {
+		t.Errorf("expected a blocked error naming the reason, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// The block lapses after its last day
+	now = func() time.Time { return time.Date(2099, 12, 6, 12, 0, 0, 0, time.UTC) }
+	if rec := book(); rec.Code != http.StatusCreated {
+		t.Errorf("expected the booking once the block expired, got %d", rec.Code)
+	}
+
+	// Lifting an indefinite block
+	block(http.MethodPut, `{"reason":"Unpaid fees"}`)
+	if rec := book(); rec.Code != http.StatusForbidden {
+		t.Errorf("expected an indefinite block to apply, got %d", rec.Code)
+	}
+	if code := block(http.MethodDelete, ""); code != http.StatusOK {
+		t.Errorf("expected the block to be lifted, got %d", code)
+	}
+	if code := block(http.MethodDelete, ""); code != http.StatusNotFound {
+		t.Errorf("expected no block to lift, got %d", code)
+	}
+}
+
+// TestBlockedMemberSkippedByWaitlist verifies promotion passes over blocked members.
+func TestBlockedMemberSkippedByWaitlist(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 1}}
+	classId = 2
+	waitlist = []WaitlistEntry{
+		{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("15-12-2099"), Tier: waitlistTierStandard, JoinedAt: time.Now().Add(-time.Hour)},
+		{ID: 2, MemberName: "Ben", ClassName: "Yoga", Date: testDate("15-12-2099"), Tier: waitlistTierStandard, JoinedAt: time.Now()},
+	}
+	waitlistId = 3
+	blocks = []MemberBlock{{MemberName: "Ann", Reason: "Unpaid fees"}}
+
+	mutex.Lock()
+	promoted := promoteWaitlist("Yoga", testDate("15-12-2099"))
+	mutex.Unlock()
+
+	if len(promoted) != 1 || promoted[0].MemberName != "Ben" {
+		t.Errorf("expected Ben to be promoted over the blocked member, got %+v", promoted)
+	}
+	if len(waitlist) != 1 || waitlist[0].MemberName != "Ann" {
+		t.Errorf("expected the blocked member to keep their place, got %+v", waitlist)
+	}
+}
@@ -0,0 +1,789 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// requestRejection explains why a request cannot be carried out
+type requestRejection struct {
+	StatusCode int
+	Message    string
+}
+
+// checkBooking validates a booking request and ensures the class has a free slot
+// on the requested date. It returns the class and the slots available before the
+// booking. Callers must hold the mutex.
+func checkBooking(newBooking Booking) (*Class, int, *requestRejection) {
+	// Validate the booking fields
+	if newBooking.MemberName == "" || newBooking.Date.IsZero() || newBooking.ClassName == "" {
+		return nil, 0, &requestRejection{http.StatusBadRequest, "Invalid field format"}
+	}
+	if rejection := checkBlocked(newBooking.MemberName); rejection != nil {
+		return nil, 0, rejection
+	}
+	bookingDate := newBooking.Date.Time
+
+	// Find the class by name and ensure the date is within its range
+	classFound := findClassOn(newBooking.ClassName, bookingDate)
+	if classFound == nil {
+		return nil, 0, &requestRejection{http.StatusBadRequest, "Class is not available on the specified date"}
+	}
+	// Capacity and cancellation belong to the session on the requested date
+	session := findSession(newBooking.ClassName, newBooking.Date)
+	if session == nil {
+		return nil, 0, &requestRejection{http.StatusBadRequest, "Class is not available on the specified date"}
+	}
+	if session.CancelledAt != nil {
+		return nil, 0, &requestRejection{http.StatusConflict, "Class session on this date is cancelled"}
+	}
+	capacity := session.Capacity
+	if instructor := sessionInstructor(*classFound, newBooking.Date); instructor != "" && instructorUnavailable(instructor, newBooking.Date) {
+		return nil, 0, &requestRejection{http.StatusConflict, "Instructor is unavailable on this date"}
+	}
+
+	// The member must meet the class's level and age rules
+	if rejection := checkEligibility(classFound, newBooking.MemberName, bookingDate, newBooking.LevelOverride); rejection != nil {
+		return nil, 0, rejection
+	}
+	if rejection := checkRentals(classFound, newBooking); rejection != nil {
+		return nil, 0, rejection
+	}
+	if rejection := checkVerifiedEmail(newBooking.MemberName); rejection != nil {
+		return nil, 0, rejection
+	}
+	if rejection := checkWaiver(newBooking.MemberName); rejection != nil {
+		return nil, 0, rejection
+	}
+
+	// Calculate available slots, counting held slots as taken, and ensure there's availability
+	availableSlots := capacity - countBookings(session.ID) - countHolds(session.ID)
+	if availableSlots <= 0 {
+		return nil, 0, &requestRejection{http.StatusBadRequest, "No available slots for the selected class on this date"}
+	}
+	return classFound, availableSlots, nil
+}
+
+// checkMember applies the member rules of checkBooking to someone booked
+// by another member: blocks, level and age, verified email and waiver. The
+// class is nil for resource bookings, which have no level or age rules.
+// Callers must hold the mutex.
+func checkMember(class *Class, memberName string, date time.Time) *requestRejection {
+	if rejection := checkBlocked(memberName); rejection != nil {
+		return rejection
+	}
+	if class != nil {
+		if rejection := checkEligibility(class, memberName, date, false); rejection != nil {
+			return rejection
+		}
+	}
+	if rejection := checkVerifiedEmail(memberName); rejection != nil {
+		return rejection
+	}
+	return checkWaiver(memberName)
+}
+
+// addBooking assigns the next ID to a booking and appends it. Bookings are
+// confirmed unless they are requested as pending, e.g. awaiting payment, in
+// which case they expire if not confirmed in time. Callers must hold the mutex.
+func addBooking(newBooking *Booking) {
+	newBooking.ID = bookingId
+	newBooking.Code = newConfirmationCode()
+	newBooking.ExpiresAt = nil
+	if newBooking.Status == bookingStatusPending {
+		expiresAt := now().Add(time.Duration(config.PendingBookingMinutes) * time.Minute)
+		newBooking.ExpiresAt = &expiresAt
+	} else {
+		newBooking.Status = bookingStatusConfirmed
+	}
+	assignSession(newBooking)
+	// The price is fixed when booking, so later changes to the class do not alter
+	// it. Bookings paid with credits or points cost no money.
+	newBooking.Price, newBooking.Currency, newBooking.ListPrice, newBooking.PricingRule, newBooking.EarlyBird = 0, "", 0, nil, false
+	if class := findClassOn(newBooking.ClassName, newBooking.Date.Time); class != nil && class.Price > 0 && newBooking.PaymentMethod == "" {
+		newBooking.Price, newBooking.Currency = class.Price, classCurrency(*class)
+		applyEarlyBird(newBooking, class, newBooking.Date.Time)
+		applyPricingRules(newBooking, class)
+	}
+	applyPromo(newBooking)
+	applyTax(newBooking)
+	// Gift cards are redeemed by the booking handler once the booking is valid
+	newBooking.GiftCardCode, newBooking.GiftCardID, newBooking.GiftCardAmount = "", 0, 0
+	bookingId++
+	bookings = append(bookings, *newBooking)
+}
+
+// maxBatchSize bounds the number of bookings in a single batch request
+const maxBatchSize = 100
+
+// batchBookingRequest books several members into one class
+type batchBookingRequest struct {
+	ClassName string `json:"className"`
+	Mode      string `json:"mode"` // "atomic" (default) or "bestEffort"
+	Bookings  []struct {
+		MemberName string `json:"memberName"`
+		Date       Date   `json:"date"`
+	} `json:"bookings"`
+}
+
+// batchBookingResult reports the outcome of one entry of a batch
+type batchBookingResult struct {
+	Index   int      `json:"index"`
+	Success bool     `json:"success"`
+	Booking *Booking `json:"booking,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Handler for booking several members into a class at once
+func batchBookingHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	var request batchBookingRequest
+	if err := decodeBody(r, &request); err != nil {
+		errorResponse(w, http.StatusBadRequest, bodyErrorMessage(err))
+		return
+	}
+	if request.Mode == "" {
+		request.Mode = "atomic"
+	}
+	if request.Mode != "atomic" && request.Mode != "bestEffort" {
+		errorResponse(w, http.StatusBadRequest, "Invalid mode, use atomic or bestEffort")
+		return
+	}
+	if len(request.Bookings) == 0 || len(request.Bookings) > maxBatchSize {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("A batch must contain between 1 and %d bookings", maxBatchSize))
+		return
+	}
+	force, rejection := forceRequested(r)
+	if rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// Apply entries in order, so earlier entries consume capacity seen by later ones
+	originalCount, originalId := len(bookings), bookingId
+	results := make([]batchBookingResult, len(request.Bookings))
+	created := 0
+	for i, item := range request.Bookings {
+		newBooking := Booking{MemberName: item.MemberName, Date: item.Date, ClassName: request.ClassName}
+		if _, _, rejection := checkBooking(newBooking); rejection != nil {
+			results[i] = batchBookingResult{Index: i, Error: rejection.Message}
+			continue
+		}
+		if _, rejection := checkScheduleConflicts(newBooking, 0, force); rejection != nil {
+			results[i] = batchBookingResult{Index: i, Error: rejection.Message}
+			continue
+		}
+		addBooking(&newBooking)
+		results[i] = batchBookingResult{Index: i, Success: true, Booking: &newBooking}
+		created++
+	}
+
+	// In atomic mode a single failure discards the whole batch
+	if request.Mode == "atomic" && created < len(request.Bookings) {
+		bookings, bookingId = bookings[:originalCount], originalId
+		for i := range results {
+			results[i].Success, results[i].Booking = false, nil
+		}
+		// The envelope carries the per-item results so callers can see which entries failed
+		errorDetailsResponse(w, http.StatusBadRequest, "Batch booking failed, no bookings were made", map[string]interface{}{"results": results})
+		return
+	}
+
+	if created > 0 {
+		// The events announcing the bookings are saved before the bookings
+		audit := auditId
+		for _, result := range results {
+			if result.Success {
+				recordAudit(actorFromRequest(r), "create", "booking", result.Booking.ID, nil, *result.Booking)
+			}
+		}
+		if err := writeDataToJsonFile("bookings.json", bookings); err != nil {
+			bookings, bookingId = bookings[:originalCount], originalId
+			retractAudit(audit)
+			errorResponse(w, http.StatusInternalServerError, "Failed to save booking data")
+			return
+		}
+		for _, result := range results {
+			if result.Success {
+				recordBookingEvent(result.Booking.ID, bookingEventCreated, actorFromRequest(r), nil)
+			}
+		}
+	}
+
+	response := map[string]interface{}{
+		"mode":    request.Mode,
+		"created": created,
+		"failed":  len(request.Bookings) - created,
+		"results": results,
+	}
+
+	// Partial success is reported as 207 Multi-Status
+	statusCode, message := http.StatusCreated, "Batch booking successful"
+	if created == 0 {
+		statusCode, message = http.StatusBadRequest, "Batch booking failed, no bookings were made"
+		recordError(statusCode, message)
+	} else if created < len(request.Bookings) {
+		statusCode, message = http.StatusMultiStatus, "Batch booking partially successful"
+	}
+	successResponse(w, statusCode, message, response)
+	logData(message, response)
+}
+
+// groupBookingRequest reserves spots for several named attendees under one member
+type groupBookingRequest struct {
+	MemberName string   `json:"memberName"`
+	Date       Date     `json:"date"`
+	ClassName  string   `json:"className"`
+	Attendees  []string `json:"attendees"`
+}
+
+// Handler for booking a group of attendees under a primary member
+func groupBookingHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	var request groupBookingRequest
+	if err := decodeBody(r, &request); err != nil {
+		errorResponse(w, http.StatusBadRequest, bodyErrorMessage(err))
+		return
+	}
+	// Members signed in without a key book their group themselves
+	if member := sessionOnlyMember(r); member != "" {
+		request.MemberName = member
+	}
+	if request.MemberName == "" || len(request.Attendees) == 0 || len(request.Attendees) > maxBatchSize {
+		errorResponse(w, http.StatusBadRequest, "Invalid field format")
+		return
+	}
+	seen := map[string]bool{}
+	for _, attendee := range request.Attendees {
+		if attendee == "" {
+			errorResponse(w, http.StatusBadRequest, "Invalid attendee name")
+			return
+		}
+		if seen[attendee] {
+			errorResponse(w, http.StatusBadRequest, "Attendee "+attendee+" is listed more than once")
+			return
+		}
+		seen[attendee] = true
+	}
+	if rateLimited(w, request.MemberName) {
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// Every attendee takes a slot, so the whole group must fit
+	class, availableSlots, rejection := checkBooking(Booking{MemberName: request.MemberName, Date: request.Date, ClassName: request.ClassName})
+	if rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+	if availableSlots < len(request.Attendees) {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Only %d slots available for the selected class on this date", availableSlots))
+		return
+	}
+
+	// Each attendee must be allowed in the class as if they booked it themselves
+	for _, attendee := range request.Attendees {
+		if rejection := checkMember(class, attendee, request.Date.Time); rejection != nil {
+			errorResponse(w, rejection.StatusCode, "Attendee "+attendee+": "+rejection.Message)
+			return
+		}
+		if memberHasBooking(attendee, class.ClassName, request.Date) {
+			errorResponse(w, http.StatusBadRequest, "Attendee "+attendee+" already has a booking for this class on this date")
+			return
+		}
+	}
+
+	// The first attendee's booking ID identifies the group
+	originalCount, originalId := len(bookings), bookingId
+	bookedBefore := countBookings(sessionIDOn(class.ClassName, request.Date))
+	group := []Booking{}
+	for _, attendee := range request.Attendees {
+		newBooking := Booking{
+			MemberName:    attendee,
+			Date:          request.Date,
+			ClassName:     class.ClassName,
+			GroupID:       originalId,
+			PrimaryMember: request.MemberName,
+		}
+		addBooking(&newBooking)
+		group = append(group, newBooking)
+	}
+
+	// The events announcing the bookings are saved before the bookings
+	audit := auditId
+	for _, booking := range group {
+		recordAudit(actorFromRequest(r), "create", "booking", booking.ID, nil, booking)
+	}
+	if err := writeDataToJsonFile("bookings.json", bookings); err != nil {
+		bookings, bookingId = bookings[:originalCount], originalId
+		retractAudit(audit)
+		errorResponse(w, http.StatusInternalServerError, "Failed to save booking data")
+		return
+	}
+	for _, booking := range group {
+		recordBookingEvent(booking.ID, bookingEventCreated, actorFromRequest(r), map[string]string{"primaryMember": booking.PrimaryMember})
+	}
+	alertIfNearlyFull(class, request.Date, bookedBefore)
+
+	response := map[string]interface{}{
+		"groupId":        originalId,
+		"bookings":       group,
+		"availableSlots": availableSlots - len(group),
+	}
+	successResponse(w, http.StatusCreated, "Group booking successful", response)
+	logData("Group booking successful", response)
+}
+
+// bookingActions maps the last path segment of /bookings/{id}/{action} to its handler.
+// A single route serves them all, as separate ones would conflict with /bookings/code/{code}.
+var bookingActions = map[string]http.HandlerFunc{
+	"cancel":     cancelBookingHandler,
+	"transfer":   transferBookingHandler,
+	"reschedule": rescheduleBookingHandler,
+	"check-in":   checkInBookingHandler,
+	"confirm":    confirmBookingHandler,
+	"history":    bookingHistoryHandler,
+	"receipt":    bookingReceiptHandler,
+	"refund":     bookingRefundHandler,
+	"review":     reviewBookingHandler,
+}
+
+// Handler for reading a single booking
+func bookingItemHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid booking id")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := bookingIndex(id)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Booking not found")
+		return
+	}
+	successResponse(w, http.StatusOK, "Booking retrieved successfully", bookings[index])
+}
+
+// Handler dispatching /bookings/{id}/{action} requests
+func bookingActionHandler(w http.ResponseWriter, r *http.Request) {
+	handler, ok := bookingActions[r.PathValue("action")]
+	if !ok {
+		errorResponse(w, http.StatusNotFound, "Not found")
+		return
+	}
+	// Members signed in without a key only change their own bookings
+	if member := sessionOnlyMember(r); member != "" && r.Method != http.MethodGet {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		mutex.Lock()
+		index := bookingIndex(id)
+		owned := index < 0 || bookings[index].MemberName == member
+		mutex.Unlock()
+		if !owned {
+			errorResponse(w, http.StatusForbidden, "Booking belongs to another member")
+			return
+		}
+	}
+	handler(w, r)
+}
+
+// bookingIndex returns the position of the booking with the given ID, or -1.
+// Callers must hold the mutex.
+func bookingIndex(id int) int {
+	for i, booking := range bookings {
+		if booking.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Handler for cancelling a booking, its whole group with ?group=true, or the
+// remaining sessions of its series with ?series=true
+func cancelBookingHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid booking id")
+		return
+	}
+	wholeGroup := r.URL.Query().Get("group") == "true"
+	wholeSeries := r.URL.Query().Get("series") == "true"
+	if wholeGroup && wholeSeries {
+		errorResponse(w, http.StatusBadRequest, "Cancel either the group or the series")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := bookingIndex(id)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Booking not found")
+		return
+	}
+	if wholeGroup && bookings[index].GroupID == 0 {
+		errorResponse(w, http.StatusBadRequest, "Booking is not part of a group")
+		return
+	}
+
+	// Collect the bookings to cancel, skipping group members that can no longer be cancelled
+	targets := []int{index}
+	if wholeGroup {
+		targets = nil
+		for i, booking := range bookings {
+			if booking.GroupID == bookings[index].GroupID && checkTransition(booking, bookingStatusCancelled) == nil {
+				targets = append(targets, i)
+			}
+		}
+		if len(targets) == 0 {
+			errorResponse(w, http.StatusBadRequest, "No bookings in the group can be cancelled")
+			return
+		}
+	}
+	if wholeSeries && bookings[index].SeriesID == 0 {
+		errorResponse(w, http.StatusBadRequest, "Booking is not part of a series")
+		return
+	}
+	if wholeSeries {
+		// Sessions already past are left as they are
+		targets = nil
+		for i, booking := range bookings {
+			if booking.SeriesID == bookings[index].SeriesID && !booking.Date.Before(today()) && checkTransition(booking, bookingStatusCancelled) == nil {
+				targets = append(targets, i)
+			}
+		}
+		if len(targets) == 0 {
+			errorResponse(w, http.StatusBadRequest, "No remaining bookings in the series can be cancelled")
+			return
+		}
+	}
+	if rejection := checkTransition(bookings[targets[0]], bookingStatusCancelled); rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+
+	before := make([]Booking, len(targets))
+	cancelled := make([]Booking, len(targets))
+	audit := auditId
+	for i, target := range targets {
+		before[i] = bookings[target]
+		transitionBooking(target, bookingStatusCancelled)
+		cancelled[i] = bookings[target]
+		recordAudit(actorFromRequest(r), "cancel", "booking", cancelled[i].ID, before[i], cancelled[i])
+	}
+
+	if err := writeDataToJsonFile("bookings.json", bookings); err != nil {
+		for i, target := range targets {
+			bookings[target] = before[i]
+		}
+		retractAudit(audit)
+		errorResponse(w, http.StatusInternalServerError, "Failed to save booking data")
+		return
+	}
+
+	for i := range cancelled {
+		recordBookingEvent(cancelled[i].ID, bookingEventCancelled, actorFromRequest(r), nil)
+		notifyBooking(notifyBookingCancelled, cancelled[i])
+	}
+
+	// Freed slots go to the waitlist
+	for _, booking := range cancelled {
+		promoteWaitlist(booking.ClassName, booking.Date)
+	}
+
+	response := map[string]interface{}{"bookings": cancelled}
+	successResponse(w, http.StatusOK, "Booking cancelled successfully", response)
+	logData("Booking cancelled successfully", response)
+}
+
+// memberHasBooking reports whether a member already holds an active booking for
+// a class on a date. Callers must hold the mutex.
+func memberHasBooking(memberName, className string, date Date) bool {
+	for _, booking := range bookings {
+		if booking.MemberName == memberName && booking.ClassName == className && booking.Date == date && bookingActive(booking) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler for transferring a booking to another member
+func transferBookingHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid booking id")
+		return
+	}
+
+	var request struct {
+		MemberName string `json:"memberName"`
+	}
+	if err := decodeBody(r, &request); err != nil || request.MemberName == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	force, rejection := forceRequested(r)
+	if rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := bookingIndex(id)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Booking not found")
+		return
+	}
+	booking := bookings[index]
+	if !bookingActive(booking) {
+		errorResponse(w, http.StatusBadRequest, "Cancelled or expired bookings cannot be transferred")
+		return
+	}
+	if booking.Date.Before(today()) {
+		errorResponse(w, http.StatusBadRequest, "Past bookings cannot be transferred")
+		return
+	}
+	if booking.MemberName == request.MemberName {
+		errorResponse(w, http.StatusBadRequest, "Booking already belongs to this member")
+		return
+	}
+
+	// The new member must not end up holding two spots in the same session
+	if memberHasBooking(request.MemberName, booking.ClassName, booking.Date) {
+		errorResponse(w, http.StatusBadRequest, "Member already has a booking for this class on this date")
+		return
+	}
+
+	// The new member must be allowed in the class as if they booked it themselves
+	transferred := booking
+	transferred.MemberName = request.MemberName
+	var class *Class
+	if booking.ResourceID == 0 {
+		class = findClassOn(booking.ClassName, booking.Date.Time)
+	}
+	if rejection := checkMember(class, request.MemberName, booking.Date.Time); rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+	if _, rejection := checkScheduleConflicts(transferred, id, force); rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+
+	before := booking
+	bookings[index].MemberName = request.MemberName
+
+	actor := actorFromRequest(r)
+	audit := recordAudit(actor, "transfer", "booking", id, before, bookings[index])
+	if err := writeDataToJsonFile("bookings.json", bookings); err != nil {
+		bookings[index] = before
+		retractAudit(audit)
+		errorResponse(w, http.StatusInternalServerError, "Failed to save booking data")
+		return
+	}
+
+	recordBookingEvent(id, bookingEventTransferred, actor, map[string]string{
+		"from": before.MemberName,
+		"to":   request.MemberName,
+	})
+
+	successResponse(w, http.StatusOK, "Booking transferred successfully", bookings[index])
+	logData("Booking transferred successfully", bookings[index])
+}
+
+// Handler for moving a booking to another date of the same class
+func rescheduleBookingHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid booking id")
+		return
+	}
+
+	var request struct {
+		Date Date `json:"date"`
+	}
+	if err := decodeBody(r, &request); err != nil {
+		errorResponse(w, http.StatusBadRequest, bodyErrorMessage(err))
+		return
+	}
+	if request.Date.IsZero() {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if request.Date.Before(today()) {
+		errorResponse(w, http.StatusBadRequest, "Bookings cannot be moved into the past")
+		return
+	}
+	force, rejection := forceRequested(r)
+	if rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := bookingIndex(id)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Booking not found")
+		return
+	}
+	booking := bookings[index]
+	if booking.Status != bookingStatusConfirmed && booking.Status != "" {
+		errorResponse(w, http.StatusBadRequest, "Only confirmed bookings can be rescheduled")
+		return
+	}
+	if booking.Date.Before(today()) {
+		errorResponse(w, http.StatusBadRequest, "Past bookings cannot be rescheduled")
+		return
+	}
+	if booking.ResourceID != 0 {
+		errorResponse(w, http.StatusBadRequest, "Resource bookings cannot be rescheduled, cancel and book another slot")
+		return
+	}
+	if booking.Date == request.Date {
+		errorResponse(w, http.StatusBadRequest, "Booking is already on this date")
+		return
+	}
+	if memberHasBooking(booking.MemberName, booking.ClassName, request.Date) {
+		errorResponse(w, http.StatusBadRequest, "Member already has a booking for this class on this date")
+		return
+	}
+
+	// The new date must be bookable like a fresh booking
+	moved := Booking{MemberName: booking.MemberName, Date: request.Date, ClassName: booking.ClassName, LevelOverride: booking.LevelOverride, Rentals: booking.Rentals}
+	if _, _, rejection := checkBooking(moved); rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+	if _, rejection := checkScheduleConflicts(moved, id, force); rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+
+	before := booking
+	bookings[index].Date = request.Date
+	assignSession(&bookings[index])
+	bookings[index].ActionRequired = ""
+
+	actor := actorFromRequest(r)
+	audit := recordAudit(actor, "reschedule", "booking", id, before, bookings[index])
+	if err := writeDataToJsonFile("bookings.json", bookings); err != nil {
+		bookings[index] = before
+		retractAudit(audit)
+		errorResponse(w, http.StatusInternalServerError, "Failed to save booking data")
+		return
+	}
+
+	recordBookingEvent(id, bookingEventRescheduled, actor, map[string]string{
+		"from": before.Date.String(),
+		"to":   request.Date.String(),
+	})
+	promoteWaitlist(before.ClassName, before.Date)
+
+	successResponse(w, http.StatusOK, "Booking rescheduled successfully", bookings[index])
+	logData("Booking rescheduled successfully", bookings[index])
+}
+
+// checkInBooking marks a booking attended on the day of its session and saves it.
+// Callers must hold the mutex.
+func checkInBooking(index int, actor string) *requestRejection {
+	booking := bookings[index]
+	if rejection := checkTransition(booking, bookingStatusAttended); rejection != nil {
+		return rejection
+	}
+
+	// Members can only check in on the day of the session
+	if booking.Date != dateOf(now()) {
+		return &requestRejection{http.StatusBadRequest, "Check-in is only possible on the day of the class"}
+	}
+
+	before := booking
+	transitionBooking(index, bookingStatusAttended)
+
+	audit := recordAudit(actor, "check-in", "booking", booking.ID, before, bookings[index])
+	if err := writeDataToJsonFile("bookings.json", bookings); err != nil {
+		bookings[index] = before
+		retractAudit(audit)
+		return &requestRejection{http.StatusInternalServerError, "Failed to save booking data"}
+	}
+
+	recordBookingEvent(booking.ID, bookingEventCheckedIn, actor, nil)
+	awardAttendancePoints(bookings[index], actor)
+	return nil
+}
+
+// Handler for checking a member in to a booked session
+func checkInBookingHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid booking id")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := bookingIndex(id)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Booking not found")
+		return
+	}
+	if rejection := checkInBooking(index, actorFromRequest(r)); rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+
+	successResponse(w, http.StatusOK, "Checked in successfully", bookings[index])
+	logData("Checked in successfully", bookings[index])
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestBatchBookingHandler verifies atomic and best-effort batch bookings.
+func TestBatchBookingHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		statusCode int
+		created    int
+		successes  []bool
+	}{
+		{
+			name:       "Atomic Success",
+			body:       `{"className":"Pilates","bookings":[{"memberName":"A","date":"16-12-2024"},{"memberName":"B","date":"17-12-2024"}]}`,
+			statusCode: http.StatusCreated,
+			created:    2,
+			successes:  []bool{true, true},
+		},
+		{
+			name:       "Atomic Failure Books Nothing",
+			body:       `{"className":"Pilates","mode":"atomic","bookings":[{"memberName":"A","date":"16-12-2024"},{"memberName":"B","date":"16-12-2024"},{"memberName":"C","date":"16-12-2024"}]}`,
+			statusCode: http.StatusBadRequest,
+			created:    0,
+			successes:  []bool{false, false, false},
+		},
+		{
+			name:       "Best Effort Partial Success",
+			body:       `{"className":"Pilates","mode":"bestEffort","bookings":[{"memberName":"A","date":"16-12-2024"},{"memberName":"B","date":"25-12-2024"},{"memberName":"C","date":"16-12-2024"},{"memberName":"D","date":"16-12-2024"}]}`,
+			statusCode: http.StatusMultiStatus,
+			created:    2,
+			successes:  []bool{true, false, true, false},
+		},
+		{
+			name:       "Invalid Mode",
+			body:       `{"className":"Pilates","mode":"sometimes","bookings":[{"memberName":"A","date":"16-12-2024"}]}`,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Empty Batch",
+			body:       `{"className":"Pilates","bookings":[]}`,
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestEnvironment()
+			classes = []Class{{ID: 1, ClassName: "Pilates", StartDate: testDate("15-12-2024"), EndDate: testDate("20-12-2024"), Capacity: 2}}
+
+			rec := httptest.NewRecorder()
+			batchBookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings/batch", bytes.NewReader([]byte(tt.body))))
+
+			if rec.Code != tt.statusCode {
+				t.Fatalf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+			if len(bookings) != tt.created {
+				t.Errorf("expected %d bookings to be stored, got %d", tt.created, len(bookings))
+			}
+			if tt.successes == nil {
+				return
+			}
+
+			var response struct {
+				Data struct {
+					Results []batchBookingResult `json:"results"`
+				} `json:"data"`
+			}
+			json.NewDecoder(rec.Body).Decode(&response)
+			if len(response.Data.Results) != len(tt.successes) {
+				t.Fatalf("expected %d results, got %d", len(tt.successes), len(response.Data.Results))
+			}
+			for i, success := range tt.successes {
+				if response.Data.Results[i].Success != success {
+					t.Errorf("expected result %d success to be %v, got %+v", i, success, response.Data.Results[i])
+				}
+			}
+		})
+	}
+}
+
+// TestGroupBookingAndCancellation verifies group bookings consume a slot per
+// attendee and can be cancelled per attendee or as a unit.
+func TestGroupBookingAndCancellation(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Pilates", StartDate: testDate("15-12-2024"), EndDate: testDate("20-12-2024"), Capacity: 4}}
+
+	// A group larger than the remaining capacity is rejected.
+	body := `{"memberName":"Parent","date":"16-12-2024","className":"Pilates","attendees":["Parent","Kid 1","Kid 2","Kid 3","Kid 4"]}`
+	rec := httptest.NewRecorder()
+	groupBookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings/group", bytes.NewReader([]byte(body))))
+	if rec.Code != http.StatusBadRequest || len(bookings) != 0 {
+		t.Fatalf("expected an oversized group to be rejected, got %d with %d bookings", rec.Code, len(bookings))
+	}
+
+	body = `{"memberName":"Parent","date":"16-12-2024","className":"Pilates","attendees":["Parent","Kid 1","Kid 2"]}`
+	rec = httptest.NewRecorder()
+	groupBookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings/group", bytes.NewReader([]byte(body))))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status code %d, got %d", http.StatusCreated, rec.Code)
+	}
+	if countBookings(sessionIDOn("Pilates", testDate("16-12-2024"))) != 3 || bookings[2].GroupID != 1 || bookings[2].PrimaryMember != "Parent" {
+		t.Fatalf("expected 3 linked bookings, got %+v", bookings)
+	}
+
+	cancel := func(id, query string) int {
+		req := httptest.NewRequest(http.MethodPost, "/bookings/"+id+"/cancel"+query, nil)
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+		cancelBookingHandler(rec, req)
+		return rec.Code
+	}
+
+	// Cancelling one attendee frees one slot.
+	if code := cancel("2", ""); code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, code)
+	}
+	if countBookings(sessionIDOn("Pilates", testDate("16-12-2024"))) != 2 {
+		t.Errorf("expected 2 active bookings, got %d", countBookings(sessionIDOn("Pilates", testDate("16-12-2024"))))
+	}
+	if code := cancel("2", ""); code != http.StatusBadRequest {
+		t.Errorf("expected cancelling twice to fail, got %d", code)
+	}
+
+	// Cancelling the group frees the remaining slots.
+	if code := cancel("1", "?group=true"); code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, code)
+	}
+	if countBookings(sessionIDOn("Pilates", testDate("16-12-2024"))) != 0 {
+		t.Errorf("expected no active bookings, got %d", countBookings(sessionIDOn("Pilates", testDate("16-12-2024"))))
+	}
+	if code := cancel("99", ""); code != http.StatusNotFound {
+		t.Errorf("expected an unknown booking to be not found, got %d", code)
+	}
+}
+
+// TestGroupBookingChecksAttendees verifies every attendee must be allowed in the class on their own.
+func TestGroupBookingChecksAttendees(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Pilates", StartDate: testDate("15-12-2099"), EndDate: testDate("20-12-2099"), Capacity: 10, MinAge: 16}}
+	members = []Member{
+		{Name: "Parent", DateOfBirth: "01-01-1980"},
+		{Name: "Teen", DateOfBirth: "01-01-2080"},
+		{Name: "Kid", DateOfBirth: "01-01-2090"},
+		{Name: "Banned", DateOfBirth: "01-01-1980"},
+		{Name: "Booked", DateOfBirth: "01-01-1980"},
+	}
+	blocks = []MemberBlock{{MemberName: "Banned", Reason: "Unpaid fees"}}
+	bookings = []Booking{{ID: 1, MemberName: "Booked", Date: testDate("16-12-2099"), ClassName: "Pilates", Status: bookingStatusConfirmed}}
+	bookingId = 2
+	migrateSessions()
+
+	tests := []struct {
+		name       string
+		attendees  string
+		statusCode int
+	}{
+		{name: "Duplicate Attendee", attendees: `["Parent","Teen","Teen"]`, statusCode: http.StatusBadRequest},
+		{name: "Underage Attendee", attendees: `["Parent","Kid"]`, statusCode: http.StatusForbidden},
+		{name: "Blocked Attendee", attendees: `["Parent","Banned"]`, statusCode: http.StatusForbidden},
+		{name: "Attendee Already Booked", attendees: `["Parent","Booked"]`, statusCode: http.StatusBadRequest},
+		{name: "Allowed Attendees", attendees: `["Parent","Teen"]`, statusCode: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"memberName":"Parent","date":"16-12-2099","className":"Pilates","attendees":` + tt.attendees + `}`
+			rec := httptest.NewRecorder()
+			groupBookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings/group", bytes.NewReader([]byte(body))))
+			if rec.Code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d: %s", tt.statusCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+	if countBookings(sessionIDOn("Pilates", testDate("16-12-2099"))) != 3 {
+		t.Errorf("expected only the allowed group to be booked, got %+v", bookings)
+	}
+}
+
+// TestTransferBookingHandler verifies bookings can be handed to another member.
+func TestTransferBookingHandler(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2024, 12, 16, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	bookings = []Booking{
+		{ID: 1, MemberName: "John Doe", Date: testDate("18-12-2024"), ClassName: "Pilates", Status: bookingStatusConfirmed},
+		{ID: 2, MemberName: "Jane Doe", Date: testDate("18-12-2024"), ClassName: "Pilates", Status: bookingStatusConfirmed},
+		{ID: 3, MemberName: "John Doe", Date: testDate("10-12-2024"), ClassName: "Pilates", Status: bookingStatusConfirmed},
+		{ID: 4, MemberName: "John Doe", Date: testDate("19-12-2024"), ClassName: "Pilates", Status: bookingStatusCancelled},
+		{ID: 5, MemberName: "Busy", Date: testDate("18-12-2024"), ClassName: "Yoga", Status: bookingStatusConfirmed},
+	}
+	classes = []Class{
+		{ID: 1, ClassName: "Pilates", StartDate: testDate("01-12-2024"), EndDate: testDate("31-12-2024"), Capacity: 10, StartTime: "09:30"},
+		{ID: 2, ClassName: "Yoga", StartDate: testDate("01-12-2024"), EndDate: testDate("31-12-2024"), Capacity: 10, StartTime: "09:00", DurationMinutes: 60},
+	}
+	blocks = []MemberBlock{{MemberName: "Banned", Reason: "Unpaid fees"}}
+
+	tests := []struct {
+		name       string
+		id         string
+		body       string
+		statusCode int
+	}{
+		{name: "Duplicate Booking", id: "1", body: `{"memberName":"Jane Doe"}`, statusCode: http.StatusBadRequest},
+		{name: "Past Booking", id: "3", body: `{"memberName":"Alice"}`, statusCode: http.StatusBadRequest},
+		{name: "Cancelled Booking", id: "4", body: `{"memberName":"Alice"}`, statusCode: http.StatusBadRequest},
+		{name: "Same Member", id: "1", body: `{"memberName":"John Doe"}`, statusCode: http.StatusBadRequest},
+		{name: "Unknown Booking", id: "9", body: `{"memberName":"Alice"}`, statusCode: http.StatusNotFound},
+		{name: "Missing Member", id: "1", body: `{}`, statusCode: http.StatusBadRequest},
+		{name: "Blocked Member", id: "1", body: `{"memberName":"Banned"}`, statusCode: http.StatusForbidden},
+		{name: "Schedule Conflict", id: "1", body: `{"memberName":"Busy"}`, statusCode: http.StatusConflict},
+		{name: "Valid Transfer", id: "1", body: `{"memberName":"Alice"}`, statusCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/bookings/"+tt.id+"/transfer", bytes.NewReader([]byte(tt.body)))
+			req.SetPathValue("id", tt.id)
+			rec := httptest.NewRecorder()
+
+			transferBookingHandler(rec, req)
+
+			if rec.Code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+		})
+	}
+
+	if bookings[0].MemberName != "Alice" {
+		t.Errorf("expected booking 1 to belong to Alice, got %s", bookings[0].MemberName)
+	}
+	if len(bookingEvents) != 1 || bookingEvents[0].Type != bookingEventTransferred || bookingEvents[0].Details["from"] != "John Doe" {
+		t.Errorf("expected the transfer in the booking history, got %+v", bookingEvents)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// cachedResponse is a rendered response kept for the requests that follow
+type cachedResponse struct {
+	body      []byte
+	data      interface{} // Data handed to successResponse, for content negotiation
+	hasData   bool
+	expiresAt time.Time
+}
+
+// responseCacheFiles are the data files cached responses are read from.
+// Saving any of them empties the cache.
+var responseCacheFiles = map[string]bool{"classes.json": true, "class_sessions.json": true, "bookings.json": true, "holds.json": true, "reviews.json": true}
+
+var (
+	responseCache      = map[string]cachedResponse{} // Rendered responses by request URI
+	responseCacheGen   int                           // Incremented on every invalidation
+	responseCacheMutex sync.Mutex                    // Guards the cache, apart from the data mutex
+)
+
+// invalidateResponseCache drops every cached response. Responses rendered
+// before the invalidation and stored after it are dropped as well.
+func invalidateResponseCache() {
+	responseCacheMutex.Lock()
+	defer responseCacheMutex.Unlock()
+	responseCache = map[string]cachedResponse{}
+	responseCacheGen++
+}
+
+// cacheResponses serves GET requests to a read-heavy endpoint from memory,
+// rendering a response only when the data behind it has changed or it has
+// been kept for responseCacheSeconds. Clients may keep it as long.
+func cacheResponses(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		seconds := config.ResponseCacheSeconds
+		if r.Method != http.MethodGet || seconds <= 0 {
+			next(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", seconds))
+		key := r.URL.RequestURI()
+
+		responseCacheMutex.Lock()
+		cached, ok := responseCache[key]
+		generation := responseCacheGen
+		responseCacheMutex.Unlock()
+		if ok && now().Before(cached.expiresAt) {
+			w.Header().Set("X-Cache", "HIT")
+			if recorder, isRecorder := w.(*bufferedResponseWriter); isRecorder {
+				recorder.data, recorder.hasData = cached.data, cached.hasData
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write(cached.body)
+			return
+		}
+
+		w.Header().Set("X-Cache", "MISS")
+		recorder := &bufferedResponseWriter{ResponseWriter: w}
+		next(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		if outer, isRecorder := w.(*bufferedResponseWriter); isRecorder {
+			outer.data, outer.hasData = recorder.data, recorder.hasData
+		}
+		// Only successful responses are kept
+		if recorder.status == http.StatusOK {
+			responseCacheMutex.Lock()
+			if generation == responseCacheGen {
+				responseCache[key] = cachedResponse{
+					body:      recorder.body.Bytes(),
+					data:      recorder.data,
+					hasData:   recorder.hasData,
+					expiresAt: now().Add(time.Duration(seconds) * time.Second),
+				}
+			}
+			responseCacheMutex.Unlock()
+		} else {
+			w.Header().Del("Cache-Control")
+		}
+		w.WriteHeader(recorder.status)
+		w.Write(recorder.body.Bytes())
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cachedRequest sends a request through content negotiation to a cached handler
+func cachedRequest(handler http.HandlerFunc, target, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	negotiateContent(cacheResponses(handler)).ServeHTTP(rec, req)
+	return rec
+}
+
+// TestResponseCache verifies class listings are served from memory until a booking or class change, and expire.
+func TestResponseCache(t *testing.T) {
+	setupTestEnvironment()
+	defer func(original func() time.Time) { now = original }(now)
+	current := time.Now()
+	now = func() time.Time { return current }
+	rec := httptest.NewRecorder()
+	classHandler(rec, httptest.NewRequest(http.MethodPost, "/classes", bytes.NewReader([]byte(`{"className":"Yoga","startDate":"01-12-2099","endDate":"02-12-2099","capacity":2}`))))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the class to be created, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	first := cachedRequest(classHandler, "/classes", "")
+	second := cachedRequest(classHandler, "/classes", "")
+	if first.Header().Get("X-Cache") != "MISS" || second.Header().Get("X-Cache") != "HIT" || first.Body.String() != second.Body.String() {
+		t.Fatalf("expected the second listing from the cache, got %q then %q", first.Header().Get("X-Cache"), second.Header().Get("X-Cache"))
+	}
+	if second.Header().Get("Cache-Control") != "private, max-age=5" {
+		t.Errorf("expected clients to keep the listing briefly, got %q", second.Header().Get("Cache-Control"))
+	}
+
+	// A cached response is still sent in the format the client asks for
+	rec = cachedRequest(classHandler, "/classes", formatJSONAPI)
+	if rec.Header().Get("X-Cache") != "HIT" || !strings.Contains(rec.Body.String(), `"type":"classes"`) {
+		t.Errorf("expected a JSON:API document from the cache, got %q: %s", rec.Header().Get("X-Cache"), rec.Body.String())
+	}
+
+	// Booking a place changes the session's availability
+	req := httptest.NewRequest(http.MethodGet, "/classes/1/sessions/01-12-2099", nil)
+	req.SetPathValue("id", "1")
+	req.SetPathValue("date", "01-12-2099")
+	rec = httptest.NewRecorder()
+	cacheResponses(classSessionHandler)(rec, req)
+	rec = httptest.NewRecorder()
+	bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"Ann","className":"Yoga","date":"01-12-2099"}`))))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the booking to be made, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	cacheResponses(classSessionHandler)(rec, req)
+	if rec.Header().Get("X-Cache") != "MISS" || !strings.Contains(rec.Body.String(), `"availableSlots":1`) {
+		t.Errorf("expected the booking to invalidate the session, got %q: %s", rec.Header().Get("X-Cache"), rec.Body.String())
+	}
+
+	current = current.Add(6 * time.Second)
+	if rec := cachedRequest(classHandler, "/classes", ""); rec.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("expected an expired listing to be rendered again, got %q", rec.Header().Get("X-Cache"))
+	}
+
+	// Errors are neither cached nor kept by clients
+	for range 2 {
+		rec = cachedRequest(classHandler, "/classes?limit=x", "")
+		if rec.Code != http.StatusBadRequest || rec.Header().Get("X-Cache") != "MISS" || rec.Header().Get("Cache-Control") != "" {
+			t.Errorf("expected an uncached error, got %d %q %q", rec.Code, rec.Header().Get("X-Cache"), rec.Header().Get("Cache-Control"))
+		}
+	}
+}
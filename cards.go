@@ -0,0 +1,158 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// normalizeCardNumber returns a membership card or barcode number in the form
+// it is stored and looked up in: upper case, without spaces or dashes
+func normalizeCardNumber(number string) (string, bool) {
+	number = strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(number))
+	if len(number) < 4 || len(number) > 32 {
+		return "", false
+	}
+	for _, c := range number {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return "", false
+		}
+	}
+	return number, true
+}
+
+// cardMemberIndex returns the position of the member a card is assigned to, or -1.
+// Callers must hold the mutex.
+func cardMemberIndex(number string) int {
+	for i, member := range members {
+		if member.CardNumber != "" && member.CardNumber == number {
+			return i
+		}
+	}
+	return -1
+}
+
+// Handler for assigning a card number to a member and taking it away again
+func memberCardHandler(w http.ResponseWriter, r *http.Request) {
+	memberName := r.PathValue("name")
+	if memberName == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid member name")
+		return
+	}
+
+	var number string
+	switch r.Method {
+	case http.MethodPut:
+		var request struct {
+			CardNumber string `json:"cardNumber"`
+		}
+		if err := decodeBody(r, &request); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		var ok bool
+		if number, ok = normalizeCardNumber(request.CardNumber); !ok {
+			errorResponse(w, http.StatusBadRequest, "Invalid cardNumber, use 4 to 32 letters and digits")
+			return
+		}
+	case http.MethodDelete:
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := memberIndex(memberName)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Member not found")
+		return
+	}
+	if number == "" && members[index].CardNumber == "" {
+		errorResponse(w, http.StatusNotFound, "Member has no card")
+		return
+	}
+	if holder := cardMemberIndex(number); number != "" && holder >= 0 && holder != index {
+		errorResponse(w, http.StatusConflict, "Card is already assigned to another member")
+		return
+	}
+
+	before := members[index]
+	members[index].CardNumber = number
+	if err := writeDataToJsonFile("members.json", members); err != nil {
+		members[index] = before
+		errorResponse(w, http.StatusInternalServerError, "Failed to save member data")
+		return
+	}
+	recordAudit(actorFromRequest(r), "update", "member", 0, before, members[index])
+
+	successResponse(w, http.StatusOK, "Member card updated successfully", members[index])
+	logData("Member card updated successfully", members[index])
+}
+
+// Handler for card readers: checks the member whose card was swiped in to
+// their next booking today that they have not checked in to yet
+func cardCheckInHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	var request struct {
+		CardNumber string `json:"cardNumber"`
+	}
+	if err := decodeBody(r, &request); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	number, ok := normalizeCardNumber(request.CardNumber)
+	if !ok {
+		errorResponse(w, http.StatusBadRequest, "Invalid cardNumber")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	memberIdx := cardMemberIndex(number)
+	if memberIdx < 0 {
+		errorResponse(w, http.StatusNotFound, "Card not recognised")
+		return
+	}
+	memberName := members[memberIdx].Name
+
+	// Sessions earlier in the day come first, so a member booked twice checks
+	// in to the one about to start
+	day := today()
+	type candidate struct {
+		index     int
+		startTime string
+	}
+	candidates := []candidate{}
+	for i, booking := range bookings {
+		if booking.MemberName != memberName || booking.Date != (Date{day}) || checkTransition(booking, bookingStatusAttended) != nil {
+			continue
+		}
+		startTime := ""
+		if session := findSession(booking.ClassName, booking.Date); session != nil {
+			startTime = resolveSession(*session).StartTime
+		}
+		candidates = append(candidates, candidate{i, startTime})
+	}
+	if len(candidates) == 0 {
+		errorResponse(w, http.StatusNotFound, "No booking to check in to today for "+memberName)
+		return
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].startTime < candidates[j].startTime })
+
+	if rejection := checkInBooking(candidates[0].index, actorFromRequest(r)); rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+
+	booking := bookings[candidates[0].index]
+	successResponse(w, http.StatusOK, "Checked in successfully", booking)
+	logData("Checked in successfully", booking)
+}
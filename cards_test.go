@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestMemberCard verifies card numbers are normalized, unique and kept when the profile is saved.
+func TestMemberCard(t *testing.T) {
+	setupTestEnvironment()
+	members = []Member{{Name: "Ann"}, {Name: "Ben"}}
+
+	assign := func(method, name, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/members/"+name+"/card", bytes.NewReader([]byte(body)))
+		req.SetPathValue("name", name)
+		rec := httptest.NewRecorder()
+		memberCardHandler(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		member     string
+		body       string
+		statusCode int
+	}{
+		{name: "Unknown Member", method: http.MethodPut, member: "Zed", body: `{"cardNumber":"1234"}`, statusCode: http.StatusNotFound},
+		{name: "Too Short", method: http.MethodPut, member: "Ann", body: `{"cardNumber":"12"}`, statusCode: http.StatusBadRequest},
+		{name: "Invalid Characters", method: http.MethodPut, member: "Ann", body: `{"cardNumber":"12#45"}`, statusCode: http.StatusBadRequest},
+		{name: "No Card To Remove", method: http.MethodDelete, member: "Ann", statusCode: http.StatusNotFound},
+		{name: "Assign", method: http.MethodPut, member: "Ann", body: `{"cardNumber":"ab-12 34"}`, statusCode: http.StatusOK},
+		{name: "Assign Again", method: http.MethodPut, member: "Ann", body: `{"cardNumber":"AB1234"}`, statusCode: http.StatusOK},
+		{name: "Taken", method: http.MethodPut, member: "Ben", body: `{"cardNumber":"AB-1234"}`, statusCode: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := assign(tt.method, tt.member, tt.body); rec.Code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d: %s", tt.statusCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+	if members[0].CardNumber != "AB1234" || members[1].CardNumber != "" {
+		t.Fatalf("expected only Ann to hold the card, got %+v", members)
+	}
+
+	// Saving the profile neither sets nor drops the card
+	req := httptest.NewRequest(http.MethodPut, "/members/Ann", bytes.NewReader([]byte(`{"level":"beginner","cardNumber":"9999"}`)))
+	req.SetPathValue("name", "Ann")
+	rec := httptest.NewRecorder()
+	memberProfileHandler(rec, req)
+	if rec.Code != http.StatusOK || members[0].CardNumber != "AB1234" {
+		t.Errorf("expected the profile to keep the card, got %d %+v", rec.Code, members[0])
+	}
+
+	if rec := assign(http.MethodDelete, "Ann", ""); rec.Code != http.StatusOK || members[0].CardNumber != "" {
+		t.Errorf("expected the card to be taken away, got %d %+v", rec.Code, members[0])
+	}
+}
+
+// TestCardCheckIn verifies a swiped card checks the member in to their earliest booking of the day.
+func TestCardCheckIn(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2099, 12, 2, 8, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	members = []Member{{Name: "Ann", CardNumber: "AB1234"}, {Name: "Ben", CardNumber: "CD5678"}}
+	classes = []Class{
+		{ID: 1, ClassName: "Spin", StartDate: testDate("01-12-2099"), EndDate: testDate("03-12-2099"), StartTime: "18:00", Capacity: 5},
+		{ID: 2, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("03-12-2099"), StartTime: "09:00", Capacity: 5},
+	}
+	classId = 3
+	bookings = []Booking{
+		{ID: 1, MemberName: "Ann", ClassName: "Spin", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed},
+		{ID: 2, MemberName: "Ann", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed},
+		{ID: 3, MemberName: "Ben", ClassName: "Yoga", Date: testDate("03-12-2099"), Status: bookingStatusConfirmed},
+	}
+	bookingId = 4
+
+	swipe := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		cardCheckInHandler(rec, httptest.NewRequest(http.MethodPost, "/check-in/card", bytes.NewReader([]byte(body))))
+		return rec
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		statusCode int
+		attended   int
+	}{
+		{name: "Invalid Card", body: `{"cardNumber":"!"}`, statusCode: http.StatusBadRequest},
+		{name: "Unknown Card", body: `{"cardNumber":"ZZ9999"}`, statusCode: http.StatusNotFound},
+		{name: "No Booking Today", body: `{"cardNumber":"CD5678"}`, statusCode: http.StatusNotFound},
+		{name: "Morning Class First", body: `{"cardNumber":"ab-1234"}`, statusCode: http.StatusOK, attended: 2},
+		{name: "Evening Class Next", body: `{"cardNumber":"AB1234"}`, statusCode: http.StatusOK, attended: 1},
+		{name: "All Checked In", body: `{"cardNumber":"AB1234"}`, statusCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := swipe(tt.body)
+			if rec.Code != tt.statusCode {
+				t.Fatalf("expected status code %d, got %d: %s", tt.statusCode, rec.Code, rec.Body.String())
+			}
+			if tt.attended != 0 && bookingStatus(bookings[bookingIndex(tt.attended)]) != bookingStatusAttended {
+				t.Errorf("expected booking %d to be attended, got %+v", tt.attended, bookings)
+			}
+		})
+	}
+}
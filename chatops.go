@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Operational events staff can be alerted about in chat
+const (
+	opsClassNearlyFull    = "classNearlyFull"
+	opsPersistenceFailure = "persistenceFailure"
+	opsClassCancelled     = "classCancelled"
+	opsExtraSession       = "extraSession" // An extra session was opened, or awaits approval
+)
+
+// ChatNotifier posts operational alerts to a staff chat channel
+type ChatNotifier interface {
+	Notify(event, text string)
+}
+
+// chatNotifier receives operational alerts when configured, nil disables them
+var chatNotifier ChatNotifier
+
+// chatWebhook posts alerts to a Slack or Microsoft Teams incoming webhook
+type chatWebhook struct {
+	url    string
+	format string // "slack" or "teams"
+	client *http.Client
+}
+
+// Notify posts the alert in the background so it never slows down requests
+func (c *chatWebhook) Notify(event, text string) {
+	var payload interface{} = map[string]string{"text": text}
+	if c.format == "teams" {
+		payload = map[string]string{
+			"@type":    "MessageCard",
+			"@context": "https://schema.org/extensions",
+			"summary":  event,
+			"text":     text,
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	go func() {
+		resp, err := c.client.Post(c.url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			fmt.Println("Error posting chat alert:", err)
+			return
+		}
+		resp.Body.Close()
+	}()
+}
+
+// configureChatNotifier enables chat alerts when a webhook is configured
+func configureChatNotifier() error {
+	chatNotifier = nil
+	if config.ChatWebhookURL == "" {
+		return nil
+	}
+	if config.ChatWebhookFormat != "slack" && config.ChatWebhookFormat != "teams" {
+		return fmt.Errorf("invalid chatWebhookFormat %q, use slack or teams", config.ChatWebhookFormat)
+	}
+	chatNotifier = &chatWebhook{url: config.ChatWebhookURL, format: config.ChatWebhookFormat, client: &http.Client{Timeout: 5 * time.Second}}
+	return nil
+}
+
+// alertOps sends an operational alert to chat unless its event is turned off.
+// Events missing from config.ChatEvents are on.
+func alertOps(event, text string) {
+	if chatNotifier == nil {
+		return
+	}
+	if enabled, ok := config.ChatEvents[event]; ok && !enabled {
+		return
+	}
+	chatNotifier.Notify(event, text)
+}
+
+// alertIfNearlyFull alerts staff in chat and by email, and integrators with a
+// session.nearlyFull event, when bookings push a session past the nearly full
+// threshold, so another session can be opened in time. Callers must hold the mutex.
+func alertIfNearlyFull(class *Class, date Date, bookedBefore int) {
+	capacity := sessionCapacity(class, date)
+	if capacity <= 0 || config.NearlyFullPercent <= 0 {
+		return
+	}
+	booked := countBookings(sessionIDOn(class.ClassName, date))
+	threshold := capacity * config.NearlyFullPercent
+	if bookedBefore*100 >= threshold || booked*100 < threshold {
+		return
+	}
+	text := fmt.Sprintf("%s on %s is nearly full: %d of %d places booked", class.ClassName, date, booked, capacity)
+	alertOps(opsClassNearlyFull, text)
+	if config.AdminAlertEmail != "" {
+		if err := sendEmail(config.AdminAlertEmail, class.ClassName+" on "+date.String()+" is nearly full", text+"."); err != nil {
+			fmt.Println("Error emailing nearly full alert:", err)
+		}
+	}
+	if index := sessionIndex(class.ID, date); index >= 0 {
+		recordAudit("system", "nearly-full", "session", classSessions[index].ID, nil, viewSession(classSessions[index]))
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingChatNotifier captures operational alerts
+type recordingChatNotifier struct {
+	alerts map[string][]string
+}
+
+// Notify records the alert
+func (c *recordingChatNotifier) Notify(event, text string) {
+	c.alerts[event] = append(c.alerts[event], text)
+}
+
+// TestOpsAlerts verifies staff are alerted to nearly full and cancelled classes.
+func TestOpsAlerts(t *testing.T) {
+	setupTestEnvironment()
+	notifier := &recordingChatNotifier{alerts: map[string][]string{}}
+	defer func() { chatNotifier = nil }()
+	chatNotifier = notifier
+	config.NearlyFullPercent = 75
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 4}}
+	classId = 2
+
+	for _, member := range []string{"Ann", "Ben", "Cat", "Dan"} {
+		rec := httptest.NewRecorder()
+		bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"`+member+`","className":"Yoga","date":"15-12-2099"}`))))
+	}
+	if alerts := notifier.alerts[opsClassNearlyFull]; len(alerts) != 1 || !strings.Contains(alerts[0], "3 of 4") {
+		t.Errorf("expected one alert when the session passed 75%%, got %v", alerts)
+	}
+
+	// Turned-off events stay quiet
+	config.ChatEvents = map[string]bool{opsClassCancelled: false}
+	req := httptest.NewRequest(http.MethodDelete, "/classes/1", nil)
+	req.SetPathValue("id", "1")
+	classItemHandler(httptest.NewRecorder(), req)
+	if len(notifier.alerts[opsClassCancelled]) != 0 {
+		t.Errorf("expected no alert for a turned-off event, got %v", notifier.alerts[opsClassCancelled])
+	}
+
+	config.ChatEvents = nil
+	classes[0].DeletedAt = nil
+	classItemHandler(httptest.NewRecorder(), req)
+	if alerts := notifier.alerts[opsClassCancelled]; len(alerts) != 1 || !strings.Contains(alerts[0], "4 active bookings") {
+		t.Errorf("expected an alert naming the affected bookings, got %v", alerts)
+	}
+
+	reportError("persistence", "Failed to write bookings.json", map[string]string{"error": "disk full"})
+	if alerts := notifier.alerts[opsPersistenceFailure]; len(alerts) != 1 || !strings.Contains(alerts[0], "disk full") {
+		t.Errorf("expected a persistence failure alert, got %v", alerts)
+	}
+}
+
+// TestNearlyFullNotifications verifies nearly full sessions are emailed to admins and published to webhooks once.
+func TestNearlyFullNotifications(t *testing.T) {
+	setupTestEnvironment()
+	config.NearlyFullPercent = 50
+	config.AdminAlertEmail = "ops@example.com"
+	webhooks = []WebhookSubscription{{ID: 1, URL: "http://example.com/hook", Events: []string{"session.nearlyFull"}, Active: true}}
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 4}}
+	classId = 2
+
+	var subjects []string
+	defer func(send func(to, subject, body string) error) { sendEmail = send }(sendEmail)
+	sendEmail = func(to, subject, body string) error {
+		if to == "ops@example.com" {
+			subjects = append(subjects, subject)
+		}
+		return nil
+	}
+
+	for _, member := range []string{"Ann", "Ben", "Cat"} {
+		rec := httptest.NewRecorder()
+		bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"`+member+`","className":"Yoga","date":"15-12-2099"}`))))
+	}
+	if len(subjects) != 1 || subjects[0] != "Yoga on 15-12-2099 is nearly full" {
+		t.Errorf("expected one email when the session passed 50%%, got %v", subjects)
+	}
+	if len(outbox) != 1 || outbox[0].Type != "session.nearlyFull" || outbox[0].Destination != "webhook:1" {
+		t.Fatalf("expected a session.nearlyFull event for the webhook, got %+v", outbox)
+	}
+	var event struct {
+		Data sessionView `json:"data"`
+	}
+	json.Unmarshal(outbox[0].Payload, &event)
+	if event.Data.ClassName != "Yoga" || event.Data.Date != testDate("15-12-2099") || event.Data.Booked != 2 || event.Data.AvailableSlots != 2 {
+		t.Errorf("expected the event to describe the session, got %+v", event.Data)
+	}
+}
+
+// TestChatWebhookFormats verifies the payloads posted to Slack and Teams.
+func TestChatWebhookFormats(t *testing.T) {
+	for _, format := range []string{"slack", "teams"} {
+		t.Run(format, func(t *testing.T) {
+			received := make(chan map[string]string, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var payload map[string]string
+				json.NewDecoder(r.Body).Decode(&payload)
+				received <- payload
+			}))
+			defer server.Close()
+
+			webhook := &chatWebhook{url: server.URL, format: format, client: server.Client()}
+			webhook.Notify(opsClassCancelled, "Yoga was cancelled")
+
+			select {
+			case payload := <-received:
+				if payload["text"] != "Yoga was cancelled" || (format == "teams") != (payload["@type"] == "MessageCard") {
+					t.Errorf("unexpected payload %v", payload)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("expected the alert to be posted")
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// actionOverCapacity flags a booking left beyond its session's places after
+// the class's capacity was lowered
+const actionOverCapacity = "class capacity reduced, choose another date or cancel"
+
+// overCapacitySession is a session holding more bookings than its new capacity
+type overCapacitySession struct {
+	Date     Date `json:"date"`
+	Capacity int  `json:"capacity"`
+	Booked   int  `json:"booked"`
+}
+
+// Handler for changing a class's capacity from today on. Upcoming sessions
+// take the new capacity; added places go to the waitlist, and bookings beyond
+// a lowered capacity are flagged for the member to move or cancel, latest
+// booked first.
+func classCapacityHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid class id")
+		return
+	}
+	var request struct {
+		Capacity int `json:"capacity"`
+	}
+	if err := decodeBody(r, &request); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if request.Capacity <= 0 {
+		errorResponse(w, http.StatusBadRequest, "Invalid capacity, it must be positive")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := classIndex(id)
+	if index < 0 || classes[index].DeletedAt != nil {
+		errorResponse(w, http.StatusNotFound, "Class not found")
+		return
+	}
+	before := classes[index]
+	updated := before
+	updated.Capacity = request.Capacity
+	ensureSessions(before)
+
+	// Every upcoming session must fit its room
+	day := today()
+	upcoming := []int{}
+	for i, session := range classSessions {
+		if session.ClassID != id || session.Date.Before(day) || session.CancelledAt != nil {
+			continue
+		}
+		if conflict := checkRoomCapacity(resolveSession(session).Room, request.Capacity); conflict != nil {
+			conflict.ClassID, conflict.ClassName, conflict.Date = session.ClassID, session.ClassName, session.Date
+			roomConflictResponse(w, *conflict)
+			return
+		}
+		upcoming = append(upcoming, i)
+	}
+	if conflict := checkRoomCapacity(updated.Room, updated.Capacity); conflict != nil {
+		conflict.ClassID, conflict.ClassName = updated.ID, updated.ClassName
+		roomConflictResponse(w, *conflict)
+		return
+	}
+
+	previousClasses := append([]Class(nil), classes...)
+	previousSessions := append([]ClassSession(nil), classSessions...)
+	previousBookings := append([]Booking(nil), bookings...)
+	classes[index] = updated
+	overCapacity := []overCapacitySession{}
+	flagged := []int{}
+	for _, i := range upcoming {
+		session := &classSessions[i]
+		session.Capacity = request.Capacity
+		booked := []int{}
+		for j, booking := range bookings {
+			if booking.SessionID == session.ID && bookingActive(booking) {
+				booked = append(booked, j)
+			}
+		}
+		if len(booked) <= request.Capacity {
+			continue
+		}
+		overCapacity = append(overCapacity, overCapacitySession{session.Date, request.Capacity, len(booked)})
+		// The members who booked last are asked to move
+		sort.Slice(booked, func(a, b int) bool { return bookings[booked[a]].ID < bookings[booked[b]].ID })
+		for _, j := range booked[request.Capacity:] {
+			bookings[j].ActionRequired = actionOverCapacity
+			flagged = append(flagged, j)
+		}
+	}
+	for _, file := range []struct {
+		name string
+		data interface{}
+	}{{"classes.json", classes}, {"class_sessions.json", classSessions}, {"bookings.json", bookings}} {
+		if err := writeDataToJsonFile(file.name, file.data); err != nil {
+			classes, classSessions, bookings = previousClasses, previousSessions, previousBookings
+			// Put back the files already written
+			writeDataToJsonFile("classes.json", classes)
+			writeDataToJsonFile("class_sessions.json", classSessions)
+			errorResponse(w, http.StatusInternalServerError, "Failed to save class data")
+			return
+		}
+	}
+
+	actor := actorFromRequest(r)
+	recordAudit(actor, "update", "class", id, before, updated)
+	flaggedBookings := []Booking{}
+	for _, j := range flagged {
+		booking := bookings[j]
+		flaggedBookings = append(flaggedBookings, booking)
+		notifyMember(booking.MemberName, notifySessionChanged, "Class capacity reduced",
+			fmt.Sprintf("%s on %s now has fewer places and your booking no longer fits. Please choose another date or cancel your booking.", booking.ClassName, booking.Date),
+			map[string]string{"event": notifySessionChanged, "bookingId": strconv.Itoa(booking.ID)})
+	}
+	// Added places go to the waitlist
+	promoted := []Booking{}
+	for _, i := range upcoming {
+		promoted = append(promoted, promoteWaitlist(classSessions[i].ClassName, classSessions[i].Date)...)
+	}
+
+	response := map[string]interface{}{
+		"class":        updated,
+		"sessions":     len(upcoming),
+		"promoted":     promoted,
+		"overCapacity": overCapacity,
+		"flagged":      flaggedBookings,
+	}
+	successResponse(w, http.StatusOK, "Class capacity updated successfully", response)
+	logData("Class capacity updated successfully", response)
+}
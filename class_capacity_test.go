@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestClassCapacity verifies raising a class's capacity promotes the waitlist and lowering it flags the bookings that no longer fit.
+func TestClassCapacity(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2099, 12, 2, 9, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	rooms = []Room{{Name: "Studio", Capacity: 6}}
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("03-12-2099"), Capacity: 2, Room: "Studio"}}
+	classId = 2
+	bookings = []Booking{
+		{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("01-12-2099"), Status: bookingStatusConfirmed},
+		{ID: 2, MemberName: "Ben", ClassName: "Yoga", Date: testDate("01-12-2099"), Status: bookingStatusConfirmed},
+		{ID: 3, MemberName: "Ann", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed},
+		{ID: 4, MemberName: "Ben", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed},
+	}
+	bookingId = 5
+	migrateSessions()
+	waitlist = []WaitlistEntry{{ID: 1, MemberName: "Cat", ClassName: "Yoga", Date: testDate("02-12-2099"), Tier: waitlistTierStandard}}
+	waitlistId = 2
+
+	change := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/classes/1/capacity", bytes.NewReader([]byte(body)))
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+		classCapacityHandler(rec, req)
+		return rec
+	}
+
+	for _, body := range []string{`{"capacity":0}`, `{"capacity":7}`} {
+		if rec := change(body); rec.Code == http.StatusOK {
+			t.Errorf("expected %s to be refused, got %s", body, rec.Body.String())
+		}
+	}
+
+	if rec := change(`{"capacity":4}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected the capacity to be raised, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if classes[0].Capacity != 4 || len(waitlist) != 0 || len(bookings) != 5 || bookings[4].MemberName != "Cat" {
+		t.Fatalf("expected the waitlisted member to be booked, got %+v", bookings)
+	}
+	if session := classSessions[sessionIndex(1, testDate("01-12-2099"))]; session.Capacity != 2 {
+		t.Errorf("expected past sessions to keep their capacity, got %+v", session)
+	}
+
+	bookings = append(bookings, Booking{ID: 6, MemberName: "Dan", ClassName: "Yoga", Date: testDate("03-12-2099"), Status: bookingStatusConfirmed})
+	rec := change(`{"capacity":1}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the capacity to be lowered, got %d: %s", rec.Code, rec.Body.String())
+	}
+	for _, booking := range bookings {
+		expected := ""
+		if booking.Date == testDate("02-12-2099") && booking.MemberName != "Ann" {
+			expected = actionOverCapacity
+		}
+		if booking.ActionRequired != expected {
+			t.Errorf("expected booking %d to be flagged %q, got %q", booking.ID, expected, booking.ActionRequired)
+		}
+	}
+	if session := classSessions[sessionIndex(1, testDate("02-12-2099"))]; session.Capacity != 1 {
+		t.Errorf("expected the session to take the new capacity, got %+v", session)
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Modes for rescheduling a class with bookings
+const (
+	rescheduleStrict  = "strict"  // Bookings stay on their dates, and the change is refused if any would be left without a session
+	rescheduleMigrate = "migrate" // Upcoming bookings move with the class, and those left without a session are flagged
+)
+
+// actionRescheduleClass flags a booking whose session no longer exists after its class was rescheduled
+const actionRescheduleClass = "class rescheduled, choose another date or cancel"
+
+// classRescheduleRequest moves a class to new dates or a new start time
+type classRescheduleRequest struct {
+	StartDate Date    `json:"startDate"` // Unchanged when omitted
+	EndDate   Date    `json:"endDate"`   // Unchanged when omitted
+	StartTime *string `json:"startTime"` // Unchanged when omitted
+	Mode      string  `json:"mode"`      // "strict" (default) or "migrate"
+}
+
+// Handler for moving a class to new dates or a new start time
+func rescheduleClassHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid class id")
+		return
+	}
+	var request classRescheduleRequest
+	if err := decodeBody(r, &request); err != nil {
+		errorResponse(w, http.StatusBadRequest, bodyErrorMessage(err))
+		return
+	}
+	if request.Mode == "" {
+		request.Mode = rescheduleStrict
+	}
+	if request.Mode != rescheduleStrict && request.Mode != rescheduleMigrate {
+		errorResponse(w, http.StatusBadRequest, "Invalid mode, use strict or migrate")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := classIndex(id)
+	if index < 0 || classes[index].DeletedAt != nil {
+		errorResponse(w, http.StatusNotFound, "Class not found")
+		return
+	}
+	before := classes[index]
+	updated := before
+	if !request.StartDate.IsZero() {
+		updated.StartDate = request.StartDate
+	}
+	if !request.EndDate.IsZero() {
+		updated.EndDate = request.EndDate
+	}
+	if request.StartTime != nil {
+		updated.StartTime = *request.StartTime
+	}
+	if rejection := checkClass(updated); rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+
+	// Migrated bookings and sessions move by as many days as the start date
+	oldStart, newStart, newEnd := before.StartDate.Time, updated.StartDate.Time, updated.EndDate.Time
+	shift := 0
+	if request.Mode == rescheduleMigrate {
+		shift = int(newStart.Sub(oldStart).Hours() / 24)
+	}
+	day := today()
+	// moveDate returns where an upcoming date of the class goes, and whether
+	// the new schedule still has a session there
+	moveDate := func(date Date) (Date, bool) {
+		moved := date.AddDate(0, 0, shift)
+		return Date{moved}, !moved.Before(newStart) && !moved.After(newEnd) && !moved.Before(day)
+	}
+	upcoming := func(date Date) bool {
+		return !date.Before(day) && classCovers(before, Booking{ClassName: before.ClassName, Date: date})
+	}
+
+	// Work out where each upcoming booking goes before changing anything
+	type bookingMove struct {
+		index int
+		date  Date
+		kept  bool
+	}
+	var moves []bookingMove
+	var stranded []Booking
+	for i, booking := range bookings {
+		if booking.ClassName != before.ClassName || !bookingActive(booking) || !upcoming(booking.Date) {
+			continue
+		}
+		date, kept := moveDate(booking.Date)
+		moves = append(moves, bookingMove{i, date, kept})
+		if !kept {
+			stranded = append(stranded, booking)
+		}
+	}
+	if request.Mode == rescheduleStrict && len(stranded) > 0 {
+		message := "Bookings would be left without a session, use the migrate mode or move them first"
+		// The envelope lists the bookings in the way
+		errorDetailsResponse(w, http.StatusConflict, message, map[string]interface{}{"bookings": stranded})
+		return
+	}
+
+	previousClasses := append([]Class(nil), classes...)
+	previousSessions := append([]ClassSession(nil), classSessions...)
+	previousBookings := append([]Booking(nil), bookings...)
+	previousWaitlist := append([]WaitlistEntry(nil), waitlist...)
+	previousSessionId := classSessionId
+	rollback := func() {
+		classes, classSessions, bookings, waitlist, classSessionId = previousClasses, previousSessions, previousBookings, previousWaitlist, previousSessionId
+	}
+
+	// Upcoming sessions keep their capacity and changes as they move; past ones stay as history
+	classes[index] = updated
+	keptSessions := []ClassSession{}
+	for _, session := range classSessions {
+		if session.ClassID == id && upcoming(session.Date) {
+			date, kept := moveDate(session.Date)
+			if !kept {
+				continue
+			}
+			session.Date = date
+		}
+		keptSessions = append(keptSessions, session)
+	}
+	classSessions = keptSessions
+	ensureSessions(updated)
+
+	for _, move := range moves {
+		if !move.kept {
+			bookings[move.index].ActionRequired = actionRescheduleClass
+			bookings[move.index].SessionID = 0
+			continue
+		}
+		bookings[move.index].Date = move.date
+		assignSession(&bookings[move.index])
+	}
+	keptWaitlist, droppedWaitlist := []WaitlistEntry{}, []WaitlistEntry{}
+	for _, entry := range waitlist {
+		if entry.ClassName == before.ClassName && upcoming(entry.Date) {
+			date, kept := moveDate(entry.Date)
+			if !kept {
+				droppedWaitlist = append(droppedWaitlist, entry)
+				continue
+			}
+			entry.Date = date
+		}
+		keptWaitlist = append(keptWaitlist, entry)
+	}
+	waitlist = keptWaitlist
+
+	// The events announcing the changes are saved before the changes
+	actor := actorFromRequest(r)
+	audit := recordAudit(actor, "reschedule", "class", id, before, updated)
+	for _, move := range moves {
+		if previous, booking := previousBookings[move.index], bookings[move.index]; move.kept && previous.Date != booking.Date {
+			recordAudit(actor, "reschedule", "booking", booking.ID, previous, booking)
+		}
+	}
+
+	for _, file := range []struct {
+		name string
+		data interface{}
+	}{{"classes.json", classes}, {"class_sessions.json", classSessions}, {"bookings.json", bookings}, {"waitlist.json", waitlist}} {
+		if err := writeDataToJsonFile(file.name, file.data); err != nil {
+			rollback()
+			// Put back the files already written
+			writeDataToJsonFile("classes.json", classes)
+			writeDataToJsonFile("class_sessions.json", classSessions)
+			writeDataToJsonFile("bookings.json", bookings)
+			retractAudit(audit)
+			errorResponse(w, http.StatusInternalServerError, "Failed to save class data")
+			return
+		}
+	}
+
+	recordWaitlistOutcomes(droppedWaitlist, waitlistCancelled)
+
+	migrated, flagged := []Booking{}, []Booking{}
+	for _, move := range moves {
+		booking := bookings[move.index]
+		data := map[string]string{"event": notifySessionChanged, "bookingId": strconv.Itoa(booking.ID)}
+		if !move.kept {
+			flagged = append(flagged, booking)
+			notifyMember(booking.MemberName, notifySessionChanged, "Class rescheduled",
+				fmt.Sprintf("%s has been rescheduled and your session on %s no longer runs. Please choose another date or cancel your booking.", booking.ClassName, booking.Date), data)
+			continue
+		}
+		previous := previousBookings[move.index]
+		if previous.Date == booking.Date && before.StartTime == updated.StartTime {
+			continue
+		}
+		migrated = append(migrated, booking)
+		if previous.Date != booking.Date {
+			recordBookingEvent(booking.ID, bookingEventRescheduled, actor, map[string]string{"from": previous.Date.String(), "to": booking.Date.String(), "reason": "class rescheduled"})
+		}
+		message := fmt.Sprintf("%s has been rescheduled. You are now booked in on %s", booking.ClassName, booking.Date)
+		if startTime := resolveSession(classSessions[sessionIndex(id, booking.Date)]).StartTime; startTime != "" {
+			message += " at " + startTime
+		}
+		notifyMember(booking.MemberName, notifySessionChanged, "Class rescheduled", message+".", data)
+	}
+
+	response := map[string]interface{}{
+		"class":    updated,
+		"migrated": migrated,
+		"flagged":  flagged,
+	}
+	successResponse(w, http.StatusOK, "Class rescheduled successfully", response)
+	logData("Class rescheduled successfully", response)
+}
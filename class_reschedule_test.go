@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRescheduleClass verifies rescheduling moves compatible bookings with the class and flags the rest.
+func TestRescheduleClass(t *testing.T) {
+	setupTestEnvironment()
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2099, 12, 1, 9, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		dates          []string // Dates of the bookings afterwards
+		flagged        int
+	}{
+		{"Strict Shrink", `{"endDate":"05-12-2099"}`, http.StatusConflict, []string{"03-12-2099", "06-12-2099", "07-12-2099"}, 0},
+		{"Strict Time", `{"startTime":"18:00"}`, http.StatusOK, []string{"03-12-2099", "06-12-2099", "07-12-2099"}, 0},
+		{"Invalid Mode", `{"startDate":"03-12-2099","mode":"move"}`, http.StatusBadRequest, []string{"03-12-2099", "06-12-2099", "07-12-2099"}, 0},
+		{"Invalid Time", `{"startTime":"noon"}`, http.StatusBadRequest, []string{"03-12-2099", "06-12-2099", "07-12-2099"}, 0},
+		// The last session would move past the new end date
+		{"Migrate A Day Later", `{"startDate":"03-12-2099","mode":"migrate"}`, http.StatusOK, []string{"04-12-2099", "07-12-2099", "07-12-2099"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("02-12-2099"), EndDate: testDate("07-12-2099"), Capacity: 5, StartTime: "09:00"}}
+			classId = 2
+			classSessions, classSessionId = []ClassSession{}, 1
+			bookings = []Booking{
+				{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("03-12-2099"), Status: bookingStatusConfirmed},
+				{ID: 2, MemberName: "Ben", ClassName: "Yoga", Date: testDate("06-12-2099"), Status: bookingStatusConfirmed},
+				{ID: 3, MemberName: "Cat", ClassName: "Yoga", Date: testDate("07-12-2099"), Status: bookingStatusConfirmed},
+			}
+			bookingId = 4
+			ensureSessions(classes[0])
+
+			req := httptest.NewRequest(http.MethodPost, "/classes/1/reschedule", bytes.NewReader([]byte(tt.body)))
+			req.SetPathValue("id", "1")
+			rec := httptest.NewRecorder()
+			rescheduleClassHandler(rec, req)
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+			flagged := 0
+			for i, booking := range bookings {
+				if booking.Date.String() != tt.dates[i] {
+					t.Errorf("expected booking %d on %s, got %s", booking.ID, tt.dates[i], booking.Date)
+				}
+				if booking.ActionRequired != "" {
+					flagged++
+				}
+			}
+			if flagged != tt.flagged {
+				t.Errorf("expected %d bookings to be flagged, got %d", tt.flagged, flagged)
+			}
+		})
+	}
+
+	// Migrated bookings follow their sessions, and the new dates have sessions of their own
+	if bookings[0].SessionID != classSessions[sessionIndex(1, testDate("04-12-2099"))].ID || sessionIndex(1, testDate("02-12-2099")) >= 0 || len(classSessions) != 5 {
+		t.Errorf("expected the sessions to move with the class, got %+v", classSessions)
+	}
+
+	// A flagged member moving their booking clears the flag
+	req := httptest.NewRequest(http.MethodPost, "/bookings/3/reschedule", bytes.NewReader([]byte(`{"date":"05-12-2099"}`)))
+	req.SetPathValue("id", "3")
+	rec := httptest.NewRecorder()
+	rescheduleBookingHandler(rec, req)
+	if rec.Code != http.StatusOK || bookings[2].ActionRequired != "" {
+		t.Errorf("expected the flag to be cleared, got %d: %+v", rec.Code, bookings[2])
+	}
+}
@@ -0,0 +1,597 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClassSession is one dated occurrence of a class. A Class is the series: the
+// details shared by every date from its startDate to its endDate. Capacity,
+// bookings and cancellations belong to the session.
+type ClassSession struct {
+	ID        int    `json:"id"`
+	ClassID   int    `json:"classId"` // Series the session belongs to
+	ClassName string `json:"className"`
+	Date      Date   `json:"date"`     // DD-MM-YYYY on the wire
+	Capacity  int    `json:"capacity"` // Starts as the class's capacity and can be overridden per session
+	// Changes to this session alone; empty values follow the class
+	StartTime    string     `json:"startTime,omitempty"`
+	Room         string     `json:"room,omitempty"`
+	Instructor   string     `json:"instructor,omitempty"` // e.g. a cover for the class's instructor
+	CancelledAt  *time.Time `json:"cancelledAt,omitempty"`
+	CancelReason string     `json:"cancelReason,omitempty"`
+}
+
+// Policies for the bookings of a cancelled session
+const (
+	sessionPolicyCancel = "cancel" // Bookings are cancelled
+	sessionPolicyRebook = "rebook" // Bookings move to the next session with a free place, or are cancelled
+)
+
+var (
+	classSessions  []ClassSession // Temp Slice to hold class sessions
+	classSessionId = 1            // Incremental ID for class sessions
+)
+
+// sessionIndex returns the position of a class's session on a date, or -1.
+// Callers must hold the mutex.
+func sessionIndex(classID int, date Date) int {
+	for i, session := range classSessions {
+		if session.ClassID == classID && session.Date == date {
+			return i
+		}
+	}
+	return -1
+}
+
+// ensureSessions creates the sessions a class is missing, one per date of its
+// range, and saves them. It returns how many were created.
+// Callers must hold the mutex.
+func ensureSessions(class Class) int {
+	if class.StartDate.IsZero() || class.EndDate.IsZero() {
+		return 0
+	}
+
+	existing := map[Date]bool{}
+	for _, session := range classSessions {
+		if session.ClassID == class.ID {
+			existing[session.Date] = true
+		}
+	}
+	created := 0
+	for day := class.StartDate.Time; !day.After(class.EndDate.Time); day = day.AddDate(0, 0, 1) {
+		date := Date{day}
+		if existing[date] {
+			continue
+		}
+		classSessions = append(classSessions, ClassSession{ID: classSessionId, ClassID: class.ID, ClassName: class.ClassName, Date: date, Capacity: class.Capacity})
+		classSessionId++
+		created++
+	}
+	if created > 0 {
+		if err := writeDataToJsonFile("class_sessions.json", classSessions); err != nil {
+			fmt.Println("Error saving class sessions:", err)
+		}
+	}
+	return created
+}
+
+// findSession returns the session of the class running on a date, or nil.
+// Callers must hold the mutex and must not keep the pointer across changes
+// to classSessions.
+func findSession(className string, date Date) *ClassSession {
+	class := findClassOn(className, date.Time)
+	if class == nil {
+		return nil
+	}
+	index := sessionIndex(class.ID, date)
+	if index < 0 {
+		ensureSessions(*class)
+		index = sessionIndex(class.ID, date)
+	}
+	if index < 0 {
+		return nil
+	}
+	return &classSessions[index]
+}
+
+// sessionAt returns the stored session of a class on a date.
+// Callers must hold the mutex.
+func sessionAt(class Class, date Date) (ClassSession, bool) {
+	if index := sessionIndex(class.ID, date); index >= 0 {
+		return classSessions[index], true
+	}
+	return ClassSession{}, false
+}
+
+// sessionInstructor returns who teaches a class on a date, which is the
+// class's instructor unless the session has a cover. Callers must hold the mutex.
+func sessionInstructor(class Class, date Date) string {
+	if session, ok := sessionAt(class, date); ok && session.Instructor != "" {
+		return session.Instructor
+	}
+	return class.Instructor
+}
+
+// resolveSession fills the details a session leaves to its class.
+// Callers must hold the mutex.
+func resolveSession(session ClassSession) ClassSession {
+	index := classIndex(session.ClassID)
+	if index < 0 {
+		return session
+	}
+	class := classes[index]
+	if session.StartTime == "" {
+		session.StartTime = class.StartTime
+	}
+	if session.Room == "" {
+		session.Room = class.Room
+	}
+	if session.Instructor == "" {
+		session.Instructor = class.Instructor
+	}
+	return session
+}
+
+// sessionCapacity returns the capacity of a class's session on a date, or
+// the class's own capacity when the date cannot be resolved.
+// Callers must hold the mutex.
+func sessionCapacity(class *Class, date Date) int {
+	index := sessionIndex(class.ID, date)
+	if index < 0 && ensureSessions(*class) > 0 {
+		index = sessionIndex(class.ID, date)
+	}
+	if index < 0 {
+		return class.Capacity
+	}
+	return classSessions[index].Capacity
+}
+
+// sessionIDOn returns the ID of the session of the class running on a date,
+// or 0 when there is none. Callers must hold the mutex.
+func sessionIDOn(className string, date Date) int {
+	if session := findSession(className, date); session != nil {
+		return session.ID
+	}
+	return 0
+}
+
+// assignSession records the session a booking attends and its class, or
+// clears them when there is none. Callers must hold the mutex.
+func assignSession(booking *Booking) {
+	booking.SessionID, booking.ClassID = 0, 0
+	if session := findSession(booking.ClassName, booking.Date); session != nil {
+		booking.SessionID, booking.ClassID = session.ID, session.ClassID
+	}
+}
+
+// migrateSessions gives classes, bookings and holds saved before sessions
+// existed their sessions. Callers must hold the mutex once the server is running.
+func migrateSessions() {
+	for _, class := range classes {
+		if class.DeletedAt == nil {
+			ensureSessions(class)
+		}
+	}
+	linked := 0
+	for i := range bookings {
+		if bookings[i].SessionID == 0 || bookings[i].ClassID == 0 {
+			before := bookings[i]
+			assignSession(&bookings[i])
+			if bookings[i].SessionID != before.SessionID || bookings[i].ClassID != before.ClassID {
+				linked++
+			}
+		}
+	}
+	if linked > 0 {
+		if err := writeDataToJsonFile("bookings.json", bookings); err != nil {
+			fmt.Println("Error saving bookings:", err)
+		}
+	}
+	linked = 0
+	for i := range holds {
+		if holds[i].SessionID == 0 {
+			if holds[i].SessionID = sessionIDOn(holds[i].ClassName, holds[i].Date); holds[i].SessionID != 0 {
+				linked++
+			}
+		}
+	}
+	if linked > 0 {
+		if err := writeDataToJsonFile("holds.json", holds); err != nil {
+			fmt.Println("Error saving holds:", err)
+		}
+	}
+}
+
+// splitSessions separates the sessions of the given classes from the rest.
+// Callers must hold the mutex.
+func splitSessions(removed []Class) (kept, split []ClassSession) {
+	ids := map[int]bool{}
+	for _, class := range removed {
+		ids[class.ID] = true
+	}
+	kept = []ClassSession{}
+	for _, session := range classSessions {
+		if ids[session.ClassID] {
+			split = append(split, session)
+		} else {
+			kept = append(kept, session)
+		}
+	}
+	return kept, split
+}
+
+// sessionView is a session with its current bookings
+type sessionView struct {
+	ClassSession
+	Booked         int `json:"booked"`
+	AvailableSlots int `json:"availableSlots"`
+}
+
+// viewSession adds the details inherited from the class and the booking
+// counts to a session. Callers must hold the mutex.
+func viewSession(session ClassSession) sessionView {
+	booked := countBookings(session.ID)
+	return sessionView{ClassSession: resolveSession(session), Booked: booked, AvailableSlots: max(session.Capacity-booked-countHolds(session.ID), 0)}
+}
+
+// Handler for listing the sessions of a class
+func classSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid class id")
+		return
+	}
+	page, err := paginationParams(r)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	mutex.Lock()
+	index := classIndex(id)
+	if index < 0 || classes[index].DeletedAt != nil {
+		mutex.Unlock()
+		errorResponse(w, http.StatusNotFound, "Class not found")
+		return
+	}
+	ensureSessions(classes[index])
+	views := []sessionView{}
+	for _, session := range classSessions {
+		if session.ClassID == id {
+			views = append(views, viewSession(session))
+		}
+	}
+	mutex.Unlock()
+
+	items, nextCursor := paginate(views, page, func(view sessionView) pageCursor {
+		return pageCursor{ID: view.ID}
+	}, false)
+	response := map[string]interface{}{
+		"sessions":   items,
+		"total":      len(views),
+		"limit":      page.Limit,
+		"offset":     page.Offset,
+		"nextCursor": nextCursor,
+	}
+	successResponse(w, http.StatusOK, "Class sessions retrieved successfully", response)
+}
+
+// Handler for reading a single session and overriding its capacity
+func classSessionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid class id")
+		return
+	}
+	date, err := parseDate(r.PathValue("date"))
+	if err != nil || date.IsZero() {
+		errorResponse(w, http.StatusBadRequest, "Invalid date format, use DD-MM-YYYY")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index, rejection := lookupSession(id, date)
+		if rejection != nil {
+			errorResponse(w, rejection.StatusCode, rejection.Message)
+			return
+		}
+		successResponse(w, http.StatusOK, "Class session retrieved successfully", viewSession(classSessions[index]))
+
+	case http.MethodPut:
+		// Only the fields sent are changed; an empty startTime, room or
+		// instructor returns the session to the class's
+		var request struct {
+			Capacity   *int    `json:"capacity"`
+			StartTime  *string `json:"startTime"`
+			Room       *string `json:"room"`
+			Instructor *string `json:"instructor"`
+		}
+		if err := decodeBody(r, &request); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if request.Capacity != nil && *request.Capacity <= 0 {
+			errorResponse(w, http.StatusBadRequest, "Invalid capacity, it must be positive")
+			return
+		}
+		if request.StartTime != nil && *request.StartTime != "" {
+			if _, err := time.Parse(timeLayout, *request.StartTime); err != nil {
+				errorResponse(w, http.StatusBadRequest, "Invalid startTime format, use HH:MM")
+				return
+			}
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index, rejection := lookupSession(id, date)
+		if rejection != nil {
+			errorResponse(w, rejection.StatusCode, rejection.Message)
+			return
+		}
+		session := classSessions[index]
+		if session.CancelledAt != nil {
+			errorResponse(w, http.StatusConflict, "Class session is cancelled")
+			return
+		}
+		updated := session
+		if request.Capacity != nil {
+			// Members already booked keep their places
+			if taken := countBookings(session.ID) + countHolds(session.ID); *request.Capacity < taken {
+				errorResponse(w, http.StatusConflict, fmt.Sprintf("Capacity cannot be below the %d places already taken", taken))
+				return
+			}
+			updated.Capacity = *request.Capacity
+		}
+		if request.StartTime != nil {
+			updated.StartTime = *request.StartTime
+		}
+		if request.Room != nil {
+			updated.Room = *request.Room
+		}
+		if request.Instructor != nil {
+			updated.Instructor = *request.Instructor
+		}
+		// The session must fit its room, whether the capacity or the room changed
+		if conflict := checkRoomCapacity(resolveSession(updated).Room, updated.Capacity); conflict != nil {
+			conflict.ClassID, conflict.ClassName, conflict.Date = session.ClassID, session.ClassName, session.Date
+			roomConflictResponse(w, *conflict)
+			return
+		}
+		if instructor := resolveSession(updated).Instructor; instructor != resolveSession(session).Instructor && instructor != "" && instructorUnavailable(instructor, session.Date) {
+			errorResponse(w, http.StatusConflict, "Instructor is unavailable on this date")
+			return
+		}
+
+		classSessions[index] = updated
+		if err := writeDataToJsonFile("class_sessions.json", classSessions); err != nil {
+			classSessions[index] = session
+			errorResponse(w, http.StatusInternalServerError, "Failed to save class session data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "update", "class-session", session.ID, session, updated)
+
+		// Added places go to the waitlist, and booked members hear about anything else
+		promoteWaitlist(session.ClassName, session.Date)
+		if changes := sessionChanges(resolveSession(session), resolveSession(updated)); changes != "" {
+			for _, booking := range bookings {
+				if booking.SessionID == session.ID && bookingActive(booking) {
+					notifyMember(booking.MemberName, notifySessionChanged, "Class changed",
+						fmt.Sprintf("%s on %s has changed: %s.", session.ClassName, session.Date, changes),
+						map[string]string{"event": notifySessionChanged, "bookingId": strconv.Itoa(booking.ID)})
+				}
+			}
+		}
+
+		successResponse(w, http.StatusOK, "Class session updated successfully", viewSession(updated))
+		logData("Class session updated successfully", updated)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// lookupSession returns the position of a class's session on a date.
+// Callers must hold the mutex.
+func lookupSession(classID int, date Date) (int, *requestRejection) {
+	index := classIndex(classID)
+	if index < 0 || classes[index].DeletedAt != nil {
+		return -1, &requestRejection{http.StatusNotFound, "Class not found"}
+	}
+	ensureSessions(classes[index])
+	session := sessionIndex(classID, date)
+	if session < 0 {
+		return -1, &requestRejection{http.StatusNotFound, "Class does not run on this date"}
+	}
+	return session, nil
+}
+
+// sessionChanges describes the changes members notice between two resolved
+// versions of a session, or returns "" when there are none
+func sessionChanges(before, after ClassSession) string {
+	var changes []string
+	if before.StartTime != after.StartTime {
+		changes = append(changes, "it now starts at "+after.StartTime)
+	}
+	if before.Room != after.Room {
+		changes = append(changes, "it is now in "+after.Room)
+	}
+	if before.Instructor != after.Instructor {
+		changes = append(changes, "it is now taught by "+after.Instructor)
+	}
+	return strings.Join(changes, ", ")
+}
+
+// Handler for cancelling a single session of a class. Its bookings are
+// cancelled or rebooked according to the session cancellation policy.
+func cancelSessionHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid class id")
+		return
+	}
+	date, err := parseDate(r.PathValue("date"))
+	if err != nil || date.IsZero() {
+		errorResponse(w, http.StatusBadRequest, "Invalid date format, use DD-MM-YYYY")
+		return
+	}
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	// The reason is optional, so an empty body is accepted
+	if err := decodeBody(r, &request); err != nil && err != io.EOF {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index, rejection := lookupSession(id, date)
+	if rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+	session := classSessions[index]
+	if session.CancelledAt != nil {
+		errorResponse(w, http.StatusConflict, "Class session is already cancelled")
+		return
+	}
+
+	cancelledAt := now()
+	classSessions[index].CancelledAt = &cancelledAt
+	classSessions[index].CancelReason = request.Reason
+
+	// Bookings that can still be cancelled are affected, attended ones stay as they are
+	var targets []int
+	for i, booking := range bookings {
+		if booking.SessionID == session.ID && checkTransition(booking, bookingStatusCancelled) == nil {
+			targets = append(targets, i)
+		}
+	}
+	before := make([]Booking, len(targets))
+	rebooked := make([]bool, len(targets))
+	for i, target := range targets {
+		before[i] = bookings[target]
+		if config.SessionCancellationPolicy == sessionPolicyRebook {
+			rebooked[i] = rebookOnNextSession(target, id, date)
+		}
+		if !rebooked[i] {
+			transitionBooking(target, bookingStatusCancelled)
+		}
+	}
+	// Nobody waits for a session that will not run
+	keptWaitlist, droppedWaitlist := []WaitlistEntry{}, []WaitlistEntry{}
+	for _, entry := range waitlist {
+		if entry.ClassName != session.ClassName || entry.Date != session.Date {
+			keptWaitlist = append(keptWaitlist, entry)
+		} else {
+			droppedWaitlist = append(droppedWaitlist, entry)
+		}
+	}
+	previousWaitlist := waitlist
+	waitlist = keptWaitlist
+
+	rollback := func() {
+		classSessions[index] = session
+		for i, target := range targets {
+			bookings[target] = before[i]
+		}
+		waitlist = previousWaitlist
+	}
+
+	// The events announcing the changes are saved before the changes
+	actor := actorFromRequest(r)
+	audit := recordAudit(actor, "cancel", "class-session", session.ID, session, classSessions[index])
+	for i, target := range targets {
+		if rebooked[i] {
+			recordAudit(actor, "reschedule", "booking", bookings[target].ID, before[i], bookings[target])
+		} else {
+			recordAudit(actor, "cancel", "booking", bookings[target].ID, before[i], bookings[target])
+		}
+	}
+	if err := writeDataToJsonFile("bookings.json", bookings); err != nil {
+		rollback()
+		retractAudit(audit)
+		errorResponse(w, http.StatusInternalServerError, "Failed to save booking data")
+		return
+	}
+	if err := writeDataToJsonFile("class_sessions.json", classSessions); err != nil {
+		rollback()
+		writeDataToJsonFile("bookings.json", bookings)
+		retractAudit(audit)
+		errorResponse(w, http.StatusInternalServerError, "Failed to save class session data")
+		return
+	}
+	if err := writeDataToJsonFile("waitlist.json", waitlist); err != nil {
+		fmt.Println("Error saving waitlist:", err)
+	}
+	recordWaitlistOutcomes(droppedWaitlist, waitlistCancelled)
+
+	cancelled, moved := []Booking{}, []Booking{}
+	for i, target := range targets {
+		booking := bookings[target]
+		if rebooked[i] {
+			moved = append(moved, booking)
+			recordBookingEvent(booking.ID, bookingEventRescheduled, actor, map[string]string{"from": before[i].Date.String(), "to": booking.Date.String(), "reason": "session cancelled"})
+			notifyMember(booking.MemberName, notifySessionChanged, "Class moved",
+				fmt.Sprintf("%s on %s was cancelled, so you are now booked in on %s.", booking.ClassName, before[i].Date, booking.Date),
+				map[string]string{"event": notifySessionChanged, "bookingId": strconv.Itoa(booking.ID)})
+			continue
+		}
+		cancelled = append(cancelled, booking)
+		recordBookingEvent(booking.ID, bookingEventCancelled, actor, map[string]string{"reason": "session cancelled"})
+		notifyBooking(notifyBookingCancelled, booking)
+	}
+	alertOps(opsClassCancelled, fmt.Sprintf("Class %s on %s was cancelled by %s with %d active bookings", session.ClassName, session.Date, actor, len(targets)))
+
+	response := map[string]interface{}{
+		"session":   viewSession(classSessions[index]),
+		"cancelled": cancelled,
+		"rebooked":  moved,
+	}
+	successResponse(w, http.StatusOK, "Class session cancelled successfully", response)
+	logData("Class session cancelled successfully", response)
+}
+
+// rebookOnNextSession moves a booking to the first later session of the class
+// it can be booked into, and reports whether it found one.
+// Callers must hold the mutex.
+func rebookOnNextSession(index, classID int, from Date) bool {
+	class := classes[classIndex(classID)]
+	booking := bookings[index]
+	for day := from.AddDate(0, 0, 1); !day.After(class.EndDate.Time); day = day.AddDate(0, 0, 1) {
+		date := Date{day}
+		if memberHasBooking(booking.MemberName, booking.ClassName, date) {
+			continue
+		}
+		if _, _, rejection := checkBooking(Booking{MemberName: booking.MemberName, Date: date, ClassName: booking.ClassName, LevelOverride: booking.LevelOverride, Rentals: booking.Rentals}); rejection != nil {
+			continue
+		}
+		bookings[index].Date = date
+		assignSession(&bookings[index])
+		return true
+	}
+	return false
+}
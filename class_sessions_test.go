@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestClassSessions verifies classes are split into per-date sessions whose capacity can be overridden.
+func TestClassSessions(t *testing.T) {
+	setupTestEnvironment()
+	rec := httptest.NewRecorder()
+	classHandler(rec, httptest.NewRequest(http.MethodPost, "/classes", bytes.NewReader([]byte(`{"className":"Yoga","startDate":"01-12-2099","endDate":"10-12-2099","capacity":2}`))))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the class to be created, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(classSessions) != 10 || classSessions[0].Date != testDate("01-12-2099") || classSessions[9].Capacity != 2 {
+		t.Fatalf("expected a session per date, got %+v", classSessions)
+	}
+
+	for _, member := range []string{"Ann", "Ben"} {
+		rec = httptest.NewRecorder()
+		bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"`+member+`","className":"Yoga","date":"05-12-2099"}`))))
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected %s to book, got %d: %s", member, rec.Code, rec.Body.String())
+		}
+	}
+	if bookings[0].SessionID != classSessions[4].ID {
+		t.Errorf("expected the booking to attend session %d, got %d", classSessions[4].ID, bookings[0].SessionID)
+	}
+
+	tests := []struct {
+		name           string
+		date           string
+		body           string
+		expectedStatus int
+	}{
+		{"Raise Capacity", "05-12-2099", `{"capacity":3}`, http.StatusOK},
+		{"Below Booked", "05-12-2099", `{"capacity":1}`, http.StatusConflict},
+		{"Not Positive", "05-12-2099", `{"capacity":0}`, http.StatusBadRequest},
+		{"Outside Range", "11-12-2099", `{"capacity":3}`, http.StatusNotFound},
+		{"Invalid Date", "2099-12-05", `{"capacity":3}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/classes/1/sessions/"+tt.date, bytes.NewReader([]byte(tt.body)))
+			req.SetPathValue("id", "1")
+			req.SetPathValue("date", tt.date)
+			rec := httptest.NewRecorder()
+			classSessionHandler(rec, req)
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	// Only the overridden session gained a place
+	rec = httptest.NewRecorder()
+	bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"Cat","className":"Yoga","date":"05-12-2099"}`))))
+	if rec.Code != http.StatusCreated {
+		t.Errorf("expected the added place to be bookable, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if classSessions[5].Capacity != 2 {
+		t.Errorf("expected other sessions to keep the class capacity, got %d", classSessions[5].Capacity)
+	}
+
+	// Cancelled sessions take no bookings
+	cancelledAt := time.Now()
+	classSessions[6].CancelledAt = &cancelledAt
+	rec = httptest.NewRecorder()
+	bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"Ann","className":"Yoga","date":"07-12-2099"}`))))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected a cancelled session to be refused, got %d", rec.Code)
+	}
+}
+
+// TestSessionMigration verifies data saved before sessions existed gains them on load.
+func TestSessionMigration(t *testing.T) {
+	setupTestEnvironment()
+	writeDataToJsonFile("classes.json", []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("03-12-2099"), Capacity: 5}})
+	writeDataToJsonFile("bookings.json", []Booking{{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed}})
+	writeDataToJsonFile("holds.json", []Hold{{ID: 1, MemberName: "Ben", ClassName: "Yoga", Date: testDate("03-12-2099"), ExpiresAt: time.Now().Add(time.Hour)}})
+	if err := loadData(); err != nil {
+		t.Fatalf("failed to load data: %v", err)
+	}
+	if len(classSessions) != 3 || classSessionId != 4 {
+		t.Fatalf("expected three sessions to be created, got %+v", classSessions)
+	}
+	if bookings[0].SessionID != classSessions[1].ID {
+		t.Errorf("expected the booking to be linked to its session, got %d", bookings[0].SessionID)
+	}
+	if holds[0].SessionID != classSessions[2].ID {
+		t.Errorf("expected the hold to be linked to its session, got %d", holds[0].SessionID)
+	}
+
+	// Loading again creates nothing new
+	if err := loadData(); err != nil {
+		t.Fatalf("failed to reload data: %v", err)
+	}
+	if len(classSessions) != 3 {
+		t.Errorf("expected the migration to run once, got %d sessions", len(classSessions))
+	}
+}
+
+// TestSessionCountsItsOwnBookings verifies a session only counts the bookings
+// made for it, not those of a deleted class with the same name and date.
+func TestSessionCountsItsOwnBookings(t *testing.T) {
+	setupTestEnvironment()
+	deletedAt := time.Now()
+	classes = []Class{
+		{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("03-12-2099"), Capacity: 1},
+		{ID: 2, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("03-12-2099"), Capacity: 1},
+	}
+	classId = 3
+	bookings = []Booking{{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed}}
+	bookingId = 2
+	migrateSessions()
+	classes[0].DeletedAt = &deletedAt
+
+	index := sessionIndex(2, testDate("02-12-2099"))
+	if view := viewSession(classSessions[index]); view.Booked != 0 || view.AvailableSlots != 1 {
+		t.Fatalf("expected the session to be free, got %+v", view)
+	}
+	rec := httptest.NewRecorder()
+	bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"Ben","className":"Yoga","date":"02-12-2099"}`))))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the booking to be created, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if bookings[1].SessionID != classSessions[index].ID || countBookings(classSessions[index].ID) != 1 {
+		t.Errorf("expected one booking in the session, got %+v", bookings)
+	}
+}
+
+// TestChangeSingleSession verifies one session can get a new time, room or cover without changing the class.
+func TestChangeSingleSession(t *testing.T) {
+	setupTestEnvironment()
+	defer func(send func(to, subject, body string) error) { sendEmail = send }(sendEmail)
+	var sent []string
+	sendEmail = func(to, subject, body string) error {
+		sent = append(sent, body)
+		return nil
+	}
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("03-12-2099"), Capacity: 5, StartTime: "09:00", Room: "Studio A", Instructor: "Kim"}}
+	classId = 2
+	members = []Member{{Name: "Ann", Email: "ann@example.com"}}
+	bookings = []Booking{{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed}}
+	bookingId = 2
+	migrateSessions()
+	instructorAbsences = []InstructorAbsence{{ID: 1, Instructor: "Lee", Date: testDate("02-12-2099")}}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"Move Time And Room", `{"startTime":"18:30","room":"Studio B"}`, http.StatusOK},
+		{"Invalid Time", `{"startTime":"6pm"}`, http.StatusBadRequest},
+		{"Unavailable Cover", `{"instructor":"Lee"}`, http.StatusConflict},
+		{"Cover", `{"instructor":"Max"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/classes/1/sessions/02-12-2099", bytes.NewReader([]byte(tt.body)))
+			req.SetPathValue("id", "1")
+			req.SetPathValue("date", "02-12-2099")
+			rec := httptest.NewRecorder()
+			classSessionHandler(rec, req)
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	session := classSessions[sessionIndex(1, testDate("02-12-2099"))]
+	if session.StartTime != "18:30" || session.Room != "Studio B" || session.Instructor != "Max" || session.Capacity != 5 {
+		t.Errorf("expected the session to be changed, got %+v", session)
+	}
+	if other := resolveSession(classSessions[sessionIndex(1, testDate("03-12-2099"))]); other.StartTime != "09:00" || other.Instructor != "Kim" {
+		t.Errorf("expected the rest of the class to be unchanged, got %+v", other)
+	}
+	if len(sent) != 2 || !strings.Contains(sent[0], "starts at 18:30, it is now in Studio B") {
+		t.Errorf("expected the booked member to hear about each change, got %v", sent)
+	}
+}
+
+// TestCancelSingleSession verifies cancelling one session handles its bookings per the policy.
+func TestCancelSingleSession(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		expected []string // Status and date of Ann's and Ben's bookings
+	}{
+		{"Cancel", sessionPolicyCancel, []string{bookingStatusCancelled + " 02-12-2099", bookingStatusCancelled + " 02-12-2099"}},
+		// The next session is full, so both move to the one after
+		{"Rebook", sessionPolicyRebook, []string{bookingStatusConfirmed + " 04-12-2099", bookingStatusConfirmed + " 04-12-2099"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestEnvironment()
+			config.SessionCancellationPolicy = tt.policy
+			classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("04-12-2099"), Capacity: 2}}
+			classId = 2
+			bookings = []Booking{
+				{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed},
+				{ID: 2, MemberName: "Ben", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed},
+				{ID: 3, MemberName: "Ben", ClassName: "Yoga", Date: testDate("03-12-2099"), Status: bookingStatusConfirmed},
+				{ID: 4, MemberName: "Cat", ClassName: "Yoga", Date: testDate("03-12-2099"), Status: bookingStatusConfirmed},
+			}
+			bookingId = 5
+			migrateSessions()
+			waitlist = []WaitlistEntry{{ID: 1, MemberName: "Dan", ClassName: "Yoga", Date: testDate("02-12-2099")}}
+
+			req := httptest.NewRequest(http.MethodPost, "/classes/1/sessions/02-12-2099/cancel", bytes.NewReader([]byte(`{"reason":"Burst pipe"}`)))
+			req.SetPathValue("id", "1")
+			req.SetPathValue("date", "02-12-2099")
+			rec := httptest.NewRecorder()
+			cancelSessionHandler(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected the session to be cancelled, got %d: %s", rec.Code, rec.Body.String())
+			}
+			for i, expected := range tt.expected {
+				if got := bookings[i].Status + " " + bookings[i].Date.String(); got != expected {
+					t.Errorf("expected booking %d to be %s, got %s", bookings[i].ID, expected, got)
+				}
+			}
+			if len(waitlist) != 0 {
+				t.Errorf("expected the waitlist for the session to be cleared, got %+v", waitlist)
+			}
+			if session := classSessions[sessionIndex(1, testDate("02-12-2099"))]; session.CancelledAt == nil || session.CancelReason != "Burst pipe" {
+				t.Errorf("expected the session to be marked cancelled, got %+v", session)
+			}
+
+			rec = httptest.NewRecorder()
+			cancelSessionHandler(rec, req)
+			if rec.Code != http.StatusConflict {
+				t.Errorf("expected a second cancellation to conflict, got %d", rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// classIndex returns the position of the class with the given ID, or -1.
+// Callers must hold the mutex.
+func classIndex(id int) int {
+	if index, ok := indexClasses().byID[id]; ok {
+		return index
+	}
+	return -1
+}
+
+// classIndexes maps class IDs and names to positions in the classes slice.
+// It remembers the slice it was built from and is rebuilt once classes has
+// been replaced, grown or shrunk, so code swapping the slice wholesale, such
+// as loading, archiving or rolling back a failed write, keeps it current
+// without bookkeeping. Classes are never renamed or renumbered in place.
+type classIndexes struct {
+	indexed []Class
+	byID    map[int]int
+	byName  map[string][]int
+}
+
+// classLookup is the index of the classes slice
+var classLookup classIndexes
+
+// indexClasses returns the class index, rebuilding it if classes has changed
+// since it was built. Callers must hold the mutex.
+func indexClasses() *classIndexes {
+	current := len(classLookup.indexed) == len(classes) && classLookup.byID != nil
+	if current && len(classes) > 0 {
+		current = &classLookup.indexed[0] == &classes[0]
+	}
+	if !current {
+		classLookup.byID, classLookup.byName = map[int]int{}, map[string][]int{}
+		for i, class := range classes {
+			classLookup.byID[class.ID] = i
+			classLookup.byName[class.ClassName] = append(classLookup.byName[class.ClassName], i)
+		}
+		classLookup.indexed = classes
+	}
+	return &classLookup
+}
+
+// classesNamed returns the positions of the classes with a name, deleted
+// ones included. Callers must hold the mutex.
+func classesNamed(className string) []int {
+	return indexClasses().byName[className]
+}
+
+// checkClass validates the fields and dates of a new class and the availability
+// of its instructor. Callers must hold the mutex.
+func checkClass(newClass Class) *requestRejection {
+	// Validate the class fields
+	if newClass.ClassName == "" || newClass.StartDate.IsZero() || newClass.EndDate.IsZero() || newClass.Capacity <= 0 {
+		return &requestRejection{http.StatusBadRequest, "Invalid data format"}
+	}
+	if newClass.DurationMinutes < 0 || newClass.Price < 0 || newClass.CreditCost < 0 || newClass.LoyaltyPoints < 0 {
+		return &requestRejection{http.StatusBadRequest, "Invalid data format"}
+	}
+	if newClass.EarlyBirdPrice < 0 || newClass.EarlyBirdPrice > 0 && (newClass.EarlyBirdPrice >= newClass.Price || newClass.EarlyBirdDays <= 0) {
+		return &requestRejection{http.StatusBadRequest, "Invalid early-bird pricing, give an earlyBirdPrice below the price and positive earlyBirdDays"}
+	}
+	if _, err := time.Parse(timeLayout, newClass.StartTime); newClass.StartTime != "" && err != nil {
+		return &requestRejection{http.StatusBadRequest, "Invalid startTime format, use HH:MM"}
+	}
+	for _, stock := range newClass.RentalInventory {
+		if stock < 0 {
+			return &requestRejection{http.StatusBadRequest, "Invalid rental inventory"}
+		}
+	}
+	if newClass.MinAge < 0 || newClass.MaxAge < 0 || (newClass.MaxAge > 0 && newClass.MinAge > newClass.MaxAge) {
+		return &requestRejection{http.StatusBadRequest, "Invalid age range"}
+	}
+	if _, ok := levelRanks[newClass.Level]; newClass.Level != "" && !ok {
+		return &requestRejection{http.StatusBadRequest, "Invalid level, use beginner, intermediate or advanced"}
+	}
+
+	// Ensure the end date is not before the start date
+	if newClass.EndDate.Before(newClass.StartDate.Time) {
+		return &requestRejection{http.StatusBadRequest, "endDate must be after startDate"}
+	}
+	if rejection := checkTaxonomy(newClass); rejection != nil {
+		return rejection
+	}
+	if newClass.StudioID != 0 && studioIndex(newClass.StudioID) < 0 {
+		return &requestRejection{http.StatusBadRequest, "Unknown studioId"}
+	}
+	if rejection := checkClassCurrency(newClass); rejection != nil {
+		return rejection
+	}
+	if rejection := checkAutoScaleRule(newClass); rejection != nil {
+		return rejection
+	}
+	return checkInstructor(newClass)
+}
+
+// addClass assigns the next ID to a class and appends it. Its sessions are
+// left for the caller to create with ensureSessions once the class is saved,
+// so a failed save only has to drop the class and put classId back.
+// Callers must hold the mutex.
+func addClass(newClass *Class) {
+	if newClass.Price > 0 && newClass.Currency == "" {
+		newClass.Currency = classCurrency(*newClass)
+	}
+	newClass.ID = classId
+	classId++
+	index := indexClasses()
+	classes = append(classes, *newClass)
+	index.byID[newClass.ID] = len(classes) - 1
+	index.byName[newClass.ClassName] = append(index.byName[newClass.ClassName], len(classes)-1)
+	index.indexed = classes
+}
+
+// classCursor orders classes by ID
+func classCursor(class Class) pageCursor {
+	return pageCursor{ID: class.ID}
+}
+
+// listClasses sends the classes that have not been deleted, optionally
+// narrowed to a category, tag or studio
+func listClasses(w http.ResponseWriter, r *http.Request) {
+	page, err := paginationParams(r)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	category, tag := r.URL.Query().Get("category"), r.URL.Query().Get("tag")
+	studioID := 0
+	if value := r.URL.Query().Get("studioId"); value != "" {
+		if studioID, err = strconv.Atoi(value); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid studioId")
+			return
+		}
+	}
+
+	mutex.Lock()
+	active := []Class{}
+	for _, class := range classes {
+		if class.DeletedAt == nil && classMatchesFilters(class, category, tag) && (studioID == 0 || class.StudioID == studioID) {
+			active = append(active, class)
+		}
+	}
+	ratings := classRatings()
+	mutex.Unlock()
+
+	items, nextCursor := paginate(active, page, classCursor, false)
+	listings := make([]classListing, len(items))
+	for i, class := range items {
+		listings[i] = classListing{class, ratings[class.ID]}
+	}
+	response := map[string]interface{}{
+		"classes":    listings,
+		"total":      len(active),
+		"limit":      page.Limit,
+		"offset":     page.Offset,
+		"nextCursor": nextCursor,
+	}
+	successResponse(w, http.StatusOK, "Classes retrieved successfully", response)
+}
+
+// Handler for soft-deleting a class
+func classItemHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is DELETE
+	if r.Method != http.MethodDelete {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid class id")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := classIndex(id)
+	if index < 0 || classes[index].DeletedAt != nil {
+		errorResponse(w, http.StatusNotFound, "Class not found")
+		return
+	}
+
+	// Mark the class deleted instead of removing it, so it can be restored
+	before := classes[index]
+	deletedAt := now()
+	classes[index].DeletedAt = &deletedAt
+
+	audit := recordAudit(actorFromRequest(r), "delete", "class", id, before, classes[index])
+	if err := writeDataToJsonFile("classes.json", classes); err != nil {
+		classes[index] = before
+		retractAudit(audit)
+		errorResponse(w, http.StatusInternalServerError, "Failed to save class data")
+		return
+	}
+
+	// Staff need to contact members booked into the cancelled class
+	affected := 0
+	for _, booking := range bookings {
+		if booking.ClassName == before.ClassName && bookingActive(booking) {
+			affected++
+		}
+	}
+	alertOps(opsClassCancelled, fmt.Sprintf("Class %s (%s to %s) was cancelled by %s with %d active bookings", before.ClassName, before.StartDate, before.EndDate, actorFromRequest(r), affected))
+
+	successResponse(w, http.StatusOK, "Class deleted successfully", classes[index])
+	logData("Class deleted successfully", classes[index])
+}
+
+// Handler for restoring a soft-deleted class
+func restoreClassHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid class id")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := classIndex(id)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Class not found")
+		return
+	}
+	if classes[index].DeletedAt == nil {
+		errorResponse(w, http.StatusBadRequest, "Class is not deleted")
+		return
+	}
+
+	before := classes[index]
+	classes[index].DeletedAt = nil
+
+	audit := recordAudit(actorFromRequest(r), "restore", "class", id, before, classes[index])
+	if err := writeDataToJsonFile("classes.json", classes); err != nil {
+		classes[index] = before
+		retractAudit(audit)
+		errorResponse(w, http.StatusInternalServerError, "Failed to save class data")
+		return
+	}
+
+	successResponse(w, http.StatusOK, "Class restored successfully", classes[index])
+	logData("Class restored successfully", classes[index])
+}
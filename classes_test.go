@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestClassSoftDeleteAndRestore verifies deleted classes are hidden and recoverable.
+func TestClassSoftDeleteAndRestore(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{
+		{ID: 1, ClassName: "Pilates", StartDate: testDate("15-12-2024"), EndDate: testDate("20-12-2024"), Capacity: 10},
+		{ID: 2, ClassName: "Yoga", StartDate: testDate("15-12-2024"), EndDate: testDate("20-12-2024"), Capacity: 10},
+	}
+	classId = 3
+
+	// Define the steps of the delete/restore flow in order.
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		id         string
+		handler    http.HandlerFunc
+		statusCode int
+	}{
+		{name: "Delete Class", method: http.MethodDelete, path: "/classes/1", id: "1", handler: classItemHandler, statusCode: http.StatusOK},
+		{name: "Delete Deleted Class", method: http.MethodDelete, path: "/classes/1", id: "1", handler: classItemHandler, statusCode: http.StatusNotFound},
+		{name: "Delete Unknown Class", method: http.MethodDelete, path: "/classes/9", id: "9", handler: classItemHandler, statusCode: http.StatusNotFound},
+		{name: "Restore Active Class", method: http.MethodPost, path: "/classes/2/restore", id: "2", handler: restoreClassHandler, statusCode: http.StatusBadRequest},
+		{name: "Invalid Class ID", method: http.MethodPost, path: "/classes/abc/restore", id: "abc", handler: restoreClassHandler, statusCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.SetPathValue("id", tt.id)
+			rec := httptest.NewRecorder()
+
+			tt.handler(rec, req)
+
+			if rec.Code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+		})
+	}
+
+	// The deleted class is excluded from listings.
+	rec := httptest.NewRecorder()
+	classHandler(rec, httptest.NewRequest(http.MethodGet, "/classes", nil))
+	var listing struct {
+		Data struct {
+			Classes []Class `json:"classes"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&listing)
+	if len(listing.Data.Classes) != 1 || listing.Data.Classes[0].ID != 2 {
+		t.Errorf("expected only class 2 to be listed, got %+v", listing.Data.Classes)
+	}
+
+	// The deleted class can no longer be booked.
+	body, _ := json.Marshal(Booking{MemberName: "John Doe", Date: testDate("16-12-2024"), ClassName: "Pilates"})
+	rec = httptest.NewRecorder()
+	bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected booking a deleted class to fail, got %d", rec.Code)
+	}
+
+	// Restoring makes it bookable again.
+	req := httptest.NewRequest(http.MethodPost, "/classes/1/restore", nil)
+	req.SetPathValue("id", "1")
+	rec = httptest.NewRecorder()
+	restoreClassHandler(rec, req)
+	if rec.Code != http.StatusOK || classes[0].DeletedAt != nil {
+		t.Fatalf("expected class 1 to be restored, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Errorf("expected booking a restored class to succeed, got %d", rec.Code)
+	}
+}
+
+// TestClassLookup verifies classes are found by ID and name after being added, replaced or rolled back.
+func TestClassLookup(t *testing.T) {
+	setupTestEnvironment()
+	for _, class := range []Class{
+		{ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 5},
+		{ClassName: "Spin", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 5},
+		{ClassName: "Yoga", StartDate: testDate("11-12-2099"), EndDate: testDate("20-12-2099"), Capacity: 5},
+	} {
+		addClass(&class)
+	}
+	date, _ := time.Parse(dateLayout, "15-12-2099")
+	if class := findClassOn("Yoga", date); class == nil || class.ID != 3 {
+		t.Errorf("expected the second Yoga class to run on the date, got %+v", class)
+	}
+	if classIndex(2) != 1 || classIndex(9) != -1 || len(classesNamed("Yoga")) != 2 {
+		t.Errorf("expected the classes to be indexed, got %+v", classLookup)
+	}
+
+	// A rolled back class is no longer found, and a replaced slice is indexed afresh
+	classes = classes[:2]
+	if classIndex(3) != -1 || findClassOn("Yoga", date) != nil {
+		t.Error("expected the removed class to be gone from the index")
+	}
+	classes = []Class{{ID: 7, ClassName: "Boxing", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099")}}
+	if classIndex(7) != 0 || classIndex(1) != -1 || findClassOn("Boxing", date) == nil {
+		t.Errorf("expected the replaced classes to be indexed, got %+v", classLookup)
+	}
+}
+
+// TestClassSaveFailure verifies a class that cannot be saved is not kept.
+func TestClassSaveFailure(t *testing.T) {
+	setupTestEnvironment()
+	// A directory in its place makes the classes file impossible to write
+	os.Remove("classes.json")
+	os.Mkdir("classes.json", 0755)
+	defer func() {
+		os.Remove("classes.json")
+		os.WriteFile("classes.json", []byte("[]"), 0666)
+	}()
+
+	rec := httptest.NewRecorder()
+	classHandler(rec, httptest.NewRequest(http.MethodPost, "/classes", bytes.NewReader([]byte(`{"className":"Yoga","startDate":"01-12-2099","endDate":"10-12-2099","capacity":5}`))))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+	if len(classes) != 0 || classId != 1 || len(classSessions) != 0 || len(auditEntries) != 0 {
+		t.Errorf("expected the class to be rolled back, got %+v with next ID %d", classes, classId)
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Closure records a day the whole studio was closed, e.g. for snow or
+// maintenance
+type Closure struct {
+	ID                int       `json:"id"`
+	Date              Date      `json:"date"` // DD-MM-YYYY on the wire
+	Reason            string    `json:"reason"`
+	SessionsCancelled int       `json:"sessionsCancelled"`
+	BookingsCancelled int       `json:"bookingsCancelled"`
+	Refunds           int       `json:"refunds"`
+	Actor             string    `json:"actor"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+var (
+	closures  []Closure // Temp Slice to hold studio closures
+	closureId = 1       // Incremental ID for studio closures
+)
+
+// Handler for listing studio closures and closing the studio for a day. A
+// closure cancels every session and booking on the date, closes resources
+// for the day and refunds the bookings that were paid for.
+func closureHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		response := append([]Closure{}, closures...)
+		mutex.Unlock()
+		successResponse(w, http.StatusOK, "Closures retrieved successfully", map[string]interface{}{"closures": response})
+
+	case http.MethodPost:
+		var request struct {
+			Date   Date   `json:"date"`
+			Reason string `json:"reason"`
+		}
+		if err := decodeBody(r, &request); err != nil {
+			errorResponse(w, http.StatusBadRequest, bodyErrorMessage(err))
+			return
+		}
+		if request.Date.IsZero() {
+			errorResponse(w, http.StatusBadRequest, "Invalid date format, use DD-MM-YYYY")
+			return
+		}
+		if request.Reason == "" {
+			errorResponse(w, http.StatusBadRequest, "Give the reason for the closure")
+			return
+		}
+		if request.Date.Before(today()) {
+			errorResponse(w, http.StatusBadRequest, "Past dates cannot be closed")
+			return
+		}
+		closeStudio(w, r, request.Date, request.Reason)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// closeStudio carries out a closure and responds with it
+func closeStudio(w http.ResponseWriter, r *http.Request, date Date, reason string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for _, closure := range closures {
+		if closure.Date == date {
+			errorResponse(w, http.StatusConflict, "Studio is already closed on this date")
+			return
+		}
+	}
+
+	previousSessions := append([]ClassSession(nil), classSessions...)
+	previousBookings := append([]Booking(nil), bookings...)
+	previousResources := append([]Resource(nil), resources...)
+	previousWaitlist := waitlist
+	rollback := func() {
+		classSessions, bookings, resources, waitlist = previousSessions, previousBookings, previousResources, previousWaitlist
+	}
+
+	cancelledAt := now()
+	sessionsCancelled := 0
+	for _, class := range classes {
+		if class.DeletedAt != nil || !classCovers(class, Booking{ClassName: class.ClassName, Date: date}) {
+			continue
+		}
+		ensureSessions(class)
+		index := sessionIndex(class.ID, date)
+		if index < 0 || classSessions[index].CancelledAt != nil {
+			continue
+		}
+		classSessions[index].CancelledAt = &cancelledAt
+		classSessions[index].CancelReason = reason
+		sessionsCancelled++
+	}
+	for i := range resources {
+		if !slices.Contains(resources[i].ClosedDates, date) {
+			resources[i].ClosedDates = append(slices.Clone(resources[i].ClosedDates), date)
+		}
+	}
+	// Bookings that can still be cancelled are affected, attended ones stay as they are
+	var targets []int
+	for i, booking := range bookings {
+		if booking.Date == date && checkTransition(booking, bookingStatusCancelled) == nil {
+			transitionBooking(i, bookingStatusCancelled)
+			targets = append(targets, i)
+		}
+	}
+	// Nobody waits for a session that will not run
+	keptWaitlist, droppedWaitlist := []WaitlistEntry{}, []WaitlistEntry{}
+	for _, entry := range waitlist {
+		if entry.Date != date {
+			keptWaitlist = append(keptWaitlist, entry)
+		} else {
+			droppedWaitlist = append(droppedWaitlist, entry)
+		}
+	}
+	waitlist = keptWaitlist
+
+	actor := actorFromRequest(r)
+	closure := Closure{
+		ID:                closureId,
+		Date:              date,
+		Reason:            reason,
+		SessionsCancelled: sessionsCancelled,
+		BookingsCancelled: len(targets),
+		Actor:             actor,
+		CreatedAt:         cancelledAt,
+	}
+	closureId++
+	closures = append(closures, closure)
+
+	// The events announcing the changes are saved before the changes
+	audit := recordAudit(actor, "create", "closure", closure.ID, nil, closure)
+	for _, target := range targets {
+		recordAudit(actor, "cancel", "booking", bookings[target].ID, previousBookings[target], bookings[target])
+	}
+	for _, file := range []struct {
+		name string
+		data interface{}
+	}{{"bookings.json", bookings}, {"class_sessions.json", classSessions}, {"resources.json", resources}, {"closures.json", closures}} {
+		if err := writeDataToJsonFile(file.name, file.data); err != nil {
+			rollback()
+			closures = closures[:len(closures)-1]
+			closureId--
+			// Put back the files already written
+			writeDataToJsonFile("bookings.json", bookings)
+			writeDataToJsonFile("class_sessions.json", classSessions)
+			writeDataToJsonFile("resources.json", resources)
+			retractAudit(audit)
+			errorResponse(w, http.StatusInternalServerError, "Failed to save closure data")
+			return
+		}
+	}
+	if err := writeDataToJsonFile("waitlist.json", waitlist); err != nil {
+		fmt.Println("Error saving waitlist:", err)
+	}
+	recordWaitlistOutcomes(droppedWaitlist, waitlistCancelled)
+
+	cancelled, refunded := []Booking{}, []Refund{}
+	refundFailures := []map[string]interface{}{}
+	for i, target := range targets {
+		booking := bookings[target]
+		cancelled = append(cancelled, booking)
+		recordBookingEvent(booking.ID, bookingEventCancelled, actor, map[string]string{"reason": "studio closed"})
+		notifyMember(booking.MemberName, notifyBookingCancelled, "Studio closed",
+			fmt.Sprintf("The studio is closed on %s (%s), so your booking for %s has been cancelled.", date, reason, bookingTitle(booking)),
+			map[string]string{"event": notifyBookingCancelled, "bookingId": strconv.Itoa(booking.ID)})
+
+		// Paid bookings get everything back, the way they were paid
+		if booking.Price == 0 && booking.CreditsUsed == 0 && booking.PointsUsed == 0 {
+			continue
+		}
+		refund, rejection := issueRefund(target, 100, "Studio closed: "+reason, actor)
+		if rejection != nil {
+			refundFailures = append(refundFailures, map[string]interface{}{"bookingId": booking.ID, "error": rejection.Message})
+			continue
+		}
+		refunded = append(refunded, refund)
+		cancelled[i] = bookings[target]
+	}
+	if len(refunded) > 0 {
+		closures[len(closures)-1].Refunds = len(refunded)
+		closure.Refunds = len(refunded)
+		if err := writeDataToJsonFile("closures.json", closures); err != nil {
+			fmt.Println("Error saving closure:", err)
+		}
+	}
+	alertOps(opsClassCancelled, fmt.Sprintf("Studio closed on %s by %s (%s): %d sessions and %d bookings cancelled", date, actor, reason, sessionsCancelled, len(targets)))
+
+	response := map[string]interface{}{
+		"closure":        closure,
+		"cancelled":      cancelled,
+		"refunds":        refunded,
+		"refundFailures": refundFailures,
+	}
+	successResponse(w, http.StatusCreated, "Studio closed successfully", response)
+	logData("Studio closed successfully", response)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestStudioClosure verifies closing the studio cancels the day's sessions and bookings and refunds paid bookings.
+func TestStudioClosure(t *testing.T) {
+	setupTestEnvironment()
+	defer func(provider PaymentProvider) { paymentProvider = provider }(paymentProvider)
+	paymentProvider = &fakePaymentProvider{}
+	now = func() time.Time { return time.Date(2099, 12, 1, 9, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("03-12-2099"), Capacity: 5, StartTime: "09:00"}}
+	classId = 2
+	resources = []Resource{{ID: 1, Name: "Court 1"}}
+	bookings = []Booking{
+		{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed, Price: 1500, Total: 1500, PaymentReference: "pi_1"},
+		{ID: 2, MemberName: "Ben", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed, PaymentMethod: paymentCredits, CreditsUsed: 1},
+		{ID: 3, MemberName: "Cat", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed},
+		{ID: 4, MemberName: "Dan", ClassName: "Yoga", Date: testDate("03-12-2099"), Status: bookingStatusConfirmed},
+	}
+	bookingId = 5
+	waitlist = []WaitlistEntry{{ID: 1, MemberName: "Eve", ClassName: "Yoga", Date: testDate("02-12-2099"), Tier: waitlistTierStandard}}
+	waitlistId = 2
+
+	closeDay := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/closures", bytes.NewReader([]byte(body)))
+		rec := httptest.NewRecorder()
+		closureHandler(rec, req)
+		return rec
+	}
+
+	for _, body := range []string{`{"date":"02-12-2099"}`, `{"date":"30-11-2099","reason":"Snow"}`, `{"date":"2099-12-02","reason":"Snow"}`} {
+		if rec := closeDay(body); rec.Code != http.StatusBadRequest {
+			t.Errorf("expected %s to be refused, got %d", body, rec.Code)
+		}
+	}
+
+	rec := closeDay(`{"date":"02-12-2099","reason":"Snow"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the studio to close, got %d: %s", rec.Code, rec.Body.String())
+	}
+	for _, booking := range bookings {
+		expected := bookingStatusCancelled
+		if booking.Date != testDate("02-12-2099") {
+			expected = bookingStatusConfirmed
+		}
+		if booking.Status != expected {
+			t.Errorf("expected booking %d to be %s, got %s", booking.ID, expected, booking.Status)
+		}
+	}
+	if len(refunds) != 2 || refunds[0].Amount != 1500 || refunds[0].Method != refundMethodProvider || refunds[1].Credits != 1 {
+		t.Errorf("expected the paid bookings to be refunded in full, got %+v", refunds)
+	}
+	if session := classSessions[sessionIndex(1, testDate("02-12-2099"))]; session.CancelledAt == nil || session.CancelReason != "Snow" {
+		t.Errorf("expected the session to be cancelled, got %+v", session)
+	}
+	if len(waitlist) != 0 || len(resources[0].ClosedDates) != 1 {
+		t.Errorf("expected the waitlist cleared and resources closed, got %+v %+v", waitlist, resources)
+	}
+	if len(closures) != 1 || closures[0].SessionsCancelled != 1 || closures[0].BookingsCancelled != 3 || closures[0].Refunds != 2 {
+		t.Errorf("expected the closure to be recorded, got %+v", closures)
+	}
+
+	if rec := closeDay(`{"date":"02-12-2099","reason":"Snow"}`); rec.Code != http.StatusConflict {
+		t.Errorf("expected a second closure of the day to be refused, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/rand"
+	"net/http"
+	"strings"
+)
+
+// codeAlphabet leaves out characters that are easily confused, such as 0/O and 1/I/L
+const codeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// codeLength is the number of random characters in a confirmation code
+const codeLength = 6
+
+// newConfirmationCode returns a short code, such as "BK-7F3K9Q", that no
+// other booking uses. Callers must hold the mutex.
+func newConfirmationCode() string {
+	for {
+		token := make([]byte, codeLength)
+		rand.Read(token)
+		for i := range token {
+			token[i] = codeAlphabet[int(token[i])%len(codeAlphabet)]
+		}
+		code := "BK-" + string(token)
+		if bookingByCode(code) < 0 {
+			return code
+		}
+	}
+}
+
+// bookingByCode returns the position of the booking with the given confirmation
+// code, or -1. Callers must hold the mutex.
+func bookingByCode(code string) int {
+	for i, booking := range bookings {
+		if booking.Code == code {
+			return i
+		}
+	}
+	return -1
+}
+
+// Handler for looking a booking up by its confirmation code
+func bookingCodeHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	// Codes are read out at the front desk, so accept any letter case
+	code := strings.ToUpper(r.PathValue("code"))
+	if code == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid confirmation code")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := bookingByCode(code)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Booking not found")
+		return
+	}
+	successResponse(w, http.StatusOK, "Booking retrieved successfully", bookings[index])
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestBookingCodeHandler verifies bookings get a confirmation code that resolves back to them.
+func TestBookingCodeHandler(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Pilates", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10}}
+	classId = 2
+
+	body := `{"memberName":"John Doe","date":"15-12-2099","className":"Pilates"}`
+	rec := httptest.NewRecorder()
+	bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(body))))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status code %d, got %d", http.StatusCreated, rec.Code)
+	}
+	code := bookings[0].Code
+	if !regexp.MustCompile(`^BK-[2-9A-HJKMNP-Z]{6}$`).MatchString(code) {
+		t.Fatalf("expected a confirmation code like BK-7F3K9Q, got %q", code)
+	}
+	if !strings.Contains(rec.Body.String(), code) {
+		t.Errorf("expected the code in the booking response, got %s", rec.Body.String())
+	}
+
+	// The lookup goes through the same routes as the server.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/bookings/code/{code}", bookingCodeHandler)
+	mux.HandleFunc("/bookings/{id}/{action}", bookingActionHandler)
+
+	tests := []struct {
+		name       string
+		path       string
+		statusCode int
+	}{
+		{name: "Known Code", path: "/bookings/code/" + code, statusCode: http.StatusOK},
+		{name: "Lower Case Code", path: "/bookings/code/" + strings.ToLower(code), statusCode: http.StatusOK},
+		{name: "Unknown Code", path: "/bookings/code/BK-AAAAAA", statusCode: http.StatusNotFound},
+		{name: "Booking Action Still Routed", path: "/bookings/1/history", statusCode: http.StatusOK},
+		{name: "Unknown Booking Action", path: "/bookings/1/upgrade", statusCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.statusCode {
+				t.Fatalf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+			if tt.name == "Known Code" {
+				var response struct {
+					Data Booking `json:"data"`
+				}
+				json.NewDecoder(rec.Body).Decode(&response)
+				if response.Data.ID != 1 || response.Data.Code != code {
+					t.Errorf("expected booking 1, got %+v", response.Data)
+				}
+			}
+		})
+	}
+}
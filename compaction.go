@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// compactionReport describes what a compaction moved out of the booking files
+type compactionReport struct {
+	BookingsArchived int   `json:"bookingsArchived"`
+	EventsRemoved    int   `json:"eventsRemoved"`
+	BytesBefore      int64 `json:"bytesBefore"`
+	BytesAfter       int64 `json:"bytesAfter"`
+	BytesReclaimed   int64 `json:"bytesReclaimed"`
+}
+
+// fileSize returns the size of a file, or 0 if it does not exist
+func fileSize(fileName string) int64 {
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// ledgerBookings returns the IDs of bookings referenced by a refund, a credits
+// or points entry, a gift card transaction or a promo redemption. Those stay
+// live so the money they moved can still be traced and refunded.
+// Callers must hold the mutex.
+func ledgerBookings() map[int]bool {
+	referenced := map[int]bool{}
+	for _, refund := range refunds {
+		referenced[refund.BookingID] = true
+	}
+	for _, entry := range creditEntries {
+		referenced[entry.BookingID] = true
+	}
+	for _, entry := range pointsEntries {
+		referenced[entry.BookingID] = true
+	}
+	for _, card := range giftCards {
+		for _, transaction := range card.Transactions {
+			referenced[transaction.BookingID] = true
+		}
+	}
+	for _, redemption := range promoRedemptions {
+		referenced[redemption.BookingID] = true
+	}
+	delete(referenced, 0)
+	return referenced
+}
+
+// compactData moves dead records out of the booking files: cancelled and
+// expired bookings whose date has passed go to the archive, unless a ledger
+// row refers to them, and history events of bookings that no longer exist
+// anywhere are dropped. Callers must hold the mutex.
+func compactData() (compactionReport, error) {
+	var report compactionReport
+	files := []string{"bookings.json", "booking_events.json"}
+	for _, fileName := range files {
+		report.BytesBefore += fileSize(fileName)
+	}
+
+	day := today()
+	referenced := ledgerBookings()
+	keptBookings := []Booking{}
+	deadBookings := []Booking{}
+	for _, booking := range bookings {
+		if !bookingActive(booking) && !referenced[booking.ID] && booking.Date.Before(day) {
+			deadBookings = append(deadBookings, booking)
+			continue
+		}
+		keptBookings = append(keptBookings, booking)
+	}
+	archive := archived
+	archive.Bookings = append(append([]Booking{}, archived.Bookings...), deadBookings...)
+
+	// Events of archived bookings are still needed to explain them
+	known := map[int]bool{}
+	for _, booking := range keptBookings {
+		known[booking.ID] = true
+	}
+	for _, booking := range archive.Bookings {
+		known[booking.ID] = true
+	}
+	keptEvents := []BookingEvent{}
+	for _, event := range bookingEvents {
+		if known[event.BookingID] {
+			keptEvents = append(keptEvents, event)
+		}
+	}
+
+	// The archive is written first, so a failure never leaves a booking in neither file
+	if len(deadBookings) > 0 {
+		if err := writeDataToJsonFile(archiveFile, archive); err != nil {
+			return report, err
+		}
+	}
+	if err := writeDataToJsonFile("bookings.json", keptBookings); err != nil {
+		if len(deadBookings) > 0 {
+			writeDataToJsonFile(archiveFile, archived)
+		}
+		return report, err
+	}
+	report.BookingsArchived = len(deadBookings)
+	archived = archive
+	bookings = keptBookings
+	if err := writeDataToJsonFile("booking_events.json", keptEvents); err != nil {
+		return report, err
+	}
+	report.EventsRemoved = len(bookingEvents) - len(keptEvents)
+	bookingEvents = keptEvents
+
+	for _, fileName := range files {
+		report.BytesAfter += fileSize(fileName)
+	}
+	report.BytesReclaimed = report.BytesBefore - report.BytesAfter
+	return report, nil
+}
+
+// runCompaction is the scheduled entry point for compactData
+func runCompaction() {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	report, err := compactData()
+	if err != nil {
+		fmt.Println("Error compacting data files:", err)
+		return
+	}
+	recordAudit("system", "compact", "storage", 0, nil, report)
+	logData("Compaction completed", report)
+}
+
+// Handler for compacting the data files on demand
+func compactionHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	report, err := compactData()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to compact data files")
+		return
+	}
+	recordAudit(actorFromRequest(r), "compact", "storage", 0, nil, report)
+
+	successResponse(w, http.StatusOK, "Compaction completed successfully", report)
+	logData("Compaction completed successfully", report)
+}
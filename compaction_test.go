@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestCompactionHandler verifies dead bookings are archived, orphaned events dropped and the reclaimed space reported.
+func TestCompactionHandler(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2024, 12, 16, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	bookings = []Booking{
+		{ID: 1, MemberName: "A", Date: testDate("10-12-2024"), ClassName: "Pilates", Status: bookingStatusCancelled},
+		{ID: 2, MemberName: "B", Date: testDate("10-12-2024"), ClassName: "Pilates", Status: bookingStatusAttended},
+		{ID: 3, MemberName: "C", Date: testDate("20-12-2024"), ClassName: "Pilates", Status: bookingStatusCancelled},
+		{ID: 4, MemberName: "D", Date: testDate("10-12-2024"), ClassName: "Pilates", Status: bookingStatusCancelled, Price: 1000, RefundID: 1},
+	}
+	refunds = []Refund{{ID: 1, BookingID: 4, MemberName: "D", Amount: 1000}}
+	bookingEvents = []BookingEvent{
+		{ID: 1, BookingID: 1, Type: bookingEventCreated},
+		{ID: 2, BookingID: 1, Type: bookingEventCancelled},
+		{ID: 3, BookingID: 2, Type: bookingEventCreated},
+		{ID: 4, BookingID: 9, Type: bookingEventCreated},
+	}
+	writeDataToJsonFile("bookings.json", bookings)
+	writeDataToJsonFile("booking_events.json", bookingEvents)
+
+	tests := []struct {
+		name       string
+		method     string
+		statusCode int
+	}{
+		{name: "Invalid Method", method: http.MethodGet, statusCode: http.StatusMethodNotAllowed},
+		{name: "Compact", method: http.MethodPost, statusCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			compactionHandler(rec, httptest.NewRequest(tt.method, "/admin/compact", nil))
+
+			if rec.Code != tt.statusCode {
+				t.Fatalf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+			if tt.statusCode != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Data compactionReport `json:"data"`
+			}
+			json.NewDecoder(rec.Body).Decode(&response)
+			if response.Data.BookingsArchived != 1 || response.Data.EventsRemoved != 1 {
+				t.Errorf("expected 1 booking archived and 1 event removed, got %+v", response.Data)
+			}
+			if response.Data.BytesReclaimed <= 0 || response.Data.BytesReclaimed != response.Data.BytesBefore-response.Data.BytesAfter {
+				t.Errorf("expected reclaimed bytes to be reported, got %+v", response.Data)
+			}
+		})
+	}
+
+	if len(bookings) != 3 || bookingIndex(1) >= 0 || bookingIndex(4) < 0 {
+		t.Errorf("expected the past cancelled booking to be moved and the refunded one kept, got %+v", bookings)
+	}
+	if len(archived.Bookings) != 1 || archived.Bookings[0].ID != 1 {
+		t.Errorf("expected the past cancelled booking in the archive, got %+v", archived.Bookings)
+	}
+	if len(bookingEvents) != 3 {
+		t.Errorf("expected only the orphaned event to be dropped, got %+v", bookingEvents)
+	}
+
+	// The archived booking's ID is not handed out again after a reload
+	if err := loadData(); err != nil {
+		t.Fatalf("unexpected error reloading data: %v", err)
+	}
+	if bookingId != 5 {
+		t.Errorf("expected the booking counter past the archived ID, got %d", bookingId)
+	}
+}
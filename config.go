@@ -0,0 +1,157 @@
+package main
+
+// Config holds the tunable server settings loaded from config.json
+type Config struct {
+	RetentionDays             int             `json:"retentionDays"`             // Age in days after which bookings are purged, 0 keeps them forever
+	SoftDeleteGraceDays       int             `json:"softDeleteGraceDays"`       // Days a soft-deleted class stays restorable before it is purged
+	CleanupIntervalMinutes    int             `json:"cleanupIntervalMinutes"`    // How often the retention cleanup runs, 0 disables it
+	ArchiveIntervalMinutes    int             `json:"archiveIntervalMinutes"`    // How often classes past their endDate are archived, 0 disables it
+	CompactionIntervalMinutes int             `json:"compactionIntervalMinutes"` // How often dead records are compacted out of the data files, 0 disables it
+	HoldMinutes               int             `json:"holdMinutes"`               // How long POST /holds reserves a slot when no minutes are given
+	HoldSweepSeconds          int             `json:"holdSweepSeconds"`          // How often expired holds and pending bookings are released, 0 disables the sweepers
+	PendingBookingMinutes     int             `json:"pendingBookingMinutes"`     // How long a pending booking waits to be confirmed before it expires
+	LongPollSeconds           int             `json:"longPollSeconds"`           // Longest an availability poll waits for a change before answering
+	ResponseCacheSeconds      int             `json:"responseCacheSeconds"`      // How long class listings and availability are served from memory and kept by clients, 0 disables caching
+	PublicBaseURL             string          `json:"publicBaseUrl"`             // Address of the API used in links emailed to members
+	VerificationTokenHours    int             `json:"verificationTokenHours"`    // How long an email verification link stays valid
+	SessionHours              int             `json:"sessionHours"`              // How long a member stays signed in after /login
+	ResetTokenMinutes         int             `json:"resetTokenMinutes"`         // How long a password reset link stays valid
+	OIDCIssuer                string          `json:"oidcIssuer"`                // OpenID Connect issuer for "Sign in with Google"
+	OIDCClientID              string          `json:"oidcClientId"`              // OAuth client ID, empty disables Google sign-in
+	OIDCClientSecret          string          `json:"oidcClientSecret"`          // OAuth client secret
+	RequireAPIKey             bool            `json:"requireApiKey"`             // Reject requests without an X-API-Key header, except member sign-in
+	APIKeyGraceMinutes        int             `json:"apiKeyGraceMinutes"`        // How long a rotated API key keeps working
+	RequireVerifiedEmail      bool            `json:"requireVerifiedEmail"`      // Reject a member's first booking until their email is verified
+	BookingRateLimit          int             `json:"bookingRateLimit"`          // Booking attempts a member may make per window, 0 disables the limit
+	BookingRateWindowSeconds  int             `json:"bookingRateWindowSeconds"`  // Length of the booking rate limit window
+	WaiverVersion             string          `json:"waiverVersion"`             // Terms of service members must accept before their first booking, empty requires none
+	DigestIntervalHours       int             `json:"digestIntervalHours"`       // How often members are emailed a digest of their next week's bookings, 0 disables it
+	ChatWebhookURL            string          `json:"chatWebhookUrl"`            // Slack or Teams incoming webhook receiving operational alerts, empty disables them
+	ChatWebhookFormat         string          `json:"chatWebhookFormat"`         // Payload format of the chat webhook, "slack" or "teams"
+	ChatEvents                map[string]bool `json:"chatEvents"`                // Turns alerts on or off per event, all are on by default
+	NearlyFullPercent         int             `json:"nearlyFullPercent"`         // Share of a session's places booked at which staff are alerted
+	AdminAlertEmail           string          `json:"adminAlertEmail"`           // Address emailed when a session is nearly full, empty disables the emails
+	RefundCutoffHours         int             `json:"refundCutoffHours"`         // Bookings cancelled at least this long before the start are refunded in full
+	LateRefundPercent         int             `json:"lateRefundPercent"`         // Share refunded for later cancellations, 0 refunds nothing
+	CreditExpiryMinutes       int             `json:"creditExpiryMinutes"`       // How often expired credits are removed and expiry warnings sent, 0 disables it
+	CreditExpiryWarningDays   int             `json:"creditExpiryWarningDays"`   // How long before credits expire members are warned, 0 disables the warning
+	ReportIntervalMinutes     int             `json:"reportIntervalMinutes"`     // How often report subscriptions are checked for reports to email, 0 disables them
+	ReferralRewardCredits     int             `json:"referralRewardCredits"`     // Credits a referrer earns when a member they referred first books, 0 disables rewards
+	LoyaltyPointsPerClass     int             `json:"loyaltyPointsPerClass"`     // Points a member earns for attending a class that sets none, 0 disables them
+	LoyaltyRedemptionPoints   int             `json:"loyaltyRedemptionPoints"`   // Points a free booking costs, 0 disables redemption
+	PaymentProvider           string          `json:"paymentProvider"`           // Provider refunding card payments, "stripe", empty leaves refunds to staff
+	PaymentAPIURL             string          `json:"paymentApiUrl"`             // Base URL of the payment provider's API
+	PaymentSecretKey          string          `json:"paymentSecretKey"`          // Secret API key of the payment provider
+	TaxRatePercent            float64         `json:"taxRatePercent"`            // Flat tax rate charged on top of booking prices, 0 charges none
+	TaxName                   string          `json:"taxName"`                   // Label of the tax on bookings and invoices, e.g. "VAT"
+	DefaultCurrency           string          `json:"defaultCurrency"`           // ISO 4217 code of prices that name no currency, nor are held at a studio with one
+	ScheduleConflictMode      string          `json:"scheduleConflictMode"`      // Handling of bookings that overlap another booking of the member, "reject", "warn" or "off"
+	SessionCancellationPolicy string          `json:"sessionCancellationPolicy"` // What happens to bookings of a cancelled session, "cancel" or "rebook" onto the next session with a free place
+	EventBusDriver            string          `json:"eventBusDriver"`            // Broker receiving booking and class events, "nats" or "kafka", empty disables it
+	EventBusAddress           string          `json:"eventBusAddress"`           // host:port of the NATS server, or the URL of the Kafka REST Proxy
+	EventBusSubject           string          `json:"eventBusSubject"`           // NATS subject prefix, or Kafka topic
+	EventBusFormat            string          `json:"eventBusFormat"`            // Encoding of published events, "json" or "protobuf"
+	EventConsumerAddress      string          `json:"eventConsumerAddress"`      // host:port of a NATS server delivering CRM membership events, empty disables consuming
+	EventConsumerSubject      string          `json:"eventConsumerSubject"`      // NATS subject carrying the membership events
+	EventConsumerQueue        string          `json:"eventConsumerQueue"`        // Queue group shared by instances, so each event is applied by one of them
+	OutboxPollSeconds         int             `json:"outboxPollSeconds"`         // How often the outbox is checked for retries that are due, 0 disables delivery
+	OutboxRetrySeconds        int             `json:"outboxRetrySeconds"`        // Delay before the first retry of a failed delivery, doubling after each further failure
+	OutboxMaxAttempts         int             `json:"outboxMaxAttempts"`         // Delivery attempts after which an event is marked failed
+	OutboxRetentionHours      int             `json:"outboxRetentionHours"`      // How long delivered events stay in the outbox for inspection
+	PrivacyMode               bool            `json:"privacyMode"`               // Mask personal fields in log output
+	StorageFormat             string          `json:"storageFormat"`             // Encoding of the data files, "json" (indented) or the more compact "gob"
+	BackupIntervalMinutes     int             `json:"backupIntervalMinutes"`     // How often an automatic backup is taken, 0 disables it
+	BackupDir                 string          `json:"backupDir"`                 // Directory that receives automatic backups
+	BackupRetain              int             `json:"backupRetain"`              // Number of automatic backups kept, 0 keeps all
+	LogMaxSizeKB              int             `json:"logMaxSizeKB"`              // Size at which the API log is rotated, 0 disables size rotation
+	LogMaxAgeHours            int             `json:"logMaxAgeHours"`            // Age at which the API log is rotated, 0 disables age rotation
+	LogMaxRotated             int             `json:"logMaxRotated"`             // Number of compressed rotated logs kept, 0 keeps all
+	SyslogNetwork             string          `json:"syslogNetwork"`             // Transport used to reach the syslog server, "udp" or "tcp"
+	SyslogAddress             string          `json:"syslogAddress"`             // host:port of a syslog server, empty disables syslog output
+	LogCollectorURL           string          `json:"logCollectorUrl"`           // HTTP endpoint receiving log entries as JSON, empty disables it
+	ErrorReporterDSN          string          `json:"errorReporterDsn"`          // Sentry-style DSN receiving panics, 5xx responses and persistence failures
+	Environment               string          `json:"environment"`               // Deployment environment used to resolve feature flags
+	FlagsFile                 string          `json:"flagsFile"`                 // JSON file holding the feature flags
+	FlagsReloadSeconds        int             `json:"flagsReloadSeconds"`        // How often the flags file is checked for changes, 0 disables reloading
+	BlobStore                 string          `json:"blobStore"`                 // Where uploaded images are kept, "disk" or "s3", which also takes automatic backups
+	BlobDir                   string          `json:"blobDir"`                   // Directory holding uploaded images on disk
+	MaxImageKB                int             `json:"maxImageKB"`                // Largest image upload accepted
+	S3Endpoint                string          `json:"s3Endpoint"`                // Base URL of the S3-compatible service, e.g. "https://s3.eu-west-1.amazonaws.com"
+	S3Region                  string          `json:"s3Region"`                  // Region requests are signed for
+	S3Bucket                  string          `json:"s3Bucket"`                  // Bucket holding images and backups
+	S3AccessKey               string          `json:"s3AccessKey"`               // Access key ID of the S3 credentials
+	S3SecretKey               string          `json:"s3SecretKey"`               // Secret access key of the S3 credentials
+}
+
+// config holds the active settings, starting from the defaults
+var config = defaultConfig()
+
+// defaultConfig returns the settings used when config.json omits them
+func defaultConfig() Config {
+	return Config{
+		RetentionDays:             365,
+		SoftDeleteGraceDays:       30,
+		CleanupIntervalMinutes:    60,
+		ArchiveIntervalMinutes:    60,
+		CompactionIntervalMinutes: 1440,
+		HoldMinutes:               10,
+		HoldSweepSeconds:          30,
+		PendingBookingMinutes:     15,
+		LongPollSeconds:           30,
+		ResponseCacheSeconds:      5,
+		DigestIntervalHours:       168,
+		ChatWebhookFormat:         "slack",
+		NearlyFullPercent:         90,
+		SessionCancellationPolicy: sessionPolicyCancel,
+		ScheduleConflictMode:      conflictModeReject,
+		DefaultCurrency:           "USD",
+		TaxName:                   "Tax",
+		RefundCutoffHours:         24,
+		CreditExpiryMinutes:       60,
+		CreditExpiryWarningDays:   7,
+		ReportIntervalMinutes:     60,
+		ReferralRewardCredits:     1,
+		LoyaltyPointsPerClass:     10,
+		LoyaltyRedemptionPoints:   100,
+		PaymentAPIURL:             "https://api.stripe.com",
+		EventBusSubject:           "gym.events",
+		EventBusFormat:            "json",
+		StorageFormat:             "json",
+		EventConsumerSubject:      "crm.members",
+		EventConsumerQueue:        "gym-api",
+		OutboxPollSeconds:         5,
+		OutboxRetrySeconds:        30,
+		OutboxMaxAttempts:         10,
+		OutboxRetentionHours:      24,
+		BookingRateLimit:          5,
+		BookingRateWindowSeconds:  60,
+		PublicBaseURL:             "http://localhost:8088",
+		VerificationTokenHours:    48,
+		SessionHours:              24,
+		ResetTokenMinutes:         60,
+		APIKeyGraceMinutes:        1440,
+		OIDCIssuer:                "https://accounts.google.com",
+		BackupDir:                 "backups",
+		BackupRetain:              7,
+		LogMaxSizeKB:              10240,
+		LogMaxRotated:             5,
+		SyslogNetwork:             "udp",
+		Environment:               "development",
+		FlagsFile:                 "flags.json",
+		FlagsReloadSeconds:        30,
+		BlobStore:                 "disk",
+		BlobDir:                   "uploads",
+		MaxImageKB:                5120,
+		S3Region:                  "us-east-1",
+	}
+}
+
+// loadConfig overlays the settings in a JSON file on top of the defaults
+func loadConfig(fileName string) error {
+	loaded := defaultConfig()
+	if err := dataFromJsonFile(fileName, &loaded); err != nil {
+		return err
+	}
+	config = loaded
+	return nil
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExternalEvent is a membership change published by another system, such as a CRM
+type ExternalEvent struct {
+	ID          string `json:"id"` // Unique per event, so redelivered events are applied once
+	Type        string `json:"type"`
+	MemberName  string `json:"memberName"`
+	Email       string `json:"email,omitempty"`
+	Phone       string `json:"phone,omitempty"`
+	Level       string `json:"level,omitempty"`
+	DateOfBirth string `json:"dateOfBirth,omitempty"`
+	Tier        string `json:"tier,omitempty"`   // member.tierChanged
+	Reason      string `json:"reason,omitempty"` // member.banned
+	Until       string `json:"until,omitempty"`  // member.banned, last banned day
+}
+
+// External event types
+const (
+	externalMemberCreated     = "member.created"
+	externalMemberTierChanged = "member.tierChanged"
+	externalMemberBanned      = "member.banned"
+	externalMemberUnbanned    = "member.unbanned"
+)
+
+// externalActor is recorded in the audit log for changes made by external events
+const externalActor = "crm"
+
+// ConsumedEvent records an external event that was processed
+type ConsumedEvent struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	MemberName  string    `json:"memberName"`
+	Applied     bool      `json:"applied"`
+	Error       string    `json:"error,omitempty"` // Why the event was rejected
+	ProcessedAt time.Time `json:"processedAt"`
+}
+
+// consumedEventsKept bounds how many processed events are remembered for deduplication
+const consumedEventsKept = 5000
+
+var consumedEvents []ConsumedEvent // Temp Slice to hold processed external events
+
+// consumedEventIndex returns the position of a processed event, or -1.
+// Callers must hold the mutex.
+func consumedEventIndex(id string) int {
+	for i, event := range consumedEvents {
+		if event.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// applyExternalEvent changes local state as an external event describes.
+// Callers must hold the mutex.
+func applyExternalEvent(event ExternalEvent) error {
+	if event.MemberName == "" {
+		return fmt.Errorf("memberName is required")
+	}
+	index := memberIndex(event.MemberName)
+
+	switch event.Type {
+	case externalMemberCreated:
+		profile := Member{Name: event.MemberName}
+		if index >= 0 {
+			profile = members[index]
+		}
+		if event.Level != "" {
+			if _, ok := levelRanks[event.Level]; !ok {
+				return fmt.Errorf("invalid level %q", event.Level)
+			}
+			profile.Level = event.Level
+		}
+		if event.DateOfBirth != "" {
+			birth, err := time.Parse(dateLayout, event.DateOfBirth)
+			if err != nil || birth.After(today()) {
+				return fmt.Errorf("invalid dateOfBirth %q", event.DateOfBirth)
+			}
+			profile.DateOfBirth = event.DateOfBirth
+		}
+		if event.Email != "" {
+			email, err := normalizeEmail(event.Email)
+			if err != nil {
+				return fmt.Errorf("invalid email %q", event.Email)
+			}
+			// The CRM has already confirmed the address it holds
+			profile.EmailVerified = true
+			profile.Email = email
+		}
+		if event.Phone != "" {
+			phone, err := normalizePhone(event.Phone)
+			if err != nil {
+				return fmt.Errorf("invalid phone %q", event.Phone)
+			}
+			profile.Phone = phone
+		}
+		if event.Tier != "" {
+			if _, ok := waitlistTierRanks[event.Tier]; !ok {
+				return fmt.Errorf("invalid tier %q", event.Tier)
+			}
+			profile.Tier = event.Tier
+		}
+		return saveExternalMember(index, profile)
+
+	case externalMemberTierChanged:
+		if index < 0 {
+			return fmt.Errorf("member %s not found", event.MemberName)
+		}
+		if _, ok := waitlistTierRanks[event.Tier]; !ok {
+			return fmt.Errorf("invalid tier %q", event.Tier)
+		}
+		profile := members[index]
+		profile.Tier = event.Tier
+		return saveExternalMember(index, profile)
+
+	case externalMemberBanned:
+		if event.Until != "" {
+			if _, err := time.Parse(dateLayout, event.Until); err != nil {
+				return fmt.Errorf("invalid until %q", event.Until)
+			}
+		}
+		reason := event.Reason
+		if reason == "" {
+			reason = "Banned by " + externalActor
+		}
+		block := MemberBlock{MemberName: event.MemberName, Reason: reason, Until: event.Until, BlockedBy: externalActor, BlockedAt: now()}
+		var before interface{}
+		blockAt := blockIndex(event.MemberName)
+		if blockAt < 0 {
+			blocks = append(blocks, block)
+		} else {
+			before = blocks[blockAt]
+			blocks[blockAt] = block
+		}
+		if err := writeDataToJsonFile("blocks.json", blocks); err != nil {
+			if blockAt < 0 {
+				blocks = blocks[:len(blocks)-1]
+			} else {
+				blocks[blockAt] = before.(MemberBlock)
+			}
+			return fmt.Errorf("failed to save block data")
+		}
+		recordAudit(externalActor, "block", "member", 0, before, block)
+		return nil
+
+	case externalMemberUnbanned:
+		blockAt := blockIndex(event.MemberName)
+		if blockAt < 0 {
+			// Already unbanned, nothing to do
+			return nil
+		}
+		block := blocks[blockAt]
+		blocks = append(blocks[:blockAt], blocks[blockAt+1:]...)
+		if err := writeDataToJsonFile("blocks.json", blocks); err != nil {
+			blocks = append(blocks[:blockAt], append([]MemberBlock{block}, blocks[blockAt:]...)...)
+			return fmt.Errorf("failed to save block data")
+		}
+		recordAudit(externalActor, "unblock", "member", 0, block, nil)
+		return nil
+	}
+	return fmt.Errorf("unknown event type %q", event.Type)
+}
+
+// saveExternalMember creates or replaces a profile for an external event.
+// Callers must hold the mutex.
+func saveExternalMember(index int, profile Member) error {
+	var before interface{}
+	if index < 0 {
+		members = append(members, profile)
+	} else {
+		before = members[index]
+		members[index] = profile
+	}
+	if err := writeDataToJsonFile("members.json", members); err != nil {
+		if index < 0 {
+			members = members[:len(members)-1]
+		} else {
+			members[index] = before.(Member)
+		}
+		return fmt.Errorf("failed to save member data")
+	}
+	recordAudit(externalActor, "update", "member", 0, before, profile)
+	return nil
+}
+
+// consumeExternalEvent applies an event once. Redelivered events are skipped,
+// and rejected events are recorded so they are not retried forever. It reports
+// whether the event had already been processed.
+func consumeExternalEvent(event ExternalEvent) (ConsumedEvent, bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if index := consumedEventIndex(event.ID); index >= 0 {
+		return consumedEvents[index], true
+	}
+
+	consumed := ConsumedEvent{ID: event.ID, Type: event.Type, MemberName: event.MemberName, Applied: true, ProcessedAt: now()}
+	if err := applyExternalEvent(event); err != nil {
+		consumed.Applied = false
+		consumed.Error = err.Error()
+	}
+	consumedEvents = append(consumedEvents, consumed)
+	if len(consumedEvents) > consumedEventsKept {
+		consumedEvents = consumedEvents[len(consumedEvents)-consumedEventsKept:]
+	}
+	if err := writeDataToJsonFile("consumed_events.json", consumedEvents); err != nil {
+		fmt.Println("Error saving consumed events:", err)
+	}
+	logData("External event processed", consumed)
+	return consumed, false
+}
+
+// decodeExternalEvent parses a message from the queue
+func decodeExternalEvent(data []byte) (ExternalEvent, error) {
+	var event ExternalEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return event, err
+	}
+	if strings.TrimSpace(event.ID) == "" || event.Type == "" {
+		return event, fmt.Errorf("id and type are required")
+	}
+	return event, nil
+}
+
+// runEventConsumer subscribes to the configured NATS subject in a queue group,
+// so several instances share the work, and reconnects when the connection drops
+func runEventConsumer() {
+	if config.EventConsumerAddress == "" {
+		return
+	}
+	go func() {
+		for {
+			if err := consumeNATS(config.EventConsumerAddress, config.EventConsumerSubject, config.EventConsumerQueue); err != nil {
+				fmt.Println("Error consuming events:", err)
+			}
+			time.Sleep(5 * time.Second)
+		}
+	}()
+}
+
+// consumeNATS applies the messages on a subject until the connection fails
+func consumeNATS(address, subject, queue string) error {
+	conn, reader, err := natsDial(address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(fmt.Sprintf("SUB %s %s 1\r\n", subject, queue))); err != nil {
+		return err
+	}
+	// The server pings idle connections, so no read deadline is needed
+	conn.SetDeadline(time.Time{})
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case fields[0] == "PING":
+			if _, err := conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case fields[0] == "-ERR":
+			return fmt.Errorf("NATS error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		case fields[0] == "MSG" && (len(fields) == 4 || len(fields) == 5):
+			// MSG <subject> <sid> [reply-to] <size>
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil {
+				return fmt.Errorf("invalid NATS message size %q", fields[len(fields)-1])
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return err
+			}
+			event, err := decodeExternalEvent(payload[:size])
+			if err != nil {
+				fmt.Println("Error decoding external event:", err)
+				continue
+			}
+			consumeExternalEvent(event)
+		}
+	}
+}
+
+// Handler for pushing external events over HTTP and listing processed ones
+func externalEventsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		// Newest first
+		processed := []ConsumedEvent{}
+		for i := len(consumedEvents) - 1; i >= 0; i-- {
+			processed = append(processed, consumedEvents[i])
+		}
+		mutex.Unlock()
+		successResponse(w, http.StatusOK, "External events retrieved successfully", map[string]interface{}{"events": processed})
+
+	case http.MethodPost:
+		var event ExternalEvent
+		if err := decodeBody(r, &event); err != nil || strings.TrimSpace(event.ID) == "" || event.Type == "" {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body, id and type are required")
+			return
+		}
+		consumed, duplicate := consumeExternalEvent(event)
+		if duplicate {
+			successResponse(w, http.StatusOK, "External event already processed", consumed)
+			return
+		}
+		if !consumed.Applied {
+			errorResponse(w, http.StatusUnprocessableEntity, "External event rejected: "+consumed.Error)
+			return
+		}
+		successResponse(w, http.StatusOK, "External event applied successfully", consumed)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
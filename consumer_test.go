@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestExternalEvents verifies CRM events create members, change tiers and ban members once each.
+func TestExternalEvents(t *testing.T) {
+	setupTestEnvironment()
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"Member Created", `{"id":"e1","type":"member.created","memberName":"Ann","email":"Ann@Example.com","level":"beginner"}`, http.StatusOK},
+		{"Redelivered", `{"id":"e1","type":"member.created","memberName":"Ann","level":"advanced"}`, http.StatusOK},
+		{"Tier Upgraded", `{"id":"e2","type":"member.tierChanged","memberName":"Ann","tier":"premium"}`, http.StatusOK},
+		{"Unknown Tier", `{"id":"e3","type":"member.tierChanged","memberName":"Ann","tier":"gold"}`, http.StatusUnprocessableEntity},
+		{"Unknown Member", `{"id":"e4","type":"member.tierChanged","memberName":"Zed","tier":"premium"}`, http.StatusUnprocessableEntity},
+		{"Unknown Type", `{"id":"e5","type":"member.renamed","memberName":"Ann"}`, http.StatusUnprocessableEntity},
+		{"Missing ID", `{"type":"member.created","memberName":"Ann"}`, http.StatusBadRequest},
+		{"Banned", `{"id":"e6","type":"member.banned","memberName":"Ann","reason":"Unpaid fees"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			externalEventsHandler(rec, httptest.NewRequest(http.MethodPost, "/admin/events", bytes.NewReader([]byte(tt.body))))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	if len(members) != 1 || members[0].Email != "ann@example.com" || !members[0].EmailVerified || members[0].Level != "beginner" || members[0].Tier != "premium" {
+		t.Fatalf("expected the redelivered event to be skipped and the tier upgraded, got %+v", members)
+	}
+	if len(consumedEvents) != 6 {
+		t.Errorf("expected every distinct event to be recorded, got %+v", consumedEvents)
+	}
+
+	// The ban stops bookings until the CRM lifts it
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10}}
+	classId = 2
+	rec := httptest.NewRecorder()
+	bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"Ann","className":"Yoga","date":"15-12-2099"}`))))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected a banned member to be refused, got %d", rec.Code)
+	}
+	consumeExternalEvent(ExternalEvent{ID: "e7", Type: externalMemberUnbanned, MemberName: "Ann"})
+	rec = httptest.NewRecorder()
+	bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"Ann","className":"Yoga","date":"15-12-2099"}`))))
+	if rec.Code != http.StatusCreated {
+		t.Errorf("expected the unbanned member to book, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Profile updates keep the CRM tier
+	req := httptest.NewRequest(http.MethodPut, "/members/Ann", bytes.NewReader([]byte(`{"level":"advanced","tier":"staff"}`)))
+	req.SetPathValue("name", "Ann")
+	memberProfileHandler(httptest.NewRecorder(), req)
+	if members[0].Tier != "premium" {
+		t.Errorf("expected the tier to be kept, got %s", members[0].Tier)
+	}
+}
+
+// TestNATSEventConsumer verifies events published on the subscribed subject are applied.
+func TestNATSEventConsumer(t *testing.T) {
+	setupTestEnvironment()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	subscribed := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("INFO {}\r\n"))
+		reader := bufio.NewReader(conn)
+		reader.ReadString('\n') // CONNECT
+		sub, _ := reader.ReadString('\n')
+		subscribed <- sub
+		for i, payload := range []string{
+			`{"id":"n1","type":"member.created","memberName":"Ben","tier":"staff"}`,
+			`not json`,
+			`{"id":"n1","type":"member.created","memberName":"Ben","tier":"standard"}`,
+		} {
+			fmt.Fprintf(conn, "MSG crm.members 1 %d\r\n%s\r\n", len(payload), payload)
+			if i == 0 {
+				conn.Write([]byte("PING\r\n"))
+			}
+		}
+		// Closing the connection ends the consumer
+		reader.ReadString('\n')
+	}()
+
+	done := make(chan error, 1)
+	go func() { done <- consumeNATS(listener.Addr().String(), "crm.members", "gym-api") }()
+	if sub := <-subscribed; sub != "SUB crm.members gym-api 1\r\n" {
+		t.Errorf("expected a queue subscription, got %q", sub)
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the consumer to stop when the connection closed")
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(members) != 1 || members[0].Name != "Ben" || members[0].Tier != "staff" || len(consumedEvents) != 1 {
+		t.Errorf("expected Ben to be created once, got %+v %+v", members, consumedEvents)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// e164Pattern matches a phone number in E.164 form: a plus sign and up to 15 digits
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// normalizeEmail validates an email address and returns it trimmed and lower-cased
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	address, err := mail.ParseAddress(email)
+	// Display names such as "John <john@example.com>" are not accepted
+	if err != nil || address.Address != email {
+		return "", errors.New("invalid email")
+	}
+	local, domain, _ := strings.Cut(email, "@")
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", errors.New("invalid email")
+	}
+	return strings.ToLower(local + "@" + domain), nil
+}
+
+// normalizePhone validates a phone number and returns it in E.164 form.
+// Spaces, dashes, dots and parentheses are dropped and a leading 00 becomes +.
+func normalizePhone(phone string) (string, error) {
+	phone = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "").Replace(strings.TrimSpace(phone))
+	if strings.HasPrefix(phone, "00") {
+		phone = "+" + phone[2:]
+	}
+	if !e164Pattern.MatchString(phone) {
+		return "", errors.New("invalid phone")
+	}
+	return phone, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestNormalizeContactDetails verifies emails and phone numbers are validated and normalized.
+func TestNormalizeContactDetails(t *testing.T) {
+	emails := []struct {
+		input string
+		want  string
+		valid bool
+	}{
+		{input: " John.Doe@Example.COM ", want: "john.doe@example.com", valid: true},
+		{input: "john+gym@example.co.uk", want: "john+gym@example.co.uk", valid: true},
+		{input: "john@localhost", valid: false},
+		{input: "John <john@example.com>", valid: false},
+		{input: "not-an-email", valid: false},
+		{input: "john@example.", valid: false},
+	}
+	for _, tt := range emails {
+		t.Run("Email "+tt.input, func(t *testing.T) {
+			got, err := normalizeEmail(tt.input)
+			if (err == nil) != tt.valid || got != tt.want {
+				t.Errorf("expected %q (valid %v), got %q, %v", tt.want, tt.valid, got, err)
+			}
+		})
+	}
+
+	phones := []struct {
+		input string
+		want  string
+		valid bool
+	}{
+		{input: "+44 7700 900123", want: "+447700900123", valid: true},
+		{input: "0044 (7700) 900-123", want: "+447700900123", valid: true},
+		{input: "+1.415.555.0100", want: "+14155550100", valid: true},
+		{input: "07700 900123", valid: false},
+		{input: "+0123456789", valid: false},
+		{input: "+1234", valid: false},
+		{input: "+44 7700 900123 ext 5", valid: false},
+	}
+	for _, tt := range phones {
+		t.Run("Phone "+tt.input, func(t *testing.T) {
+			got, err := normalizePhone(tt.input)
+			if (err == nil) != tt.valid || got != tt.want {
+				t.Errorf("expected %q (valid %v), got %q, %v", tt.want, tt.valid, got, err)
+			}
+		})
+	}
+}
+
+// TestMemberProfileContactDetails verifies profiles store normalized contact details.
+func TestMemberProfileContactDetails(t *testing.T) {
+	setupTestEnvironment()
+
+	tests := []struct {
+		name       string
+		body       string
+		statusCode int
+	}{
+		{name: "Valid Details", body: `{"email":"John@Example.com","phone":"+44 7700 900123"}`, statusCode: http.StatusOK},
+		{name: "Invalid Email", body: `{"email":"john@","phone":"+447700900123"}`, statusCode: http.StatusBadRequest},
+		{name: "Invalid Phone", body: `{"email":"john@example.com","phone":"12"}`, statusCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/members/John", strings.NewReader(tt.body))
+			req.SetPathValue("name", "John")
+			rec := httptest.NewRecorder()
+			memberProfileHandler(rec, req)
+
+			if rec.Code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+		})
+	}
+
+	if len(members) != 1 || members[0].Email != "john@example.com" || members[0].Phone != "+447700900123" {
+		t.Errorf("expected normalized contact details, got %+v", members)
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// CreditEntry is one change to a member's class-pack credits. The ledger is
+// append-only: a member's balance is the sum of their entries.
+type CreditEntry struct {
+	ID         int        `json:"id"`
+	MemberName string     `json:"memberName"`
+	Delta      int        `json:"delta"`  // Credits added, or taken when negative
+	Reason     string     `json:"reason"` // e.g. "pack", "booking" or "refund"
+	BookingID  int        `json:"bookingId,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"` // When unused credits of a pack expire, nil never
+	LotID      int        `json:"lotId,omitempty"`     // Entry whose credits an expiry entry removes
+	WarnedAt   *time.Time `json:"warnedAt,omitempty"`  // When the member was told the credits expire soon
+	Actor      string     `json:"actor"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// Reasons for credit entries made by the system
+const (
+	creditReasonBooking = "booking"
+	creditReasonRefund  = "refund"
+	creditReasonExpiry  = "expiry"
+)
+
+// notifyCreditsExpiring warns a member their unused credits expire soon
+const notifyCreditsExpiring = "creditsExpiring"
+
+// creditLot is what is left of the credits one entry added
+type creditLot struct {
+	EntryID   int        `json:"entryId"`
+	Credits   int        `json:"credits"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// paymentCredits pays for a booking from the member's class-pack credits
+const paymentCredits = "credits"
+
+var (
+	creditEntries []CreditEntry // Temp Slice to hold the credits ledger
+	creditEntryId = 1           // Incremental ID for credit entries
+)
+
+// creditLots replays a member's ledger and returns the unused credits of each
+// entry that added some. Credits are spent from the lot that expires first,
+// lots that never expire last. Callers must hold the mutex.
+func creditLots(memberName string) []creditLot {
+	var lots []creditLot
+	for _, entry := range creditEntries {
+		if entry.MemberName != memberName {
+			continue
+		}
+		if entry.Delta > 0 {
+			lots = append(lots, creditLot{EntryID: entry.ID, Credits: entry.Delta, ExpiresAt: entry.ExpiresAt})
+			continue
+		}
+		owed := -entry.Delta
+		if entry.LotID != 0 {
+			for i := range lots {
+				if lots[i].EntryID == entry.LotID {
+					lots[i].Credits -= owed
+				}
+			}
+			continue
+		}
+		// Lots that had expired when the credits were spent cannot pay for them
+		order := make([]int, 0, len(lots))
+		for i, lot := range lots {
+			if lot.Credits > 0 && (lot.ExpiresAt == nil || lot.ExpiresAt.After(entry.CreatedAt)) {
+				order = append(order, i)
+			}
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			expiresA, expiresB := lots[order[a]].ExpiresAt, lots[order[b]].ExpiresAt
+			return expiresA != nil && (expiresB == nil || expiresA.Before(*expiresB))
+		})
+		for _, i := range order {
+			spent := min(owed, lots[i].Credits)
+			lots[i].Credits -= spent
+			owed -= spent
+		}
+	}
+
+	unused := lots[:0]
+	for _, lot := range lots {
+		if lot.Credits > 0 {
+			unused = append(unused, lot)
+		}
+	}
+	return unused
+}
+
+// creditBalance returns the credits a member can still spend, leaving out
+// those past their expiry the expiry job has not removed yet.
+// Callers must hold the mutex.
+func creditBalance(memberName string) int {
+	balance := 0
+	for _, lot := range creditLots(memberName) {
+		if lot.ExpiresAt == nil || lot.ExpiresAt.After(now()) {
+			balance += lot.Credits
+		}
+	}
+	return balance
+}
+
+// addCreditEntry appends an entry to the ledger and persists it, undoing the
+// entry when it cannot be saved. Callers must hold the mutex.
+func addCreditEntry(entry CreditEntry) (CreditEntry, error) {
+	entry.ID = creditEntryId
+	entry.CreatedAt = now()
+	creditEntries = append(creditEntries, entry)
+	creditEntryId++
+	if err := writeDataToJsonFile("credits.json", creditEntries); err != nil {
+		creditEntries = creditEntries[:len(creditEntries)-1]
+		creditEntryId--
+		return CreditEntry{}, err
+	}
+	return entry, nil
+}
+
+// creditCost returns the credits a booking costs when paid for with them,
+// or a rejection when it cannot be. Callers must hold the mutex.
+func creditCost(booking Booking) (int, *requestRejection) {
+	if booking.ResourceID != 0 {
+		return 0, &requestRejection{http.StatusBadRequest, "Resource bookings cannot be paid for with credits"}
+	}
+	class := findClassOn(booking.ClassName, booking.Date.Time)
+	if class == nil {
+		return 0, &requestRejection{http.StatusBadRequest, "Class is not available on the specified date"}
+	}
+	cost := max(class.CreditCost, 1)
+	if creditBalance(booking.MemberName) < cost {
+		return 0, &requestRejection{http.StatusBadRequest, "Not enough credits for this class"}
+	}
+	return cost, nil
+}
+
+// Handler for a member's credits balance and ledger, and for adding credits,
+// e.g. when a class pack is bought, or correcting them
+func memberCreditsHandler(w http.ResponseWriter, r *http.Request) {
+	memberName := r.PathValue("name")
+	if memberName == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid member name")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		entries := []CreditEntry{}
+		for _, entry := range creditEntries {
+			if entry.MemberName == memberName {
+				entries = append(entries, entry)
+			}
+		}
+		balance := creditBalance(memberName)
+		expiring := []creditLot{}
+		for _, lot := range creditLots(memberName) {
+			if lot.ExpiresAt != nil && lot.ExpiresAt.After(now()) {
+				expiring = append(expiring, lot)
+			}
+		}
+		mutex.Unlock()
+		successResponse(w, http.StatusOK, "Credits retrieved successfully", map[string]interface{}{
+			"memberName": memberName,
+			"balance":    balance,
+			"expiring":   expiring,
+			"entries":    entries,
+		})
+
+	case http.MethodPost:
+		var request struct {
+			Credits   int    `json:"credits"`
+			Reason    string `json:"reason"`
+			ExpiresOn string `json:"expiresOn"` // Last day the credits can be used, DD-MM-YYYY
+		}
+		if err := decodeBody(r, &request); err != nil || request.Credits == 0 || request.Reason == "" {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body, give non-zero credits and a reason")
+			return
+		}
+		var expiresAt *time.Time
+		if request.ExpiresOn != "" {
+			lastDay, err := time.Parse(dateLayout, request.ExpiresOn)
+			if err != nil {
+				errorResponse(w, http.StatusBadRequest, "Invalid expiresOn format, use DD-MM-YYYY")
+				return
+			}
+			if request.Credits < 0 {
+				errorResponse(w, http.StatusBadRequest, "Only added credits can expire")
+				return
+			}
+			if lastDay.Before(today()) {
+				errorResponse(w, http.StatusBadRequest, "expiresOn cannot be in the past")
+				return
+			}
+			expiry := lastDay.AddDate(0, 0, 1)
+			expiresAt = &expiry
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if creditBalance(memberName)+request.Credits < 0 {
+			errorResponse(w, http.StatusBadRequest, "Credits cannot go below zero")
+			return
+		}
+		entry, err := addCreditEntry(CreditEntry{MemberName: memberName, Delta: request.Credits, Reason: request.Reason, ExpiresAt: expiresAt, Actor: actorFromRequest(r)})
+		if err != nil {
+			errorResponse(w, http.StatusInternalServerError, "Failed to save credit data")
+			return
+		}
+		recordAudit(entry.Actor, "adjust", "credits", entry.ID, nil, entry)
+
+		response := map[string]interface{}{"entry": entry, "balance": creditBalance(memberName)}
+		successResponse(w, http.StatusCreated, "Credits updated successfully", response)
+		logData("Credits updated successfully", response)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// expireCredits removes the unused credits of lots past their expiry from the
+// ledger, warns members whose credits expire within
+// config.CreditExpiryWarningDays, and returns how many credits expired
+func expireCredits() (int, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	memberNames := map[string]bool{}
+	for _, entry := range creditEntries {
+		memberNames[entry.MemberName] = true
+	}
+	expired := 0
+	for memberName := range memberNames {
+		for _, lot := range creditLots(memberName) {
+			if lot.ExpiresAt == nil || lot.ExpiresAt.After(now()) {
+				continue
+			}
+			entry, err := addCreditEntry(CreditEntry{MemberName: memberName, Delta: -lot.Credits, Reason: creditReasonExpiry, LotID: lot.EntryID, Actor: "system"})
+			if err != nil {
+				return expired, err
+			}
+			recordAudit("system", "expire", "credits", entry.ID, nil, entry)
+			expired += lot.Credits
+		}
+	}
+
+	// Lots are marked as warned and saved before any member is told, so a
+	// failed save neither loses a warning nor sends it twice
+	warnBefore := now().AddDate(0, 0, config.CreditExpiryWarningDays)
+	warnedIndexes := []int{}
+	warnings := []func(){}
+	for memberName := range memberNames {
+		for _, lot := range creditLots(memberName) {
+			if config.CreditExpiryWarningDays <= 0 || lot.ExpiresAt == nil || !lot.ExpiresAt.After(now()) || !lot.ExpiresAt.Before(warnBefore) {
+				continue
+			}
+			index := creditEntryIndex(lot.EntryID)
+			if creditEntries[index].WarnedAt != nil {
+				continue
+			}
+			warnedAt := now()
+			creditEntries[index].WarnedAt = &warnedAt
+			warnedIndexes = append(warnedIndexes, index)
+			lastDay := lot.ExpiresAt.AddDate(0, 0, -1).Format(dateLayout)
+			message := fmt.Sprintf("%d of your credits expire after %s. Book a class to use them.", lot.Credits, lastDay)
+			warnings = append(warnings, func() {
+				notifyMember(memberName, notifyCreditsExpiring, "Credits expiring soon", message, map[string]string{"event": notifyCreditsExpiring, "credits": strconv.Itoa(lot.Credits), "expiresOn": lastDay})
+			})
+		}
+	}
+	if len(warnings) > 0 {
+		if err := writeDataToJsonFile("credits.json", creditEntries); err != nil {
+			for _, index := range warnedIndexes {
+				creditEntries[index].WarnedAt = nil
+			}
+			return expired, err
+		}
+		for _, warn := range warnings {
+			warn()
+		}
+	}
+	if expired > 0 {
+		logData("Credits expired", map[string]int{"credits": expired})
+	}
+	return expired, nil
+}
+
+// creditEntryIndex returns the position of a ledger entry, or -1.
+// Callers must hold the mutex.
+func creditEntryIndex(id int) int {
+	for i, entry := range creditEntries {
+		if entry.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// runCreditExpiry is the scheduled entry point for expireCredits
+func runCreditExpiry() {
+	if _, err := expireCredits(); err != nil {
+		fmt.Println("Error expiring credits:", err)
+	}
+}
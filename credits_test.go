@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCreditsLedger verifies class packs add credits and bookings paid with them take credits.
+func TestCreditsLedger(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{
+		{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 5, Price: 1500, Currency: "EUR"},
+		{ID: 2, ClassName: "Reformer", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 5, CreditCost: 3},
+	}
+	classId = 3
+
+	for _, tt := range []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"Buy Pack", `{"credits":4,"reason":"pack"}`, http.StatusCreated},
+		{"Missing Reason", `{"credits":4}`, http.StatusBadRequest},
+		{"Below Zero", `{"credits":-10,"reason":"correction"}`, http.StatusBadRequest},
+		{"Expiry In Past", `{"credits":4,"reason":"pack","expiresOn":"01-01-2000"}`, http.StatusBadRequest},
+		{"Expiring Correction", `{"credits":-1,"reason":"correction","expiresOn":"01-01-2099"}`, http.StatusBadRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/members/Ann/credits", bytes.NewReader([]byte(tt.body)))
+			req.SetPathValue("name", "Ann")
+			rec := httptest.NewRecorder()
+			memberCreditsHandler(rec, req)
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		balance        int
+	}{
+		{"Pay With Credits", `{"memberName":"Ann","className":"Yoga","date":"02-12-2099","paymentMethod":"credits"}`, http.StatusCreated, 3},
+		{"Class Costs More", `{"memberName":"Ann","className":"Reformer","date":"02-12-2099","paymentMethod":"credits"}`, http.StatusCreated, 0},
+		{"Not Enough Credits", `{"memberName":"Ann","className":"Yoga","date":"03-12-2099","paymentMethod":"credits"}`, http.StatusBadRequest, 0},
+		{"Unknown Method", `{"memberName":"Ann","className":"Yoga","date":"03-12-2099","paymentMethod":"cash"}`, http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(tt.body))))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+			if balance := creditBalance("Ann"); balance != tt.balance {
+				t.Errorf("expected a balance of %d, got %d", tt.balance, balance)
+			}
+		})
+	}
+	if bookings[0].CreditsUsed != 1 || bookings[0].Price != 0 || bookings[1].CreditsUsed != 3 {
+		t.Errorf("expected credit bookings to cost credits rather than money, got %+v", bookings)
+	}
+	if entry := creditEntries[len(creditEntries)-1]; entry.Delta != -3 || entry.BookingID != bookings[1].ID || entry.Reason != creditReasonBooking {
+		t.Errorf("expected the booking in the ledger, got %+v", entry)
+	}
+}
+
+// TestCreditExpiry verifies unused pack credits expire, spending the soonest to expire first, and members are warned.
+func TestCreditExpiry(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2099, 12, 1, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	sent := map[string]string{}
+	defer func(send func(to, subject, body string) error) { sendEmail = send }(sendEmail)
+	sendEmail = func(to, subject, body string) error {
+		sent[to] = body
+		return nil
+	}
+
+	soon := time.Date(2099, 12, 3, 0, 0, 0, 0, time.UTC)
+	past := time.Date(2099, 11, 30, 0, 0, 0, 0, time.UTC)
+	members = []Member{{Name: "Ann", Email: "ann@example.com"}, {Name: "Ben", Email: "ben@example.com"}}
+	creditEntries = []CreditEntry{
+		{ID: 1, MemberName: "Ann", Delta: 5, Reason: "pack", ExpiresAt: &soon, CreatedAt: time.Date(2099, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: 2, MemberName: "Ann", Delta: 3, Reason: "pack", CreatedAt: time.Date(2099, 11, 2, 0, 0, 0, 0, time.UTC)},
+		{ID: 3, MemberName: "Ann", Delta: -2, Reason: creditReasonBooking, CreatedAt: time.Date(2099, 11, 15, 0, 0, 0, 0, time.UTC)},
+		{ID: 4, MemberName: "Ben", Delta: 2, Reason: "pack", ExpiresAt: &past, CreatedAt: time.Date(2099, 11, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	creditEntryId = 5
+	if creditBalance("Ann") != 6 || creditBalance("Ben") != 0 {
+		t.Fatalf("expected expired credits to be unusable, got %d and %d", creditBalance("Ann"), creditBalance("Ben"))
+	}
+
+	if expired, err := expireCredits(); err != nil || expired != 2 {
+		t.Fatalf("expected Ben's 2 credits to expire, got %d: %v", expired, err)
+	}
+	if entry := creditEntries[4]; entry.MemberName != "Ben" || entry.Delta != -2 || entry.LotID != 4 || entry.Reason != creditReasonExpiry {
+		t.Errorf("expected an expiry entry, got %+v", entry)
+	}
+	if !strings.Contains(sent["ann@example.com"], "3 of your credits expire after 02-12-2099") || sent["ben@example.com"] != "" {
+		t.Errorf("expected only Ann to be warned about the rest of her pack, got %v", sent)
+	}
+	if creditEntries[0].WarnedAt == nil {
+		t.Error("expected the warning to be recorded")
+	}
+
+	delete(sent, "ann@example.com")
+	if expired, _ := expireCredits(); expired != 0 || len(sent) != 0 {
+		t.Errorf("expected nothing more to happen, got %d expired and %v", expired, sent)
+	}
+
+	now = func() time.Time { return time.Date(2099, 12, 3, 10, 0, 0, 0, time.UTC) }
+	if expired, _ := expireCredits(); expired != 3 || creditBalance("Ann") != 3 || len(creditEntries) != 6 {
+		t.Errorf("expected Ann's last 3 pack credits to expire, got %d with %d left", expired, creditBalance("Ann"))
+	}
+}
+
+// TestCreditExpiryWarningSaveFailure verifies members are warned only once the warning is saved.
+func TestCreditExpiryWarningSaveFailure(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2099, 12, 1, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	sent := map[string]string{}
+	defer func(send func(to, subject, body string) error) { sendEmail = send }(sendEmail)
+	sendEmail = func(to, subject, body string) error {
+		sent[to] = body
+		return nil
+	}
+
+	soon := time.Date(2099, 12, 3, 0, 0, 0, 0, time.UTC)
+	members = []Member{{Name: "Ann", Email: "ann@example.com"}}
+	creditEntries = []CreditEntry{{ID: 1, MemberName: "Ann", Delta: 5, Reason: "pack", ExpiresAt: &soon, CreatedAt: time.Date(2099, 11, 1, 0, 0, 0, 0, time.UTC)}}
+	creditEntryId = 2
+
+	// A directory in its place makes the credits file impossible to write
+	os.Remove("credits.json")
+	os.Mkdir("credits.json", 0755)
+	_, err := expireCredits()
+	os.Remove("credits.json")
+	if err == nil {
+		t.Fatal("expected the failed save to be reported")
+	}
+	if len(sent) != 0 || creditEntries[0].WarnedAt != nil {
+		t.Errorf("expected no warning before it is saved, got %v and %+v", sent, creditEntries[0])
+	}
+
+	if _, err := expireCredits(); err != nil || !strings.Contains(sent["ann@example.com"], "5 of your credits expire") || creditEntries[0].WarnedAt == nil {
+		t.Errorf("expected the warning on the next run, got %v and %v", err, sent)
+	}
+}
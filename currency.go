@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// currency describes how amounts in an ISO 4217 currency are written
+type currency struct {
+	Exponent int    // Digits after the decimal point, e.g. 2 for cents
+	Symbol   string // Written before the amount, empty to write the code after it
+}
+
+// currencies are the ISO 4217 codes prices may be set in
+var currencies = map[string]currency{
+	"AUD": {2, "A$"},
+	"BRL": {2, "R$"},
+	"CAD": {2, "CA$"},
+	"CHF": {2, ""},
+	"CNY": {2, "CN¥"},
+	"DKK": {2, ""},
+	"EUR": {2, "€"},
+	"GBP": {2, "£"},
+	"HKD": {2, "HK$"},
+	"INR": {2, "₹"},
+	"JPY": {0, "¥"},
+	"KRW": {0, "₩"},
+	"KWD": {3, ""},
+	"MXN": {2, "MX$"},
+	"NOK": {2, ""},
+	"NZD": {2, "NZ$"},
+	"SEK": {2, ""},
+	"SGD": {2, "S$"},
+	"USD": {2, "$"},
+	"ZAR": {2, "R"},
+}
+
+// checkCurrency rejects codes that are not supported ISO 4217 currencies
+func checkCurrency(code string) *requestRejection {
+	if _, ok := currencies[code]; !ok {
+		return &requestRejection{http.StatusBadRequest, "Invalid currency, use a supported ISO 4217 code such as USD or EUR"}
+	}
+	return nil
+}
+
+// formatAmount writes an amount in minor units for display, e.g. 1250 USD
+// as "$12.50", 1200 JPY as "¥1,200" and 1500 KWD as "1.500 KWD"
+func formatAmount(minor int, code string) string {
+	sign, amount := groupMinorUnits(minor, code)
+	if symbol := currencies[code].Symbol; symbol != "" {
+		return sign + symbol + amount
+	}
+	return formatAmountCode(minor, code)
+}
+
+// formatAmountCode writes an amount with its code rather than its symbol,
+// e.g. "12.50 USD", for output limited to ASCII
+func formatAmountCode(minor int, code string) string {
+	sign, amount := groupMinorUnits(minor, code)
+	return fmt.Sprintf("%s%s %s", sign, amount, code)
+}
+
+// groupMinorUnits splits an amount into its sign and its digits, with the
+// currency's decimals and the whole part grouped in thousands
+func groupMinorUnits(minor int, code string) (string, string) {
+	info, ok := currencies[code]
+	if !ok {
+		info = currency{Exponent: 2}
+	}
+	sign := ""
+	if minor < 0 {
+		sign, minor = "-", -minor
+	}
+	digits := strconv.Itoa(minor)
+	if len(digits) <= info.Exponent {
+		digits = strings.Repeat("0", info.Exponent-len(digits)+1) + digits
+	}
+	whole, fraction := digits[:len(digits)-info.Exponent], digits[len(digits)-info.Exponent:]
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	if fraction != "" {
+		return sign, whole + "." + fraction
+	}
+	return sign, whole
+}
+
+// studioCurrency returns the currency a studio's prices are in: the one it is
+// configured with, or else that of its priced classes, leaving out the class
+// with excludeID. It is empty while neither is set. Callers must hold the mutex.
+func studioCurrency(studioID, excludeID int) string {
+	if index := studioIndex(studioID); index >= 0 && studios[index].Currency != "" {
+		return studios[index].Currency
+	}
+	for _, class := range classes {
+		if class.StudioID == studioID && class.ID != excludeID && class.DeletedAt == nil && class.Price > 0 && class.Currency != "" {
+			return class.Currency
+		}
+	}
+	return ""
+}
+
+// checkClassCurrency validates the currency of a class and that it matches the
+// other prices at its studio. Callers must hold the mutex.
+func checkClassCurrency(class Class) *requestRejection {
+	if class.Currency == "" {
+		return nil
+	}
+	if rejection := checkCurrency(class.Currency); rejection != nil {
+		return rejection
+	}
+	if class.StudioID == 0 {
+		return nil
+	}
+	if expected := studioCurrency(class.StudioID, class.ID); expected != "" && expected != class.Currency {
+		return &requestRejection{http.StatusBadRequest, "Prices at this studio are in " + expected}
+	}
+	return nil
+}
+
+// classCurrency returns the currency of a class's price: its own, else its
+// studio's, else the default. Callers must hold the mutex.
+func classCurrency(class Class) string {
+	if class.Currency != "" {
+		return class.Currency
+	}
+	if class.StudioID != 0 {
+		if studio := studioCurrency(class.StudioID, class.ID); studio != "" {
+			return studio
+		}
+	}
+	return config.DefaultCurrency
+}
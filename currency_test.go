@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestFormatAmount verifies amounts are written with their currency's decimals and symbol.
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		minor    int
+		currency string
+		expected string
+	}{
+		{1250, "USD", "$12.50"},
+		{5, "EUR", "€0.05"},
+		{123456789, "GBP", "£1,234,567.89"},
+		{1200, "JPY", "¥1,200"},
+		{1500, "KWD", "1.500 KWD"},
+		{99900, "CHF", "999.00 CHF"},
+		{-250, "USD", "-$2.50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := formatAmount(tt.minor, tt.currency); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestClassCurrency verifies prices stay in one currency per studio and are fixed on bookings.
+func TestClassCurrency(t *testing.T) {
+	setupTestEnvironment()
+	defer func(send func(to, subject, body string) error) { sendEmail = send }(sendEmail)
+	var sent []string
+	sendEmail = func(to, subject, body string) error {
+		sent = append(sent, body)
+		return nil
+	}
+	studios = []Studio{{ID: 1, Name: "Berlin"}, {ID: 2, Name: "Tokyo", Currency: "JPY"}}
+	studioId = 3
+	members = []Member{{Name: "Ann", Email: "ann@example.com"}}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"First Price Sets Studio Currency", `{"className":"Yoga","startDate":"01-12-2099","endDate":"10-12-2099","capacity":5,"price":1500,"currency":"EUR","studioId":1}`, http.StatusCreated},
+		{"Other Currency At Studio", `{"className":"Spin","startDate":"01-12-2099","endDate":"10-12-2099","capacity":5,"price":1500,"currency":"USD","studioId":1}`, http.StatusBadRequest},
+		{"Studio Currency", `{"className":"Zen","startDate":"01-12-2099","endDate":"10-12-2099","capacity":5,"price":2000,"currency":"EUR","studioId":2}`, http.StatusBadRequest},
+		{"Inherits Studio Currency", `{"className":"Zen","startDate":"01-12-2099","endDate":"10-12-2099","capacity":5,"price":2000,"studioId":2}`, http.StatusCreated},
+		{"Unknown Currency", `{"className":"Pump","startDate":"01-12-2099","endDate":"10-12-2099","capacity":5,"price":900,"currency":"XYZ"}`, http.StatusBadRequest},
+		{"Default Currency", `{"className":"Pump","startDate":"01-12-2099","endDate":"10-12-2099","capacity":5,"price":900}`, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			classHandler(rec, httptest.NewRequest(http.MethodPost, "/classes", bytes.NewReader([]byte(tt.body))))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+	if classes[1].Currency != "JPY" || classes[2].Currency != config.DefaultCurrency {
+		t.Errorf("expected currencies to be filled in, got %+v", classes)
+	}
+
+	rec := httptest.NewRecorder()
+	bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"Ann","className":"Yoga","date":"02-12-2099","price":1}`))))
+	if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"price":"€15.00"`) {
+		t.Fatalf("expected the formatted price in the response, got %d: %s", rec.Code, rec.Body.String())
+	}
+	// Later price changes leave the booking as it was
+	classes[0].Price = 1800
+	if bookings[0].Price != 1500 || bookings[0].Currency != "EUR" {
+		t.Errorf("expected the booking to keep the class price, got %d %s", bookings[0].Price, bookings[0].Currency)
+	}
+	if len(sent) != 1 || !strings.Contains(sent[0], "The price is €15.00.") {
+		t.Errorf("expected the confirmation to state the price, got %v", sent)
+	}
+
+	// Studios cannot take a currency their classes are not priced in
+	req := httptest.NewRequest(http.MethodPut, "/studios/1", bytes.NewReader([]byte(`{"name":"Berlin","currency":"USD"}`)))
+	req.SetPathValue("id", "1")
+	rec = httptest.NewRecorder()
+	studioItemHandler(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected a clashing studio currency to conflict, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Date is a calendar day, sent and stored as DD-MM-YYYY. It is parsed once,
+// when a request or data file is decoded, so the code using it works with a
+// time.Time and never meets a malformed date. Dates are always midnight UTC,
+// so two of them compare equal with == and can key maps.
+type Date struct {
+	time.Time
+}
+
+// dateOf returns the calendar day a time falls on
+func dateOf(t time.Time) Date {
+	year, month, day := t.Date()
+	return Date{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// errInvalidDate reports a date not given as DD-MM-YYYY
+var errInvalidDate = errors.New("invalid date format, use DD-MM-YYYY")
+
+// parseDate reads a DD-MM-YYYY date. An empty one is the zero Date, left for
+// callers to require.
+func parseDate(value string) (Date, error) {
+	if value == "" {
+		return Date{}, nil
+	}
+	parsed, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return Date{}, errInvalidDate
+	}
+	return Date{parsed}, nil
+}
+
+// String formats the date as DD-MM-YYYY, or empty for the zero Date
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(dateLayout)
+}
+
+// MarshalText writes the date in its wire format
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText reads a date in its wire format
+func (d *Date) UnmarshalText(text []byte) error {
+	parsed, err := parseDate(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// MarshalJSON writes the date as a DD-MM-YYYY string, in place of the
+// timestamp of the embedded time
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON reads a DD-MM-YYYY string, leaving the date unchanged for null
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return errInvalidDate
+	}
+	return d.UnmarshalText([]byte(text))
+}
+
+// bodyErrorMessage is the message refusing a request body that could not be
+// decoded, naming the date format when a date was the problem
+func bodyErrorMessage(err error) string {
+	if errors.Is(err, errInvalidDate) {
+		return "Invalid date format, use DD-MM-YYYY"
+	}
+	return "Invalid request body"
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testDate returns the Date of a DD-MM-YYYY literal
+func testDate(value string) Date {
+	date, err := parseDate(value)
+	if err != nil {
+		panic(err)
+	}
+	return date
+}
+
+// TestDateCodec verifies dates are read and written as DD-MM-YYYY and malformed ones refused when decoded.
+func TestDateCodec(t *testing.T) {
+	var class Class
+	if err := json.Unmarshal([]byte(`{"startDate":"01-12-2099","endDate":"31-12-2099"}`), &class); err != nil {
+		t.Fatalf("expected the dates to decode, got %v", err)
+	}
+	if class.StartDate.Day() != 1 || class.EndDate.Month() != 12 || !class.StartDate.Before(class.EndDate.Time) {
+		t.Errorf("expected parsed dates, got %v and %v", class.StartDate, class.EndDate)
+	}
+	encoded, _ := json.Marshal(class)
+	if !strings.Contains(string(encoded), `"startDate":"01-12-2099","endDate":"31-12-2099"`) {
+		t.Errorf("expected the wire format back, got %s", encoded)
+	}
+	if encoded, _ := json.Marshal(Date{}); string(encoded) != `""` {
+		t.Errorf("expected an empty date, got %s", encoded)
+	}
+
+	for _, body := range []string{`{"startDate":"2099-12-01"}`, `{"startDate":31}`} {
+		if err := json.Unmarshal([]byte(body), &class); !errors.Is(err, errInvalidDate) {
+			t.Errorf("expected %s to be refused as a date, got %v", body, err)
+		}
+	}
+
+	// Class requests naming a malformed date are refused with the format
+	setupTestEnvironment()
+	rec := httptest.NewRecorder()
+	classHandler(rec, httptest.NewRequest(http.MethodPost, "/classes", strings.NewReader(`{"className":"Yoga","startDate":"2099-12-01","endDate":"31-12-2099","capacity":5}`)))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Invalid date format, use DD-MM-YYYY") || len(classes) != 0 {
+		t.Errorf("expected the class to be refused, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// So are bookings
+	rec = httptest.NewRecorder()
+	bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(`{"memberName":"Alice","date":"12/16/2024","className":"Pilates"}`)))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Invalid date format, use DD-MM-YYYY") || len(bookings) != 0 {
+		t.Errorf("expected the booking to be refused, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
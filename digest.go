@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// notifyWeeklyDigest is the weekly summary of a member's upcoming bookings
+const notifyWeeklyDigest = "weeklyDigest"
+
+// digestTemplate renders the weekly digest email
+var digestTemplate = template.Must(template.New("digest").Funcs(template.FuncMap{"title": bookingTitle}).Parse(`Hi {{.MemberName}},
+
+Here are your bookings for the week of {{.From}} to {{.To}}:
+{{range .Bookings}}
+- {{.Date}}: {{title .}} (code {{.Code}}{{if eq .Status "pending"}}, awaiting confirmation{{end}})
+{{- end}}
+
+See you there!
+`))
+
+// digest is the data the digest template renders
+type digest struct {
+	MemberName string
+	From       string
+	To         string
+	Bookings   []Booking
+}
+
+// sendWeeklyDigests emails every member their active bookings for the next
+// seven days and returns how many digests were sent
+func sendWeeklyDigests() int {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	from := today()
+	to := from.AddDate(0, 0, 7)
+	upcoming := map[string][]Booking{}
+	for _, booking := range bookings {
+		if !bookingActive(booking) || bookingStatus(booking) == bookingStatusAttended || booking.Date.Before(from) || !booking.Date.Before(to) {
+			continue
+		}
+		upcoming[booking.MemberName] = append(upcoming[booking.MemberName], booking)
+	}
+
+	sent := 0
+	for _, member := range members {
+		memberBookings := upcoming[member.Name]
+		if member.Email == "" || len(memberBookings) == 0 || !notificationAllowed(member, notifyWeeklyDigest, channelEmail, now()) {
+			continue
+		}
+		sort.Slice(memberBookings, func(i, j int) bool {
+			if dateI, dateJ := memberBookings[i].Date, memberBookings[j].Date; dateI != dateJ {
+				return dateI.Before(dateJ.Time)
+			}
+			return memberBookings[i].ID < memberBookings[j].ID
+		})
+
+		var body strings.Builder
+		if err := digestTemplate.Execute(&body, digest{
+			MemberName: member.Name,
+			From:       from.Format(dateLayout),
+			To:         to.AddDate(0, 0, -1).Format(dateLayout),
+			Bookings:   memberBookings,
+		}); err != nil {
+			fmt.Println("Error rendering weekly digest:", err)
+			continue
+		}
+		if err := sendEmail(member.Email, "Your bookings this week", body.String()); err != nil {
+			fmt.Println("Error sending weekly digest:", err)
+			continue
+		}
+		sent++
+	}
+	if sent > 0 {
+		logData("Weekly digests sent", map[string]int{"members": sent})
+	}
+	return sent
+}
+
+// runWeeklyDigest is the scheduled entry point for sendWeeklyDigests
+func runWeeklyDigest() {
+	sendWeeklyDigests()
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWeeklyDigest verifies members are emailed one digest of the coming week's bookings.
+func TestWeeklyDigest(t *testing.T) {
+	setupTestEnvironment()
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2099, 12, 1, 12, 0, 0, 0, time.UTC) }
+	members = []Member{
+		{Name: "Ann", Email: "ann@example.com"},
+		{Name: "Ben", Email: "ben@example.com", Preferences: &NotificationPreferences{Events: map[string]map[string]bool{notifyWeeklyDigest: {channelEmail: false}}}},
+		{Name: "Cat"},
+	}
+	bookings = []Booking{
+		{ID: 1, MemberName: "Ann", ClassName: "Spin", Date: testDate("05-12-2099"), Code: "BK-SPIN01", Status: bookingStatusConfirmed},
+		{ID: 2, MemberName: "Ann", ClassName: "Yoga", Date: testDate("01-12-2099"), Code: "BK-YOGA01", Status: bookingStatusPending},
+		{ID: 3, MemberName: "Ann", ClassName: "Pilates", Date: testDate("03-12-2099"), Status: bookingStatusCancelled},
+		{ID: 4, MemberName: "Ann", ClassName: "Boxing", Date: testDate("08-12-2099"), Status: bookingStatusConfirmed},
+		{ID: 5, MemberName: "Ben", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed},
+		{ID: 6, MemberName: "Cat", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed},
+	}
+
+	sent := map[string]string{}
+	defer func(send func(to, subject, body string) error) { sendEmail = send }(sendEmail)
+	sendEmail = func(to, subject, body string) error {
+		sent[to] = body
+		return nil
+	}
+
+	if count := sendWeeklyDigests(); count != 1 || len(sent) != 1 {
+		t.Fatalf("expected one digest, got %d: %v", count, sent)
+	}
+	body := sent["ann@example.com"]
+	if !strings.Contains(body, "01-12-2099 to 07-12-2099") {
+		t.Errorf("expected the week's dates, got %q", body)
+	}
+	yoga, spin := strings.Index(body, "Yoga"), strings.Index(body, "Spin")
+	if yoga < 0 || spin < 0 || yoga > spin || !strings.Contains(body, "awaiting confirmation") {
+		t.Errorf("expected upcoming bookings in date order, got %q", body)
+	}
+	if strings.Contains(body, "Pilates") || strings.Contains(body, "Boxing") {
+		t.Errorf("expected cancelled and later bookings to be left out, got %q", body)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// encryptedPrefix marks a data file written with AES-GCM encryption
+var encryptedPrefix = []byte("GCM1")
+
+// KeyProvider supplies the key used to encrypt data files at rest.
+// Implementations may read it from the environment or fetch it from a KMS.
+type KeyProvider interface {
+	DataKey() ([]byte, error)
+}
+
+// envKeyProvider reads a base64 encoded AES key from an environment variable
+type envKeyProvider struct {
+	variable string
+}
+
+// DataKey decodes the key held in the environment variable
+func (p envKeyProvider) DataKey() ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(os.Getenv(p.variable))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", p.variable, err)
+	}
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		return nil, fmt.Errorf("invalid %s: key must be 16, 24 or 32 bytes", p.variable)
+	}
+	return key, nil
+}
+
+// keyProvider enables encryption at rest when set, nil stores plain JSON
+var keyProvider KeyProvider
+
+// configureEncryption enables encryption when DATA_ENCRYPTION_KEY is set
+func configureEncryption() {
+	if os.Getenv("DATA_ENCRYPTION_KEY") != "" {
+		keyProvider = envKeyProvider{variable: "DATA_ENCRYPTION_KEY"}
+	}
+}
+
+// newGCM builds an AES-GCM cipher from the provider's key
+func newGCM() (cipher.AEAD, error) {
+	key, err := keyProvider.DataKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptData seals data when encryption is enabled and returns it unchanged otherwise
+func encryptData(data []byte) ([]byte, error) {
+	if keyProvider == nil {
+		return data, nil
+	}
+	gcm, err := newGCM()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	// Layout: prefix | nonce | ciphertext
+	sealed := append([]byte{}, encryptedPrefix...)
+	sealed = append(sealed, nonce...)
+	return gcm.Seal(sealed, nonce, data, nil), nil
+}
+
+// decryptData opens encrypted data and passes plain JSON through, so existing
+// unencrypted files keep loading after encryption is switched on
+func decryptData(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, encryptedPrefix) {
+		return data, nil
+	}
+	if keyProvider == nil {
+		return nil, errors.New("data file is encrypted but no key is configured")
+	}
+	gcm, err := newGCM()
+	if err != nil {
+		return nil, err
+	}
+	data = data[len(encryptedPrefix):]
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("encrypted data file is truncated")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"testing"
+)
+
+// TestEncryptionAtRest verifies data files are encrypted and transparently decrypted.
+func TestEncryptionAtRest(t *testing.T) {
+	setupTestEnvironment()
+	t.Setenv("DATA_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+	keyProvider = envKeyProvider{variable: "DATA_ENCRYPTION_KEY"}
+	defer func() { keyProvider = nil }()
+
+	written := []Class{{ID: 1, ClassName: "Pilates", StartDate: testDate("15-12-2024"), EndDate: testDate("20-12-2024"), Capacity: 10}}
+	if err := writeDataToJsonFile("classes.json", written); err != nil {
+		t.Fatalf("unexpected error writing: %v", err)
+	}
+
+	raw, _ := os.ReadFile("classes.json")
+	if !bytes.HasPrefix(raw, encryptedPrefix) || bytes.Contains(raw, []byte("Pilates")) {
+		t.Fatalf("expected the file to be encrypted, got %q", raw)
+	}
+
+	var loaded []Class
+	if err := dataFromJsonFile("classes.json", &loaded); err != nil {
+		t.Fatalf("unexpected error reading: %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].ClassName != "Pilates" {
+		t.Errorf("expected the class to round trip, got %+v", loaded)
+	}
+
+	// Plain JSON written before encryption was enabled still loads.
+	os.WriteFile("bookings.json", []byte(`[{"id":1,"memberName":"John Doe"}]`), 0666)
+	var plain []Booking
+	if err := dataFromJsonFile("bookings.json", &plain); err != nil || len(plain) != 1 {
+		t.Errorf("expected plain JSON to load, got %+v, %v", plain, err)
+	}
+
+	// Encrypted files cannot be read without the key.
+	keyProvider = nil
+	if err := dataFromJsonFile("classes.json", &loaded); err == nil {
+		t.Errorf("expected an error reading an encrypted file without a key")
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+)
+
+// countRentals returns how many of an item active bookings rent for a class on a date.
+// Callers must hold the mutex.
+func countRentals(className string, date Date, item string) int {
+	count := 0
+	for _, booking := range bookings {
+		if booking.ClassName == className && booking.Date == date && bookingActive(booking) && slices.Contains(booking.Rentals, item) {
+			count++
+		}
+	}
+	return count
+}
+
+// checkRentals rejects rental add-ons the class does not offer or has run out of
+// for the session. Callers must hold the mutex.
+func checkRentals(class *Class, newBooking Booking) *requestRejection {
+	for i, item := range newBooking.Rentals {
+		if slices.Contains(newBooking.Rentals[:i], item) {
+			return &requestRejection{http.StatusBadRequest, "Each rental item can only be added once"}
+		}
+		stock, offered := class.RentalInventory[item]
+		if !offered {
+			return &requestRejection{http.StatusBadRequest, "Class does not offer " + item + " for rent"}
+		}
+		if countRentals(newBooking.ClassName, newBooking.Date, item) >= stock {
+			return &requestRejection{http.StatusBadRequest, "No " + item + " left to rent for this session"}
+		}
+	}
+	return nil
+}
+
+// rentalsAvailable returns the rental stock left for a class on a date.
+// Callers must hold the mutex.
+func rentalsAvailable(class *Class, date Date) map[string]int {
+	available := map[string]int{}
+	for item, stock := range class.RentalInventory {
+		available[item] = stock - countRentals(class.ClassName, date, item)
+	}
+	return available
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestEquipmentRentals verifies rental add-ons are limited by each session's inventory.
+func TestEquipmentRentals(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{
+		ID: 1, ClassName: "Spin", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10,
+		Equipment:       []string{"cycling shoes", "towel"},
+		RentalInventory: map[string]int{"cycling shoes": 1, "towel": 5},
+	}}
+	classId = 2
+
+	tests := []struct {
+		name       string
+		body       string
+		statusCode int
+	}{
+		{name: "Rent Shoes", body: `{"memberName":"Ann","className":"Spin","date":"15-12-2099","rentals":["cycling shoes","towel"]}`, statusCode: http.StatusCreated},
+		{name: "Shoes Sold Out", body: `{"memberName":"Ben","className":"Spin","date":"15-12-2099","rentals":["cycling shoes"]}`, statusCode: http.StatusBadRequest},
+		{name: "Shoes Free On Another Day", body: `{"memberName":"Ben","className":"Spin","date":"16-12-2099","rentals":["cycling shoes"]}`, statusCode: http.StatusCreated},
+		{name: "Item Not Offered", body: `{"memberName":"Cat","className":"Spin","date":"15-12-2099","rentals":["helmet"]}`, statusCode: http.StatusBadRequest},
+		{name: "Item Twice", body: `{"memberName":"Cat","className":"Spin","date":"15-12-2099","rentals":["towel","towel"]}`, statusCode: http.StatusBadRequest},
+		{name: "No Rentals", body: `{"memberName":"Cat","className":"Spin","date":"15-12-2099"}`, statusCode: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(tt.body))))
+
+			if rec.Code != tt.statusCode {
+				t.Fatalf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+			if tt.name != "Rent Shoes" {
+				return
+			}
+
+			// The confirmation lists the rentals, what to bring and what is left.
+			var response struct {
+				Data struct {
+					Booking           Booking        `json:"booking"`
+					RequiredEquipment []string       `json:"requiredEquipment"`
+					RentalsAvailable  map[string]int `json:"rentalsAvailable"`
+				} `json:"data"`
+			}
+			json.NewDecoder(rec.Body).Decode(&response)
+			if len(response.Data.Booking.Rentals) != 2 || len(response.Data.RequiredEquipment) != 2 {
+				t.Errorf("expected rentals and required equipment in the confirmation, got %+v", response.Data)
+			}
+			if response.Data.RentalsAvailable["cycling shoes"] != 0 || response.Data.RentalsAvailable["towel"] != 4 {
+				t.Errorf("expected the remaining stock, got %+v", response.Data.RentalsAvailable)
+			}
+		})
+	}
+}
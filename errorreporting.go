@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// ErrorEvent describes a failure worth alerting an operator about
+type ErrorEvent struct {
+	Time    time.Time         `json:"time"`
+	Kind    string            `json:"kind"` // "panic", "response" or "persistence"
+	Message string            `json:"message"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// ErrorReporter forwards error events to an external alerting service
+type ErrorReporter interface {
+	Report(event ErrorEvent)
+}
+
+// errorReporter receives error events when configured, nil disables reporting
+var errorReporter ErrorReporter
+
+// reportError sends an error event to the configured reporter
+func reportError(kind, message string, details map[string]string) {
+	if kind == "persistence" {
+		alertOps(opsPersistenceFailure, fmt.Sprintf("Persistence failure: %s (%s)", message, details["error"]))
+	}
+	if errorReporter == nil {
+		return
+	}
+	errorReporter.Report(ErrorEvent{Time: now(), Kind: kind, Message: message, Details: details})
+}
+
+// dsnReporter posts error events to a Sentry-style DSN
+// (scheme://publicKey@host/projectId)
+type dsnReporter struct {
+	storeURL  string
+	publicKey string
+	client    *http.Client
+}
+
+// newDSNReporter parses a DSN into a reporter
+func newDSNReporter(dsn string) (*dsnReporter, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+	projectID := strings.Trim(parsed.Path, "/")
+	if parsed.User == nil || parsed.User.Username() == "" || projectID == "" {
+		return nil, errors.New("invalid DSN, use scheme://publicKey@host/projectId")
+	}
+	return &dsnReporter{
+		storeURL:  fmt.Sprintf("%s://%s/api/%s/store/", parsed.Scheme, parsed.Host, projectID),
+		publicKey: parsed.User.Username(),
+		client:    &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+// Report sends the event in the background so failures never slow down requests
+func (d *dsnReporter) Report(event ErrorEvent) {
+	body, err := json.Marshal(map[string]interface{}{
+		"timestamp": event.Time.Format(time.RFC3339),
+		"level":     "error",
+		"platform":  "go",
+		"logger":    event.Kind,
+		"message":   event.Message,
+		"extra":     event.Details,
+	})
+	if err != nil {
+		return
+	}
+
+	go func() {
+		req, err := http.NewRequest(http.MethodPost, d.storeURL, bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=interviewBackend/1.0, sentry_key=%s", d.publicKey))
+		resp, err := d.client.Do(req)
+		if err != nil {
+			fmt.Println("Error reporting error event:", err)
+			return
+		}
+		resp.Body.Close()
+	}()
+}
+
+// configureErrorReporter enables error reporting when a DSN is configured
+func configureErrorReporter() error {
+	errorReporter = nil
+	if config.ErrorReporterDSN == "" {
+		return nil
+	}
+	reporter, err := newDSNReporter(config.ErrorReporterDSN)
+	if err != nil {
+		return err
+	}
+	errorReporter = reporter
+	return nil
+}
+
+// recoverPanics turns a panicking handler into a 500 response and reports it
+func recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				reportError("panic", fmt.Sprint(recovered), map[string]string{
+					"method": r.Method,
+					"path":   r.URL.Path,
+					"stack":  string(debug.Stack()),
+				})
+				errorResponse(w, http.StatusInternalServerError, "Internal server error")
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingReporter keeps reported events for inspection
+type recordingReporter struct {
+	events []ErrorEvent
+}
+
+func (r *recordingReporter) Report(event ErrorEvent) {
+	r.events = append(r.events, event)
+}
+
+// TestErrorReporting verifies panics, 5xx responses and persistence failures are reported.
+func TestErrorReporting(t *testing.T) {
+	setupTestEnvironment()
+	reporter := &recordingReporter{}
+	errorReporter = reporter
+	defer func() { errorReporter = nil }()
+
+	// A panicking handler is recovered into a 500 response.
+	panicking := recoverPanics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	rec := httptest.NewRecorder()
+	panicking.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/classes", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status code %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+
+	// Client errors are not reported.
+	errorResponse(httptest.NewRecorder(), http.StatusBadRequest, "Invalid request body")
+
+	// Persistence failures are reported.
+	writeDataToJsonFile("missing-dir/classes.json", classes)
+
+	kinds := []string{}
+	for _, event := range reporter.events {
+		kinds = append(kinds, event.Kind)
+	}
+	if strings.Join(kinds, ",") != "panic,response,persistence" {
+		t.Errorf("expected panic, response and persistence events, got %v", kinds)
+	}
+	if reporter.events[0].Message != "boom" || reporter.events[0].Details["stack"] == "" {
+		t.Errorf("expected the panic value and stack, got %+v", reporter.events[0])
+	}
+}
+
+// TestDSNReporter verifies events are posted to the DSN's store endpoint.
+func TestDSNReporter(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	bodies := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		received <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	reporter, err := newDSNReporter(strings.Replace(server.URL, "http://", "http://public@", 1) + "/42")
+	if err != nil {
+		t.Fatalf("unexpected error parsing DSN: %v", err)
+	}
+	reporter.Report(ErrorEvent{Time: time.Now(), Kind: "response", Message: "Failed to save booking data"})
+
+	select {
+	case req := <-received:
+		if req.URL.Path != "/api/42/store/" || !strings.Contains(req.Header.Get("X-Sentry-Auth"), "sentry_key=public") {
+			t.Errorf("unexpected request %s %v", req.URL.Path, req.Header)
+		}
+		if body := <-bodies; body["message"] != "Failed to save booking data" {
+			t.Errorf("unexpected event body %v", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the event to be posted")
+	}
+
+	if _, err := newDSNReporter("https://example.com"); err == nil {
+		t.Errorf("expected a DSN without key and project to be rejected")
+	}
+}
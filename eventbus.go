@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// busDestination is the outbox destination of the event bus
+const busDestination = "bus"
+
+// EventBus publishes domain events to a message broker
+type EventBus interface {
+	Publish(eventType string, payload []byte) error
+}
+
+// eventBus receives booking and class events when configured, nil disables it
+var eventBus EventBus
+
+// configureEventBus connects the event bus selected in the config
+func configureEventBus() error {
+	eventBus = nil
+	if config.EventBusDriver == "" {
+		return nil
+	}
+	if config.EventBusFormat != "json" && config.EventBusFormat != "protobuf" {
+		return fmt.Errorf("invalid eventBusFormat %q, use json or protobuf", config.EventBusFormat)
+	}
+	if config.EventBusAddress == "" || config.EventBusSubject == "" {
+		return fmt.Errorf("eventBusAddress and eventBusSubject are required")
+	}
+	switch config.EventBusDriver {
+	case "nats":
+		eventBus = &natsPublisher{address: config.EventBusAddress, subject: config.EventBusSubject, format: config.EventBusFormat}
+	case "kafka":
+		eventBus = &kafkaRESTPublisher{url: strings.TrimSuffix(config.EventBusAddress, "/"), topic: config.EventBusSubject, format: config.EventBusFormat, client: &http.Client{Timeout: 10 * time.Second}}
+	default:
+		return fmt.Errorf("invalid eventBusDriver %q, use nats or kafka", config.EventBusDriver)
+	}
+	return nil
+}
+
+// encodeBusEvent converts an outbox payload to the wire format
+func encodeBusEvent(format string, payload []byte) ([]byte, error) {
+	if format != "protobuf" {
+		return payload, nil
+	}
+	var event struct {
+		ID       int             `json:"id"`
+		Type     string          `json:"type"`
+		Time     time.Time       `json:"time"`
+		EntityID int             `json:"entityId"`
+		Data     json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+	var data bytes.Buffer
+	if len(event.Data) > 0 {
+		if err := json.Compact(&data, event.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	// Wire format of the DomainEvent message in event.proto
+	var message []byte
+	message = appendProtoVarint(message, 1, uint64(event.ID))
+	message = appendProtoBytes(message, 2, []byte(event.Type))
+	message = appendProtoVarint(message, 3, uint64(event.Time.UnixMilli()))
+	message = appendProtoVarint(message, 4, uint64(event.EntityID))
+	message = appendProtoBytes(message, 5, data.Bytes())
+	return message, nil
+}
+
+// appendProtoVarint appends a varint field, skipping the default zero value
+func appendProtoVarint(message []byte, field int, value uint64) []byte {
+	if value == 0 {
+		return message
+	}
+	message = binary.AppendUvarint(message, uint64(field)<<3)
+	return binary.AppendUvarint(message, value)
+}
+
+// appendProtoBytes appends a length-delimited field, skipping empty values
+func appendProtoBytes(message []byte, field int, value []byte) []byte {
+	if len(value) == 0 {
+		return message
+	}
+	message = binary.AppendUvarint(message, uint64(field)<<3|2)
+	message = binary.AppendUvarint(message, uint64(len(value)))
+	return append(message, value...)
+}
+
+// natsPublisher publishes to a NATS server over its text protocol, one
+// subject per event type under the configured prefix
+type natsPublisher struct {
+	address string
+	subject string
+	format  string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// natsDial connects to a NATS server and completes the handshake
+func natsDial(address string) (net.Conn, *bufio.Reader, error) {
+	conn, err := net.DialTimeout("tcp", address, 5*time.Second)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+	info, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(info, "INFO") {
+		conn.Close()
+		return nil, nil, fmt.Errorf("unexpected NATS greeting %q", strings.TrimSpace(info))
+	}
+	if _, err := conn.Write([]byte("CONNECT {\"verbose\":false,\"pedantic\":false,\"name\":\"gym-api\"}\r\n")); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, reader, nil
+}
+
+// connect opens the connection. Callers must hold p.mu.
+func (p *natsPublisher) connect() error {
+	conn, reader, err := natsDial(p.address)
+	if err != nil {
+		return err
+	}
+	p.conn, p.reader = conn, reader
+	return nil
+}
+
+// Publish sends the event and waits for the server to acknowledge it with a PONG
+func (p *natsPublisher) Publish(eventType string, payload []byte) error {
+	message, err := encodeBusEvent(p.format, payload)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		if err := p.connect(); err != nil {
+			return err
+		}
+	}
+	if err := p.publish(p.subject+"."+eventType, message); err != nil {
+		// Reconnect on the next attempt
+		p.conn.Close()
+		p.conn, p.reader = nil, nil
+		return err
+	}
+	return nil
+}
+
+// publish writes a PUB and flushes it with a PING. Callers must hold p.mu.
+func (p *natsPublisher) publish(subject string, message []byte) error {
+	p.conn.SetDeadline(time.Now().Add(5 * time.Second))
+	frame := fmt.Sprintf("PUB %s %d\r\n", subject, len(message))
+	if _, err := p.conn.Write(append(append([]byte(frame), message...), []byte("\r\nPING\r\n")...)); err != nil {
+		return err
+	}
+	for {
+		line, err := p.reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+// kafkaRESTPublisher produces to a Kafka topic through a Kafka REST Proxy,
+// keyed by entity so events about one booking or class stay in order
+type kafkaRESTPublisher struct {
+	url    string
+	topic  string
+	format string
+	client *http.Client
+}
+
+// Publish produces the event as a single record
+func (p *kafkaRESTPublisher) Publish(eventType string, payload []byte) error {
+	var event struct {
+		EntityID int `json:"entityId"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return err
+	}
+	key := strings.SplitN(eventType, ".", 2)[0] + ":" + strconv.Itoa(event.EntityID)
+
+	contentType := "application/vnd.kafka.json.v2+json"
+	record := map[string]interface{}{"key": key, "value": json.RawMessage(payload)}
+	if p.format == "protobuf" {
+		message, err := encodeBusEvent(p.format, payload)
+		if err != nil {
+			return err
+		}
+		// Binary records carry base64 keys and values
+		contentType = "application/vnd.kafka.binary.v2+json"
+		record = map[string]interface{}{
+			"key":   base64.StdEncoding.EncodeToString([]byte(key)),
+			"value": base64.StdEncoding.EncodeToString(message),
+		}
+	}
+	body, err := json.Marshal(map[string]interface{}{"records": []interface{}{record}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.url+"/topics/"+p.topic, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Kafka REST proxy responded %d", resp.StatusCode)
+	}
+	return nil
+}
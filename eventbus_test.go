@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeNATSServer accepts one connection and records the messages published on it
+func fakeNATSServer(t *testing.T) (string, chan [2]string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	published := make(chan [2]string, 10)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			fields := strings.Fields(line)
+			switch {
+			case len(fields) == 3 && fields[0] == "PUB":
+				var size int
+				fmt.Sscan(fields[2], &size)
+				payload := make([]byte, size+2)
+				io.ReadFull(reader, payload)
+				published <- [2]string{fields[1], string(payload[:size])}
+			case len(fields) == 1 && fields[0] == "PING":
+				conn.Write([]byte("PONG\r\n"))
+			}
+		}
+	}()
+	return listener.Addr().String(), published
+}
+
+// TestEventBusPublishing verifies booking events reach NATS and Kafka through the outbox.
+func TestEventBusPublishing(t *testing.T) {
+	setupTestEnvironment()
+	defer func() { eventBus = nil }()
+	address, published := fakeNATSServer(t)
+	config.EventBusDriver = "nats"
+	config.EventBusAddress = address
+	if err := configureEventBus(); err != nil {
+		t.Fatalf("expected the event bus to be configured, got %v", err)
+	}
+
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10}}
+	classId = 2
+	rec := httptest.NewRecorder()
+	bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"Ann","className":"Yoga","date":"15-12-2099"}`))))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the booking to be created, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if delivered := dispatchOutbox(); delivered != 1 {
+		t.Fatalf("expected the event to be published, got %d deliveries: %+v", delivered, outbox)
+	}
+	message := <-published
+	var event DomainEvent
+	if err := json.Unmarshal([]byte(message[1]), &event); err != nil || message[0] != "gym.events.booking.created" || event.EntityID != 1 {
+		t.Errorf("expected a JSON booking.created event, got %v", message)
+	}
+
+	var records []map[string]string
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Records []map[string]string `json:"records"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		records, contentType = body.Records, r.Header.Get("Content-Type")
+		if r.URL.Path != "/topics/gym.events" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	config.EventBusDriver = "kafka"
+	config.EventBusAddress = server.URL
+	config.EventBusFormat = "protobuf"
+	if err := configureEventBus(); err != nil {
+		t.Fatalf("expected the event bus to be configured, got %v", err)
+	}
+	if err := eventBus.Publish("booking.created", outbox[0].Payload); err != nil {
+		t.Fatalf("expected the event to be produced, got %v", err)
+	}
+	if len(records) != 1 || contentType != "application/vnd.kafka.binary.v2+json" {
+		t.Fatalf("expected one binary record, got %v %s", records, contentType)
+	}
+	key, _ := base64.StdEncoding.DecodeString(records[0]["key"])
+	value, _ := base64.StdEncoding.DecodeString(records[0]["value"])
+	if string(key) != "booking:1" {
+		t.Errorf("expected the record to be keyed by booking, got %q", key)
+	}
+	// The first field is the event ID as a varint
+	if len(value) < 2 || value[0] != 1<<3 {
+		t.Fatalf("expected a protobuf message, got %x", value)
+	}
+	if id, _ := binary.Uvarint(value[1:]); id != 1 || !bytes.Contains(value, []byte("booking.created")) {
+		t.Errorf("expected the protobuf message to carry the event, got %x", value)
+	}
+
+	config.EventBusFormat = "xml"
+	if err := configureEventBus(); err == nil {
+		t.Error("expected an invalid format to be rejected")
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// FeatureFlag switches a feature on by default, per environment or per tenant.
+// Tenant overrides win over environment overrides, which win over the default.
+type FeatureFlag struct {
+	Enabled      bool            `json:"enabled"`
+	Environments map[string]bool `json:"environments,omitempty"`
+	Tenants      map[string]bool `json:"tenants,omitempty"`
+}
+
+var (
+	featureFlags = map[string]FeatureFlag{} // Flags loaded from the flags file
+	flagsModTime time.Time                  // Modification time of the loaded flags file
+	flagsMutex   sync.RWMutex               // Guards featureFlags and flagsModTime
+)
+
+// loadFlags reads the flags file when it changed since the last load
+func loadFlags() error {
+	info, err := os.Stat(config.FlagsFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	flagsMutex.RLock()
+	unchanged := info.ModTime().Equal(flagsModTime)
+	flagsMutex.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	loaded := map[string]FeatureFlag{}
+	if err := dataFromJsonFile(config.FlagsFile, &loaded); err != nil {
+		return err
+	}
+
+	flagsMutex.Lock()
+	featureFlags = loaded
+	flagsModTime = info.ModTime()
+	flagsMutex.Unlock()
+	return nil
+}
+
+// runFlagsReload is the scheduled entry point for loadFlags
+func runFlagsReload() {
+	if err := loadFlags(); err != nil {
+		fmt.Println("Error reloading feature flags:", err)
+	}
+}
+
+// flagEnabled reports whether a feature is on for a tenant in the current environment.
+// Unknown flags are off.
+func flagEnabled(name, tenant string) bool {
+	flagsMutex.RLock()
+	flag, ok := featureFlags[name]
+	flagsMutex.RUnlock()
+	if !ok {
+		return false
+	}
+
+	if enabled, ok := flag.Tenants[tenant]; ok && tenant != "" {
+		return enabled
+	}
+	if enabled, ok := flag.Environments[config.Environment]; ok {
+		return enabled
+	}
+	return flag.Enabled
+}
+
+// tenantFromRequest identifies the tenant a request belongs to
+func tenantFromRequest(r *http.Request) string {
+	return r.Header.Get("X-Tenant")
+}
+
+// flagEnabledFor reports whether a feature is on for the tenant making a request
+func flagEnabledFor(r *http.Request, name string) bool {
+	return flagEnabled(name, tenantFromRequest(r))
+}
+
+// Handler for listing the feature flags as seen by the caller's tenant
+func flagsHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	flagsMutex.RLock()
+	names := make([]string, 0, len(featureFlags))
+	for name := range featureFlags {
+		names = append(names, name)
+	}
+	flagsMutex.RUnlock()
+
+	resolved := map[string]bool{}
+	for _, name := range names {
+		resolved[name] = flagEnabledFor(r, name)
+	}
+
+	response := map[string]interface{}{
+		"environment": config.Environment,
+		"tenant":      tenantFromRequest(r),
+		"flags":       resolved,
+	}
+	successResponse(w, http.StatusOK, "Feature flags retrieved successfully", response)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestFeatureFlags verifies flag resolution and hot reloading.
+func TestFeatureFlags(t *testing.T) {
+	setupTestEnvironment()
+	config.FlagsFile = filepath.Join(t.TempDir(), "flags.json")
+	config.Environment = "staging"
+
+	os.WriteFile(config.FlagsFile, []byte(`{
+		"waitlist": {"enabled": false, "environments": {"staging": true}, "tenants": {"studio-a": false}},
+		"dynamicPricing": {"enabled": false, "tenants": {"studio-b": true}}
+	}`), 0666)
+	if err := loadFlags(); err != nil {
+		t.Fatalf("unexpected error loading flags: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		flag    string
+		tenant  string
+		enabled bool
+	}{
+		{name: "Environment Override", flag: "waitlist", tenant: "", enabled: true},
+		{name: "Tenant Override Wins", flag: "waitlist", tenant: "studio-a", enabled: false},
+		{name: "Default Applies", flag: "dynamicPricing", tenant: "studio-a", enabled: false},
+		{name: "Tenant Enabled", flag: "dynamicPricing", tenant: "studio-b", enabled: true},
+		{name: "Unknown Flag", flag: "missing", tenant: "", enabled: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/flags", nil)
+			req.Header.Set("X-Tenant", tt.tenant)
+			if got := flagEnabledFor(req, tt.flag); got != tt.enabled {
+				t.Errorf("expected %s to be %v, got %v", tt.flag, tt.enabled, got)
+			}
+		})
+	}
+
+	// Changing the file is picked up on the next reload.
+	os.WriteFile(config.FlagsFile, []byte(`{"waitlist": {"enabled": false}}`), 0666)
+	os.Chtimes(config.FlagsFile, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	runFlagsReload()
+
+	if flagEnabled("waitlist", "") || flagEnabled("dynamicPricing", "studio-b") {
+		t.Errorf("expected the reloaded flags to apply, got %+v", featureFlags)
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GiftCard is prepaid money members spend on bookings by quoting its code
+type GiftCard struct {
+	ID               int                   `json:"id"`
+	Code             string                `json:"code"`
+	Currency         string                `json:"currency"`
+	InitialAmount    int                   `json:"initialAmount"` // In minor units
+	Balance          int                   `json:"balance"`       // In minor units
+	PurchaserName    string                `json:"purchaserName,omitempty"`
+	RecipientEmail   string                `json:"recipientEmail,omitempty"`
+	PaymentReference string                `json:"paymentReference,omitempty"` // Payment of a purchased card
+	Transactions     []GiftCardTransaction `json:"transactions"`
+	CreatedAt        time.Time             `json:"createdAt"`
+	UpdatedAt        time.Time             `json:"updatedAt"`
+}
+
+// GiftCardTransaction is one change to a gift card's balance
+type GiftCardTransaction struct {
+	Amount    int       `json:"amount"` // Added, or spent when negative
+	Reason    string    `json:"reason"` // e.g. "purchase", "redemption" or an admin's reason
+	BookingID int       `json:"bookingId,omitempty"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Reasons for gift card transactions made by the system
+const (
+	giftCardReasonPurchase   = "purchase"
+	giftCardReasonRedemption = "redemption"
+	giftCardReasonRefund     = "refund"
+)
+
+// giftCardCodeLength is the number of random characters in a gift card code,
+// longer than confirmation codes as the code alone spends the balance
+const giftCardCodeLength = 12
+
+var (
+	giftCards  []GiftCard // Temp Slice to hold gift cards
+	giftCardId = 1        // Incremental ID for gift cards
+)
+
+// newGiftCardCode returns a code, such as "GC-7F3K-9QAB-M2XT", that no other
+// gift card uses. Callers must hold the mutex.
+func newGiftCardCode() string {
+	for {
+		token := make([]byte, giftCardCodeLength)
+		rand.Read(token)
+		for i := range token {
+			token[i] = codeAlphabet[int(token[i])%len(codeAlphabet)]
+		}
+		code := fmt.Sprintf("GC-%s-%s-%s", token[:4], token[4:8], token[8:])
+		if giftCardByCode(code) < 0 {
+			return code
+		}
+	}
+}
+
+// giftCardByCode returns the position of the gift card with the given code,
+// or -1. Codes may be typed in any letter case. Callers must hold the mutex.
+func giftCardByCode(code string) int {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	for i, card := range giftCards {
+		if card.Code == code {
+			return i
+		}
+	}
+	return -1
+}
+
+// giftCardIndex returns the position of the gift card with the given ID, or -1.
+// Callers must hold the mutex.
+func giftCardIndex(id int) int {
+	for i, card := range giftCards {
+		if card.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// giftCardRequest is the body for buying or issuing a gift card
+type giftCardRequest struct {
+	Amount           int    `json:"amount"` // In minor units
+	Currency         string `json:"currency"`
+	PurchaserName    string `json:"purchaserName"`
+	RecipientEmail   string `json:"recipientEmail"`
+	PaymentReference string `json:"paymentReference"`
+	Reason           string `json:"reason"`
+}
+
+// issueGiftCard creates a gift card for the request, persists it and emails
+// the code to the recipient. Callers must hold the mutex.
+func issueGiftCard(request giftCardRequest, reason, actor string) (GiftCard, *requestRejection) {
+	if request.Amount <= 0 {
+		return GiftCard{}, &requestRejection{http.StatusBadRequest, "amount must be greater than zero"}
+	}
+	if request.Currency == "" {
+		request.Currency = config.DefaultCurrency
+	}
+	request.Currency = strings.ToUpper(request.Currency)
+	if rejection := checkCurrency(request.Currency); rejection != nil {
+		return GiftCard{}, rejection
+	}
+	if request.RecipientEmail != "" && !strings.Contains(request.RecipientEmail, "@") {
+		return GiftCard{}, &requestRejection{http.StatusBadRequest, "Invalid recipientEmail"}
+	}
+
+	card := GiftCard{
+		ID:               giftCardId,
+		Code:             newGiftCardCode(),
+		Currency:         request.Currency,
+		InitialAmount:    request.Amount,
+		Balance:          request.Amount,
+		PurchaserName:    request.PurchaserName,
+		RecipientEmail:   request.RecipientEmail,
+		PaymentReference: request.PaymentReference,
+		Transactions:     []GiftCardTransaction{{Amount: request.Amount, Reason: reason, Actor: actor, CreatedAt: now()}},
+		CreatedAt:        now(),
+		UpdatedAt:        now(),
+	}
+	giftCards = append(giftCards, card)
+	giftCardId++
+	if err := writeDataToJsonFile("giftcards.json", giftCards); err != nil {
+		giftCards = giftCards[:len(giftCards)-1]
+		giftCardId--
+		return GiftCard{}, &requestRejection{http.StatusInternalServerError, "Failed to save gift card data"}
+	}
+	recordAudit(actor, "issue", "giftCard", card.ID, nil, card)
+
+	if card.RecipientEmail != "" {
+		body := fmt.Sprintf("You have been given a gift card worth %s. Quote the code %s when booking a class.", formatAmount(card.Balance, card.Currency), card.Code)
+		if err := sendEmail(card.RecipientEmail, "Your gift card", body); err != nil {
+			fmt.Println("Error sending gift card:", err)
+		}
+	}
+	return card, nil
+}
+
+// adjustGiftCard changes a gift card's balance and persists it, undoing the
+// change when it cannot be saved. Callers must hold the mutex.
+func adjustGiftCard(index int, transaction GiftCardTransaction) error {
+	before := giftCards[index]
+	transaction.CreatedAt = now()
+	card := &giftCards[index]
+	card.Balance += transaction.Amount
+	card.Transactions = append(append([]GiftCardTransaction{}, card.Transactions...), transaction)
+	card.UpdatedAt = now()
+	if err := writeDataToJsonFile("giftcards.json", giftCards); err != nil {
+		giftCards[index] = before
+		return err
+	}
+	return nil
+}
+
+// checkGiftCard checks a booking can be part paid with the gift card it
+// quotes and returns the card's position. Callers must hold the mutex.
+func checkGiftCard(booking Booking) (int, *requestRejection) {
+	index := giftCardByCode(booking.GiftCardCode)
+	if index < 0 {
+		return -1, &requestRejection{http.StatusBadRequest, "Unknown gift card"}
+	}
+	card := giftCards[index]
+	if card.Balance <= 0 {
+		return -1, &requestRejection{http.StatusBadRequest, "Gift card has no balance left"}
+	}
+	if booking.PaymentMethod != "" || booking.ResourceID != 0 {
+		return -1, &requestRejection{http.StatusBadRequest, "Gift cards only pay for priced class bookings"}
+	}
+	class := findClassOn(booking.ClassName, booking.Date.Time)
+	if class == nil || class.Price == 0 {
+		return -1, &requestRejection{http.StatusBadRequest, "Gift cards only pay for priced class bookings"}
+	}
+	if currency := classCurrency(*class); currency != card.Currency {
+		return -1, &requestRejection{http.StatusBadRequest, fmt.Sprintf("Gift card is in %s but the class is priced in %s", card.Currency, currency)}
+	}
+	return index, nil
+}
+
+// amountDue returns what is left to pay for a booking after its gift card
+func amountDue(booking Booking) int {
+	return max(booking.Total, booking.Price) - booking.GiftCardAmount
+}
+
+// Handler for buying a gift card
+func giftCardPurchaseHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	var request giftCardRequest
+	if err := decodeBody(r, &request); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if request.PurchaserName == "" || request.PaymentReference == "" {
+		errorResponse(w, http.StatusBadRequest, "purchaserName and paymentReference are required")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	card, rejection := issueGiftCard(request, giftCardReasonPurchase, actorFromRequest(r))
+	if rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+	successResponse(w, http.StatusCreated, "Gift card purchased successfully", card)
+	logData("Gift card purchased successfully", map[string]interface{}{"id": card.ID, "amount": card.InitialAmount, "currency": card.Currency})
+}
+
+// Handler for checking a gift card's balance by its code
+func giftCardBalanceHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := giftCardByCode(r.PathValue("code"))
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Gift card not found")
+		return
+	}
+	card := giftCards[index]
+	successResponse(w, http.StatusOK, "Gift card retrieved successfully", map[string]interface{}{
+		"code":      card.Code,
+		"currency":  card.Currency,
+		"balance":   card.Balance,
+		"formatted": formatAmount(card.Balance, card.Currency),
+	})
+}
+
+// Handler for listing gift cards and issuing them without payment, e.g. as
+// compensation or for promotions
+func adminGiftCardHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		list := append([]GiftCard{}, giftCards...)
+		mutex.Unlock()
+		successResponse(w, http.StatusOK, "Gift cards retrieved successfully", list)
+
+	case http.MethodPost:
+		var request giftCardRequest
+		if err := decodeBody(r, &request); err != nil || request.Reason == "" {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body, give an amount and a reason")
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		card, rejection := issueGiftCard(request, request.Reason, actorFromRequest(r))
+		if rejection != nil {
+			errorResponse(w, rejection.StatusCode, rejection.Message)
+			return
+		}
+		successResponse(w, http.StatusCreated, "Gift card issued successfully", card)
+		logData("Gift card issued successfully", map[string]interface{}{"id": card.ID, "amount": card.InitialAmount, "currency": card.Currency})
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// Handler for viewing a gift card and correcting its balance
+func adminGiftCardItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid gift card id")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := giftCardIndex(id)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Gift card not found")
+			return
+		}
+		successResponse(w, http.StatusOK, "Gift card retrieved successfully", giftCards[index])
+
+	case http.MethodPost:
+		var request struct {
+			Amount int    `json:"amount"`
+			Reason string `json:"reason"`
+		}
+		if err := decodeBody(r, &request); err != nil || request.Amount == 0 || request.Reason == "" {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body, give a non-zero amount and a reason")
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := giftCardIndex(id)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Gift card not found")
+			return
+		}
+		if giftCards[index].Balance+request.Amount < 0 {
+			errorResponse(w, http.StatusBadRequest, "Balance cannot go below zero")
+			return
+		}
+		before := giftCards[index]
+		actor := actorFromRequest(r)
+		if err := adjustGiftCard(index, GiftCardTransaction{Amount: request.Amount, Reason: request.Reason, Actor: actor}); err != nil {
+			errorResponse(w, http.StatusInternalServerError, "Failed to save gift card data")
+			return
+		}
+		recordAudit(actor, "adjust", "giftCard", id, before, giftCards[index])
+
+		successResponse(w, http.StatusOK, "Gift card adjusted successfully", giftCards[index])
+		logData("Gift card adjusted successfully", map[string]interface{}{"id": id, "amount": request.Amount, "balance": giftCards[index].Balance})
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestGiftCardIssuance verifies gift cards are bought, issued, looked up and adjusted.
+func TestGiftCardIssuance(t *testing.T) {
+	setupTestEnvironment()
+	sent := map[string]string{}
+	defer func(send func(to, subject, body string) error) { sendEmail = send }(sendEmail)
+	sendEmail = func(to, subject, body string) error {
+		sent[to] = body
+		return nil
+	}
+
+	tests := []struct {
+		name           string
+		handler        http.HandlerFunc
+		body           string
+		expectedStatus int
+	}{
+		{"Purchase", giftCardPurchaseHandler, `{"amount":5000,"currency":"eur","purchaserName":"Ann","recipientEmail":"ben@example.com","paymentReference":"pi_1"}`, http.StatusCreated},
+		{"Purchase Unpaid", giftCardPurchaseHandler, `{"amount":5000,"purchaserName":"Ann"}`, http.StatusBadRequest},
+		{"Purchase Nothing", giftCardPurchaseHandler, `{"amount":0,"purchaserName":"Ann","paymentReference":"pi_1"}`, http.StatusBadRequest},
+		{"Unknown Currency", giftCardPurchaseHandler, `{"amount":5000,"currency":"XYZ","purchaserName":"Ann","paymentReference":"pi_1"}`, http.StatusBadRequest},
+		{"Admin Issue", adminGiftCardHandler, `{"amount":2000,"reason":"Apology for the cancelled class"}`, http.StatusCreated},
+		{"Admin Issue Without Reason", adminGiftCardHandler, `{"amount":2000}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler(rec, httptest.NewRequest(http.MethodPost, "/giftcards", bytes.NewReader([]byte(tt.body))))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+	if len(giftCards) != 2 || giftCards[0].Currency != "EUR" || giftCards[1].Currency != "USD" || giftCards[1].Transactions[0].Reason != "Apology for the cancelled class" {
+		t.Fatalf("expected two gift cards, got %+v", giftCards)
+	}
+	if !strings.Contains(sent["ben@example.com"], giftCards[0].Code) || !strings.Contains(sent["ben@example.com"], "€50.00") {
+		t.Errorf("expected the code to be emailed to the recipient, got %v", sent)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/giftcards/"+strings.ToLower(giftCards[0].Code), nil)
+	req.SetPathValue("code", strings.ToLower(giftCards[0].Code))
+	rec := httptest.NewRecorder()
+	giftCardBalanceHandler(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"balance":5000`) {
+		t.Errorf("expected the balance by code, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	for _, tt := range []struct {
+		name           string
+		body           string
+		expectedStatus int
+		balance        int
+	}{
+		{"Top Up", `{"amount":500,"reason":"goodwill"}`, http.StatusOK, 2500},
+		{"Below Zero", `{"amount":-3000,"reason":"correction"}`, http.StatusBadRequest, 2500},
+		{"Missing Reason", `{"amount":100}`, http.StatusBadRequest, 2500},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin/giftcards/2", bytes.NewReader([]byte(tt.body)))
+			req.SetPathValue("id", "2")
+			rec := httptest.NewRecorder()
+			adminGiftCardItemHandler(rec, req)
+			if rec.Code != tt.expectedStatus || giftCards[1].Balance != tt.balance {
+				t.Errorf("expected status %d and balance %d, got %d and %d", tt.expectedStatus, tt.balance, rec.Code, giftCards[1].Balance)
+			}
+		})
+	}
+}
+
+// TestGiftCardRedemption verifies gift cards pay what they can of a booking and are paid back on refund.
+func TestGiftCardRedemption(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{
+		{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 5, Price: 1500, Currency: "EUR"},
+		{ID: 2, ClassName: "Spin", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 5, Price: 1500, Currency: "USD"},
+		{ID: 3, ClassName: "Stretch", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 5},
+	}
+	classId = 4
+	giftCards = []GiftCard{{ID: 1, Code: "GC-AAAA-BBBB-CCCC", Currency: "EUR", InitialAmount: 2000, Balance: 2000}}
+	giftCardId = 2
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		giftCardAmount int
+		balance        int
+	}{
+		{"Pays In Full", `{"memberName":"Ann","className":"Yoga","date":"02-12-2099","giftCardCode":"gc-aaaa-bbbb-cccc","giftCardAmount":9999}`, http.StatusCreated, 1500, 500},
+		{"Pays Partly", `{"memberName":"Ann","className":"Yoga","date":"03-12-2099","giftCardCode":"GC-AAAA-BBBB-CCCC"}`, http.StatusCreated, 500, 0},
+		{"Empty Card", `{"memberName":"Ann","className":"Yoga","date":"04-12-2099","giftCardCode":"GC-AAAA-BBBB-CCCC"}`, http.StatusBadRequest, 0, 0},
+		{"Unknown Card", `{"memberName":"Ben","className":"Yoga","date":"04-12-2099","giftCardCode":"GC-NOPE"}`, http.StatusBadRequest, 0, 0},
+		{"Other Currency", `{"memberName":"Ben","className":"Spin","date":"04-12-2099","giftCardCode":"GC-AAAA-BBBB-CCCC"}`, http.StatusBadRequest, 0, 0},
+		{"Free Class", `{"memberName":"Ben","className":"Stretch","date":"04-12-2099","giftCardCode":"GC-AAAA-BBBB-CCCC"}`, http.StatusBadRequest, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(tt.body))))
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+			if giftCards[0].Balance != tt.balance {
+				t.Errorf("expected a balance of %d, got %d", tt.balance, giftCards[0].Balance)
+			}
+			if tt.expectedStatus == http.StatusCreated {
+				booking := bookings[len(bookings)-1]
+				if booking.GiftCardAmount != tt.giftCardAmount || booking.GiftCardID != 1 || booking.GiftCardCode != "" {
+					t.Errorf("expected %d paid by the gift card, got %+v", tt.giftCardAmount, booking)
+				}
+			}
+		})
+	}
+	if receipt := newReceipt(bookings[1]); receipt.GiftCard == nil || receipt.GiftCard.Formatted != "€5.00" {
+		t.Errorf("expected the gift card on the receipt, got %+v", receipt.GiftCard)
+	}
+
+	// Cancelled early, the gift card gets its share back and the rest is refunded by hand
+	bookings[1].Status = bookingStatusCancelled
+	req := httptest.NewRequest(http.MethodPost, "/bookings/2/refund", nil)
+	req.SetPathValue("id", "2")
+	rec := httptest.NewRecorder()
+	bookingRefundHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the refund to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if refund := refunds[0]; refund.Amount != 1500 || refund.GiftCardAmount != 500 || refund.Method != refundMethodManual || giftCards[0].Balance != 500 {
+		t.Errorf("expected 5.00 back on the gift card, got %+v with balance %d", refund, giftCards[0].Balance)
+	}
+}
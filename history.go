@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// BookingEvent records a change made to a booking
+type BookingEvent struct {
+	ID        int               `json:"id"`
+	BookingID int               `json:"bookingId"`
+	Type      string            `json:"type"`
+	Time      time.Time         `json:"time"`
+	Actor     string            `json:"actor"`
+	Details   map[string]string `json:"details,omitempty"`
+}
+
+// Booking event types
+const (
+	bookingEventCreated     = "created"
+	bookingEventConfirmed   = "confirmed"
+	bookingEventRescheduled = "rescheduled"
+	bookingEventTransferred = "transferred"
+	bookingEventCancelled   = "cancelled"
+	bookingEventCheckedIn   = "checked-in"
+	bookingEventExpired     = "expired"
+)
+
+var (
+	bookingEvents  []BookingEvent // Temp Slice to hold booking events
+	bookingEventId = 1            // Incremental ID for booking events
+)
+
+// recordBookingEvent appends an event to a booking's history and persists it.
+// Callers must hold the mutex.
+func recordBookingEvent(bookingID int, eventType, actor string, details map[string]string) {
+	bookingEvents = append(bookingEvents, BookingEvent{
+		ID:        bookingEventId,
+		BookingID: bookingID,
+		Type:      eventType,
+		Time:      now(),
+		Actor:     actor,
+		Details:   details,
+	})
+	bookingEventId++
+
+	if err := writeDataToJsonFile("booking_events.json", bookingEvents); err != nil {
+		fmt.Println("Error saving booking history:", err)
+	}
+}
+
+// Handler for a booking's change history
+func bookingHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid booking id")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := bookingIndex(id)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Booking not found")
+		return
+	}
+
+	// Events are stored in the order they happened
+	events := []BookingEvent{}
+	for _, event := range bookingEvents {
+		if event.BookingID == id {
+			events = append(events, event)
+		}
+	}
+
+	response := map[string]interface{}{
+		"booking": bookings[index],
+		"events":  events,
+	}
+	successResponse(w, http.StatusOK, "Booking history retrieved successfully", response)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestBookingHistoryHandler verifies every state transition lands in the history.
+func TestBookingHistoryHandler(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2024, 12, 16, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	classes = []Class{{ID: 1, ClassName: "Pilates", StartDate: testDate("15-12-2024"), EndDate: testDate("20-12-2024"), Capacity: 10}}
+
+	// post calls a booking action handler and returns the status code.
+	post := func(handler http.HandlerFunc, path, id, body string) int {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(body)))
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec.Code
+	}
+
+	body, _ := json.Marshal(Booking{MemberName: "John Doe", Date: testDate("18-12-2024"), ClassName: "Pilates"})
+	bookingHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader(body)))
+
+	if code := post(checkInBookingHandler, "/bookings/1/check-in", "1", ""); code != http.StatusBadRequest {
+		t.Errorf("expected check-in before the class day to fail, got %d", code)
+	}
+	if code := post(rescheduleBookingHandler, "/bookings/1/reschedule", "1", `{"date":"25-12-2024"}`); code != http.StatusBadRequest {
+		t.Errorf("expected rescheduling outside the class dates to fail, got %d", code)
+	}
+	if code := post(rescheduleBookingHandler, "/bookings/1/reschedule", "1", `{"date":"16-12-2024"}`); code != http.StatusOK {
+		t.Errorf("expected the reschedule to succeed, got %d", code)
+	}
+	if code := post(transferBookingHandler, "/bookings/1/transfer", "1", `{"memberName":"Jane Doe"}`); code != http.StatusOK {
+		t.Errorf("expected the transfer to succeed, got %d", code)
+	}
+	if code := post(checkInBookingHandler, "/bookings/1/check-in", "1", ""); code != http.StatusOK {
+		t.Errorf("expected the check-in to succeed, got %d", code)
+	}
+	if code := post(cancelBookingHandler, "/bookings/1/cancel", "1", ""); code != http.StatusBadRequest {
+		t.Errorf("expected cancelling an attended booking to fail, got %d", code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/bookings/1/history", nil)
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+	bookingHistoryHandler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, rec.Code)
+	}
+	var response struct {
+		Data struct {
+			Booking Booking        `json:"booking"`
+			Events  []BookingEvent `json:"events"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&response)
+
+	want := []string{bookingEventCreated, bookingEventRescheduled, bookingEventTransferred, bookingEventCheckedIn}
+	if len(response.Data.Events) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), response.Data.Events)
+	}
+	for i, eventType := range want {
+		if response.Data.Events[i].Type != eventType {
+			t.Errorf("expected event %d to be %s, got %s", i, eventType, response.Data.Events[i].Type)
+		}
+	}
+	if response.Data.Booking.Status != bookingStatusAttended || response.Data.Booking.Date != testDate("16-12-2024") {
+		t.Errorf("expected the attended, rescheduled booking, got %+v", response.Data.Booking)
+	}
+}
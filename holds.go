@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Hold reserves a slot in a class for a member while they check out
+type Hold struct {
+	ID         int       `json:"id"`
+	MemberName string    `json:"memberName"`
+	ClassName  string    `json:"className"`
+	Date       Date      `json:"date"`
+	SessionID  int       `json:"sessionId,omitempty"` // Class session the slot is held in
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// maxHoldMinutes bounds how long a slot can be held
+const maxHoldMinutes = 60
+
+var (
+	holds  []Hold // Temp Slice to hold slot reservations
+	holdId = 1    // Incremental ID for holds
+)
+
+// holdIndex returns the position of the hold with the given ID, or -1.
+// Callers must hold the mutex.
+func holdIndex(id int) int {
+	for i, hold := range holds {
+		if hold.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// countHolds returns the number of unexpired holds for a class session.
+// Callers must hold the mutex.
+func countHolds(sessionID int) int {
+	if sessionID == 0 {
+		return 0
+	}
+	count := 0
+	for _, hold := range holds {
+		if hold.SessionID == sessionID && hold.ExpiresAt.After(now()) {
+			count++
+		}
+	}
+	return count
+}
+
+// claimHold removes the hold a booking is made from, so its slot passes to the
+// booking. The caller puts it back if the booking fails. Callers must hold the mutex.
+func claimHold(newBooking Booking) (Hold, *requestRejection) {
+	index := holdIndex(newBooking.HoldID)
+	if index < 0 || !holds[index].ExpiresAt.After(now()) {
+		return Hold{}, &requestRejection{http.StatusBadRequest, "Hold not found or expired"}
+	}
+	hold := holds[index]
+	if hold.MemberName != newBooking.MemberName || hold.ClassName != newBooking.ClassName || hold.Date != newBooking.Date {
+		return Hold{}, &requestRejection{http.StatusBadRequest, "Hold does not match the booking"}
+	}
+	holds = append(holds[:index], holds[index+1:]...)
+	return hold, nil
+}
+
+// Handler for holding a slot before the booking is confirmed
+func holdHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	var request struct {
+		MemberName string `json:"memberName"`
+		ClassName  string `json:"className"`
+		Date       Date   `json:"date"`
+		Minutes    int    `json:"minutes"` // Defaults to holdMinutes from the config
+	}
+	if err := decodeBody(r, &request); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	// Members signed in without a key hold slots for themselves
+	if member := sessionOnlyMember(r); member != "" {
+		request.MemberName = member
+	}
+	if request.Minutes == 0 {
+		request.Minutes = config.HoldMinutes
+	}
+	if request.Minutes <= 0 || request.Minutes > maxHoldMinutes {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("minutes must be between 1 and %d", maxHoldMinutes))
+		return
+	}
+	// Holds take slots too, so they share the booking rate limit
+	if request.MemberName != "" && rateLimited(w, request.MemberName) {
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// A hold needs a free slot just like a booking does
+	_, availableSlots, rejection := checkBooking(Booking{MemberName: request.MemberName, ClassName: request.ClassName, Date: request.Date})
+	if rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+
+	newHold := Hold{
+		ID:         holdId,
+		MemberName: request.MemberName,
+		ClassName:  request.ClassName,
+		Date:       request.Date,
+		SessionID:  sessionIDOn(request.ClassName, request.Date),
+		ExpiresAt:  now().Add(time.Duration(request.Minutes) * time.Minute),
+	}
+	holdId++
+	holds = append(holds, newHold)
+
+	if err := writeDataToJsonFile("holds.json", holds); err != nil {
+		holds = holds[:len(holds)-1]
+		holdId--
+		errorResponse(w, http.StatusInternalServerError, "Failed to save hold data")
+		return
+	}
+	recordAudit(actorFromRequest(r), "create", "hold", newHold.ID, nil, newHold)
+
+	response := map[string]interface{}{
+		"hold":           newHold,
+		"availableSlots": availableSlots - 1,
+	}
+	successResponse(w, http.StatusCreated, "Slot held successfully", response)
+	logData("Slot held successfully", response)
+}
+
+// expireHolds removes holds that have run out and returns how many were removed
+func expireHolds() (int, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	kept, released := []Hold{}, []Hold{}
+	for _, hold := range holds {
+		if hold.ExpiresAt.After(now()) {
+			kept = append(kept, hold)
+		} else {
+			released = append(released, hold)
+		}
+	}
+	expired := len(holds) - len(kept)
+	if expired == 0 {
+		return 0, nil
+	}
+
+	if err := writeDataToJsonFile("holds.json", kept); err != nil {
+		return 0, err
+	}
+	holds = kept
+	logData("Expired holds released", map[string]int{"holds": expired})
+	for _, hold := range released {
+		promoteWaitlist(hold.ClassName, hold.Date)
+	}
+	return expired, nil
+}
+
+// runHoldSweeper is the scheduled entry point for expireHolds
+func runHoldSweeper() {
+	if _, err := expireHolds(); err != nil {
+		fmt.Println("Error releasing expired holds:", err)
+	}
+}
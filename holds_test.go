@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestHoldThenBook verifies a hold takes a slot until it is confirmed or expires.
+func TestHoldThenBook(t *testing.T) {
+	setupTestEnvironment()
+	current := time.Date(2024, 12, 16, 10, 0, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+	classes = []Class{{ID: 1, ClassName: "Pilates", StartDate: testDate("01-12-2024"), EndDate: testDate("31-12-2024"), Capacity: 1}}
+	classId = 2
+
+	post := func(handler http.HandlerFunc, path, body string) int {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(body))))
+		return rec.Code
+	}
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		path       string
+		body       string
+		statusCode int
+	}{
+		{name: "Hold The Last Slot", handler: holdHandler, path: "/holds", body: `{"memberName":"John Doe","className":"Pilates","date":"20-12-2024","minutes":5}`, statusCode: http.StatusCreated},
+		{name: "Held Slot Is Taken", handler: bookingHandler, path: "/bookings", body: `{"memberName":"Jane Doe","className":"Pilates","date":"20-12-2024"}`, statusCode: http.StatusBadRequest},
+		{name: "Hold Too Long", handler: holdHandler, path: "/holds", body: `{"memberName":"Jane Doe","className":"Pilates","date":"21-12-2024","minutes":600}`, statusCode: http.StatusBadRequest},
+		{name: "Hold For Another Member", handler: bookingHandler, path: "/bookings", body: `{"memberName":"Jane Doe","className":"Pilates","date":"20-12-2024","holdId":1}`, statusCode: http.StatusBadRequest},
+		{name: "Confirm The Hold", handler: bookingHandler, path: "/bookings", body: `{"memberName":"John Doe","className":"Pilates","date":"20-12-2024","holdId":1}`, statusCode: http.StatusCreated},
+		{name: "Hold Already Used", handler: bookingHandler, path: "/bookings", body: `{"memberName":"John Doe","className":"Pilates","date":"20-12-2024","holdId":1}`, statusCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := post(tt.handler, tt.path, tt.body); code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, code)
+			}
+		})
+	}
+
+	if len(holds) != 0 || len(bookings) != 1 || bookings[0].HoldID != 1 {
+		t.Errorf("expected the hold to become a booking, got holds %+v and bookings %+v", holds, bookings)
+	}
+
+	// An expired hold frees its slot and is released by the sweeper.
+	if code := post(holdHandler, "/holds", `{"memberName":"Jane Doe","className":"Pilates","date":"21-12-2024"}`); code != http.StatusCreated {
+		t.Fatalf("expected status code %d, got %d", http.StatusCreated, code)
+	}
+	current = current.Add(time.Duration(config.HoldMinutes) * time.Minute)
+	if code := post(bookingHandler, "/bookings", `{"memberName":"Jane Doe","className":"Pilates","date":"21-12-2024","holdId":2}`); code != http.StatusBadRequest {
+		t.Errorf("expected an expired hold to be rejected, got %d", code)
+	}
+	if expired, err := expireHolds(); err != nil || expired != 1 || len(holds) != 0 {
+		t.Errorf("expected the expired hold to be released, got %d, %v, %+v", expired, err, holds)
+	}
+	if code := post(bookingHandler, "/bookings", `{"memberName":"Max Doe","className":"Pilates","date":"21-12-2024"}`); code != http.StatusCreated {
+		t.Errorf("expected the released slot to be bookable, got %d", code)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// imagesPath is the URL prefix uploaded images are served under
+const imagesPath = "/images/"
+
+// imageTypes maps the accepted image types to the extension they are stored with
+var imageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// imageURL returns the address an image is served at. Keys are never reused,
+// so the address of an image never changes.
+func imageURL(key string) string {
+	return imagesPath + key
+}
+
+// imageContentType returns the type of a stored image from its key's extension
+func imageContentType(key string) string {
+	return mime.TypeByExtension(path.Ext(key))
+}
+
+// uploadedImageKey returns the blob key of an image URL, or "" when the URL
+// does not point at an uploaded image
+func uploadedImageKey(url string) string {
+	if !strings.HasPrefix(url, imagesPath) {
+		return ""
+	}
+	return strings.TrimPrefix(url, imagesPath)
+}
+
+// storeImageUpload validates the image in the "image" field of a multipart
+// request and stores it under a new key for an owner, e.g. "classes/3"
+func storeImageUpload(w http.ResponseWriter, r *http.Request, owner string) (string, *requestRejection) {
+	limit := int64(config.MaxImageKB) << 10
+	// The form around the file is allowed a little room
+	r.Body = http.MaxBytesReader(w, r.Body, limit+64<<10)
+	file, header, err := r.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return "", &requestRejection{http.StatusRequestEntityTooLarge, fmt.Sprintf("Image is too large, the maximum is %d KB", config.MaxImageKB)}
+		}
+		return "", &requestRejection{http.StatusBadRequest, "Send the image as the multipart form field image"}
+	}
+	defer file.Close()
+	if header.Size > limit {
+		return "", &requestRejection{http.StatusRequestEntityTooLarge, fmt.Sprintf("Image is too large, the maximum is %d KB", config.MaxImageKB)}
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", &requestRejection{http.StatusBadRequest, "Invalid image upload"}
+	}
+	// The content decides the type, whatever the client claims
+	contentType := http.DetectContentType(data)
+	extension, ok := imageTypes[contentType]
+	if !ok {
+		return "", &requestRejection{http.StatusUnsupportedMediaType, "Unsupported image type, use JPEG, PNG or GIF"}
+	}
+	if rejection := checkImageDimensions(data); rejection != nil {
+		return "", rejection
+	}
+
+	token := make([]byte, 8)
+	rand.Read(token)
+	key := owner + "-" + hex.EncodeToString(token) + extension
+	if err := blobStore.Put(key, contentType, data); err != nil {
+		reportError("persistence", "Failed to store image "+key, map[string]string{"error": err.Error()})
+		return "", &requestRejection{http.StatusInternalServerError, "Failed to store image"}
+	}
+	return key, nil
+}
+
+// removeImage deletes an uploaded image that is no longer referenced, with
+// its scaled-down variants
+func removeImage(url string) {
+	key := uploadedImageKey(url)
+	if key == "" {
+		return
+	}
+	keys := []string{key}
+	for size := range imageSizes {
+		keys = append(keys, imageVariantKey(key, size))
+	}
+	for _, key := range keys {
+		if err := blobStore.Delete(key); err != nil {
+			fmt.Println("Error deleting image:", err)
+		}
+	}
+}
+
+// Handler for uploading and removing a class's image
+func classImageHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid class id")
+		return
+	}
+
+	var key string
+	switch r.Method {
+	case http.MethodPost:
+		var rejection *requestRejection
+		if key, rejection = storeImageUpload(w, r, "classes/"+strconv.Itoa(id)); rejection != nil {
+			errorResponse(w, rejection.StatusCode, rejection.Message)
+			return
+		}
+	case http.MethodDelete:
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := classIndex(id)
+	if index < 0 || classes[index].DeletedAt != nil {
+		if key != "" {
+			blobStore.Delete(key)
+		}
+		errorResponse(w, http.StatusNotFound, "Class not found")
+		return
+	}
+	before := classes[index]
+	classes[index].Image = ""
+	if key != "" {
+		classes[index].Image = imageURL(key)
+	}
+	if err := writeDataToJsonFile("classes.json", classes); err != nil {
+		classes[index] = before
+		if key != "" {
+			blobStore.Delete(key)
+		}
+		errorResponse(w, http.StatusInternalServerError, "Failed to save class data")
+		return
+	}
+	removeImage(before.Image)
+	recordAudit(actorFromRequest(r), "update", "class", id, before, classes[index])
+
+	successResponse(w, http.StatusOK, "Class image updated successfully", classes[index])
+	logData("Class image updated successfully", classes[index])
+}
+
+// Handler for uploading and removing an instructor's photo
+func instructorPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid instructor id")
+		return
+	}
+
+	var key string
+	switch r.Method {
+	case http.MethodPost:
+		var rejection *requestRejection
+		if key, rejection = storeImageUpload(w, r, "instructors/"+strconv.Itoa(id)); rejection != nil {
+			errorResponse(w, rejection.StatusCode, rejection.Message)
+			return
+		}
+	case http.MethodDelete:
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := instructorIndex(id)
+	if index < 0 {
+		if key != "" {
+			blobStore.Delete(key)
+		}
+		errorResponse(w, http.StatusNotFound, "Instructor not found")
+		return
+	}
+	before := instructors[index]
+	instructors[index].Photo = ""
+	if key != "" {
+		instructors[index].Photo = imageURL(key)
+	}
+	if err := writeDataToJsonFile("instructors.json", instructors); err != nil {
+		instructors[index] = before
+		if key != "" {
+			blobStore.Delete(key)
+		}
+		errorResponse(w, http.StatusInternalServerError, "Failed to save instructor data")
+		return
+	}
+	removeImage(before.Photo)
+	recordAudit(actorFromRequest(r), "update", "instructor", id, before, instructors[index])
+
+	successResponse(w, http.StatusOK, "Instructor photo updated successfully", instructors[index])
+	logData("Instructor photo updated successfully", instructors[index])
+}
+
+// Handler serving uploaded images, scaled down with ?size=thumbnail or
+// ?size=card. Their addresses never change, so clients may cache them for good.
+func imageHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	key, size := r.PathValue("key"), r.URL.Query().Get("size")
+	if _, ok := imageSizes[size]; !ok && size != "" && size != "full" {
+		errorResponse(w, http.StatusBadRequest, "Invalid size, use thumbnail, card or full")
+		return
+	}
+	// Variants are served from their own keys, never from their original's
+	if strings.HasPrefix(key, "variants/") {
+		errorResponse(w, http.StatusNotFound, "Image not found")
+		return
+	}
+	var data []byte
+	var err error
+	if imageSizes[size] > 0 {
+		data, err = imageVariant(key, size)
+	} else {
+		data, err = blobStore.Get(key)
+	}
+	if errors.Is(err, errBlobNotFound) {
+		errorResponse(w, http.StatusNotFound, "Image not found")
+		return
+	}
+	if err != nil {
+		fmt.Println("Error reading image:", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to read image")
+		return
+	}
+	w.Header().Set("Content-Type", imageContentType(key))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Write(data)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// imageUploadRequest builds a multipart request carrying a file in the image field
+func imageUploadRequest(target string, data []byte) *http.Request {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("image", "upload")
+	part.Write(data)
+	form.Close()
+	req := httptest.NewRequest(http.MethodPost, target, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+// TestImageUpload verifies images are validated, stored, served at the recorded address and replaced.
+func TestImageUpload(t *testing.T) {
+	setupTestEnvironment()
+	defer func(store BlobStore) { blobStore = store }(blobStore)
+	blobStore = diskBlobStore{dir: t.TempDir()}
+	defer func(limit int) { config.MaxImageKB = limit }(config.MaxImageKB)
+	config.MaxImageKB = 64
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 5}}
+
+	var picture bytes.Buffer
+	png.Encode(&picture, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+
+	upload := func(data []byte) *httptest.ResponseRecorder {
+		req := imageUploadRequest("/classes/1/image", data)
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+		classImageHandler(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name           string
+		data           []byte
+		expectedStatus int
+	}{
+		{"Not An Image", []byte("just some text"), http.StatusUnsupportedMediaType},
+		{"Too Large", append(picture.Bytes(), make([]byte, 100<<10)...), http.StatusRequestEntityTooLarge},
+		{"PNG", picture.Bytes(), http.StatusOK},
+	}
+	for _, tt := range tests {
+		if rec := upload(tt.data); rec.Code != tt.expectedStatus {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.expectedStatus, rec.Code, rec.Body.String())
+		}
+	}
+	first := classes[0].Image
+	if uploadedImageKey(first) == "" {
+		t.Fatalf("expected the class to reference the image, got %q", first)
+	}
+
+	serve := func(url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.SetPathValue("key", uploadedImageKey(url))
+		rec := httptest.NewRecorder()
+		imageHandler(rec, req)
+		return rec
+	}
+	rec := serve(first)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" || !bytes.Equal(rec.Body.Bytes(), picture.Bytes()) {
+		t.Errorf("expected the image to be served, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	if rec := upload(picture.Bytes()); rec.Code != http.StatusOK || classes[0].Image == first {
+		t.Fatalf("expected the image to be replaced, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(first); rec.Code != http.StatusNotFound {
+		t.Errorf("expected the replaced image to be deleted, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// impersonationHeader names the member an admin is acting for
+const impersonationHeader = "X-Impersonate-Member"
+
+// onBehalfOf joins an admin and the member they act for into one actor
+const onBehalfOf = " on behalf of "
+
+// impersonatedMember returns the member an admin request acts for, or "".
+// Only requests authenticated with an admin API key may impersonate.
+func impersonatedMember(r *http.Request) string {
+	member := strings.TrimSpace(r.Header.Get(impersonationHeader))
+	if member == "" {
+		return ""
+	}
+	if key, ok := requestAPIKey(r); !ok || key.Scope != apiKeyScopeAdmin {
+		return ""
+	}
+	return member
+}
+
+// requestMember returns the member a request acts as: the impersonated member
+// for admins, otherwise the signed-in member, or ""
+func requestMember(r *http.Request) string {
+	if member := impersonatedMember(r); member != "" {
+		return member
+	}
+	return sessionMember(r)
+}
+
+// escapeActor rewrites the separator inside a name, so splitActor cannot be
+// fooled into recording an impersonation that did not happen
+func escapeActor(name string) string {
+	return strings.ReplaceAll(name, onBehalfOf, " (on behalf of) ")
+}
+
+// splitActor separates an impersonating admin from the member they act for
+func splitActor(actor string) (string, string) {
+	admin, member, _ := strings.Cut(actor, onBehalfOf)
+	return admin, member
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestAdminImpersonation verifies admins can book for a member and that the
+// audit log records both of them.
+func TestAdminImpersonation(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10}}
+	classId = 2
+	_, admin := createTestAPIKey(t, "front-desk", apiKeyScopeAdmin)
+	_, kiosk := createTestAPIKey(t, "kiosk", apiKeyScopeBookings)
+	handler := requireAPIKey(http.HandlerFunc(bookingHandler))
+
+	tests := []struct {
+		name       string
+		key        string
+		body       string
+		statusCode int
+	}{
+		{name: "Without Key", body: `{"className":"Yoga","date":"15-12-2099"}`, statusCode: http.StatusForbidden},
+		{name: "Bookings Key", key: kiosk, body: `{"className":"Yoga","date":"15-12-2099"}`, statusCode: http.StatusForbidden},
+		{name: "Other Member", key: admin, body: `{"memberName":"Ben","className":"Yoga","date":"15-12-2099"}`, statusCode: http.StatusBadRequest},
+		{name: "Admin Key", key: admin, body: `{"className":"Yoga","date":"15-12-2099"}`, statusCode: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(tt.body)))
+			req.Header.Set(impersonationHeader, "Ann")
+			req.Header.Set("X-Actor", "Sam")
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+		})
+	}
+
+	if len(bookings) != 1 || bookings[0].MemberName != "Ann" {
+		t.Fatalf("expected one booking for Ann, got %+v", bookings)
+	}
+	entry := auditEntries[len(auditEntries)-1]
+	if entry.Entity != "booking" || entry.Actor != "api-key:front-desk" || entry.OnBehalfOf != "Ann" {
+		t.Errorf("expected the admin key acting on behalf of the member, got %+v", entry)
+	}
+	if event := bookingEvents[len(bookingEvents)-1]; event.Actor != "api-key:front-desk on behalf of Ann" {
+		t.Errorf("expected the booking history to name both, got %q", event.Actor)
+	}
+}
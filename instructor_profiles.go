@@ -0,0 +1,191 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// instructorProfileDays is how far ahead an instructor's profile lists sessions
+const instructorProfileDays = 14
+
+// Instructor is the public profile of someone who teaches classes. Classes and
+// sessions name their instructor, so the name cannot change.
+type Instructor struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Bio       string    `json:"bio,omitempty"`
+	Photo     string    `json:"photo,omitempty"` // URL or reference of a photo
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+var (
+	instructors  []Instructor // Temp Slice to hold instructor profiles
+	instructorId = 1          // Incremental ID for instructor profiles
+)
+
+// instructorIndex returns the position of the instructor with the given ID,
+// or -1. Callers must hold the mutex.
+func instructorIndex(id int) int {
+	for i, instructor := range instructors {
+		if instructor.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// instructorRating returns the rating of the approved reviews of the sessions
+// an instructor taught, covers included. Callers must hold the mutex.
+func instructorRating(name string) ratingSummary {
+	rating, total := ratingSummary{}, 0
+	for _, review := range reviews {
+		index := classIndex(review.ClassID)
+		if reviewStatus(review) != reviewApproved || index < 0 || sessionInstructor(classes[index], review.Date) != name {
+			continue
+		}
+		rating.Count++
+		total += review.Rating
+	}
+	rating.Average = statsAverage(total, rating.Count)
+	return rating
+}
+
+// Handler for listing and adding instructor profiles
+func instructorHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		response := append([]Instructor{}, instructors...)
+		mutex.Unlock()
+		successResponse(w, http.StatusOK, "Instructors retrieved successfully", map[string]interface{}{"instructors": response})
+
+	case http.MethodPost:
+		var instructor Instructor
+		if err := decodeBody(r, &instructor); err != nil || strings.TrimSpace(instructor.Name) == "" {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body, an instructor needs a name")
+			return
+		}
+		instructor.Name = strings.TrimSpace(instructor.Name)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		for _, existing := range instructors {
+			if existing.Name == instructor.Name {
+				errorResponse(w, http.StatusConflict, "Instructor already exists")
+				return
+			}
+		}
+		instructor.ID = instructorId
+		instructor.CreatedAt = now()
+		instructorId++
+		instructors = append(instructors, instructor)
+		if err := writeDataToJsonFile("instructors.json", instructors); err != nil {
+			instructors = instructors[:len(instructors)-1]
+			instructorId--
+			errorResponse(w, http.StatusInternalServerError, "Failed to save instructor data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "create", "instructor", instructor.ID, nil, instructor)
+
+		successResponse(w, http.StatusCreated, "Instructor created successfully", instructor)
+		logData("Instructor created successfully", instructor)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// Handler for an instructor's public profile, with their classes, sessions
+// over the next two weeks and rating, and for updating their bio and photo
+func instructorItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid instructor id")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := instructorIndex(id)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Instructor not found")
+			return
+		}
+		instructor := instructors[index]
+
+		// Classes they lead that are still running
+		day := today()
+		ratings := classRatings()
+		upcomingClasses := []classListing{}
+		for _, class := range classes {
+			if class.DeletedAt == nil && class.Instructor == instructor.Name && !class.EndDate.Before(day) {
+				upcomingClasses = append(upcomingClasses, classListing{class, ratings[class.ID]})
+			}
+		}
+		// Sessions they teach soon, including covers for other instructors
+		sessions := []sessionView{}
+		for _, session := range statsSessions(day, day.AddDate(0, 0, instructorProfileDays-1)) {
+			if index := classIndex(session.ClassID); session.Instructor == instructor.Name && index >= 0 && classes[index].DeletedAt == nil {
+				sessions = append(sessions, viewSession(session))
+			}
+		}
+		sort.SliceStable(sessions, func(i, j int) bool {
+			if dateI, dateJ := sessions[i].Date, sessions[j].Date; dateI != dateJ {
+				return dateI.Before(dateJ.Time)
+			}
+			return sessions[i].StartTime < sessions[j].StartTime
+		})
+
+		response := map[string]interface{}{
+			"instructor": instructor,
+			"classes":    upcomingClasses,
+			"upcoming":   sessions,
+			"rating":     instructorRating(instructor.Name),
+		}
+		successResponse(w, http.StatusOK, "Instructor retrieved successfully", response)
+
+	case http.MethodPut:
+		var request struct {
+			Bio   string `json:"bio"`
+			Photo string `json:"photo"`
+		}
+		if err := decodeBody(r, &request); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := instructorIndex(id)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Instructor not found")
+			return
+		}
+		before := instructors[index]
+		instructors[index].Bio = request.Bio
+		instructors[index].Photo = request.Photo
+		if err := writeDataToJsonFile("instructors.json", instructors); err != nil {
+			instructors[index] = before
+			errorResponse(w, http.StatusInternalServerError, "Failed to save instructor data")
+			return
+		}
+		if before.Photo != request.Photo {
+			removeImage(before.Photo)
+		}
+		recordAudit(actorFromRequest(r), "update", "instructor", id, before, instructors[index])
+
+		successResponse(w, http.StatusOK, "Instructor updated successfully", instructors[index])
+		logData("Instructor updated successfully", instructors[index])
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
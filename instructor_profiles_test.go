@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestInstructorProfile verifies a profile gathers the instructor's running classes, upcoming sessions and rating.
+func TestInstructorProfile(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2099, 12, 5, 9, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	classes = []Class{
+		{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("07-12-2099"), Capacity: 5, StartTime: "09:00", Instructor: "Maya"},
+		{ID: 2, ClassName: "Pilates", StartDate: testDate("01-12-2099"), EndDate: testDate("07-12-2099"), Capacity: 5, StartTime: "18:00", Instructor: "Leo"},
+		{ID: 3, ClassName: "Spin", StartDate: testDate("01-11-2099"), EndDate: testDate("30-11-2099"), Capacity: 5, Instructor: "Maya"},
+	}
+	classSessions = []ClassSession{
+		{ID: 1, ClassID: 2, ClassName: "Pilates", Date: testDate("06-12-2099"), Capacity: 5, Instructor: "Maya"},
+		{ID: 2, ClassID: 1, ClassName: "Yoga", Date: testDate("07-12-2099"), Capacity: 5, Instructor: "Leo"},
+	}
+	reviews = []Review{
+		{ID: 1, ClassID: 1, Date: testDate("02-12-2099"), Rating: 5, Status: reviewApproved},
+		{ID: 2, ClassID: 3, Date: testDate("02-11-2099"), Rating: 4, Status: reviewApproved},
+		{ID: 3, ClassID: 2, Date: testDate("02-12-2099"), Rating: 1, Status: reviewApproved},
+		{ID: 4, ClassID: 1, Date: testDate("03-12-2099"), Rating: 1, Status: reviewPending},
+	}
+
+	call := func(method, id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/instructors/"+id, bytes.NewReader([]byte(body)))
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+		if id == "" {
+			instructorHandler(rec, req)
+		} else {
+			instructorItemHandler(rec, req)
+		}
+		return rec
+	}
+
+	if rec := call(http.MethodPost, "", `{"name":"Maya","bio":"Teaching since 2080"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("expected the instructor to be created, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := call(http.MethodPost, "", `{"name":"Maya"}`); rec.Code != http.StatusConflict {
+		t.Errorf("expected a duplicate instructor to be refused, got %d", rec.Code)
+	}
+	if rec := call(http.MethodPut, "1", `{"bio":"Teaching since 2080","photo":"/images/maya.jpg"}`); rec.Code != http.StatusOK || instructors[0].Photo != "/images/maya.jpg" {
+		t.Errorf("expected the photo to be set, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := call(http.MethodGet, "9", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected an unknown instructor to be not found, got %d", rec.Code)
+	}
+
+	rec := call(http.MethodGet, "1", "")
+	var response struct {
+		Data struct {
+			Instructor Instructor     `json:"instructor"`
+			Classes    []classListing `json:"classes"`
+			Upcoming   []sessionView  `json:"upcoming"`
+			Rating     ratingSummary  `json:"rating"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&response)
+	if rec.Code != http.StatusOK || response.Data.Instructor.Name != "Maya" {
+		t.Fatalf("expected the profile, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(response.Data.Classes) != 1 || response.Data.Classes[0].ID != 1 || response.Data.Classes[0].Rating == nil {
+		t.Errorf("expected the running Yoga class with its rating, got %+v", response.Data.Classes)
+	}
+	var dates []string
+	for _, session := range response.Data.Upcoming {
+		dates = append(dates, session.ClassName+" "+session.Date.String())
+	}
+	if !slices.Equal(dates, []string{"Yoga 05-12-2099", "Yoga 06-12-2099", "Pilates 06-12-2099"}) {
+		t.Errorf("expected Yoga until the cover on the 7th and the Pilates cover on the 6th, got %v", dates)
+	}
+	if response.Data.Rating.Count != 2 || response.Data.Rating.Average != 4.5 {
+		t.Errorf("expected a 4.5 rating from 2 reviews, got %+v", response.Data.Rating)
+	}
+}
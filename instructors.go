@@ -0,0 +1,188 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// InstructorAbsence marks a date an instructor cannot teach
+type InstructorAbsence struct {
+	ID         int    `json:"id"`
+	Instructor string `json:"instructor"`
+	Date       Date   `json:"date"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+var (
+	instructorAbsences  []InstructorAbsence // Temp Slice to hold instructor unavailability
+	instructorAbsenceId = 1                 // Incremental ID for instructor unavailability
+)
+
+// instructorUnavailable reports whether an instructor has declared a date unavailable.
+// Callers must hold the mutex.
+func instructorUnavailable(instructor string, date Date) bool {
+	for _, absence := range instructorAbsences {
+		if absence.Instructor == instructor && absence.Date == date {
+			return true
+		}
+	}
+	return false
+}
+
+// checkInstructor rejects a class whose instructor is unavailable on any of its
+// dates, naming the conflicting dates. Callers must hold the mutex.
+func checkInstructor(newClass Class) *requestRejection {
+	if newClass.Instructor == "" {
+		return nil
+	}
+	var conflicts []string
+	for _, absence := range instructorAbsences {
+		if absence.Instructor == newClass.Instructor && !absence.Date.Before(newClass.StartDate.Time) && !absence.Date.After(newClass.EndDate.Time) {
+			conflicts = append(conflicts, absence.Date.String())
+		}
+	}
+	if len(conflicts) > 0 {
+		return &requestRejection{http.StatusConflict, "Instructor is unavailable on " + strings.Join(conflicts, ", ")}
+	}
+	return nil
+}
+
+// instructorConflict is a session an instructor is scheduled for on an unavailable date
+type instructorConflict struct {
+	ClassID   int    `json:"classId"`
+	ClassName string `json:"className"`
+	Date      Date   `json:"date"`
+	Booked    int    `json:"booked"`
+}
+
+// Handler for listing and declaring an instructor's unavailable dates
+func instructorAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	instructor := r.PathValue("name")
+	if instructor == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid instructor name")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		dates := []InstructorAbsence{}
+		for _, absence := range instructorAbsences {
+			if absence.Instructor == instructor {
+				dates = append(dates, absence)
+			}
+		}
+		mutex.Unlock()
+		successResponse(w, http.StatusOK, "Unavailable dates retrieved successfully", map[string]interface{}{"unavailable": dates})
+
+	case http.MethodPost:
+		var request struct {
+			Dates  []Date `json:"dates"`
+			Reason string `json:"reason"`
+		}
+		if err := decodeBody(r, &request); err != nil {
+			errorResponse(w, http.StatusBadRequest, bodyErrorMessage(err))
+			return
+		}
+		if len(request.Dates) == 0 {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		for _, date := range request.Dates {
+			if date.IsZero() {
+				errorResponse(w, http.StatusBadRequest, "Invalid date format, use DD-MM-YYYY")
+				return
+			}
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		added := []InstructorAbsence{}
+		for _, date := range request.Dates {
+			if instructorUnavailable(instructor, date) {
+				continue
+			}
+			absence := InstructorAbsence{ID: instructorAbsenceId, Instructor: instructor, Date: date, Reason: request.Reason}
+			instructorAbsenceId++
+			instructorAbsences = append(instructorAbsences, absence)
+			added = append(added, absence)
+		}
+
+		if err := writeDataToJsonFile("instructor_absences.json", instructorAbsences); err != nil {
+			instructorAbsences = instructorAbsences[:len(instructorAbsences)-len(added)]
+			instructorAbsenceId -= len(added)
+			errorResponse(w, http.StatusInternalServerError, "Failed to save instructor data")
+			return
+		}
+		for _, absence := range added {
+			recordAudit(actorFromRequest(r), "create", "instructor-absence", absence.ID, nil, absence)
+		}
+
+		// Sessions already scheduled on these dates need a cover or a cancellation
+		conflicts := []instructorConflict{}
+		for _, date := range request.Dates {
+			for _, class := range classes {
+				if class.DeletedAt != nil || date.Before(class.StartDate.Time) || date.After(class.EndDate.Time) || sessionInstructor(class, date) != instructor {
+					continue
+				}
+				if session, ok := sessionAt(class, date); ok && session.CancelledAt != nil {
+					continue
+				}
+				conflicts = append(conflicts, instructorConflict{
+					ClassID:   class.ID,
+					ClassName: class.ClassName,
+					Date:      date,
+					Booked:    countBookings(sessionIDOn(class.ClassName, date)),
+				})
+			}
+		}
+
+		response := map[string]interface{}{
+			"unavailable": added,
+			"conflicts":   conflicts,
+		}
+		successResponse(w, http.StatusCreated, "Unavailable dates saved successfully", response)
+		logData("Unavailable dates saved successfully", response)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// Handler for making an instructor available again on a date
+func instructorAbsenceHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is DELETE
+	if r.Method != http.MethodDelete {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	instructor := r.PathValue("name")
+	date, err := parseDate(r.PathValue("date"))
+	if err != nil || date.IsZero() {
+		errorResponse(w, http.StatusBadRequest, "Invalid date format, use DD-MM-YYYY")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for i, absence := range instructorAbsences {
+		if absence.Instructor != instructor || absence.Date != date {
+			continue
+		}
+		instructorAbsences = append(instructorAbsences[:i], instructorAbsences[i+1:]...)
+		if err := writeDataToJsonFile("instructor_absences.json", instructorAbsences); err != nil {
+			instructorAbsences = append(instructorAbsences[:i], append([]InstructorAbsence{absence}, instructorAbsences[i:]...)...)
+			errorResponse(w, http.StatusInternalServerError, "Failed to save instructor data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "delete", "instructor-absence", absence.ID, absence, nil)
+
+		successResponse(w, http.StatusOK, "Unavailable date removed successfully", absence)
+		logData("Unavailable date removed successfully", absence)
+		return
+	}
+	errorResponse(w, http.StatusNotFound, "Unavailable date not found")
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestInstructorAvailability verifies classes and bookings respect an instructor's unavailable dates.
+func TestInstructorAvailability(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Pilates", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10, Instructor: "Alex"}}
+	classId = 2
+
+	req := httptest.NewRequest(http.MethodPost, "/instructors/Alex/unavailability", bytes.NewReader([]byte(`{"dates":["24-12-2099","25-12-2099"],"reason":"Holiday"}`)))
+	req.SetPathValue("name", "Alex")
+	rec := httptest.NewRecorder()
+	instructorAvailabilityHandler(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status code %d, got %d", http.StatusCreated, rec.Code)
+	}
+
+	// Sessions already scheduled on those dates are reported.
+	var response struct {
+		Data struct {
+			Conflicts []instructorConflict `json:"conflicts"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&response)
+	if len(response.Data.Conflicts) != 2 || response.Data.Conflicts[0].ClassID != 1 {
+		t.Errorf("expected both dates to conflict with class 1, got %+v", response.Data.Conflicts)
+	}
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		path       string
+		body       string
+		statusCode int
+	}{
+		{name: "Class Over Unavailable Dates", handler: classHandler, path: "/classes", body: `{"className":"Yoga","startDate":"20-12-2099","endDate":"26-12-2099","capacity":5,"instructor":"Alex"}`, statusCode: http.StatusConflict},
+		{name: "Class With Another Instructor", handler: classHandler, path: "/classes", body: `{"className":"Yoga","startDate":"20-12-2099","endDate":"26-12-2099","capacity":5,"instructor":"Sam"}`, statusCode: http.StatusCreated},
+		{name: "Class Outside Unavailable Dates", handler: classHandler, path: "/classes", body: `{"className":"Dance","startDate":"01-12-2099","endDate":"10-12-2099","capacity":5,"instructor":"Alex"}`, statusCode: http.StatusCreated},
+		{name: "Booking On Unavailable Date", handler: bookingHandler, path: "/bookings", body: `{"memberName":"John Doe","className":"Pilates","date":"24-12-2099"}`, statusCode: http.StatusConflict},
+		{name: "Booking On Available Date", handler: bookingHandler, path: "/bookings", body: `{"memberName":"John Doe","className":"Pilates","date":"23-12-2099"}`, statusCode: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler(rec, httptest.NewRequest(http.MethodPost, tt.path, bytes.NewReader([]byte(tt.body))))
+
+			if rec.Code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d: %s", tt.statusCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	// Removing the date makes the instructor bookable again.
+	req = httptest.NewRequest(http.MethodDelete, "/instructors/Alex/unavailability/24-12-2099", nil)
+	req.SetPathValue("name", "Alex")
+	req.SetPathValue("date", "24-12-2099")
+	rec = httptest.NewRecorder()
+	instructorAbsenceHandler(rec, req)
+	if rec.Code != http.StatusOK || instructorUnavailable("Alex", testDate("24-12-2099")) {
+		t.Errorf("expected the date to be removed, got %d", rec.Code)
+	}
+}
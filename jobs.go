@@ -0,0 +1,17 @@
+package main
+
+import "time"
+
+// runEvery runs job in the background on every tick of interval
+func runEvery(interval time.Duration, job func()) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			job()
+		}
+	}()
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSON:API (https://jsonapi.org) documents are offered to clients that send
+// Accept: application/vnd.api+json. Resources are the structs handlers respond
+// with that carry an identifier: their type is the struct's name in the
+// plural, e.g. "bookings" or "waitlistEntries", or that of the struct it
+// embeds first, as a class listing is a class, and numeric fields named after
+// another resource, such as classId, become relationships. Everything else in
+// a response, its message included, goes in the document's meta, and the
+// links of bookings and classes become their resources' links.
+
+// jsonAPIIdentifiers names the field identifying resources that have no id
+var jsonAPIIdentifiers = map[string]string{
+	"Member": "memberName",
+	"Room":   "name",
+	"Promo":  "code",
+}
+
+// jsonAPIRelatedTypes gives the type of relationships not named after it
+var jsonAPIRelatedTypes = map[string]string{
+	"extraClass": "classes",
+	"entry":      "waitlistEntries",
+}
+
+// jsonAPIIdentifier points at a resource
+type jsonAPIIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// jsonAPIRelationship links a resource to another
+type jsonAPIRelationship struct {
+	Data jsonAPIIdentifier `json:"data"`
+}
+
+// jsonAPIResource is a resource object
+type jsonAPIResource struct {
+	Type          string                         `json:"type"`
+	ID            string                         `json:"id"`
+	Attributes    map[string]interface{}         `json:"attributes,omitempty"`
+	Relationships map[string]jsonAPIRelationship `json:"relationships,omitempty"`
+	Links         map[string]interface{}         `json:"links,omitempty"`
+}
+
+// jsonAPIError is an error object
+type jsonAPIError struct {
+	Status string `json:"status"`
+	Title  string `json:"title"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// jsonAPIDocument is the top level of every JSON:API response
+type jsonAPIDocument struct {
+	Data    interface{}            `json:"data,omitempty"`
+	Errors  []jsonAPIError         `json:"errors,omitempty"`
+	Meta    map[string]interface{} `json:"meta,omitempty"`
+	JSONAPI map[string]string      `json:"jsonapi"`
+}
+
+// isJSONAPIContent reports whether a request body is a JSON:API document
+func isJSONAPIContent(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == formatJSONAPI
+}
+
+// decodeJSONAPIBody reads the attributes of the resource in a JSON:API request
+// body into v
+func decodeJSONAPIBody(r *http.Request, v interface{}) error {
+	var document struct {
+		Data *struct {
+			Attributes json.RawMessage `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&document); err != nil {
+		return err
+	}
+	if document.Data == nil || len(document.Data.Attributes) == 0 {
+		return errors.New("no resource attributes in the document")
+	}
+	return json.Unmarshal(document.Data.Attributes, v)
+}
+
+// jsonAPIPlural returns the plural of a camel-case name
+func jsonAPIPlural(name string) string {
+	switch {
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	}
+	return name + "s"
+}
+
+// jsonAPIType returns the resource type of a struct type's name, in camel
+// case with any leading initialism lowered, e.g. "apiKeys"
+func jsonAPIType(name string) string {
+	upper := 0
+	for upper < len(name) && 'A' <= name[upper] && name[upper] <= 'Z' {
+		upper++
+	}
+	if upper > 1 && upper < len(name) {
+		upper--
+	}
+	return jsonAPIPlural(strings.ToLower(name[:upper]) + name[upper:])
+}
+
+// jsonAPIStruct returns the struct a value holds, behind any pointers or
+// interfaces, if it is a named struct that could be a resource
+func jsonAPIStruct(value reflect.Value) (reflect.Value, bool) {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return value, false
+		}
+		value = value.Elem()
+	}
+	isResource := value.Kind() == reflect.Struct && value.Type().Name() != "" && value.Type() != reflect.TypeOf(time.Time{})
+	return value, isResource
+}
+
+// jsonAPIResourceOf returns the resource object of a struct, if it has an
+// identifier
+func jsonAPIResourceOf(value reflect.Value) (*jsonAPIResource, bool) {
+	value, ok := jsonAPIStruct(value)
+	if !ok {
+		return nil, false
+	}
+	raw, err := json.Marshal(value.Interface())
+	if err != nil {
+		return nil, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var attributes map[string]interface{}
+	if err := decoder.Decode(&attributes); err != nil {
+		return nil, false
+	}
+
+	typeName := value.Type().Name()
+	if value.NumField() > 0 && value.Type().Field(0).Anonymous && value.Field(0).Kind() == reflect.Struct {
+		typeName = value.Type().Field(0).Type.Name()
+	}
+	key := jsonAPIIdentifiers[typeName]
+	if key == "" {
+		key = "id"
+	}
+	id := jsonText(attributes[key])
+	if id == "" || id == "0" {
+		return nil, false
+	}
+	delete(attributes, key)
+
+	resource := &jsonAPIResource{Type: jsonAPIType(typeName), ID: id, Attributes: attributes}
+	// Hypermedia links are the resource's links, with the method of those that
+	// are not GET kept in their meta
+	if links, ok := attributes["_links"].(map[string]interface{}); ok {
+		resource.Links = map[string]interface{}{}
+		for name, value := range links {
+			followUp, _ := value.(map[string]interface{})
+			if method, ok := followUp["method"]; ok {
+				resource.Links[name] = map[string]interface{}{"href": followUp["href"], "meta": map[string]interface{}{"method": method}}
+			} else {
+				resource.Links[name] = followUp["href"]
+			}
+		}
+		delete(attributes, "_links")
+	}
+	for name, attribute := range attributes {
+		related := strings.TrimSuffix(name, "Id")
+		number, ok := attribute.(json.Number)
+		if related == name || related == "" || !ok {
+			continue
+		}
+		relatedType := jsonAPIRelatedTypes[related]
+		if relatedType == "" {
+			relatedType = jsonAPIPlural(related)
+		}
+		if resource.Relationships == nil {
+			resource.Relationships = map[string]jsonAPIRelationship{}
+		}
+		resource.Relationships[related] = jsonAPIRelationship{Data: jsonAPIIdentifier{Type: relatedType, ID: number.String()}}
+		delete(attributes, name)
+	}
+	return resource, true
+}
+
+// jsonAPIPrimary returns a value as primary data: a resource object, or a list
+// of them for a slice of resources
+func jsonAPIPrimary(value reflect.Value) (interface{}, bool) {
+	if resource, ok := jsonAPIResourceOf(value); ok {
+		return resource, true
+	}
+	value, _ = jsonAPIStruct(value)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return nil, false
+	}
+	// An empty list is only a collection when its entries would be resources
+	if value.Len() == 0 {
+		element := value.Type().Elem()
+		for element.Kind() == reflect.Pointer {
+			element = element.Elem()
+		}
+		_, isResource := jsonAPIStruct(reflect.Zero(element))
+		return []jsonAPIResource{}, isResource
+	}
+	resources := []jsonAPIResource{}
+	for i := 0; i < value.Len(); i++ {
+		resource, ok := jsonAPIResourceOf(value.Index(i))
+		if !ok {
+			return nil, false
+		}
+		resources = append(resources, *resource)
+	}
+	return resources, true
+}
+
+// addJSONAPIMeta adds the fields of a value to a document's meta, or the
+// value itself under the given key when it has no fields
+func addJSONAPIMeta(meta map[string]interface{}, key string, value interface{}) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	var fields map[string]interface{}
+	if json.Unmarshal(raw, &fields) == nil && fields != nil {
+		for name, field := range fields {
+			meta[name] = field
+		}
+		return
+	}
+	meta[key] = value
+}
+
+// writeJSONAPI writes a buffered response as a JSON:API document: resources
+// in its data are the document's primary data, errors are error objects, and
+// the rest is meta
+func writeJSONAPI(w io.Writer, recorder *bufferedResponseWriter) error {
+	var envelope struct {
+		Message string      `json:"message"`
+		Data    interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.body.Bytes(), &envelope); err != nil {
+		return err
+	}
+	data := envelope.Data
+	if recorder.hasData {
+		data = recorder.data
+	}
+
+	document := jsonAPIDocument{Meta: map[string]interface{}{}, JSONAPI: map[string]string{"version": "1.1"}}
+	if recorder.status >= http.StatusBadRequest {
+		document.Errors = []jsonAPIError{{Status: strconv.Itoa(recorder.status), Title: http.StatusText(recorder.status), Detail: envelope.Message}}
+		if data != nil {
+			addJSONAPIMeta(document.Meta, "data", data)
+		}
+	} else {
+		document.Meta["message"] = envelope.Message
+		value := reflect.ValueOf(data)
+		if primary, ok := jsonAPIPrimary(value); ok {
+			document.Data = primary
+		} else if value.Kind() == reflect.Map && value.Type().Key().Kind() == reflect.String {
+			// A map holding one resource or list of them makes that the
+			// primary data, and the rest meta
+			var primaryKey string
+			for _, key := range value.MapKeys() {
+				if candidate, ok := jsonAPIPrimary(value.MapIndex(key)); ok {
+					if primaryKey != "" {
+						document.Data, primaryKey = nil, ""
+						break
+					}
+					document.Data, primaryKey = candidate, key.String()
+				}
+			}
+			for _, key := range value.MapKeys() {
+				if key.String() != primaryKey {
+					document.Meta[key.String()] = value.MapIndex(key).Interface()
+				}
+			}
+		} else if data != nil {
+			addJSONAPIMeta(document.Meta, "data", data)
+		}
+	}
+	return json.NewEncoder(w).Encode(document)
+}
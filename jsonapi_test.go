@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// jsonAPIRequest sends a request asking for JSON:API through content negotiation
+func jsonAPIRequest(handler http.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, bytes.NewReader([]byte(body)))
+	req.Header.Set("Accept", formatJSONAPI)
+	if body != "" {
+		req.Header.Set("Content-Type", formatJSONAPI)
+	}
+	rec := httptest.NewRecorder()
+	negotiateContent(handler).ServeHTTP(rec, req)
+	return rec
+}
+
+// TestJSONAPIBooking verifies bookings are sent and answered as JSON:API documents, with errors as error objects.
+func TestJSONAPIBooking(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Pilates", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10}}
+	classId = 2
+
+	rec := jsonAPIRequest(bookingHandler, http.MethodPost, "/bookings",
+		`{"data":{"type":"bookings","attributes":{"memberName":"Rahul R P","date":"16-12-2099","className":"Pilates"}}}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the booking to be made, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Content-Type") != formatJSONAPI {
+		t.Errorf("expected a JSON:API response, got %q", rec.Header().Get("Content-Type"))
+	}
+	var document struct {
+		Data struct {
+			Type       string                 `json:"type"`
+			ID         string                 `json:"id"`
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"data"`
+		Meta    map[string]interface{} `json:"meta"`
+		JSONAPI map[string]string      `json:"jsonapi"`
+	}
+	json.NewDecoder(rec.Body).Decode(&document)
+	if document.Data.Type != "bookings" || document.Data.ID != "1" || document.Data.Attributes["memberName"] != "Rahul R P" {
+		t.Errorf("expected the booking as primary data, got %+v", document.Data)
+	}
+	if _, ok := document.Data.Attributes["id"]; ok {
+		t.Error("expected the id to be left out of the attributes")
+	}
+	if document.Meta["message"] != "Booking successful" || document.Meta["availableSlots"] != 9.0 || document.JSONAPI["version"] != "1.1" {
+		t.Errorf("expected the rest of the response as meta, got %+v", document)
+	}
+
+	rec = jsonAPIRequest(bookingHandler, http.MethodPost, "/bookings", `{"data":{"type":"bookings"}}`)
+	var failure struct {
+		Data   interface{}    `json:"data"`
+		Errors []jsonAPIError `json:"errors"`
+	}
+	json.NewDecoder(rec.Body).Decode(&failure)
+	if rec.Code != http.StatusBadRequest || len(failure.Errors) != 1 || failure.Errors[0].Status != "400" || failure.Errors[0].Detail != "Invalid request body" || failure.Data != nil {
+		t.Errorf("expected an error object, got %d: %+v", rec.Code, failure)
+	}
+}
+
+// TestJSONAPICollection verifies lists become collections of resources, with ids of other resources as relationships.
+func TestJSONAPICollection(t *testing.T) {
+	setupTestEnvironment()
+	extraSessions = []ExtraSession{
+		{ID: 1, ClassID: 4, ClassName: "Yoga", Date: testDate("15-12-2099"), Status: extraSessionOpened, ExtraClassID: 7},
+		{ID: 2, ClassID: 4, ClassName: "Yoga", Date: testDate("16-12-2099"), Status: extraSessionPending},
+	}
+
+	rec := jsonAPIRequest(extraSessionsHandler, http.MethodGet, "/admin/extra-sessions", "")
+	var document struct {
+		Data []jsonAPIResource `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&document)
+	if rec.Code != http.StatusOK || len(document.Data) != 2 {
+		t.Fatalf("expected both extra sessions, got %d: %+v", rec.Code, document)
+	}
+	first := document.Data[0]
+	if first.Type != "extraSessions" || first.ID != "1" ||
+		first.Relationships["class"].Data != (jsonAPIIdentifier{Type: "classes", ID: "4"}) ||
+		first.Relationships["extraClass"].Data != (jsonAPIIdentifier{Type: "classes", ID: "7"}) {
+		t.Errorf("expected the classes as relationships, got %+v", first)
+	}
+	if _, ok := first.Attributes["classId"]; ok {
+		t.Error("expected the related ids to be left out of the attributes")
+	}
+
+	extraSessions = nil
+	rec = jsonAPIRequest(extraSessionsHandler, http.MethodGet, "/admin/extra-sessions", "")
+	if body := rec.Body.String(); !strings.Contains(body, `"data":[]`) {
+		t.Errorf("expected an empty collection, got %s", body)
+	}
+
+	tests := []struct {
+		name     string
+		singular string
+		plural   string
+	}{
+		{name: "Regular", singular: "Booking", plural: "bookings"},
+		{name: "Sibilant", singular: "Class", plural: "classes"},
+		{name: "Consonant Y", singular: "WaitlistEntry", plural: "waitlistEntries"},
+		{name: "Vowel Y", singular: "Key", plural: "keys"},
+		{name: "Initialism", singular: "APIKey", plural: "apiKeys"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jsonAPIType(tt.singular); got != tt.plural {
+				t.Errorf("expected %s, got %s", tt.plural, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// link is a request a client can make from a resource
+type link struct {
+	Href   string `json:"href"`
+	Method string `json:"method,omitempty"` // Left out for GET
+}
+
+// resourceLinks names the requests that follow from a resource, so clients
+// need not build URLs themselves. They are worked out from the resource alone
+// when it is sent, and never stored.
+type resourceLinks map[string]link
+
+// UnmarshalJSON ignores links sent in request bodies or found in data files,
+// as links are only ever generated
+func (l *resourceLinks) UnmarshalJSON([]byte) error {
+	return nil
+}
+
+// bookingLinks returns the links of a booking: itself, the class or resource
+// it is for, its session's availability, and cancelling or rescheduling it
+// while the booking allows that
+func bookingLinks(booking Booking) resourceLinks {
+	if booking.ID == 0 {
+		return nil
+	}
+	self := fmt.Sprintf("/bookings/%d", booking.ID)
+	links := resourceLinks{"self": {Href: self}}
+	if booking.ResourceID != 0 {
+		links["resource"] = link{Href: fmt.Sprintf("/resources/%d", booking.ResourceID)}
+		links["availability"] = link{Href: fmt.Sprintf("/resources/%d/availability?date=%s", booking.ResourceID, booking.Date)}
+	} else if booking.ClassID != 0 {
+		links["class"] = link{Href: fmt.Sprintf("/classes/%d", booking.ClassID)}
+		links["availability"] = link{Href: fmt.Sprintf("/classes/%d/sessions/%s", booking.ClassID, booking.Date)}
+	}
+
+	if booking.Date.Before(today()) {
+		return links
+	}
+	if checkTransition(booking, bookingStatusCancelled) == nil {
+		links["cancel"] = link{Href: self + "/cancel", Method: http.MethodPost}
+	}
+	if bookingStatus(booking) == bookingStatusConfirmed && booking.ResourceID == 0 {
+		links["reschedule"] = link{Href: self + "/reschedule", Method: http.MethodPost}
+	}
+	return links
+}
+
+// classLinks returns the links of a class: itself, the availability of its
+// sessions, and booking or rescheduling it, or restoring it once deleted
+func classLinks(class Class) resourceLinks {
+	if class.ID == 0 {
+		return nil
+	}
+	self := fmt.Sprintf("/classes/%d", class.ID)
+	links := resourceLinks{"self": {Href: self}}
+	if class.DeletedAt != nil {
+		links["restore"] = link{Href: self + "/restore", Method: http.MethodPost}
+		return links
+	}
+	links["availability"] = link{Href: self + "/sessions"}
+	links["book"] = link{Href: "/bookings", Method: http.MethodPost}
+	links["reschedule"] = link{Href: self + "/reschedule", Method: http.MethodPost}
+	return links
+}
+
+// withLinks returns response data with links added to the bookings and
+// classes in it. They are added to copies, leaving the stored records as they
+// are.
+func withLinks(data interface{}) interface{} {
+	switch value := data.(type) {
+	case Booking:
+		value.Links = bookingLinks(value)
+		return value
+	case *Booking:
+		if value != nil {
+			return withLinks(*value)
+		}
+	case []Booking:
+		linked := make([]Booking, len(value))
+		for i, booking := range value {
+			linked[i] = withLinks(booking).(Booking)
+		}
+		return linked
+	case Class:
+		value.Links = classLinks(value)
+		return value
+	case *Class:
+		if value != nil {
+			return withLinks(*value)
+		}
+	case []Class:
+		linked := make([]Class, len(value))
+		for i, class := range value {
+			linked[i] = withLinks(class).(Class)
+		}
+		return linked
+	case classListing:
+		value.Class = withLinks(value.Class).(Class)
+		return value
+	case []classListing:
+		linked := make([]classListing, len(value))
+		for i, listing := range value {
+			linked[i] = withLinks(listing).(classListing)
+		}
+		return linked
+	case []interface{}:
+		linked := make([]interface{}, len(value))
+		for i, item := range value {
+			linked[i] = withLinks(item)
+		}
+		return linked
+	case map[string]interface{}:
+		linked := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			linked[key] = withLinks(item)
+		}
+		return linked
+	}
+	return data
+}
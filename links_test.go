@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestBookingLinks verifies bookings offer the follow-up actions their state allows.
+func TestBookingLinks(t *testing.T) {
+	now = func() time.Time { return time.Date(2099, 12, 10, 9, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	tests := []struct {
+		name    string
+		booking Booking
+		links   []string
+	}{
+		{name: "Upcoming", booking: Booking{ID: 1, ClassID: 3, Date: testDate("12-12-2099"), Status: bookingStatusConfirmed}, links: []string{"availability", "cancel", "class", "reschedule", "self"}},
+		{name: "Pending", booking: Booking{ID: 1, ClassID: 3, Date: testDate("12-12-2099"), Status: bookingStatusPending}, links: []string{"availability", "cancel", "class", "self"}},
+		{name: "Cancelled", booking: Booking{ID: 1, ClassID: 3, Date: testDate("12-12-2099"), Status: bookingStatusCancelled}, links: []string{"availability", "class", "self"}},
+		{name: "Past", booking: Booking{ID: 1, ClassID: 3, Date: testDate("01-12-2099"), Status: bookingStatusConfirmed}, links: []string{"availability", "class", "self"}},
+		{name: "Resource", booking: Booking{ID: 1, ResourceID: 2, Date: testDate("12-12-2099"), Status: bookingStatusConfirmed}, links: []string{"availability", "cancel", "resource", "self"}},
+		{name: "Unsaved", booking: Booking{Date: testDate("12-12-2099")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for name := range bookingLinks(tt.booking) {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			if !reflect.DeepEqual(names, tt.links) {
+				t.Errorf("expected links %v, got %v", tt.links, names)
+			}
+		})
+	}
+
+	links := bookingLinks(tests[0].booking)
+	if links["availability"].Href != "/classes/3/sessions/12-12-2099" || links["cancel"] != (link{Href: "/bookings/1/cancel", Method: http.MethodPost}) {
+		t.Errorf("expected the session and cancel links, got %+v", links)
+	}
+	deleted := time.Now()
+	if links := classLinks(Class{ID: 4, DeletedAt: &deleted}); links["restore"].Href != "/classes/4/restore" || len(links) != 2 {
+		t.Errorf("expected a deleted class to offer only restoring, got %+v", links)
+	}
+}
+
+// TestResponseLinks verifies responses carry links while stored records and request bodies do not.
+func TestResponseLinks(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Pilates", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10}}
+	classId = 2
+
+	rec := httptest.NewRecorder()
+	bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(
+		`{"memberName":"Rahul R P","date":"16-12-2099","className":"Pilates","_links":{"self":{"href":"/elsewhere"}}}`))))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the booking to be made, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if bookings[0].ClassID != 1 || bookings[0].Links != nil {
+		t.Errorf("expected the booking to be stored with its class and without links, got %+v", bookings[0])
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/bookings/1", nil)
+	req.SetPathValue("id", "1")
+	rec = httptest.NewRecorder()
+	bookingItemHandler(rec, req)
+	var response struct {
+		Data Booking `json:"data"`
+	}
+	var raw struct {
+		Data struct {
+			Links map[string]link `json:"_links"`
+		} `json:"data"`
+	}
+	body := rec.Body.Bytes()
+	json.Unmarshal(body, &response)
+	json.Unmarshal(body, &raw)
+	if rec.Code != http.StatusOK || response.Data.MemberName != "Rahul R P" {
+		t.Fatalf("expected the booking, got %d: %s", rec.Code, body)
+	}
+	if raw.Data.Links["self"].Href != "/bookings/1" || raw.Data.Links["class"].Href != "/classes/1" || raw.Data.Links["reschedule"].Method != http.MethodPost {
+		t.Errorf("expected the booking's links, got %+v", raw.Data.Links)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/bookings/9", nil)
+	req.SetPathValue("id", "9")
+	rec = httptest.NewRecorder()
+	bookingItemHandler(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected an unknown booking to be not found, got %d", rec.Code)
+	}
+
+	// Class listings carry the links of their class
+	rec = httptest.NewRecorder()
+	classHandler(rec, httptest.NewRequest(http.MethodGet, "/classes", nil))
+	var list struct {
+		Data struct {
+			Classes []struct {
+				Links map[string]link `json:"_links"`
+			} `json:"classes"`
+		} `json:"data"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &list)
+	if len(list.Data.Classes) != 1 || list.Data.Classes[0].Links["availability"].Href != "/classes/1/sessions" {
+		t.Errorf("expected the class's links, got %s", rec.Body.String())
+	}
+	if classes[0].Links != nil {
+		t.Errorf("expected the stored class to stay without links, got %+v", classes[0].Links)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logFileName is the API response log written by logData
+const logFileName = "api_responses.log"
+
+var (
+	logMutex     sync.Mutex // Serializes writes and rotation of the log file
+	logStartedAt time.Time  // When the current log file was started, zero until known
+)
+
+// rotateLogIfNeeded rotates the log file when it exceeds the configured size or age.
+// Callers must hold logMutex.
+func rotateLogIfNeeded() error {
+	info, err := os.Stat(logFileName)
+	if os.IsNotExist(err) {
+		logStartedAt = now()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Fall back to the modification time for a log left by a previous run
+	if logStartedAt.IsZero() {
+		logStartedAt = info.ModTime()
+	}
+
+	tooLarge := config.LogMaxSizeKB > 0 && info.Size() >= int64(config.LogMaxSizeKB)*1024
+	tooOld := config.LogMaxAgeHours > 0 && now().Sub(logStartedAt) >= time.Duration(config.LogMaxAgeHours)*time.Hour
+	if !tooLarge && !tooOld {
+		return nil
+	}
+	return rotateLog()
+}
+
+// rotateLog compresses the current log file into a timestamped archive
+// and removes the oldest archives beyond the retained count
+func rotateLog() error {
+	base := strings.TrimSuffix(logFileName, filepath.Ext(logFileName))
+	rotatedName := base + "-" + now().Format("20060102-150405") + ".log.gz"
+
+	if err := compressFile(logFileName, rotatedName); err != nil {
+		return err
+	}
+	if err := os.Remove(logFileName); err != nil {
+		return err
+	}
+	logStartedAt = now()
+
+	// Timestamped names sort oldest first
+	rotated, err := filepath.Glob(base + "-*.log.gz")
+	if err != nil {
+		return err
+	}
+	sort.Strings(rotated)
+	for config.LogMaxRotated > 0 && len(rotated) > config.LogMaxRotated {
+		if err := os.Remove(rotated[0]); err != nil {
+			return err
+		}
+		rotated = rotated[1:]
+	}
+	return nil
+}
+
+// compressFile writes a gzip compressed copy of source to destination
+func compressFile(source, destination string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	gzipWriter := gzip.NewWriter(out)
+	if _, err := io.Copy(gzipWriter, in); err != nil {
+		out.Close()
+		os.Remove(destination)
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		out.Close()
+		os.Remove(destination)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestLogRotation verifies the API log is rotated by size and age and pruned.
+func TestLogRotation(t *testing.T) {
+	setupTestEnvironment()
+	os.Remove(logFileName)
+	t.Cleanup(func() {
+		rotated, _ := filepath.Glob("api_responses-*.log.gz")
+		for _, name := range rotated {
+			os.Remove(name)
+		}
+		now = time.Now
+	})
+
+	config.LogMaxSizeKB = 1
+	config.LogMaxAgeHours = 0
+	config.LogMaxRotated = 2
+
+	// Each day's entries exceed the size limit, so the next write rotates them.
+	for day := 1; day <= 4; day++ {
+		now = func() time.Time { return time.Date(2024, 12, day, 10, 0, 0, 0, time.UTC) }
+		for i := 0; i < 20; i++ {
+			logData("Booking successful", map[string]interface{}{"className": "Pilates", "padding": "................................................"})
+		}
+	}
+
+	rotated, _ := filepath.Glob("api_responses-*.log.gz")
+	if len(rotated) != 2 {
+		t.Fatalf("expected 2 rotated logs to be retained, got %v", rotated)
+	}
+	if rotated[1] != "api_responses-20241204-100000.log.gz" {
+		t.Errorf("expected the newest rotation to be kept, got %v", rotated)
+	}
+
+	// Age-based rotation applies regardless of size.
+	config.LogMaxSizeKB = 0
+	config.LogMaxAgeHours = 24
+	now = func() time.Time { return time.Date(2024, 12, 10, 10, 0, 0, 0, time.UTC) }
+	logData("Class created successfully", "Yoga")
+
+	if _, err := os.Stat("api_responses-20241210-100000.log.gz"); err != nil {
+		t.Errorf("expected an age-based rotation, got %v", err)
+	}
+}
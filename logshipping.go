@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// LogSink receives every API log entry in addition to the local log file
+type LogSink interface {
+	Send(entry string) error
+}
+
+// syslogSink ships log entries to a syslog server using RFC 5424 framing
+type syslogSink struct {
+	network  string // "udp" or "tcp"
+	address  string
+	hostname string
+	conn     net.Conn
+}
+
+// Send writes an entry as a local0.info syslog message, redialing after failures
+func (s *syslogSink) Send(entry string) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.address, 5*time.Second)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	message := fmt.Sprintf("<134>1 %s %s interviewBackend %d - - %s",
+		now().Format(time.RFC3339), s.hostname, os.Getpid(), strings.TrimSpace(entry))
+	if s.network == "tcp" {
+		// Octet counting framing for stream transports
+		message = fmt.Sprintf("%d %s", len(message), message)
+	}
+
+	if _, err := s.conn.Write([]byte(message)); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+// httpLogSink posts log entries as JSON to an HTTP log collector
+type httpLogSink struct {
+	url    string
+	client *http.Client
+}
+
+// Send posts a single entry to the collector
+func (s *httpLogSink) Send(entry string) error {
+	body, err := json.Marshal(map[string]string{
+		"time":    now().Format(time.RFC3339),
+		"message": strings.TrimSpace(entry),
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("log collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+var (
+	logSinks     []LogSink   // Remote outputs selected by the config
+	logShipQueue chan string // Entries waiting to be shipped to the sinks
+)
+
+// configureLogSinks sets up the remote log outputs selected by the config and
+// starts the background shipper, so slow collectors never block requests
+func configureLogSinks() {
+	logSinks = nil
+	if config.SyslogAddress != "" {
+		hostname, _ := os.Hostname()
+		logSinks = append(logSinks, &syslogSink{network: config.SyslogNetwork, address: config.SyslogAddress, hostname: hostname})
+	}
+	if config.LogCollectorURL != "" {
+		logSinks = append(logSinks, &httpLogSink{url: config.LogCollectorURL, client: &http.Client{Timeout: 5 * time.Second}})
+	}
+	if len(logSinks) == 0 {
+		logShipQueue = nil
+		return
+	}
+
+	queue := make(chan string, 1000)
+	logShipQueue = queue
+	sinks := logSinks
+	go func() {
+		for entry := range queue {
+			for _, sink := range sinks {
+				if err := sink.Send(entry); err != nil {
+					fmt.Println("Error shipping log entry. Error: ", err)
+				}
+			}
+		}
+	}()
+}
+
+// shipLogEntry queues an entry for the remote sinks, dropping it if the queue is full
+func shipLogEntry(entry string) {
+	if logShipQueue == nil {
+		return
+	}
+	select {
+	case logShipQueue <- entry:
+	default:
+		fmt.Println("Log shipping queue is full, dropping entry")
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLogShipping verifies log entries reach syslog and the HTTP collector.
+func TestLogShipping(t *testing.T) {
+	setupTestEnvironment()
+
+	// A UDP syslog server.
+	syslogConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error listening: %v", err)
+	}
+	defer syslogConn.Close()
+
+	// An HTTP log collector.
+	collected := make(chan string, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var entry map[string]string
+		json.NewDecoder(r.Body).Decode(&entry)
+		collected <- entry["message"]
+	}))
+	defer collector.Close()
+
+	config.SyslogAddress = syslogConn.LocalAddr().String()
+	config.LogCollectorURL = collector.URL
+	configureLogSinks()
+	defer func() {
+		close(logShipQueue)
+		logShipQueue = nil
+	}()
+
+	logData("Class created successfully", "Pilates")
+
+	buffer := make([]byte, 2048)
+	syslogConn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := syslogConn.ReadFrom(buffer)
+	if err != nil {
+		t.Fatalf("expected a syslog message, got %v", err)
+	}
+	if message := string(buffer[:n]); !strings.HasPrefix(message, "<134>1 ") || !strings.Contains(message, "Class created successfully: Pilates") {
+		t.Errorf("unexpected syslog message %q", message)
+	}
+
+	select {
+	case message := <-collected:
+		if !strings.Contains(message, "Class created successfully: Pilates") {
+			t.Errorf("unexpected collected message %q", message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the log collector to receive the entry")
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// availabilityFiles are the data files a class's availability is read from
+var availabilityFiles = map[string]bool{"classes.json": true, "class_sessions.json": true, "bookings.json": true, "holds.json": true}
+
+// availabilitySignal is closed and replaced whenever an availability file is
+// saved, waking the polls waiting on it to check their class again
+var availabilitySignal = make(chan struct{})
+
+// signalAvailability wakes the waiting availability polls.
+// Callers must hold the mutex.
+func signalAvailability() {
+	close(availabilitySignal)
+	availabilitySignal = make(chan struct{})
+}
+
+// sessionAvailability is the availability of one session of a class
+type sessionAvailability struct {
+	SessionID      int  `json:"sessionId"`
+	Date           Date `json:"date"`
+	Capacity       int  `json:"capacity"`
+	Booked         int  `json:"booked"`
+	AvailableSlots int  `json:"availableSlots"`
+	Cancelled      bool `json:"cancelled,omitempty"`
+}
+
+// classAvailability returns the availability of a class's sessions and a
+// version that changes whenever it does. Callers must hold the mutex.
+func classAvailability(class Class) ([]sessionAvailability, string) {
+	ensureSessions(class)
+	availability := []sessionAvailability{}
+	for _, session := range classSessions {
+		if session.ClassID != class.ID {
+			continue
+		}
+		view := viewSession(session)
+		availability = append(availability, sessionAvailability{
+			SessionID:      session.ID,
+			Date:           session.Date,
+			Capacity:       session.Capacity,
+			Booked:         view.Booked,
+			AvailableSlots: view.AvailableSlots,
+			Cancelled:      session.CancelledAt != nil,
+		})
+	}
+	encoded, _ := json.Marshal(availability)
+	hash := fnv.New64a()
+	hash.Write(encoded)
+	return availability, fmt.Sprintf("%016x", hash.Sum64())
+}
+
+// Handler for long polling a class's availability, for clients whose proxies
+// break streaming responses. It answers as soon as the availability differs
+// from the version given as since, or with the same version once the wait
+// is over.
+func availabilityPollHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid class id")
+		return
+	}
+	// Clients may wait less than the configured longest poll
+	wait := config.LongPollSeconds
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		timeout, err := strconv.Atoi(value)
+		if err != nil || timeout < 0 {
+			errorResponse(w, http.StatusBadRequest, "Invalid timeout, use a number of seconds")
+			return
+		}
+		wait = min(wait, timeout)
+	}
+	since := r.URL.Query().Get("since")
+
+	timer := time.NewTimer(time.Duration(wait) * time.Second)
+	defer timer.Stop()
+	timedOut := false
+	for {
+		mutex.Lock()
+		index := classIndex(id)
+		if index < 0 || classes[index].DeletedAt != nil {
+			mutex.Unlock()
+			errorResponse(w, http.StatusNotFound, "Class not found")
+			return
+		}
+		availability, version := classAvailability(classes[index])
+		changed := availabilitySignal
+		mutex.Unlock()
+
+		if version != since || timedOut {
+			successResponse(w, http.StatusOK, "Class availability retrieved successfully", map[string]interface{}{
+				"classId":  id,
+				"version":  version,
+				"changed":  version != since,
+				"sessions": availability,
+			})
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			timedOut = true
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
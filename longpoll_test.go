@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// pollAvailability long polls a class's availability and decodes the response
+func pollAvailability(t *testing.T, query string) (int, map[string]interface{}) {
+	req := httptest.NewRequest(http.MethodGet, "/classes/1/availability/poll"+query, nil)
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+	availabilityPollHandler(rec, req)
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	return rec.Code, response.Data
+}
+
+// TestAvailabilityPoll verifies a poll answers at once for a stale version, waits for a booking otherwise, and times out unchanged.
+func TestAvailabilityPoll(t *testing.T) {
+	setupTestEnvironment()
+	rec := httptest.NewRecorder()
+	classHandler(rec, httptest.NewRequest(http.MethodPost, "/classes", bytes.NewReader([]byte(`{"className":"Yoga","startDate":"01-12-2099","endDate":"02-12-2099","capacity":2}`))))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the class to be created, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	code, first := pollAvailability(t, "")
+	if code != http.StatusOK || first["changed"] != true || len(first["sessions"].([]interface{})) != 2 {
+		t.Fatalf("expected the current availability at once, got %d: %+v", code, first)
+	}
+	version := first["version"].(string)
+
+	// A booking made while the poll waits ends it with the new availability
+	done := make(chan map[string]interface{})
+	go func() {
+		_, data := pollAvailability(t, "?since="+version+"&timeout=5")
+		done <- data
+	}()
+	time.Sleep(50 * time.Millisecond)
+	rec = httptest.NewRecorder()
+	bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"Ann","className":"Yoga","date":"01-12-2099"}`))))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the booking to be made, got %d: %s", rec.Code, rec.Body.String())
+	}
+	select {
+	case data := <-done:
+		session := data["sessions"].([]interface{})[0].(map[string]interface{})
+		if data["changed"] != true || data["version"] == version || session["availableSlots"] != float64(1) {
+			t.Errorf("expected the booked place in the answer, got %+v", data)
+		}
+		version = data["version"].(string)
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected the booking to end the poll")
+	}
+
+	code, data := pollAvailability(t, "?since="+version+"&timeout=0")
+	if code != http.StatusOK || data["changed"] != false || data["version"] != version {
+		t.Errorf("expected an unchanged answer once the wait is over, got %d: %+v", code, data)
+	}
+
+	tests := []struct {
+		name           string
+		id             string
+		query          string
+		expectedStatus int
+	}{
+		{"Invalid Timeout", "1", "?timeout=soon", http.StatusBadRequest},
+		{"Unknown Class", "9", "", http.StatusNotFound},
+		{"Invalid ID", "abc", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/classes/"+tt.id+"/availability/poll"+tt.query, nil)
+			req.SetPathValue("id", tt.id)
+			rec := httptest.NewRecorder()
+			availabilityPollHandler(rec, req)
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// PointsEntry is one change to a member's loyalty points. The ledger is
+// append-only: a member's balance is the sum of their entries.
+type PointsEntry struct {
+	ID         int       `json:"id"`
+	MemberName string    `json:"memberName"`
+	Delta      int       `json:"delta"`  // Points earned, or spent when negative
+	Reason     string    `json:"reason"` // "attendance", "redemption" or "refund"
+	BookingID  int       `json:"bookingId,omitempty"`
+	Actor      string    `json:"actor"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// Reasons for points entries
+const (
+	pointsReasonAttendance = "attendance"
+	pointsReasonRedemption = "redemption"
+	pointsReasonRefund     = "refund"
+)
+
+// paymentPoints books a class for free in exchange for loyalty points
+const paymentPoints = "points"
+
+var (
+	pointsEntries []PointsEntry // Temp Slice to hold the loyalty points ledger
+	pointsEntryId = 1           // Incremental ID for points entries
+)
+
+// pointsBalance returns the loyalty points a member has.
+// Callers must hold the mutex.
+func pointsBalance(memberName string) int {
+	balance := 0
+	for _, entry := range pointsEntries {
+		if entry.MemberName == memberName {
+			balance += entry.Delta
+		}
+	}
+	return balance
+}
+
+// addPointsEntry appends an entry to the ledger and persists it, undoing the
+// entry when it cannot be saved. Callers must hold the mutex.
+func addPointsEntry(entry PointsEntry) (PointsEntry, error) {
+	entry.ID = pointsEntryId
+	entry.CreatedAt = now()
+	pointsEntries = append(pointsEntries, entry)
+	pointsEntryId++
+	if err := writeDataToJsonFile("points.json", pointsEntries); err != nil {
+		pointsEntries = pointsEntries[:len(pointsEntries)-1]
+		pointsEntryId--
+		return PointsEntry{}, err
+	}
+	return entry, nil
+}
+
+// pointsCost returns the points a free booking costs, or a rejection when the
+// member cannot redeem them for it. Callers must hold the mutex.
+func pointsCost(booking Booking) (int, *requestRejection) {
+	if booking.ResourceID != 0 {
+		return 0, &requestRejection{http.StatusBadRequest, "Resource bookings cannot be paid for with points"}
+	}
+	if config.LoyaltyRedemptionPoints <= 0 {
+		return 0, &requestRejection{http.StatusBadRequest, "Loyalty points cannot be redeemed"}
+	}
+	if pointsBalance(booking.MemberName) < config.LoyaltyRedemptionPoints {
+		return 0, &requestRejection{http.StatusBadRequest, fmt.Sprintf("A free booking takes %d loyalty points", config.LoyaltyRedemptionPoints)}
+	}
+	return config.LoyaltyRedemptionPoints, nil
+}
+
+// awardAttendancePoints credits a member with the points an attended class
+// earns: the class's loyaltyPoints, or config.LoyaltyPointsPerClass when it
+// sets none. Callers must hold the mutex.
+func awardAttendancePoints(booking Booking, actor string) {
+	if booking.ResourceID != 0 {
+		return
+	}
+	points := config.LoyaltyPointsPerClass
+	if class := findClassOn(booking.ClassName, booking.Date.Time); class != nil && class.LoyaltyPoints > 0 {
+		points = class.LoyaltyPoints
+	}
+	if points <= 0 {
+		return
+	}
+	if _, err := addPointsEntry(PointsEntry{MemberName: booking.MemberName, Delta: points, Reason: pointsReasonAttendance, BookingID: booking.ID, Actor: actor}); err != nil {
+		fmt.Println("Error awarding loyalty points:", err)
+	}
+}
+
+// Handler for a member's loyalty points balance and ledger
+func memberPointsHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+	memberName := r.PathValue("name")
+	if memberName == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid member name")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	entries := []PointsEntry{}
+	for _, entry := range pointsEntries {
+		if entry.MemberName == memberName {
+			entries = append(entries, entry)
+		}
+	}
+	successResponse(w, http.StatusOK, "Loyalty points retrieved successfully", map[string]interface{}{
+		"memberName":       memberName,
+		"balance":          pointsBalance(memberName),
+		"redemptionPoints": config.LoyaltyRedemptionPoints,
+		"entries":          entries,
+	})
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLoyaltyPoints verifies attended classes earn points that members redeem for free bookings.
+func TestLoyaltyPoints(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2099, 12, 2, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	config.LoyaltyPointsPerClass, config.LoyaltyRedemptionPoints = 40, 100
+	members = []Member{{Name: "Ann"}}
+	classes = []Class{
+		{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 5, Price: 1500, Currency: "EUR"},
+		{ID: 2, ClassName: "Reformer", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 5, LoyaltyPoints: 25},
+	}
+	classId = 3
+	bookings = []Booking{
+		{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed},
+		{ID: 2, MemberName: "Ann", ClassName: "Reformer", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed},
+		{ID: 3, MemberName: "Ann", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed},
+	}
+	bookingId = 4
+
+	for _, tt := range []struct {
+		id      string
+		balance int
+	}{{"1", 40}, {"2", 65}, {"3", 105}, {"3", 105}} {
+		req := httptest.NewRequest(http.MethodPost, "/bookings/"+tt.id+"/check-in", nil)
+		req.SetPathValue("id", tt.id)
+		checkInBookingHandler(httptest.NewRecorder(), req)
+		if balance := pointsBalance("Ann"); balance != tt.balance {
+			t.Errorf("expected %d points after checking in to booking %s, got %d", tt.balance, tt.id, balance)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/members/Ann", nil)
+	req.SetPathValue("name", "Ann")
+	rec := httptest.NewRecorder()
+	memberProfileHandler(rec, req)
+	if !strings.Contains(rec.Body.String(), `"loyaltyPoints":105`) {
+		t.Errorf("expected the balance on the profile, got %s", rec.Body.String())
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		balance        int
+	}{
+		{"Redeem Free Booking", `{"memberName":"Ann","className":"Yoga","date":"05-12-2099","paymentMethod":"points"}`, http.StatusCreated, 5},
+		{"Not Enough Points", `{"memberName":"Ann","className":"Yoga","date":"06-12-2099","paymentMethod":"points"}`, http.StatusBadRequest, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(tt.body))))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+			if balance := pointsBalance("Ann"); balance != tt.balance {
+				t.Errorf("expected %d points left, got %d", tt.balance, balance)
+			}
+		})
+	}
+	if booking := bookings[3]; booking.PointsUsed != 100 || booking.Price != 0 || booking.Total != 0 {
+		t.Errorf("expected a free booking paid with points, got %+v", booking)
+	}
+
+	// A free booking cancelled in time gets its points back
+	bookings[3].Status = bookingStatusCancelled
+	req = httptest.NewRequest(http.MethodPost, "/bookings/4/refund", nil)
+	req.SetPathValue("id", "4")
+	rec = httptest.NewRecorder()
+	bookingRefundHandler(rec, req)
+	if rec.Code != http.StatusOK || refunds[0].Points != 100 || refunds[0].Method != refundMethodPoints || pointsBalance("Ann") != 105 {
+		t.Errorf("expected the points to be returned, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if entry := pointsEntries[len(pointsEntries)-1]; entry.Reason != pointsReasonRefund || entry.BookingID != 4 {
+		t.Errorf("expected the refund in the ledger, got %+v", entry)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,27 +13,98 @@ import (
 
 // Class represents a studio class
 type Class struct {
-	ID        int    `json:"id"`
-	ClassName string `json:"className"`
-	StartDate string `json:"startDate"`
-	EndDate   string `json:"endDate"`
-	Capacity  int    `json:"capacity"`
+	ID              int        `json:"id"`
+	ClassName       string     `json:"className"`
+	StartDate       Date       `json:"startDate"` // DD-MM-YYYY on the wire
+	EndDate         Date       `json:"endDate"`
+	Capacity        int        `json:"capacity"`
+	DurationMinutes int        `json:"durationMinutes,omitempty"`
+	StartTime       string     `json:"startTime,omitempty"` // HH:MM each session starts at
+	Room            string     `json:"room,omitempty"`
+	StudioID        int        `json:"studioId,omitempty"` // Location the class is held at
+	Price           int        `json:"price,omitempty"` // In minor units, e.g. cents
+	Currency        string     `json:"currency,omitempty"` // ISO 4217 code of the price
+	EarlyBirdPrice  int        `json:"earlyBirdPrice,omitempty"` // Discounted price of sessions booked early enough
+	EarlyBirdDays   int        `json:"earlyBirdDays,omitempty"` // Days before a session the early-bird price ends
+	CreditCost      int        `json:"creditCost,omitempty"` // Credits a booking costs when paid from a class pack, 1 when unset
+	LoyaltyPoints   int        `json:"loyaltyPoints,omitempty"` // Points attending earns, the configured default when unset
+	TemplateID      int        `json:"templateId,omitempty"`
+	Instructor      string     `json:"instructor,omitempty"`
+	Category        string     `json:"category,omitempty"`
+	Level           string     `json:"level,omitempty"` // Skill level members need to book
+	MinAge          int        `json:"minAge,omitempty"`
+	MaxAge          int        `json:"maxAge,omitempty"`
+	Equipment       []string   `json:"equipment,omitempty"`       // Equipment members need to bring or rent
+	RentalInventory map[string]int `json:"rentalInventory,omitempty"` // Items for rent and how many each session has
+	Tags            []string   `json:"tags,omitempty"`
+	Image           string     `json:"image,omitempty"` // URL of the class's uploaded image
+	AutoScale       *AutoScaleRule `json:"autoScale,omitempty"` // Opens extra sessions when the waitlist grows
+	DeletedAt       *time.Time `json:"deletedAt,omitempty"`
+	Links           resourceLinks `json:"_links,omitempty"` // Follow-up requests, added when sent
 }
 
 // Booking represents a booking for a class
 type Booking struct {
-	ID          int    `json:"id"`
-	MemberName  string `json:"memberName"`
-	Date        string `json:"date"`
-	ClassName   string `json:"className"`
+	ID            int    `json:"id"`
+	Code          string `json:"code,omitempty"` // Confirmation code quoted by members
+	MemberName    string `json:"memberName"`
+	Date          Date   `json:"date"` // DD-MM-YYYY on the wire
+	ClassName     string `json:"className"`
+	Status        string `json:"status"`
+	GroupID       int    `json:"groupId,omitempty"`
+	SeriesID      int    `json:"seriesId,omitempty"` // First booking of a series booked in one request
+	SessionID     int    `json:"sessionId,omitempty"` // Class session the booking attends
+	ClassID       int    `json:"classId,omitempty"` // Class of that session
+	ResourceID    int    `json:"resourceId,omitempty"` // Resource booked instead of a class
+	StartTime     string `json:"startTime,omitempty"` // HH:MM slot of a resource booking
+	Price         int    `json:"price,omitempty"` // Class price in minor units when booked
+	ListPrice     int    `json:"listPrice,omitempty"` // Class price before a pricing rule changed it
+	PricingRule   *AppliedPricingRule `json:"pricingRule,omitempty"` // Rule that set the price
+	EarlyBird     bool   `json:"earlyBird,omitempty"` // Booked at the class's early-bird price
+	PromoCode     string `json:"promoCode,omitempty"` // Promo code quoted when booking
+	PromoDiscount int    `json:"promoDiscount,omitempty"` // Taken off the price by the promo code
+	Currency      string `json:"currency,omitempty"` // ISO 4217 code of the price
+	Tax           *TaxLine `json:"tax,omitempty"` // Tax charged on top of the price
+	Total         int    `json:"total,omitempty"` // Price plus tax in minor units
+	PaymentReference string `json:"paymentReference,omitempty"` // Reference of the payment, shown on receipts
+	PaymentMethod string `json:"paymentMethod,omitempty"` // "credits" when paid from a class pack, "points" when redeemed for loyalty points
+	CreditsUsed   int    `json:"creditsUsed,omitempty"`
+	PointsUsed    int    `json:"pointsUsed,omitempty"`
+	GiftCardCode  string `json:"giftCardCode,omitempty"` // Gift card to pay with, only read when booking
+	GiftCardID    int    `json:"giftCardId,omitempty"`
+	GiftCardAmount int   `json:"giftCardAmount,omitempty"` // Part of the total paid by the gift card
+	RefundID      int    `json:"refundId,omitempty"`
+	ActionRequired string `json:"actionRequired,omitempty"` // Why the member needs to move or cancel the booking
+	PrimaryMember string `json:"primaryMember,omitempty"`
+	HoldID        int    `json:"holdId,omitempty"` // Hold the booking was confirmed from
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"` // Deadline for confirming a pending booking
+	LevelOverride bool   `json:"levelOverride,omitempty"` // Set by staff to book a member below the class level
+	Rentals       []string `json:"rentals,omitempty"` // Equipment rented with the booking
+	Links         resourceLinks `json:"_links,omitempty"` // Follow-up requests, added when sent
 }
 
+// Booking statuses
+const (
+	bookingStatusPending   = "pending"
+	bookingStatusConfirmed = "confirmed"
+	bookingStatusCancelled = "cancelled"
+	bookingStatusAttended  = "attended"
+	bookingStatusExpired   = "expired"
+)
+
+// dateLayout is the DD-MM-YYYY wire format used for all dates
+const dateLayout = "02-01-2006"
+
+// timeLayout is the HH:MM wire format used for times of day
+const timeLayout = "15:04"
+
 var (
 	classes    []Class    // Temp Slice to hold class data
 	bookings   []Booking  // Temp Slice to hold booking data
 	classId    =1         // Incremental ID for classes
 	bookingId  =1         // Incremental ID for bookings
 	mutex      sync.Mutex // Mutex for thread safety
+	now        = time.Now // Clock, replaceable in tests
 )
 
 // dataFromJsonFile reads and unmarshals data from a JSON file
@@ -54,31 +126,335 @@ func dataFromJsonFile(fileName string, destination interface{}) error {
 	if len(data)==0 {
 		return nil      
 	}
-	return json.Unmarshal(data, destination)
+
+	// Decrypt the data if it was encrypted at rest
+	data, err = decryptData(data)
+	if err != nil {
+		return err
+	}
+	return decodeData(data, destination)
 }
 
 
 // writeDataToJsonFile writes updated data into a JSON file
 func writeDataToJsonFile(fileName string, data interface{}) error {
-		// Marshal the data into JSON format
-	jsonData, err := json.MarshalIndent(data, "", " ")
+		// Encode the data in the configured storage format
+	jsonData, err := encodeData(config.StorageFormat, data)
 	if err!= nil {
 		return err
 	}
+	// Encrypt the data when encryption at rest is enabled
+	jsonData, err = encryptData(jsonData)
+	if err != nil {
+		reportError("persistence", "Failed to encrypt "+fileName, map[string]string{"error": err.Error()})
+		return err
+	}
 	// Write the JSON data into the file
-	return os.WriteFile(fileName, jsonData, 0666)
+	if err := os.WriteFile(fileName, jsonData, 0666); err != nil {
+		reportError("persistence", "Failed to write "+fileName, map[string]string{"error": err.Error()})
+		return err
+	}
+	// Wake availability polls and drop cached responses when the data behind them changes
+	if availabilityFiles[fileName] {
+		signalAvailability()
+	}
+	if responseCacheFiles[fileName] {
+		invalidateResponseCache()
+	}
+	return nil
+}
+
+
+// loadData loads every data file into memory and rebuilds the ID counters.
+// Callers must hold the mutex once the server is running.
+func loadData() error {
+	invalidateResponseCache()
+	classes, bookings, auditEntries, bookingEvents, templates, holds, waitlist, instructorAbsences = nil, nil, nil, nil, nil, nil, nil, nil
+	taxonomy, members, credentials, apiKeys, blocks, waiverAcceptances, devices, webhooks, outbox, consumedEvents, classSessions, rooms, resources, studios, creditEntries, refunds, giftCards, referrals, pointsEntries, pricingRules, promos, promoRedemptions, reportSubscriptions, closures, announcements, reviews, instructors, extraSessions, waitlistHistory = classTaxonomy{}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil
+	// Sessions are guarded by their own mutex, as sessionMember reads them without the main one
+	sessionMutex.Lock()
+	sessions = nil
+	sessionMutex.Unlock()
+	if err := dataFromJsonFile("classes.json", &classes); err != nil {
+		return fmt.Errorf("loading classes: %w", err)
+	}
+	if err := dataFromJsonFile("bookings.json", &bookings); err != nil {
+		return fmt.Errorf("loading bookings: %w", err)
+	}
+	if err := dataFromJsonFile("audit.json", &auditEntries); err != nil {
+		return fmt.Errorf("loading audit log: %w", err)
+	}
+	if err := dataFromJsonFile("booking_events.json", &bookingEvents); err != nil {
+		return fmt.Errorf("loading booking history: %w", err)
+	}
+	if err := dataFromJsonFile("templates.json", &templates); err != nil {
+		return fmt.Errorf("loading templates: %w", err)
+	}
+	if err := dataFromJsonFile("holds.json", &holds); err != nil {
+		return fmt.Errorf("loading holds: %w", err)
+	}
+	if err := dataFromJsonFile("waitlist.json", &waitlist); err != nil {
+		return fmt.Errorf("loading waitlist: %w", err)
+	}
+	if err := dataFromJsonFile("instructor_absences.json", &instructorAbsences); err != nil {
+		return fmt.Errorf("loading instructor availability: %w", err)
+	}
+	if err := dataFromJsonFile("taxonomy.json", &taxonomy); err != nil {
+		return fmt.Errorf("loading taxonomy: %w", err)
+	}
+	if err := dataFromJsonFile("members.json", &members); err != nil {
+		return fmt.Errorf("loading members: %w", err)
+	}
+	if err := dataFromJsonFile("credentials.json", &credentials); err != nil {
+		return fmt.Errorf("loading credentials: %w", err)
+	}
+	var loadedSessions []Session
+	if err := dataFromJsonFile("sessions.json", &loadedSessions); err != nil {
+		return fmt.Errorf("loading sessions: %w", err)
+	}
+	sessionMutex.Lock()
+	sessions = loadedSessions
+	sessionMutex.Unlock()
+	if err := dataFromJsonFile("api_keys.json", &apiKeys); err != nil {
+		return fmt.Errorf("loading API keys: %w", err)
+	}
+	if err := dataFromJsonFile("blocks.json", &blocks); err != nil {
+		return fmt.Errorf("loading blocks: %w", err)
+	}
+	if err := dataFromJsonFile("waivers.json", &waiverAcceptances); err != nil {
+		return fmt.Errorf("loading waivers: %w", err)
+	}
+	if err := dataFromJsonFile("devices.json", &devices); err != nil {
+		return fmt.Errorf("loading devices: %w", err)
+	}
+	if err := dataFromJsonFile("webhooks.json", &webhooks); err != nil {
+		return fmt.Errorf("loading webhooks: %w", err)
+	}
+	if err := dataFromJsonFile("outbox.json", &outbox); err != nil {
+		return fmt.Errorf("loading outbox: %w", err)
+	}
+	outboxEventMark = 0
+	if err := dataFromJsonFile(outboxMarkFile, &outboxEventMark); err != nil {
+		return fmt.Errorf("loading outbox mark: %w", err)
+	}
+	if err := dataFromJsonFile("consumed_events.json", &consumedEvents); err != nil {
+		return fmt.Errorf("loading consumed events: %w", err)
+	}
+	if err := dataFromJsonFile("class_sessions.json", &classSessions); err != nil {
+		return fmt.Errorf("loading class sessions: %w", err)
+	}
+	if err := dataFromJsonFile("rooms.json", &rooms); err != nil {
+		return fmt.Errorf("loading rooms: %w", err)
+	}
+	if err := dataFromJsonFile("resources.json", &resources); err != nil {
+		return fmt.Errorf("loading resources: %w", err)
+	}
+	if err := dataFromJsonFile("studios.json", &studios); err != nil {
+		return fmt.Errorf("loading studios: %w", err)
+	}
+	if err := dataFromJsonFile("credits.json", &creditEntries); err != nil {
+		return fmt.Errorf("loading credits: %w", err)
+	}
+	if err := dataFromJsonFile("refunds.json", &refunds); err != nil {
+		return fmt.Errorf("loading refunds: %w", err)
+	}
+	if err := dataFromJsonFile("giftcards.json", &giftCards); err != nil {
+		return fmt.Errorf("loading gift cards: %w", err)
+	}
+	if err := dataFromJsonFile("referrals.json", &referrals); err != nil {
+		return fmt.Errorf("loading referrals: %w", err)
+	}
+	if err := dataFromJsonFile("points.json", &pointsEntries); err != nil {
+		return fmt.Errorf("loading loyalty points: %w", err)
+	}
+	if err := dataFromJsonFile("pricing_rules.json", &pricingRules); err != nil {
+		return fmt.Errorf("loading pricing rules: %w", err)
+	}
+	if err := dataFromJsonFile("promos.json", &promos); err != nil {
+		return fmt.Errorf("loading promo codes: %w", err)
+	}
+	if err := dataFromJsonFile("promo_redemptions.json", &promoRedemptions); err != nil {
+		return fmt.Errorf("loading promo code redemptions: %w", err)
+	}
+	if err := dataFromJsonFile("report_subscriptions.json", &reportSubscriptions); err != nil {
+		return fmt.Errorf("loading report subscriptions: %w", err)
+	}
+	if err := dataFromJsonFile("closures.json", &closures); err != nil {
+		return fmt.Errorf("loading closures: %w", err)
+	}
+	if err := dataFromJsonFile("announcements.json", &announcements); err != nil {
+		return fmt.Errorf("loading announcements: %w", err)
+	}
+	if err := dataFromJsonFile("reviews.json", &reviews); err != nil {
+		return fmt.Errorf("loading reviews: %w", err)
+	}
+	if err := dataFromJsonFile("instructors.json", &instructors); err != nil {
+		return fmt.Errorf("loading instructors: %w", err)
+	}
+	if err := dataFromJsonFile("extra_sessions.json", &extraSessions); err != nil {
+		return fmt.Errorf("loading extra session data: %w", err)
+	}
+	if err := dataFromJsonFile("waitlist_history.json", &waitlistHistory); err != nil {
+		return fmt.Errorf("loading waitlist history: %w", err)
+	}
+
+	// Changes whose events were saved without them are finished
+	if err := replayEvents(); err != nil {
+		return fmt.Errorf("replaying events: %w", err)
+	}
+
+	// Continue numbering after the highest stored IDs
+	classId, bookingId, auditId, bookingEventId, templateId, holdId, waitlistId, instructorAbsenceId, apiKeyId, waiverId, deviceId, webhookId, outboxId, classSessionId, resourceId, studioId, creditEntryId, refundId, giftCardId, referralId, pointsEntryId, pricingRuleId, promoRedemptionId, reportSubscriptionId, closureId, announcementId, reviewId, instructorId, extraSessionId, waitlistOutcomeId = 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1
+	for _, class := range classes {
+		classId = max(classId, class.ID+1)
+	}
+	for _, booking := range bookings {
+		bookingId = max(bookingId, booking.ID+1)
+	}
+	for _, entry := range auditEntries {
+		auditId = max(auditId, entry.ID+1)
+	}
+	for _, event := range bookingEvents {
+		bookingEventId = max(bookingEventId, event.ID+1)
+	}
+	for _, template := range templates {
+		templateId = max(templateId, template.ID+1)
+	}
+	for _, hold := range holds {
+		holdId = max(holdId, hold.ID+1)
+	}
+	for _, entry := range waitlist {
+		waitlistId = max(waitlistId, entry.ID+1)
+	}
+	for _, absence := range instructorAbsences {
+		instructorAbsenceId = max(instructorAbsenceId, absence.ID+1)
+	}
+	for _, key := range apiKeys {
+		apiKeyId = max(apiKeyId, key.ID+1)
+	}
+	for _, acceptance := range waiverAcceptances {
+		waiverId = max(waiverId, acceptance.ID+1)
+	}
+	for _, device := range devices {
+		deviceId = max(deviceId, device.ID+1)
+	}
+	for _, webhook := range webhooks {
+		webhookId = max(webhookId, webhook.ID+1)
+	}
+	for _, record := range outbox {
+		outboxId = max(outboxId, record.ID+1)
+		outboxEventMark = max(outboxEventMark, record.EventID)
+	}
+	for _, session := range classSessions {
+		classSessionId = max(classSessionId, session.ID+1)
+	}
+	for _, resource := range resources {
+		resourceId = max(resourceId, resource.ID+1)
+	}
+	for _, studio := range studios {
+		studioId = max(studioId, studio.ID+1)
+	}
+	for _, entry := range creditEntries {
+		creditEntryId = max(creditEntryId, entry.ID+1)
+	}
+	for _, refund := range refunds {
+		refundId = max(refundId, refund.ID+1)
+	}
+	for _, card := range giftCards {
+		giftCardId = max(giftCardId, card.ID+1)
+	}
+	for _, referral := range referrals {
+		referralId = max(referralId, referral.ID+1)
+	}
+	for _, entry := range pointsEntries {
+		pointsEntryId = max(pointsEntryId, entry.ID+1)
+	}
+	for _, rule := range pricingRules {
+		pricingRuleId = max(pricingRuleId, rule.ID+1)
+	}
+	for _, redemption := range promoRedemptions {
+		promoRedemptionId = max(promoRedemptionId, redemption.ID+1)
+	}
+	for _, subscription := range reportSubscriptions {
+		reportSubscriptionId = max(reportSubscriptionId, subscription.ID+1)
+	}
+	for _, closure := range closures {
+		closureId = max(closureId, closure.ID+1)
+	}
+	for _, announcement := range announcements {
+		announcementId = max(announcementId, announcement.ID+1)
+	}
+	for _, review := range reviews {
+		reviewId = max(reviewId, review.ID+1)
+	}
+	for _, instructor := range instructors {
+		instructorId = max(instructorId, instructor.ID+1)
+	}
+	for _, extra := range extraSessions {
+		extraSessionId = max(extraSessionId, extra.ID+1)
+	}
+	for _, outcome := range waitlistHistory {
+		waitlistOutcomeId = max(waitlistOutcomeId, outcome.ID+1)
+	}
+
+	// Archived records keep their IDs, so numbering must skip past them too
+	archived = classArchive{}
+	if err := dataFromJsonFile(archiveFile, &archived); err != nil {
+		return fmt.Errorf("loading archive: %w", err)
+	}
+	for _, class := range archived.Classes {
+		classId = max(classId, class.ID+1)
+	}
+	for _, booking := range archived.Bookings {
+		bookingId = max(bookingId, booking.ID+1)
+	}
+	for _, session := range archived.Sessions {
+		classSessionId = max(classSessionId, session.ID+1)
+	}
+	var retained retentionArchive
+	if err := dataFromJsonFile(retentionArchiveFile, &retained); err != nil {
+		return fmt.Errorf("loading retention archive: %w", err)
+	}
+	for _, class := range retained.Classes {
+		classId = max(classId, class.ID+1)
+	}
+	for _, booking := range retained.Bookings {
+		bookingId = max(bookingId, booking.ID+1)
+	}
+	for _, session := range retained.Sessions {
+		classSessionId = max(classSessionId, session.ID+1)
+	}
+
+	// Data saved before classes had sessions gains them now
+	migrateSessions()
+	// Events whose outbox records were lost are queued again
+	republishEvents()
+	return nil
 }
 
 
 // logData writes a log entry for each API call response
 func logData(msg string, data interface{}) {
+	logMutex.Lock()
+	defer logMutex.Unlock()
+
+	// Rotate the log file once it is too large or too old
+	if err := rotateLogIfNeeded(); err != nil {
+		fmt.Println("Error rotating log file. Error: ", err)
+	}
+
 	// Open or create the log file
-	logFile, err:= os.OpenFile("api_responses.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	logFile, err:= os.OpenFile(logFileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
 	if err != nil {
 		fmt.Println("Error accessing log file. Error: ",err)
 	}
 	defer logFile.Close()
 
+	// Mask personal fields when privacy mode is enabled
+	if config.PrivacyMode {
+		data = redactPersonalData(data)
+	}
+
 	// Prepare the log entry with a timestamp
 	logEntry := fmt.Sprintf("[%s] %s: %v\n", time.Now().Format("02-01-2006 15:04:05"), msg, data)
 	
@@ -87,10 +463,18 @@ func logData(msg string, data interface{}) {
 	if err != nil {
 		fmt.Println("Error writing to the Log File, Error: ", err)
 	}
+
+	// Ship the entry to any remote log outputs
+	shipLogEntry(logEntry)
 }
 
 // successResponse to send a consistent success response
 func successResponse(w http.ResponseWriter, statusCode int, message string, data interface{}) {
+	data = withLinks(data)
+	// Other response formats are built from the data itself
+	if recorder, ok := w.(*bufferedResponseWriter); ok {
+		recorder.data, recorder.hasData = data, true
+	}
 	w.WriteHeader(statusCode)
 
 	// a custom response with message and data
@@ -110,6 +494,10 @@ func successResponse(w http.ResponseWriter, statusCode int, message string, data
 
 // errorResponse to send a consistent error response
 func errorResponse(w http.ResponseWriter, statusCode int,message string){
+	recordError(statusCode, message)
+	if statusCode >= http.StatusInternalServerError {
+		reportError("response", message, map[string]string{"statusCode": fmt.Sprint(statusCode)})
+	}
 	w.WriteHeader(statusCode)
 
 	// Construct an error response with a message
@@ -120,9 +508,55 @@ func errorResponse(w http.ResponseWriter, statusCode int,message string){
 	json.NewEncoder(w).Encode(response)
 }
 
+// errorDetailsResponse sends an error whose data explains it, such as the
+// per-item results of a failed batch
+func errorDetailsResponse(w http.ResponseWriter, statusCode int, message string, data interface{}) {
+	recordError(statusCode, message)
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": message,
+		"data":    data,
+	})
+}
+
+
+// findClassOn returns the class with the given name running on a date, or nil.
+// Callers must hold the mutex.
+func findClassOn(className string, date time.Time) *Class {
+	for _, index := range classesNamed(className) {
+		if class := classes[index]; class.DeletedAt == nil {
+			if !date.Before(class.StartDate.Time) && !date.After(class.EndDate.Time) {
+				return &class
+			}
+		}
+	}
+	return nil
+}
+
+// countBookings returns the number of active bookings for a class session.
+// Callers must hold the mutex.
+func countBookings(sessionID int) int {
+	if sessionID == 0 {
+		return 0
+	}
+	count := 0
+	for _, booking := range bookings {
+		if booking.SessionID == sessionID && bookingActive(booking) {
+			count++
+		}
+	}
+	return count
+}
 
-// Handler for class creation
+
+// Handler for class creation and listing
 func classHandler(w http.ResponseWriter, r *http.Request) {
+	// List the classes on GET
+	if r.Method == http.MethodGet {
+		listClasses(w, r)
+		return
+	}
+
 	// Ensure the request method is POST
 	if r.Method != http.MethodPost {
 		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
@@ -131,50 +565,47 @@ func classHandler(w http.ResponseWriter, r *http.Request) {
 	
 	// Decode the request body into a Class struct
 	var newClass Class
-	if err := json.NewDecoder(r.Body).Decode(&newClass); err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+	if err := decodeBody(r, &newClass); err != nil {
+		errorResponse(w, http.StatusBadRequest, bodyErrorMessage(err))
 		return
 	}
+	newClass.DeletedAt = nil
 
-	// Validate the class fields
-	if newClass.ClassName == "" || newClass.StartDate == "" || newClass.EndDate == "" || newClass.Capacity <= 0 {
-		errorResponse(w, http.StatusBadRequest, "Invalid data format")
-		return
-	}
+	// Ensure hold on the classes slice temporarily to tackle concurrency
+	mutex.Lock()
+	defer mutex.Unlock()
 
-	// Parse and validate the dates
-	startDate, err := time.Parse("02-01-2006", newClass.StartDate)
-	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid startDate format, use DD-MM-YYYY")
-		return
+	// Fill in the details a template provides
+	if newClass.TemplateID != 0 {
+		if rejection := applyTemplate(&newClass); rejection != nil {
+			errorResponse(w, rejection.StatusCode, rejection.Message)
+			return
+		}
 	}
 
-	endDate, err := time.Parse("02-01-2006", newClass.EndDate)
-	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid endDate format, use DD-MM-YYYY")
+	// Validate the class fields and dates
+	if rejection := checkClass(newClass); rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
 		return
 	}
-
-	// Ensure the end date is not before the start date
-	if endDate.Before(startDate) {
-		errorResponse(w, http.StatusBadRequest, "endDate must be after startDate")
+	if conflict := checkRoomCapacity(newClass.Room, newClass.Capacity); conflict != nil {
+		roomConflictResponse(w, *conflict)
 		return
 	}
 
-	// Ensure hold on the classes slice temporarily to tackle concurrency
-	mutex.Lock()
-	defer mutex.Unlock()
-
 	// Assign a unique ID to the class and append it to the classes slice
-	newClass.ID = classId
-	classId++
-	classes = append(classes, newClass)
+	addClass(&newClass)
 
-	// Save classes to JSON file
+	// Save classes to JSON file, after the event announcing the class
+	audit := recordAudit(actorFromRequest(r), "create", "class", newClass.ID, nil, newClass)
 	if err := writeDataToJsonFile("classes.json", classes); err != nil {
+		classes = classes[:len(classes)-1]
+		classId--
+		retractAudit(audit)
 		errorResponse(w, http.StatusInternalServerError, "Failed to save class data")
 		return
 	}
+	ensureSessions(newClass)
 
 	// Send a success response and log the event
 	successResponse(w, http.StatusCreated, "Class created successfully", newClass)
@@ -192,75 +623,192 @@ func bookingHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Decode the request body into a Booking struct
 	var newBooking Booking
-	if err := json.NewDecoder(r.Body).Decode(&newBooking); err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+	if err := decodeBody(r, &newBooking); err != nil {
+		errorResponse(w, http.StatusBadRequest, bodyErrorMessage(err))
 		return
 	}
-
-	// Validate the booking fields
-	if newBooking.MemberName == "" || newBooking.Date == "" || newBooking.ClassName == "" {
-		errorResponse(w, http.StatusBadRequest, "Invalid field format")
+	// Admins impersonating a member book for that member
+	if member := impersonatedMember(r); member != "" {
+		if newBooking.MemberName != "" && newBooking.MemberName != member {
+			errorResponse(w, http.StatusBadRequest, "memberName does not match the impersonated member")
+			return
+		}
+		newBooking.MemberName = member
+	}
+	// Members signed in without a key book for themselves
+	if member := sessionOnlyMember(r); member != "" {
+		newBooking.MemberName = member
+	}
+	force, rejection := forceRequested(r)
+	if rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
 		return
 	}
-
-	// Validate the booking fields
-	bookingDate, err := time.Parse("02-01-2006", newBooking.Date)
-	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid date format, use DD-MM-YYYY")
+	if newBooking.PaymentMethod != "" && newBooking.PaymentMethod != paymentCredits && newBooking.PaymentMethod != paymentPoints {
+		errorResponse(w, http.StatusBadRequest, "Invalid paymentMethod, use credits, points or leave it out")
+		return
+	}
+	// Scripts grabbing slots the moment a class opens are slowed down per member
+	if newBooking.MemberName != "" && rateLimited(w, newBooking.MemberName) {
 		return
 	}
 
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	// Find the class by name and ensure the date is within its range
-	var classFound *Class
-	for _, class := range classes {
-		if class.ClassName == newBooking.ClassName {
-			startDate, _ := time.Parse("02-01-2006", class.StartDate)
-			endDate, _ := time.Parse("02-01-2006", class.EndDate)
-			if !bookingDate.Before(startDate) && !bookingDate.After(endDate) {
-				classFound = &class
-				break
-			}
+	// A booking confirmed from a hold takes over the slot the hold reserved
+	var claimed *Hold
+	if newBooking.HoldID != 0 {
+		hold, rejection := claimHold(newBooking)
+		if rejection != nil {
+			errorResponse(w, rejection.StatusCode, rejection.Message)
+			return
 		}
+		claimed = &hold
 	}
 
-	if classFound == nil {
-		errorResponse(w, http.StatusBadRequest, "Class is not available on the specified date")
-		return
+	// Validate the booking and ensure there's availability, in the class or
+	// in the resource slot it is for
+	var classFound *Class
+	var availableSlots int
+	if newBooking.ResourceID != 0 {
+		availableSlots, rejection = checkResourceBooking(newBooking)
+	} else {
+		classFound, availableSlots, rejection = checkBooking(newBooking)
 	}
-
-	// Count current bookings for the class on the specified date
-	currentBookings := 0
-	for _, booking := range bookings {
-		if booking.ClassName == newBooking.ClassName && booking.Date == newBooking.Date {
-			currentBookings++
-		}
+	var conflicts []Booking
+	if rejection == nil {
+		conflicts, rejection = checkScheduleConflicts(newBooking, 0, force)
 	}
-
-	// Calculate available slots and ensure there's availability	
-	availableSlots := classFound.Capacity - currentBookings
-	if availableSlots <= 0 {
-		errorResponse(w, http.StatusBadRequest, "No available slots for the selected class on this date")
+	// Bookings paid for from a class pack need enough credits
+	newBooking.CreditsUsed = 0
+	if rejection == nil && newBooking.PaymentMethod == paymentCredits {
+		newBooking.CreditsUsed, rejection = creditCost(newBooking)
+	}
+	// Free bookings are redeemed for loyalty points
+	newBooking.PointsUsed = 0
+	if rejection == nil && newBooking.PaymentMethod == paymentPoints {
+		newBooking.PointsUsed, rejection = pointsCost(newBooking)
+	}
+	if rejection == nil && newBooking.PromoCode != "" {
+		rejection = promoRedeemable(newBooking)
+	}
+	// A gift card pays what it can of the total, the rest is paid as usual
+	giftCard := -1
+	if rejection == nil && newBooking.GiftCardCode != "" {
+		giftCard, rejection = checkGiftCard(newBooking)
+	}
+	if rejection != nil {
+		if claimed != nil {
+			holds = append(holds, *claimed)
+		}
+		errorResponse(w, rejection.StatusCode, rejection.Message)
 		return
 	}
+
 	// Assign a unique ID to the booking and append it to the bookings slice
-	newBooking.ID = bookingId
-	bookingId++
-	bookings = append(bookings, newBooking)
+	bookedBefore := countBookings(sessionIDOn(newBooking.ClassName, newBooking.Date))
+	addBooking(&newBooking)
+	if giftCard >= 0 {
+		newBooking.GiftCardID, newBooking.GiftCardAmount = giftCards[giftCard].ID, min(giftCards[giftCard].Balance, newBooking.Total)
+		bookings[len(bookings)-1] = newBooking
+	}
 
-	// Save bookings to the JSON file
+	// Save bookings to the JSON file, after the event announcing the booking
+	audit := recordAudit(actorFromRequest(r), "create", "booking", newBooking.ID, nil, newBooking)
 	if err := writeDataToJsonFile("bookings.json", bookings); err != nil {
+		bookings = bookings[:len(bookings)-1]
+		bookingId--
+		retractAudit(audit)
+		if claimed != nil {
+			holds = append(holds, *claimed)
+		}
 		errorResponse(w, http.StatusInternalServerError, "Failed to save booking data")
 		return
 	}
+	if claimed != nil {
+		if err := writeDataToJsonFile("holds.json", holds); err != nil {
+			fmt.Println("Error saving holds:", err)
+		}
+	}
+	if newBooking.CreditsUsed > 0 {
+		if _, err := addCreditEntry(CreditEntry{MemberName: newBooking.MemberName, Delta: -newBooking.CreditsUsed, Reason: creditReasonBooking, BookingID: newBooking.ID, Actor: actorFromRequest(r)}); err != nil {
+			bookings = bookings[:len(bookings)-1]
+			bookingId--
+			writeDataToJsonFile("bookings.json", bookings)
+			retractAudit(audit)
+			errorResponse(w, http.StatusInternalServerError, "Failed to save credit data")
+			return
+		}
+	}
+	if newBooking.PromoCode != "" {
+		if err := recordPromoRedemption(newBooking); err != nil {
+			bookings = bookings[:len(bookings)-1]
+			bookingId--
+			writeDataToJsonFile("bookings.json", bookings)
+			retractAudit(audit)
+			errorResponse(w, http.StatusInternalServerError, "Failed to save promo code data")
+			return
+		}
+	}
+	if newBooking.PointsUsed > 0 {
+		if _, err := addPointsEntry(PointsEntry{MemberName: newBooking.MemberName, Delta: -newBooking.PointsUsed, Reason: pointsReasonRedemption, BookingID: newBooking.ID, Actor: actorFromRequest(r)}); err != nil {
+			bookings = bookings[:len(bookings)-1]
+			bookingId--
+			writeDataToJsonFile("bookings.json", bookings)
+			retractAudit(audit)
+			errorResponse(w, http.StatusInternalServerError, "Failed to save loyalty points data")
+			return
+		}
+	}
+	if giftCard >= 0 {
+		if err := adjustGiftCard(giftCard, GiftCardTransaction{Amount: -newBooking.GiftCardAmount, Reason: giftCardReasonRedemption, BookingID: newBooking.ID, Actor: actorFromRequest(r)}); err != nil {
+			bookings = bookings[:len(bookings)-1]
+			bookingId--
+			writeDataToJsonFile("bookings.json", bookings)
+			retractAudit(audit)
+			errorResponse(w, http.StatusInternalServerError, "Failed to save gift card data")
+			return
+		}
+	}
+
+	recordBookingEvent(newBooking.ID, bookingEventCreated, actorFromRequest(r), nil)
+	notifyBooking(notifyBookingConfirmed, newBooking)
+	attributeFirstBooking(newBooking)
+	if classFound != nil {
+		alertIfNearlyFull(classFound, newBooking.Date, bookedBefore)
+	}
 
 	// Prepare the response with booking details and available slots
 	response := map[string]interface{}{
 		"booking":        newBooking,
 		"availableSlots": availableSlots - 1,
 	}
+	if newBooking.Price > 0 {
+		response["price"] = formatAmount(newBooking.Price, newBooking.Currency)
+		response["total"] = formatAmount(newBooking.Total, newBooking.Currency)
+		response["listPrice"] = formatAmount(listPrice(newBooking), newBooking.Currency)
+	}
+	if newBooking.PromoDiscount > 0 {
+		response["promoDiscount"] = formatAmount(newBooking.PromoDiscount, newBooking.Currency)
+	}
+	if newBooking.Tax != nil {
+		response["tax"] = formatAmount(newBooking.Tax.Amount, newBooking.Currency)
+	}
+	if newBooking.GiftCardAmount > 0 {
+		response["giftCard"] = formatAmount(newBooking.GiftCardAmount, newBooking.Currency)
+		response["amountDue"] = formatAmount(amountDue(newBooking), newBooking.Currency)
+	}
+	// Remind the member what to bring, rentals are listed on the booking itself
+	if classFound != nil && len(classFound.Equipment) > 0 {
+		response["requiredEquipment"] = classFound.Equipment
+	}
+	if classFound != nil && len(classFound.RentalInventory) > 0 {
+		response["rentalsAvailable"] = rentalsAvailable(classFound, newBooking.Date)
+	}
+	if len(conflicts) > 0 {
+		response["conflicts"] = conflicts
+	}
 
 	// Send a success response and log the event
 	successResponse(w, http.StatusCreated, "Booking successful", response)
@@ -269,20 +817,204 @@ func bookingHandler(w http.ResponseWriter, r *http.Request) {
 
 
 func main() {
+		// Load the server settings
+		if err := loadConfig("config.json"); err != nil {
+			fmt.Println("Error loading config:", err)
+		}
+		configureEncryption()
+		if err := configureSigning(); err != nil {
+			fmt.Println("Error configuring token signing:", err)
+		}
+		configureLogSinks()
+		if err := configureErrorReporter(); err != nil {
+			fmt.Println("Error configuring error reporter:", err)
+		}
+		if err := configureChatNotifier(); err != nil {
+			fmt.Println("Error configuring chat alerts:", err)
+		}
+		if err := configureTax(); err != nil {
+			fmt.Println("Error configuring tax:", err)
+		}
+		if err := configurePayments(); err != nil {
+			fmt.Println("Error configuring payments:", err)
+		}
+		if err := configureBlobs(); err != nil {
+			fmt.Println("Error configuring blob storage:", err)
+		}
+		if err := configureEventBus(); err != nil {
+			fmt.Println("Error configuring event bus:", err)
+		}
+		if err := configureStorage(); err != nil {
+			fmt.Println("Error configuring storage:", err)
+		}
+		if err := loadFlags(); err != nil {
+			fmt.Println("Error loading feature flags:", err)
+		}
+
+		// Restore a backup before loading, when requested
+		restoreFrom := flag.String("restore-from", "", "restore the data files from a backup archive, or the newest automatic backup with \"latest\", before starting")
+		convertTo := flag.String("convert-storage", "", "rewrite the data files in the \"json\" or \"gob\" storage format and exit")
+		adminKeyName := flag.String("create-admin-key", "", "create an admin API key with this name, print its secret and exit")
+		flag.Parse()
+		if *restoreFrom != "" {
+			if err := restoreBackupFile(*restoreFrom); err != nil {
+				fmt.Println("Error restoring backup:", err)
+				os.Exit(1)
+			}
+		}
+
+		// Convert the data files to another storage format, when requested
+		if *convertTo != "" {
+			if err := convertStorage(*convertTo); err != nil {
+				fmt.Println("Error converting storage:", err)
+				os.Exit(1)
+			}
+			fmt.Println("Data files converted to", *convertTo+"; set storageFormat to match")
+			return
+		}
+
 		// Load data from JSON files
-		if err := dataFromJsonFile("classes.json", &classes); err != nil {
-			fmt.Println("Error loading classes:", err)
+		if err := loadData(); err != nil {
+			fmt.Println("Error loading data:", err)
 		}
 	
-		if err := dataFromJsonFile("bookings.json", &bookings); err != nil {
-			fmt.Println("Error loading bookings:", err)
+		// Create an admin key for a new deployment, when requested
+		if *adminKeyName != "" {
+			secret, err := createAdminKey(*adminKeyName)
+			if err != nil {
+				fmt.Println("Error creating admin key:", err)
+				os.Exit(1)
+			}
+			fmt.Println("Admin API key created, keep it safe:", secret)
+			return
 		}
-	
+
 		// Register HTTP handlers
-		http.HandleFunc("/classes", classHandler)
+		http.HandleFunc("/classes", cacheResponses(classHandler))
+		http.HandleFunc("/classes/{id}", classItemHandler)
+		http.HandleFunc("/classes/{id}/restore", restoreClassHandler)
+		http.HandleFunc("/classes/{id}/clone", cloneClassHandler)
+		http.HandleFunc("/classes/{id}/reschedule", rescheduleClassHandler)
+		http.HandleFunc("/classes/{id}/capacity", classCapacityHandler)
+		http.HandleFunc("/classes/{id}/reviews", classReviewsHandler)
+		http.HandleFunc("/classes/{id}/image", classImageHandler)
+		http.HandleFunc("/classes/{id}/sessions", cacheResponses(classSessionsHandler))
+		http.HandleFunc("/classes/{id}/sessions/{date}", cacheResponses(classSessionHandler))
+		http.HandleFunc("/classes/{id}/sessions/{date}/cancel", cancelSessionHandler)
+		http.HandleFunc("/classes/{id}/availability/poll", availabilityPollHandler)
+		http.HandleFunc("/rooms", roomHandler)
+		http.HandleFunc("/rooms/{name}", roomItemHandler)
+		http.HandleFunc("/resources", resourceHandler)
+		http.HandleFunc("/resources/{id}", resourceItemHandler)
+		http.HandleFunc("/resources/{id}/availability", resourceAvailabilityHandler)
+		http.HandleFunc("/studios", studioHandler)
+		http.HandleFunc("/studios/nearby", nearbyStudiosHandler)
+		http.HandleFunc("/studios/{id}", studioItemHandler)
+		http.HandleFunc("/templates", templateHandler)
+		http.HandleFunc("/taxonomy", taxonomyHandler)
+		http.HandleFunc("/taxonomy/{kind}", taxonomyKindHandler)
+		http.HandleFunc("/taxonomy/{kind}/{name}", taxonomyItemHandler)
 		http.HandleFunc("/bookings", bookingHandler)
+		http.HandleFunc("/bookings/batch", batchBookingHandler)
+		http.HandleFunc("/bookings/group", groupBookingHandler)
+		http.HandleFunc("/bookings/series", seriesBookingHandler)
+		http.HandleFunc("/holds", holdHandler)
+		http.HandleFunc("/waitlist", waitlistHandler)
+		http.HandleFunc("/waitlist/{id}", waitlistItemHandler)
+		http.HandleFunc("/bookings/code/{code}", bookingCodeHandler)
+		http.HandleFunc("/bookings/{id}", bookingItemHandler)
+		http.HandleFunc("/bookings/{id}/{action}", bookingActionHandler)
+		http.HandleFunc("/check-in/card", cardCheckInHandler)
+		http.HandleFunc("/instructors", instructorHandler)
+		http.HandleFunc("/instructors/{id}", instructorItemHandler)
+		http.HandleFunc("/instructors/{id}/photo", instructorPhotoHandler)
+		http.HandleFunc("/images/{key...}", imageHandler)
+		http.HandleFunc("/instructors/{name}/unavailability", instructorAvailabilityHandler)
+		http.HandleFunc("/instructors/{name}/unavailability/{date}", instructorAbsenceHandler)
+		http.HandleFunc("/members/{name}", memberProfileHandler)
+		http.HandleFunc("/members/{name}/bookings", memberBookingsHandler)
+		http.HandleFunc("/members/{name}/invoice", memberInvoiceHandler)
+		http.HandleFunc("/members/{name}/credits", memberCreditsHandler)
+		http.HandleFunc("/members/{name}/referral", memberReferralHandler)
+		http.HandleFunc("/members/{name}/points", memberPointsHandler)
+		http.HandleFunc("/giftcards", giftCardPurchaseHandler)
+		http.HandleFunc("/giftcards/{code}", giftCardBalanceHandler)
+		http.HandleFunc("/members/{name}/data", memberDataHandler)
+		http.HandleFunc("/members/{name}/waitlist", memberWaitlistHandler)
+		http.HandleFunc("/members/{name}/password", memberPasswordHandler)
+		http.HandleFunc("/members/{name}/block", memberBlockHandler)
+		http.HandleFunc("/members/{name}/card", memberCardHandler)
+		http.HandleFunc("/members/{name}/waiver", memberWaiverHandler)
+		http.HandleFunc("/members/{name}/preferences", memberPreferencesHandler)
+		http.HandleFunc("/members/{name}/devices", memberDevicesHandler)
+		http.HandleFunc("/members/{name}/devices/{id}", memberDeviceHandler)
+		http.HandleFunc("/verify", verifyEmailHandler)
+		http.HandleFunc("/login", loginHandler)
+		http.HandleFunc("/logout", logoutHandler)
+		http.HandleFunc("/password-reset", passwordResetHandler)
+		http.HandleFunc("/password-reset/confirm", passwordResetConfirmHandler)
+		http.HandleFunc("/me", meHandler)
+		http.HandleFunc("/auth/google", oidcLoginHandler)
+		http.HandleFunc("/auth/google/callback", oidcCallbackHandler)
+		http.HandleFunc("/archive", archiveHandler)
+		http.HandleFunc("/stats/revenue", revenueStatsHandler)
+		http.HandleFunc("/stats/instructors", instructorStatsHandler)
+		http.HandleFunc("/stats/heatmap", heatmapStatsHandler)
+		http.HandleFunc("/stats/retention", retentionStatsHandler)
+		http.HandleFunc("/stats/capacity", capacityStatsHandler)
+		http.HandleFunc("/stats/waitlist", waitlistStatsHandler)
+		http.HandleFunc("/admin/summary", adminSummaryHandler)
+		http.HandleFunc("/admin/audit", auditHandler)
+		http.HandleFunc("/admin/backup", backupHandler)
+		http.HandleFunc("/admin/restore", restoreHandler)
+		http.HandleFunc("/admin/flags", flagsHandler)
+		http.HandleFunc("/admin/compact", compactionHandler)
+		http.HandleFunc("/admin/blocks", blocksHandler)
+		http.HandleFunc("/admin/waivers", waiversHandler)
+		http.HandleFunc("/admin/keys", apiKeyHandler)
+		http.HandleFunc("/admin/keys/{id}", apiKeyItemHandler)
+		http.HandleFunc("/admin/keys/{id}/rotate", rotateAPIKeyHandler)
+		http.HandleFunc("/admin/webhooks", webhookHandler)
+		http.HandleFunc("/admin/webhooks/{id}", webhookItemHandler)
+		http.HandleFunc("/admin/webhooks/{id}/test", webhookTestHandler)
+		http.HandleFunc("/admin/outbox", outboxHandler)
+		http.HandleFunc("/admin/giftcards", adminGiftCardHandler)
+		http.HandleFunc("/admin/referrals", referralReportHandler)
+		http.HandleFunc("/admin/pricing-rules", pricingRuleHandler)
+		http.HandleFunc("/admin/pricing-rules/{id}", pricingRuleItemHandler)
+		http.HandleFunc("/admin/promos", promoHandler)
+		http.HandleFunc("/admin/promos/{code}", promoItemHandler)
+		http.HandleFunc("/admin/promos/{code}/redemptions", promoRedemptionsHandler)
+		http.HandleFunc("/admin/closures", closureHandler)
+		http.HandleFunc("/admin/extra-sessions", extraSessionsHandler)
+		http.HandleFunc("/admin/extra-sessions/{id}/{action}", decideExtraSessionHandler)
+		http.HandleFunc("/admin/announcements", announcementHandler)
+		http.HandleFunc("/admin/reviews", adminReviewsHandler)
+		http.HandleFunc("/admin/reviews/{id}/{action}", moderateReviewHandler)
+		http.HandleFunc("/admin/report-subscriptions", reportSubscriptionHandler)
+		http.HandleFunc("/admin/report-subscriptions/{id}", reportSubscriptionItemHandler)
+		http.HandleFunc("/admin/giftcards/{id}", adminGiftCardItemHandler)
+		http.HandleFunc("/admin/events", externalEventsHandler)
+		http.HandleFunc("/ui/{$}", staffScheduleHandler)
+		http.HandleFunc("/ui/roster", staffRosterHandler)
+		http.HandleFunc("/ui/classes/new", staffNewClassHandler)
+		http.HandleFunc("/ui/classes", staffCreateClassHandler)
+	
+		// Start the background jobs
+		runEvery(time.Duration(config.CleanupIntervalMinutes)*time.Minute, runRetentionCleanup)
+		runEvery(time.Duration(config.ArchiveIntervalMinutes)*time.Minute, runArchival)
+		runEvery(time.Duration(config.HoldSweepSeconds)*time.Second, runHoldSweeper)
+		runEvery(time.Duration(config.HoldSweepSeconds)*time.Second, runPendingBookingSweeper)
+		runEvery(time.Duration(config.CompactionIntervalMinutes)*time.Minute, runCompaction)
+		runEvery(time.Duration(config.DigestIntervalHours)*time.Hour, runWeeklyDigest)
+		runEvery(time.Duration(config.BackupIntervalMinutes)*time.Minute, runScheduledBackup)
+		runEvery(time.Duration(config.FlagsReloadSeconds)*time.Second, runFlagsReload)
+		runEvery(time.Duration(config.CreditExpiryMinutes)*time.Minute, runCreditExpiry)
+		runEvery(time.Duration(config.ReportIntervalMinutes)*time.Minute, runScheduledReports)
+		runOutboxDispatcher(time.Duration(config.OutboxPollSeconds) * time.Second)
+		runEventConsumer()
 	
 		// Start the HTTP server
 		fmt.Println("Listening on :8088")
-		http.ListenAndServe(":8088", nil)
+		http.ListenAndServe(":8088", recoverPanics(negotiateContent(requireAPIKey(http.DefaultServeMux))))
 }
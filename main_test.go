@@ -9,6 +9,7 @@ import (
 	"os"
 	"sync"
 	"testing"
+	"time"
 )
 
 // resetTestFiles ensures empty JSON Files
@@ -16,6 +17,45 @@ func resetTestFiles() {
 	// Replace with temporary file abstraction or mock logic for cleaner testing.
 	os.WriteFile("classes.json", []byte("[]"), 0666)
 	os.WriteFile("bookings.json", []byte("[]"), 0666)
+	os.WriteFile("audit.json", []byte("[]"), 0666)
+	os.WriteFile("booking_events.json", []byte("[]"), 0666)
+	os.WriteFile("templates.json", []byte("[]"), 0666)
+	os.WriteFile("holds.json", []byte("[]"), 0666)
+	os.WriteFile("waitlist.json", []byte("[]"), 0666)
+	os.WriteFile("instructor_absences.json", []byte("[]"), 0666)
+	os.WriteFile("taxonomy.json", []byte("{}"), 0666)
+	os.WriteFile("members.json", []byte("[]"), 0666)
+	os.WriteFile("credentials.json", []byte("[]"), 0666)
+	os.WriteFile("sessions.json", []byte("[]"), 0666)
+	os.WriteFile("api_keys.json", []byte("[]"), 0666)
+	os.WriteFile("blocks.json", []byte("[]"), 0666)
+	os.WriteFile("waivers.json", []byte("[]"), 0666)
+	os.WriteFile("devices.json", []byte("[]"), 0666)
+	os.WriteFile("webhooks.json", []byte("[]"), 0666)
+	os.WriteFile("outbox.json", []byte("[]"), 0666)
+	os.WriteFile("consumed_events.json", []byte("[]"), 0666)
+	os.WriteFile("class_sessions.json", []byte("[]"), 0666)
+	os.WriteFile("rooms.json", []byte("[]"), 0666)
+	os.WriteFile("resources.json", []byte("[]"), 0666)
+	os.WriteFile("studios.json", []byte("[]"), 0666)
+	os.WriteFile("credits.json", []byte("[]"), 0666)
+	os.WriteFile("refunds.json", []byte("[]"), 0666)
+	os.WriteFile("giftcards.json", []byte("[]"), 0666)
+	os.WriteFile("referrals.json", []byte("[]"), 0666)
+	os.WriteFile("points.json", []byte("[]"), 0666)
+	os.WriteFile("pricing_rules.json", []byte("[]"), 0666)
+	os.WriteFile("promos.json", []byte("[]"), 0666)
+	os.WriteFile("promo_redemptions.json", []byte("[]"), 0666)
+	os.WriteFile("report_subscriptions.json", []byte("[]"), 0666)
+	os.WriteFile("closures.json", []byte("[]"), 0666)
+	os.WriteFile("announcements.json", []byte("[]"), 0666)
+	os.WriteFile("reviews.json", []byte("[]"), 0666)
+	os.WriteFile("instructors.json", []byte("[]"), 0666)
+	os.WriteFile("extra_sessions.json", []byte("[]"), 0666)
+	os.WriteFile("waitlist_history.json", []byte("[]"), 0666)
+	os.Remove(archiveFile)
+	os.Remove(retentionArchiveFile)
+	os.Remove(outboxMarkFile)
 }
 
 // setupTestEnvironment initializes the test environment by resetting data
@@ -27,6 +67,78 @@ func setupTestEnvironment() {
 	classId = 1
 	bookingId = 1
 	mutex = sync.Mutex{}
+	recentErrors = nil
+	auditEntries = []AuditEntry{}
+	auditId = 1
+	bookingEvents = []BookingEvent{}
+	bookingEventId = 1
+	templates = []ClassTemplate{}
+	templateId = 1
+	holds = []Hold{}
+	holdId = 1
+	waitlist = []WaitlistEntry{}
+	waitlistId = 1
+	instructorAbsences = []InstructorAbsence{}
+	instructorAbsenceId = 1
+	taxonomy = classTaxonomy{}
+	members = []Member{}
+	credentials = []Credential{}
+	sessions = []Session{}
+	apiKeys = []APIKey{}
+	apiKeyId = 1
+	blocks = []MemberBlock{}
+	waiverAcceptances = []WaiverAcceptance{}
+	waiverId = 1
+	devices = []Device{}
+	deviceId = 1
+	webhooks = []WebhookSubscription{}
+	webhookId = 1
+	outbox = []OutboxRecord{}
+	outboxId = 1
+	outboxEventMark = 0
+	consumedEvents = []ConsumedEvent{}
+	classSessions = []ClassSession{}
+	classSessionId = 1
+	rooms = []Room{}
+	resources = []Resource{}
+	resourceId = 1
+	studios = []Studio{}
+	studioId = 1
+	creditEntries = []CreditEntry{}
+	creditEntryId = 1
+	refunds = []Refund{}
+	refundId = 1
+	giftCards = []GiftCard{}
+	giftCardId = 1
+	referrals = []Referral{}
+	referralId = 1
+	pointsEntries = []PointsEntry{}
+	pointsEntryId = 1
+	pricingRules = []PricingRule{}
+	pricingRuleId = 1
+	promos = []Promo{}
+	promoRedemptions = []PromoRedemption{}
+	promoRedemptionId = 1
+	reportSubscriptions = []ReportSubscription{}
+	reportSubscriptionId = 1
+	closures = []Closure{}
+	closureId = 1
+	announcements = []Announcement{}
+	announcementId = 1
+	reviews = []Review{}
+	reviewId = 1
+	instructors = []Instructor{}
+	instructorId = 1
+	extraSessions = []ExtraSession{}
+	extraSessionId = 1
+	waitlistHistory = []WaitlistOutcome{}
+	waitlistOutcomeId = 1
+	archived = classArchive{}
+	bookingAttempts = map[string][]time.Time{}
+	config = defaultConfig()
+	logStartedAt = time.Time{}
+	featureFlags = map[string]FeatureFlag{}
+	flagsModTime = time.Time{}
 }
 // TestClassHandler verifies the behavior of the class creation handler.
 func TestClassHandler(t *testing.T) {
@@ -43,8 +155,8 @@ func TestClassHandler(t *testing.T) {
 			name: "Valid Class Creation",
 			input: Class{
 				ClassName: "Yoga",
-				StartDate: "01-12-2024",
-				EndDate:   "31-12-2024",
+				StartDate: testDate("01-12-2024"),
+				EndDate:   testDate("31-12-2024"),
 				Capacity:  20,
 			},
 			statusCode: http.StatusCreated,
@@ -54,8 +166,8 @@ func TestClassHandler(t *testing.T) {
 			name: "Invalid Dates",
 			input: Class{
 				ClassName: "Pilates",
-				StartDate: "31-12-2024",
-				EndDate:   "01-12-2024",
+				StartDate: testDate("31-12-2024"),
+				EndDate:   testDate("01-12-2024"),
 				Capacity:  10,
 			},
 			statusCode: http.StatusBadRequest,
@@ -65,8 +177,8 @@ func TestClassHandler(t *testing.T) {
 			name: "Negative Capacity",
 			input: Class{
 				ClassName: "Dance",
-				StartDate: "10-12-2024",
-				EndDate:   "20-12-2024",
+				StartDate: testDate("10-12-2024"),
+				EndDate:   testDate("20-12-2024"),
 				Capacity:  -5,
 			},
 			statusCode: http.StatusBadRequest,
@@ -106,8 +218,8 @@ func TestBookingHandler(t *testing.T) {
 	classes = append(classes, Class{
 		ID:        1,
 		ClassName: "Pilates",
-		StartDate: "15-12-2024",
-		EndDate:   "20-12-2024",
+		StartDate: testDate("15-12-2024"),
+		EndDate:   testDate("20-12-2024"),
 		Capacity:  10,
 	})
 	// Save the pre-created class to the JSON file.
@@ -124,7 +236,7 @@ func TestBookingHandler(t *testing.T) {
 			name: "Valid Booking",
 			input: Booking{
 				MemberName: "John Doe",
-				Date:       "16-12-2024",
+				Date:       testDate("16-12-2024"),
 				ClassName:  "Pilates",
 			},
 			statusCode: http.StatusCreated,
@@ -134,27 +246,17 @@ func TestBookingHandler(t *testing.T) {
 			name: "Class Not Available",
 			input: Booking{
 				MemberName: "Jane Doe",
-				Date:       "25-12-2024",
+				Date:       testDate("25-12-2024"),
 				ClassName:  "Pilates",
 			},
 			statusCode: http.StatusBadRequest,
 			message:    "Class is not available on the specified date",
 		},
-		{
-			name: "Invalid Date Format",
-			input: Booking{
-				MemberName: "Alice",
-				Date:       "12/16/2024",
-				ClassName:  "Pilates",
-			},
-			statusCode: http.StatusBadRequest,
-			message:    "Invalid date format, use DD-MM-YYYY",
-		},
 		{
 			name: "No Slots Available",
 			input: Booking{
 				MemberName: "Exceeding Slots",
-				Date:       "16-12-2024",
+				Date:       testDate("16-12-2024"),
 				ClassName:  "Pilates",
 			},
 			statusCode: http.StatusBadRequest,
@@ -171,11 +273,12 @@ func TestBookingHandler(t *testing.T) {
 					bookings = append(bookings, Booking{
 						ID:         bookingId,
 						MemberName: fmt.Sprintf("Member %d", i),
-						Date:       "16-12-2024",
+						Date:       testDate("16-12-2024"),
 						ClassName:  "Pilates",
 					})
 					bookingId++
 				}
+				migrateSessions()
 				// Save the filled bookings to the JSON file.
 				writeDataToJsonFile("bookings.json", bookings)
 			}
@@ -219,7 +322,7 @@ func TestAdditionalEdgeCases(t *testing.T) {
 			name: "Booking with Empty MemberName",
 			input: Booking{
 				MemberName: "",
-				Date:       "16-12-2024",
+				Date:       testDate("16-12-2024"),
 				ClassName:  "Pilates",
 			},
 			statusCode: http.StatusBadRequest,
@@ -229,7 +332,7 @@ func TestAdditionalEdgeCases(t *testing.T) {
 			name: "Booking with Past Date",
 			input: Booking{
 				MemberName: "John",
-				Date:       "10-10-2020",
+				Date:       testDate("10-10-2020"),
 				ClassName:  "Pilates",
 			},
 			statusCode: http.StatusBadRequest,
@@ -239,7 +342,7 @@ func TestAdditionalEdgeCases(t *testing.T) {
 			name: "Overlapping Classes with Different Names",
 			input: Booking{
 				MemberName: "John",
-				Date:       "16-12-2024",
+				Date:       testDate("16-12-2024"),
 				ClassName:  "Dance",
 			},
 			statusCode: http.StatusBadRequest,
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Member is a member's profile, identified by their name
+type Member struct {
+	Name          string                   `json:"memberName"`
+	Level         string                   `json:"level,omitempty"`
+	DateOfBirth   string                   `json:"dateOfBirth,omitempty"` // DD-MM-YYYY
+	Email         string                   `json:"email,omitempty"`
+	EmailVerified bool                     `json:"emailVerified,omitempty"`
+	OIDCSubject   string                   `json:"oidcSubject,omitempty"` // Google account linked for sign-in
+	Preferences   *NotificationPreferences `json:"preferences,omitempty"`
+	Phone         string                   `json:"phone,omitempty"`        // E.164, e.g. +447700900123
+	Tier          string                   `json:"tier,omitempty"`         // Membership tier set by the CRM, orders the waitlist
+	ReferralCode  string                   `json:"referralCode,omitempty"` // Code the member gives friends who sign up
+	ReferredBy    string                   `json:"referredBy,omitempty"`   // Member whose referral code was used to sign up
+	CardNumber    string                   `json:"cardNumber,omitempty"`   // Membership card or barcode scanned at check-in
+}
+
+// Skill levels, in increasing order
+const (
+	levelBeginner     = "beginner"
+	levelIntermediate = "intermediate"
+	levelAdvanced     = "advanced"
+)
+
+// levelRanks orders the skill levels
+var levelRanks = map[string]int{
+	levelBeginner:     0,
+	levelIntermediate: 1,
+	levelAdvanced:     2,
+}
+
+var members []Member // Temp Slice to hold member profiles
+
+// memberIndex returns the position of the member's profile, or -1.
+// Callers must hold the mutex.
+func memberIndex(name string) int {
+	for i, member := range members {
+		if member.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// memberLevel returns a member's skill level. Members without one are beginners.
+// Callers must hold the mutex.
+func memberLevel(name string) string {
+	if index := memberIndex(name); index >= 0 && members[index].Level != "" {
+		return members[index].Level
+	}
+	return levelBeginner
+}
+
+// bookingHistoryEntry is a booking annotated with whether it is still upcoming
+type bookingHistoryEntry struct {
+	Booking
+	Upcoming bool `json:"upcoming"`
+}
+
+// today returns the current date at midnight, comparable with parsed DD-MM-YYYY dates
+func today() time.Time {
+	year, month, day := now().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// historyCursor orders a member's bookings by date, then by ID
+func historyCursor(entry bookingHistoryEntry) pageCursor {
+	return pageCursor{Key: entry.Date.Format("20060102"), ID: entry.ID}
+}
+
+// Handler for a member's booking history
+func memberBookingsHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	memberName := r.PathValue("name")
+	if memberName == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid member name")
+		return
+	}
+
+	// "upcoming" or "past" narrows the history, anything else returns both
+	when := r.URL.Query().Get("when")
+	if when != "" && when != "upcoming" && when != "past" {
+		errorResponse(w, http.StatusBadRequest, "Invalid when filter, use upcoming or past")
+		return
+	}
+
+	page, err := paginationParams(r)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	mutex.Lock()
+	history := []bookingHistoryEntry{}
+	for _, booking := range allBookings() {
+		if booking.MemberName != memberName {
+			continue
+		}
+		// Bookings saved before statuses existed are confirmed
+		if booking.Status == "" {
+			booking.Status = bookingStatusConfirmed
+		}
+		entry := bookingHistoryEntry{Booking: booking, Upcoming: !booking.Date.Before(today())}
+		if (when == "upcoming" && !entry.Upcoming) || (when == "past" && entry.Upcoming) {
+			continue
+		}
+		history = append(history, entry)
+	}
+	mutex.Unlock()
+
+	// Sort by date, oldest first, then by booking ID within a day
+	sort.Slice(history, func(i, j int) bool {
+		return historyCursor(history[i]).before(historyCursor(history[j]))
+	})
+
+	items, nextCursor := paginate(history, page, historyCursor, false)
+	response := map[string]interface{}{
+		"bookings":   items,
+		"total":      len(history),
+		"limit":      page.Limit,
+		"offset":     page.Offset,
+		"nextCursor": nextCursor,
+	}
+	successResponse(w, http.StatusOK, "Booking history retrieved successfully", response)
+}
+
+// ageOn returns how old someone born on birth is on date, in whole years
+func ageOn(birth, date time.Time) int {
+	age := date.Year() - birth.Year()
+	if date.Month() < birth.Month() || (date.Month() == birth.Month() && date.Day() < birth.Day()) {
+		age--
+	}
+	return age
+}
+
+// checkEligibility rejects a member who does not meet a class's level or age
+// rules on the given date. Staff can waive the level rule with levelOverride.
+// Callers must hold the mutex.
+func checkEligibility(class *Class, memberName string, date time.Time, levelOverride bool) *requestRejection {
+	if class.Level != "" && !levelOverride && levelRanks[memberLevel(memberName)] < levelRanks[class.Level] {
+		return &requestRejection{http.StatusForbidden, "Member's level does not meet the class prerequisite of " + class.Level}
+	}
+
+	if class.MinAge == 0 && class.MaxAge == 0 {
+		return nil
+	}
+	index := memberIndex(memberName)
+	if index < 0 || members[index].DateOfBirth == "" {
+		return &requestRejection{http.StatusForbidden, "Member's date of birth is required for this class"}
+	}
+	birth, err := time.Parse(dateLayout, members[index].DateOfBirth)
+	if err != nil {
+		return &requestRejection{http.StatusForbidden, "Member's date of birth is required for this class"}
+	}
+	age := ageOn(birth, date)
+	if class.MinAge > 0 && age < class.MinAge {
+		return &requestRejection{http.StatusForbidden, fmt.Sprintf("Member must be at least %d years old for this class", class.MinAge)}
+	}
+	if class.MaxAge > 0 && age > class.MaxAge {
+		return &requestRejection{http.StatusForbidden, fmt.Sprintf("Member must be at most %d years old for this class", class.MaxAge)}
+	}
+	return nil
+}
+
+// memberProfile is a member as their profile shows them, with their
+// loyalty points balance
+type memberProfile struct {
+	Member
+	LoyaltyPoints int `json:"loyaltyPoints"`
+}
+
+// Handler for viewing and saving a member's profile
+func memberProfileHandler(w http.ResponseWriter, r *http.Request) {
+	memberName := r.PathValue("name")
+	if memberName == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid member name")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := memberIndex(memberName)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Member not found")
+			return
+		}
+		successResponse(w, http.StatusOK, "Member retrieved successfully", memberProfile{members[index], pointsBalance(memberName)})
+
+	case http.MethodPut:
+		var request struct {
+			Member
+			ReferrerCode string `json:"referrerCode"` // Referral code a new member signs up with
+		}
+		if err := decodeBody(r, &request); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		profile := request.Member
+		profile.Name = memberName
+		// Only the verification link marks an email verified, and only sign-in links an account.
+		// Preferences and cards are saved through their own endpoints, and the tier comes from the CRM.
+		profile.EmailVerified, profile.OIDCSubject, profile.Preferences, profile.Tier = false, "", nil, ""
+		profile.ReferralCode, profile.ReferredBy, profile.CardNumber = "", "", ""
+		if _, ok := levelRanks[profile.Level]; profile.Level != "" && !ok {
+			errorResponse(w, http.StatusBadRequest, "Invalid level, use beginner, intermediate or advanced")
+			return
+		}
+		// Store contact details in a single normalized form so notifications reach the member
+		if profile.Email != "" {
+			email, err := normalizeEmail(profile.Email)
+			if err != nil {
+				errorResponse(w, http.StatusBadRequest, "Invalid email address")
+				return
+			}
+			profile.Email = email
+		}
+		if profile.Phone != "" {
+			phone, err := normalizePhone(profile.Phone)
+			if err != nil {
+				errorResponse(w, http.StatusBadRequest, "Invalid phone number, use international format such as +447700900123")
+				return
+			}
+			profile.Phone = phone
+		}
+		if profile.DateOfBirth != "" {
+			birth, err := time.Parse(dateLayout, profile.DateOfBirth)
+			if err != nil || birth.After(today()) {
+				errorResponse(w, http.StatusBadRequest, "Invalid dateOfBirth, use a past DD-MM-YYYY date")
+				return
+			}
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		// Create the profile, or replace the existing one
+		var before interface{}
+		index := memberIndex(memberName)
+		if index < 0 {
+			// Signups are attributed to the member who referred them
+			if request.ReferrerCode != "" {
+				referrer, rejection := recordReferral(memberName, request.ReferrerCode)
+				if rejection != nil {
+					errorResponse(w, rejection.StatusCode, rejection.Message)
+					return
+				}
+				profile.ReferredBy = referrer
+			}
+			members = append(members, profile)
+		} else {
+			before = members[index]
+			// An unchanged email stays verified
+			profile.EmailVerified = profile.Email != "" && profile.Email == members[index].Email && members[index].EmailVerified
+			profile.OIDCSubject = members[index].OIDCSubject
+			profile.Preferences = members[index].Preferences
+			profile.Tier = members[index].Tier
+			profile.ReferralCode, profile.ReferredBy = members[index].ReferralCode, members[index].ReferredBy
+			profile.CardNumber = members[index].CardNumber
+			members[index] = profile
+		}
+
+		if err := writeDataToJsonFile("members.json", members); err != nil {
+			if index < 0 {
+				members = members[:len(members)-1]
+				if profile.ReferredBy != "" {
+					referrals = referrals[:len(referrals)-1]
+					referralId--
+					writeDataToJsonFile("referrals.json", referrals)
+				}
+			} else {
+				members[index] = before.(Member)
+			}
+			errorResponse(w, http.StatusInternalServerError, "Failed to save member data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "update", "member", 0, before, profile)
+
+		// New or changed addresses get a verification link
+		if profile.Email != "" && !profile.EmailVerified {
+			if err := sendVerificationEmail(profile); err != nil {
+				fmt.Println("Error sending verification email:", err)
+			}
+		}
+
+		successResponse(w, http.StatusOK, "Member saved successfully", profile)
+		logData("Member saved successfully", profile)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMemberBookingsHandler verifies the member booking history endpoint.
+func TestMemberBookingsHandler(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2024, 12, 16, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	bookings = []Booking{
+		{ID: 1, MemberName: "John Doe", Date: testDate("20-12-2024"), ClassName: "Pilates", Status: bookingStatusConfirmed},
+		{ID: 2, MemberName: "John Doe", Date: testDate("10-12-2024"), ClassName: "Yoga", Status: bookingStatusAttended},
+		{ID: 3, MemberName: "Jane Doe", Date: testDate("16-12-2024"), ClassName: "Pilates", Status: bookingStatusConfirmed},
+		{ID: 4, MemberName: "John Doe", Date: testDate("16-12-2024"), ClassName: "Pilates", Status: bookingStatusCancelled},
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		statusCode int
+		wantIDs    []int
+		total      int
+	}{
+		{name: "All Bookings Sorted By Date", query: "", statusCode: http.StatusOK, wantIDs: []int{2, 4, 1}, total: 3},
+		{name: "Upcoming Only", query: "?when=upcoming", statusCode: http.StatusOK, wantIDs: []int{4, 1}, total: 2},
+		{name: "Past Only", query: "?when=past", statusCode: http.StatusOK, wantIDs: []int{2}, total: 1},
+		{name: "Paginated", query: "?limit=1&offset=1", statusCode: http.StatusOK, wantIDs: []int{4}, total: 3},
+		{name: "From Cursor", query: "?limit=1&cursor=" + encodeCursor(pageCursor{Key: "20241210", ID: 2}), statusCode: http.StatusOK, wantIDs: []int{4}, total: 3},
+		{name: "Invalid Limit", query: "?limit=0", statusCode: http.StatusBadRequest},
+		{name: "Invalid Cursor", query: "?cursor=not-a-cursor", statusCode: http.StatusBadRequest},
+		{name: "Invalid When", query: "?when=soon", statusCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/members/John%20Doe/bookings"+tt.query, nil)
+			req.SetPathValue("name", "John Doe")
+			rec := httptest.NewRecorder()
+
+			memberBookingsHandler(rec, req)
+
+			if rec.Code != tt.statusCode {
+				t.Fatalf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+			if tt.statusCode != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Data struct {
+					Bookings []bookingHistoryEntry `json:"bookings"`
+					Total    int                   `json:"total"`
+				} `json:"data"`
+			}
+			json.NewDecoder(rec.Body).Decode(&response)
+
+			if response.Data.Total != tt.total {
+				t.Errorf("expected total %d, got %d", tt.total, response.Data.Total)
+			}
+			if len(response.Data.Bookings) != len(tt.wantIDs) {
+				t.Fatalf("expected %d bookings, got %d", len(tt.wantIDs), len(response.Data.Bookings))
+			}
+			for i, id := range tt.wantIDs {
+				if response.Data.Bookings[i].ID != id {
+					t.Errorf("expected booking %d at position %d, got %d", id, i, response.Data.Bookings[i].ID)
+				}
+			}
+		})
+	}
+}
+
+// TestClassLevelPrerequisite verifies members below a class's level cannot book it without an override.
+func TestClassLevelPrerequisite(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Advanced Pilates", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10, Level: levelIntermediate}}
+	classId = 2
+
+	for name, level := range map[string]string{"Pro": levelAdvanced, "Mid": levelIntermediate, "New": levelBeginner} {
+		req := httptest.NewRequest(http.MethodPut, "/members/"+name, strings.NewReader(`{"level":"`+level+`"}`))
+		req.SetPathValue("name", name)
+		rec := httptest.NewRecorder()
+		memberProfileHandler(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status code %d saving %s, got %d", http.StatusOK, name, rec.Code)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		statusCode int
+	}{
+		{name: "Above The Level", body: `{"memberName":"Pro","className":"Advanced Pilates","date":"15-12-2099"}`, statusCode: http.StatusCreated},
+		{name: "At The Level", body: `{"memberName":"Mid","className":"Advanced Pilates","date":"15-12-2099"}`, statusCode: http.StatusCreated},
+		{name: "Below The Level", body: `{"memberName":"New","className":"Advanced Pilates","date":"15-12-2099"}`, statusCode: http.StatusForbidden},
+		{name: "No Profile Counts As Beginner", body: `{"memberName":"Guest","className":"Advanced Pilates","date":"15-12-2099"}`, statusCode: http.StatusForbidden},
+		{name: "Staff Override", body: `{"memberName":"New","className":"Advanced Pilates","date":"15-12-2099","levelOverride":true}`, statusCode: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+		})
+	}
+
+	// Unknown levels are rejected on profiles and classes alike.
+	req := httptest.NewRequest(http.MethodPut, "/members/New", strings.NewReader(`{"level":"expert"}`))
+	req.SetPathValue("name", "New")
+	rec := httptest.NewRecorder()
+	memberProfileHandler(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+	if rejection := checkClass(Class{ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 5, Level: "expert"}); rejection == nil {
+		t.Errorf("expected an unknown class level to be rejected")
+	}
+}
+
+// TestClassAgeRestriction verifies members outside a class's age range cannot book it.
+func TestClassAgeRestriction(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Kids Gym", StartDate: testDate("01-06-2099"), EndDate: testDate("30-06-2099"), Capacity: 10, MinAge: 6, MaxAge: 12}}
+	classId = 2
+	members = []Member{
+		{Name: "Turns Six", DateOfBirth: "15-06-2093"},
+		{Name: "Thirteen", DateOfBirth: "01-01-2086"},
+		{Name: "No Birthday"},
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		statusCode int
+	}{
+		{name: "Before Birthday", body: `{"memberName":"Turns Six","className":"Kids Gym","date":"14-06-2099"}`, statusCode: http.StatusForbidden},
+		{name: "On Birthday", body: `{"memberName":"Turns Six","className":"Kids Gym","date":"15-06-2099"}`, statusCode: http.StatusCreated},
+		{name: "Too Old", body: `{"memberName":"Thirteen","className":"Kids Gym","date":"15-06-2099"}`, statusCode: http.StatusForbidden},
+		{name: "Date Of Birth Missing", body: `{"memberName":"No Birthday","className":"Kids Gym","date":"15-06-2099"}`, statusCode: http.StatusForbidden},
+		{name: "Override Does Not Waive Age", body: `{"memberName":"Thirteen","className":"Kids Gym","date":"16-06-2099","levelOverride":true}`, statusCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+		})
+	}
+
+	if rejection := checkClass(Class{ClassName: "Teens", StartDate: testDate("01-06-2099"), EndDate: testDate("30-06-2099"), Capacity: 5, MinAge: 18, MaxAge: 13}); rejection == nil {
+		t.Errorf("expected an inverted age range to be rejected")
+	}
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// XML is offered next to JSON for systems that cannot speak JSON. Documents
+// use the JSON field names as element names; arrays hold one <item> element
+// per entry, and map keys that are not valid element names become
+// <entry key="..."> elements.
+const (
+	xmlRoot  = "response" // Element wrapping every XML response
+	xmlItem  = "item"     // Element of each array entry
+	xmlEntry = "entry"    // Element of a map key that is not a valid element name
+)
+
+// Response formats a client can pick with its Accept header
+const (
+	formatJSON     = "application/json"
+	formatXML      = "application/xml"
+	formatJSONAPI  = "application/vnd.api+json"
+	formatProtobuf = "application/x-protobuf"
+)
+
+// isXMLContent reports whether a request body is XML
+func isXMLContent(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "application/xml" || mediaType == "text/xml"
+}
+
+// preferredFormat returns the response format a request's Accept header
+// prefers, JSON unless another format is preferred to it
+func preferredFormat(r *http.Request) string {
+	qualities := map[string]float64{}
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil {
+			quality = q
+		}
+		format := mediaType
+		switch mediaType {
+		case "text/xml":
+			format = formatXML
+		case "*/*", "application/*":
+			format = formatJSON
+		case formatJSON, formatXML, formatJSONAPI, formatProtobuf:
+		default:
+			continue
+		}
+		if quality > qualities[format] {
+			qualities[format] = quality
+		}
+	}
+	preferred := formatJSON
+	for _, format := range []string{formatXML, formatJSONAPI, formatProtobuf} {
+		if qualities[format] > qualities[preferred] {
+			preferred = format
+		}
+	}
+	return preferred
+}
+
+// decodeBody reads a JSON, JSON:API, XML or protobuf request body into v. XML
+// is turned into the JSON it stands for first, and a JSON:API document gives
+// the attributes of its resource, so all go through the same decoding and the
+// handlers' validation. An empty body returns io.EOF either way.
+func decodeBody(r *http.Request, v interface{}) error {
+	if isJSONAPIContent(r) {
+		return decodeJSONAPIBody(r, v)
+	}
+	if isProtobufContent(r) {
+		return decodeProtobufBody(r, v)
+	}
+	if !isXMLContent(r) {
+		return json.NewDecoder(r.Body).Decode(v)
+	}
+	root, err := parseXMLNode(xml.NewDecoder(r.Body))
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(xmlNodeValue(root, reflect.TypeOf(v).Elem()))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// xmlNode is an element of an XML request body
+type xmlNode struct {
+	name     string
+	key      string // key attribute of a map entry
+	text     string
+	children []*xmlNode
+}
+
+// parseXMLNode reads the next element and everything in it
+func parseXMLNode(decoder *xml.Decoder) (*xmlNode, error) {
+	var stack []*xmlNode
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: token.Name.Local}
+			for _, attr := range token.Attr {
+				if attr.Name.Local == "key" {
+					node.key = attr.Value
+				}
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			}
+			stack = append(stack, node)
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(token)
+			}
+		case xml.EndElement:
+			node := stack[len(stack)-1]
+			if stack = stack[:len(stack)-1]; len(stack) == 0 {
+				return node, nil
+			}
+		}
+	}
+}
+
+// xmlFieldTypes returns the types of a struct's fields by their JSON names,
+// including the fields of embedded structs
+func xmlFieldTypes(structType reflect.Type, fields map[string]reflect.Type) map[string]reflect.Type {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			xmlFieldTypes(field.Type, fields)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// xmlNodeValue returns the JSON value an element stands for, read as the
+// given Go type so numbers and booleans are told apart from text
+func xmlNodeValue(node *xmlNode, target reflect.Type) interface{} {
+	for target.Kind() == reflect.Pointer {
+		target = target.Elem()
+	}
+	text := strings.TrimSpace(node.text)
+	// Types that decode themselves, such as times, are given the text
+	if reflect.PointerTo(target).Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()) ||
+		reflect.PointerTo(target).Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()) {
+		return text
+	}
+
+	switch target.Kind() {
+	case reflect.Struct:
+		fields := xmlFieldTypes(target, map[string]reflect.Type{})
+		object := map[string]interface{}{}
+		for _, child := range node.children {
+			if fieldType, ok := fields[child.name]; ok {
+				object[child.name] = xmlNodeValue(child, fieldType)
+			}
+		}
+		return object
+	case reflect.Map:
+		object := map[string]interface{}{}
+		for _, child := range node.children {
+			key := child.name
+			if child.key != "" {
+				key = child.key
+			}
+			object[key] = xmlNodeValue(child, target.Elem())
+		}
+		return object
+	case reflect.Slice, reflect.Array:
+		if target.Elem().Kind() == reflect.Uint8 {
+			return text
+		}
+		items := []interface{}{}
+		for _, child := range node.children {
+			items = append(items, xmlNodeValue(child, target.Elem()))
+		}
+		return items
+	case reflect.Bool:
+		if value, err := strconv.ParseBool(text); err == nil {
+			return value
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(text, 64); err == nil {
+			return json.Number(text)
+		}
+	case reflect.Interface:
+		if len(node.children) > 0 {
+			object := map[string]interface{}{}
+			for _, child := range node.children {
+				object[child.name] = xmlNodeValue(child, target)
+			}
+			return object
+		}
+	}
+	// Anything else is left as text, which JSON decoding rejects where it
+	// does not belong
+	return text
+}
+
+// bufferedResponseWriter holds back a response so it can be sent in another
+// format
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	body    bytes.Buffer
+	data    interface{} // Data handed to successResponse, before it became JSON
+	hasData bool
+}
+
+// WriteHeader records the status code for later
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+// Write buffers the body
+func (b *bufferedResponseWriter) Write(data []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(data)
+}
+
+// negotiateContent sends the JSON responses of clients that prefer XML,
+// JSON:API or protobuf in that format. Responses with a content type of
+// their own, such as CSV or images, are sent as they are, and those the
+// format cannot carry are sent as JSON.
+func negotiateContent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format := preferredFormat(r)
+		if format == formatJSON {
+			next.ServeHTTP(w, r)
+			return
+		}
+		recorder := &bufferedResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+
+		body := recorder.body.Bytes()
+		if w.Header().Get("Content-Type") == "" && len(body) > 0 {
+			var converted bytes.Buffer
+			var err error
+			switch format {
+			case formatXML:
+				converted.WriteString(xml.Header)
+				err = jsonToXML(json.NewDecoder(bytes.NewReader(body)), xml.NewEncoder(&converted), xmlRoot)
+			case formatJSONAPI:
+				err = writeJSONAPI(&converted, recorder)
+			case formatProtobuf:
+				err = writeProtobuf(&converted, recorder)
+			}
+			if err != nil {
+				w.Header().Set("Content-Type", formatJSON)
+			} else {
+				contentType := format
+				// JSON:API allows no parameters on its media type
+				if format == formatXML {
+					contentType += "; charset=utf-8"
+				}
+				w.Header().Set("Content-Type", contentType)
+				body = converted.Bytes()
+			}
+		}
+		w.WriteHeader(recorder.status)
+		w.Write(body)
+	})
+}
+
+// xmlName reports whether a JSON key can be used as an element name
+func xmlName(key string) bool {
+	if key == "" || strings.HasPrefix(strings.ToLower(key), "xml") {
+		return false
+	}
+	for i, c := range key {
+		letter := c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+		if !letter && (i == 0 || c != '-' && c != '.' && (c < '0' || c > '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonToXML copies the next JSON value to the encoder as an element with the
+// given name, keeping the order of object keys
+func jsonToXML(decoder *json.Decoder, encoder *xml.Encoder, name string) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if !xmlName(name) {
+		start = xml.StartElement{Name: xml.Name{Local: xmlEntry}, Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}}}
+	}
+	decoder.UseNumber()
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	switch token := token.(type) {
+	case json.Delim:
+		for decoder.More() {
+			childName := xmlItem
+			if token == '{' {
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				childName = key.(string)
+			}
+			if err := jsonToXML(decoder, encoder, childName); err != nil {
+				return err
+			}
+		}
+		// The closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return err
+		}
+	case nil:
+	default:
+		if err := encoder.EncodeToken(xml.CharData(strings.TrimSpace(jsonText(token)))); err != nil {
+			return err
+		}
+	}
+	if err := encoder.EncodeToken(start.End()); err != nil {
+		return err
+	}
+	return encoder.Flush()
+}
+
+// jsonText returns the text of a JSON string, number or boolean
+func jsonText(token json.Token) string {
+	switch token := token.(type) {
+	case string:
+		return token
+	case json.Number:
+		return token.String()
+	case bool:
+		return strconv.FormatBool(token)
+	}
+	return ""
+}
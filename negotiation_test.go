@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestDecodeXMLBody verifies XML bodies decode into the same values as JSON, typed by the target's fields.
+func TestDecodeXMLBody(t *testing.T) {
+	body := `<class>
+		<className>Yoga</className>
+		<startDate>01-12-2099</startDate>
+		<endDate>31-12-2099</endDate>
+		<capacity>12</capacity>
+		<tags><item>calm</item><item>stretch</item></tags>
+		<autoScale><waitlistThreshold>3</waitlistThreshold><requireApproval>true</requireApproval></autoScale>
+		<unknown>ignored</unknown>
+	</class>`
+	req := httptest.NewRequest(http.MethodPost, "/classes", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	var class Class
+	if err := decodeBody(req, &class); err != nil {
+		t.Fatalf("expected the body to decode, got %v", err)
+	}
+	if class.ClassName != "Yoga" || class.Capacity != 12 || len(class.Tags) != 2 || class.Tags[1] != "stretch" ||
+		class.AutoScale == nil || class.AutoScale.WaitlistThreshold != 3 || !class.AutoScale.RequireApproval {
+		t.Errorf("expected the class to be read, got %+v", class)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		err  bool
+	}{
+		{name: "Wrong Type", body: `<class><capacity>many</capacity></class>`, err: true},
+		{name: "Malformed", body: `<class><capacity>`, err: true},
+		{name: "Map Keys", body: `<class><rentalInventory><entry key="yoga mat">4</entry></rentalInventory></class>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/classes", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "text/xml")
+			var class Class
+			if err := decodeBody(req, &class); (err != nil) != tt.err {
+				t.Errorf("expected error %v, got %v", tt.err, err)
+			}
+		})
+	}
+
+	empty := httptest.NewRequest(http.MethodPost, "/classes", nil)
+	empty.Header.Set("Content-Type", "application/xml")
+	if err := decodeBody(empty, &class); err != io.EOF {
+		t.Errorf("expected an empty body to return io.EOF, got %v", err)
+	}
+}
+
+// TestPreferredFormat verifies the Accept header picks another format only when preferred over JSON.
+func TestPreferredFormat(t *testing.T) {
+	tests := []struct {
+		accept string
+		format string
+	}{
+		{accept: "", format: formatJSON},
+		{accept: "application/json", format: formatJSON},
+		{accept: "application/xml", format: formatXML},
+		{accept: "text/xml, */*;q=0.1", format: formatXML},
+		{accept: "application/json, application/xml", format: formatJSON},
+		{accept: "application/json;q=0.5, application/xml", format: formatXML},
+		{accept: "application/xml;q=0", format: formatJSON},
+		{accept: "application/vnd.api+json", format: formatJSONAPI},
+		{accept: "application/xml;q=0.5, application/vnd.api+json", format: formatJSONAPI},
+		{accept: "application/x-protobuf", format: formatProtobuf},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/classes", nil)
+		req.Header.Set("Accept", tt.accept)
+		if got := preferredFormat(req); got != tt.format {
+			t.Errorf("Accept %q: expected %s, got %s", tt.accept, tt.format, got)
+		}
+	}
+}
+
+// TestXMLBooking verifies a booking can be sent and answered in XML, and rejected the same way as JSON.
+func TestXMLBooking(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Pilates", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10}}
+	classId = 2
+
+	book := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/xml")
+		req.Header.Set("Accept", "application/xml")
+		rec := httptest.NewRecorder()
+		negotiateContent(http.HandlerFunc(bookingHandler)).ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := book(`<booking><memberName>Rahul R P</memberName><date>16-12-2099</date><className>Pilates</className></booking>`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the booking to be made, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/xml") {
+		t.Errorf("expected an XML response, got %q", rec.Header().Get("Content-Type"))
+	}
+	var response struct {
+		XMLName xml.Name `xml:"response"`
+		Message string   `xml:"message"`
+		Data    struct {
+			AvailableSlots int `xml:"availableSlots"`
+			Booking        struct {
+				ID         int    `xml:"id"`
+				MemberName string `xml:"memberName"`
+			} `xml:"booking"`
+		} `xml:"data"`
+	}
+	if err := xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("expected a valid XML document, got %v: %s", err, rec.Body.String())
+	}
+	if response.Message != "Booking successful" || response.Data.AvailableSlots != 9 || response.Data.Booking.MemberName != "Rahul R P" {
+		t.Errorf("expected the booking in the response, got %+v", response)
+	}
+
+	rec = book(`<booking><memberName>Rahul R P</memberName><date>16-12-2099</date></booking>`)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "<message>") {
+		t.Errorf("expected the missing class to be refused in XML, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Responses that are not the JSON envelope pass through untouched
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/xml")
+	rec = httptest.NewRecorder()
+	negotiateContent(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte("a,b\n"))
+	})).ServeHTTP(rec, req)
+	if rec.Body.String() != "a,b\n" || rec.Header().Get("Content-Type") != "text/csv" {
+		t.Errorf("expected the CSV to pass through, got %q", rec.Body.String())
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Notification events members can receive
+const (
+	notifyBookingConfirmed = "bookingConfirmed"
+	notifyBookingCancelled = "bookingCancelled"
+	notifyWaitlistPromoted = "waitlistPromoted"
+	notifySessionChanged   = "sessionChanged"
+	notifyAccount          = "account" // Verification and password resets, which cannot be turned off
+)
+
+// Notification channels
+const (
+	channelEmail = "email"
+	channelSMS   = "sms"
+	channelPush  = "push"
+)
+
+// notificationEvents lists the events members can turn off per channel
+var notificationEvents = map[string]bool{
+	notifyBookingConfirmed: true,
+	notifyBookingCancelled: true,
+	notifyWaitlistPromoted: true,
+	notifySessionChanged:   true,
+	notifyBookingRefunded:  true,
+	notifyCreditsExpiring:  true,
+	notifyReferralRewarded: true,
+	notifyWeeklyDigest:     true,
+	notifyAnnouncement:     true,
+	notifyExtraSession:     true,
+}
+
+// notificationChannels lists the channels notifications are sent on
+var notificationChannels = map[string]bool{channelEmail: true, channelSMS: true, channelPush: true}
+
+// quietHoursLayout is the HH:MM format of quiet hours
+const quietHoursLayout = "15:04"
+
+// NotificationPreferences controls which notifications a member receives.
+// Events and channels missing from Events are on.
+type NotificationPreferences struct {
+	Events     map[string]map[string]bool `json:"events,omitempty"` // Event, then channel, to on or off
+	QuietHours *QuietHours                `json:"quietHours,omitempty"`
+}
+
+// QuietHours is a daily window, in server time, with no notifications. It may
+// run past midnight, e.g. 22:00 to 07:00.
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// sendSMS delivers a text message to a member. Without an SMS provider
+// configured the message is written to the API log.
+var sendSMS = func(to, body string) error {
+	logData("SMS sent", map[string]string{"phone": to, "body": body})
+	return nil
+}
+
+// minuteOfDay returns the minutes since midnight of an HH:MM time
+func minuteOfDay(value string) (int, error) {
+	parsed, err := time.Parse(quietHoursLayout, value)
+	if err != nil {
+		return 0, err
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+// inQuietHours reports whether a time falls within the quiet hours
+func inQuietHours(quiet *QuietHours, at time.Time) bool {
+	if quiet == nil {
+		return false
+	}
+	start, startErr := minuteOfDay(quiet.Start)
+	end, endErr := minuteOfDay(quiet.End)
+	if startErr != nil || endErr != nil || start == end {
+		return false
+	}
+	minute := at.Hour()*60 + at.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// notificationAllowed reports whether a member wants an event on a channel at
+// a given time. Account notifications are always sent. Every sender must ask
+// before dispatching.
+func notificationAllowed(member Member, event, channel string, at time.Time) bool {
+	if event == notifyAccount {
+		return true
+	}
+	preferences := member.Preferences
+	if preferences == nil {
+		return true
+	}
+	if enabled, ok := preferences.Events[event][channel]; ok && !enabled {
+		return false
+	}
+	return !inQuietHours(preferences.QuietHours, at)
+}
+
+// notifyMember sends a member a notification on every channel they can be
+// reached on and want it on. Data is passed to the app with push
+// notifications. Callers must hold the mutex.
+func notifyMember(memberName, event, subject, body string, data map[string]string) {
+	index := memberIndex(memberName)
+	if index < 0 {
+		return
+	}
+	member := members[index]
+
+	if member.Email != "" && notificationAllowed(member, event, channelEmail, now()) {
+		if err := sendEmail(member.Email, subject, body); err != nil {
+			fmt.Println("Error sending email:", err)
+		}
+	}
+	if member.Phone != "" && notificationAllowed(member, event, channelSMS, now()) {
+		if err := sendSMS(member.Phone, body); err != nil {
+			fmt.Println("Error sending SMS:", err)
+		}
+	}
+	if notificationAllowed(member, event, channelPush, now()) {
+		pushToMember(member.Name, PushNotification{Title: subject, Body: body, Data: data})
+	}
+}
+
+// notifyBooking tells a member about a change to one of their bookings.
+// Callers must hold the mutex.
+func notifyBooking(event string, booking Booking) {
+	data := map[string]string{"event": event, "bookingId": strconv.Itoa(booking.ID)}
+	switch event {
+	case notifyBookingConfirmed:
+		body := fmt.Sprintf("You are booked into %s on %s. Your confirmation code is %s.", bookingTitle(booking), booking.Date, booking.Code)
+		if booking.Tax != nil {
+			body += fmt.Sprintf(" The price is %s plus %s %s, %s in total.", formatAmount(booking.Price, booking.Currency), formatAmount(booking.Tax.Amount, booking.Currency), booking.Tax.Name, formatAmount(booking.Total, booking.Currency))
+		} else if booking.Price > 0 {
+			body += " The price is " + formatAmount(booking.Price, booking.Currency) + "."
+		}
+		notifyMember(booking.MemberName, event, "Booking confirmed", body, data)
+	case notifyBookingCancelled:
+		notifyMember(booking.MemberName, event, "Booking cancelled", fmt.Sprintf("Your booking for %s on %s has been cancelled.", bookingTitle(booking), booking.Date), data)
+	case notifyWaitlistPromoted:
+		notifyMember(booking.MemberName, event, "You're off the waitlist", fmt.Sprintf("A place opened up and you are now booked into %s on %s. Your confirmation code is %s.", bookingTitle(booking), booking.Date, booking.Code), data)
+	}
+}
+
+// checkPreferences rejects unknown events, unknown channels and malformed quiet hours
+func checkPreferences(preferences NotificationPreferences) error {
+	for event, channels := range preferences.Events {
+		if !notificationEvents[event] {
+			return fmt.Errorf("unknown event %s", event)
+		}
+		for channel := range channels {
+			if !notificationChannels[channel] {
+				return fmt.Errorf("unknown channel %s", channel)
+			}
+		}
+	}
+	if quiet := preferences.QuietHours; quiet != nil {
+		if _, err := minuteOfDay(quiet.Start); err != nil {
+			return fmt.Errorf("invalid quiet hours start, use HH:MM")
+		}
+		if _, err := minuteOfDay(quiet.End); err != nil {
+			return fmt.Errorf("invalid quiet hours end, use HH:MM")
+		}
+	}
+	return nil
+}
+
+// Handler for viewing and saving a member's notification preferences
+func memberPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	memberName := r.PathValue("name")
+	if memberName == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid member name")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		preferences := NotificationPreferences{}
+		if index := memberIndex(memberName); index >= 0 && members[index].Preferences != nil {
+			preferences = *members[index].Preferences
+		}
+		successResponse(w, http.StatusOK, "Preferences retrieved successfully", preferences)
+
+	case http.MethodPut:
+		var preferences NotificationPreferences
+		if err := decodeBody(r, &preferences); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if err := checkPreferences(preferences); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid preferences: "+err.Error())
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := memberIndex(memberName)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Member not found")
+			return
+		}
+		before := members[index]
+		members[index].Preferences = &preferences
+		if err := writeDataToJsonFile("members.json", members); err != nil {
+			members[index] = before
+			errorResponse(w, http.StatusInternalServerError, "Failed to save member data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "update", "member", 0, before, members[index])
+
+		successResponse(w, http.StatusOK, "Preferences saved successfully", preferences)
+		logData("Preferences saved successfully", preferences)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNotificationPreferences verifies senders honour per-event toggles and quiet hours.
+func TestNotificationPreferences(t *testing.T) {
+	setupTestEnvironment()
+	members = []Member{{Name: "Ann", Email: "ann@example.com", Phone: "+447700900123"}}
+
+	save := func(body string) int {
+		req := httptest.NewRequest(http.MethodPut, "/members/Ann/preferences", strings.NewReader(body))
+		req.SetPathValue("name", "Ann")
+		rec := httptest.NewRecorder()
+		memberPreferencesHandler(rec, req)
+		return rec.Code
+	}
+
+	saves := []struct {
+		name       string
+		body       string
+		statusCode int
+	}{
+		{name: "Unknown Event", body: `{"events":{"birthday":{"email":false}}}`, statusCode: http.StatusBadRequest},
+		{name: "Unknown Channel", body: `{"events":{"bookingConfirmed":{"fax":false}}}`, statusCode: http.StatusBadRequest},
+		{name: "Bad Quiet Hours", body: `{"quietHours":{"start":"10pm","end":"07:00"}}`, statusCode: http.StatusBadRequest},
+		{name: "Valid", body: `{"events":{"bookingConfirmed":{"sms":false}},"quietHours":{"start":"22:00","end":"07:00"}}`, statusCode: http.StatusOK},
+	}
+	for _, tt := range saves {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := save(tt.body); code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, code)
+			}
+		})
+	}
+
+	// Saving the profile keeps the preferences
+	req := httptest.NewRequest(http.MethodPut, "/members/Ann", strings.NewReader(`{"email":"ann@example.com","phone":"+447700900123"}`))
+	req.SetPathValue("name", "Ann")
+	memberProfileHandler(httptest.NewRecorder(), req)
+	member := members[0]
+	if member.Preferences == nil {
+		t.Fatalf("expected the preferences to survive a profile update")
+	}
+
+	day := time.Date(2099, 12, 1, 12, 0, 0, 0, time.UTC)
+	night := time.Date(2099, 12, 1, 23, 30, 0, 0, time.UTC)
+	early := time.Date(2099, 12, 2, 6, 59, 0, 0, time.UTC)
+	checks := []struct {
+		name    string
+		event   string
+		channel string
+		at      time.Time
+		allowed bool
+	}{
+		{name: "Email By Day", event: notifyBookingConfirmed, channel: channelEmail, at: day, allowed: true},
+		{name: "SMS Turned Off", event: notifyBookingConfirmed, channel: channelSMS, at: day, allowed: false},
+		{name: "SMS For Other Events", event: notifyBookingCancelled, channel: channelSMS, at: day, allowed: true},
+		{name: "Quiet Before Midnight", event: notifyBookingCancelled, channel: channelEmail, at: night, allowed: false},
+		{name: "Quiet After Midnight", event: notifyWaitlistPromoted, channel: channelPush, at: early, allowed: false},
+		{name: "Account Always Sent", event: notifyAccount, channel: channelEmail, at: night, allowed: true},
+	}
+	for _, tt := range checks {
+		t.Run(tt.name, func(t *testing.T) {
+			if allowed := notificationAllowed(member, tt.event, tt.channel, tt.at); allowed != tt.allowed {
+				t.Errorf("expected allowed %v, got %v", tt.allowed, allowed)
+			}
+		})
+	}
+}
+
+// TestNotifyBooking verifies booking notifications go out on the channels the member allows.
+func TestNotifyBooking(t *testing.T) {
+	setupTestEnvironment()
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2099, 12, 1, 12, 0, 0, 0, time.UTC) }
+	members = []Member{{Name: "Ann", Email: "ann@example.com", Phone: "+447700900123", Preferences: &NotificationPreferences{
+		Events: map[string]map[string]bool{notifyBookingConfirmed: {channelSMS: false}},
+	}}}
+
+	var emails, texts int
+	defer func(send func(to, subject, body string) error) { sendEmail = send }(sendEmail)
+	defer func(send func(to, body string) error) { sendSMS = send }(sendSMS)
+	sendEmail = func(to, subject, body string) error { emails++; return nil }
+	sendSMS = func(to, body string) error { texts++; return nil }
+
+	notifyBooking(notifyBookingConfirmed, Booking{MemberName: "Ann", ClassName: "Yoga", Date: testDate("15-12-2099")})
+	notifyBooking(notifyBookingCancelled, Booking{MemberName: "Ann", ClassName: "Yoga", Date: testDate("15-12-2099")})
+	if emails != 2 || texts != 1 {
+		t.Errorf("expected 2 emails and 1 text, got %d and %d", emails, texts)
+	}
+}
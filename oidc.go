@@ -0,0 +1,342 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenPurposeOIDCState marks the state parameter of a sign-in redirect
+const tokenPurposeOIDCState = "oidc-state"
+
+// oidcStateCookie binds a sign-in redirect to the browser that started it
+const oidcStateCookie = "oidc_state"
+
+// oidcProvider holds the endpoints published in an issuer's discovery document
+type oidcProvider struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// idTokenClaims are the ID token claims used to find the member
+type idTokenClaims struct {
+	Issuer        string          `json:"iss"`
+	Audience      json.RawMessage `json:"aud"`
+	Subject       string          `json:"sub"`
+	ExpiresAt     int64           `json:"exp"`
+	Nonce         string          `json:"nonce"`
+	Email         string          `json:"email"`
+	EmailVerified bool            `json:"email_verified"`
+	Name          string          `json:"name"`
+}
+
+var (
+	oidcClient    = &http.Client{Timeout: 10 * time.Second} // Client used to reach the identity provider
+	oidcDiscovery *oidcProvider                             // Cached discovery document
+	oidcMutex     sync.Mutex                                // Guards oidcDiscovery
+)
+
+// fetchJSON decodes the JSON document at a URL
+func fetchJSON(target string, destination interface{}) error {
+	resp, err := oidcClient.Get(target)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", target, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(destination)
+}
+
+// discoverOIDC returns the configured issuer's endpoints, fetching them once
+func discoverOIDC() (*oidcProvider, error) {
+	oidcMutex.Lock()
+	defer oidcMutex.Unlock()
+	if oidcDiscovery != nil {
+		return oidcDiscovery, nil
+	}
+	var provider oidcProvider
+	if err := fetchJSON(strings.TrimSuffix(config.OIDCIssuer, "/")+"/.well-known/openid-configuration", &provider); err != nil {
+		return nil, err
+	}
+	oidcDiscovery = &provider
+	return oidcDiscovery, nil
+}
+
+// oidcRedirectURI is where the identity provider sends members back to
+func oidcRedirectURI() string {
+	return config.PublicBaseURL + "/auth/google/callback"
+}
+
+// verifyIDToken checks an RS256 ID token's signature against the issuer's keys
+// and its issuer, audience, expiry and nonce
+func verifyIDToken(provider *oidcProvider, idToken, nonce string) (idTokenClaims, error) {
+	var claims idTokenClaims
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return claims, errors.New("malformed id token")
+	}
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || json.Unmarshal(headerJSON, &header) != nil || header.Algorithm != "RS256" {
+		return claims, errors.New("unsupported id token")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, errors.New("malformed id token")
+	}
+
+	var keySet struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			KeyID   string `json:"kid"`
+			N       string `json:"n"`
+			E       string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := fetchJSON(provider.JWKSURI, &keySet); err != nil {
+		return claims, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	verified := false
+	for _, key := range keySet.Keys {
+		if key.KeyType != "RSA" || key.KeyID != header.KeyID {
+			continue
+		}
+		n, nErr := base64.RawURLEncoding.DecodeString(key.N)
+		e, eErr := base64.RawURLEncoding.DecodeString(key.E)
+		if nErr != nil || eErr != nil {
+			continue
+		}
+		publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return claims, errors.New("invalid id token signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(payload, &claims) != nil {
+		return idTokenClaims{}, errors.New("malformed id token")
+	}
+	// Google issues tokens with and without the scheme
+	issuer := strings.TrimSuffix(config.OIDCIssuer, "/")
+	if claims.Issuer != issuer && "https://"+claims.Issuer != issuer {
+		return idTokenClaims{}, errors.New("unexpected issuer")
+	}
+	var audiences []string
+	if json.Unmarshal(claims.Audience, &audiences) != nil {
+		var audience string
+		json.Unmarshal(claims.Audience, &audience)
+		audiences = []string{audience}
+	}
+	if !containsFold(audiences, config.OIDCClientID) {
+		return idTokenClaims{}, errors.New("unexpected audience")
+	}
+	if now().Unix() >= claims.ExpiresAt {
+		return idTokenClaims{}, errors.New("id token expired")
+	}
+	if claims.Nonce != nonce {
+		return idTokenClaims{}, errors.New("unexpected nonce")
+	}
+	return claims, nil
+}
+
+// exchangeCode trades an authorization code for the member's ID token
+func exchangeCode(provider *oidcProvider, code string) (string, error) {
+	resp, err := oidcClient.PostForm(provider.TokenEndpoint, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {oidcRedirectURI()},
+		"client_id":     {config.OIDCClientID},
+		"client_secret": {config.OIDCClientSecret},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&tokens) != nil || tokens.IDToken == "" {
+		return "", fmt.Errorf("token endpoint returned %s", resp.Status)
+	}
+	return tokens.IDToken, nil
+}
+
+// linkOIDCMember returns the member an identity signs in as, linking it to a
+// profile with the same verified email or creating a profile.
+// Callers must hold the mutex.
+func linkOIDCMember(claims idTokenClaims) (Member, *requestRejection) {
+	for _, member := range members {
+		if member.OIDCSubject == claims.Subject {
+			return member, nil
+		}
+	}
+
+	email, err := normalizeEmail(claims.Email)
+	if err != nil || !claims.EmailVerified {
+		return Member{}, &requestRejection{http.StatusForbidden, "Google account has no verified email"}
+	}
+
+	var before interface{}
+	index := -1
+	for i, member := range members {
+		if member.Email == email {
+			index = i
+			break
+		}
+	}
+	if index >= 0 {
+		// Only a verified address proves the profile belongs to the same person
+		if !members[index].EmailVerified {
+			return Member{}, &requestRejection{http.StatusConflict, "Verify the member's email before signing in with Google"}
+		}
+		before = members[index]
+		members[index].OIDCSubject = claims.Subject
+	} else {
+		name := strings.TrimSpace(claims.Name)
+		if name == "" || memberIndex(name) >= 0 {
+			name = email
+		}
+		if memberIndex(name) >= 0 {
+			return Member{}, &requestRejection{http.StatusConflict, "A member named " + name + " already exists"}
+		}
+		members = append(members, Member{Name: name, Email: email, EmailVerified: true, OIDCSubject: claims.Subject})
+		index = len(members) - 1
+	}
+
+	if err := writeDataToJsonFile("members.json", members); err != nil {
+		if before != nil {
+			members[index] = before.(Member)
+		} else {
+			members = members[:len(members)-1]
+		}
+		return Member{}, &requestRejection{http.StatusInternalServerError, "Failed to save member data"}
+	}
+	recordAudit(members[index].Name, "link", "member", 0, before, members[index])
+	return members[index], nil
+}
+
+// Handler starting "Sign in with Google" by redirecting to the provider
+func oidcLoginHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+	if config.OIDCClientID == "" {
+		errorResponse(w, http.StatusNotFound, "Google sign-in is not configured")
+		return
+	}
+
+	provider, err := discoverOIDC()
+	if err != nil {
+		errorResponse(w, http.StatusBadGateway, "Failed to reach the identity provider")
+		return
+	}
+
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	state := signToken(tokenPurposeOIDCState, "", map[string]string{"nonce": hex.EncodeToString(nonce)}, 10*time.Minute)
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Value: state, Path: "/auth/google", MaxAge: 600, HttpOnly: true, Secure: r.TLS != nil, SameSite: http.SameSiteLaxMode})
+
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {config.OIDCClientID},
+		"redirect_uri":  {oidcRedirectURI()},
+		"scope":         {"openid email profile"},
+		"state":         {state},
+		"nonce":         {hex.EncodeToString(nonce)},
+	}
+	http.Redirect(w, r, provider.AuthorizationEndpoint+"?"+query.Encode(), http.StatusFound)
+}
+
+// Handler completing "Sign in with Google" and starting a member session
+func oidcCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+	if config.OIDCClientID == "" {
+		errorResponse(w, http.StatusNotFound, "Google sign-in is not configured")
+		return
+	}
+
+	// The state must be ours and come back to the browser it was issued to
+	state := r.URL.Query().Get("state")
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || cookie.Value != state {
+		errorResponse(w, http.StatusBadRequest, "Invalid sign-in state")
+		return
+	}
+	stateClaims, err := verifyToken(state, tokenPurposeOIDCState)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid sign-in state")
+		return
+	}
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		errorResponse(w, http.StatusBadRequest, "Sign-in was not completed")
+		return
+	}
+
+	provider, err := discoverOIDC()
+	if err != nil {
+		errorResponse(w, http.StatusBadGateway, "Failed to reach the identity provider")
+		return
+	}
+	idToken, err := exchangeCode(provider, code)
+	if err != nil {
+		errorResponse(w, http.StatusBadGateway, "Failed to exchange the authorization code")
+		return
+	}
+	claims, err := verifyIDToken(provider, idToken, stateClaims.Data["nonce"])
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "Invalid identity token")
+		return
+	}
+
+	mutex.Lock()
+	member, rejection := linkOIDCMember(claims)
+	mutex.Unlock()
+	if rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+
+	token, session, err := startSession(member.Name)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to save session data")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/auth/google", MaxAge: -1})
+
+	successResponse(w, http.StatusOK, "Signed in successfully", map[string]interface{}{
+		"token":      token,
+		"memberName": session.MemberName,
+		"expiresAt":  session.ExpiresAt,
+	})
+	logData("Signed in with Google", map[string]string{"memberName": session.MemberName})
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// fakeIdentityProvider serves discovery, keys and a token endpoint issuing the given claims
+func fakeIdentityProvider(key *rsa.PrivateKey, claims *map[string]interface{}) *httptest.Server {
+	mux := http.NewServeMux()
+	var server *httptest.Server
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(oidcProvider{
+			AuthorizationEndpoint: server.URL + "/authorize",
+			TokenEndpoint:         server.URL + "/token",
+			JWKSURI:               server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test-key",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "good-code" || r.FormValue("client_secret") != "secret" {
+			http.Error(w, "invalid_grant", http.StatusBadRequest)
+			return
+		}
+		header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test-key"})
+		payload, _ := json.Marshal(*claims)
+		signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+		digest := sha256.Sum256([]byte(signed))
+		signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		json.NewEncoder(w).Encode(map[string]string{"id_token": signed + "." + base64.RawURLEncoding.EncodeToString(signature)})
+	})
+	server = httptest.NewServer(mux)
+	return server
+}
+
+// TestGoogleSignIn verifies the code flow signs members in, creating or linking profiles.
+func TestGoogleSignIn(t *testing.T) {
+	setupTestEnvironment()
+	defer func() { oidcDiscovery = nil }()
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	claims := map[string]interface{}{}
+	provider := fakeIdentityProvider(key, &claims)
+	defer provider.Close()
+
+	oidcDiscovery = nil
+	config.OIDCIssuer, config.OIDCClientID, config.OIDCClientSecret = provider.URL, "client-id", "secret"
+	members = []Member{
+		{Name: "Ann", Email: "ann@example.com", EmailVerified: true},
+		{Name: "Ben", Email: "ben@example.com"},
+	}
+
+	// Start the flow and follow the redirect's state and nonce back
+	signIn := func(code string, tamperState bool) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		oidcLoginHandler(rec, httptest.NewRequest(http.MethodGet, "/auth/google", nil))
+		if rec.Code != http.StatusFound {
+			t.Fatalf("expected a redirect to the provider, got %d", rec.Code)
+		}
+		redirect, _ := url.Parse(rec.Header().Get("Location"))
+		state := redirect.Query().Get("state")
+		claims["nonce"] = redirect.Query().Get("nonce")
+
+		callbackState := state
+		if tamperState {
+			callbackState = signToken(tokenPurposeOIDCState, "", map[string]string{"nonce": "x"}, time.Minute)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/auth/google/callback?code="+code+"&state="+url.QueryEscape(callbackState), nil)
+		req.AddCookie(rec.Result().Cookies()[0])
+		rec = httptest.NewRecorder()
+		oidcCallbackHandler(rec, req)
+		return rec
+	}
+	identity := func(subject, email, name string, verified bool) {
+		claims["iss"], claims["aud"], claims["exp"] = provider.URL, "client-id", time.Now().Add(time.Hour).Unix()
+		claims["sub"], claims["email"], claims["name"], claims["email_verified"] = subject, email, name, verified
+	}
+
+	tests := []struct {
+		name        string
+		subject     string
+		email       string
+		displayName string
+		verified    bool
+		code        string
+		tamper      bool
+		statusCode  int
+		memberName  string
+	}{
+		{name: "Links Verified Profile", subject: "g-ann", email: "Ann@Example.com", displayName: "Ann G", verified: true, code: "good-code", statusCode: http.StatusOK, memberName: "Ann"},
+		{name: "Linked Account Signs In Again", subject: "g-ann", email: "other@example.com", verified: true, code: "good-code", statusCode: http.StatusOK, memberName: "Ann"},
+		{name: "Creates Profile", subject: "g-cat", email: "cat@example.com", displayName: "Cat", verified: true, code: "good-code", statusCode: http.StatusOK, memberName: "Cat"},
+		{name: "Unverified Profile Not Linked", subject: "g-ben", email: "ben@example.com", displayName: "Ben", verified: true, code: "good-code", statusCode: http.StatusConflict},
+		{name: "Unverified Google Email", subject: "g-dan", email: "dan@example.com", displayName: "Dan", verified: false, code: "good-code", statusCode: http.StatusForbidden},
+		{name: "Bad Code", subject: "g-eve", email: "eve@example.com", verified: true, code: "bad-code", statusCode: http.StatusBadGateway},
+		{name: "Forged State", subject: "g-eve", email: "eve@example.com", verified: true, code: "good-code", tamper: true, statusCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity(tt.subject, tt.email, tt.displayName, tt.verified)
+			rec := signIn(tt.code, tt.tamper)
+			if rec.Code != tt.statusCode {
+				t.Fatalf("expected status code %d, got %d: %s", tt.statusCode, rec.Code, rec.Body.String())
+			}
+			if tt.memberName == "" {
+				return
+			}
+			var response struct {
+				Data struct {
+					Token      string `json:"token"`
+					MemberName string `json:"memberName"`
+				} `json:"data"`
+			}
+			json.NewDecoder(rec.Body).Decode(&response)
+			if response.Data.MemberName != tt.memberName || response.Data.Token == "" {
+				t.Errorf("expected a session for %s, got %+v", tt.memberName, response.Data)
+			}
+		})
+	}
+
+	if index := memberIndex("Cat"); index < 0 || !members[index].EmailVerified || members[index].OIDCSubject != "g-cat" {
+		t.Errorf("expected a verified profile linked to the Google account, got %+v", members)
+	}
+}
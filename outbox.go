@@ -0,0 +1,416 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OutboxRecord is a domain event waiting to be delivered to one destination.
+// The audit entry of a change is saved first, marked unpublished, then its
+// records, and only then the change itself. On load, changes whose data was
+// not saved are replayed from the audit log and unpublished entries are queued
+// again, so events survive a crash and are retried until delivered.
+type OutboxRecord struct {
+	ID            int             `json:"id"`
+	EventID       int             `json:"eventId"` // Audit entry the event comes from
+	Type          string          `json:"type"`
+	Destination   string          `json:"destination"` // "webhook:<id>" or "bus"
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt"`
+	LastError     string          `json:"lastError,omitempty"`
+	DeliveredAt   *time.Time      `json:"deliveredAt,omitempty"`
+	FailedAt      *time.Time      `json:"failedAt,omitempty"` // Set once the record runs out of attempts
+}
+
+// Outbox record statuses used by GET /admin/outbox
+const (
+	outboxPending   = "pending"
+	outboxDelivered = "delivered"
+	outboxFailed    = "failed"
+)
+
+// outboxRetryCap bounds the delay between delivery attempts
+const outboxRetryCap = time.Hour
+
+var (
+	outbox   []OutboxRecord // Temp Slice to hold outbox records
+	outboxId = 1            // Incremental ID for outbox records
+
+	// outboxEventMark is the highest event ID whose records were saved. It
+	// outlives the records, so events delivered and dropped after the
+	// retention are not queued again.
+	outboxEventMark int
+)
+
+// outboxMarkFile persists outboxEventMark once records are dropped
+const outboxMarkFile = "outbox_mark.json"
+
+// outboxWake prompts the dispatcher to deliver new records without waiting for its next poll
+var outboxWake = make(chan struct{}, 1)
+
+// outboxStatus reports where a record is in its delivery
+func outboxStatus(record OutboxRecord) string {
+	switch {
+	case record.DeliveredAt != nil:
+		return outboxDelivered
+	case record.FailedAt != nil:
+		return outboxFailed
+	default:
+		return outboxPending
+	}
+}
+
+// webhookDestination names the outbox destination of a webhook subscription
+func webhookDestination(id int) string {
+	return "webhook:" + strconv.Itoa(id)
+}
+
+// outboxIndex returns the position of the record with the given ID, or -1.
+// Callers must hold the mutex.
+func outboxIndex(id int) int {
+	for i, record := range outbox {
+		if record.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// enqueueOutbox saves one outbox record per destination and wakes the
+// dispatcher. Records that cannot be saved are still delivered by this
+// process, and the error is returned. Callers must hold the mutex.
+func enqueueOutbox(eventID int, eventType string, payload []byte, destinations []string) error {
+	if len(destinations) == 0 {
+		return nil
+	}
+	for _, destination := range destinations {
+		outbox = append(outbox, OutboxRecord{
+			ID:            outboxId,
+			EventID:       eventID,
+			Type:          eventType,
+			Destination:   destination,
+			Payload:       payload,
+			CreatedAt:     now(),
+			NextAttemptAt: now(),
+		})
+		outboxId++
+	}
+	err := writeDataToJsonFile("outbox.json", outbox)
+	if err != nil {
+		fmt.Println("Error saving outbox:", err)
+	} else {
+		outboxEventMark = max(outboxEventMark, eventID)
+	}
+
+	select {
+	case outboxWake <- struct{}{}:
+	default:
+	}
+	return err
+}
+
+// outboxBackoff returns the delay before the next attempt after a failed one
+func outboxBackoff(attempts int) time.Duration {
+	delay := time.Duration(config.OutboxRetrySeconds) * time.Second
+	for i := 1; i < attempts && delay < outboxRetryCap; i++ {
+		delay *= 2
+	}
+	return min(delay, outboxRetryCap)
+}
+
+// publishEvent queues an audited operation in the outbox for every webhook
+// that wants it and for the event bus. It returns an error if the records
+// could not be saved. Callers must hold the mutex.
+func publishEvent(entry AuditEntry) error {
+	eventType, ok := domainEvents[entry.Entity+"."+entry.Action]
+	if !ok {
+		return nil
+	}
+	data := entry.After
+	if data == nil {
+		data = entry.Before
+	}
+	// Events leave the system, so they are masked like the log output
+	if config.PrivacyMode {
+		data = redactPersonalData(data)
+	}
+	body, err := json.Marshal(DomainEvent{ID: entry.ID, Type: eventType, Time: entry.Time, EntityID: entry.EntityID, Data: data})
+	if err != nil {
+		return err
+	}
+
+	destinations := []string{}
+	for _, subscription := range webhooks {
+		if webhookWants(subscription, eventType) {
+			destinations = append(destinations, webhookDestination(subscription.ID))
+		}
+	}
+	if eventBus != nil {
+		destinations = append(destinations, busDestination)
+	}
+	return enqueueOutbox(entry.ID, eventType, body, destinations)
+}
+
+// republishEvents queues the events of audit entries whose outbox records were
+// never saved, unless a later save of the outbox picked them up after all.
+// Callers must hold the mutex.
+func republishEvents() {
+	queued := map[int]bool{}
+	for _, record := range outbox {
+		queued[record.EventID] = true
+	}
+	changed := false
+	for i, entry := range auditEntries {
+		if !entry.Unpublished {
+			continue
+		}
+		if entry.ID > outboxEventMark && !queued[entry.ID] {
+			if err := publishEvent(entry); err != nil {
+				continue
+			}
+		}
+		auditEntries[i].Unpublished = false
+		changed = true
+	}
+	if !changed {
+		return
+	}
+	if err := writeDataToJsonFile("audit.json", auditEntries); err != nil {
+		fmt.Println("Error saving audit log:", err)
+	}
+}
+
+// replayEvents finishes the changes whose events were saved but whose own data
+// was not, because the process stopped in between. Events are saved just
+// before their change, so only the last entries of the audit log can be
+// unfinished; they are applied from the newest back for as long as their
+// booking or class is still in the state before the change.
+// Callers must hold the mutex.
+func replayEvents() error {
+	replayed := map[string]bool{}
+	for i := len(auditEntries) - 1; i >= 0; i-- {
+		entry := auditEntries[i]
+		applied := false
+		switch entry.Entity {
+		case "booking":
+			bookings, applied = replayChange(bookings, func(booking Booking) int { return booking.ID }, entry)
+		case "class":
+			classes, applied = replayChange(classes, func(class Class) int { return class.ID }, entry)
+		}
+		if !applied {
+			break
+		}
+		replayed[entry.Entity] = true
+	}
+	if replayed["booking"] {
+		if err := writeDataToJsonFile("bookings.json", bookings); err != nil {
+			return err
+		}
+	}
+	if replayed["class"] {
+		if err := writeDataToJsonFile("classes.json", classes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replayChange applies the after state of an audit entry to the item it
+// describes, if the item is still in the entry's before state or, for an
+// event that creates it, missing. It reports whether the entry was applied.
+func replayChange[T any](items []T, idOf func(T) int, entry AuditEntry) ([]T, bool) {
+	eventType, ok := domainEvents[entry.Entity+"."+entry.Action]
+	if !ok || entry.EntityID == 0 || entry.After == nil {
+		return items, false
+	}
+	data, err := json.Marshal(entry.After)
+	if err != nil {
+		return items, false
+	}
+	var after T
+	if err := json.Unmarshal(data, &after); err != nil {
+		return items, false
+	}
+	for i, item := range items {
+		if idOf(item) != entry.EntityID {
+			continue
+		}
+		current, _ := jsonValue(item)
+		before, _ := jsonValue(entry.Before)
+		if entry.Before == nil || !reflect.DeepEqual(current, before) {
+			return items, false
+		}
+		items[i] = after
+		return items, true
+	}
+	if entry.Before != nil && !strings.HasSuffix(eventType, ".created") {
+		return items, false
+	}
+	return append(items, after), true
+}
+
+// deliverOutboxRecord sends a record to its destination
+func deliverOutboxRecord(record OutboxRecord, subscriptions map[string]WebhookSubscription) error {
+	if strings.HasPrefix(record.Destination, "webhook:") {
+		subscription, ok := subscriptions[record.Destination]
+		if !ok {
+			return fmt.Errorf("webhook no longer exists")
+		}
+		_, err := deliverWebhook(subscription, record.Type, record.Payload)
+		return err
+	}
+	if record.Destination == busDestination && eventBus != nil {
+		return eventBus.Publish(record.Type, record.Payload)
+	}
+	return fmt.Errorf("unknown destination %s", record.Destination)
+}
+
+// dispatchOutbox delivers every due record, schedules retries for failures and
+// drops delivered records older than the retention. It returns how many records
+// were delivered. Deliveries happen without holding the mutex; only the
+// dispatcher goroutine should call it.
+func dispatchOutbox() int {
+	mutex.Lock()
+	due := []OutboxRecord{}
+	for _, record := range outbox {
+		if outboxStatus(record) == outboxPending && !record.NextAttemptAt.After(now()) {
+			due = append(due, record)
+		}
+	}
+	subscriptions := map[string]WebhookSubscription{}
+	for _, subscription := range webhooks {
+		subscriptions[webhookDestination(subscription.ID)] = subscription
+	}
+	mutex.Unlock()
+
+	results := map[int]error{}
+	for _, record := range due {
+		results[record.ID] = deliverOutboxRecord(record, subscriptions)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	delivered := 0
+	for id, err := range results {
+		// The outbox may have been reloaded by a restore while delivering
+		index := outboxIndex(id)
+		if index < 0 {
+			continue
+		}
+		record := &outbox[index]
+		record.Attempts++
+		at := now()
+		if err == nil {
+			record.DeliveredAt = &at
+			record.LastError = ""
+			delivered++
+			continue
+		}
+		record.LastError = err.Error()
+		if record.Attempts >= config.OutboxMaxAttempts {
+			record.FailedAt = &at
+			reportError("outbox", "Outbox delivery failed for good", map[string]string{"destination": record.Destination, "type": record.Type, "error": err.Error()})
+			continue
+		}
+		record.NextAttemptAt = at.Add(outboxBackoff(record.Attempts))
+	}
+
+	// Delivered records are only kept for inspection
+	retention := time.Duration(config.OutboxRetentionHours) * time.Hour
+	kept := []OutboxRecord{}
+	for _, record := range outbox {
+		if record.DeliveredAt != nil && now().Sub(*record.DeliveredAt) > retention {
+			continue
+		}
+		kept = append(kept, record)
+	}
+	if len(results) == 0 && len(kept) == len(outbox) {
+		return 0
+	}
+	if len(kept) < len(outbox) {
+		mark := outboxEventMark
+		for _, record := range outbox {
+			mark = max(mark, record.EventID)
+		}
+		// Records are only dropped once the mark covers them
+		if err := writeDataToJsonFile(outboxMarkFile, mark); err != nil {
+			fmt.Println("Error saving outbox mark:", err)
+			kept = outbox
+		} else {
+			outboxEventMark = mark
+		}
+	}
+	outbox = kept
+	if err := writeDataToJsonFile("outbox.json", outbox); err != nil {
+		fmt.Println("Error saving outbox:", err)
+	}
+	return delivered
+}
+
+// runOutboxDispatcher delivers outbox records as they are saved, and polls for
+// retries that have come due
+func runOutboxDispatcher(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-outboxWake:
+			}
+			dispatchOutbox()
+		}
+	}()
+}
+
+// Handler for inspecting the outbox
+func outboxHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && status != outboxPending && status != outboxDelivered && status != outboxFailed {
+		errorResponse(w, http.StatusBadRequest, "Invalid status, use pending, delivered or failed")
+		return
+	}
+	page, err := paginationParams(r)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	mutex.Lock()
+	matches := []OutboxRecord{}
+	for _, record := range outbox {
+		if status == "" || outboxStatus(record) == status {
+			matches = append(matches, record)
+		}
+	}
+	mutex.Unlock()
+
+	items, nextCursor := paginate(matches, page, func(record OutboxRecord) pageCursor {
+		return pageCursor{ID: record.ID}
+	}, false)
+	response := map[string]interface{}{
+		"records":    items,
+		"total":      len(matches),
+		"limit":      page.Limit,
+		"offset":     page.Offset,
+		"nextCursor": nextCursor,
+	}
+	successResponse(w, http.StatusOK, "Outbox retrieved successfully", response)
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestOutboxDelivery verifies events survive a restart and are retried until delivered.
+func TestOutboxDelivery(t *testing.T) {
+	setupTestEnvironment()
+	defer func() { now = time.Now }()
+	start := time.Date(2099, 12, 1, 9, 0, 0, 0, time.UTC)
+	now = func() time.Time { return start }
+
+	failing := true
+	deliveries := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deliveries++
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	webhooks = []WebhookSubscription{{ID: 1, URL: server.URL, Secret: "s3cret", Active: true}}
+	webhookId = 2
+	writeDataToJsonFile("webhooks.json", webhooks)
+	config.OutboxRetrySeconds = 30
+	config.OutboxMaxAttempts = 3
+
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10}}
+	classId = 2
+	rec := httptest.NewRecorder()
+	bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"Ann","className":"Yoga","date":"15-12-2099"}`))))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the booking to be created, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// A crash before delivery loses nothing, the record is reloaded from disk
+	outbox = nil
+	if err := loadData(); err != nil {
+		t.Fatalf("expected the data to reload, got %v", err)
+	}
+	if len(outbox) != 1 || outbox[0].Type != "booking.created" || outbox[0].Destination != "webhook:1" {
+		t.Fatalf("expected the booking event to be saved in the outbox, got %+v", outbox)
+	}
+
+	tests := []struct {
+		name      string
+		at        time.Duration
+		failing   bool
+		attempts  int
+		delivered int
+		status    string
+	}{
+		{"First Attempt Fails", 0, true, 1, 0, outboxPending},
+		{"Retry Not Yet Due", 20 * time.Second, true, 1, 0, outboxPending},
+		{"Retry Fails", 30 * time.Second, true, 2, 0, outboxPending},
+		{"Backoff Doubles", 80 * time.Second, true, 2, 0, outboxPending},
+		{"Retry Succeeds", 90 * time.Second, false, 3, 1, outboxDelivered},
+		{"Delivered Once", 10 * time.Minute, false, 3, 0, outboxDelivered},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = func() time.Time { return start.Add(tt.at) }
+			failing = tt.failing
+			if delivered := dispatchOutbox(); delivered != tt.delivered {
+				t.Errorf("expected %d deliveries, got %d", tt.delivered, delivered)
+			}
+			if outbox[0].Attempts != tt.attempts || outboxStatus(outbox[0]) != tt.status {
+				t.Errorf("expected %d attempts and status %s, got %+v", tt.attempts, tt.status, outbox[0])
+			}
+		})
+	}
+	if deliveries != 3 {
+		t.Errorf("expected 3 requests to the endpoint, got %d", deliveries)
+	}
+
+	// Delivered records are dropped after the retention
+	now = func() time.Time { return start.Add(25 * time.Hour) }
+	outbox = append(outbox, OutboxRecord{ID: 2, Type: "booking.cancelled", Destination: "webhook:9", NextAttemptAt: start})
+	outboxId = 3
+	dispatchOutbox()
+	if len(outbox) != 1 || outbox[0].ID != 2 || outbox[0].LastError != "webhook no longer exists" {
+		t.Fatalf("expected only the undeliverable record to remain, got %+v", outbox)
+	}
+
+	// Records out of attempts are marked failed
+	config.OutboxMaxAttempts = 1
+	outbox[0].NextAttemptAt = now()
+	dispatchOutbox()
+	if outboxStatus(outbox[0]) != outboxFailed {
+		t.Errorf("expected the record to be marked failed, got %+v", outbox[0])
+	}
+
+	rec = httptest.NewRecorder()
+	outboxHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/outbox?status=failed", nil))
+	if rec.Code != http.StatusOK || !bytes.Contains(rec.Body.Bytes(), []byte(`"total":1`)) {
+		t.Errorf("expected one failed record, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	outboxHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/outbox?status=lost", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an unknown status, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+// TestOutboxPrivacyMode verifies event payloads are masked before they leave the system in privacy mode.
+func TestOutboxPrivacyMode(t *testing.T) {
+	setupTestEnvironment()
+	webhooks = []WebhookSubscription{{ID: 1, URL: "http://example.invalid", Active: true}}
+	config.PrivacyMode = true
+	defer func() { config.PrivacyMode = false }()
+
+	recordAudit("admin", "create", "booking", 1, nil, Booking{ID: 1, MemberName: "Annabel", ClassName: "Yoga", Date: testDate("15-12-2099")})
+	if len(outbox) != 1 {
+		t.Fatalf("expected the event in the outbox, got %+v", outbox)
+	}
+	if bytes.Contains(outbox[0].Payload, []byte("Annabel")) || !bytes.Contains(outbox[0].Payload, []byte(`"memberName":"A***"`)) {
+		t.Errorf("expected the member name to be masked, got %s", outbox[0].Payload)
+	}
+}
+
+// TestOutboxSaveFailure verifies events whose outbox records could not be saved are queued again on load.
+func TestOutboxSaveFailure(t *testing.T) {
+	setupTestEnvironment()
+	webhooks = []WebhookSubscription{{ID: 1, URL: "http://example.invalid", Active: true}}
+	writeDataToJsonFile("webhooks.json", webhooks)
+
+	// A directory in its place makes the outbox file impossible to write
+	os.Remove("outbox.json")
+	os.Mkdir("outbox.json", 0755)
+	recordAudit("admin", "create", "booking", 1, nil, Booking{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("15-12-2099")})
+	os.Remove("outbox.json")
+	os.WriteFile("outbox.json", []byte("[]"), 0666)
+	if len(auditEntries) != 1 || !auditEntries[0].Unpublished {
+		t.Fatalf("expected the audit entry to be marked unpublished, got %+v", auditEntries)
+	}
+
+	// The process stops before any later save of the outbox
+	if err := loadData(); err != nil {
+		t.Fatalf("expected the data to reload, got %v", err)
+	}
+	if len(outbox) != 1 || outbox[0].EventID != 1 || outbox[0].Type != "booking.created" {
+		t.Fatalf("expected the event to be queued again, got %+v", outbox)
+	}
+	if auditEntries[0].Unpublished {
+		t.Errorf("expected the audit entry to be published, got %+v", auditEntries[0])
+	}
+
+	// Reloading again does not queue it twice
+	if err := loadData(); err != nil || len(outbox) != 1 {
+		t.Errorf("expected the event to be queued once, got %v: %+v", err, outbox)
+	}
+}
+
+// TestOutboxCrashRecovery verifies changes and their events are kept together across crashes and failed saves.
+func TestOutboxCrashRecovery(t *testing.T) {
+	setupTestEnvironment()
+	defer func() { now = time.Now }()
+	start := time.Date(2099, 12, 1, 9, 0, 0, 0, time.UTC)
+	now = func() time.Time { return start }
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	webhooks = []WebhookSubscription{{ID: 1, URL: server.URL, Active: true}}
+	writeDataToJsonFile("webhooks.json", webhooks)
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10}}
+	classId = 2
+	writeDataToJsonFile("classes.json", classes)
+
+	// A failed save withdraws the event of the change
+	os.Remove("bookings.json")
+	os.Mkdir("bookings.json", 0755)
+	rec := httptest.NewRecorder()
+	bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"Ann","className":"Yoga","date":"15-12-2099"}`))))
+	os.Remove("bookings.json")
+	os.WriteFile("bookings.json", []byte("[]"), 0666)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+	if len(auditEntries) != 0 || len(outbox) != 0 || auditId != 1 || outboxId != 1 {
+		t.Fatalf("expected the event to be withdrawn, got %+v and %+v", auditEntries, outbox)
+	}
+
+	// The process stops after saving the event but before saving the booking
+	booking := Booking{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("15-12-2099")}
+	recordAudit("admin", "create", "booking", booking.ID, nil, booking)
+	if err := loadData(); err != nil {
+		t.Fatalf("expected the data to reload, got %v", err)
+	}
+	if len(bookings) != 1 || bookings[0].MemberName != "Ann" || bookingId != 2 {
+		t.Fatalf("expected the booking to be replayed, got %+v", bookings)
+	}
+	if len(outbox) != 1 || outbox[0].EventID != 1 {
+		t.Fatalf("expected the event to be queued once, got %+v", outbox)
+	}
+
+	// Only the last changes can be unfinished, so a booking removed since is not brought back
+	bookings = nil
+	writeDataToJsonFile("bookings.json", bookings)
+	recordAudit("admin", "create", "closure", 1, nil, Closure{ID: 1})
+	if err := loadData(); err != nil || len(bookings) != 0 {
+		t.Fatalf("expected no booking to be replayed, got %v: %+v", err, bookings)
+	}
+
+	// Events delivered and dropped are not queued again, even if their audit
+	// entry was saved before the outbox
+	dispatchOutbox()
+	now = func() time.Time { return start.Add(25 * time.Hour) }
+	dispatchOutbox()
+	if len(outbox) != 0 || outboxEventMark != 1 {
+		t.Fatalf("expected the delivered record to be dropped, got %+v and mark %d", outbox, outboxEventMark)
+	}
+	auditEntries[0].Unpublished = true
+	writeDataToJsonFile("audit.json", auditEntries)
+	if err := loadData(); err != nil || len(outbox) != 0 {
+		t.Errorf("expected the delivered event not to be queued again, got %v: %+v", err, outbox)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+const (
+	defaultPageSize = 20  // Page size used when no limit is given
+	maxPageSize     = 100 // Upper bound on a single page
+)
+
+// pageCursor identifies the last item of a page so the next page can resume
+// after it, even if records were inserted or removed in the meantime
+type pageCursor struct {
+	Key string `json:"k,omitempty"` // Sortable value the list is ordered by before the ID, if any
+	ID  int    `json:"id"`
+}
+
+// before reports whether c sorts ahead of other
+func (c pageCursor) before(other pageCursor) bool {
+	if c.Key != other.Key {
+		return c.Key < other.Key
+	}
+	return c.ID < other.ID
+}
+
+// encodeCursor turns a cursor into an opaque token for clients
+func encodeCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reads a token produced by encodeCursor
+func decodeCursor(token string) (pageCursor, error) {
+	var c pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, errors.New("invalid cursor")
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, errors.New("invalid cursor")
+	}
+	return c, nil
+}
+
+// pageParams selects a page of a list
+type pageParams struct {
+	Limit  int
+	Offset int
+	After  *pageCursor // Set when resuming from a cursor, which takes precedence over Offset
+}
+
+// paginationParams reads the limit, offset and cursor query parameters
+func paginationParams(r *http.Request) (page pageParams, err error) {
+	page.Limit = defaultPageSize
+	if value := r.URL.Query().Get("limit"); value != "" {
+		page.Limit, err = strconv.Atoi(value)
+		if err != nil || page.Limit <= 0 || page.Limit > maxPageSize {
+			return pageParams{}, errors.New("invalid limit")
+		}
+	}
+
+	if value := r.URL.Query().Get("offset"); value != "" {
+		page.Offset, err = strconv.Atoi(value)
+		if err != nil || page.Offset < 0 {
+			return pageParams{}, errors.New("invalid offset")
+		}
+	}
+
+	if value := r.URL.Query().Get("cursor"); value != "" {
+		after, err := decodeCursor(value)
+		if err != nil {
+			return pageParams{}, err
+		}
+		page.After = &after
+	}
+	return page, nil
+}
+
+// paginate returns the page of items selected by page, along with the cursor
+// for the following page, which is empty on the last page. Items must be
+// sorted by cursorOf, newest first when descending is set.
+func paginate[T any](items []T, page pageParams, cursorOf func(T) pageCursor, descending bool) ([]T, string) {
+	start := page.Offset
+	if page.After != nil {
+		after := *page.After
+		start = sort.Search(len(items), func(i int) bool {
+			if descending {
+				return cursorOf(items[i]).before(after)
+			}
+			return after.before(cursorOf(items[i]))
+		})
+	}
+	if start >= len(items) {
+		return []T{}, ""
+	}
+
+	end := start + page.Limit
+	if end >= len(items) {
+		return items[start:], ""
+	}
+	return items[start:end], encodeCursor(cursorOf(items[end-1]))
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+)
+
+// TestPaginateWithCursor verifies cursors resume after the last item seen,
+// even when records are inserted between pages.
+func TestPaginateWithCursor(t *testing.T) {
+	cursorOf := func(id int) pageCursor { return pageCursor{ID: id} }
+
+	tests := []struct {
+		name       string
+		descending bool
+		items      []int
+		inserted   []int // Items after an insertion made once the first page is read
+		wantPages  [][]int
+	}{
+		{name: "Ascending", items: []int{1, 2, 3, 4, 5}, inserted: []int{1, 2, 3, 4, 5, 6}, wantPages: [][]int{{1, 2}, {3, 4}, {5, 6}}},
+		{name: "Descending", descending: true, items: []int{5, 4, 3, 2, 1}, inserted: []int{6, 5, 4, 3, 2, 1}, wantPages: [][]int{{5, 4}, {3, 2}, {1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := pageParams{Limit: 2}
+			items := tt.items
+			for i, want := range tt.wantPages {
+				got, nextCursor := paginate(items, page, cursorOf, tt.descending)
+				if len(got) != len(want) {
+					t.Fatalf("page %d: expected %v, got %v", i, want, got)
+				}
+				for j := range want {
+					if got[j] != want[j] {
+						t.Fatalf("page %d: expected %v, got %v", i, want, got)
+					}
+				}
+
+				if i == len(tt.wantPages)-1 {
+					if nextCursor != "" {
+						t.Errorf("expected no cursor on the last page, got %q", nextCursor)
+					}
+					return
+				}
+				after, err := decodeCursor(nextCursor)
+				if err != nil {
+					t.Fatalf("page %d: unexpected error decoding cursor: %v", i, err)
+				}
+				page.After = &after
+				items = tt.inserted
+			}
+		})
+	}
+}
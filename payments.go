@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PaymentProvider moves money back to members for the payments bookings
+// reference, e.g. through Stripe
+type PaymentProvider interface {
+	// Refund returns an amount in minor units of a payment and the provider's ID of the refund
+	Refund(paymentReference string, amount int, currency string) (string, error)
+}
+
+// paymentProvider refunds card payments when configured, nil leaves them to staff
+var paymentProvider PaymentProvider
+
+// stripeProvider refunds Stripe payment intents
+type stripeProvider struct {
+	baseURL   string
+	secretKey string
+	client    *http.Client
+}
+
+// Refund creates a refund of the payment intent through the Stripe API
+func (s *stripeProvider) Refund(paymentReference string, amount int, currency string) (string, error) {
+	form := url.Values{"payment_intent": {paymentReference}, "amount": {strconv.Itoa(amount)}}
+	req, err := http.NewRequest(http.MethodPost, s.baseURL+"/v1/refunds", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.secretKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		ID    string `json:"id"`
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("reading refund response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("refund refused with status %d: %s", resp.StatusCode, result.Error.Message)
+	}
+	return result.ID, nil
+}
+
+// configurePayments enables refunds through the configured payment provider
+func configurePayments() error {
+	paymentProvider = nil
+	switch config.PaymentProvider {
+	case "":
+		return nil
+	case "stripe":
+		if config.PaymentSecretKey == "" {
+			return fmt.Errorf("paymentSecretKey is required")
+		}
+		paymentProvider = &stripeProvider{baseURL: strings.TrimSuffix(config.PaymentAPIURL, "/"), secretKey: config.PaymentSecretKey, client: &http.Client{Timeout: 10 * time.Second}}
+		return nil
+	default:
+		return fmt.Errorf("invalid paymentProvider %q, use stripe", config.PaymentProvider)
+	}
+}
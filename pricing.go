@@ -0,0 +1,251 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PricingRule changes the price of bookings made while its conditions hold,
+// e.g. 20% more when fewer than 3 slots remain, or 15% off sessions starting
+// before 16:00. Conditions left out always hold.
+type PricingRule struct {
+	ID           int        `json:"id"`
+	Name         string     `json:"name"`
+	Percent      int        `json:"percent"`                // Price change, e.g. 20 for 20% more or -15 for 15% off
+	ClassName    string     `json:"className,omitempty"`    // Class the rule is for, all classes when empty
+	SlotsBelow   int        `json:"slotsBelow,omitempty"`   // Applies when fewer slots than this remain
+	StartsBefore string     `json:"startsBefore,omitempty"` // HH:MM the session must start before
+	StartsAfter  string     `json:"startsAfter,omitempty"`  // HH:MM the session must start at or after
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    *time.Time `json:"updatedAt,omitempty"`
+}
+
+// AppliedPricingRule records the pricing rule that set a booking's price
+type AppliedPricingRule struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Percent int    `json:"percent"`
+}
+
+var (
+	pricingRules  []PricingRule // Temp Slice to hold pricing rules
+	pricingRuleId = 1           // Incremental ID for pricing rules
+)
+
+// listPrice returns the class price a booking was priced from
+func listPrice(booking Booking) int {
+	if booking.ListPrice > 0 {
+		return booking.ListPrice
+	}
+	return booking.Price
+}
+
+// pricingRuleIndex returns the position of a pricing rule, or -1.
+// Callers must hold the mutex.
+func pricingRuleIndex(id int) int {
+	for i, rule := range pricingRules {
+		if rule.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkPricingRule validates the fields of a pricing rule
+func checkPricingRule(rule PricingRule) *requestRejection {
+	if strings.TrimSpace(rule.Name) == "" || rule.Percent == 0 || rule.Percent <= -100 || rule.SlotsBelow < 0 {
+		return &requestRejection{http.StatusBadRequest, "Invalid data format, a rule needs a name and a non-zero percent above -100"}
+	}
+	if _, err := minuteOfDay(rule.StartsBefore); rule.StartsBefore != "" && err != nil {
+		return &requestRejection{http.StatusBadRequest, "Invalid startsBefore format, use HH:MM"}
+	}
+	if _, err := minuteOfDay(rule.StartsAfter); rule.StartsAfter != "" && err != nil {
+		return &requestRejection{http.StatusBadRequest, "Invalid startsAfter format, use HH:MM"}
+	}
+	return nil
+}
+
+// pricingRuleMatches reports whether a rule applies to booking a class with
+// slotsLeft slots remaining
+func pricingRuleMatches(rule PricingRule, class *Class, slotsLeft int) bool {
+	if rule.ClassName != "" && rule.ClassName != class.ClassName {
+		return false
+	}
+	if rule.SlotsBelow > 0 && slotsLeft >= rule.SlotsBelow {
+		return false
+	}
+	if rule.StartsBefore != "" || rule.StartsAfter != "" {
+		start, err := minuteOfDay(class.StartTime)
+		if err != nil {
+			return false
+		}
+		if before, _ := minuteOfDay(rule.StartsBefore); rule.StartsBefore != "" && start >= before {
+			return false
+		}
+		if after, _ := minuteOfDay(rule.StartsAfter); rule.StartsAfter != "" && start < after {
+			return false
+		}
+	}
+	return true
+}
+
+// applyEarlyBird gives a booking the class's early-bird price when it is made
+// at least earlyBirdDays before the session, keeping the class price as the
+// list price
+func applyEarlyBird(booking *Booking, class *Class, date time.Time) {
+	if class.EarlyBirdPrice == 0 || today().After(date.AddDate(0, 0, -class.EarlyBirdDays)) {
+		return
+	}
+	booking.ListPrice, booking.Price, booking.EarlyBird = booking.Price, class.EarlyBirdPrice, true
+}
+
+// applyPricingRules prices a booking with the first rule, in the order they
+// were created, that applies to it, keeping the class price as the list
+// price. Rules change the early-bird price of bookings that get it.
+// Callers must hold the mutex.
+func applyPricingRules(booking *Booking, class *Class) {
+	if booking.Price == 0 {
+		return
+	}
+	session := sessionIDOn(class.ClassName, booking.Date)
+	slotsLeft := sessionCapacity(class, booking.Date) - countBookings(session) - countHolds(session)
+	for _, rule := range pricingRules {
+		if !pricingRuleMatches(rule, class, slotsLeft) {
+			continue
+		}
+		if booking.ListPrice == 0 {
+			booking.ListPrice = booking.Price
+		}
+		booking.Price = int(math.Round(float64(booking.Price) * float64(100+rule.Percent) / 100))
+		booking.PricingRule = &AppliedPricingRule{ID: rule.ID, Name: rule.Name, Percent: rule.Percent}
+		return
+	}
+}
+
+// Handler for listing and creating pricing rules
+func pricingRuleHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		response := append([]PricingRule{}, pricingRules...)
+		mutex.Unlock()
+		successResponse(w, http.StatusOK, "Pricing rules retrieved successfully", map[string]interface{}{"rules": response})
+
+	case http.MethodPost:
+		var rule PricingRule
+		if err := decodeBody(r, &rule); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if rejection := checkPricingRule(rule); rejection != nil {
+			errorResponse(w, rejection.StatusCode, rejection.Message)
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		rule.ID = pricingRuleId
+		rule.CreatedAt = now()
+		rule.UpdatedAt = nil
+		pricingRuleId++
+		pricingRules = append(pricingRules, rule)
+		if err := writeDataToJsonFile("pricing_rules.json", pricingRules); err != nil {
+			pricingRules = pricingRules[:len(pricingRules)-1]
+			pricingRuleId--
+			errorResponse(w, http.StatusInternalServerError, "Failed to save pricing rule data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "create", "pricingRule", rule.ID, nil, rule)
+
+		successResponse(w, http.StatusCreated, "Pricing rule created successfully", rule)
+		logData("Pricing rule created successfully", rule)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// Handler for reading, changing and removing a pricing rule. Bookings keep
+// the price they were made at.
+func pricingRuleItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid pricing rule id")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := pricingRuleIndex(id)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Pricing rule not found")
+			return
+		}
+		successResponse(w, http.StatusOK, "Pricing rule retrieved successfully", pricingRules[index])
+
+	case http.MethodPut:
+		var request PricingRule
+		if err := decodeBody(r, &request); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if rejection := checkPricingRule(request); rejection != nil {
+			errorResponse(w, rejection.StatusCode, rejection.Message)
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := pricingRuleIndex(id)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Pricing rule not found")
+			return
+		}
+		before := pricingRules[index]
+		request.ID, request.CreatedAt = before.ID, before.CreatedAt
+		updatedAt := now()
+		request.UpdatedAt = &updatedAt
+		pricingRules[index] = request
+		if err := writeDataToJsonFile("pricing_rules.json", pricingRules); err != nil {
+			pricingRules[index] = before
+			errorResponse(w, http.StatusInternalServerError, "Failed to save pricing rule data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "update", "pricingRule", id, before, request)
+
+		successResponse(w, http.StatusOK, "Pricing rule updated successfully", request)
+		logData("Pricing rule updated successfully", request)
+
+	case http.MethodDelete:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := pricingRuleIndex(id)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Pricing rule not found")
+			return
+		}
+		previous, before := pricingRules, pricingRules[index]
+		pricingRules = append(append([]PricingRule{}, pricingRules[:index]...), pricingRules[index+1:]...)
+		if err := writeDataToJsonFile("pricing_rules.json", pricingRules); err != nil {
+			pricingRules = previous
+			errorResponse(w, http.StatusInternalServerError, "Failed to save pricing rule data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "delete", "pricingRule", id, before, nil)
+
+		successResponse(w, http.StatusOK, "Pricing rule deleted successfully", before)
+		logData("Pricing rule deleted successfully", before)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPricingRuleValidation verifies malformed pricing rules are rejected.
+func TestPricingRuleValidation(t *testing.T) {
+	setupTestEnvironment()
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"Surge", `{"name":"Last spots","percent":20,"slotsBelow":3}`, http.StatusCreated},
+		{"Off Peak", `{"name":"Off-peak","percent":-15,"startsBefore":"16:00"}`, http.StatusCreated},
+		{"No Change", `{"name":"Nothing","percent":0}`, http.StatusBadRequest},
+		{"Free", `{"name":"Free","percent":-100}`, http.StatusBadRequest},
+		{"Bad Time", `{"name":"Evening","percent":10,"startsAfter":"6pm"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			pricingRuleHandler(rec, httptest.NewRequest(http.MethodPost, "/admin/pricing-rules", bytes.NewReader([]byte(tt.body))))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+	if len(pricingRules) != 2 {
+		t.Errorf("expected two rules, got %+v", pricingRules)
+	}
+}
+
+// TestDynamicPricing verifies the first matching rule prices a booking and is recorded on it.
+func TestDynamicPricing(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{
+		{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 4, StartTime: "18:00", Price: 1000, Currency: "EUR"},
+		{ID: 2, ClassName: "Sunrise", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 10, StartTime: "07:00", Price: 1000, Currency: "EUR"},
+	}
+	classId = 3
+	pricingRules = []PricingRule{
+		{ID: 1, Name: "Last spots", Percent: 20, SlotsBelow: 3},
+		{ID: 2, Name: "Off-peak", Percent: -15, StartsBefore: "16:00"},
+	}
+	pricingRuleId = 3
+
+	tests := []struct {
+		member    string
+		className string
+		price     int
+		listPrice int
+		rule      int
+	}{
+		{"Ann", "Yoga", 1000, 0, 0},
+		{"Ben", "Yoga", 1000, 0, 0},
+		{"Cat", "Yoga", 1200, 1000, 1},
+		{"Dan", "Yoga", 1200, 1000, 1},
+		{"Ann", "Sunrise", 850, 1000, 2},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%s %s", tt.member, tt.className), func(t *testing.T) {
+			body := fmt.Sprintf(`{"memberName":%q,"className":%q,"date":"02-12-2099","listPrice":1,"pricingRule":{"id":9}}`, tt.member, tt.className)
+			rec := httptest.NewRecorder()
+			bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(body))))
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("expected the booking to succeed, got %d: %s", rec.Code, rec.Body.String())
+			}
+			booking := bookings[i]
+			if booking.Price != tt.price || booking.ListPrice != tt.listPrice || (booking.PricingRule == nil) != (tt.rule == 0) {
+				t.Fatalf("expected a price of %d, got %+v", tt.price, booking)
+			}
+			if tt.rule != 0 && booking.PricingRule.ID != tt.rule {
+				t.Errorf("expected rule %d to apply, got %+v", tt.rule, booking.PricingRule)
+			}
+		})
+	}
+
+	receipt := newReceipt(bookings[4])
+	if receipt.Items[0].Amount != 1000 || len(receipt.Discounts) != 1 || receipt.Discounts[0].Amount != -150 || receipt.Total.Amount != 850 {
+		t.Errorf("expected the discount on the receipt, got %+v", receipt)
+	}
+	if receipt := newReceipt(bookings[2]); receipt.Items[0].Amount != 1200 || len(receipt.Discounts) != 0 {
+		t.Errorf("expected the surcharge in the item price, got %+v", receipt)
+	}
+}
+
+// TestEarlyBirdPricing verifies bookings made early enough get the early-bird price.
+func TestEarlyBirdPricing(t *testing.T) {
+	setupTestEnvironment()
+	defer func() { now = time.Now }()
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("20-12-2099"), Capacity: 5, Price: 2000, Currency: "EUR", EarlyBirdPrice: 1500, EarlyBirdDays: 7}}
+	classId = 2
+
+	tests := []struct {
+		name      string
+		today     int
+		price     int
+		earlyBird bool
+	}{
+		{"Weeks Ahead", 1, 1500, true},
+		{"Last Early Day", 3, 1500, true},
+		{"Too Late", 4, 2000, false},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = func() time.Time { return time.Date(2099, 12, tt.today, 10, 0, 0, 0, time.UTC) }
+			body := fmt.Sprintf(`{"memberName":"Member%d","className":"Yoga","date":"10-12-2099","earlyBird":true}`, i)
+			rec := httptest.NewRecorder()
+			bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(body))))
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("expected the booking to succeed, got %d: %s", rec.Code, rec.Body.String())
+			}
+			if booking := bookings[i]; booking.Price != tt.price || booking.EarlyBird != tt.earlyBird {
+				t.Errorf("expected a price of %d, got %+v", tt.price, booking)
+			}
+			charged := fmt.Sprintf(`"price":"€%d.00"`, tt.price/100)
+			if !strings.Contains(rec.Body.String(), `"listPrice":"€20.00"`) || !strings.Contains(rec.Body.String(), charged) {
+				t.Errorf("expected the original and charged prices, got %s", rec.Body.String())
+			}
+		})
+	}
+
+	for _, class := range []Class{
+		{ClassName: "Spin", StartDate: testDate("01-12-2099"), EndDate: testDate("20-12-2099"), Capacity: 5, Price: 1000, EarlyBirdPrice: 1000, EarlyBirdDays: 7},
+		{ClassName: "Spin", StartDate: testDate("01-12-2099"), EndDate: testDate("20-12-2099"), Capacity: 5, Price: 1000, EarlyBirdPrice: 800},
+	} {
+		if rejection := checkClass(class); rejection == nil || rejection.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected invalid early-bird pricing to be rejected, got %v", rejection)
+		}
+	}
+}
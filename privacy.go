@@ -0,0 +1,391 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+)
+
+// pseudonymPrefix starts the placeholder names of erased members
+const pseudonymPrefix = "erased-"
+
+// newPseudonym returns a random placeholder that replaces an erased member's name
+func newPseudonym() string {
+	token := make([]byte, 4)
+	rand.Read(token)
+	return pseudonymPrefix + hex.EncodeToString(token)
+}
+
+// personalFields lists the JSON fields that hold personal data
+var personalFields = map[string]bool{
+	"memberName":    true,
+	"primaryMember": true,
+	"referrerName":  true,
+	"purchaserName": true,
+	"actor":         true,
+	"dateOfBirth":   true,
+	"email":         true,
+	"phone":         true,
+}
+
+// jsonValue converts value to its generic JSON representation, so structs and
+// decoded maps can be walked alike. It returns false if value cannot be converted.
+func jsonValue(value interface{}) (interface{}, bool) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, false
+	}
+	return decoded, true
+}
+
+// maskString keeps the first character of a personal value and hides the rest
+func maskString(value string) string {
+	if value == "" {
+		return value
+	}
+	runes := []rune(value)
+	return string(runes[0]) + "***"
+}
+
+// redactPersonalData returns a copy of value with every personal field masked
+func redactPersonalData(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	decoded, ok := jsonValue(value)
+	if !ok {
+		return value
+	}
+
+	var walk func(v interface{}) interface{}
+	walk = func(v interface{}) interface{} {
+		switch typed := v.(type) {
+		case map[string]interface{}:
+			for key, item := range typed {
+				if text, isString := item.(string); isString && personalFields[key] {
+					typed[key] = maskString(text)
+				} else {
+					typed[key] = walk(item)
+				}
+			}
+		case []interface{}:
+			for i, item := range typed {
+				typed[i] = walk(item)
+			}
+		}
+		return v
+	}
+	return walk(decoded)
+}
+
+// replaceString returns a copy of value with every string equal to old replaced
+func replaceString(value interface{}, old, replacement string) interface{} {
+	if value == nil {
+		return nil
+	}
+	decoded, ok := jsonValue(value)
+	if !ok {
+		return value
+	}
+
+	var walk func(v interface{}) interface{}
+	walk = func(v interface{}) interface{} {
+		switch typed := v.(type) {
+		case string:
+			if typed == old {
+				return replacement
+			}
+		case map[string]interface{}:
+			for key, item := range typed {
+				typed[key] = walk(item)
+			}
+		case []interface{}:
+			for i, item := range typed {
+				typed[i] = walk(item)
+			}
+		}
+		return v
+	}
+	return walk(decoded)
+}
+
+// pseudonymizeBooking replaces a member's name on a booking, as its member or
+// as the primary member of its group. It reports whether the booking changed.
+func pseudonymizeBooking(booking *Booking, memberName, pseudonym string) bool {
+	changed := false
+	if booking.MemberName == memberName {
+		booking.MemberName = pseudonym
+		changed = true
+	}
+	if booking.PrimaryMember == memberName {
+		booking.PrimaryMember = pseudonym
+		changed = true
+	}
+	return changed
+}
+
+// Handler for erasing a member's personal data
+func memberDataHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is DELETE
+	if r.Method != http.MethodDelete {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	memberName := r.PathValue("name")
+	if memberName == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid member name")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// Anonymize bookings in place so capacity history keeps its counts
+	pseudonym := newPseudonym()
+	anonymized := 0
+	original := append([]Booking{}, bookings...)
+	for i := range bookings {
+		if pseudonymizeBooking(&bookings[i], memberName, pseudonym) {
+			anonymized++
+		}
+	}
+	profile := memberIndex(memberName)
+	if anonymized == 0 && profile < 0 {
+		errorResponse(w, http.StatusNotFound, "Member not found")
+		return
+	}
+
+	if err := writeDataToJsonFile("bookings.json", bookings); err != nil {
+		bookings = original
+		errorResponse(w, http.StatusInternalServerError, "Failed to save booking data")
+		return
+	}
+
+	// The bookings are already erased, so a store that fails to save is reported rather than stopping the rest
+	unsaved := []string{}
+	save := func(file string, data interface{}) {
+		if err := writeDataToJsonFile(file, data); err != nil {
+			unsaved = append(unsaved, file)
+		}
+	}
+
+	// The profile goes entirely, and members they referred keep only the pseudonym
+	if profile >= 0 {
+		members = append(members[:profile], members[profile+1:]...)
+	}
+	for i := range members {
+		if members[i].ReferredBy == memberName {
+			members[i].ReferredBy = pseudonym
+		}
+	}
+	save("members.json", members)
+
+	// So are the member's password and sessions
+	if credential := credentialIndex(memberName); credential >= 0 {
+		credentials = append(credentials[:credential], credentials[credential+1:]...)
+		save("credentials.json", credentials)
+	}
+	if err := endSessions(memberName, ""); err != nil {
+		unsaved = append(unsaved, "sessions.json")
+	}
+
+	// Their devices stop receiving notifications
+	remaining := []Device{}
+	for _, device := range devices {
+		if device.MemberName != memberName {
+			remaining = append(remaining, device)
+		}
+	}
+	devices = remaining
+	save("devices.json", devices)
+
+	// Any block on the member goes with them
+	if block := blockIndex(memberName); block >= 0 {
+		blocks = append(blocks[:block], blocks[block+1:]...)
+		save("blocks.json", blocks)
+	}
+
+	// Waiver acceptances are kept for legal audits, under the pseudonym
+	for i := range waiverAcceptances {
+		if waiverAcceptances[i].MemberName == memberName {
+			waiverAcceptances[i].MemberName = pseudonym
+		}
+		if waiverAcceptances[i].RecordedBy == memberName {
+			waiverAcceptances[i].RecordedBy = pseudonym
+		}
+	}
+	save("waivers.json", waiverAcceptances)
+
+	// Pending holds, waitlist entries and their outcomes are personal data as well
+	for i := range holds {
+		if holds[i].MemberName == memberName {
+			holds[i].MemberName = pseudonym
+		}
+	}
+	save("holds.json", holds)
+	for i := range waitlist {
+		if waitlist[i].MemberName == memberName {
+			waitlist[i].MemberName = pseudonym
+		}
+	}
+	save("waitlist.json", waitlist)
+	for i := range waitlistHistory {
+		if waitlistHistory[i].MemberName == memberName {
+			waitlistHistory[i].MemberName = pseudonym
+		}
+	}
+	save("waitlist_history.json", waitlistHistory)
+
+	// Credits, points, refunds, gift cards, referrals and promo code redemptions are kept for the accounts, under the pseudonym
+	for i := range creditEntries {
+		if creditEntries[i].MemberName == memberName {
+			creditEntries[i].MemberName = pseudonym
+		}
+		if creditEntries[i].Actor == memberName {
+			creditEntries[i].Actor = pseudonym
+		}
+	}
+	save("credits.json", creditEntries)
+	for i := range pointsEntries {
+		if pointsEntries[i].MemberName == memberName {
+			pointsEntries[i].MemberName = pseudonym
+		}
+		if pointsEntries[i].Actor == memberName {
+			pointsEntries[i].Actor = pseudonym
+		}
+	}
+	save("points.json", pointsEntries)
+	for i := range refunds {
+		if refunds[i].MemberName == memberName {
+			refunds[i].MemberName = pseudonym
+		}
+		if refunds[i].Actor == memberName {
+			refunds[i].Actor = pseudonym
+		}
+	}
+	save("refunds.json", refunds)
+	for i := range giftCards {
+		if giftCards[i].PurchaserName == memberName {
+			giftCards[i].PurchaserName = pseudonym
+		}
+		for j := range giftCards[i].Transactions {
+			if giftCards[i].Transactions[j].Actor == memberName {
+				giftCards[i].Transactions[j].Actor = pseudonym
+			}
+		}
+	}
+	save("giftcards.json", giftCards)
+	for i := range referrals {
+		if referrals[i].ReferrerName == memberName {
+			referrals[i].ReferrerName = pseudonym
+		}
+		if referrals[i].MemberName == memberName {
+			referrals[i].MemberName = pseudonym
+		}
+	}
+	save("referrals.json", referrals)
+	for i := range promoRedemptions {
+		if promoRedemptions[i].MemberName == memberName {
+			promoRedemptions[i].MemberName = pseudonym
+		}
+	}
+	save("promo_redemptions.json", promoRedemptions)
+	// Announcement recipients and reviews are kept under the pseudonym too
+	for i := range announcements {
+		for j := range announcements[i].Recipients {
+			if announcements[i].Recipients[j] == memberName {
+				announcements[i].Recipients[j] = pseudonym
+			}
+		}
+	}
+	save("announcements.json", announcements)
+	for i := range reviews {
+		if reviews[i].MemberName == memberName {
+			reviews[i].MemberName = pseudonym
+		}
+	}
+	save("reviews.json", reviews)
+
+	// Scrub the name from audit entries, booking history and archived bookings too
+	for i := range auditEntries {
+		if auditEntries[i].Actor == memberName {
+			auditEntries[i].Actor = pseudonym
+		}
+		if auditEntries[i].OnBehalfOf == memberName {
+			auditEntries[i].OnBehalfOf = pseudonym
+		}
+		auditEntries[i].Before = replaceString(auditEntries[i].Before, memberName, pseudonym)
+		auditEntries[i].After = replaceString(auditEntries[i].After, memberName, pseudonym)
+	}
+	for i := range outbox {
+		if payload, err := json.Marshal(replaceString(outbox[i].Payload, memberName, pseudonym)); err == nil {
+			outbox[i].Payload = payload
+		}
+	}
+	save("outbox.json", outbox)
+	for i := range consumedEvents {
+		if consumedEvents[i].MemberName == memberName {
+			consumedEvents[i].MemberName = pseudonym
+		}
+	}
+	save("consumed_events.json", consumedEvents)
+
+	scrubbedEvents := false
+	for i := range bookingEvents {
+		if bookingEvents[i].Actor == memberName {
+			bookingEvents[i].Actor = pseudonym
+			scrubbedEvents = true
+		}
+		if admin, member := splitActor(bookingEvents[i].Actor); member == memberName {
+			bookingEvents[i].Actor = admin + onBehalfOf + pseudonym
+			scrubbedEvents = true
+		}
+		for key, value := range bookingEvents[i].Details {
+			if value == memberName {
+				bookingEvents[i].Details[key] = pseudonym
+				scrubbedEvents = true
+			}
+		}
+	}
+	if scrubbedEvents {
+		save("booking_events.json", bookingEvents)
+	}
+
+	var archive retentionArchive
+	if err := dataFromJsonFile(retentionArchiveFile, &archive); err == nil && len(archive.Bookings) > 0 {
+		for i := range archive.Bookings {
+			pseudonymizeBooking(&archive.Bookings[i], memberName, pseudonym)
+		}
+		save(retentionArchiveFile, archive)
+	}
+
+	if len(archived.Bookings) > 0 {
+		for i := range archived.Bookings {
+			pseudonymizeBooking(&archived.Bookings[i], memberName, pseudonym)
+		}
+		save(archiveFile, archived)
+	}
+
+	response := map[string]interface{}{
+		"pseudonym":          pseudonym,
+		"bookingsAnonymized": anonymized,
+	}
+	if len(unsaved) > 0 {
+		response["unsaved"] = unsaved
+	}
+	recordAudit(actorFromRequest(r), "erase", "member", 0, nil, response)
+
+	if len(unsaved) > 0 {
+		errorDetailsResponse(w, http.StatusInternalServerError, "Failed to save some member data", response)
+		return
+	}
+
+	successResponse(w, http.StatusOK, "Member data erased successfully", response)
+	logData("Member data erased successfully", response)
+}
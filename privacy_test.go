@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestMemberDataHandler verifies a member's personal data is anonymized.
+func TestMemberDataHandler(t *testing.T) {
+	setupTestEnvironment()
+	bookings = []Booking{
+		{ID: 1, MemberName: "John Doe", Date: testDate("16-12-2024"), ClassName: "Pilates", Status: bookingStatusConfirmed},
+		{ID: 2, MemberName: "Jane Doe", Date: testDate("16-12-2024"), ClassName: "Pilates", Status: bookingStatusConfirmed},
+		{ID: 3, MemberName: "John Doe", Date: testDate("17-12-2024"), ClassName: "Pilates", Status: bookingStatusConfirmed},
+		{ID: 4, MemberName: "Kid Doe", Date: testDate("17-12-2024"), ClassName: "Pilates", Status: bookingStatusConfirmed, GroupID: 3, PrimaryMember: "John Doe"},
+	}
+	archived = classArchive{Bookings: []Booking{{ID: 5, MemberName: "Kid Doe", Date: testDate("10-11-2024"), ClassName: "Pilates", GroupID: 5, PrimaryMember: "John Doe"}}}
+	writeDataToJsonFile(retentionArchiveFile, retentionArchive{Bookings: []Booking{{ID: 6, MemberName: "John Doe", Date: testDate("10-10-2024"), ClassName: "Pilates"}}})
+	members = []Member{{Name: "John Doe", Level: levelAdvanced}}
+	classes = []Class{{ID: 1, ClassName: "Pilates", StartDate: testDate("01-12-2024"), EndDate: testDate("31-12-2024"), Capacity: 10}}
+	migrateSessions()
+	recordAudit("John Doe", "create", "booking", 1, nil, bookings[0])
+
+	req := httptest.NewRequest(http.MethodDelete, "/members/John%20Doe/data", nil)
+	req.SetPathValue("name", "John Doe")
+	rec := httptest.NewRecorder()
+	memberDataHandler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	// Bookings keep their counts but lose the name.
+	if len(bookings) != 4 || countBookings(sessionIDOn("Pilates", testDate("16-12-2024"))) != 2 {
+		t.Errorf("expected booking counts to be preserved, got %+v", bookings)
+	}
+	if bookings[0].MemberName == "John Doe" || bookings[0].MemberName != bookings[2].MemberName || bookings[1].MemberName != "Jane Doe" {
+		t.Errorf("expected only John Doe's bookings to be anonymized, got %+v", bookings)
+	}
+	if bookings[3].MemberName != "Kid Doe" || bookings[3].PrimaryMember != bookings[0].MemberName {
+		t.Errorf("expected the group's primary member to be anonymized, got %+v", bookings[3])
+	}
+	var retained retentionArchive
+	dataFromJsonFile(retentionArchiveFile, &retained)
+	if archived.Bookings[0].PrimaryMember != bookings[0].MemberName || len(retained.Bookings) != 1 || retained.Bookings[0].MemberName != bookings[0].MemberName {
+		t.Errorf("expected the archives to be anonymized, got %+v and %+v", archived.Bookings, retained.Bookings)
+	}
+
+	if memberIndex("John Doe") >= 0 {
+		t.Errorf("expected the profile to be removed, got %+v", members)
+	}
+
+	// Earlier audit entries are scrubbed and the erasure itself is audited.
+	first := auditEntries[0]
+	if first.Actor == "John Doe" || strings.Contains(fmt.Sprint(first.After), "John Doe") {
+		t.Errorf("expected the audit entry to be scrubbed, got %+v", first)
+	}
+	if last := auditEntries[len(auditEntries)-1]; last.Action != "erase" || last.Entity != "member" {
+		t.Errorf("expected an erase audit entry, got %+v", last)
+	}
+
+	// Erasing an unknown member fails.
+	rec = httptest.NewRecorder()
+	memberDataHandler(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status code %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+// TestMemberDataSaveFailure verifies stores that cannot be saved are reported.
+func TestMemberDataSaveFailure(t *testing.T) {
+	setupTestEnvironment()
+	bookings = []Booking{{ID: 1, MemberName: "John Doe", Date: testDate("16-12-2024"), ClassName: "Pilates", Status: bookingStatusConfirmed}}
+	members = []Member{{Name: "John Doe", Level: levelAdvanced}}
+
+	// A directory in its place makes the members file impossible to write
+	os.Remove("members.json")
+	os.Mkdir("members.json", 0755)
+	defer os.Remove("members.json")
+
+	req := httptest.NewRequest(http.MethodDelete, "/members/John%20Doe/data", nil)
+	req.SetPathValue("name", "John Doe")
+	rec := httptest.NewRecorder()
+	memberDataHandler(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status code %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `"unsaved":["members.json"]`) {
+		t.Errorf("expected the members store to be listed as unsaved, got %s", rec.Body.String())
+	}
+	if bookings[0].MemberName == "John Doe" {
+		t.Errorf("expected the saved bookings to stay anonymized, got %+v", bookings[0])
+	}
+}
+
+// TestRedactPersonalData verifies personal fields are masked for logging.
+func TestRedactPersonalData(t *testing.T) {
+	response := map[string]interface{}{
+		"booking":        Booking{ID: 1, MemberName: "Rahul R P", Date: testDate("16-12-2024"), ClassName: "Pilates", PrimaryMember: "Rahul R P"},
+		"availableSlots": 9,
+	}
+
+	redacted := fmt.Sprint(redactPersonalData(response))
+	if strings.Contains(redacted, "Rahul R P") {
+		t.Errorf("expected the member name to be masked, got %s", redacted)
+	}
+	if !strings.Contains(redacted, "R***") || !strings.Contains(redacted, "Pilates") {
+		t.Errorf("expected only personal fields to be masked, got %s", redacted)
+	}
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Promo is a code marketing hands out for a discount on bookings. It takes
+// either a percent or an amount off the price, up to its usage caps.
+type Promo struct {
+	Code           string     `json:"code"`
+	Percent        int        `json:"percent,omitempty"`        // Percent off the price
+	AmountOff      int        `json:"amountOff,omitempty"`      // Minor units off the price, in Currency
+	Currency       string     `json:"currency,omitempty"`       // ISO 4217 code of AmountOff
+	MaxRedemptions int        `json:"maxRedemptions,omitempty"` // Bookings the code can be used for in total, 0 for no cap
+	MaxPerMember   int        `json:"maxPerMember,omitempty"`   // Bookings each member can use it for, 0 for no cap
+	ExpiresOn      string     `json:"expiresOn,omitempty"`      // Last day the code can be used, DD-MM-YYYY
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      *time.Time `json:"updatedAt,omitempty"`
+}
+
+// PromoRedemption records a booking a promo code was used for
+type PromoRedemption struct {
+	ID         int       `json:"id"`
+	Code       string    `json:"code"`
+	MemberName string    `json:"memberName"`
+	BookingID  int       `json:"bookingId"`
+	Discount   int       `json:"discount"` // Minor units taken off the price
+	Currency   string    `json:"currency"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+var (
+	promos            []Promo           // Temp Slice to hold promo codes
+	promoRedemptions  []PromoRedemption // Temp Slice to hold promo code redemptions
+	promoRedemptionId = 1               // Incremental ID for promo code redemptions
+)
+
+// promoIndex returns the position of a promo code, in any letter case, or -1.
+// Callers must hold the mutex.
+func promoIndex(code string) int {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	for i, promo := range promos {
+		if promo.Code == code {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkPromo validates the fields of a promo code
+func checkPromo(promo Promo) *requestRejection {
+	if promo.Code == "" || strings.ContainsAny(promo.Code, " /") {
+		return &requestRejection{http.StatusBadRequest, "Invalid code, use letters and digits"}
+	}
+	if (promo.Percent == 0) == (promo.AmountOff == 0) || promo.Percent < 0 || promo.Percent > 100 || promo.AmountOff < 0 {
+		return &requestRejection{http.StatusBadRequest, "Give either a percent up to 100 or an amountOff"}
+	}
+	if promo.AmountOff > 0 {
+		if rejection := checkCurrency(promo.Currency); rejection != nil {
+			return rejection
+		}
+	}
+	if promo.MaxRedemptions < 0 || promo.MaxPerMember < 0 {
+		return &requestRejection{http.StatusBadRequest, "Usage caps cannot be negative"}
+	}
+	if _, err := time.Parse(dateLayout, promo.ExpiresOn); promo.ExpiresOn != "" && err != nil {
+		return &requestRejection{http.StatusBadRequest, "Invalid expiresOn format, use DD-MM-YYYY"}
+	}
+	return nil
+}
+
+// normalizePromo stores codes and currencies in upper case
+func normalizePromo(promo *Promo) {
+	promo.Code = strings.ToUpper(strings.TrimSpace(promo.Code))
+	promo.Currency = strings.ToUpper(promo.Currency)
+	if promo.Percent > 0 {
+		promo.Currency = ""
+	}
+}
+
+// promoRedeemable checks a booking can use the promo code it quotes: the code
+// exists, has not expired, is under its caps and fits the class's price.
+// Callers must hold the mutex.
+func promoRedeemable(booking Booking) *requestRejection {
+	index := promoIndex(booking.PromoCode)
+	if index < 0 {
+		return &requestRejection{http.StatusBadRequest, "Unknown promo code"}
+	}
+	promo := promos[index]
+	if promo.ExpiresOn != "" {
+		if lastDay, _ := time.Parse(dateLayout, promo.ExpiresOn); today().After(lastDay) {
+			return &requestRejection{http.StatusBadRequest, "Promo code has expired"}
+		}
+	}
+	used, usedByMember := 0, 0
+	for _, redemption := range promoRedemptions {
+		if redemption.Code == promo.Code {
+			used++
+			if redemption.MemberName == booking.MemberName {
+				usedByMember++
+			}
+		}
+	}
+	if promo.MaxRedemptions > 0 && used >= promo.MaxRedemptions {
+		return &requestRejection{http.StatusConflict, "Promo code has been fully redeemed"}
+	}
+	if promo.MaxPerMember > 0 && usedByMember >= promo.MaxPerMember {
+		return &requestRejection{http.StatusConflict, "Promo code has already been used the maximum number of times"}
+	}
+
+	class := findClassOn(booking.ClassName, booking.Date.Time)
+	if booking.PaymentMethod != "" || booking.ResourceID != 0 || class == nil || class.Price == 0 {
+		return &requestRejection{http.StatusBadRequest, "Promo codes only apply to priced class bookings"}
+	}
+	if promo.AmountOff > 0 && promo.Currency != classCurrency(*class) {
+		return &requestRejection{http.StatusBadRequest, fmt.Sprintf("Promo code is for classes priced in %s", promo.Currency)}
+	}
+	return nil
+}
+
+// applyPromo takes a booking's promo code discount off its price. Codes the
+// booking handler has not checked are dropped. Callers must hold the mutex.
+func applyPromo(booking *Booking) {
+	booking.PromoDiscount = 0
+	index := promoIndex(booking.PromoCode)
+	if index < 0 || booking.Price == 0 {
+		booking.PromoCode = ""
+		return
+	}
+	promo := promos[index]
+	booking.PromoCode = promo.Code
+	if booking.ListPrice == 0 {
+		booking.ListPrice = booking.Price
+	}
+	if promo.Percent > 0 {
+		booking.PromoDiscount = int(math.Round(float64(booking.Price) * float64(promo.Percent) / 100))
+	} else {
+		booking.PromoDiscount = min(promo.AmountOff, booking.Price)
+	}
+	booking.Price -= booking.PromoDiscount
+}
+
+// recordPromoRedemption counts a booking against its promo code's caps.
+// Callers must hold the mutex.
+func recordPromoRedemption(booking Booking) error {
+	promoRedemptions = append(promoRedemptions, PromoRedemption{
+		ID:         promoRedemptionId,
+		Code:       booking.PromoCode,
+		MemberName: booking.MemberName,
+		BookingID:  booking.ID,
+		Discount:   booking.PromoDiscount,
+		Currency:   booking.Currency,
+		CreatedAt:  now(),
+	})
+	promoRedemptionId++
+	if err := writeDataToJsonFile("promo_redemptions.json", promoRedemptions); err != nil {
+		promoRedemptions = promoRedemptions[:len(promoRedemptions)-1]
+		promoRedemptionId--
+		return err
+	}
+	return nil
+}
+
+// Handler for listing and creating promo codes
+func promoHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		response := append([]Promo{}, promos...)
+		mutex.Unlock()
+		successResponse(w, http.StatusOK, "Promo codes retrieved successfully", map[string]interface{}{"promos": response})
+
+	case http.MethodPost:
+		var promo Promo
+		if err := decodeBody(r, &promo); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		normalizePromo(&promo)
+		if rejection := checkPromo(promo); rejection != nil {
+			errorResponse(w, rejection.StatusCode, rejection.Message)
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if promoIndex(promo.Code) >= 0 {
+			errorResponse(w, http.StatusConflict, "Promo code already exists")
+			return
+		}
+		promo.CreatedAt = now()
+		promo.UpdatedAt = nil
+		promos = append(promos, promo)
+		if err := writeDataToJsonFile("promos.json", promos); err != nil {
+			promos = promos[:len(promos)-1]
+			errorResponse(w, http.StatusInternalServerError, "Failed to save promo code data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "create", "promo", 0, nil, promo)
+
+		successResponse(w, http.StatusCreated, "Promo code created successfully", promo)
+		logData("Promo code created successfully", promo)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// Handler for reading, changing and removing a promo code. Past redemptions
+// are kept when a code is removed.
+func promoItemHandler(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(r.PathValue("code"))
+
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := promoIndex(code)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Promo code not found")
+			return
+		}
+		successResponse(w, http.StatusOK, "Promo code retrieved successfully", promos[index])
+
+	case http.MethodPut:
+		var request Promo
+		if err := decodeBody(r, &request); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		request.Code = code
+		normalizePromo(&request)
+		if rejection := checkPromo(request); rejection != nil {
+			errorResponse(w, rejection.StatusCode, rejection.Message)
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := promoIndex(code)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Promo code not found")
+			return
+		}
+		before := promos[index]
+		request.CreatedAt = before.CreatedAt
+		updatedAt := now()
+		request.UpdatedAt = &updatedAt
+		promos[index] = request
+		if err := writeDataToJsonFile("promos.json", promos); err != nil {
+			promos[index] = before
+			errorResponse(w, http.StatusInternalServerError, "Failed to save promo code data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "update", "promo", 0, before, request)
+
+		successResponse(w, http.StatusOK, "Promo code updated successfully", request)
+		logData("Promo code updated successfully", request)
+
+	case http.MethodDelete:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := promoIndex(code)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Promo code not found")
+			return
+		}
+		previous, before := promos, promos[index]
+		promos = append(append([]Promo{}, promos[:index]...), promos[index+1:]...)
+		if err := writeDataToJsonFile("promos.json", promos); err != nil {
+			promos = previous
+			errorResponse(w, http.StatusInternalServerError, "Failed to save promo code data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "delete", "promo", 0, before, nil)
+
+		successResponse(w, http.StatusOK, "Promo code deleted successfully", before)
+		logData("Promo code deleted successfully", before)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// promoCurrencyTotals sums a promo code's redemptions in one currency
+type promoCurrencyTotals struct {
+	Currency string `json:"currency"`
+	Discount int    `json:"discount"` // Given away, in minor units
+	Revenue  int    `json:"revenue"`  // Charged for the bookings, tax included
+}
+
+// Handler for a promo code's redemptions, so campaigns can be measured
+func promoRedemptionsHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+	code := strings.ToUpper(r.PathValue("code"))
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := promoIndex(code)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Promo code not found")
+		return
+	}
+
+	redemptions := []PromoRedemption{}
+	memberNames := map[string]bool{}
+	totals := map[string]*promoCurrencyTotals{}
+	cancelled := 0
+	for _, redemption := range promoRedemptions {
+		if redemption.Code != code {
+			continue
+		}
+		redemptions = append(redemptions, redemption)
+		memberNames[redemption.MemberName] = true
+		if totals[redemption.Currency] == nil {
+			totals[redemption.Currency] = &promoCurrencyTotals{Currency: redemption.Currency}
+		}
+		totals[redemption.Currency].Discount += redemption.Discount
+		if booking := bookingIndex(redemption.BookingID); booking >= 0 {
+			if bookingActive(bookings[booking]) {
+				totals[redemption.Currency].Revenue += max(bookings[booking].Total, bookings[booking].Price)
+			} else {
+				cancelled++
+			}
+		}
+	}
+	byCurrency := []promoCurrencyTotals{}
+	for _, total := range totals {
+		byCurrency = append(byCurrency, *total)
+	}
+	sort.Slice(byCurrency, func(i, j int) bool { return byCurrency[i].Currency < byCurrency[j].Currency })
+
+	response := map[string]interface{}{
+		"promo":       promos[index],
+		"redemptions": redemptions,
+		"count":       len(redemptions),
+		"members":     len(memberNames),
+		"cancelled":   cancelled,
+		"totals":      byCurrency,
+	}
+	if promos[index].MaxRedemptions > 0 {
+		response["remaining"] = max(promos[index].MaxRedemptions-len(redemptions), 0)
+	}
+	successResponse(w, http.StatusOK, "Promo code redemptions retrieved successfully", response)
+}
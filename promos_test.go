@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestPromoValidation verifies malformed and duplicate promo codes are rejected.
+func TestPromoValidation(t *testing.T) {
+	setupTestEnvironment()
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"Percent", `{"code":"spring10","percent":10,"maxPerMember":1}`, http.StatusCreated},
+		{"Amount Off", `{"code":"FIVE","amountOff":500,"currency":"eur","expiresOn":"31-12-2099"}`, http.StatusCreated},
+		{"Duplicate", `{"code":"Spring10","percent":20}`, http.StatusConflict},
+		{"Both Discounts", `{"code":"BOTH","percent":10,"amountOff":500,"currency":"EUR"}`, http.StatusBadRequest},
+		{"Too Much", `{"code":"FREE","percent":150}`, http.StatusBadRequest},
+		{"No Currency", `{"code":"CASH","amountOff":500}`, http.StatusBadRequest},
+		{"Negative Cap", `{"code":"CAP","percent":10,"maxRedemptions":-1}`, http.StatusBadRequest},
+		{"Bad Expiry", `{"code":"LATE","percent":10,"expiresOn":"2099-12-31"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			promoHandler(rec, httptest.NewRequest(http.MethodPost, "/admin/promos", bytes.NewReader([]byte(tt.body))))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+	if len(promos) != 2 || promos[0].Code != "SPRING10" || promos[1].Currency != "EUR" {
+		t.Errorf("expected two normalized codes, got %+v", promos)
+	}
+}
+
+// TestPromoRedemption verifies promo codes discount bookings within their caps and are reported.
+func TestPromoRedemption(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2099, 12, 1, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	classes = []Class{
+		{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("20-12-2099"), Capacity: 10, Price: 2000, Currency: "EUR"},
+		{ID: 2, ClassName: "Spin", StartDate: testDate("01-12-2099"), EndDate: testDate("20-12-2099"), Capacity: 10, Price: 1500, Currency: "USD"},
+		{ID: 3, ClassName: "Open Gym", StartDate: testDate("01-12-2099"), EndDate: testDate("20-12-2099"), Capacity: 10},
+	}
+	classId = 4
+	promos = []Promo{
+		{Code: "SPRING10", Percent: 10, MaxRedemptions: 3, MaxPerMember: 1},
+		{Code: "FIVE", AmountOff: 500, Currency: "EUR"},
+		{Code: "OLD", Percent: 50, ExpiresOn: "30-11-2099"},
+	}
+
+	tests := []struct {
+		name           string
+		member         string
+		className      string
+		code           string
+		expectedStatus int
+		price          int
+	}{
+		{"Percent Off", "Ann", "Yoga", "spring10", http.StatusCreated, 1800},
+		{"Once Per Member", "Ann", "Yoga", "SPRING10", http.StatusConflict, 0},
+		{"Other Currency", "Ben", "Spin", "SPRING10", http.StatusCreated, 1350},
+		{"Amount Off", "Ben", "Yoga", "FIVE", http.StatusCreated, 1500},
+		{"Wrong Currency", "Cat", "Spin", "FIVE", http.StatusBadRequest, 0},
+		{"Expired", "Cat", "Yoga", "OLD", http.StatusBadRequest, 0},
+		{"Unknown", "Cat", "Yoga", "NOPE", http.StatusBadRequest, 0},
+		{"Free Class", "Cat", "Open Gym", "FIVE", http.StatusBadRequest, 0},
+		{"Last Redemption", "Cat", "Yoga", "SPRING10", http.StatusCreated, 1800},
+		{"Fully Redeemed", "Dan", "Yoga", "SPRING10", http.StatusConflict, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count := len(bookings)
+			body := fmt.Sprintf(`{"memberName":%q,"className":%q,"date":"05-12-2099","promoCode":%q,"promoDiscount":9999}`, tt.member, tt.className, tt.code)
+			rec := httptest.NewRecorder()
+			bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(body))))
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+			if tt.expectedStatus != http.StatusCreated {
+				if len(bookings) != count {
+					t.Errorf("expected no booking, got %+v", bookings[count:])
+				}
+				return
+			}
+			booking := bookings[len(bookings)-1]
+			if booking.Price != tt.price || booking.PromoDiscount != booking.ListPrice-tt.price || booking.PromoCode != promos[promoIndex(tt.code)].Code {
+				t.Errorf("expected a price of %d, got %+v", tt.price, booking)
+			}
+		})
+	}
+	if len(promoRedemptions) != 4 {
+		t.Fatalf("expected four redemptions, got %+v", promoRedemptions)
+	}
+
+	receipt := newReceipt(bookings[2])
+	if receipt.Items[0].Amount != 2000 || len(receipt.Discounts) != 1 || receipt.Discounts[0].Amount != -500 || receipt.Total.Amount != 1500 {
+		t.Errorf("expected the promo discount on the receipt, got %+v", receipt)
+	}
+
+	bookings[0].Status = bookingStatusCancelled
+	req := httptest.NewRequest(http.MethodGet, "/admin/promos/spring10/redemptions", nil)
+	req.SetPathValue("code", "spring10")
+	rec := httptest.NewRecorder()
+	promoRedemptionsHandler(rec, req)
+	var response struct {
+		Data struct {
+			Count     int                   `json:"count"`
+			Members   int                   `json:"members"`
+			Cancelled int                   `json:"cancelled"`
+			Remaining int                   `json:"remaining"`
+			Totals    []promoCurrencyTotals `json:"totals"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected the report, got %d: %v", rec.Code, err)
+	}
+	report := response.Data
+	if report.Count != 3 || report.Members != 3 || report.Cancelled != 1 || report.Remaining != 0 || len(report.Totals) != 2 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if eur := report.Totals[0]; eur.Currency != "EUR" || eur.Discount != 400 || eur.Revenue != 1800 {
+		t.Errorf("expected the EUR totals to leave out the cancelled booking's revenue, got %+v", eur)
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// Messages of api.proto, for internal callers that send and accept
+// application/x-protobuf. Only the core endpoints have messages; other
+// endpoints refuse protobuf bodies and answer in JSON.
+
+// protoField is a field read from a protobuf message
+type protoField struct {
+	number int
+	varint uint64
+	bytes  []byte
+}
+
+// errNoProtoMessage reports a request or response api.proto has no message for
+var errNoProtoMessage = errors.New("no protobuf message for this data")
+
+// isProtobufContent reports whether a request body is a protobuf message
+func isProtobufContent(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == formatProtobuf
+}
+
+// readProtoFields splits a protobuf message into its fields, skipping the
+// fixed-size ones api.proto does not use
+func readProtoFields(message []byte) ([]protoField, error) {
+	var fields []protoField
+	for len(message) > 0 {
+		key, n := binary.Uvarint(message)
+		if n <= 0 {
+			return nil, errors.New("invalid protobuf field key")
+		}
+		message = message[n:]
+		field := protoField{number: int(key >> 3)}
+		switch key & 7 {
+		case 0:
+			value, n := binary.Uvarint(message)
+			if n <= 0 {
+				return nil, errors.New("invalid protobuf varint")
+			}
+			field.varint, message = value, message[n:]
+		case 1, 5:
+			size := 8
+			if key&7 == 5 {
+				size = 4
+			}
+			if len(message) < size {
+				return nil, errors.New("truncated protobuf field")
+			}
+			message = message[size:]
+			continue
+		case 2:
+			length, n := binary.Uvarint(message)
+			if n <= 0 || uint64(len(message)-n) < length {
+				return nil, errors.New("truncated protobuf field")
+			}
+			field.bytes, message = message[n:n+int(length)], message[n+int(length):]
+		default:
+			return nil, errors.New("unsupported protobuf wire type")
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// appendProtoMessage appends an embedded message, kept even when empty so a
+// oneof or list entry is still there
+func appendProtoMessage(message []byte, field int, value []byte) []byte {
+	message = binary.AppendUvarint(message, uint64(field)<<3|2)
+	message = binary.AppendUvarint(message, uint64(len(value)))
+	return append(message, value...)
+}
+
+// encodeProtoClass encodes the Class message of a class
+func encodeProtoClass(class Class) []byte {
+	var message []byte
+	message = appendProtoVarint(message, 1, uint64(class.ID))
+	message = appendProtoBytes(message, 2, []byte(class.ClassName))
+	message = appendProtoBytes(message, 3, []byte(class.StartDate.String()))
+	message = appendProtoBytes(message, 4, []byte(class.EndDate.String()))
+	message = appendProtoVarint(message, 5, uint64(class.Capacity))
+	message = appendProtoVarint(message, 6, uint64(class.DurationMinutes))
+	message = appendProtoBytes(message, 7, []byte(class.StartTime))
+	message = appendProtoBytes(message, 8, []byte(class.Room))
+	message = appendProtoVarint(message, 9, uint64(class.StudioID))
+	message = appendProtoVarint(message, 10, uint64(class.Price))
+	message = appendProtoBytes(message, 11, []byte(class.Currency))
+	message = appendProtoBytes(message, 12, []byte(class.Instructor))
+	message = appendProtoBytes(message, 13, []byte(class.Category))
+	message = appendProtoBytes(message, 14, []byte(class.Level))
+	for _, tag := range class.Tags {
+		message = appendProtoMessage(message, 15, []byte(tag))
+	}
+	return message
+}
+
+// decodeProtoClass sets the fields of a class sent in a Class message,
+// refusing dates not in DD-MM-YYYY
+func decodeProtoClass(fields []protoField, class *Class) error {
+	for _, field := range fields {
+		switch field.number {
+		case 1:
+			class.ID = int(field.varint)
+		case 2:
+			class.ClassName = string(field.bytes)
+		case 3:
+			if err := class.StartDate.UnmarshalText(field.bytes); err != nil {
+				return err
+			}
+		case 4:
+			if err := class.EndDate.UnmarshalText(field.bytes); err != nil {
+				return err
+			}
+		case 5:
+			class.Capacity = int(field.varint)
+		case 6:
+			class.DurationMinutes = int(field.varint)
+		case 7:
+			class.StartTime = string(field.bytes)
+		case 8:
+			class.Room = string(field.bytes)
+		case 9:
+			class.StudioID = int(field.varint)
+		case 10:
+			class.Price = int(field.varint)
+		case 11:
+			class.Currency = string(field.bytes)
+		case 12:
+			class.Instructor = string(field.bytes)
+		case 13:
+			class.Category = string(field.bytes)
+		case 14:
+			class.Level = string(field.bytes)
+		case 15:
+			class.Tags = append(class.Tags, string(field.bytes))
+		}
+	}
+	return nil
+}
+
+// encodeProtoBooking encodes the Booking message of a booking
+func encodeProtoBooking(booking Booking) []byte {
+	var message []byte
+	message = appendProtoVarint(message, 1, uint64(booking.ID))
+	message = appendProtoBytes(message, 2, []byte(booking.Code))
+	message = appendProtoBytes(message, 3, []byte(booking.MemberName))
+	message = appendProtoBytes(message, 4, []byte(booking.Date.String()))
+	message = appendProtoBytes(message, 5, []byte(booking.ClassName))
+	message = appendProtoBytes(message, 6, []byte(booking.Status))
+	message = appendProtoVarint(message, 7, uint64(booking.ClassID))
+	message = appendProtoVarint(message, 8, uint64(booking.SessionID))
+	message = appendProtoVarint(message, 9, uint64(booking.Price))
+	message = appendProtoBytes(message, 10, []byte(booking.Currency))
+	message = appendProtoVarint(message, 11, uint64(booking.Total))
+	message = appendProtoBytes(message, 12, []byte(booking.PromoCode))
+	return message
+}
+
+// decodeProtoBooking sets the fields of a booking sent in a Booking message,
+// refusing a date not in DD-MM-YYYY. Fields set by the server, such as the
+// price, are read like the JSON ones and left for the handler to overwrite.
+func decodeProtoBooking(fields []protoField, booking *Booking) error {
+	for _, field := range fields {
+		switch field.number {
+		case 1:
+			booking.ID = int(field.varint)
+		case 2:
+			booking.Code = string(field.bytes)
+		case 3:
+			booking.MemberName = string(field.bytes)
+		case 4:
+			if err := booking.Date.UnmarshalText(field.bytes); err != nil {
+				return err
+			}
+		case 5:
+			booking.ClassName = string(field.bytes)
+		case 6:
+			booking.Status = string(field.bytes)
+		case 7:
+			booking.ClassID = int(field.varint)
+		case 8:
+			booking.SessionID = int(field.varint)
+		case 9:
+			booking.Price = int(field.varint)
+		case 10:
+			booking.Currency = string(field.bytes)
+		case 11:
+			booking.Total = int(field.varint)
+		case 12:
+			booking.PromoCode = string(field.bytes)
+		}
+	}
+	return nil
+}
+
+// decodeProtobufBody reads a Class or Booking message into v. Bodies of
+// other requests have no message and are refused.
+func decodeProtobufBody(r *http.Request, v interface{}) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if len(body) == 0 {
+		return io.EOF
+	}
+	fields, err := readProtoFields(body)
+	if err != nil {
+		return err
+	}
+	switch target := v.(type) {
+	case *Class:
+		return decodeProtoClass(fields, target)
+	case *Booking:
+		return decodeProtoBooking(fields, target)
+	default:
+		return errNoProtoMessage
+	}
+}
+
+// encodeProtoData adds response data to a Response message: a class, a
+// booking, or a list of either, along with the slots left and list total
+// sent next to them
+func encodeProtoData(response []byte, data interface{}) ([]byte, error) {
+	switch value := data.(type) {
+	case nil:
+		return response, nil
+	case Class:
+		return appendProtoMessage(response, 2, encodeProtoClass(value)), nil
+	case Booking:
+		return appendProtoMessage(response, 3, encodeProtoBooking(value)), nil
+	case []Class:
+		var list []byte
+		for _, class := range value {
+			list = appendProtoMessage(list, 1, encodeProtoClass(class))
+		}
+		return appendProtoMessage(response, 4, list), nil
+	case []classListing:
+		var list []byte
+		for _, listing := range value {
+			list = appendProtoMessage(list, 1, encodeProtoClass(listing.Class))
+		}
+		return appendProtoMessage(response, 4, list), nil
+	case []Booking:
+		var list []byte
+		for _, booking := range value {
+			list = appendProtoMessage(list, 1, encodeProtoBooking(booking))
+		}
+		return appendProtoMessage(response, 5, list), nil
+	case map[string]interface{}:
+		// Responses holding a record next to other figures send the record
+		// and the figures the schema has; a list of bookings or classes is
+		// the one of its kind
+		found := false
+		for _, key := range []string{"class", "booking", "classes", "bookings"} {
+			if item, ok := value[key]; ok {
+				var err error
+				if response, err = encodeProtoData(response, item); err == nil {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			return nil, errNoProtoMessage
+		}
+		if slots, ok := value["availableSlots"].(int); ok {
+			response = appendProtoVarint(response, 6, uint64(slots))
+		}
+		if total, ok := value["total"].(int); ok {
+			response = appendProtoVarint(response, 7, uint64(total))
+		}
+		return response, nil
+	}
+	return nil, errNoProtoMessage
+}
+
+// writeProtobuf writes a buffered response as a Response message. Errors
+// carry their message alone; data the schema has no message for is refused,
+// and the response is sent as JSON instead.
+func writeProtobuf(w io.Writer, recorder *bufferedResponseWriter) error {
+	var envelope struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(recorder.body.Bytes(), &envelope); err != nil {
+		return err
+	}
+	response := appendProtoBytes(nil, 1, []byte(envelope.Message))
+	if recorder.status < http.StatusBadRequest {
+		if !recorder.hasData {
+			return errNoProtoMessage
+		}
+		var err error
+		if response, err = encodeProtoData(response, recorder.data); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(response)
+	return err
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// protobufRequest sends a protobuf request through content negotiation
+func protobufRequest(handler http.HandlerFunc, method, target string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, bytes.NewReader(body))
+	req.Header.Set("Accept", formatProtobuf)
+	if body != nil {
+		req.Header.Set("Content-Type", formatProtobuf)
+	}
+	rec := httptest.NewRecorder()
+	negotiateContent(handler).ServeHTTP(rec, req)
+	return rec
+}
+
+// protoFieldMap reads a message's fields by number, keeping the last of each
+func protoFieldMap(t *testing.T, message []byte) map[int]protoField {
+	fields, err := readProtoFields(message)
+	if err != nil {
+		t.Fatalf("expected a valid protobuf message, got %v", err)
+	}
+	byNumber := map[int]protoField{}
+	for _, field := range fields {
+		byNumber[field.number] = field
+	}
+	return byNumber
+}
+
+// TestProtobufClassAndBooking verifies classes and bookings can be created and read back as protobuf messages.
+func TestProtobufClassAndBooking(t *testing.T) {
+	setupTestEnvironment()
+
+	class := encodeProtoClass(Class{ClassName: "Pilates", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10, StartTime: "09:00"})
+	rec := protobufRequest(classHandler, http.MethodPost, "/classes", class)
+	if rec.Code != http.StatusCreated || rec.Header().Get("Content-Type") != formatProtobuf {
+		t.Fatalf("expected the class to be created, got %d %q: %q", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	if len(classes) != 1 || classes[0].ClassName != "Pilates" || classes[0].Capacity != 10 || classes[0].StartTime != "09:00" {
+		t.Errorf("expected the class to be read from the message, got %+v", classes)
+	}
+	response := protoFieldMap(t, rec.Body.Bytes())
+	created := protoFieldMap(t, response[2].bytes)
+	if string(response[1].bytes) != "Class created successfully" || created[1].varint != 1 || string(created[2].bytes) != "Pilates" {
+		t.Errorf("expected the created class in the response, got %+v", response)
+	}
+
+	booking := encodeProtoBooking(Booking{MemberName: "Rahul R P", Date: testDate("16-12-2099"), ClassName: "Pilates"})
+	rec = protobufRequest(bookingHandler, http.MethodPost, "/bookings", booking)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the booking to be made, got %d: %q", rec.Code, rec.Body.String())
+	}
+	response = protoFieldMap(t, rec.Body.Bytes())
+	booked := protoFieldMap(t, response[3].bytes)
+	if response[6].varint != 9 || string(booked[3].bytes) != "Rahul R P" || booked[7].varint != 1 || string(booked[6].bytes) != bookingStatusConfirmed {
+		t.Errorf("expected the booking and the slots left, got %+v / %+v", response, booked)
+	}
+
+	rec = protobufRequest(classHandler, http.MethodGet, "/classes", nil)
+	response = protoFieldMap(t, rec.Body.Bytes())
+	list, _ := readProtoFields(response[4].bytes)
+	if len(list) != 1 || response[7].varint != 1 {
+		t.Errorf("expected the list of classes and its total, got %+v", response)
+	}
+}
+
+// TestProtobufFallbacks verifies errors stay protobuf while endpoints without messages refuse protobuf or answer in JSON.
+func TestProtobufFallbacks(t *testing.T) {
+	setupTestEnvironment()
+
+	rec := protobufRequest(bookingHandler, http.MethodPost, "/bookings", encodeProtoBooking(Booking{MemberName: "Ann", Date: testDate("16-12-2099"), ClassName: "Nothing"}))
+	response := protoFieldMap(t, rec.Body.Bytes())
+	if rec.Code != http.StatusBadRequest || string(response[1].bytes) != "Class is not available on the specified date" {
+		t.Errorf("expected the refusal as a protobuf message, got %d: %q", rec.Code, rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/extra-sessions/1/reject", bytes.NewReader([]byte{0x0a, 0x01, 'x'}))
+	req.Header.Set("Content-Type", formatProtobuf)
+	req.SetPathValue("id", "1")
+	req.SetPathValue("action", "reject")
+	rec = httptest.NewRecorder()
+	decideExtraSessionHandler(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected a body without a message to be refused, got %d", rec.Code)
+	}
+
+	rec = protobufRequest(extraSessionsHandler, http.MethodGet, "/admin/extra-sessions", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != formatJSON || !bytes.Contains(rec.Body.Bytes(), []byte(`"extraSessions"`)) {
+		t.Errorf("expected JSON from an endpoint without a message, got %q: %s", rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+
+	fields, _ := readProtoFields(encodeProtoClass(Class{Tags: []string{"core", "", "mat"}}))
+	var tagged Class
+	decodeProtoClass(fields, &tagged)
+	if len(tagged.Tags) != 3 || tagged.Tags[2] != "mat" {
+		t.Errorf("expected repeated tags to be kept in order, got %v", tagged.Tags)
+	}
+	if _, err := readProtoFields([]byte{0x12, 0x05, 'a'}); err == nil {
+		t.Error("expected a truncated message to be refused")
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// PushNotification is a message shown on a member's device
+type PushNotification struct {
+	Title string            `json:"title"`
+	Body  string            `json:"body"`
+	Data  map[string]string `json:"data,omitempty"` // Passed to the app, e.g. the booking ID
+}
+
+// PushProvider delivers push notifications, e.g. through FCM or APNs.
+// Send returns errPushTokenInvalid when the device is no longer registered.
+type PushProvider interface {
+	Send(device Device, notification PushNotification) error
+}
+
+// errPushTokenInvalid reports a device token the provider no longer accepts
+var errPushTokenInvalid = errors.New("push token is no longer valid")
+
+// logPushProvider writes push notifications to the API log, until a real provider is configured
+type logPushProvider struct{}
+
+// Send logs the notification
+func (logPushProvider) Send(device Device, notification PushNotification) error {
+	logData("Push notification sent", map[string]interface{}{"deviceId": device.ID, "platform": device.Platform, "notification": notification})
+	return nil
+}
+
+// pushProvider delivers push notifications
+var pushProvider PushProvider = logPushProvider{}
+
+// Device platforms
+const (
+	platformIOS     = "ios"
+	platformAndroid = "android"
+)
+
+// Device is a member's mobile device registered for push notifications
+type Device struct {
+	ID           int       `json:"id"`
+	MemberName   string    `json:"memberName"`
+	Token        string    `json:"token"`
+	Platform     string    `json:"platform"`
+	RegisteredAt time.Time `json:"registeredAt"`
+}
+
+var (
+	devices  []Device // Temp Slice to hold registered devices
+	deviceId = 1      // Incremental ID for devices
+)
+
+// pushToMember sends a notification to each of a member's devices, forgetting
+// devices whose token the provider rejects. Callers must hold the mutex.
+func pushToMember(memberName string, notification PushNotification) {
+	kept := devices[:0:0]
+	for _, device := range devices {
+		if device.MemberName == memberName {
+			if err := pushProvider.Send(device, notification); errors.Is(err, errPushTokenInvalid) {
+				continue
+			} else if err != nil {
+				fmt.Println("Error sending push notification:", err)
+			}
+		}
+		kept = append(kept, device)
+	}
+	if len(kept) == len(devices) {
+		return
+	}
+	devices = kept
+	if err := writeDataToJsonFile("devices.json", devices); err != nil {
+		fmt.Println("Error saving devices:", err)
+	}
+}
+
+// Handler for listing and registering a member's devices
+func memberDevicesHandler(w http.ResponseWriter, r *http.Request) {
+	memberName := r.PathValue("name")
+	if memberName == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid member name")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		registered := []Device{}
+		for _, device := range devices {
+			if device.MemberName == memberName {
+				registered = append(registered, device)
+			}
+		}
+		mutex.Unlock()
+		successResponse(w, http.StatusOK, "Devices retrieved successfully", map[string]interface{}{"devices": registered})
+
+	case http.MethodPost:
+		var device Device
+		if err := decodeBody(r, &device); err != nil || device.Token == "" {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if device.Platform != platformIOS && device.Platform != platformAndroid {
+			errorResponse(w, http.StatusBadRequest, "Invalid platform, use ios or android")
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		// A token belongs to one device, so registering it again moves it to this member
+		before := slices.Clone(devices)
+		for i, existing := range devices {
+			if existing.Token == device.Token {
+				devices = append(devices[:i], devices[i+1:]...)
+				break
+			}
+		}
+		device.ID = deviceId
+		device.MemberName = memberName
+		device.RegisteredAt = now()
+		deviceId++
+		devices = append(devices, device)
+
+		if err := writeDataToJsonFile("devices.json", devices); err != nil {
+			devices = before
+			deviceId--
+			errorResponse(w, http.StatusInternalServerError, "Failed to save device data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "create", "device", device.ID, nil, device)
+
+		successResponse(w, http.StatusCreated, "Device registered successfully", device)
+		logData("Device registered successfully", device)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// Handler for unregistering a device
+func memberDeviceHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is DELETE
+	if r.Method != http.MethodDelete {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid device id")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for i, device := range devices {
+		if device.ID != id || device.MemberName != r.PathValue("name") {
+			continue
+		}
+		devices = append(devices[:i], devices[i+1:]...)
+		if err := writeDataToJsonFile("devices.json", devices); err != nil {
+			devices = append(devices[:i], append([]Device{device}, devices[i:]...)...)
+			errorResponse(w, http.StatusInternalServerError, "Failed to save device data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "delete", "device", id, device, nil)
+
+		successResponse(w, http.StatusOK, "Device removed successfully", device)
+		logData("Device removed successfully", device)
+		return
+	}
+	errorResponse(w, http.StatusNotFound, "Device not found")
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// recordingPushProvider captures push notifications and rejects listed tokens
+type recordingPushProvider struct {
+	sent    []PushNotification
+	invalid map[string]bool
+}
+
+// Send records the notification
+func (p *recordingPushProvider) Send(device Device, notification PushNotification) error {
+	if p.invalid[device.Token] {
+		return errPushTokenInvalid
+	}
+	p.sent = append(p.sent, notification)
+	return nil
+}
+
+// TestPushNotifications verifies device registration and delivery through the provider.
+func TestPushNotifications(t *testing.T) {
+	setupTestEnvironment()
+	members = []Member{{Name: "Ann"}}
+	provider := &recordingPushProvider{invalid: map[string]bool{"stale-token": true}}
+	defer func(previous PushProvider) { pushProvider = previous }(pushProvider)
+	pushProvider = provider
+
+	register := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/members/Ann/devices", strings.NewReader(body))
+		req.SetPathValue("name", "Ann")
+		rec := httptest.NewRecorder()
+		memberDevicesHandler(rec, req)
+		return rec.Code
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		statusCode int
+	}{
+		{name: "Missing Token", body: `{"platform":"ios"}`, statusCode: http.StatusBadRequest},
+		{name: "Unknown Platform", body: `{"token":"abc","platform":"palm"}`, statusCode: http.StatusBadRequest},
+		{name: "Phone", body: `{"token":"phone-token","platform":"ios"}`, statusCode: http.StatusCreated},
+		{name: "Stale Tablet", body: `{"token":"stale-token","platform":"android"}`, statusCode: http.StatusCreated},
+		{name: "Phone Again", body: `{"token":"phone-token","platform":"ios"}`, statusCode: http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := register(tt.body); code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, code)
+			}
+		})
+	}
+	if len(devices) != 2 {
+		t.Fatalf("expected re-registering a token to replace it, got %+v", devices)
+	}
+
+	mutex.Lock()
+	notifyBooking(notifyWaitlistPromoted, Booking{ID: 7, MemberName: "Ann", ClassName: "Yoga", Date: testDate("15-12-2099")})
+	mutex.Unlock()
+
+	if len(provider.sent) != 1 || provider.sent[0].Data["bookingId"] != "7" || provider.sent[0].Data["event"] != notifyWaitlistPromoted {
+		t.Errorf("expected one push for the promotion, got %+v", provider.sent)
+	}
+	if len(devices) != 1 || devices[0].Token != "phone-token" {
+		t.Errorf("expected the rejected device to be forgotten, got %+v", devices)
+	}
+
+	// Unregistering
+	req := httptest.NewRequest(http.MethodDelete, "/members/Ann/devices/3", nil)
+	req.SetPathValue("name", "Ann")
+	req.SetPathValue("id", "3")
+	rec := httptest.NewRecorder()
+	memberDeviceHandler(rec, req)
+	if rec.Code != http.StatusOK || len(devices) != 0 {
+		t.Errorf("expected the device to be removed, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	bookingAttempts = map[string][]time.Time{} // Recent booking attempts per member
+	rateMutex       sync.Mutex                 // Guards bookingAttempts
+)
+
+// allowBookingAttempt records a member's attempt to take a slot and reports
+// whether it is within config.BookingRateLimit per window. When it is not,
+// it returns how long until the oldest attempt leaves the window. Handlers
+// call it once the request itself is valid, so every well-formed attempt
+// counts, including those then refused because the class is full.
+func allowBookingAttempt(memberName string) (bool, time.Duration) {
+	if config.BookingRateLimit <= 0 || config.BookingRateWindowSeconds <= 0 {
+		return true, 0
+	}
+	window := time.Duration(config.BookingRateWindowSeconds) * time.Second
+
+	rateMutex.Lock()
+	defer rateMutex.Unlock()
+
+	// Forget attempts that have left the window
+	recent := []time.Time{}
+	for _, attempt := range bookingAttempts[memberName] {
+		if now().Sub(attempt) < window {
+			recent = append(recent, attempt)
+		}
+	}
+	if len(recent) >= config.BookingRateLimit {
+		bookingAttempts[memberName] = recent
+		return false, window - now().Sub(recent[0])
+	}
+	bookingAttempts[memberName] = append(recent, now())
+	return true, 0
+}
+
+// rateLimited replies 429 when a member is booking too fast and reports whether it did
+func rateLimited(w http.ResponseWriter, memberName string) bool {
+	allowed, retryAfter := allowBookingAttempt(memberName)
+	if allowed {
+		return false
+	}
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	errorResponse(w, http.StatusTooManyRequests, "Too many booking attempts, try again in "+strconv.Itoa(seconds)+" seconds")
+	return true
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestBookingRateLimit verifies each member can only attempt so many bookings per window.
+func TestBookingRateLimit(t *testing.T) {
+	setupTestEnvironment()
+	defer func() { now = time.Now }()
+	start := time.Now()
+	now = func() time.Time { return start }
+	config.BookingRateLimit, config.BookingRateWindowSeconds = 2, 60
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10}}
+	classId = 2
+
+	book := func(member, date string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"`+member+`","className":"Yoga","date":"`+date+`"}`))))
+		return rec
+	}
+
+	tests := []struct {
+		name       string
+		member     string
+		date       string
+		after      time.Duration
+		statusCode int
+	}{
+		{name: "First", member: "Ann", date: "10-12-2099", statusCode: http.StatusCreated},
+		{name: "Failed Attempt Counts", member: "Ann", date: "01-01-2100", statusCode: http.StatusBadRequest},
+		{name: "Over The Limit", member: "Ann", date: "11-12-2099", statusCode: http.StatusTooManyRequests},
+		{name: "Other Member", member: "Ben", date: "11-12-2099", statusCode: http.StatusCreated},
+		{name: "Window Passed", member: "Ann", date: "11-12-2099", after: time.Minute, statusCode: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = func() time.Time { return start.Add(tt.after) }
+			rec := book(tt.member, tt.date)
+			if rec.Code != tt.statusCode {
+				t.Fatalf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+			if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") != "60" {
+				t.Errorf("expected a Retry-After header, got %q", rec.Header().Get("Retry-After"))
+			}
+		})
+	}
+
+	// Malformed requests are refused before they count
+	rec := httptest.NewRecorder()
+	holdHandler(rec, httptest.NewRequest(http.MethodPost, "/holds", bytes.NewReader([]byte(`{"memberName":"Ann","className":"Yoga","date":"12-12-2099","minutes":500}`))))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status code %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+	if rec := book("Ann", "12-12-2099"); rec.Code != http.StatusCreated {
+		t.Errorf("expected the second attempt in the window to be allowed, got %d", rec.Code)
+	}
+}
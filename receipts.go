@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// monthLayout is the MM-YYYY format invoices are requested by
+const monthLayout = "01-2006"
+
+// ReceiptLine is one amount on a receipt or invoice
+type ReceiptLine struct {
+	Description string `json:"description"`
+	Amount      int    `json:"amount"`    // In minor units
+	Formatted   string `json:"formatted"` // Amount written for its currency
+}
+
+// Receipt itemises what a member paid for a booking
+type Receipt struct {
+	Number           string        `json:"number"`
+	BookingID        int           `json:"bookingId"`
+	BookingCode      string        `json:"bookingCode,omitempty"`
+	MemberName       string        `json:"memberName"`
+	Date             Date          `json:"date"`
+	Status           string        `json:"status"`
+	IssuedAt         time.Time     `json:"issuedAt"`
+	Currency         string        `json:"currency,omitempty"`
+	Items            []ReceiptLine `json:"items"`
+	Discounts        []ReceiptLine `json:"discounts"` // Negative amounts taken off the items
+	Subtotal         ReceiptLine   `json:"subtotal"`
+	Tax              *ReceiptLine  `json:"tax,omitempty"`
+	Total            ReceiptLine   `json:"total"`
+	GiftCard         *ReceiptLine  `json:"giftCard,omitempty"` // Part of the total paid by gift card
+	PaymentReference string        `json:"paymentReference,omitempty"`
+}
+
+// invoiceTotal sums an invoice's receipts in one currency
+type invoiceTotal struct {
+	Currency string      `json:"currency"`
+	Subtotal ReceiptLine `json:"subtotal"`
+	Tax      ReceiptLine `json:"tax"`
+	Total    ReceiptLine `json:"total"`
+}
+
+// Invoice rolls up a member's receipts for a month
+type Invoice struct {
+	MemberName string         `json:"memberName"`
+	Month      string         `json:"month"`
+	IssuedAt   time.Time      `json:"issuedAt"`
+	Receipts   []Receipt      `json:"receipts"`
+	Totals     []invoiceTotal `json:"totals"` // One per currency, as prices may differ by studio
+}
+
+// receiptLine builds a line with its amount formatted for the currency
+func receiptLine(description string, amount int, currency string) ReceiptLine {
+	return ReceiptLine{Description: description, Amount: amount, Formatted: formatAmount(amount, currency)}
+}
+
+// receiptIssued reports whether a booking has been paid for, so a receipt
+// can be issued for it
+func receiptIssued(booking Booking) bool {
+	status := bookingStatus(booking)
+	return status == bookingStatusConfirmed || status == bookingStatusAttended
+}
+
+// newReceipt itemises a booking. Callers must hold the mutex.
+func newReceipt(booking Booking) Receipt {
+	receipt := Receipt{
+		Number:           fmt.Sprintf("R-%06d", booking.ID),
+		BookingID:        booking.ID,
+		BookingCode:      booking.Code,
+		MemberName:       booking.MemberName,
+		Date:             booking.Date,
+		Status:           bookingStatus(booking),
+		IssuedAt:         now(),
+		Currency:         booking.Currency,
+		Items:            []ReceiptLine{receiptLine(bookingTitle(booking)+" on "+booking.Date.String(), booking.Price+booking.PromoDiscount, booking.Currency)},
+		Discounts:        []ReceiptLine{},
+		Subtotal:         receiptLine("Subtotal", booking.Price, booking.Currency),
+		Total:            receiptLine("Total", booking.Price, booking.Currency),
+		PaymentReference: booking.PaymentReference,
+	}
+	// Discounted bookings list the class at its list price, less the discounts
+	priced := booking.Price + booking.PromoDiscount
+	if booking.ListPrice > priced {
+		var reasons []string
+		if booking.EarlyBird {
+			reasons = append(reasons, "Early-bird price")
+		}
+		if booking.PricingRule != nil {
+			reasons = append(reasons, booking.PricingRule.Name)
+		}
+		receipt.Items[0] = receiptLine(bookingTitle(booking)+" on "+booking.Date.String(), booking.ListPrice, booking.Currency)
+		receipt.Discounts = append(receipt.Discounts, receiptLine(strings.Join(reasons, ", "), priced-booking.ListPrice, booking.Currency))
+	}
+	if booking.PromoDiscount > 0 {
+		receipt.Discounts = append(receipt.Discounts, receiptLine("Promo code "+booking.PromoCode, -booking.PromoDiscount, booking.Currency))
+	}
+	if booking.Tax != nil {
+		line := receiptLine(fmt.Sprintf("%s %s%%", booking.Tax.Name, strconv.FormatFloat(booking.Tax.RatePercent, 'f', -1, 64)), booking.Tax.Amount, booking.Currency)
+		receipt.Tax = &line
+		receipt.Total = receiptLine("Total", booking.Total, booking.Currency)
+	}
+	if booking.GiftCardAmount > 0 {
+		line := receiptLine("Paid by gift card", booking.GiftCardAmount, booking.Currency)
+		receipt.GiftCard = &line
+	}
+	return receipt
+}
+
+// pdfText escapes a line for a PDF string, replacing characters the
+// standard fonts cannot show
+func pdfText(line string) string {
+	var escaped strings.Builder
+	for _, r := range line {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			escaped.WriteRune('\\')
+			escaped.WriteRune(r)
+		case r < 32 || r > 126:
+			escaped.WriteRune('?')
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+	return escaped.String()
+}
+
+// renderReceiptPDF lays a receipt out as a single page PDF. Amounts are
+// written with their currency code, as the standard fonts lack most symbols.
+func renderReceiptPDF(receipt Receipt) []byte {
+	amount := func(line ReceiptLine) string {
+		return fmt.Sprintf("%s: %s", line.Description, formatAmountCode(line.Amount, receipt.Currency))
+	}
+	lines := []string{
+		"Receipt " + receipt.Number,
+		"Issued " + receipt.IssuedAt.Format(dateLayout),
+		"",
+		"Member: " + receipt.MemberName,
+		fmt.Sprintf("Booking: %d (code %s), %s", receipt.BookingID, receipt.BookingCode, receipt.Status),
+		"",
+	}
+	for _, item := range receipt.Items {
+		lines = append(lines, amount(item))
+	}
+	for _, discount := range receipt.Discounts {
+		lines = append(lines, amount(discount))
+	}
+	lines = append(lines, amount(receipt.Subtotal))
+	if receipt.Tax != nil {
+		lines = append(lines, amount(*receipt.Tax))
+	}
+	lines = append(lines, amount(receipt.Total))
+	if receipt.GiftCard != nil {
+		lines = append(lines, amount(*receipt.GiftCard))
+	}
+	if receipt.PaymentReference != "" {
+		lines = append(lines, "", "Payment reference: "+receipt.PaymentReference)
+	}
+
+	var content strings.Builder
+	content.WriteString("BT /F1 12 Tf 16 TL 56 780 Td\n")
+	for _, line := range lines {
+		fmt.Fprintf(&content, "(%s) Tj T*\n", pdfText(line))
+	}
+	content.WriteString("ET")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = pdf.Len()
+		fmt.Fprintf(&pdf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := pdf.Len()
+	fmt.Fprintf(&pdf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&pdf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&pdf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return pdf.Bytes()
+}
+
+// Handler for the receipt of a booking, as JSON or with ?format=pdf as a PDF
+func bookingReceiptHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid booking id")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" && strings.Contains(r.Header.Get("Accept"), "application/pdf") {
+		format = "pdf"
+	}
+	if format != "" && format != "json" && format != "pdf" {
+		errorResponse(w, http.StatusBadRequest, "Invalid format, use json or pdf")
+		return
+	}
+
+	mutex.Lock()
+	booking, ok := findAnyBooking(id)
+	if !ok {
+		mutex.Unlock()
+		errorResponse(w, http.StatusNotFound, "Booking not found")
+		return
+	}
+	if !receiptIssued(booking) {
+		mutex.Unlock()
+		errorResponse(w, http.StatusConflict, "Receipts are only issued for confirmed or attended bookings")
+		return
+	}
+	receipt := newReceipt(booking)
+	mutex.Unlock()
+
+	if format == "pdf" {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", receipt.Number+".pdf"))
+		w.Write(renderReceiptPDF(receipt))
+		logData("Receipt rendered", receipt.Number)
+		return
+	}
+	successResponse(w, http.StatusOK, "Receipt retrieved successfully", receipt)
+}
+
+// Handler for a member's monthly invoice, given as ?month=MM-YYYY
+func memberInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	memberName := r.PathValue("name")
+	if memberName == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid member name")
+		return
+	}
+	month, err := time.Parse(monthLayout, r.URL.Query().Get("month"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid month format, use MM-YYYY")
+		return
+	}
+
+	mutex.Lock()
+	invoice := Invoice{MemberName: memberName, Month: month.Format(monthLayout), IssuedAt: now(), Receipts: []Receipt{}, Totals: []invoiceTotal{}}
+	sums := map[string]*[3]int{} // Subtotal, tax and total per currency
+	for _, booking := range allBookings() {
+		if booking.MemberName != memberName || booking.Price == 0 || !receiptIssued(booking) || booking.Date.Format(monthLayout) != invoice.Month {
+			continue
+		}
+		receipt := newReceipt(booking)
+		invoice.Receipts = append(invoice.Receipts, receipt)
+		sum := sums[receipt.Currency]
+		if sum == nil {
+			sum = &[3]int{}
+			sums[receipt.Currency] = sum
+		}
+		sum[0] += receipt.Subtotal.Amount
+		if receipt.Tax != nil {
+			sum[1] += receipt.Tax.Amount
+		}
+		sum[2] += receipt.Total.Amount
+	}
+	mutex.Unlock()
+
+	sort.SliceStable(invoice.Receipts, func(i, j int) bool {
+		return invoice.Receipts[i].Date.Before(invoice.Receipts[j].Date.Time)
+	})
+	for currency, sum := range sums {
+		invoice.Totals = append(invoice.Totals, invoiceTotal{
+			Currency: currency,
+			Subtotal: receiptLine("Subtotal", sum[0], currency),
+			Tax:      receiptLine("Tax", sum[1], currency),
+			Total:    receiptLine("Total", sum[2], currency),
+		})
+	}
+	sort.Slice(invoice.Totals, func(i, j int) bool { return invoice.Totals[i].Currency < invoice.Totals[j].Currency })
+
+	successResponse(w, http.StatusOK, "Invoice retrieved successfully", invoice)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestBookingReceipt verifies receipts itemise price, tax and payment, as JSON or PDF.
+func TestBookingReceipt(t *testing.T) {
+	setupTestEnvironment()
+	bookings = []Booking{
+		{ID: 1, Code: "ABC123", MemberName: "Ann", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed, Price: 1500, Currency: "EUR", Tax: &TaxLine{Name: "VAT", RatePercent: 20, Amount: 300}, Total: 1800, PaymentReference: "pi_123"},
+		{ID: 2, MemberName: "Ann", ClassName: "Yoga", Date: testDate("03-12-2099"), Status: bookingStatusPending, Price: 1500, Currency: "EUR"},
+	}
+	bookingId = 3
+
+	tests := []struct {
+		name           string
+		id             string
+		query          string
+		expectedStatus int
+	}{
+		{"JSON", "1", "", http.StatusOK},
+		{"PDF", "1", "?format=pdf", http.StatusOK},
+		{"Unknown Format", "1", "?format=xml", http.StatusBadRequest},
+		{"Not Paid", "2", "", http.StatusConflict},
+		{"Unknown Booking", "9", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/bookings/"+tt.id+"/receipt"+tt.query, nil)
+			req.SetPathValue("id", tt.id)
+			rec := httptest.NewRecorder()
+			bookingReceiptHandler(rec, req)
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+			switch tt.name {
+			case "JSON":
+				var response struct {
+					Data Receipt `json:"data"`
+				}
+				json.NewDecoder(rec.Body).Decode(&response)
+				receipt := response.Data
+				if receipt.Number != "R-000001" || receipt.Subtotal.Formatted != "€15.00" || receipt.Tax == nil || receipt.Tax.Description != "VAT 20%" || receipt.Total.Amount != 1800 || receipt.PaymentReference != "pi_123" {
+					t.Errorf("unexpected receipt %+v", receipt)
+				}
+			case "PDF":
+				body := rec.Body.String()
+				if rec.Header().Get("Content-Type") != "application/pdf" || !strings.HasPrefix(body, "%PDF-") || !strings.Contains(body, "(Total: 18.00 EUR) Tj") {
+					t.Errorf("expected a PDF with the total, got %q", body)
+				}
+			}
+		})
+	}
+}
+
+// TestMemberInvoice verifies the monthly invoice totals a member's paid bookings per currency.
+func TestMemberInvoice(t *testing.T) {
+	setupTestEnvironment()
+	bookings = []Booking{
+		{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("20-12-2099"), Status: bookingStatusAttended, Price: 1500, Currency: "EUR", Tax: &TaxLine{Name: "VAT", RatePercent: 20, Amount: 300}, Total: 1800},
+		{ID: 2, MemberName: "Ann", ClassName: "Spin", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed, Price: 1000, Currency: "EUR", Total: 1000},
+		{ID: 3, MemberName: "Ann", ClassName: "Zen", Date: testDate("05-12-2099"), Status: bookingStatusConfirmed, Price: 2000, Currency: "JPY", Total: 2000},
+		{ID: 4, MemberName: "Ann", ClassName: "Yoga", Date: testDate("06-12-2099"), Status: bookingStatusCancelled, Price: 1500, Currency: "EUR", Total: 1500},
+		{ID: 5, MemberName: "Ann", ClassName: "Yoga", Date: testDate("02-01-2100"), Status: bookingStatusConfirmed, Price: 1500, Currency: "EUR", Total: 1500},
+		{ID: 6, MemberName: "Ben", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed, Price: 1500, Currency: "EUR", Total: 1500},
+		{ID: 7, MemberName: "Ann", ClassName: "Stretch", Date: testDate("03-12-2099"), Status: bookingStatusConfirmed},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/members/Ann/invoice?month=12-2099", nil)
+	req.SetPathValue("name", "Ann")
+	rec := httptest.NewRecorder()
+	memberInvoiceHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the invoice, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response struct {
+		Data Invoice `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&response)
+	invoice := response.Data
+	if len(invoice.Receipts) != 3 || invoice.Receipts[0].BookingID != 2 || invoice.Receipts[2].BookingID != 1 {
+		t.Fatalf("expected the month's paid bookings by date, got %+v", invoice.Receipts)
+	}
+	if len(invoice.Totals) != 2 || invoice.Totals[0].Currency != "EUR" || invoice.Totals[0].Tax.Amount != 300 || invoice.Totals[0].Total.Formatted != "€28.00" || invoice.Totals[1].Total.Formatted != "¥2,000" {
+		t.Errorf("expected totals per currency, got %+v", invoice.Totals)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/members/Ann/invoice?month=2099-12", nil)
+	req.SetPathValue("name", "Ann")
+	rec = httptest.NewRecorder()
+	memberInvoiceHandler(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected an invalid month to be refused, got %d", rec.Code)
+	}
+}
+
+// TestConfirmWithPaymentReference verifies confirming a pending booking records its payment.
+func TestConfirmWithPaymentReference(t *testing.T) {
+	setupTestEnvironment()
+	bookings = []Booking{{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusPending}}
+	req := httptest.NewRequest(http.MethodPost, "/bookings/1/confirm", bytes.NewReader([]byte(`{"paymentReference":"pi_456"}`)))
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+	confirmBookingHandler(rec, req)
+	if rec.Code != http.StatusOK || bookings[0].PaymentReference != "pi_456" {
+		t.Errorf("expected the payment reference to be kept, got %d %+v", rec.Code, bookings[0])
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Referral tracks a member who signed up with another member's referral code
+type Referral struct {
+	ID             int        `json:"id"`
+	ReferrerName   string     `json:"referrerName"`
+	MemberName     string     `json:"memberName"` // The member who was referred
+	Code           string     `json:"code"`
+	SignedUpAt     time.Time  `json:"signedUpAt"`
+	FirstBookingID int        `json:"firstBookingId,omitempty"`
+	FirstBookedAt  *time.Time `json:"firstBookedAt,omitempty"`
+	RewardCredits  int        `json:"rewardCredits,omitempty"` // Credits the referrer earned
+}
+
+// creditReasonReferral rewards a referrer whose referral booked a class
+const creditReasonReferral = "referral"
+
+// notifyReferralRewarded tells a member they earned credits for a referral
+const notifyReferralRewarded = "referralRewarded"
+
+var (
+	referrals  []Referral // Temp Slice to hold referrals
+	referralId = 1        // Incremental ID for referrals
+)
+
+// newReferralCode returns a code, such as "RF-7F3K9Q", that no other member
+// uses. Callers must hold the mutex.
+func newReferralCode() string {
+	for {
+		token := make([]byte, codeLength)
+		rand.Read(token)
+		for i := range token {
+			token[i] = codeAlphabet[int(token[i])%len(codeAlphabet)]
+		}
+		code := "RF-" + string(token)
+		if memberByReferralCode(code) < 0 {
+			return code
+		}
+	}
+}
+
+// memberByReferralCode returns the position of the member owning a referral
+// code, or -1. Callers must hold the mutex.
+func memberByReferralCode(code string) int {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	for i, member := range members {
+		if member.ReferralCode != "" && member.ReferralCode == code {
+			return i
+		}
+	}
+	return -1
+}
+
+// referralFor returns the position of the referral that brought a member in,
+// or -1. Callers must hold the mutex.
+func referralFor(memberName string) int {
+	for i, referral := range referrals {
+		if referral.MemberName == memberName {
+			return i
+		}
+	}
+	return -1
+}
+
+// recordReferral attributes a new member's signup to the owner of the code
+// they gave and returns the referrer's name. Callers must hold the mutex.
+func recordReferral(memberName, code string) (string, *requestRejection) {
+	referrer := memberByReferralCode(code)
+	if referrer < 0 {
+		return "", &requestRejection{http.StatusBadRequest, "Unknown referral code"}
+	}
+	if members[referrer].Name == memberName {
+		return "", &requestRejection{http.StatusBadRequest, "Members cannot refer themselves"}
+	}
+	referral := Referral{
+		ID:           referralId,
+		ReferrerName: members[referrer].Name,
+		MemberName:   memberName,
+		Code:         members[referrer].ReferralCode,
+		SignedUpAt:   now(),
+	}
+	referrals = append(referrals, referral)
+	referralId++
+	if err := writeDataToJsonFile("referrals.json", referrals); err != nil {
+		referrals = referrals[:len(referrals)-1]
+		referralId--
+		return "", &requestRejection{http.StatusInternalServerError, "Failed to save referral data"}
+	}
+	return referral.ReferrerName, nil
+}
+
+// attributeFirstBooking records a referred member's first booking and rewards
+// the referrer with config.ReferralRewardCredits. Callers must hold the mutex.
+func attributeFirstBooking(booking Booking) {
+	index := referralFor(booking.MemberName)
+	if index < 0 || referrals[index].FirstBookingID != 0 {
+		return
+	}
+	bookedAt := now()
+	referral := &referrals[index]
+	referral.FirstBookingID, referral.FirstBookedAt = booking.ID, &bookedAt
+	if config.ReferralRewardCredits > 0 {
+		if _, err := addCreditEntry(CreditEntry{MemberName: referral.ReferrerName, Delta: config.ReferralRewardCredits, Reason: creditReasonReferral, BookingID: booking.ID, Actor: "system"}); err != nil {
+			fmt.Println("Error rewarding referral:", err)
+		} else {
+			referral.RewardCredits = config.ReferralRewardCredits
+			message := fmt.Sprintf("%s booked their first class, so you earned %d credits. Thanks for the referral!", referral.MemberName, referral.RewardCredits)
+			notifyMember(referral.ReferrerName, notifyReferralRewarded, "You earned referral credits", message, map[string]string{"event": notifyReferralRewarded, "credits": strconv.Itoa(referral.RewardCredits)})
+		}
+	}
+	if err := writeDataToJsonFile("referrals.json", referrals); err != nil {
+		fmt.Println("Error saving referrals:", err)
+	}
+	recordAudit("system", "attribute", "referral", referral.ID, nil, *referral)
+}
+
+// Handler for a member's referral code and the members they referred. The
+// code is created the first time it is asked for.
+func memberReferralHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+	memberName := r.PathValue("name")
+	if memberName == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid member name")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := memberIndex(memberName)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Member not found")
+		return
+	}
+	if members[index].ReferralCode == "" {
+		members[index].ReferralCode = newReferralCode()
+		if err := writeDataToJsonFile("members.json", members); err != nil {
+			members[index].ReferralCode = ""
+			errorResponse(w, http.StatusInternalServerError, "Failed to save member data")
+			return
+		}
+	}
+
+	referred := []Referral{}
+	for _, referral := range referrals {
+		if referral.ReferrerName == memberName {
+			referred = append(referred, referral)
+		}
+	}
+	successResponse(w, http.StatusOK, "Referrals retrieved successfully", map[string]interface{}{
+		"code":      members[index].ReferralCode,
+		"referrals": referred,
+	})
+}
+
+// referrerSummary is one referrer's line in the referral report
+type referrerSummary struct {
+	ReferrerName  string `json:"referrerName"`
+	Signups       int    `json:"signups"`
+	FirstBookings int    `json:"firstBookings"`
+	CreditsEarned int    `json:"creditsEarned"`
+}
+
+// Handler for the referral report: signups, first bookings and rewards per referrer
+func referralReportHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	byReferrer := map[string]*referrerSummary{}
+	total := referrerSummary{}
+	for _, referral := range referrals {
+		summary := byReferrer[referral.ReferrerName]
+		if summary == nil {
+			summary = &referrerSummary{ReferrerName: referral.ReferrerName}
+			byReferrer[referral.ReferrerName] = summary
+		}
+		for _, counts := range []*referrerSummary{summary, &total} {
+			counts.Signups++
+			if referral.FirstBookingID != 0 {
+				counts.FirstBookings++
+			}
+			counts.CreditsEarned += referral.RewardCredits
+		}
+	}
+
+	// Most successful referrers first
+	report := []referrerSummary{}
+	for _, summary := range byReferrer {
+		report = append(report, *summary)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].FirstBookings != report[j].FirstBookings {
+			return report[i].FirstBookings > report[j].FirstBookings
+		}
+		if report[i].Signups != report[j].Signups {
+			return report[i].Signups > report[j].Signups
+		}
+		return report[i].ReferrerName < report[j].ReferrerName
+	})
+	successResponse(w, http.StatusOK, "Referral report retrieved successfully", map[string]interface{}{
+		"referrers":     report,
+		"signups":       total.Signups,
+		"firstBookings": total.FirstBookings,
+		"creditsEarned": total.CreditsEarned,
+	})
+}
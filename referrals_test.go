@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestReferrals verifies signups and first bookings are attributed to the referrer, who earns credits.
+func TestReferrals(t *testing.T) {
+	setupTestEnvironment()
+	config.ReferralRewardCredits = 2
+	members = []Member{{Name: "Ann"}}
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 5}}
+	classId = 2
+
+	req := httptest.NewRequest(http.MethodGet, "/members/Ann/referral", nil)
+	req.SetPathValue("name", "Ann")
+	rec := httptest.NewRecorder()
+	memberReferralHandler(rec, req)
+	var response struct {
+		Data struct {
+			Code string `json:"code"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&response)
+	code := response.Data.Code
+	if rec.Code != http.StatusOK || code == "" || members[0].ReferralCode != code {
+		t.Fatalf("expected Ann to get a referral code, got %d %q", rec.Code, code)
+	}
+
+	tests := []struct {
+		name           string
+		memberName     string
+		body           string
+		expectedStatus int
+		referredBy     string
+	}{
+		{"Signs Up With Code", "Ben", `{"referrerCode":"` + code + `"}`, http.StatusOK, "Ann"},
+		{"Unknown Code", "Cat", `{"referrerCode":"RF-NOPE"}`, http.StatusBadRequest, ""},
+		{"Refers Themselves", "Ann", `{"referrerCode":"` + code + `","referralCode":"RF-MINE"}`, http.StatusOK, ""},
+		{"Code Only Counts At Signup", "Ben", `{"level":"beginner","referredBy":"Cat"}`, http.StatusOK, "Ann"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/members/"+tt.memberName, bytes.NewReader([]byte(tt.body)))
+			req.SetPathValue("name", tt.memberName)
+			rec := httptest.NewRecorder()
+			memberProfileHandler(rec, req)
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+			index := memberIndex(tt.memberName)
+			if tt.expectedStatus == http.StatusOK && members[index].ReferredBy != tt.referredBy {
+				t.Errorf("expected %s to be referred by %q, got %q", tt.memberName, tt.referredBy, members[index].ReferredBy)
+			}
+		})
+	}
+	if len(referrals) != 1 || memberIndex("Cat") >= 0 || members[0].ReferralCode != code {
+		t.Fatalf("expected only Ben's signup to be attributed, got %+v", referrals)
+	}
+
+	for _, date := range []string{"02-12-2099", "03-12-2099"} {
+		rec := httptest.NewRecorder()
+		bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"Ben","className":"Yoga","date":"`+date+`"}`))))
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected Ben to book, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	if referral := referrals[0]; referral.FirstBookingID != bookings[0].ID || referral.RewardCredits != 2 || creditBalance("Ann") != 2 {
+		t.Errorf("expected Ann to be rewarded once for Ben's first booking, got %+v with %d credits", referral, creditBalance("Ann"))
+	}
+
+	rec = httptest.NewRecorder()
+	referralReportHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/referrals", nil))
+	var report struct {
+		Data struct {
+			Referrers     []referrerSummary `json:"referrers"`
+			FirstBookings int               `json:"firstBookings"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&report)
+	expected := referrerSummary{ReferrerName: "Ann", Signups: 1, FirstBookings: 1, CreditsEarned: 2}
+	if len(report.Data.Referrers) != 1 || report.Data.Referrers[0] != expected || report.Data.FirstBookings != 1 {
+		t.Errorf("expected Ann in the report, got %+v", report.Data)
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Refund records money or credits returned for a cancelled booking
+type Refund struct {
+	ID               int       `json:"id"`
+	BookingID        int       `json:"bookingId"`
+	MemberName       string    `json:"memberName"`
+	Percent          int       `json:"percent"`          // Share of the payment returned under the cancellation policy
+	Amount           int       `json:"amount,omitempty"` // In minor units
+	Currency         string    `json:"currency,omitempty"`
+	GiftCardAmount   int       `json:"giftCardAmount,omitempty"` // Part of the amount returned to the gift card that paid it
+	Credits          int       `json:"credits,omitempty"`
+	Points           int       `json:"points,omitempty"` // Loyalty points returned for a free booking
+	Method           string    `json:"method"`
+	PaymentReference string    `json:"paymentReference,omitempty"`
+	ProviderRefundID string    `json:"providerRefundId,omitempty"`
+	Reason           string    `json:"reason,omitempty"`
+	Actor            string    `json:"actor"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+// How a refund reaches the member
+const (
+	refundMethodProvider = "provider" // Through the payment provider
+	refundMethodManual   = "manual"   // By staff, for payments the provider did not take
+	refundMethodCredits  = "credits"  // Only credits were returned
+	refundMethodGiftCard = "giftCard" // Only the gift card was paid back
+	refundMethodPoints   = "points"   // Only loyalty points were returned
+)
+
+// bookingEventRefunded records a refund in a booking's history
+const bookingEventRefunded = "refunded"
+
+// notifyBookingRefunded tells a member their booking was refunded
+const notifyBookingRefunded = "bookingRefunded"
+
+var (
+	refunds  []Refund // Temp Slice to hold refunds
+	refundId = 1      // Incremental ID for refunds
+)
+
+// cancellationTime returns when a booking was cancelled, from its history.
+// Callers must hold the mutex.
+func cancellationTime(bookingID int) (time.Time, bool) {
+	for i := len(bookingEvents) - 1; i >= 0; i-- {
+		if bookingEvents[i].BookingID == bookingID && bookingEvents[i].Type == bookingEventCancelled {
+			return bookingEvents[i].Time, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// refundPercent checks a booking can be refunded and returns the share of its
+// payment the cancellation policy returns. Cancelling at least
+// config.RefundCutoffHours before the start returns everything, later
+// cancellations config.LateRefundPercent. Sessions the studio cancelled are
+// always refunded in full. Callers must hold the mutex.
+func refundPercent(booking Booking) (int, *requestRejection) {
+	if booking.RefundID != 0 {
+		return 0, &requestRejection{http.StatusConflict, "Booking has already been refunded"}
+	}
+	if bookingStatus(booking) != bookingStatusCancelled {
+		return 0, &requestRejection{http.StatusConflict, "Only cancelled bookings can be refunded, cancel the booking first"}
+	}
+	if booking.Price == 0 && booking.CreditsUsed == 0 && booking.PointsUsed == 0 {
+		return 0, &requestRejection{http.StatusConflict, "Booking was not paid for"}
+	}
+	for _, session := range classSessions {
+		if session.ID == booking.SessionID && booking.SessionID != 0 && session.CancelledAt != nil {
+			return 100, nil
+		}
+	}
+
+	start, _, ok := bookingWindow(booking)
+	if !ok {
+		start = booking.Date.Time
+	}
+	// Bookings cancelled before their history was kept count as cancelled now
+	cancelledAt, ok := cancellationTime(booking.ID)
+	if !ok {
+		cancelledAt = now()
+	}
+	if start.Sub(cancelledAt) >= time.Duration(config.RefundCutoffHours)*time.Hour {
+		return 100, nil
+	}
+	if config.LateRefundPercent == 0 {
+		return 0, &requestRejection{http.StatusConflict, fmt.Sprintf("Bookings cancelled less than %d hours before the start are not refundable", config.RefundCutoffHours)}
+	}
+	return config.LateRefundPercent, nil
+}
+
+// creditsPayer returns the member whose credits paid for a booking. After a
+// transfer it is not the booking's member. Callers must hold the mutex.
+func creditsPayer(booking Booking) string {
+	for _, entry := range creditEntries {
+		if entry.BookingID == booking.ID && entry.Reason == creditReasonBooking {
+			return entry.MemberName
+		}
+	}
+	return booking.MemberName
+}
+
+// pointsPayer returns the member whose loyalty points paid for a booking.
+// Callers must hold the mutex.
+func pointsPayer(booking Booking) string {
+	for _, entry := range pointsEntries {
+		if entry.BookingID == booking.ID && entry.Reason == pointsReasonRedemption {
+			return entry.MemberName
+		}
+	}
+	return booking.MemberName
+}
+
+// issueRefund returns percent of a cancelled booking's payment the way it was
+// paid: card payments through the payment provider, gift card payments to the
+// card, credits and points to the ledgers of the member who spent them. It
+// records the refund on the booking and tells the member refunded.
+// Callers must hold the mutex.
+func issueRefund(index, percent int, reason, actor string) (Refund, *requestRejection) {
+	booking := bookings[index]
+	// Bookings taxed before the total was kept paid their price
+	paid := max(booking.Total, booking.Price)
+	refund := Refund{
+		BookingID:        booking.ID,
+		MemberName:       booking.MemberName,
+		Percent:          percent,
+		Amount:           int(math.Round(float64(paid) * float64(percent) / 100)),
+		Currency:         booking.Currency,
+		Credits:          booking.CreditsUsed * percent / 100,
+		Points:           booking.PointsUsed * percent / 100,
+		Method:           refundMethodCredits,
+		PaymentReference: booking.PaymentReference,
+		Reason:           reason,
+		Actor:            actor,
+	}
+	if refund.Points > 0 && refund.Credits == 0 {
+		refund.Method = refundMethodPoints
+	}
+	// Credits and points go back to whoever spent them
+	switch refund.Method {
+	case refundMethodCredits:
+		refund.MemberName = creditsPayer(booking)
+	case refundMethodPoints:
+		refund.MemberName = pointsPayer(booking)
+	}
+	if refund.Amount == 0 && refund.Credits == 0 && refund.Points == 0 {
+		return Refund{}, &requestRejection{http.StatusConflict, "Nothing is refundable under the cancellation policy"}
+	}
+	// Gift cards are paid back what they paid, the rest goes back the way it was paid
+	refund.GiftCardAmount = int(math.Round(float64(booking.GiftCardAmount) * float64(percent) / 100))
+	paidOut := refund.Amount - refund.GiftCardAmount
+	if refund.GiftCardAmount > 0 {
+		refund.Method = refundMethodGiftCard
+	}
+	if paidOut > 0 {
+		refund.Method = refundMethodManual
+		if booking.PaymentReference != "" {
+			if paymentProvider == nil {
+				return Refund{}, &requestRejection{http.StatusServiceUnavailable, "No payment provider is configured to refund this payment"}
+			}
+			providerID, err := paymentProvider.Refund(booking.PaymentReference, paidOut, booking.Currency)
+			if err != nil {
+				reportError("payment", "Refund failed", map[string]string{"bookingId": strconv.Itoa(booking.ID), "error": err.Error()})
+				return Refund{}, &requestRejection{http.StatusBadGateway, "Payment provider could not refund the payment"}
+			}
+			refund.Method, refund.ProviderRefundID = refundMethodProvider, providerID
+		}
+	}
+
+	refund.ID = refundId
+	refund.CreatedAt = now()
+	refundId++
+	refunds = append(refunds, refund)
+	bookings[index].RefundID = refund.ID
+	audit := recordAudit(refund.Actor, "refund", "booking", booking.ID, booking, bookings[index])
+	if err := writeDataToJsonFile("refunds.json", refunds); err != nil {
+		refunds = refunds[:len(refunds)-1]
+		refundId--
+		bookings[index] = booking
+		retractAudit(audit)
+		if refund.ProviderRefundID != "" {
+			fmt.Println("Payment provider refund not recorded:", refund.ProviderRefundID)
+		}
+		return Refund{}, &requestRejection{http.StatusInternalServerError, "Failed to save refund data"}
+	}
+	if err := writeDataToJsonFile("bookings.json", bookings); err != nil {
+		fmt.Println("Error saving refunded booking:", err)
+	}
+	if refund.GiftCardAmount > 0 {
+		if card := giftCardIndex(booking.GiftCardID); card >= 0 {
+			if err := adjustGiftCard(card, GiftCardTransaction{Amount: refund.GiftCardAmount, Reason: giftCardReasonRefund, BookingID: booking.ID, Actor: refund.Actor}); err != nil {
+				fmt.Println("Error paying back gift card:", err)
+			}
+		}
+	}
+	if refund.Credits > 0 {
+		if _, err := addCreditEntry(CreditEntry{MemberName: creditsPayer(booking), Delta: refund.Credits, Reason: creditReasonRefund, BookingID: booking.ID, Actor: refund.Actor}); err != nil {
+			fmt.Println("Error returning refunded credits:", err)
+		}
+	}
+
+	if refund.Points > 0 {
+		if _, err := addPointsEntry(PointsEntry{MemberName: pointsPayer(booking), Delta: refund.Points, Reason: pointsReasonRefund, BookingID: booking.ID, Actor: refund.Actor}); err != nil {
+			fmt.Println("Error returning refunded points:", err)
+		}
+	}
+
+	recordBookingEvent(booking.ID, bookingEventRefunded, refund.Actor, map[string]string{
+		"refundId": strconv.Itoa(refund.ID),
+		"percent":  strconv.Itoa(percent),
+	})
+	var returned []string
+	if paidOut > 0 {
+		returned = append(returned, formatAmount(paidOut, refund.Currency))
+	}
+	if refund.GiftCardAmount > 0 {
+		returned = append(returned, formatAmount(refund.GiftCardAmount, refund.Currency)+" to your gift card")
+	}
+	if refund.Credits > 0 {
+		returned = append(returned, fmt.Sprintf("%d credits", refund.Credits))
+	}
+	if refund.Points > 0 {
+		returned = append(returned, fmt.Sprintf("%d loyalty points", refund.Points))
+	}
+	message := fmt.Sprintf("Your booking for %s on %s has been refunded: %s.", bookingTitle(booking), booking.Date, strings.Join(returned, " and "))
+	notifyMember(refund.MemberName, notifyBookingRefunded, "Booking refunded", message, map[string]string{"event": notifyBookingRefunded, "bookingId": strconv.Itoa(booking.ID)})
+
+	return refund, nil
+}
+
+// Handler for refunding a cancelled booking under the cancellation policy
+func bookingRefundHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid booking id")
+		return
+	}
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	// The reason is optional, so an empty body is accepted
+	if err := decodeBody(r, &request); err != nil && err != io.EOF {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := bookingIndex(id)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Booking not found")
+		return
+	}
+	percent, rejection := refundPercent(bookings[index])
+	if rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+	refund, rejection := issueRefund(index, percent, request.Reason, actorFromRequest(r))
+	if rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+
+	successResponse(w, http.StatusOK, "Booking refunded successfully", refund)
+	logData("Booking refunded successfully", refund)
+}
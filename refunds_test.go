@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakePaymentProvider records refunds instead of moving money
+type fakePaymentProvider struct {
+	refunded []string
+	err      error
+}
+
+func (f *fakePaymentProvider) Refund(paymentReference string, amount int, currency string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	f.refunded = append(f.refunded, paymentReference)
+	return "re_1", nil
+}
+
+// TestBookingRefund verifies refunds follow the cancellation policy and return money or credits.
+func TestBookingRefund(t *testing.T) {
+	defer func(provider PaymentProvider) { paymentProvider = provider }(paymentProvider)
+	early := time.Date(2099, 11, 30, 9, 0, 0, 0, time.UTC) // Two days before the session
+	late := time.Date(2099, 12, 2, 7, 0, 0, 0, time.UTC)   // Two hours before
+
+	tests := []struct {
+		name            string
+		booking         Booking
+		cancelledAt     time.Time
+		latePercent     int
+		sessionCanceled bool
+		providerErr     error
+		expectedStatus  int
+		expectedAmount  int
+		expectedCredits int
+	}{
+		{"Early Card Refund", Booking{Price: 1500, Total: 1800, PaymentReference: "pi_1"}, early, 0, false, nil, http.StatusOK, 1800, 0},
+		{"Early Manual Refund", Booking{Price: 1500, Total: 1500}, early, 0, false, nil, http.StatusOK, 1500, 0},
+		{"Late Not Refundable", Booking{Price: 1500, Total: 1500}, late, 0, false, nil, http.StatusConflict, 0, 0},
+		{"Late Partial Refund", Booking{Price: 1500, Total: 1500}, late, 50, false, nil, http.StatusOK, 750, 0},
+		{"Session Cancelled", Booking{Price: 1500, Total: 1500}, late, 0, true, nil, http.StatusOK, 1500, 0},
+		{"Credits Returned", Booking{PaymentMethod: paymentCredits, CreditsUsed: 2}, early, 0, false, nil, http.StatusOK, 0, 2},
+		{"Not Paid", Booking{}, early, 0, false, nil, http.StatusConflict, 0, 0},
+		{"Provider Fails", Booking{Price: 1500, Total: 1500, PaymentReference: "pi_1"}, early, 0, false, errors.New("card expired"), http.StatusBadGateway, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestEnvironment()
+			config.LateRefundPercent = tt.latePercent
+			provider := &fakePaymentProvider{err: tt.providerErr}
+			paymentProvider = provider
+			classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 5, StartTime: "09:00"}}
+			classSessions = []ClassSession{{ID: 1, ClassID: 1, ClassName: "Yoga", Date: testDate("02-12-2099"), Capacity: 5}}
+			if tt.sessionCanceled {
+				classSessions[0].CancelledAt = &tt.cancelledAt
+			}
+			booking := tt.booking
+			booking.ID, booking.MemberName, booking.ClassName, booking.Date, booking.Status, booking.SessionID, booking.Currency = 1, "Ann", "Yoga", testDate("02-12-2099"), bookingStatusCancelled, 1, "EUR"
+			bookings = []Booking{booking}
+			bookingEvents = []BookingEvent{{ID: 1, BookingID: 1, Type: bookingEventCancelled, Time: tt.cancelledAt}}
+			bookingEventId = 2
+
+			req := httptest.NewRequest(http.MethodPost, "/bookings/1/refund", bytes.NewReader([]byte(`{"reason":"Injured"}`)))
+			req.SetPathValue("id", "1")
+			rec := httptest.NewRecorder()
+			bookingRefundHandler(rec, req)
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				if len(refunds) != 0 || bookings[0].RefundID != 0 {
+					t.Errorf("expected nothing to be refunded, got %+v", refunds)
+				}
+				return
+			}
+			refund := refunds[0]
+			if refund.Amount != tt.expectedAmount || refund.Credits != tt.expectedCredits || bookings[0].RefundID != refund.ID {
+				t.Errorf("expected %d and %d credits refunded, got %+v", tt.expectedAmount, tt.expectedCredits, refund)
+			}
+			if (booking.PaymentReference != "") != (refund.Method == refundMethodProvider && len(provider.refunded) == 1) {
+				t.Errorf("expected card payments to go back through the provider, got %s", refund.Method)
+			}
+			if creditBalance("Ann") != tt.expectedCredits {
+				t.Errorf("expected %d credits back, got %d", tt.expectedCredits, creditBalance("Ann"))
+			}
+
+			rec = httptest.NewRecorder()
+			bookingRefundHandler(rec, req)
+			if rec.Code != http.StatusConflict {
+				t.Errorf("expected a second refund to conflict, got %d", rec.Code)
+			}
+		})
+	}
+}
+
+// TestRefundAfterTransfer verifies credits go back to the member who spent them, not the one the booking was transferred to.
+func TestRefundAfterTransfer(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2099, 11, 30, 9, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	bookings = []Booking{{ID: 1, MemberName: "Ben", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusCancelled, PaymentMethod: paymentCredits, CreditsUsed: 2}}
+	creditEntries = []CreditEntry{{ID: 1, MemberName: "Ann", Delta: -2, Reason: creditReasonBooking, BookingID: 1}}
+	creditEntryId = 2
+
+	req := httptest.NewRequest(http.MethodPost, "/bookings/1/refund", nil)
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+	bookingRefundHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the refund to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if refunds[0].MemberName != "Ann" {
+		t.Errorf("expected the refund to belong to the payer, got %+v", refunds[0])
+	}
+	last := creditEntries[len(creditEntries)-1]
+	if last.Reason != creditReasonRefund || last.MemberName != "Ann" || last.Delta != 2 {
+		t.Errorf("expected the credits back on the payer's ledger, got %+v", last)
+	}
+}
+
+// TestRefundRequiresCancellation verifies active bookings are cancelled before they are refunded.
+func TestRefundRequiresCancellation(t *testing.T) {
+	setupTestEnvironment()
+	bookings = []Booking{{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed, Price: 1500}}
+	req := httptest.NewRequest(http.MethodPost, "/bookings/1/refund", nil)
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+	bookingRefundHandler(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected an active booking to be refused, got %d", rec.Code)
+	}
+}
+
+// TestStripeRefund verifies refunds are sent to the Stripe API.
+func TestStripeRefund(t *testing.T) {
+	var form string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = r.URL.Path + " " + r.Header.Get("Authorization") + " " + r.Form.Encode()
+		if r.Form.Get("payment_intent") == "pi_bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"No such payment_intent"}}`))
+			return
+		}
+		w.Write([]byte(`{"id":"re_123"}`))
+	}))
+	defer server.Close()
+
+	provider := &stripeProvider{baseURL: server.URL, secretKey: "sk_test", client: server.Client()}
+	id, err := provider.Refund("pi_1", 750, "EUR")
+	if err != nil || id != "re_123" || form != "/v1/refunds Bearer sk_test amount=750&payment_intent=pi_1" {
+		t.Errorf("expected the refund to be created, got %q %v from %q", id, err, form)
+	}
+	if _, err := provider.Refund("pi_bad", 750, "EUR"); err == nil {
+		t.Error("expected a refused refund to fail")
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReportSubscription emails an admin a set of /stats reports every week or
+// month, covering the week or month just ended
+type ReportSubscription struct {
+	ID         int        `json:"id"`
+	Email      string     `json:"email"`
+	Reports    []string   `json:"reports"`   // e.g. "revenue", "instructors"
+	Frequency  string     `json:"frequency"` // "weekly" or "monthly"
+	LastSentAt *time.Time `json:"lastSentAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// Report frequencies
+const (
+	reportWeekly  = "weekly"
+	reportMonthly = "monthly"
+)
+
+// scheduledReports maps the reports that can be subscribed to to their handlers
+var scheduledReports = map[string]http.HandlerFunc{
+	"revenue":     revenueStatsHandler,
+	"instructors": instructorStatsHandler,
+	"heatmap":     heatmapStatsHandler,
+	"retention":   retentionStatsHandler,
+	"capacity":    capacityStatsHandler,
+	"waitlist":    waitlistStatsHandler,
+}
+
+var (
+	reportSubscriptions  []ReportSubscription // Temp Slice to hold report subscriptions
+	reportSubscriptionId = 1                  // Incremental ID for report subscriptions
+)
+
+// reportSubscriptionIndex returns the position of a report subscription, or -1.
+// Callers must hold the mutex.
+func reportSubscriptionIndex(id int) int {
+	for i, subscription := range reportSubscriptions {
+		if subscription.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkReportSubscription validates the fields of a report subscription and
+// normalizes its email address
+func checkReportSubscription(subscription *ReportSubscription) *requestRejection {
+	email, err := normalizeEmail(subscription.Email)
+	if err != nil {
+		return &requestRejection{http.StatusBadRequest, "Invalid email address"}
+	}
+	subscription.Email = email
+	if subscription.Frequency != reportWeekly && subscription.Frequency != reportMonthly {
+		return &requestRejection{http.StatusBadRequest, "Invalid frequency, use weekly or monthly"}
+	}
+	if len(subscription.Reports) == 0 {
+		return &requestRejection{http.StatusBadRequest, "Choose at least one report"}
+	}
+	for _, report := range subscription.Reports {
+		if scheduledReports[report] == nil {
+			return &requestRejection{http.StatusBadRequest, fmt.Sprintf("Unknown report %s", report)}
+		}
+	}
+	return nil
+}
+
+// reportPeriod returns the first and last days of the week (Monday to Sunday)
+// or month before the one containing day
+func reportPeriod(frequency string, day time.Time) (time.Time, time.Time) {
+	if frequency == reportMonthly {
+		start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start.AddDate(0, -1, 0), start.AddDate(0, 0, -1)
+	}
+	start := weekStart(day)
+	return start.AddDate(0, 0, -7), start.AddDate(0, 0, -1)
+}
+
+// reportRecorder collects the response of a report handler
+type reportRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (recorder *reportRecorder) Header() http.Header { return recorder.header }
+
+func (recorder *reportRecorder) Write(data []byte) (int, error) { return recorder.body.Write(data) }
+
+func (recorder *reportRecorder) WriteHeader(status int) { recorder.status = status }
+
+// renderReport runs a report as CSV over a period. It takes the mutex itself,
+// so callers must not hold it.
+func renderReport(report string, from, to time.Time) (string, error) {
+	query := url.Values{"from": {from.Format(dateLayout)}, "to": {to.Format(dateLayout)}, "format": {"csv"}}
+	request, err := http.NewRequest(http.MethodGet, "/stats/"+report+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	recorder := &reportRecorder{header: http.Header{}, status: http.StatusOK}
+	scheduledReports[report](recorder, request)
+	if recorder.status != http.StatusOK {
+		return "", fmt.Errorf("%s report failed with status %d", report, recorder.status)
+	}
+	return recorder.body.String(), nil
+}
+
+// sendScheduledReports emails every subscription whose report period has ended
+// since it was last sent, and returns how many emails were sent
+func sendScheduledReports() int {
+	day := today()
+	mutex.Lock()
+	due := []ReportSubscription{}
+	for _, subscription := range reportSubscriptions {
+		_, to := reportPeriod(subscription.Frequency, day)
+		if subscription.LastSentAt == nil || subscription.LastSentAt.Before(to.AddDate(0, 0, 1)) {
+			due = append(due, subscription)
+		}
+	}
+	mutex.Unlock()
+
+	// Reports take the mutex themselves, so they are rendered without it
+	sent := []int{}
+	for _, subscription := range due {
+		from, to := reportPeriod(subscription.Frequency, day)
+		var body strings.Builder
+		fmt.Fprintf(&body, "Reports for %s to %s, as CSV:\n", from.Format(dateLayout), to.Format(dateLayout))
+		failed := false
+		for _, report := range subscription.Reports {
+			content, err := renderReport(report, from, to)
+			if err != nil {
+				fmt.Println("Error rendering scheduled report:", err)
+				failed = true
+				break
+			}
+			fmt.Fprintf(&body, "\n%s\n%s", report, content)
+		}
+		if failed {
+			continue
+		}
+		subject := fmt.Sprintf("Your %s reports for %s to %s", subscription.Frequency, from.Format(dateLayout), to.Format(dateLayout))
+		if err := sendEmail(subscription.Email, subject, body.String()); err != nil {
+			fmt.Println("Error sending scheduled report:", err)
+			continue
+		}
+		sent = append(sent, subscription.ID)
+	}
+	if len(sent) == 0 {
+		return 0
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	sentAt := now()
+	for _, id := range sent {
+		if index := reportSubscriptionIndex(id); index >= 0 {
+			reportSubscriptions[index].LastSentAt = &sentAt
+		}
+	}
+	if err := writeDataToJsonFile("report_subscriptions.json", reportSubscriptions); err != nil {
+		fmt.Println("Error saving report subscriptions:", err)
+	}
+	logData("Scheduled reports sent", map[string]int{"subscriptions": len(sent)})
+	return len(sent)
+}
+
+// runScheduledReports is the scheduled entry point for sendScheduledReports
+func runScheduledReports() {
+	sendScheduledReports()
+}
+
+// Handler for listing and creating report subscriptions
+func reportSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		response := append([]ReportSubscription{}, reportSubscriptions...)
+		mutex.Unlock()
+		successResponse(w, http.StatusOK, "Report subscriptions retrieved successfully", map[string]interface{}{"subscriptions": response})
+
+	case http.MethodPost:
+		var subscription ReportSubscription
+		if err := decodeBody(r, &subscription); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if rejection := checkReportSubscription(&subscription); rejection != nil {
+			errorResponse(w, rejection.StatusCode, rejection.Message)
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		subscription.ID = reportSubscriptionId
+		subscription.CreatedAt = now()
+		subscription.LastSentAt = nil
+		reportSubscriptionId++
+		reportSubscriptions = append(reportSubscriptions, subscription)
+		if err := writeDataToJsonFile("report_subscriptions.json", reportSubscriptions); err != nil {
+			reportSubscriptions = reportSubscriptions[:len(reportSubscriptions)-1]
+			reportSubscriptionId--
+			errorResponse(w, http.StatusInternalServerError, "Failed to save report subscription data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "create", "reportSubscription", subscription.ID, nil, subscription)
+
+		successResponse(w, http.StatusCreated, "Report subscription created successfully", subscription)
+		logData("Report subscription created successfully", subscription)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// Handler for reading and removing a report subscription
+func reportSubscriptionItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid report subscription id")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := reportSubscriptionIndex(id)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Report subscription not found")
+			return
+		}
+		successResponse(w, http.StatusOK, "Report subscription retrieved successfully", reportSubscriptions[index])
+
+	case http.MethodDelete:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := reportSubscriptionIndex(id)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Report subscription not found")
+			return
+		}
+		previous, before := reportSubscriptions, reportSubscriptions[index]
+		reportSubscriptions = append(append([]ReportSubscription{}, reportSubscriptions[:index]...), reportSubscriptions[index+1:]...)
+		if err := writeDataToJsonFile("report_subscriptions.json", reportSubscriptions); err != nil {
+			reportSubscriptions = previous
+			errorResponse(w, http.StatusInternalServerError, "Failed to save report subscription data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "delete", "reportSubscription", id, before, nil)
+
+		successResponse(w, http.StatusOK, "Report subscription deleted successfully", before)
+		logData("Report subscription deleted successfully", before)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestReportSubscriptionValidation verifies malformed report subscriptions are rejected.
+func TestReportSubscriptionValidation(t *testing.T) {
+	setupTestEnvironment()
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"Weekly", `{"email":"Owner@Example.com","reports":["revenue","capacity"],"frequency":"weekly"}`, http.StatusCreated},
+		{"Bad Email", `{"email":"owner","reports":["revenue"],"frequency":"weekly"}`, http.StatusBadRequest},
+		{"Bad Frequency", `{"email":"owner@example.com","reports":["revenue"],"frequency":"daily"}`, http.StatusBadRequest},
+		{"No Reports", `{"email":"owner@example.com","reports":[],"frequency":"monthly"}`, http.StatusBadRequest},
+		{"Unknown Report", `{"email":"owner@example.com","reports":["payroll"],"frequency":"monthly"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			reportSubscriptionHandler(rec, httptest.NewRequest(http.MethodPost, "/admin/report-subscriptions", bytes.NewReader([]byte(tt.body))))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+	if len(reportSubscriptions) != 1 || reportSubscriptions[0].Email != "owner@example.com" {
+		t.Errorf("expected one normalized subscription, got %+v", reportSubscriptions)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/admin/report-subscriptions/1", nil)
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+	reportSubscriptionItemHandler(rec, req)
+	if rec.Code != http.StatusOK || len(reportSubscriptions) != 0 {
+		t.Errorf("expected the subscription to be deleted, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestScheduledReports verifies subscriptions are emailed the reports for the period just ended, once.
+func TestScheduledReports(t *testing.T) {
+	setupTestEnvironment()
+	defer func() { now = time.Now }()
+	type email struct{ to, subject, body string }
+	var sent []email
+	defer func(send func(to, subject, body string) error) { sendEmail = send }(sendEmail)
+	sendEmail = func(to, subject, body string) error {
+		sent = append(sent, email{to, subject, body})
+		return nil
+	}
+	bookings = []Booking{
+		{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("01-12-2099"), Status: bookingStatusAttended, Price: 1000, Total: 1000, Currency: "EUR"},
+		{ID: 2, MemberName: "Ben", ClassName: "Yoga", Date: testDate("08-12-2099"), Status: bookingStatusAttended, Price: 1000, Total: 1000, Currency: "EUR"},
+	}
+	reportSubscriptions = []ReportSubscription{
+		{ID: 1, Email: "owner@example.com", Reports: []string{"revenue", "retention"}, Frequency: reportWeekly},
+		{ID: 2, Email: "accounts@example.com", Reports: []string{"revenue"}, Frequency: reportMonthly},
+	}
+	reportSubscriptionId = 3
+
+	// Thursday 10-12-2099: last week was 30-11 to 06-12, last month November
+	now = func() time.Time { return time.Date(2099, 12, 10, 6, 0, 0, 0, time.UTC) }
+	if count := sendScheduledReports(); count != 2 || len(sent) != 2 {
+		t.Fatalf("expected both subscriptions to be sent, got %d: %+v", count, sent)
+	}
+	weekly := sent[0]
+	if weekly.to != "owner@example.com" || weekly.subject != "Your weekly reports for 30-11-2099 to 06-12-2099" {
+		t.Errorf("unexpected weekly email %+v", weekly)
+	}
+	if !strings.Contains(weekly.body, "revenue\ngroup,currency,bookings,gross,discounts,refunds,net\n01-12-2099,EUR,1,1000,0,0,1000\n") || !strings.Contains(weekly.body, "retention\nmonth,activeMembers") || strings.Contains(weekly.body, "08-12-2099") {
+		t.Errorf("expected last week's reports as CSV, got %s", weekly.body)
+	}
+	if monthly := sent[1]; monthly.subject != "Your monthly reports for 01-11-2099 to 30-11-2099" || strings.Contains(monthly.body, "01-12-2099,EUR") {
+		t.Errorf("expected November's revenue, got %+v", monthly)
+	}
+
+	// Nothing more is due until the next period ends
+	now = func() time.Time { return time.Date(2099, 12, 13, 6, 0, 0, 0, time.UTC) }
+	if count := sendScheduledReports(); count != 0 {
+		t.Errorf("expected no reports to be due, got %d", count)
+	}
+	now = func() time.Time { return time.Date(2099, 12, 14, 6, 0, 0, 0, time.UTC) }
+	if count := sendScheduledReports(); count != 1 || !strings.Contains(sent[2].body, "08-12-2099,EUR,1,1000") {
+		t.Errorf("expected the weekly report for the next week, got %d: %+v", count, sent[2:])
+	}
+}
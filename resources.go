@@ -0,0 +1,352 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Resource is something members book by the time slot rather than by class,
+// such as a squash court or a reformer machine. Its calendar is its opening
+// hours cut into slots, less the dates it is closed.
+type Resource struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Kind        string     `json:"kind,omitempty"`        // e.g. "court", "lane" or "machine"
+	Capacity    int        `json:"capacity"`              // Bookings each slot takes, e.g. 1 for a court or the number of machines
+	SlotMinutes int        `json:"slotMinutes"`           // Length of a slot
+	OpensAt     string     `json:"opensAt"`               // HH:MM the first slot starts
+	ClosesAt    string     `json:"closesAt"`              // HH:MM the last slot ends by
+	ClosedDates []Date     `json:"closedDates,omitempty"` // DD-MM-YYYY dates with no slots, e.g. for maintenance
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
+}
+
+var (
+	resources  []Resource // Temp Slice to hold bookable resources
+	resourceId = 1        // Incremental ID for resources
+)
+
+// resourceIndex returns the position of a resource, or -1.
+// Callers must hold the mutex.
+func resourceIndex(id int) int {
+	for i, resource := range resources {
+		if resource.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkResource validates the fields and calendar of a resource
+func checkResource(resource Resource) *requestRejection {
+	if strings.TrimSpace(resource.Name) == "" || resource.Capacity <= 0 || resource.SlotMinutes <= 0 {
+		return &requestRejection{http.StatusBadRequest, "Invalid data format, a resource needs a name, a positive capacity and slotMinutes"}
+	}
+	opens, errOpens := minuteOfDay(resource.OpensAt)
+	closes, errCloses := minuteOfDay(resource.ClosesAt)
+	if errOpens != nil || errCloses != nil {
+		return &requestRejection{http.StatusBadRequest, "Invalid opensAt or closesAt format, use HH:MM"}
+	}
+	if closes-opens < resource.SlotMinutes {
+		return &requestRejection{http.StatusBadRequest, "Opening hours must fit at least one slot"}
+	}
+	for _, date := range resource.ClosedDates {
+		if date.IsZero() {
+			return &requestRejection{http.StatusBadRequest, "Invalid closedDates format, use DD-MM-YYYY"}
+		}
+	}
+	return nil
+}
+
+// resourceSlots returns the HH:MM start times of a resource's slots on a
+// date, or none when it is closed that day
+func resourceSlots(resource Resource, date Date) []string {
+	if slices.Contains(resource.ClosedDates, date) {
+		return nil
+	}
+	opens, _ := minuteOfDay(resource.OpensAt)
+	closes, _ := minuteOfDay(resource.ClosesAt)
+	var slots []string
+	for start := opens; start+resource.SlotMinutes <= closes; start += resource.SlotMinutes {
+		slots = append(slots, fmt.Sprintf("%02d:%02d", start/60, start%60))
+	}
+	return slots
+}
+
+// countResourceBookings counts the active bookings of a resource's slot.
+// Callers must hold the mutex.
+func countResourceBookings(resourceID int, date Date, startTime string) int {
+	count := 0
+	for _, booking := range bookings {
+		if booking.ResourceID == resourceID && booking.Date == date && booking.StartTime == startTime && bookingActive(booking) {
+			count++
+		}
+	}
+	return count
+}
+
+// checkResourceBooking validates a booking of a resource and ensures the slot
+// has room. It returns the places left before the booking.
+// Callers must hold the mutex.
+func checkResourceBooking(newBooking Booking) (int, *requestRejection) {
+	if newBooking.MemberName == "" || newBooking.Date.IsZero() || newBooking.StartTime == "" || newBooking.ClassName != "" {
+		return 0, &requestRejection{http.StatusBadRequest, "Invalid field format, a resource booking needs a memberName, date and startTime"}
+	}
+	if newBooking.HoldID != 0 || newBooking.GroupID != 0 || len(newBooking.Rentals) > 0 {
+		return 0, &requestRejection{http.StatusBadRequest, "Holds, groups and rentals only apply to classes"}
+	}
+	if rejection := checkBlocked(newBooking.MemberName); rejection != nil {
+		return 0, rejection
+	}
+	index := resourceIndex(newBooking.ResourceID)
+	if index < 0 {
+		return 0, &requestRejection{http.StatusNotFound, "Resource not found"}
+	}
+	resource := &resources[index]
+	if !slices.Contains(resourceSlots(*resource, newBooking.Date), newBooking.StartTime) {
+		return 0, &requestRejection{http.StatusBadRequest, "Resource has no slot starting at this time on the specified date"}
+	}
+	if rejection := checkVerifiedEmail(newBooking.MemberName); rejection != nil {
+		return 0, rejection
+	}
+	if rejection := checkWaiver(newBooking.MemberName); rejection != nil {
+		return 0, rejection
+	}
+
+	availableSlots := resource.Capacity - countResourceBookings(resource.ID, newBooking.Date, newBooking.StartTime)
+	if availableSlots <= 0 {
+		return 0, &requestRejection{http.StatusBadRequest, "No available slots for the selected resource at this time"}
+	}
+	return availableSlots, nil
+}
+
+// resourceWindow returns when a resource booking starts and ends.
+// Callers must hold the mutex.
+func resourceWindow(booking Booking) (time.Time, time.Time, bool) {
+	index := resourceIndex(booking.ResourceID)
+	if index < 0 {
+		return time.Time{}, time.Time{}, false
+	}
+	start, err := time.Parse(dateLayout+" "+timeLayout, booking.Date.String()+" "+booking.StartTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, start.Add(time.Duration(resources[index].SlotMinutes) * time.Minute), true
+}
+
+// bookingTitle names what a booking is for: its class, or its resource and
+// slot. Callers must hold the mutex.
+func bookingTitle(booking Booking) string {
+	if booking.ResourceID == 0 {
+		return booking.ClassName
+	}
+	name := "Resource " + strconv.Itoa(booking.ResourceID)
+	if index := resourceIndex(booking.ResourceID); index >= 0 {
+		name = resources[index].Name
+	}
+	return name + " at " + booking.StartTime
+}
+
+// Handler for listing and adding resources
+func resourceHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		kind := r.URL.Query().Get("kind")
+		mutex.Lock()
+		response := []Resource{}
+		for _, resource := range resources {
+			if kind == "" || strings.EqualFold(resource.Kind, kind) {
+				response = append(response, resource)
+			}
+		}
+		mutex.Unlock()
+		successResponse(w, http.StatusOK, "Resources retrieved successfully", map[string]interface{}{"resources": response})
+
+	case http.MethodPost:
+		var resource Resource
+		if err := decodeBody(r, &resource); err != nil {
+			errorResponse(w, http.StatusBadRequest, bodyErrorMessage(err))
+			return
+		}
+		if rejection := checkResource(resource); rejection != nil {
+			errorResponse(w, rejection.StatusCode, rejection.Message)
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		resource.ID = resourceId
+		resource.CreatedAt = now()
+		resource.UpdatedAt = nil
+		resourceId++
+		resources = append(resources, resource)
+		if err := writeDataToJsonFile("resources.json", resources); err != nil {
+			resources = resources[:len(resources)-1]
+			resourceId--
+			errorResponse(w, http.StatusInternalServerError, "Failed to save resource data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "create", "resource", resource.ID, nil, resource)
+
+		successResponse(w, http.StatusCreated, "Resource created successfully", resource)
+		logData("Resource created successfully", resource)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// Handler for reading, changing and removing a resource
+func resourceItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid resource id")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := resourceIndex(id)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Resource not found")
+			return
+		}
+		successResponse(w, http.StatusOK, "Resource retrieved successfully", resources[index])
+
+	case http.MethodPut:
+		var request Resource
+		if err := decodeBody(r, &request); err != nil {
+			errorResponse(w, http.StatusBadRequest, bodyErrorMessage(err))
+			return
+		}
+		if rejection := checkResource(request); rejection != nil {
+			errorResponse(w, rejection.StatusCode, rejection.Message)
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := resourceIndex(id)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Resource not found")
+			return
+		}
+		// Booked slots must survive the change
+		before := resources[index]
+		request.ID, request.CreatedAt = before.ID, before.CreatedAt
+		for _, booking := range upcomingResourceBookings(id) {
+			if !slices.Contains(resourceSlots(request, booking.Date), booking.StartTime) || countResourceBookings(id, booking.Date, booking.StartTime) > request.Capacity {
+				errorResponse(w, http.StatusConflict, fmt.Sprintf("Booking %d on %s at %s would no longer fit, cancel it first", booking.ID, booking.Date, booking.StartTime))
+				return
+			}
+		}
+		updatedAt := now()
+		request.UpdatedAt = &updatedAt
+		resources[index] = request
+		if err := writeDataToJsonFile("resources.json", resources); err != nil {
+			resources[index] = before
+			errorResponse(w, http.StatusInternalServerError, "Failed to save resource data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "update", "resource", id, before, request)
+
+		successResponse(w, http.StatusOK, "Resource updated successfully", request)
+		logData("Resource updated successfully", request)
+
+	case http.MethodDelete:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := resourceIndex(id)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Resource not found")
+			return
+		}
+		if upcoming := upcomingResourceBookings(id); len(upcoming) > 0 {
+			errorResponse(w, http.StatusConflict, fmt.Sprintf("Resource has %d upcoming bookings, cancel them first", len(upcoming)))
+			return
+		}
+		previous, before := resources, resources[index]
+		resources = append(append([]Resource{}, resources[:index]...), resources[index+1:]...)
+		if err := writeDataToJsonFile("resources.json", resources); err != nil {
+			resources = previous
+			errorResponse(w, http.StatusInternalServerError, "Failed to save resource data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "delete", "resource", id, before, nil)
+
+		successResponse(w, http.StatusOK, "Resource deleted successfully", before)
+		logData("Resource deleted successfully", before)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// upcomingResourceBookings returns the active bookings of a resource from
+// today on. Callers must hold the mutex.
+func upcomingResourceBookings(resourceID int) []Booking {
+	var upcoming []Booking
+	for _, booking := range bookings {
+		if booking.ResourceID == resourceID && bookingActive(booking) && !booking.Date.Before(today()) {
+			upcoming = append(upcoming, booking)
+		}
+	}
+	return upcoming
+}
+
+// resourceSlotView is a slot of a resource with its remaining places
+type resourceSlotView struct {
+	StartTime      string `json:"startTime"`
+	Booked         int    `json:"booked"`
+	AvailableSlots int    `json:"availableSlots"`
+}
+
+// Handler for a resource's slots on a date
+func resourceAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid resource id")
+		return
+	}
+	date, err := parseDate(r.URL.Query().Get("date"))
+	if err != nil || date.IsZero() {
+		errorResponse(w, http.StatusBadRequest, "Invalid date format, use DD-MM-YYYY")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := resourceIndex(id)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+	resource := resources[index]
+	slots := []resourceSlotView{}
+	for _, startTime := range resourceSlots(resource, date) {
+		booked := countResourceBookings(id, date, startTime)
+		slots = append(slots, resourceSlotView{StartTime: startTime, Booked: booked, AvailableSlots: max(resource.Capacity-booked, 0)})
+	}
+	successResponse(w, http.StatusOK, "Resource availability retrieved successfully", map[string]interface{}{
+		"resource": resource,
+		"date":     date,
+		"slots":    slots,
+	})
+}
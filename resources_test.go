@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestResourceHandler verifies resources are validated when they are added.
+func TestResourceHandler(t *testing.T) {
+	setupTestEnvironment()
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"Valid Court", `{"name":"Court 1","kind":"court","capacity":1,"slotMinutes":45,"opensAt":"07:00","closesAt":"22:00"}`, http.StatusCreated},
+		{"Missing Capacity", `{"name":"Court 2","slotMinutes":45,"opensAt":"07:00","closesAt":"22:00"}`, http.StatusBadRequest},
+		{"Invalid Hours", `{"name":"Court 2","capacity":1,"slotMinutes":45,"opensAt":"7am","closesAt":"22:00"}`, http.StatusBadRequest},
+		{"No Whole Slot", `{"name":"Court 2","capacity":1,"slotMinutes":90,"opensAt":"07:00","closesAt":"08:00"}`, http.StatusBadRequest},
+		{"Invalid Closed Date", `{"name":"Court 2","capacity":1,"slotMinutes":45,"opensAt":"07:00","closesAt":"22:00","closedDates":["2099-12-25"]}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			resourceHandler(rec, httptest.NewRequest(http.MethodPost, "/resources", bytes.NewReader([]byte(tt.body))))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+	if len(resources) != 1 || resources[0].ID != 1 {
+		t.Errorf("expected only the valid resource to be added, got %+v", resources)
+	}
+}
+
+// TestResourceBooking verifies resource slots are booked through the booking pipeline.
+func TestResourceBooking(t *testing.T) {
+	setupTestEnvironment()
+	resources = []Resource{
+		{ID: 1, Name: "Reformer", Kind: "machine", Capacity: 2, SlotMinutes: 60, OpensAt: "08:00", ClosesAt: "12:00", ClosedDates: []Date{testDate("25-12-2099")}},
+		{ID: 2, Name: "Court 1", Kind: "court", Capacity: 1, SlotMinutes: 30, OpensAt: "08:00", ClosesAt: "12:00"},
+	}
+	resourceId = 3
+	classes = []Class{{ID: 1, ClassName: "Spin", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10, StartTime: "09:30"}}
+	classId = 2
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"Book Slot", `{"memberName":"Ann","resourceId":1,"date":"01-12-2099","startTime":"08:00"}`, http.StatusCreated},
+		{"Second Machine", `{"memberName":"Ben","resourceId":1,"date":"01-12-2099","startTime":"08:00"}`, http.StatusCreated},
+		{"Slot Full", `{"memberName":"Cat","resourceId":1,"date":"01-12-2099","startTime":"08:00"}`, http.StatusBadRequest},
+		{"Not A Slot Start", `{"memberName":"Cat","resourceId":1,"date":"01-12-2099","startTime":"08:30"}`, http.StatusBadRequest},
+		{"After Closing", `{"memberName":"Cat","resourceId":1,"date":"01-12-2099","startTime":"12:00"}`, http.StatusBadRequest},
+		{"Closed Date", `{"memberName":"Cat","resourceId":1,"date":"25-12-2099","startTime":"08:00"}`, http.StatusBadRequest},
+		{"Unknown Resource", `{"memberName":"Cat","resourceId":9,"date":"01-12-2099","startTime":"08:00"}`, http.StatusNotFound},
+		{"Missing Start Time", `{"memberName":"Dan","resourceId":1,"date":"01-12-2099"}`, http.StatusBadRequest},
+		{"Class And Resource", `{"memberName":"Dan","resourceId":1,"className":"Spin","date":"01-12-2099","startTime":"09:00"}`, http.StatusBadRequest},
+		// Ann is on the reformer from 08:00 to 09:00
+		{"Overlapping Slot", `{"memberName":"Ann","resourceId":2,"date":"01-12-2099","startTime":"08:30"}`, http.StatusConflict},
+		{"Adjacent Slot", `{"memberName":"Ann","resourceId":2,"date":"01-12-2099","startTime":"09:00"}`, http.StatusCreated},
+		// The court slot from 09:00 to 09:30 ends as Spin starts
+		{"Class After Slot", `{"memberName":"Ann","className":"Spin","date":"01-12-2099"}`, http.StatusCreated},
+		{"Slot During Class", `{"memberName":"Ann","resourceId":1,"date":"01-12-2099","startTime":"10:00"}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(tt.body))))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/resources/1/availability?date=01-12-2099", nil)
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+	resourceAvailabilityHandler(rec, req)
+	var response struct {
+		Data struct {
+			Slots []resourceSlotView `json:"slots"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode availability: %v", err)
+	}
+	if len(response.Data.Slots) != 4 || response.Data.Slots[0].AvailableSlots != 0 || response.Data.Slots[1].AvailableSlots != 2 {
+		t.Errorf("expected the 08:00 slot to be full and the rest open, got %+v", response.Data.Slots)
+	}
+
+	// Booked resources cannot lose the slots their bookings are in
+	req = httptest.NewRequest(http.MethodPut, "/resources/1", bytes.NewReader([]byte(`{"name":"Reformer","capacity":1,"slotMinutes":60,"opensAt":"08:00","closesAt":"12:00"}`)))
+	req.SetPathValue("id", "1")
+	rec = httptest.NewRecorder()
+	resourceItemHandler(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected shrinking below the bookings to conflict, got %d", rec.Code)
+	}
+	req = httptest.NewRequest(http.MethodDelete, "/resources/1", nil)
+	req.SetPathValue("id", "1")
+	rec = httptest.NewRecorder()
+	resourceItemHandler(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected deleting a booked resource to conflict, got %d", rec.Code)
+	}
+}
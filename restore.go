@@ -0,0 +1,283 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+)
+
+// maxBackupSize bounds the size of an uploaded backup archive
+const maxBackupSize = 100 << 20
+
+// Bounds on the unpacked contents of a backup archive, so a small archive
+// cannot decompress into more data than the service can hold in memory
+var (
+	maxBackupFileSize int64 = 256 << 20 // Largest single file
+	maxBackupDataSize int64 = 1 << 30   // All files together
+)
+
+// readBackupArchive unpacks a backup archive and validates its contents
+func readBackupArchive(r io.Reader) (backupManifest, map[string][]byte, error) {
+	var manifest backupManifest
+
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return manifest, nil, errors.New("not a gzip archive")
+	}
+	tarReader := tar.NewReader(gzipReader)
+
+	files := map[string][]byte{}
+	remaining := maxBackupDataSize
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return manifest, nil, errors.New("corrupt tar archive")
+		}
+		// Only known file names are accepted, which also rules out path traversal
+		if header.Name != backupManifestName && !slices.Contains(dataFiles, header.Name) {
+			return manifest, nil, fmt.Errorf("unexpected file %q", header.Name)
+		}
+		// One byte past the limit tells a file that fits from one that does not
+		limit := min(maxBackupFileSize, remaining)
+		data, err := io.ReadAll(io.LimitReader(tarReader, limit+1))
+		if err != nil {
+			return manifest, nil, errors.New("corrupt tar archive")
+		}
+		if int64(len(data)) > limit {
+			if limit == maxBackupFileSize {
+				return manifest, nil, fmt.Errorf("file %q is too large", header.Name)
+			}
+			return manifest, nil, errors.New("archive is too large when unpacked")
+		}
+		remaining -= int64(len(data))
+		files[header.Name] = data
+	}
+
+	manifestData, ok := files[backupManifestName]
+	if !ok {
+		return manifest, nil, errors.New("missing manifest")
+	}
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return manifest, nil, errors.New("invalid manifest")
+	}
+	delete(files, backupManifestName)
+
+	for _, fileName := range manifest.Files {
+		if _, ok := files[fileName]; !ok {
+			return manifest, nil, fmt.Errorf("missing file %q", fileName)
+		}
+	}
+	for fileName, data := range files {
+		if err := validateDataFile(fileName, data); err != nil {
+			return manifest, nil, fmt.Errorf("invalid %s: %w", fileName, err)
+		}
+	}
+	return manifest, files, nil
+}
+
+// validateDataFile checks that file contents decode into the expected type
+func validateDataFile(fileName string, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	data, err := decryptData(data)
+	if err != nil {
+		return err
+	}
+	return decodeData(data, dataFileDestination(fileName))
+}
+
+// dataFileDestination returns a pointer to a new value of the type held in a
+// data file
+func dataFileDestination(fileName string) interface{} {
+	var destination interface{}
+	switch fileName {
+	case "classes.json":
+		destination = &[]Class{}
+	case "bookings.json":
+		destination = &[]Booking{}
+	case "audit.json":
+		destination = &[]AuditEntry{}
+	case "booking_events.json":
+		destination = &[]BookingEvent{}
+	case "templates.json":
+		destination = &[]ClassTemplate{}
+	case "holds.json":
+		destination = &[]Hold{}
+	case "waitlist.json":
+		destination = &[]WaitlistEntry{}
+	case "instructor_absences.json":
+		destination = &[]InstructorAbsence{}
+	case "taxonomy.json":
+		destination = &classTaxonomy{}
+	case "members.json":
+		destination = &[]Member{}
+	case "credentials.json":
+		destination = &[]Credential{}
+	case "sessions.json":
+		destination = &[]Session{}
+	case "api_keys.json":
+		destination = &[]APIKey{}
+	case "blocks.json":
+		destination = &[]MemberBlock{}
+	case "waivers.json":
+		destination = &[]WaiverAcceptance{}
+	case "devices.json":
+		destination = &[]Device{}
+	case "webhooks.json":
+		destination = &[]WebhookSubscription{}
+	case "outbox.json":
+		destination = &[]OutboxRecord{}
+	case outboxMarkFile:
+		destination = new(int)
+	case "consumed_events.json":
+		destination = &[]ConsumedEvent{}
+	case "class_sessions.json":
+		destination = &[]ClassSession{}
+	case "rooms.json":
+		destination = &[]Room{}
+	case "resources.json":
+		destination = &[]Resource{}
+	case "studios.json":
+		destination = &[]Studio{}
+	case "credits.json":
+		destination = &[]CreditEntry{}
+	case "refunds.json":
+		destination = &[]Refund{}
+	case "giftcards.json":
+		destination = &[]GiftCard{}
+	case "referrals.json":
+		destination = &[]Referral{}
+	case "points.json":
+		destination = &[]PointsEntry{}
+	case "pricing_rules.json":
+		destination = &[]PricingRule{}
+	case "promos.json":
+		destination = &[]Promo{}
+	case "promo_redemptions.json":
+		destination = &[]PromoRedemption{}
+	case "report_subscriptions.json":
+		destination = &[]ReportSubscription{}
+	case "closures.json":
+		destination = &[]Closure{}
+	case "announcements.json":
+		destination = &[]Announcement{}
+	case "reviews.json":
+		destination = &[]Review{}
+	case "instructors.json":
+		destination = &[]Instructor{}
+	case "extra_sessions.json":
+		destination = &[]ExtraSession{}
+	case "waitlist_history.json":
+		destination = &[]WaitlistOutcome{}
+	case archiveFile:
+		destination = &classArchive{}
+	case retentionArchiveFile:
+		destination = &retentionArchive{}
+	default:
+		destination = new(interface{})
+	}
+	return destination
+}
+
+// applyBackup swaps the backed up files in and reloads the in-memory state.
+// Data files missing from the backup did not exist when it was taken and are removed.
+// Callers must hold the mutex.
+func applyBackup(files map[string][]byte) error {
+	// Stage every file first so a failed write leaves the current data untouched
+	for fileName, data := range files {
+		if err := os.WriteFile(fileName+".restore", data, 0666); err != nil {
+			for staged := range files {
+				os.Remove(staged + ".restore")
+			}
+			return err
+		}
+	}
+
+	for _, fileName := range dataFiles {
+		if _, ok := files[fileName]; ok {
+			if err := os.Rename(fileName+".restore", fileName); err != nil {
+				return err
+			}
+		} else if err := os.Remove(fileName); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return loadData()
+}
+
+// restoreLatest names the newest automatic backup in place of an archive path
+const restoreLatest = "latest"
+
+// restoreBackupFile restores the data files from a backup archive on disk, or
+// from the newest automatic backup when the path is "latest"
+func restoreBackupFile(path string) error {
+	var archive io.Reader
+	if path == restoreLatest {
+		data, err := latestBackup()
+		if err != nil {
+			return err
+		}
+		archive = bytes.NewReader(data)
+	} else {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		archive = file
+	}
+
+	_, files, err := readBackupArchive(archive)
+	if err != nil {
+		return fmt.Errorf("invalid backup archive: %w", err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	return applyBackup(files)
+}
+
+// Handler for restoring an uploaded backup archive
+func restoreHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	manifest, files, err := readBackupArchive(http.MaxBytesReader(w, r.Body, maxBackupSize))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid backup archive: "+err.Error())
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if err := applyBackup(files); err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to restore backup")
+		return
+	}
+
+	response := map[string]interface{}{
+		"backupCreatedAt": manifest.CreatedAt,
+		"files":           manifest.Files,
+		"classes":         len(classes),
+		"bookings":        len(bookings),
+	}
+	recordAudit(actorFromRequest(r), "restore", "backup", 0, nil, response)
+
+	successResponse(w, http.StatusOK, "Backup restored successfully", response)
+	logData("Backup restored successfully", response)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRestoreHandler verifies a backup can be restored and state rebuilt.
+func TestRestoreHandler(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 4, ClassName: "Pilates", StartDate: testDate("15-12-2024"), EndDate: testDate("20-12-2024"), Capacity: 10}}
+	bookings = []Booking{{ID: 7, MemberName: "John Doe", Date: testDate("16-12-2024"), ClassName: "Pilates"}}
+	writeDataToJsonFile("classes.json", classes)
+	writeDataToJsonFile("bookings.json", bookings)
+
+	var backup bytes.Buffer
+	if err := writeBackup(&backup); err != nil {
+		t.Fatalf("unexpected error creating backup: %v", err)
+	}
+
+	// Lose the data after the backup was taken.
+	setupTestEnvironment()
+
+	rec := httptest.NewRecorder()
+	restoreHandler(rec, httptest.NewRequest(http.MethodPost, "/admin/restore", bytes.NewReader(backup.Bytes())))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if len(classes) != 1 || len(bookings) != 1 {
+		t.Fatalf("expected the backed up data to be restored, got %+v %+v", classes, bookings)
+	}
+	if classId != 5 || bookingId != 8 {
+		t.Errorf("expected the ID counters to continue after restored IDs, got %d and %d", classId, bookingId)
+	}
+	if last := auditEntries[len(auditEntries)-1]; last.Action != "restore" || last.Entity != "backup" {
+		t.Errorf("expected the restore to be audited, got %+v", last)
+	}
+}
+
+// TestRestoreRejectsInvalidArchives verifies invalid uploads leave the data untouched.
+func TestRestoreRejectsInvalidArchives(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("15-12-2024"), EndDate: testDate("20-12-2024"), Capacity: 10}}
+	writeDataToJsonFile("classes.json", classes)
+
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{name: "Not An Archive", body: []byte("not a backup")},
+		{name: "Empty Body", body: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			restoreHandler(rec, httptest.NewRequest(http.MethodPost, "/admin/restore", bytes.NewReader(tt.body)))
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rec.Code)
+			}
+			if len(classes) != 1 {
+				t.Errorf("expected the existing data to be kept, got %+v", classes)
+			}
+		})
+	}
+}
+
+// TestRestoreRejectsOversizedArchives verifies archives unpacking past the size limits are refused.
+func TestRestoreRejectsOversizedArchives(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("15-12-2024"), EndDate: testDate("20-12-2024"), Capacity: 10}}
+	writeDataToJsonFile("classes.json", classes)
+	writeDataToJsonFile("bookings.json", []Booking{{ID: 1, MemberName: "Ann", Date: testDate("16-12-2024"), ClassName: "Yoga"}})
+	var backup bytes.Buffer
+	if err := writeBackup(&backup); err != nil {
+		t.Fatalf("unexpected error creating backup: %v", err)
+	}
+	defer func(file, data int64) { maxBackupFileSize, maxBackupDataSize = file, data }(maxBackupFileSize, maxBackupDataSize)
+
+	tests := []struct {
+		name     string
+		fileSize int64
+		dataSize int64
+		message  string
+	}{
+		{name: "File Too Large", fileSize: 16, dataSize: 1 << 20, message: "is too large"},
+		{name: "Archive Too Large", fileSize: 1 << 20, dataSize: 64, message: "archive is too large when unpacked"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxBackupFileSize, maxBackupDataSize = tt.fileSize, tt.dataSize
+			rec := httptest.NewRecorder()
+			restoreHandler(rec, httptest.NewRequest(http.MethodPost, "/admin/restore", bytes.NewReader(backup.Bytes())))
+
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), tt.message) {
+				t.Errorf("expected the archive to be refused with %q, got %d: %s", tt.message, rec.Code, rec.Body.String())
+			}
+			if len(classes) != 1 {
+				t.Errorf("expected the existing data to be kept, got %+v", classes)
+			}
+		})
+	}
+}
+
+// TestRestoreBackupFile verifies the startup restore reads an archive from disk.
+func TestRestoreBackupFile(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 2, ClassName: "Dance", StartDate: testDate("15-12-2024"), EndDate: testDate("20-12-2024"), Capacity: 10}}
+	writeDataToJsonFile("classes.json", classes)
+
+	path := filepath.Join(t.TempDir(), "backup.tar.gz")
+	file, _ := os.Create(path)
+	writeBackup(file)
+	file.Close()
+
+	setupTestEnvironment()
+	if err := restoreBackupFile(path); err != nil {
+		t.Fatalf("unexpected error restoring: %v", err)
+	}
+	if len(classes) != 1 || classes[0].ClassName != "Dance" {
+		t.Errorf("expected the class to be restored, got %+v", classes)
+	}
+}
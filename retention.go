@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+)
+
+// retentionArchive holds records permanently removed by the retention cleanup
+type retentionArchive struct {
+	Classes  []Class        `json:"classes"`
+	Sessions []ClassSession `json:"sessions,omitempty"`
+	Bookings []Booking      `json:"bookings"`
+}
+
+// retentionArchiveFile receives purged records before they are removed
+const retentionArchiveFile = "retention_archive.json"
+
+// purgeExpiredData permanently removes soft-deleted classes past their grace
+// period and bookings older than the retention period, archiving them first.
+// It returns the number of classes and bookings removed.
+func purgeExpiredData() (int, int, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	day := today()
+	graceCutoff := now().AddDate(0, 0, -config.SoftDeleteGraceDays)
+	bookingCutoff := day.AddDate(0, 0, -config.RetentionDays)
+
+	var keptClasses, purgedClasses []Class
+	for _, class := range classes {
+		if class.DeletedAt != nil && class.DeletedAt.Before(graceCutoff) {
+			purgedClasses = append(purgedClasses, class)
+		} else {
+			keptClasses = append(keptClasses, class)
+		}
+	}
+
+	var keptBookings, purgedBookings []Booking
+	for _, booking := range bookings {
+		if config.RetentionDays > 0 && booking.Date.Before(bookingCutoff) {
+			purgedBookings = append(purgedBookings, booking)
+		} else {
+			keptBookings = append(keptBookings, booking)
+		}
+	}
+
+	if len(purgedClasses) == 0 && len(purgedBookings) == 0 {
+		return 0, 0, nil
+	}
+
+	keptSessions, purgedSessions := splitSessions(purgedClasses)
+
+	// Write the purged records to the archive before removing them
+	var archive retentionArchive
+	if err := dataFromJsonFile(retentionArchiveFile, &archive); err != nil {
+		return 0, 0, err
+	}
+	archive.Classes = append(archive.Classes, purgedClasses...)
+	archive.Sessions = append(archive.Sessions, purgedSessions...)
+	archive.Bookings = append(archive.Bookings, purgedBookings...)
+	if err := writeDataToJsonFile(retentionArchiveFile, archive); err != nil {
+		return 0, 0, err
+	}
+
+	if keptClasses == nil {
+		keptClasses = []Class{}
+	}
+	if keptBookings == nil {
+		keptBookings = []Booking{}
+	}
+	if err := writeDataToJsonFile("classes.json", keptClasses); err != nil {
+		return 0, 0, err
+	}
+	classes = keptClasses
+	if err := writeDataToJsonFile("class_sessions.json", keptSessions); err != nil {
+		return 0, 0, err
+	}
+	classSessions = keptSessions
+	if err := writeDataToJsonFile("bookings.json", keptBookings); err != nil {
+		return 0, 0, err
+	}
+	bookings = keptBookings
+
+	counts := map[string]int{"classes": len(purgedClasses), "bookings": len(purgedBookings)}
+	recordAudit("system", "purge", "retention", 0, nil, counts)
+	logData("Retention cleanup completed", counts)
+	return len(purgedClasses), len(purgedBookings), nil
+}
+
+// runRetentionCleanup is the scheduled entry point for purgeExpiredData
+func runRetentionCleanup() {
+	if _, _, err := purgeExpiredData(); err != nil {
+		fmt.Println("Error running retention cleanup:", err)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestPurgeExpiredData verifies stale records are archived and removed.
+func TestPurgeExpiredData(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2024, 12, 16, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	config.RetentionDays = 30
+	config.SoftDeleteGraceDays = 7
+
+	longAgo := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	recently := time.Date(2024, 12, 14, 0, 0, 0, 0, time.UTC)
+	classes = []Class{
+		{ID: 1, ClassName: "Pilates", StartDate: testDate("01-10-2024"), EndDate: testDate("31-12-2024"), Capacity: 10},
+		{ID: 2, ClassName: "Yoga", StartDate: testDate("01-10-2024"), EndDate: testDate("31-12-2024"), Capacity: 10, DeletedAt: &longAgo},
+		{ID: 3, ClassName: "Dance", StartDate: testDate("01-10-2024"), EndDate: testDate("31-12-2024"), Capacity: 10, DeletedAt: &recently},
+	}
+	bookings = []Booking{
+		{ID: 1, MemberName: "A", Date: testDate("01-10-2024"), ClassName: "Pilates"},
+		{ID: 2, MemberName: "B", Date: testDate("16-11-2024"), ClassName: "Pilates"},
+		{ID: 3, MemberName: "C", Date: testDate("16-12-2024"), ClassName: "Pilates"},
+	}
+
+	purgedClasses, purgedBookings, err := purgeExpiredData()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if purgedClasses != 1 || purgedBookings != 1 {
+		t.Errorf("expected 1 class and 1 booking purged, got %d and %d", purgedClasses, purgedBookings)
+	}
+	if len(classes) != 2 || classIndex(2) >= 0 {
+		t.Errorf("expected class 2 to be purged, got %+v", classes)
+	}
+	if len(bookings) != 2 || bookings[0].ID != 2 {
+		t.Errorf("expected booking 1 to be purged, got %+v", bookings)
+	}
+
+	// The purged records are kept in the archive file.
+	var archive retentionArchive
+	if err := dataFromJsonFile(retentionArchiveFile, &archive); err != nil {
+		t.Fatalf("unexpected error reading archive: %v", err)
+	}
+	if len(archive.Classes) != 1 || archive.Classes[0].ID != 2 || len(archive.Bookings) != 1 || archive.Bookings[0].ID != 1 {
+		t.Errorf("expected purged records in the archive, got %+v", archive)
+	}
+
+	// Zero retention keeps bookings forever.
+	config.RetentionDays = 0
+	if _, purgedBookings, _ := purgeExpiredData(); purgedBookings != 0 {
+		t.Errorf("expected no bookings purged with retention disabled, got %d", purgedBookings)
+	}
+
+	// IDs of purged records are not handed out again after a reload.
+	archive.Classes[0].ID, archive.Bookings[0].ID = 7, 9
+	writeDataToJsonFile(retentionArchiveFile, archive)
+	if err := loadData(); err != nil {
+		t.Fatalf("unexpected error reloading data: %v", err)
+	}
+	if classId != 8 || bookingId != 10 {
+		t.Errorf("expected counters past the purged IDs, got %d and %d", classId, bookingId)
+	}
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxReviewLength caps the characters of a review's comment
+const maxReviewLength = 2000
+
+// Review moderation states. Reviews are held as pending until approved, and
+// only approved reviews are shown or counted in ratings.
+const (
+	reviewPending  = "pending"
+	reviewApproved = "approved"
+	reviewHidden   = "hidden"
+	reviewFlagged  = "flagged" // Held for a closer look
+)
+
+// reviewModerations maps each moderation action to the state it leaves a review in
+var reviewModerations = map[string]string{
+	"approve": reviewApproved,
+	"hide":    reviewHidden,
+	"flag":    reviewFlagged,
+}
+
+// Review is a member's rating, from 1 to 5, of a class session they attended
+type Review struct {
+	ID             int        `json:"id"`
+	ClassID        int        `json:"classId"`
+	ClassName      string     `json:"className"`
+	BookingID      int        `json:"bookingId"`
+	MemberName     string     `json:"memberName"`
+	Date           Date       `json:"date"` // DD-MM-YYYY of the session attended
+	Rating         int        `json:"rating"`
+	Comment        string     `json:"comment,omitempty"`
+	Status         string     `json:"status,omitempty"` // Reviews from before moderation have none and count as approved
+	CreatedAt      time.Time  `json:"createdAt"`
+	ModeratedBy    string     `json:"moderatedBy,omitempty"`
+	ModeratedAt    *time.Time `json:"moderatedAt,omitempty"`
+	ModerationNote string     `json:"moderationNote,omitempty"`
+}
+
+// ratingSummary is the average rating of a class and how many reviews it has
+type ratingSummary struct {
+	Average float64 `json:"average"`
+	Count   int     `json:"count"`
+}
+
+// classListing is a class as listed, with its rating once it has been reviewed
+type classListing struct {
+	Class
+	Rating *ratingSummary `json:"rating,omitempty"`
+}
+
+var (
+	reviews  []Review // Temp Slice to hold reviews
+	reviewId = 1      // Incremental ID for reviews
+)
+
+// reviewStatus returns the moderation state of a review
+func reviewStatus(review Review) string {
+	if review.Status == "" {
+		return reviewApproved
+	}
+	return review.Status
+}
+
+// reviewIndex returns the position of the review with the given ID, or -1.
+// Callers must hold the mutex.
+func reviewIndex(id int) int {
+	for i, review := range reviews {
+		if review.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// classRatings returns the rating summary of every class with approved
+// reviews, by class ID. Callers must hold the mutex.
+func classRatings() map[int]*ratingSummary {
+	totals := map[int]int{}
+	ratings := map[int]*ratingSummary{}
+	for _, review := range reviews {
+		if reviewStatus(review) != reviewApproved {
+			continue
+		}
+		if ratings[review.ClassID] == nil {
+			ratings[review.ClassID] = &ratingSummary{}
+		}
+		ratings[review.ClassID].Count++
+		totals[review.ClassID] += review.Rating
+	}
+	for id, rating := range ratings {
+		rating.Average = statsAverage(totals[id], rating.Count)
+	}
+	return ratings
+}
+
+// Handler for a member reviewing a session they attended. Only the member who
+// attended can review, once per booking, and the review waits for approval.
+func reviewBookingHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid booking id")
+		return
+	}
+	var request struct {
+		Rating  int    `json:"rating"`
+		Comment string `json:"comment"`
+	}
+	if err := decodeBody(r, &request); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if request.Rating < 1 || request.Rating > 5 {
+		errorResponse(w, http.StatusBadRequest, "Invalid rating, it must be from 1 to 5")
+		return
+	}
+	request.Comment = strings.TrimSpace(request.Comment)
+	if len([]rune(request.Comment)) > maxReviewLength {
+		errorResponse(w, http.StatusBadRequest, "Review is too long, the maximum is "+strconv.Itoa(maxReviewLength)+" characters")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := bookingIndex(id)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Booking not found")
+		return
+	}
+	booking := bookings[index]
+	// Signed-in members and impersonating admins may only review their own sessions
+	if member := requestMember(r); member != "" && member != booking.MemberName {
+		errorResponse(w, http.StatusForbidden, "Only the member who attended can review the session")
+		return
+	}
+	if bookingStatus(booking) != bookingStatusAttended {
+		errorResponse(w, http.StatusForbidden, "Only sessions the member attended can be reviewed")
+		return
+	}
+	for _, review := range reviews {
+		if review.BookingID == booking.ID {
+			errorResponse(w, http.StatusConflict, "Booking has already been reviewed")
+			return
+		}
+	}
+	class := findClassOn(booking.ClassName, booking.Date.Time)
+	if class == nil {
+		errorResponse(w, http.StatusNotFound, "Class not found")
+		return
+	}
+
+	review := Review{
+		ID:         reviewId,
+		ClassID:    class.ID,
+		ClassName:  class.ClassName,
+		BookingID:  booking.ID,
+		MemberName: booking.MemberName,
+		Date:       booking.Date,
+		Rating:     request.Rating,
+		Comment:    request.Comment,
+		Status:     reviewPending,
+		CreatedAt:  now(),
+	}
+	reviewId++
+	reviews = append(reviews, review)
+	if err := writeDataToJsonFile("reviews.json", reviews); err != nil {
+		reviews = reviews[:len(reviews)-1]
+		reviewId--
+		errorResponse(w, http.StatusInternalServerError, "Failed to save review data")
+		return
+	}
+	recordAudit(actorFromRequest(r), "create", "review", review.ID, nil, review)
+
+	successResponse(w, http.StatusCreated, "Review submitted successfully", review)
+	logData("Review submitted successfully", review)
+}
+
+// Handler for listing the approved reviews of a class, newest first, with its
+// rating
+func classReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid class id")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := classIndex(id)
+	if index < 0 || classes[index].DeletedAt != nil {
+		errorResponse(w, http.StatusNotFound, "Class not found")
+		return
+	}
+	classReviews := []Review{}
+	for i := len(reviews) - 1; i >= 0; i-- {
+		if reviews[i].ClassID == id && reviewStatus(reviews[i]) == reviewApproved {
+			classReviews = append(classReviews, reviews[i])
+		}
+	}
+	rating := ratingSummary{}
+	if summary := classRatings()[id]; summary != nil {
+		rating = *summary
+	}
+
+	successResponse(w, http.StatusOK, "Reviews retrieved successfully", map[string]interface{}{"rating": rating, "reviews": classReviews})
+}
+
+// Handler for listing reviews for moderation, optionally by ?status=, oldest
+// first so the longest waiting are seen first
+func adminReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && status != reviewPending && status != reviewApproved && status != reviewHidden && status != reviewFlagged {
+		errorResponse(w, http.StatusBadRequest, "Invalid status, use pending, approved, hidden or flagged")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	matching := []Review{}
+	for _, review := range reviews {
+		if status == "" || reviewStatus(review) == status {
+			matching = append(matching, review)
+		}
+	}
+	successResponse(w, http.StatusOK, "Reviews retrieved successfully", map[string]interface{}{"reviews": matching})
+}
+
+// Handler for approving, hiding or flagging a review, with an optional note
+// for other moderators
+func moderateReviewHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	action := r.PathValue("action")
+	status, ok := reviewModerations[action]
+	if !ok {
+		errorResponse(w, http.StatusNotFound, "Not found")
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid review id")
+		return
+	}
+	var request struct {
+		Note string `json:"note"`
+	}
+	// The note is optional, so an empty body is accepted
+	if err := decodeBody(r, &request); err != nil && err != io.EOF {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := reviewIndex(id)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Review not found")
+		return
+	}
+	before := reviews[index]
+	if reviewStatus(before) == status {
+		errorResponse(w, http.StatusConflict, "Review is already "+status)
+		return
+	}
+
+	actor := actorFromRequest(r)
+	moderatedAt := now()
+	reviews[index].Status = status
+	reviews[index].ModeratedBy = actor
+	reviews[index].ModeratedAt = &moderatedAt
+	reviews[index].ModerationNote = strings.TrimSpace(request.Note)
+	if err := writeDataToJsonFile("reviews.json", reviews); err != nil {
+		reviews[index] = before
+		errorResponse(w, http.StatusInternalServerError, "Failed to save review data")
+		return
+	}
+	recordAudit(actor, action, "review", id, before, reviews[index])
+
+	successResponse(w, http.StatusOK, "Review moderated successfully", reviews[index])
+	logData("Review moderated successfully", reviews[index])
+}
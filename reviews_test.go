@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestClassReviews verifies only attendees can review a session, once, and classes are listed with their rating.
+func TestClassReviews(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{
+		{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 5},
+		{ID: 2, ClassName: "Pilates", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 5},
+	}
+	bookings = []Booking{
+		{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusAttended},
+		{ID: 2, MemberName: "Ben", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusAttended},
+		{ID: 3, MemberName: "Cat", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed},
+	}
+	token, _, err := startSession("Ann")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	review := func(id, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/bookings/"+id+"/review", bytes.NewReader([]byte(body)))
+		req.SetPathValue("id", id)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		reviewBookingHandler(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name           string
+		id             string
+		body           string
+		token          string
+		expectedStatus int
+	}{
+		{"Rating Out Of Range", "1", `{"rating":6}`, "", http.StatusBadRequest},
+		{"Not Attended", "3", `{"rating":4}`, "", http.StatusForbidden},
+		{"Someone Else's Booking", "2", `{"rating":4}`, token, http.StatusForbidden},
+		{"Attendee Reviews", "1", `{"rating":5,"comment":"Great class"}`, token, http.StatusCreated},
+		{"Reviewed Twice", "1", `{"rating":4}`, token, http.StatusConflict},
+		{"Second Attendee Reviews", "2", `{"rating":4}`, "", http.StatusCreated},
+	}
+	for _, tt := range tests {
+		if rec := review(tt.id, tt.body, tt.token); rec.Code != tt.expectedStatus {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.expectedStatus, rec.Code, rec.Body.String())
+		}
+	}
+
+	// Ratings count reviews once they are approved
+	for i := range reviews {
+		reviews[i].Status = reviewApproved
+	}
+	req := httptest.NewRequest(http.MethodGet, "/classes", nil)
+	rec := httptest.NewRecorder()
+	listClasses(rec, req)
+	var response struct {
+		Data struct {
+			Classes []classListing `json:"classes"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&response)
+	if len(response.Data.Classes) != 2 {
+		t.Fatalf("expected 2 classes, got %s", rec.Body.String())
+	}
+	if rating := response.Data.Classes[0].Rating; rating == nil || rating.Average != 4.5 || rating.Count != 2 {
+		t.Errorf("expected Yoga to be rated 4.5 from 2 reviews, got %+v", rating)
+	}
+	if response.Data.Classes[1].Rating != nil {
+		t.Errorf("expected Pilates to have no rating, got %+v", response.Data.Classes[1].Rating)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/classes/1/reviews", nil)
+	req.SetPathValue("id", "1")
+	rec = httptest.NewRecorder()
+	classReviewsHandler(rec, req)
+	if rec.Code != http.StatusOK || !bytes.Contains(rec.Body.Bytes(), []byte("Great class")) {
+		t.Errorf("expected the class reviews, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestReviewModeration verifies pending and hidden reviews are left out of ratings and moderation is audited.
+func TestReviewModeration(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 5}}
+	bookings = []Booking{
+		{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusAttended},
+		{ID: 2, MemberName: "Ben", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusAttended},
+	}
+	for _, id := range []string{"1", "2"} {
+		req := httptest.NewRequest(http.MethodPost, "/bookings/"+id+"/review", bytes.NewReader([]byte(`{"rating":`+id+`}`)))
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+		reviewBookingHandler(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected the review to be submitted, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	if len(classRatings()) != 0 {
+		t.Errorf("expected pending reviews to be left out of ratings, got %+v", classRatings()[1])
+	}
+
+	moderate := func(id, action, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/reviews/"+id+"/"+action, bytes.NewReader([]byte(body)))
+		req.SetPathValue("id", id)
+		req.SetPathValue("action", action)
+		rec := httptest.NewRecorder()
+		moderateReviewHandler(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name           string
+		id             string
+		action         string
+		body           string
+		expectedStatus int
+	}{
+		{"Unknown Action", "1", "delete", "", http.StatusNotFound},
+		{"Unknown Review", "9", "approve", "", http.StatusNotFound},
+		{"Approve", "1", "approve", "", http.StatusOK},
+		{"Approve Twice", "1", "approve", "", http.StatusConflict},
+		{"Flag", "2", "flag", `{"note":"Mentions an instructor by name"}`, http.StatusOK},
+		{"Approve Flagged", "2", "approve", "", http.StatusOK},
+		{"Hide", "2", "hide", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if rec := moderate(tt.id, tt.action, tt.body); rec.Code != tt.expectedStatus {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.expectedStatus, rec.Code, rec.Body.String())
+		}
+	}
+	if rating := classRatings()[1]; rating == nil || rating.Count != 1 || rating.Average != 1 {
+		t.Errorf("expected only the approved review to be counted, got %+v", rating)
+	}
+	if reviews[1].Status != reviewHidden || reviews[1].ModeratedAt == nil {
+		t.Errorf("expected the second review to be hidden, got %+v", reviews[1])
+	}
+	moderations := 0
+	for _, entry := range auditEntries {
+		if entry.Entity == "review" && entry.Action != "create" {
+			moderations++
+		}
+	}
+	if moderations != 4 {
+		t.Errorf("expected 4 moderation actions audited, got %d", moderations)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/reviews?status=hidden", nil)
+	rec := httptest.NewRecorder()
+	adminReviewsHandler(rec, req)
+	var response struct {
+		Data struct {
+			Reviews []Review `json:"reviews"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&response)
+	if len(response.Data.Reviews) != 1 || response.Data.Reviews[0].ID != 2 {
+		t.Errorf("expected the hidden review, got %s", rec.Body.String())
+	}
+}
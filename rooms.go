@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Room is a space classes are held in. A class or session in a known room
+// cannot take more members than the room holds.
+type Room struct {
+	Name     string `json:"name"`
+	Capacity int    `json:"capacity"`
+}
+
+var rooms []Room // Temp Slice to hold rooms
+
+// roomConflict describes a class or session that would hold more members
+// than its room
+type roomConflict struct {
+	Room         string `json:"room"`
+	RoomCapacity int    `json:"roomCapacity"`
+	ClassID      int    `json:"classId,omitempty"`
+	ClassName    string `json:"className,omitempty"`
+	Date         Date   `json:"date,omitzero"` // Set for a single session
+	Capacity     int    `json:"capacity"`
+}
+
+// roomIndex returns the position of a room, ignoring case, or -1.
+// Callers must hold the mutex.
+func roomIndex(name string) int {
+	for i, room := range rooms {
+		if strings.EqualFold(room.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkRoomCapacity returns a conflict when a capacity exceeds that of a
+// known room. Rooms that are not registered are not checked.
+// Callers must hold the mutex.
+func checkRoomCapacity(roomName string, capacity int) *roomConflict {
+	index := roomIndex(roomName)
+	if roomName == "" || index < 0 || capacity <= rooms[index].Capacity {
+		return nil
+	}
+	return &roomConflict{Room: rooms[index].Name, RoomCapacity: rooms[index].Capacity, Capacity: capacity}
+}
+
+// roomConflicts returns the classes and sessions that would not fit in a room
+// of the given capacity. Callers must hold the mutex.
+func roomConflicts(roomName string, capacity int) []roomConflict {
+	conflicts := []roomConflict{}
+	for _, class := range classes {
+		if class.DeletedAt == nil && strings.EqualFold(class.Room, roomName) && class.Capacity > capacity {
+			conflicts = append(conflicts, roomConflict{Room: roomName, RoomCapacity: capacity, ClassID: class.ID, ClassName: class.ClassName, Capacity: class.Capacity})
+		}
+	}
+	// Sessions are listed when they differ from their class, which is listed already
+	for _, session := range classSessions {
+		index := classIndex(session.ClassID)
+		if index < 0 || classes[index].DeletedAt != nil || session.CancelledAt != nil || session.Capacity <= capacity {
+			continue
+		}
+		if !strings.EqualFold(resolveSession(session).Room, roomName) || (session.Room == "" && session.Capacity == classes[index].Capacity) {
+			continue
+		}
+		conflicts = append(conflicts, roomConflict{Room: roomName, RoomCapacity: capacity, ClassID: session.ClassID, ClassName: session.ClassName, Date: session.Date, Capacity: session.Capacity})
+	}
+	return conflicts
+}
+
+// roomConflictResponse refuses a change that would overfill a room, listing
+// what does not fit
+func roomConflictResponse(w http.ResponseWriter, conflicts ...roomConflict) {
+	message := fmt.Sprintf("Capacity exceeds the %d places of room %s", conflicts[0].RoomCapacity, conflicts[0].Room)
+	// The envelope carries the conflicts so callers can fix them
+	errorDetailsResponse(w, http.StatusConflict, message, map[string]interface{}{"conflicts": conflicts})
+}
+
+// Handler for listing and adding rooms
+func roomHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		response := append([]Room{}, rooms...)
+		mutex.Unlock()
+		successResponse(w, http.StatusOK, "Rooms retrieved successfully", map[string]interface{}{"rooms": response})
+
+	case http.MethodPost:
+		var room Room
+		if err := decodeBody(r, &room); err != nil || strings.TrimSpace(room.Name) == "" || room.Capacity <= 0 {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body, a room needs a name and a positive capacity")
+			return
+		}
+		room.Name = strings.TrimSpace(room.Name)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if roomIndex(room.Name) >= 0 {
+			errorResponse(w, http.StatusConflict, "Room already exists")
+			return
+		}
+		// Classes may already name the room
+		if conflicts := roomConflicts(room.Name, room.Capacity); len(conflicts) > 0 {
+			roomConflictResponse(w, conflicts...)
+			return
+		}
+		rooms = append(rooms, room)
+		if err := writeDataToJsonFile("rooms.json", rooms); err != nil {
+			rooms = rooms[:len(rooms)-1]
+			errorResponse(w, http.StatusInternalServerError, "Failed to save room data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "create", "room", 0, nil, room)
+
+		successResponse(w, http.StatusCreated, "Room created successfully", room)
+		logData("Room created successfully", room)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// Handler for reading, resizing and removing a room
+func roomItemHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := roomIndex(name)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Room not found")
+			return
+		}
+		successResponse(w, http.StatusOK, "Room retrieved successfully", rooms[index])
+
+	case http.MethodPut:
+		var request struct {
+			Capacity int `json:"capacity"`
+		}
+		if err := decodeBody(r, &request); err != nil || request.Capacity <= 0 {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body, capacity must be positive")
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := roomIndex(name)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Room not found")
+			return
+		}
+		// A smaller room must still fit every class and session held in it
+		if conflicts := roomConflicts(rooms[index].Name, request.Capacity); len(conflicts) > 0 {
+			roomConflictResponse(w, conflicts...)
+			return
+		}
+		before := rooms[index]
+		rooms[index].Capacity = request.Capacity
+		if err := writeDataToJsonFile("rooms.json", rooms); err != nil {
+			rooms[index] = before
+			errorResponse(w, http.StatusInternalServerError, "Failed to save room data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "update", "room", 0, before, rooms[index])
+
+		successResponse(w, http.StatusOK, "Room updated successfully", rooms[index])
+		logData("Room updated successfully", rooms[index])
+
+	case http.MethodDelete:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := roomIndex(name)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Room not found")
+			return
+		}
+		previous, before := rooms, rooms[index]
+		rooms = append(append([]Room{}, rooms[:index]...), rooms[index+1:]...)
+		if err := writeDataToJsonFile("rooms.json", rooms); err != nil {
+			rooms = previous
+			errorResponse(w, http.StatusInternalServerError, "Failed to save room data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "delete", "room", 0, before, nil)
+
+		successResponse(w, http.StatusOK, "Room deleted successfully", before)
+		logData("Room deleted successfully", before)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
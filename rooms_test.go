@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRoomCapacity verifies classes and sessions cannot hold more members than their room.
+func TestRoomCapacity(t *testing.T) {
+	setupTestEnvironment()
+	rec := httptest.NewRecorder()
+	roomHandler(rec, httptest.NewRequest(http.MethodPost, "/rooms", bytes.NewReader([]byte(`{"name":"Studio A","capacity":12}`))))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the room to be created, got %d: %s", rec.Code, rec.Body.String())
+	}
+	roomHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/rooms", bytes.NewReader([]byte(`{"name":"Studio B","capacity":8}`))))
+
+	classTests := []struct {
+		name       string
+		body       string
+		statusCode int
+	}{
+		{"Fits", `{"className":"Yoga","startDate":"01-12-2099","endDate":"03-12-2099","capacity":10,"room":"studio a"}`, http.StatusCreated},
+		{"Too Big", `{"className":"Spin","startDate":"01-12-2099","endDate":"03-12-2099","capacity":20,"room":"Studio A"}`, http.StatusConflict},
+		{"Unknown Room", `{"className":"Boxing","startDate":"01-12-2099","endDate":"03-12-2099","capacity":20,"room":"Gym Floor"}`, http.StatusCreated},
+	}
+	for _, tt := range classTests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			classHandler(rec, httptest.NewRequest(http.MethodPost, "/classes", bytes.NewReader([]byte(tt.body))))
+			if rec.Code != tt.statusCode {
+				t.Errorf("expected status %d, got %d: %s", tt.statusCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	sessionTests := []struct {
+		name       string
+		body       string
+		statusCode int
+	}{
+		{"Capacity Over Room", `{"capacity":13}`, http.StatusConflict},
+		{"Smaller Room", `{"room":"Studio B"}`, http.StatusConflict},
+		{"Smaller Room And Capacity", `{"room":"Studio B","capacity":8}`, http.StatusOK},
+	}
+	for _, tt := range sessionTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/classes/1/sessions/02-12-2099", bytes.NewReader([]byte(tt.body)))
+			req.SetPathValue("id", "1")
+			req.SetPathValue("date", "02-12-2099")
+			rec := httptest.NewRecorder()
+			classSessionHandler(rec, req)
+			if rec.Code != tt.statusCode {
+				t.Errorf("expected status %d, got %d: %s", tt.statusCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	// Shrinking a room lists everything that would no longer fit
+	req := httptest.NewRequest(http.MethodPut, "/rooms/Studio%20A", bytes.NewReader([]byte(`{"capacity":6}`)))
+	req.SetPathValue("name", "Studio A")
+	rec = httptest.NewRecorder()
+	roomItemHandler(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected the room to be refused, got %d", rec.Code)
+	}
+	var response struct {
+		Data struct {
+			Conflicts []roomConflict `json:"conflicts"`
+		} `json:"data"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	if len(response.Data.Conflicts) != 1 || response.Data.Conflicts[0].ClassID != 1 || response.Data.Conflicts[0].RoomCapacity != 6 || response.Data.Conflicts[0].Capacity != 10 {
+		t.Errorf("expected the class to be listed once, got %+v", response.Data.Conflicts)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Ways of handling a booking that overlaps another booking the member holds
+const (
+	conflictModeReject = "reject" // The booking is refused unless an admin forces it
+	conflictModeWarn   = "warn"   // The booking is made and the overlaps are returned with it
+	conflictModeOff    = "off"    // Overlaps are not checked
+)
+
+// defaultSessionMinutes is how long a session is taken to last when its class has no duration
+const defaultSessionMinutes = 60
+
+// sessionWindow returns when a class's session on a date starts and ends. It
+// reports false when the class or its start time is unknown.
+// Callers must hold the mutex.
+func sessionWindow(className string, date Date) (time.Time, time.Time, bool) {
+	class := findClassOn(className, date.Time)
+	if class == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	startTime := class.StartTime
+	if session, ok := sessionAt(*class, date); ok && session.StartTime != "" {
+		startTime = session.StartTime
+	}
+	start, err := time.Parse(dateLayout+" "+timeLayout, date.String()+" "+startTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	minutes := class.DurationMinutes
+	if minutes == 0 {
+		minutes = defaultSessionMinutes
+	}
+	return start, start.Add(time.Duration(minutes) * time.Minute), true
+}
+
+// bookingWindow returns when a booking starts and ends, whether it is for a
+// class session or a resource slot. Callers must hold the mutex.
+func bookingWindow(booking Booking) (time.Time, time.Time, bool) {
+	if booking.ResourceID != 0 {
+		return resourceWindow(booking)
+	}
+	return sessionWindow(booking.ClassName, booking.Date)
+}
+
+// scheduleConflicts returns the member's other active bookings that overlap
+// the session or slot a booking is for, leaving out the booking with
+// excludeID. Callers must hold the mutex.
+func scheduleConflicts(booking Booking, excludeID int) []Booking {
+	start, end, ok := bookingWindow(booking)
+	if !ok {
+		return nil
+	}
+	conflicts := []Booking{}
+	for _, other := range bookings {
+		if other.ID == excludeID || other.MemberName != booking.MemberName || other.Date != booking.Date || !bookingActive(other) {
+			continue
+		}
+		// Bookings of the same class are checked by the class itself
+		if booking.ResourceID == 0 && other.ResourceID == 0 && other.ClassName == booking.ClassName {
+			continue
+		}
+		otherStart, otherEnd, ok := bookingWindow(other)
+		if ok && start.Before(otherEnd) && otherStart.Before(end) {
+			conflicts = append(conflicts, other)
+		}
+	}
+	return conflicts
+}
+
+// checkScheduleConflicts applies the schedule conflict mode to a booking. It
+// returns the overlapping bookings to warn about, or a rejection. Forced
+// bookings are never rejected. Callers must hold the mutex.
+func checkScheduleConflicts(booking Booking, excludeID int, force bool) ([]Booking, *requestRejection) {
+	if config.ScheduleConflictMode == conflictModeOff {
+		return nil, nil
+	}
+	conflicts := scheduleConflicts(booking, excludeID)
+	if len(conflicts) == 0 {
+		return nil, nil
+	}
+	if config.ScheduleConflictMode == conflictModeWarn || force {
+		return conflicts, nil
+	}
+	classNames := make([]string, len(conflicts))
+	for i, conflict := range conflicts {
+		classNames[i] = bookingTitle(conflict)
+	}
+	return nil, &requestRejection{http.StatusConflict, "Member is already booked into " + strings.Join(classNames, ", ") + " at the same time"}
+}
+
+// forceRequested reads the force flag admins use to book past schedule
+// conflicts. It rejects the flag on requests without an admin API key.
+func forceRequested(r *http.Request) (bool, *requestRejection) {
+	if r.URL.Query().Get("force") != "true" {
+		return false, nil
+	}
+	if key, ok := requestAPIKey(r); !ok || key.Scope != apiKeyScopeAdmin {
+		return false, &requestRejection{http.StatusForbidden, "Forcing a booking requires an admin API key"}
+	}
+	return true, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestScheduleConflicts verifies overlapping bookings are refused or warned about, and that admins can force them.
+func TestScheduleConflicts(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{
+		{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10, StartTime: "09:00", DurationMinutes: 60},
+		{ID: 2, ClassName: "Pilates", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10, StartTime: "09:30"},
+		{ID: 3, ClassName: "Spin", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10, StartTime: "10:00"},
+		{ID: 4, ClassName: "Boxing", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10},
+	}
+	classId = 5
+	bookings = []Booking{{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("15-12-2099"), Status: bookingStatusConfirmed}}
+	bookingId = 2
+	_, admin := createTestAPIKey(t, "front-desk", apiKeyScopeAdmin)
+	_, kiosk := createTestAPIKey(t, "kiosk", apiKeyScopeBookings)
+	handler := requireAPIKey(http.HandlerFunc(bookingHandler))
+
+	tests := []struct {
+		name       string
+		mode       string
+		className  string
+		key        string
+		query      string
+		statusCode int
+		conflicts  int
+	}{
+		{"Overlapping", conflictModeReject, "Pilates", kiosk, "", http.StatusConflict, 0},
+		{"Force Without Admin", conflictModeReject, "Pilates", kiosk, "?force=true", http.StatusForbidden, 0},
+		{"Back To Back", conflictModeReject, "Spin", kiosk, "", http.StatusCreated, 0},
+		{"No Start Time", conflictModeReject, "Boxing", kiosk, "", http.StatusCreated, 0},
+		// Pilates also runs into the start of Spin
+		{"Warn", conflictModeWarn, "Pilates", kiosk, "", http.StatusCreated, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.ScheduleConflictMode = tt.mode
+			body := `{"memberName":"Ann","className":"` + tt.className + `","date":"15-12-2099"}`
+			req := httptest.NewRequest(http.MethodPost, "/bookings"+tt.query, bytes.NewReader([]byte(body)))
+			req.Header.Set("X-API-Key", tt.key)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.statusCode {
+				t.Fatalf("expected status %d, got %d: %s", tt.statusCode, rec.Code, rec.Body.String())
+			}
+			var response struct {
+				Data struct {
+					Conflicts []Booking `json:"conflicts"`
+				} `json:"data"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			if len(response.Data.Conflicts) != tt.conflicts {
+				t.Errorf("expected %d conflicts, got %+v", tt.conflicts, response.Data.Conflicts)
+			}
+		})
+	}
+
+	// Admins can book past a conflict, and a session moved clear of it no longer conflicts
+	config.ScheduleConflictMode = conflictModeReject
+	bookings = bookings[:1]
+	req := httptest.NewRequest(http.MethodPost, "/bookings?force=true", bytes.NewReader([]byte(`{"memberName":"Ann","className":"Pilates","date":"15-12-2099"}`)))
+	req.Header.Set("X-API-Key", admin)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Errorf("expected an admin to force the booking, got %d: %s", rec.Code, rec.Body.String())
+	}
+	ensureSessions(classes[1])
+	classSessions[sessionIndex(2, testDate("16-12-2099"))].StartTime = "11:00"
+	bookings = append(bookings, Booking{ID: 10, MemberName: "Ann", ClassName: "Yoga", Date: testDate("16-12-2099"), Status: bookingStatusConfirmed})
+	if conflicts := scheduleConflicts(Booking{MemberName: "Ann", ClassName: "Pilates", Date: testDate("16-12-2099")}, 0); len(conflicts) != 0 {
+		t.Errorf("expected the moved session not to conflict, got %+v", conflicts)
+	}
+}
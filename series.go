@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// seriesBookingRequest books a member into every remaining session of a class
+type seriesBookingRequest struct {
+	MemberName string `json:"memberName"`
+	ClassName  string `json:"className"`
+	From       string `json:"from,omitempty"` // First date to book, DD-MM-YYYY; defaults to today
+	To         string `json:"to,omitempty"`   // Last date to book; defaults to the class's end date
+}
+
+// maxSeriesSessions bounds the number of sessions booked in a single series request
+const maxSeriesSessions = 366
+
+// seriesBookingResult reports the outcome for one session of a series
+type seriesBookingResult struct {
+	Date    Date     `json:"date"`
+	Success bool     `json:"success"`
+	Booking *Booking `json:"booking,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Handler for booking every remaining session of a class in one request
+func seriesBookingHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	var request seriesBookingRequest
+	if err := decodeBody(r, &request); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if member := impersonatedMember(r); member != "" {
+		if request.MemberName != "" && request.MemberName != member {
+			errorResponse(w, http.StatusBadRequest, "memberName does not match the impersonated member")
+			return
+		}
+		request.MemberName = member
+	}
+	// Members signed in without a key book for themselves
+	if member := sessionOnlyMember(r); member != "" {
+		request.MemberName = member
+	}
+	if request.MemberName == "" || request.ClassName == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid field format")
+		return
+	}
+	from := today()
+	if request.From != "" {
+		date, err := time.Parse(dateLayout, request.From)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid from format, use DD-MM-YYYY")
+			return
+		}
+		from = date
+	}
+	var to time.Time
+	if request.To != "" {
+		date, err := time.Parse(dateLayout, request.To)
+		if err != nil || date.Before(from) {
+			errorResponse(w, http.StatusBadRequest, "Invalid to, use a DD-MM-YYYY date from the first date on")
+			return
+		}
+		to = date
+	}
+	force, rejection := forceRequested(r)
+	if rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+	// The whole series counts as a single booking attempt
+	if rateLimited(w, request.MemberName) {
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// The series spans every date range the class is scheduled over
+	var startDate, endDate time.Time
+	found := false
+	for _, index := range classesNamed(request.ClassName) {
+		class := classes[index]
+		if class.DeletedAt != nil {
+			continue
+		}
+		start, end := class.StartDate.Time, class.EndDate.Time
+		if !found || start.Before(startDate) {
+			startDate = start
+		}
+		if !found || end.After(endDate) {
+			endDate = end
+		}
+		found = true
+	}
+	if !found {
+		errorResponse(w, http.StatusNotFound, "Class not found")
+		return
+	}
+	if from.Before(startDate) {
+		from = startDate
+	}
+	if !to.IsZero() && to.Before(endDate) {
+		endDate = to
+	}
+	if from.After(endDate) {
+		errorResponse(w, http.StatusBadRequest, "Class has no remaining sessions")
+		return
+	}
+	if sessions := int(endDate.Sub(from).Hours()/24) + 1; sessions > maxSeriesSessions {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("A series can book at most %d sessions, pass an earlier to date", maxSeriesSessions))
+		return
+	}
+
+	// Each session is checked on its own, so a full date does not stop the rest.
+	// The first booking's ID identifies the series.
+	originalCount, originalId := len(bookings), bookingId
+	results := []seriesBookingResult{}
+	created := []Booking{}
+	bookedBefore := map[Date]int{}
+	for day := from; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		date := dateOf(day)
+		newBooking := Booking{MemberName: request.MemberName, Date: date, ClassName: request.ClassName}
+		if memberHasBooking(request.MemberName, request.ClassName, date) {
+			results = append(results, seriesBookingResult{Date: date, Error: "Member already has a booking for this class on this date"})
+			continue
+		}
+		if _, _, rejection := checkBooking(newBooking); rejection != nil {
+			results = append(results, seriesBookingResult{Date: date, Error: rejection.Message})
+			continue
+		}
+		if _, rejection := checkScheduleConflicts(newBooking, 0, force); rejection != nil {
+			results = append(results, seriesBookingResult{Date: date, Error: rejection.Message})
+			continue
+		}
+		bookedBefore[date] = countBookings(sessionIDOn(request.ClassName, date))
+		newBooking.SeriesID = originalId
+		addBooking(&newBooking)
+		created = append(created, newBooking)
+		results = append(results, seriesBookingResult{Date: date, Success: true, Booking: &newBooking})
+	}
+
+	if len(created) == 0 {
+		message := "Series booking failed, no sessions were booked"
+		// The envelope carries the per-date results so callers can see why
+		errorDetailsResponse(w, http.StatusBadRequest, message, map[string]interface{}{"results": results})
+		return
+	}
+
+	// The events announcing the bookings are saved before the bookings
+	actor := actorFromRequest(r)
+	audit := auditId
+	for _, booking := range created {
+		recordAudit(actor, "create", "booking", booking.ID, nil, booking)
+	}
+	if err := writeDataToJsonFile("bookings.json", bookings); err != nil {
+		bookings, bookingId = bookings[:originalCount], originalId
+		retractAudit(audit)
+		errorResponse(w, http.StatusInternalServerError, "Failed to save booking data")
+		return
+	}
+
+	for _, booking := range created {
+		recordBookingEvent(booking.ID, bookingEventCreated, actor, map[string]string{"seriesId": strconv.Itoa(originalId)})
+		if class := findClassOn(booking.ClassName, booking.Date.Time); class != nil {
+			alertIfNearlyFull(class, booking.Date, bookedBefore[booking.Date])
+		}
+	}
+	// One message covers the series rather than one per session
+	notifyMember(request.MemberName, notifyBookingConfirmed, "Series booked",
+		fmt.Sprintf("You are booked into %d sessions of %s from %s to %s.", len(created), request.ClassName, created[0].Date, created[len(created)-1].Date),
+		map[string]string{"event": notifyBookingConfirmed, "seriesId": strconv.Itoa(originalId)})
+
+	response := map[string]interface{}{
+		"seriesId": originalId,
+		"booked":   len(created),
+		"failed":   len(results) - len(created),
+		"results":  results,
+	}
+	// Partial success is reported as 207 Multi-Status
+	statusCode, message := http.StatusCreated, "Series booking successful"
+	if len(created) < len(results) {
+		statusCode, message = http.StatusMultiStatus, "Series booking partially successful"
+	}
+	successResponse(w, statusCode, message, response)
+	logData(message, response)
+}
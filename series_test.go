@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestSeriesBooking verifies a series books each remaining session on its own and cancels as one.
+func TestSeriesBooking(t *testing.T) {
+	setupTestEnvironment()
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2099, 12, 3, 9, 0, 0, 0, time.UTC) }
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("07-12-2099"), Capacity: 1}}
+	classId = 2
+	// The 5th is already full
+	bookings = []Booking{{ID: 1, MemberName: "Ben", ClassName: "Yoga", Date: testDate("05-12-2099"), Status: bookingStatusConfirmed}}
+	bookingId = 2
+	migrateSessions()
+
+	tests := []struct {
+		name       string
+		body       string
+		statusCode int
+		booked     int
+	}{
+		{"Remaining Sessions", `{"memberName":"Ann","className":"Yoga"}`, http.StatusMultiStatus, 4},
+		{"Already Booked", `{"memberName":"Ann","className":"Yoga","from":"06-12-2099"}`, http.StatusBadRequest, 0},
+		{"Date Range", `{"memberName":"Cat","className":"Yoga","from":"01-12-2099","to":"02-12-2099"}`, http.StatusCreated, 2},
+		{"Unknown Class", `{"memberName":"Ann","className":"Boxing"}`, http.StatusNotFound, 0},
+		{"Invalid To", `{"memberName":"Ann","className":"Yoga","to":"01-12-2099"}`, http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			seriesBookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings/series", bytes.NewReader([]byte(tt.body))))
+			if rec.Code != tt.statusCode {
+				t.Fatalf("expected status %d, got %d: %s", tt.statusCode, rec.Code, rec.Body.String())
+			}
+			var response struct {
+				Data struct {
+					Booked int `json:"booked"`
+				} `json:"data"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			if response.Data.Booked != tt.booked {
+				t.Errorf("expected %d sessions booked, got %d", tt.booked, response.Data.Booked)
+			}
+		})
+	}
+
+	// Ann's series covers the 3rd, 4th, 6th and 7th
+	series := []string{}
+	for _, booking := range bookings {
+		if booking.MemberName == "Ann" && booking.SeriesID == 2 {
+			series = append(series, booking.Date.String())
+		}
+	}
+	if len(series) != 4 || series[0] != "03-12-2099" || series[2] != "06-12-2099" {
+		t.Fatalf("expected the full date to be skipped, got %v", series)
+	}
+
+	// Cancelling from a session that has passed only cancels the remaining ones
+	now = func() time.Time { return time.Date(2099, 12, 4, 9, 0, 0, 0, time.UTC) }
+	req := httptest.NewRequest(http.MethodPost, "/bookings/2/cancel?series=true", nil)
+	req.SetPathValue("id", "2")
+	rec := httptest.NewRecorder()
+	cancelBookingHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the series to be cancelled, got %d: %s", rec.Code, rec.Body.String())
+	}
+	for _, booking := range bookings {
+		if booking.SeriesID != 2 {
+			continue
+		}
+		expected := bookingStatusCancelled
+		if booking.Date == testDate("03-12-2099") {
+			expected = bookingStatusConfirmed
+		}
+		if booking.Status != expected {
+			t.Errorf("expected the booking on %s to be %s, got %s", booking.Date, expected, booking.Status)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/bookings/1/cancel?series=true", nil)
+	req.SetPathValue("id", "1")
+	rec = httptest.NewRecorder()
+	cancelBookingHandler(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for a booking outside a series, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// staffUIPath is the URL prefix of the staff web pages
+const staffUIPath = "/ui/"
+
+// staffLayout wraps every staff page
+const staffLayout = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 0 1.5rem 2rem; color: #222; }
+nav { padding: 1rem 0; border-bottom: 1px solid #ddd; margin-bottom: 1rem; }
+nav a { margin-right: 1rem; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ddd; padding: .4rem; vertical-align: top; text-align: left; }
+.session { margin-bottom: .4rem; }
+.full { color: #b00; }
+.error { background: #fdd; padding: .6rem; }
+label { display: block; margin: .6rem 0; }
+</style>
+</head>
+<body>
+<nav><a href="/ui/">Schedule</a><a href="/ui/roster">Today's roster</a><a href="/ui/classes/new">New class</a></nav>
+<h1>{{.Title}}</h1>
+{{template "content" .}}
+</body>
+</html>`
+
+// staffPage parses a staff page into the layout
+func staffPage(content string) *template.Template {
+	page := template.Must(template.New("layout").Parse(staffLayout))
+	template.Must(page.New("content").Parse(content))
+	return page
+}
+
+// scheduleTemplate renders a week of sessions as a grid of start times by day
+var scheduleTemplate = staffPage(`<p><a href="/ui/?week={{.Previous}}">&larr; Previous week</a> | <a href="/ui/?week={{.Next}}">Next week &rarr;</a></p>
+<table>
+<tr><th></th>{{range .Days}}<th><a href="/ui/roster?date={{.Date}}">{{.Label}}</a></th>{{end}}</tr>
+{{range .Rows}}<tr><th>{{if .StartTime}}{{.StartTime}}{{else}}Any time{{end}}</th>
+{{range .Cells}}<td>{{range .}}<div class="session"><a href="/ui/roster?date={{.Date}}">{{.ClassName}}</a>{{if .Instructor}} with {{.Instructor}}{{end}}{{if .Room}}, {{.Room}}{{end}}<br><span{{if eq .AvailableSlots 0}} class="full"{{end}}>{{.Booked}}/{{.Capacity}} booked</span></div>{{end}}</td>{{end}}
+</tr>
+{{else}}<tr><td colspan="8">No sessions this week.</td></tr>
+{{end}}</table>`)
+
+// rosterTemplate renders the members booked into each session of a day
+var rosterTemplate = staffPage(`<p><a href="/ui/roster?date={{.Previous}}">&larr; Previous day</a> | <a href="/ui/roster?date={{.Next}}">Next day &rarr;</a></p>
+{{range .Sessions}}<h2>{{if .Session.StartTime}}{{.Session.StartTime}} {{end}}{{.Session.ClassName}}</h2>
+<p>{{if .Session.Instructor}}With {{.Session.Instructor}}. {{end}}{{if .Session.Room}}In {{.Session.Room}}. {{end}}{{.Session.Booked}} of {{.Session.Capacity}} places booked.</p>
+{{if .Bookings}}<table>
+<tr><th>Member</th><th>Status</th><th>Code</th></tr>
+{{range .Bookings}}<tr><td>{{.MemberName}}</td><td>{{.Status}}</td><td>{{.Code}}</td></tr>
+{{end}}</table>{{else}}<p>No bookings yet.</p>{{end}}
+{{else}}<p>No sessions on this day.</p>
+{{end}}`)
+
+// newClassTemplate renders the form creating a class
+var newClassTemplate = staffPage(`{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<form method="post" action="/ui/classes">
+<label>Name <input name="className" value="{{.Form.className}}" required></label>
+<label>First date <input name="startDate" value="{{.Form.startDate}}" placeholder="DD-MM-YYYY" required></label>
+<label>Last date <input name="endDate" value="{{.Form.endDate}}" placeholder="DD-MM-YYYY" required></label>
+<label>Start time <input name="startTime" value="{{.Form.startTime}}" placeholder="HH:MM"></label>
+<label>Duration in minutes <input name="durationMinutes" type="number" min="0" value="{{.Form.durationMinutes}}"></label>
+<label>Capacity <input name="capacity" type="number" min="1" value="{{.Form.capacity}}" required></label>
+<label>Room <input name="room" value="{{.Form.room}}"></label>
+<label>Instructor <input name="instructor" value="{{.Form.instructor}}"></label>
+<button type="submit">Create class</button>
+</form>`)
+
+// scheduleDay is a column of the schedule grid
+type scheduleDay struct {
+	Date  string
+	Label string
+}
+
+// scheduleRow is the sessions starting at one time on each day of the week
+type scheduleRow struct {
+	StartTime string
+	Cells     [7][]sessionView
+}
+
+// rosterSession is a session with the bookings made for it
+type rosterSession struct {
+	Session  sessionView
+	Bookings []Booking
+}
+
+// renderStaffPage writes a staff page, or a plain error when it cannot be rendered
+func renderStaffPage(w http.ResponseWriter, page *template.Template, status int, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := page.Execute(w, data); err != nil {
+		fmt.Println("Error rendering staff page:", err)
+	}
+}
+
+// staffDate returns the date of a ?date= or ?week= parameter, today when it is
+// missing, or false when it is malformed
+func staffDate(value string) (time.Time, bool) {
+	if value == "" {
+		return today(), true
+	}
+	date, err := time.Parse(dateLayout, value)
+	return date, err == nil
+}
+
+// activeSessions returns the sessions of classes that are not deleted from one
+// date to another, in order of date and start time. Callers must hold the mutex.
+func activeSessions(from, to time.Time) []sessionView {
+	sessions := []sessionView{}
+	for _, session := range statsSessions(from, to) {
+		if index := classIndex(session.ClassID); index >= 0 && classes[index].DeletedAt == nil {
+			sessions = append(sessions, viewSession(session))
+		}
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		if dateI, dateJ := sessions[i].Date, sessions[j].Date; dateI != dateJ {
+			return dateI.Before(dateJ.Time)
+		}
+		return sessions[i].StartTime < sessions[j].StartTime
+	})
+	return sessions
+}
+
+// Handler for the staff schedule grid of a week, the week of ?week= or the current one
+func staffScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+	date, ok := staffDate(r.URL.Query().Get("week"))
+	if !ok {
+		errorResponse(w, http.StatusBadRequest, "Invalid date format, use DD-MM-YYYY")
+		return
+	}
+	monday := weekStart(date)
+
+	days := make([]scheduleDay, 7)
+	for i := range days {
+		day := monday.AddDate(0, 0, i)
+		days[i] = scheduleDay{day.Format(dateLayout), day.Format("Mon 02 Jan")}
+	}
+
+	mutex.Lock()
+	sessions := activeSessions(monday, monday.AddDate(0, 0, 6))
+	mutex.Unlock()
+
+	rows := map[string]*scheduleRow{}
+	startTimes := []string{}
+	for _, session := range sessions {
+		if rows[session.StartTime] == nil {
+			rows[session.StartTime] = &scheduleRow{StartTime: session.StartTime}
+			startTimes = append(startTimes, session.StartTime)
+		}
+		column := int(session.Date.Sub(monday).Hours() / 24)
+		rows[session.StartTime].Cells[column] = append(rows[session.StartTime].Cells[column], session)
+	}
+	sort.Strings(startTimes)
+	grid := []scheduleRow{}
+	for _, startTime := range startTimes {
+		grid = append(grid, *rows[startTime])
+	}
+
+	renderStaffPage(w, scheduleTemplate, http.StatusOK, map[string]interface{}{
+		"Title":    "Week of " + monday.Format("2 January 2006"),
+		"Previous": monday.AddDate(0, 0, -7).Format(dateLayout),
+		"Next":     monday.AddDate(0, 0, 7).Format(dateLayout),
+		"Days":     days,
+		"Rows":     grid,
+	})
+}
+
+// Handler for the staff roster of a day, ?date= or today: every session with
+// the members booked into it
+func staffRosterHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+	day, ok := staffDate(r.URL.Query().Get("date"))
+	if !ok {
+		errorResponse(w, http.StatusBadRequest, "Invalid date format, use DD-MM-YYYY")
+		return
+	}
+
+	mutex.Lock()
+	roster := []rosterSession{}
+	for _, session := range activeSessions(day, day) {
+		entry := rosterSession{Session: session}
+		for _, booking := range bookings {
+			if booking.SessionID == session.ID && bookingActive(booking) {
+				booking.Status = bookingStatus(booking)
+				entry.Bookings = append(entry.Bookings, booking)
+			}
+		}
+		roster = append(roster, entry)
+	}
+	mutex.Unlock()
+
+	renderStaffPage(w, rosterTemplate, http.StatusOK, map[string]interface{}{
+		"Title":    "Roster for " + day.Format("Monday 2 January 2006"),
+		"Previous": day.AddDate(0, 0, -1).Format(dateLayout),
+		"Next":     day.AddDate(0, 0, 1).Format(dateLayout),
+		"Sessions": roster,
+	})
+}
+
+// Handler for the staff form creating a class
+func staffNewClassHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+	renderStaffPage(w, newClassTemplate, http.StatusOK, map[string]interface{}{"Title": "New class", "Form": map[string]string{}})
+}
+
+// Handler creating a class from the staff form, then showing its first week
+func staffCreateClassHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+	// Forms may only be posted from the staff pages themselves
+	if origin, err := url.Parse(r.Header.Get("Origin")); r.Header.Get("Origin") != "" && (err != nil || origin.Host != r.Host) {
+		errorResponse(w, http.StatusForbidden, "Cross-site form submissions are not allowed")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid form")
+		return
+	}
+	form := map[string]string{}
+	for _, field := range []string{"className", "startDate", "endDate", "startTime", "durationMinutes", "capacity", "room", "instructor"} {
+		form[field] = strings.TrimSpace(r.PostForm.Get(field))
+	}
+	refuse := func(status int, message string) {
+		renderStaffPage(w, newClassTemplate, status, map[string]interface{}{"Title": "New class", "Form": form, "Error": message})
+	}
+
+	newClass := Class{
+		ClassName:  form["className"],
+		StartTime:  form["startTime"],
+		Room:       form["room"],
+		Instructor: form["instructor"],
+	}
+	var err error
+	if newClass.StartDate, err = parseDate(form["startDate"]); err != nil {
+		refuse(http.StatusBadRequest, "Invalid startDate format, use DD-MM-YYYY")
+		return
+	}
+	if newClass.EndDate, err = parseDate(form["endDate"]); err != nil {
+		refuse(http.StatusBadRequest, "Invalid endDate format, use DD-MM-YYYY")
+		return
+	}
+	if newClass.Capacity, err = strconv.Atoi(form["capacity"]); err != nil {
+		refuse(http.StatusBadRequest, "Capacity must be a whole number")
+		return
+	}
+	if form["durationMinutes"] != "" {
+		if newClass.DurationMinutes, err = strconv.Atoi(form["durationMinutes"]); err != nil {
+			refuse(http.StatusBadRequest, "Duration must be a whole number of minutes")
+			return
+		}
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if rejection := checkClass(newClass); rejection != nil {
+		refuse(rejection.StatusCode, rejection.Message)
+		return
+	}
+	if conflict := checkRoomCapacity(newClass.Room, newClass.Capacity); conflict != nil {
+		refuse(http.StatusConflict, "Capacity exceeds the "+strconv.Itoa(conflict.RoomCapacity)+" places of room "+conflict.Room)
+		return
+	}
+	addClass(&newClass)
+	audit := recordAudit(actorFromRequest(r), "create", "class", newClass.ID, nil, newClass)
+	if err := writeDataToJsonFile("classes.json", classes); err != nil {
+		classes = classes[:len(classes)-1]
+		classId--
+		retractAudit(audit)
+		refuse(http.StatusInternalServerError, "Failed to save class data")
+		return
+	}
+	ensureSessions(newClass)
+	logData("Class created successfully", newClass)
+
+	http.Redirect(w, r, staffUIPath+"?week="+newClass.StartDate.String(), http.StatusSeeOther)
+}
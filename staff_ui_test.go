@@ -0,0 +1,178 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStaffPages verifies the schedule grid and the roster show the week's sessions and their bookings.
+func TestStaffPages(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2099, 12, 2, 9, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	classes = []Class{
+		{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("03-12-2099"), StartTime: "09:00", Capacity: 5, Instructor: "Ana"},
+		{ID: 2, ClassName: "Gone", StartDate: testDate("01-12-2099"), EndDate: testDate("03-12-2099"), Capacity: 5, DeletedAt: &time.Time{}},
+	}
+	classId = 3
+	bookings = []Booking{
+		{ID: 1, MemberName: "Ann <script>", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed, Code: "ABC123"},
+		{ID: 2, MemberName: "Ben", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusCancelled},
+	}
+	bookingId = 3
+	migrateSessions()
+
+	rec := httptest.NewRecorder()
+	staffScheduleHandler(rec, httptest.NewRequest(http.MethodGet, "/ui/?week=03-12-2099", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Fatalf("expected the schedule page, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	page := rec.Body.String()
+	for _, expected := range []string{"Week of 30 November 2099", "?week=23-11-2099", "?week=07-12-2099", "09:00", "with Ana", "1/5 booked", "0/5 booked"} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("expected the schedule to contain %q", expected)
+		}
+	}
+	if strings.Contains(page, "Gone") {
+		t.Error("expected deleted classes to be left out of the schedule")
+	}
+
+	rec = httptest.NewRecorder()
+	staffScheduleHandler(rec, httptest.NewRequest(http.MethodGet, "/ui/?week=2099-12-03", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected a malformed week to be refused, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	staffRosterHandler(rec, httptest.NewRequest(http.MethodGet, "/ui/roster", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected today's roster, got %d", rec.Code)
+	}
+	page = rec.Body.String()
+	for _, expected := range []string{"Roster for Wednesday 2 December 2099", "Ann &lt;script&gt;", "ABC123", "1 of 5 places booked"} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("expected the roster to contain %q", expected)
+		}
+	}
+	if strings.Contains(page, "Ben") {
+		t.Error("expected cancelled bookings to be left out of the roster")
+	}
+}
+
+// TestStaffCreateClass verifies the staff form creates classes and shows what is wrong with invalid ones.
+func TestStaffCreateClass(t *testing.T) {
+	setupTestEnvironment()
+	rooms = []Room{{Name: "Studio A", Capacity: 10}}
+
+	rec := httptest.NewRecorder()
+	staffNewClassHandler(rec, httptest.NewRequest(http.MethodGet, "/ui/classes/new", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `name="className"`) {
+		t.Fatalf("expected the class form, got %d", rec.Code)
+	}
+
+	submit := func(form url.Values, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/ui/classes", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		rec := httptest.NewRecorder()
+		staffCreateClassHandler(rec, req)
+		return rec
+	}
+	form := url.Values{"className": {"Pilates"}, "startDate": {"01-12-2099"}, "endDate": {"05-12-2099"}, "startTime": {"18:00"}, "capacity": {"12"}, "room": {"Studio A"}}
+
+	tests := []struct {
+		name       string
+		change     url.Values
+		origin     string
+		statusCode int
+		message    string
+	}{
+		{name: "Cross Site", origin: "https://evil.example", statusCode: http.StatusForbidden},
+		{name: "Capacity Not A Number", change: url.Values{"capacity": {"many"}}, statusCode: http.StatusBadRequest, message: "Capacity must be a whole number"},
+		{name: "Invalid Date", change: url.Values{"startDate": {"2099-12-01"}}, statusCode: http.StatusBadRequest},
+		{name: "Room Too Small", statusCode: http.StatusConflict, message: "Capacity exceeds the 10 places of room Studio A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := url.Values{}
+			for field, value := range form {
+				values[field] = value
+			}
+			for field, value := range tt.change {
+				values[field] = value
+			}
+			rec := submit(values, tt.origin)
+			if rec.Code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+			if tt.message != "" && (!strings.Contains(rec.Body.String(), tt.message) || !strings.Contains(rec.Body.String(), `value="Pilates"`)) {
+				t.Errorf("expected the form to be shown again with %q, got %s", tt.message, rec.Body.String())
+			}
+		})
+	}
+	if len(classes) != 0 {
+		t.Fatalf("expected no class to be created by invalid forms, got %+v", classes)
+	}
+
+	form.Set("capacity", "8")
+	rec = submit(form, "http://example.com")
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/ui/?week=01-12-2099" {
+		t.Fatalf("expected a redirect to the class's first week, got %d %q: %s", rec.Code, rec.Header().Get("Location"), rec.Body.String())
+	}
+	if len(classes) != 1 || classes[0].ClassName != "Pilates" || classes[0].Capacity != 8 || classes[0].Room != "Studio A" || classes[0].StartTime != "18:00" {
+		t.Errorf("expected the class to be created from the form, got %+v", classes)
+	}
+	if len(classSessions) != 5 {
+		t.Errorf("expected the class's sessions to be created, got %d", len(classSessions))
+	}
+}
+
+// TestStaffPagesBasicAuth verifies browsers can send the API key as the basic auth password on the staff pages.
+func TestStaffPagesBasicAuth(t *testing.T) {
+	setupTestEnvironment()
+	config.RequireAPIKey = true
+	_, readOnly := createTestAPIKey(t, "desk", apiKeyScopeReadOnly)
+	_, admin := createTestAPIKey(t, "manager", apiKeyScopeAdmin)
+	handler := requireAPIKey(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		password   string
+		statusCode int
+	}{
+		{name: "No Key", method: http.MethodGet, path: "/ui/", statusCode: http.StatusUnauthorized},
+		{name: "Read Only Schedule", method: http.MethodGet, path: "/ui/", password: readOnly, statusCode: http.StatusOK},
+		{name: "Read Only Creates Class", method: http.MethodPost, path: "/ui/classes", password: readOnly, statusCode: http.StatusForbidden},
+		{name: "Admin Creates Class", method: http.MethodPost, path: "/ui/classes", password: admin, statusCode: http.StatusOK},
+		{name: "Outside Staff Pages", method: http.MethodGet, path: "/classes", password: admin, statusCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.password != "" {
+				req.SetBasicAuth("staff", tt.password)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ui/roster", nil))
+	if rec.Header().Get("WWW-Authenticate") != `Basic realm="Staff"` {
+		t.Errorf("expected browsers to be asked for the key, got %q", rec.Header().Get("WWW-Authenticate"))
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// bookingTransitions lists the statuses each booking status may move to.
+// Cancelled, attended and expired bookings are final.
+var bookingTransitions = map[string][]string{
+	bookingStatusPending:   {bookingStatusConfirmed, bookingStatusCancelled, bookingStatusExpired},
+	bookingStatusConfirmed: {bookingStatusCancelled, bookingStatusAttended},
+}
+
+// bookingStatus returns a booking's status, treating bookings saved before
+// statuses existed as confirmed
+func bookingStatus(booking Booking) string {
+	if booking.Status == "" {
+		return bookingStatusConfirmed
+	}
+	return booking.Status
+}
+
+// bookingActive reports whether a booking still takes up a slot
+func bookingActive(booking Booking) bool {
+	status := bookingStatus(booking)
+	return status == bookingStatusPending || status == bookingStatusConfirmed || status == bookingStatusAttended
+}
+
+// checkTransition rejects a status change the booking lifecycle does not allow
+func checkTransition(booking Booking, to string) *requestRejection {
+	from := bookingStatus(booking)
+	if from == to {
+		return &requestRejection{http.StatusBadRequest, "Booking is already " + to}
+	}
+	if !slices.Contains(bookingTransitions[from], to) {
+		return &requestRejection{http.StatusBadRequest, fmt.Sprintf("A %s booking cannot become %s", from, to)}
+	}
+	return nil
+}
+
+// transitionBooking moves a booking to a new status once the lifecycle allows it.
+// Callers must hold the mutex.
+func transitionBooking(index int, to string) *requestRejection {
+	if rejection := checkTransition(bookings[index], to); rejection != nil {
+		return rejection
+	}
+	bookings[index].Status = to
+	// Only pending bookings have a deadline
+	bookings[index].ExpiresAt = nil
+	return nil
+}
+
+// Handler for confirming a pending booking, e.g. once it has been paid for
+func confirmBookingHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid booking id")
+		return
+	}
+
+	var request struct {
+		PaymentReference string `json:"paymentReference"`
+	}
+	// The payment reference is optional, so an empty body is accepted
+	if err := decodeBody(r, &request); err != nil && err != io.EOF {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := bookingIndex(id)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Booking not found")
+		return
+	}
+
+	before := bookings[index]
+	if rejection := transitionBooking(index, bookingStatusConfirmed); rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+	if request.PaymentReference != "" {
+		bookings[index].PaymentReference = request.PaymentReference
+	}
+
+	actor := actorFromRequest(r)
+	audit := recordAudit(actor, "confirm", "booking", id, before, bookings[index])
+	if err := writeDataToJsonFile("bookings.json", bookings); err != nil {
+		bookings[index] = before
+		retractAudit(audit)
+		errorResponse(w, http.StatusInternalServerError, "Failed to save booking data")
+		return
+	}
+
+	recordBookingEvent(id, bookingEventConfirmed, actor, nil)
+
+	successResponse(w, http.StatusOK, "Booking confirmed successfully", bookings[index])
+	logData("Booking confirmed successfully", bookings[index])
+}
+
+// expirePendingBookings marks pending bookings past their deadline as expired,
+// releasing their slots, and returns how many expired
+func expirePendingBookings() (int, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	var expired []int
+	before := map[int]Booking{}
+	for i, booking := range bookings {
+		if bookingStatus(booking) != bookingStatusPending || booking.ExpiresAt == nil || booking.ExpiresAt.After(now()) {
+			continue
+		}
+		before[i] = booking
+		transitionBooking(i, bookingStatusExpired)
+		expired = append(expired, i)
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	// The events announcing the changes are saved before the changes
+	audit := auditId
+	for _, i := range expired {
+		recordAudit("system", "expire", "booking", bookings[i].ID, before[i], bookings[i])
+	}
+	if err := writeDataToJsonFile("bookings.json", bookings); err != nil {
+		for i, booking := range before {
+			bookings[i] = booking
+		}
+		retractAudit(audit)
+		return 0, err
+	}
+	for _, i := range expired {
+		recordBookingEvent(bookings[i].ID, bookingEventExpired, "system", nil)
+		promoteWaitlist(before[i].ClassName, before[i].Date)
+	}
+	logData("Pending bookings expired", map[string]int{"bookings": len(expired)})
+	return len(expired), nil
+}
+
+// runPendingBookingSweeper is the scheduled entry point for expirePendingBookings
+func runPendingBookingSweeper() {
+	if _, err := expirePendingBookings(); err != nil {
+		fmt.Println("Error expiring pending bookings:", err)
+	}
+}
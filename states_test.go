@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestCheckTransition verifies the booking lifecycle only allows its defined moves.
+func TestCheckTransition(t *testing.T) {
+	tests := []struct {
+		from    string
+		to      string
+		allowed bool
+	}{
+		{from: bookingStatusPending, to: bookingStatusConfirmed, allowed: true},
+		{from: bookingStatusPending, to: bookingStatusExpired, allowed: true},
+		{from: bookingStatusPending, to: bookingStatusAttended, allowed: false},
+		{from: "", to: bookingStatusCancelled, allowed: true},
+		{from: bookingStatusConfirmed, to: bookingStatusAttended, allowed: true},
+		{from: bookingStatusConfirmed, to: bookingStatusExpired, allowed: false},
+		{from: bookingStatusConfirmed, to: bookingStatusConfirmed, allowed: false},
+		{from: bookingStatusCancelled, to: bookingStatusConfirmed, allowed: false},
+		{from: bookingStatusExpired, to: bookingStatusConfirmed, allowed: false},
+		{from: bookingStatusAttended, to: bookingStatusCancelled, allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			rejection := checkTransition(Booking{Status: tt.from}, tt.to)
+			if (rejection == nil) != tt.allowed {
+				t.Errorf("expected allowed %v, got rejection %+v", tt.allowed, rejection)
+			}
+		})
+	}
+}
+
+// TestPendingBookings verifies pending bookings hold a slot until confirmed or expired.
+func TestPendingBookings(t *testing.T) {
+	setupTestEnvironment()
+	current := time.Date(2024, 12, 16, 10, 0, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+	classes = []Class{{ID: 1, ClassName: "Pilates", StartDate: testDate("01-12-2024"), EndDate: testDate("31-12-2024"), Capacity: 1}}
+	classId = 2
+
+	book := func(body string) int {
+		rec := httptest.NewRecorder()
+		bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(body))))
+		return rec.Code
+	}
+	confirm := func(id string) int {
+		req := httptest.NewRequest(http.MethodPost, "/bookings/"+id+"/confirm", nil)
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+		confirmBookingHandler(rec, req)
+		return rec.Code
+	}
+
+	if code := book(`{"memberName":"John Doe","className":"Pilates","date":"20-12-2024","status":"pending"}`); code != http.StatusCreated {
+		t.Fatalf("expected status code %d, got %d", http.StatusCreated, code)
+	}
+	if bookings[0].Status != bookingStatusPending || bookings[0].ExpiresAt == nil {
+		t.Fatalf("expected a pending booking with a deadline, got %+v", bookings[0])
+	}
+	if code := book(`{"memberName":"Jane Doe","className":"Pilates","date":"20-12-2024"}`); code != http.StatusBadRequest {
+		t.Errorf("expected the pending booking to take the slot, got %d", code)
+	}
+	if code := confirm("1"); code != http.StatusOK || bookings[0].Status != bookingStatusConfirmed || bookings[0].ExpiresAt != nil {
+		t.Errorf("expected the booking to be confirmed, got %d and %+v", code, bookings[0])
+	}
+	if code := confirm("1"); code != http.StatusBadRequest {
+		t.Errorf("expected a second confirmation to be rejected, got %d", code)
+	}
+
+	// An unconfirmed booking expires and frees its slot.
+	if code := book(`{"memberName":"Jane Doe","className":"Pilates","date":"21-12-2024","status":"pending"}`); code != http.StatusCreated {
+		t.Fatalf("expected status code %d, got %d", http.StatusCreated, code)
+	}
+	current = current.Add(time.Duration(config.PendingBookingMinutes) * time.Minute)
+	if expired, err := expirePendingBookings(); err != nil || expired != 1 || bookings[1].Status != bookingStatusExpired {
+		t.Errorf("expected the pending booking to expire, got %d, %v, %+v", expired, err, bookings[1])
+	}
+	if code := confirm("2"); code != http.StatusBadRequest {
+		t.Errorf("expected an expired booking to be rejected, got %d", code)
+	}
+	if code := book(`{"memberName":"Max Doe","className":"Pilates","date":"21-12-2024"}`); code != http.StatusCreated {
+		t.Errorf("expected the expired slot to be bookable, got %d", code)
+	}
+}
@@ -0,0 +1,678 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultStatsDays = 30  // Days reported when no range is requested
+	maxStatsDays     = 366 // Longest range a report covers
+)
+
+// Ways revenue can be grouped
+const (
+	statsGroupDay   = "day"
+	statsGroupWeek  = "week"
+	statsGroupClass = "class"
+)
+
+// revenueGroup sums the money taken in one group, in one currency
+type revenueGroup struct {
+	Group     string `json:"group"` // Day, Monday of the week (DD-MM-YYYY) or class name
+	Currency  string `json:"currency"`
+	Bookings  int    `json:"bookings"`
+	Gross     int    `json:"gross"`     // Charged for bookings, tax included, in minor units
+	Discounts int    `json:"discounts"` // Taken off list prices by early-bird prices, pricing rules and promo codes
+	Refunds   int    `json:"refunds"`   // Returned to members
+	Net       int    `json:"net"`       // Gross less refunds
+}
+
+// statsRange reads the from and to dates (DD-MM-YYYY, both included) of a
+// report, defaulting to the last defaultStatsDays days
+func statsRange(r *http.Request) (time.Time, time.Time, *requestRejection) {
+	to, from := today(), today().AddDate(0, 0, 1-defaultStatsDays)
+	if value := r.URL.Query().Get("to"); value != "" {
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, &requestRejection{http.StatusBadRequest, "Invalid to format, use DD-MM-YYYY"}
+		}
+		to, from = date, date.AddDate(0, 0, 1-defaultStatsDays)
+	}
+	if value := r.URL.Query().Get("from"); value != "" {
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, &requestRejection{http.StatusBadRequest, "Invalid from format, use DD-MM-YYYY"}
+		}
+		from = date
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, &requestRejection{http.StatusBadRequest, "from must not be after to"}
+	}
+	if to.Sub(from) >= maxStatsDays*24*time.Hour {
+		return time.Time{}, time.Time{}, &requestRejection{http.StatusBadRequest, "Reports cover at most 366 days"}
+	}
+	return from, to, nil
+}
+
+// statsResponse sends a report as JSON, or with ?format=csv one of its tables
+// as CSV: the table named by ?table, or table when none is named. Columns
+// are the JSON fields of the table's rows.
+func statsResponse(w http.ResponseWriter, r *http.Request, message, table string, report map[string]interface{}) {
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		successResponse(w, http.StatusOK, message, report)
+		return
+	case "csv":
+	default:
+		errorResponse(w, http.StatusBadRequest, "Invalid format, use json or csv")
+		return
+	}
+	if name := r.URL.Query().Get("table"); name != "" {
+		table = name
+	}
+	rows := reflect.ValueOf(report[table])
+	if rows.Kind() != reflect.Slice || rows.Type().Elem().Kind() != reflect.Struct {
+		errorResponse(w, http.StatusBadRequest, "Invalid table, the report has no such table")
+		return
+	}
+
+	columns, header := []int{}, []string{}
+	rowType := rows.Type().Elem()
+	for i := 0; i < rowType.NumField(); i++ {
+		name, _, _ := strings.Cut(rowType.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			columns, header = append(columns, i), append(header, name)
+		}
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(r.URL.Path)+"-"+table+".csv"))
+	w.WriteHeader(http.StatusOK)
+	writer := csv.NewWriter(w)
+	writer.Write(header)
+	record := make([]string, len(columns))
+	for i := 0; i < rows.Len(); i++ {
+		for j, column := range columns {
+			record[j] = fmt.Sprint(rows.Index(i).Field(column).Interface())
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+}
+
+// weekStart returns the Monday of a date's week
+func weekStart(date time.Time) time.Time {
+	return date.AddDate(0, 0, -(int(date.Weekday())+6)%7)
+}
+
+// statsGroup returns the group a date and class are reported under
+func statsGroup(groupBy string, date time.Time, className string) string {
+	switch groupBy {
+	case statsGroupWeek:
+		return weekStart(date).Format(dateLayout)
+	case statsGroupClass:
+		return className
+	default:
+		return date.Format(dateLayout)
+	}
+}
+
+// Handler for revenue, discounts and refunds over a range of dates. Bookings
+// count on the date of their session, refunds on the day they were issued.
+func revenueStatsHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+	from, to, rejection := statsRange(r)
+	if rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+	groupBy := r.URL.Query().Get("groupBy")
+	if groupBy == "" {
+		groupBy = statsGroupDay
+	}
+	if groupBy != statsGroupDay && groupBy != statsGroupWeek && groupBy != statsGroupClass {
+		errorResponse(w, http.StatusBadRequest, "Invalid groupBy, use day, week or class")
+		return
+	}
+	inRange := func(date time.Time) bool { return !date.Before(from) && !date.After(to) }
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	groups := map[[2]string]*revenueGroup{}
+	group := func(name, currency string) *revenueGroup {
+		key := [2]string{name, currency}
+		if groups[key] == nil {
+			groups[key] = &revenueGroup{Group: name, Currency: currency}
+		}
+		return groups[key]
+	}
+	for _, booking := range allBookings() {
+		if !inRange(booking.Date.Time) || booking.Price == 0 {
+			continue
+		}
+		// Pending bookings have not been paid, expired ones never will be
+		if status := bookingStatus(booking); status == bookingStatusPending || status == bookingStatusExpired {
+			continue
+		}
+		totals := group(statsGroup(groupBy, booking.Date.Time, booking.ClassName), booking.Currency)
+		totals.Bookings++
+		totals.Gross += max(booking.Total, booking.Price)
+		totals.Discounts += max(listPrice(booking)-booking.Price, 0)
+	}
+	for _, refund := range refunds {
+		issued := time.Date(refund.CreatedAt.Year(), refund.CreatedAt.Month(), refund.CreatedAt.Day(), 0, 0, 0, 0, time.UTC)
+		if refund.Amount == 0 || !inRange(issued) {
+			continue
+		}
+		className := ""
+		if booking, ok := findAnyBooking(refund.BookingID); ok {
+			className = booking.ClassName
+		}
+		group(statsGroup(groupBy, issued, className), refund.Currency).Refunds += refund.Amount
+	}
+
+	report := []revenueGroup{}
+	totals := map[string]*revenueGroup{}
+	for _, g := range groups {
+		g.Net = g.Gross - g.Refunds
+		report = append(report, *g)
+		if totals[g.Currency] == nil {
+			totals[g.Currency] = &revenueGroup{Currency: g.Currency}
+		}
+		total := totals[g.Currency]
+		total.Bookings += g.Bookings
+		total.Gross += g.Gross
+		total.Discounts += g.Discounts
+		total.Refunds += g.Refunds
+		total.Net += g.Net
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Group != report[j].Group {
+			if groupBy == statsGroupClass {
+				return report[i].Group < report[j].Group
+			}
+			dateI, _ := time.Parse(dateLayout, report[i].Group)
+			dateJ, _ := time.Parse(dateLayout, report[j].Group)
+			return dateI.Before(dateJ)
+		}
+		return report[i].Currency < report[j].Currency
+	})
+	byCurrency := []revenueGroup{}
+	for _, total := range totals {
+		byCurrency = append(byCurrency, *total)
+	}
+	sort.Slice(byCurrency, func(i, j int) bool { return byCurrency[i].Currency < byCurrency[j].Currency })
+
+	statsResponse(w, r, "Revenue retrieved successfully", "groups", map[string]interface{}{
+		"from":    from.Format(dateLayout),
+		"to":      to.Format(dateLayout),
+		"groupBy": groupBy,
+		"groups":  report,
+		"totals":  byCurrency,
+	})
+}
+
+// sessionKey identifies a class's session by class name and date, as
+// bookings and waitlist entries refer to it
+type sessionKey struct {
+	className string
+	date      Date
+}
+
+// statsSessions returns the sessions held from one date to another, with the
+// details they leave to their class filled in. Cancelled sessions are left
+// out. Callers must hold the mutex.
+func statsSessions(from, to time.Time) []ClassSession {
+	held := []ClassSession{}
+	for _, class := range allClasses() {
+		startDate := class.StartDate.Time
+		if from.After(startDate) {
+			startDate = from
+		}
+		for day := startDate; !day.After(class.EndDate.Time) && !day.After(to); day = day.AddDate(0, 0, 1) {
+			date := dateOf(day)
+			session, ok := sessionAt(class, date)
+			if !ok {
+				session, ok = archivedSessionAt(class.ID, date)
+			}
+			if !ok {
+				session = ClassSession{ClassID: class.ID, ClassName: class.ClassName, Date: date, Capacity: class.Capacity}
+			}
+			if session.CancelledAt != nil {
+				continue
+			}
+			session.ClassName = class.ClassName
+			if session.StartTime == "" {
+				session.StartTime = class.StartTime
+			}
+			if session.Instructor == "" {
+				session.Instructor = class.Instructor
+			}
+			held = append(held, session)
+		}
+	}
+	return held
+}
+
+// instructorLoad sums the sessions an instructor taught over a range
+type instructorLoad struct {
+	Instructor string  `json:"instructor"`
+	Sessions   int     `json:"sessions"`
+	Capacity   int     `json:"capacity"`   // Places across their sessions
+	Booked     int     `json:"booked"`     // Bookings that were not cancelled
+	Attendees  int     `json:"attendees"`  // Bookings checked in
+	NoShows    int     `json:"noShows"`    // Bookings never checked in
+	FillRate   float64 `json:"fillRate"`   // Booked share of capacity
+	NoShowRate float64 `json:"noShowRate"` // No-show share of bookings
+}
+
+// statsRatio returns part/whole rounded to two decimals, or 0 without a whole
+func statsRatio(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(whole)*100) / 100
+}
+
+// Handler for the sessions, attendance and no-shows of each instructor over a
+// range of dates. Sessions count once their day is over; cancelled sessions
+// and bookings are left out.
+func instructorStatsHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+	from, to, rejection := statsRange(r)
+	if rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+	if lastDay := today().AddDate(0, 0, -1); to.After(lastDay) {
+		to = lastDay
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// Bookings of each session, keyed by class name and date
+	booked, attended := map[sessionKey]int{}, map[sessionKey]int{}
+	for _, booking := range allBookings() {
+		if booking.ResourceID != 0 {
+			continue
+		}
+		switch bookingStatus(booking) {
+		case bookingStatusAttended:
+			attended[sessionKey{booking.ClassName, booking.Date}]++
+			booked[sessionKey{booking.ClassName, booking.Date}]++
+		case bookingStatusConfirmed:
+			booked[sessionKey{booking.ClassName, booking.Date}]++
+		}
+	}
+
+	loads := map[string]*instructorLoad{}
+	for _, session := range statsSessions(from, to) {
+		if session.Instructor == "" {
+			continue
+		}
+		if loads[session.Instructor] == nil {
+			loads[session.Instructor] = &instructorLoad{Instructor: session.Instructor}
+		}
+		load := loads[session.Instructor]
+		load.Sessions++
+		load.Capacity += session.Capacity
+		load.Booked += booked[sessionKey{session.ClassName, session.Date}]
+		load.Attendees += attended[sessionKey{session.ClassName, session.Date}]
+	}
+
+	report := []instructorLoad{}
+	for _, load := range loads {
+		load.NoShows = load.Booked - load.Attendees
+		load.FillRate = statsRatio(load.Booked, load.Capacity)
+		load.NoShowRate = statsRatio(load.NoShows, load.Booked)
+		report = append(report, *load)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Instructor < report[j].Instructor })
+
+	statsResponse(w, r, "Instructor report retrieved successfully", "instructors", map[string]interface{}{
+		"from":        from.Format(dateLayout),
+		"to":          to.Format(dateLayout),
+		"instructors": report,
+	})
+}
+
+// heatmapCell sums the sessions starting in one hour of one weekday
+type heatmapCell struct {
+	Weekday  string  `json:"weekday"`
+	Hour     int     `json:"hour"` // 0 to 23
+	Sessions int     `json:"sessions"`
+	Capacity int     `json:"capacity"`
+	Booked   int     `json:"booked"`
+	FillRate float64 `json:"fillRate"` // Booked share of capacity
+}
+
+// Handler for class booking density by weekday and starting hour over a range
+// of dates, so the busiest times stand out. Sessions without a start time are
+// left out.
+func heatmapStatsHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+	from, to, rejection := statsRange(r)
+	if rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	booked := map[sessionKey]int{}
+	for _, booking := range allBookings() {
+		if booking.ResourceID == 0 && bookingActive(booking) {
+			booked[sessionKey{booking.ClassName, booking.Date}]++
+		}
+	}
+
+	// Cells are indexed Monday first
+	var cells [7][24]heatmapCell
+	for _, session := range statsSessions(from, to) {
+		start, err := minuteOfDay(session.StartTime)
+		if err != nil {
+			continue
+		}
+		cell := &cells[(int(session.Date.Weekday())+6)%7][start/60]
+		cell.Sessions++
+		cell.Capacity += session.Capacity
+		cell.Booked += booked[sessionKey{session.ClassName, session.Date}]
+	}
+
+	heatmap := []heatmapCell{}
+	peak := -1
+	for day := range cells {
+		for hour := range cells[day] {
+			cell := cells[day][hour]
+			if cell.Sessions == 0 {
+				continue
+			}
+			cell.Weekday, cell.Hour = time.Weekday((day+1)%7).String(), hour
+			cell.FillRate = statsRatio(cell.Booked, cell.Capacity)
+			heatmap = append(heatmap, cell)
+			if peak < 0 || cell.Booked > heatmap[peak].Booked {
+				peak = len(heatmap) - 1
+			}
+		}
+	}
+	response := map[string]interface{}{
+		"from":  from.Format(dateLayout),
+		"to":    to.Format(dateLayout),
+		"cells": heatmap,
+	}
+	if peak >= 0 {
+		response["peak"] = heatmap[peak]
+	}
+	statsResponse(w, r, "Heatmap retrieved successfully", "cells", response)
+}
+
+// defaultChurnWeeks is how long members go without a booking before they are
+// listed as at risk of churning, when the report does not say
+const defaultChurnWeeks = 4
+
+// retentionMonth sums the members active in one month
+type retentionMonth struct {
+	Month         string `json:"month"` // MM-YYYY
+	ActiveMembers int    `json:"activeMembers"`
+	Bookings      int    `json:"bookings"`
+	FirstTime     int    `json:"firstTime"` // Bookings that were the member's first
+	Returning     int    `json:"returning"`
+}
+
+// churnRisk is a member who has stopped booking
+type churnRisk struct {
+	MemberName  string `json:"memberName"`
+	Email       string `json:"email,omitempty"`
+	LastBooking string `json:"lastBooking"` // DD-MM-YYYY
+	Bookings    int    `json:"bookings"`    // Bookings they made in all
+}
+
+// Handler for member retention: the members active in each month of a range,
+// how many bookings were members' first, and the members with no bookings in
+// the last weeks (4 by default) for outreach
+func retentionStatsHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+	from, to, rejection := statsRange(r)
+	if rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+	weeks := defaultChurnWeeks
+	if value := r.URL.Query().Get("weeks"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			errorResponse(w, http.StatusBadRequest, "Invalid weeks, use a positive number")
+			return
+		}
+		weeks = parsed
+	}
+	cutoff := today().AddDate(0, 0, -7*weeks)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// Bookings members made, in the order they took place
+	type memberBooking struct {
+		id         int
+		memberName string
+		date       time.Time
+	}
+	made := []memberBooking{}
+	for _, booking := range allBookings() {
+		if booking.ResourceID != 0 || !bookingActive(booking) || strings.HasPrefix(booking.MemberName, pseudonymPrefix) {
+			continue
+		}
+		made = append(made, memberBooking{booking.ID, booking.MemberName, booking.Date.Time})
+	}
+	sort.Slice(made, func(i, j int) bool {
+		if !made[i].date.Equal(made[j].date) {
+			return made[i].date.Before(made[j].date)
+		}
+		return made[i].id < made[j].id
+	})
+
+	months := []retentionMonth{}
+	for month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(to); month = month.AddDate(0, 1, 0) {
+		months = append(months, retentionMonth{Month: month.Format(monthLayout)})
+	}
+	monthIndex := func(date time.Time) int {
+		return (date.Year()-from.Year())*12 + int(date.Month()) - int(from.Month())
+	}
+	active := make([]map[string]bool, len(months))
+	seen := map[string]bool{}
+	counts, last := map[string]int{}, map[string]time.Time{}
+	for _, booking := range made {
+		first := !seen[booking.memberName]
+		seen[booking.memberName] = true
+		counts[booking.memberName]++
+		last[booking.memberName] = booking.date
+		if booking.date.Before(from) || booking.date.After(to) {
+			continue
+		}
+		index := monthIndex(booking.date)
+		if active[index] == nil {
+			active[index] = map[string]bool{}
+		}
+		active[index][booking.memberName] = true
+		months[index].Bookings++
+		if first {
+			months[index].FirstTime++
+		} else {
+			months[index].Returning++
+		}
+	}
+	for i := range months {
+		months[i].ActiveMembers = len(active[i])
+	}
+
+	atRisk := []churnRisk{}
+	for memberName, lastDate := range last {
+		if !lastDate.Before(cutoff) {
+			continue
+		}
+		risk := churnRisk{MemberName: memberName, LastBooking: lastDate.Format(dateLayout), Bookings: counts[memberName]}
+		if index := memberIndex(memberName); index >= 0 {
+			risk.Email = members[index].Email
+		}
+		atRisk = append(atRisk, risk)
+	}
+	// Members who lapsed most recently are the easiest to win back
+	sort.Slice(atRisk, func(i, j int) bool {
+		if !last[atRisk[i].MemberName].Equal(last[atRisk[j].MemberName]) {
+			return last[atRisk[i].MemberName].After(last[atRisk[j].MemberName])
+		}
+		return atRisk[i].MemberName < atRisk[j].MemberName
+	})
+
+	statsResponse(w, r, "Retention retrieved successfully", "months", map[string]interface{}{
+		"from":      from.Format(dateLayout),
+		"to":        to.Format(dateLayout),
+		"months":    months,
+		"weeks":     weeks,
+		"churnRisk": atRisk,
+	})
+}
+
+// Capacity planning suggestions
+const (
+	suggestRaiseCapacity = "raiseCapacity" // The class's room has space for more places
+	suggestLargerRoom    = "largerRoom"    // Another room fits the demand
+	suggestExtraSession  = "extraSession"  // No room fits the demand
+)
+
+// classDemand compares a class's demand with its capacity over a range
+type classDemand struct {
+	ClassID         int     `json:"classId"`
+	ClassName       string  `json:"className"`
+	Room            string  `json:"room,omitempty"`
+	Sessions        int     `json:"sessions"`
+	Capacity        float64 `json:"averageCapacity"`
+	Booked          float64 `json:"averageBooked"`
+	Waitlisted      float64 `json:"averageWaitlisted"`
+	FillRate        float64 `json:"fillRate"`
+	Suggestion      string  `json:"suggestion,omitempty"`
+	SuggestedRoom   string  `json:"suggestedRoom,omitempty"`
+	SuggestedPlaces int     `json:"suggestedPlaces,omitempty"` // Places a session would need to meet demand
+}
+
+// statsAverage returns total/count rounded to one decimal, or 0 without a count
+func statsAverage(total, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return math.Round(float64(total)/float64(count)*10) / 10
+}
+
+// Handler for capacity planning: per class, the average fill rate and
+// waitlist over a range of dates, with a suggestion for classes that are
+// near full and turning members away
+func capacityStatsHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+	from, to, rejection := statsRange(r)
+	if rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	booked, waitlisted := map[sessionKey]int{}, map[sessionKey]int{}
+	for _, booking := range allBookings() {
+		if booking.ResourceID == 0 && bookingActive(booking) {
+			booked[sessionKey{booking.ClassName, booking.Date}]++
+		}
+	}
+	for _, entry := range waitlist {
+		waitlisted[sessionKey{entry.ClassName, entry.Date}]++
+	}
+
+	type classTotals struct {
+		sessions, capacity, booked, waitlisted int
+	}
+	totals := map[int]*classTotals{}
+	for _, session := range statsSessions(from, to) {
+		if totals[session.ClassID] == nil {
+			totals[session.ClassID] = &classTotals{}
+		}
+		total := totals[session.ClassID]
+		total.sessions++
+		total.capacity += session.Capacity
+		total.booked += booked[sessionKey{session.ClassName, session.Date}]
+		total.waitlisted += waitlisted[sessionKey{session.ClassName, session.Date}]
+	}
+
+	report := []classDemand{}
+	for _, class := range allClasses() {
+		total := totals[class.ID]
+		if total == nil {
+			continue
+		}
+		demand := classDemand{
+			ClassID:    class.ID,
+			ClassName:  class.ClassName,
+			Room:       class.Room,
+			Sessions:   total.sessions,
+			Capacity:   statsAverage(total.capacity, total.sessions),
+			Booked:     statsAverage(total.booked, total.sessions),
+			Waitlisted: statsAverage(total.waitlisted, total.sessions),
+			FillRate:   statsRatio(total.booked, total.capacity),
+		}
+		if demand.FillRate >= nearFullThreshold && total.waitlisted > 0 {
+			// Plan for the average demand, rounded up
+			needed := (total.booked + total.waitlisted + total.sessions - 1) / total.sessions
+			demand.SuggestedPlaces = needed
+			demand.Suggestion = suggestExtraSession
+			if index := roomIndex(class.Room); index >= 0 && rooms[index].Capacity >= needed {
+				demand.Suggestion = suggestRaiseCapacity
+			} else {
+				// The smallest room that fits
+				for _, room := range rooms {
+					if room.Capacity >= needed && (demand.SuggestedRoom == "" || room.Capacity < rooms[roomIndex(demand.SuggestedRoom)].Capacity) {
+						demand.Suggestion, demand.SuggestedRoom = suggestLargerRoom, room.Name
+					}
+				}
+			}
+		}
+		report = append(report, demand)
+	}
+	// The fullest classes need attention first
+	sort.SliceStable(report, func(i, j int) bool { return report[i].FillRate > report[j].FillRate })
+
+	statsResponse(w, r, "Capacity report retrieved successfully", "classes", map[string]interface{}{
+		"from":    from.Format(dateLayout),
+		"to":      to.Format(dateLayout),
+		"classes": report,
+	})
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestRevenueStats verifies revenue, discounts and refunds are summed per group and currency.
+func TestRevenueStats(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2099, 12, 10, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	bookings = []Booking{
+		{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("01-12-2099"), Status: bookingStatusAttended, Price: 1000, Total: 1200, Currency: "EUR"},
+		{ID: 2, MemberName: "Ben", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusCancelled, Price: 800, ListPrice: 1000, Total: 800, Currency: "EUR"},
+		{ID: 3, MemberName: "Cat", ClassName: "Spin", Date: testDate("08-12-2099"), Status: bookingStatusConfirmed, Price: 1500, Total: 1500, Currency: "USD"},
+		{ID: 4, MemberName: "Dan", ClassName: "Spin", Date: testDate("08-12-2099"), Status: bookingStatusPending, Price: 1500, Total: 1500, Currency: "USD"},
+		{ID: 5, MemberName: "Eve", ClassName: "Yoga", Date: testDate("08-12-2099"), Status: bookingStatusConfirmed, PaymentMethod: paymentCredits, CreditsUsed: 1},
+		{ID: 6, MemberName: "Fay", ClassName: "Yoga", Date: testDate("20-11-2099"), Status: bookingStatusAttended, Price: 1000, Total: 1000, Currency: "EUR"},
+	}
+	refunds = []Refund{{ID: 1, BookingID: 2, MemberName: "Ben", Amount: 800, Currency: "EUR", CreatedAt: time.Date(2099, 12, 8, 9, 0, 0, 0, time.UTC)}}
+
+	tests := []struct {
+		name   string
+		query  string
+		groups []revenueGroup
+	}{
+		{"By Day", "from=01-12-2099&to=10-12-2099", []revenueGroup{
+			{Group: "01-12-2099", Currency: "EUR", Bookings: 1, Gross: 1200, Net: 1200},
+			{Group: "02-12-2099", Currency: "EUR", Bookings: 1, Gross: 800, Discounts: 200, Net: 800},
+			{Group: "08-12-2099", Currency: "EUR", Refunds: 800, Net: -800},
+			{Group: "08-12-2099", Currency: "USD", Bookings: 1, Gross: 1500, Net: 1500},
+		}},
+		{"By Week", "from=01-12-2099&groupBy=week", []revenueGroup{
+			{Group: "30-11-2099", Currency: "EUR", Bookings: 2, Gross: 2000, Discounts: 200, Net: 2000},
+			{Group: "07-12-2099", Currency: "EUR", Refunds: 800, Net: -800},
+			{Group: "07-12-2099", Currency: "USD", Bookings: 1, Gross: 1500, Net: 1500},
+		}},
+		{"By Class", "groupBy=class", []revenueGroup{
+			{Group: "Spin", Currency: "USD", Bookings: 1, Gross: 1500, Net: 1500},
+			{Group: "Yoga", Currency: "EUR", Bookings: 3, Gross: 3000, Discounts: 200, Refunds: 800, Net: 2200},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			revenueStatsHandler(rec, httptest.NewRequest(http.MethodGet, "/stats/revenue?"+tt.query, nil))
+			var response struct {
+				Data struct {
+					Groups []revenueGroup `json:"groups"`
+					Totals []revenueGroup `json:"totals"`
+				} `json:"data"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil || rec.Code != http.StatusOK {
+				t.Fatalf("expected the report, got %d: %v", rec.Code, err)
+			}
+			if len(response.Data.Groups) != len(tt.groups) {
+				t.Fatalf("expected %d groups, got %+v", len(tt.groups), response.Data.Groups)
+			}
+			for i, group := range tt.groups {
+				if response.Data.Groups[i] != group {
+					t.Errorf("expected %+v, got %+v", group, response.Data.Groups[i])
+				}
+			}
+			if len(response.Data.Totals) != 2 || response.Data.Totals[1].Currency != "USD" || response.Data.Totals[1].Net != 1500 {
+				t.Errorf("expected totals per currency, got %+v", response.Data.Totals)
+			}
+		})
+	}
+
+	for _, query := range []string{"from=2099-12-01", "from=10-12-2099&to=01-12-2099", "from=01-01-2098&to=01-12-2099", "groupBy=month"} {
+		rec := httptest.NewRecorder()
+		revenueStatsHandler(rec, httptest.NewRequest(http.MethodGet, "/stats/revenue?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected %q to be rejected, got %d", query, rec.Code)
+		}
+	}
+}
+
+// TestInstructorStats verifies sessions taught, attendance and no-shows are summed per instructor.
+func TestInstructorStats(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2099, 12, 4, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	cancelledAt := time.Date(2099, 12, 1, 8, 0, 0, 0, time.UTC)
+	classes = []Class{
+		{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 4, Instructor: "Ian"},
+		{ID: 2, ClassName: "Spin", StartDate: testDate("02-12-2099"), EndDate: testDate("02-12-2099"), Capacity: 10, Instructor: "Jo"},
+	}
+	classSessions = []ClassSession{
+		{ID: 1, ClassID: 1, ClassName: "Yoga", Date: testDate("02-12-2099"), Capacity: 4, Instructor: "Jo"},
+		{ID: 2, ClassID: 1, ClassName: "Yoga", Date: testDate("03-12-2099"), Capacity: 4, CancelledAt: &cancelledAt},
+	}
+	bookings = []Booking{
+		{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("01-12-2099"), Status: bookingStatusAttended},
+		{ID: 2, MemberName: "Ben", ClassName: "Yoga", Date: testDate("01-12-2099"), Status: bookingStatusConfirmed},
+		{ID: 3, MemberName: "Cat", ClassName: "Yoga", Date: testDate("01-12-2099"), Status: bookingStatusCancelled},
+		{ID: 4, MemberName: "Ann", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusAttended},
+		{ID: 5, MemberName: "Ann", ClassName: "Spin", Date: testDate("02-12-2099"), Status: bookingStatusAttended},
+		{ID: 6, MemberName: "Ben", ClassName: "Spin", Date: testDate("02-12-2099"), Status: bookingStatusAttended},
+		{ID: 7, MemberName: "Ann", ClassName: "Yoga", Date: testDate("05-12-2099"), Status: bookingStatusConfirmed},
+	}
+
+	rec := httptest.NewRecorder()
+	instructorStatsHandler(rec, httptest.NewRequest(http.MethodGet, "/stats/instructors?from=01-12-2099&to=10-12-2099", nil))
+	var response struct {
+		Data struct {
+			To          string           `json:"to"`
+			Instructors []instructorLoad `json:"instructors"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected the report, got %d: %v", rec.Code, err)
+	}
+	expected := []instructorLoad{
+		{Instructor: "Ian", Sessions: 1, Capacity: 4, Booked: 2, Attendees: 1, NoShows: 1, FillRate: 0.5, NoShowRate: 0.5},
+		{Instructor: "Jo", Sessions: 2, Capacity: 14, Booked: 3, Attendees: 3, FillRate: 0.21},
+	}
+	if response.Data.To != "03-12-2099" || len(response.Data.Instructors) != len(expected) {
+		t.Fatalf("expected sessions up to yesterday for two instructors, got %+v", response.Data)
+	}
+	for i, load := range expected {
+		if response.Data.Instructors[i] != load {
+			t.Errorf("expected %+v, got %+v", load, response.Data.Instructors[i])
+		}
+	}
+}
+
+// TestHeatmapStats verifies bookings are summed by weekday and starting hour.
+func TestHeatmapStats(t *testing.T) {
+	setupTestEnvironment()
+	// 01-12-2099 is a Tuesday
+	classes = []Class{
+		{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("08-12-2099"), Capacity: 4, StartTime: "18:30"},
+		{ID: 2, ClassName: "Spin", StartDate: testDate("07-12-2099"), EndDate: testDate("07-12-2099"), Capacity: 10, StartTime: "07:00"},
+		{ID: 3, ClassName: "Open Gym", StartDate: testDate("01-12-2099"), EndDate: testDate("08-12-2099"), Capacity: 10},
+	}
+	classSessions = []ClassSession{{ID: 1, ClassID: 1, ClassName: "Yoga", Date: testDate("02-12-2099"), Capacity: 4, StartTime: "07:15"}}
+	bookings = []Booking{
+		{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("01-12-2099"), Status: bookingStatusConfirmed},
+		{ID: 2, MemberName: "Ben", ClassName: "Yoga", Date: testDate("08-12-2099"), Status: bookingStatusAttended},
+		{ID: 3, MemberName: "Cat", ClassName: "Yoga", Date: testDate("08-12-2099"), Status: bookingStatusPending},
+		{ID: 4, MemberName: "Dan", ClassName: "Yoga", Date: testDate("08-12-2099"), Status: bookingStatusCancelled},
+		{ID: 5, MemberName: "Ann", ClassName: "Spin", Date: testDate("07-12-2099"), Status: bookingStatusConfirmed},
+		{ID: 6, MemberName: "Ann", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusConfirmed},
+	}
+
+	rec := httptest.NewRecorder()
+	heatmapStatsHandler(rec, httptest.NewRequest(http.MethodGet, "/stats/heatmap?from=01-12-2099&to=08-12-2099", nil))
+	var response struct {
+		Data struct {
+			Cells []heatmapCell `json:"cells"`
+			Peak  heatmapCell   `json:"peak"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected the heatmap, got %d: %v", rec.Code, err)
+	}
+	expected := []heatmapCell{
+		{Weekday: "Monday", Hour: 7, Sessions: 1, Capacity: 10, Booked: 1, FillRate: 0.1},
+		{Weekday: "Monday", Hour: 18, Sessions: 1, Capacity: 4},
+		{Weekday: "Tuesday", Hour: 18, Sessions: 2, Capacity: 8, Booked: 3, FillRate: 0.38},
+		{Weekday: "Wednesday", Hour: 7, Sessions: 1, Capacity: 4, Booked: 1, FillRate: 0.25},
+		{Weekday: "Thursday", Hour: 18, Sessions: 1, Capacity: 4},
+		{Weekday: "Friday", Hour: 18, Sessions: 1, Capacity: 4},
+		{Weekday: "Saturday", Hour: 18, Sessions: 1, Capacity: 4},
+		{Weekday: "Sunday", Hour: 18, Sessions: 1, Capacity: 4},
+	}
+	if len(response.Data.Cells) != len(expected) {
+		t.Fatalf("expected %d cells, got %+v", len(expected), response.Data.Cells)
+	}
+	for i, cell := range expected {
+		if response.Data.Cells[i] != cell {
+			t.Errorf("expected %+v, got %+v", cell, response.Data.Cells[i])
+		}
+	}
+	if response.Data.Peak != expected[2] {
+		t.Errorf("expected Tuesday evening to peak, got %+v", response.Data.Peak)
+	}
+}
+
+// TestRetentionStats verifies monthly activity, first-time bookings and the churn-risk list.
+func TestRetentionStats(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2099, 12, 15, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	members = []Member{{Name: "Ann", Email: "ann@example.com"}}
+	bookings = []Booking{
+		{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("05-09-2099"), Status: bookingStatusAttended},
+		{ID: 2, MemberName: "Ann", ClassName: "Yoga", Date: testDate("10-10-2099"), Status: bookingStatusAttended},
+		{ID: 3, MemberName: "Ben", ClassName: "Yoga", Date: testDate("12-10-2099"), Status: bookingStatusAttended},
+		{ID: 4, MemberName: "Ben", ClassName: "Yoga", Date: testDate("20-10-2099"), Status: bookingStatusCancelled},
+		{ID: 5, MemberName: "Cat", ClassName: "Yoga", Date: testDate("01-11-2099"), Status: bookingStatusAttended},
+		{ID: 6, MemberName: "Ben", ClassName: "Yoga", Date: testDate("10-12-2099"), Status: bookingStatusAttended},
+		{ID: 7, MemberName: "Ben", ClassName: "Yoga", Date: testDate("20-12-2099"), Status: bookingStatusConfirmed},
+		{ID: 8, MemberName: "erased-1a2b3c4d", ClassName: "Yoga", Date: testDate("01-10-2099"), Status: bookingStatusAttended},
+	}
+
+	rec := httptest.NewRecorder()
+	retentionStatsHandler(rec, httptest.NewRequest(http.MethodGet, "/stats/retention?from=01-10-2099&to=31-12-2099", nil))
+	var response struct {
+		Data struct {
+			Months    []retentionMonth `json:"months"`
+			ChurnRisk []churnRisk      `json:"churnRisk"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected the report, got %d: %v", rec.Code, err)
+	}
+	months := []retentionMonth{
+		{Month: "10-2099", ActiveMembers: 2, Bookings: 2, FirstTime: 1, Returning: 1},
+		{Month: "11-2099", ActiveMembers: 1, Bookings: 1, FirstTime: 1},
+		{Month: "12-2099", ActiveMembers: 1, Bookings: 2, Returning: 2},
+	}
+	if len(response.Data.Months) != len(months) {
+		t.Fatalf("expected %d months, got %+v", len(months), response.Data.Months)
+	}
+	for i, month := range months {
+		if response.Data.Months[i] != month {
+			t.Errorf("expected %+v, got %+v", month, response.Data.Months[i])
+		}
+	}
+	churn := []churnRisk{
+		{MemberName: "Cat", LastBooking: "01-11-2099", Bookings: 1},
+		{MemberName: "Ann", Email: "ann@example.com", LastBooking: "10-10-2099", Bookings: 2},
+	}
+	if len(response.Data.ChurnRisk) != len(churn) || response.Data.ChurnRisk[0] != churn[0] || response.Data.ChurnRisk[1] != churn[1] {
+		t.Errorf("expected %+v at risk, got %+v", churn, response.Data.ChurnRisk)
+	}
+
+	rec = httptest.NewRecorder()
+	retentionStatsHandler(rec, httptest.NewRequest(http.MethodGet, "/stats/retention?weeks=10", nil))
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil || len(response.Data.ChurnRisk) != 0 {
+		t.Errorf("expected nobody lapsed for ten weeks, got %+v", response.Data.ChurnRisk)
+	}
+	rec = httptest.NewRecorder()
+	retentionStatsHandler(rec, httptest.NewRequest(http.MethodGet, "/stats/retention?weeks=0", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected an invalid weeks to be rejected, got %d", rec.Code)
+	}
+}
+
+// TestCapacityStats verifies fill rates and waitlists per class and the suggestions made for full classes.
+func TestCapacityStats(t *testing.T) {
+	setupTestEnvironment()
+	rooms = []Room{{Name: "Studio A", Capacity: 4}, {Name: "Hall", Capacity: 20}, {Name: "Studio B", Capacity: 8}}
+	classes = []Class{
+		{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("02-12-2099"), Capacity: 2, Room: "Studio A"},
+		{ID: 2, ClassName: "Spin", StartDate: testDate("01-12-2099"), EndDate: testDate("02-12-2099"), Capacity: 4, Room: "Studio A"},
+		{ID: 3, ClassName: "Pilates", StartDate: testDate("01-12-2099"), EndDate: testDate("01-12-2099"), Capacity: 4},
+		{ID: 4, ClassName: "Barre", StartDate: testDate("01-12-2099"), EndDate: testDate("02-12-2099"), Capacity: 2},
+	}
+	for i, name := range []string{"Ann", "Ben", "Cat", "Dan"} {
+		date := testDate(fmt.Sprintf("0%d-12-2099", i%2+1))
+		bookings = append(bookings,
+			Booking{ID: len(bookings) + 1, MemberName: name, ClassName: "Yoga", Date: date, Status: bookingStatusConfirmed},
+			Booking{ID: len(bookings) + 2, MemberName: name, ClassName: "Spin", Date: date, Status: bookingStatusConfirmed},
+			Booking{ID: len(bookings) + 3, MemberName: name, ClassName: "Spin", Date: date, Status: bookingStatusAttended},
+			Booking{ID: len(bookings) + 4, MemberName: name, ClassName: "Barre", Date: testDate("01-12-2099"), Status: bookingStatusConfirmed},
+		)
+	}
+	waitlist = []WaitlistEntry{
+		{ID: 1, MemberName: "Eve", ClassName: "Yoga", Date: testDate("01-12-2099")},
+		{ID: 2, MemberName: "Eve", ClassName: "Spin", Date: testDate("01-12-2099")},
+		{ID: 3, MemberName: "Fay", ClassName: "Spin", Date: testDate("01-12-2099")},
+		{ID: 4, MemberName: "Eve", ClassName: "Spin", Date: testDate("02-12-2099")},
+		{ID: 5, MemberName: "Eve", ClassName: "Barre", Date: testDate("02-12-2099")},
+	}
+
+	rec := httptest.NewRecorder()
+	capacityStatsHandler(rec, httptest.NewRequest(http.MethodGet, "/stats/capacity?from=01-12-2099&to=02-12-2099", nil))
+	var response struct {
+		Data struct {
+			Classes []classDemand `json:"classes"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected the report, got %d: %v", rec.Code, err)
+	}
+	expected := []classDemand{
+		{ClassID: 1, ClassName: "Yoga", Room: "Studio A", Sessions: 2, Capacity: 2, Booked: 2, Waitlisted: 0.5, FillRate: 1, Suggestion: suggestRaiseCapacity, SuggestedPlaces: 3},
+		{ClassID: 2, ClassName: "Spin", Room: "Studio A", Sessions: 2, Capacity: 4, Booked: 4, Waitlisted: 1.5, FillRate: 1, Suggestion: suggestLargerRoom, SuggestedRoom: "Studio B", SuggestedPlaces: 6},
+		{ClassID: 4, ClassName: "Barre", Sessions: 2, Capacity: 2, Booked: 2, Waitlisted: 0.5, FillRate: 1, Suggestion: suggestLargerRoom, SuggestedRoom: "Studio A", SuggestedPlaces: 3},
+		{ClassID: 3, ClassName: "Pilates", Sessions: 1, Capacity: 4},
+	}
+	if len(response.Data.Classes) != len(expected) {
+		t.Fatalf("expected %d classes, got %+v", len(expected), response.Data.Classes)
+	}
+	for i, class := range expected {
+		if response.Data.Classes[i] != class {
+			t.Errorf("expected %+v, got %+v", class, response.Data.Classes[i])
+		}
+	}
+
+	// Without a room big enough the class needs another session
+	rooms = rooms[:1]
+	rec = httptest.NewRecorder()
+	capacityStatsHandler(rec, httptest.NewRequest(http.MethodGet, "/stats/capacity?from=01-12-2099&to=02-12-2099", nil))
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil || response.Data.Classes[1].Suggestion != suggestExtraSession {
+		t.Errorf("expected an extra session to be suggested, got %+v", response.Data.Classes)
+	}
+}
+
+// TestStatsCSV verifies reports can be downloaded as CSV, one table at a time.
+func TestStatsCSV(t *testing.T) {
+	setupTestEnvironment()
+	members = []Member{{Name: "Ann", Email: "ann@example.com"}}
+	bookings = []Booking{
+		{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("01-12-2099"), Status: bookingStatusAttended, Price: 1000, Total: 1200, Currency: "EUR"},
+		{ID: 2, MemberName: "Ben", ClassName: "Spin", Date: testDate("02-12-2099"), Status: bookingStatusAttended, Price: 800, ListPrice: 1000, Total: 800, Currency: "EUR"},
+	}
+
+	tests := []struct {
+		name           string
+		url            string
+		handler        http.HandlerFunc
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Default Table", "/stats/revenue?from=01-12-2099&to=02-12-2099&groupBy=class&format=csv", revenueStatsHandler, http.StatusOK,
+			"group,currency,bookings,gross,discounts,refunds,net\nSpin,EUR,1,800,200,0,800\nYoga,EUR,1,1200,0,0,1200\n"},
+		{"Named Table", "/stats/retention?to=02-12-2099&format=csv&table=churnRisk", retentionStatsHandler, http.StatusOK,
+			"memberName,email,lastBooking,bookings\n"},
+		{"Unknown Table", "/stats/revenue?format=csv&table=members", revenueStatsHandler, http.StatusBadRequest, ""},
+		{"Not A Table", "/stats/revenue?format=csv&table=from", revenueStatsHandler, http.StatusBadRequest, ""},
+		{"Unknown Format", "/stats/revenue?format=xlsx", revenueStatsHandler, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			if rec.Header().Get("Content-Type") != "text/csv" || rec.Body.String() != tt.expectedBody {
+				t.Errorf("expected CSV %q, got %q (%s)", tt.expectedBody, rec.Body.String(), rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+
+	now = func() time.Time { return time.Date(2100, 2, 1, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	rec := httptest.NewRecorder()
+	retentionStatsHandler(rec, httptest.NewRequest(http.MethodGet, "/stats/retention?format=csv&table=churnRisk", nil))
+	expected := "memberName,email,lastBooking,bookings\nBen,,02-12-2099,1\nAnn,ann@example.com,01-12-2099,1\n"
+	if rec.Body.String() != expected || rec.Header().Get("Content-Disposition") != `attachment; filename="retention-churnRisk.csv"` {
+		t.Errorf("expected the churn-risk list as CSV, got %q", rec.Body.String())
+	}
+}
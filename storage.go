@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// gobPrefix marks a data file written in the gob storage format. Files are
+// recognized by it when read, so JSON and gob files load alike and a store
+// can switch formats one write at a time.
+var gobPrefix = []byte("GOB1")
+
+// configureStorage checks the storage format selected in the config
+func configureStorage() error {
+	if config.StorageFormat != "json" && config.StorageFormat != "gob" {
+		return fmt.Errorf("invalid storageFormat %q, use json or gob", config.StorageFormat)
+	}
+	return nil
+}
+
+// encodeData encodes data for a data file in the given storage format, indented
+// JSON unless it is "gob"
+func encodeData(format string, data interface{}) ([]byte, error) {
+	if format != "gob" {
+		return json.MarshalIndent(data, "", " ")
+	}
+	encoded := bytes.NewBuffer(append([]byte{}, gobPrefix...))
+	if err := gob.NewEncoder(encoded).Encode(data); err != nil {
+		return nil, err
+	}
+	return encoded.Bytes(), nil
+}
+
+// decodeData decodes the contents of a data file in either storage format
+func decodeData(data []byte, destination interface{}) error {
+	if bytes.HasPrefix(data, gobPrefix) {
+		return gob.NewDecoder(bytes.NewReader(data[len(gobPrefix):])).Decode(destination)
+	}
+	return json.Unmarshal(data, destination)
+}
+
+// auditEntryGob is an audit entry as stored in gob. The before and after
+// states hold records of any type, so they are kept as JSON and read back as
+// they would be from a JSON file.
+type auditEntryGob struct {
+	ID         int
+	Time       time.Time
+	Actor      string
+	OnBehalfOf string
+	Action     string
+	Entity     string
+	EntityID   int
+	Before     []byte
+	After      []byte
+}
+
+// GobEncode stores an audit entry with its states as JSON
+func (e AuditEntry) GobEncode() ([]byte, error) {
+	stored := auditEntryGob{ID: e.ID, Time: e.Time, Actor: e.Actor, OnBehalfOf: e.OnBehalfOf, Action: e.Action, Entity: e.Entity, EntityID: e.EntityID}
+	var err error
+	if e.Before != nil {
+		if stored.Before, err = json.Marshal(e.Before); err != nil {
+			return nil, err
+		}
+	}
+	if e.After != nil {
+		if stored.After, err = json.Marshal(e.After); err != nil {
+			return nil, err
+		}
+	}
+	var encoded bytes.Buffer
+	err = gob.NewEncoder(&encoded).Encode(stored)
+	return encoded.Bytes(), err
+}
+
+// GobDecode reads back an audit entry stored by GobEncode
+func (e *AuditEntry) GobDecode(data []byte) error {
+	var stored auditEntryGob
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&stored); err != nil {
+		return err
+	}
+	*e = AuditEntry{ID: stored.ID, Time: stored.Time, Actor: stored.Actor, OnBehalfOf: stored.OnBehalfOf, Action: stored.Action, Entity: stored.Entity, EntityID: stored.EntityID}
+	if len(stored.Before) > 0 {
+		if err := json.Unmarshal(stored.Before, &e.Before); err != nil {
+			return err
+		}
+	}
+	if len(stored.After) > 0 {
+		if err := json.Unmarshal(stored.After, &e.After); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convertStorage rewrites every data file in the given storage format. Files
+// are read whatever their format and kept encrypted when encryption is on.
+func convertStorage(format string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	config.StorageFormat = format
+	if err := configureStorage(); err != nil {
+		return err
+	}
+	for _, fileName := range dataFiles {
+		data, err := os.ReadFile(fileName)
+		if os.IsNotExist(err) || err == nil && len(data) == 0 {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if data, err = decryptData(data); err != nil {
+			return fmt.Errorf("reading %s: %w", fileName, err)
+		}
+		destination := dataFileDestination(fileName)
+		if err := decodeData(data, destination); err != nil {
+			return fmt.Errorf("reading %s: %w", fileName, err)
+		}
+		if err := writeDataToJsonFile(fileName, destination); err != nil {
+			return fmt.Errorf("writing %s: %w", fileName, err)
+		}
+	}
+	return nil
+}
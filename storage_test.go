@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestGobStorage verifies data files written as gob load like JSON ones, audit states included.
+func TestGobStorage(t *testing.T) {
+	setupTestEnvironment()
+	config.StorageFormat = "gob"
+	expires := time.Date(2099, 12, 1, 9, 0, 0, 0, time.UTC)
+	stored := []Booking{{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("02-12-2099"), Status: bookingStatusPending, ExpiresAt: &expires, Rentals: []string{"mat"}}}
+	if err := writeDataToJsonFile("bookings.json", stored); err != nil {
+		t.Fatalf("expected the bookings to be written, got %v", err)
+	}
+	if data, _ := os.ReadFile("bookings.json"); !bytes.HasPrefix(data, gobPrefix) {
+		t.Fatalf("expected a gob file, got %q", data)
+	}
+	var loaded []Booking
+	if err := dataFromJsonFile("bookings.json", &loaded); err != nil {
+		t.Fatalf("expected the bookings to load, got %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].MemberName != "Ann" || !loaded[0].ExpiresAt.Equal(expires) || loaded[0].Rentals[0] != "mat" {
+		t.Errorf("expected the stored booking back, got %+v", loaded)
+	}
+
+	entries := []AuditEntry{{ID: 1, Action: "create", Entity: "booking", EntityID: 1, After: stored[0]}}
+	if err := writeDataToJsonFile("audit.json", entries); err != nil {
+		t.Fatalf("expected the audit log to be written, got %v", err)
+	}
+	var audit []AuditEntry
+	if err := dataFromJsonFile("audit.json", &audit); err != nil {
+		t.Fatalf("expected the audit log to load, got %v", err)
+	}
+	after, ok := audit[0].After.(map[string]interface{})
+	if len(audit) != 1 || audit[0].Before != nil || !ok || after["memberName"] != "Ann" {
+		t.Errorf("expected the state as read from JSON, got %+v", audit)
+	}
+}
+
+// TestConvertStorage verifies the conversion command rewrites every data file and back.
+func TestConvertStorage(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 5}}
+	writeDataToJsonFile("classes.json", classes)
+
+	if err := convertStorage("gob"); err != nil {
+		t.Fatalf("expected the files to convert, got %v", err)
+	}
+	for _, fileName := range []string{"classes.json", "bookings.json", "taxonomy.json", "waitlist_history.json"} {
+		if data, _ := os.ReadFile(fileName); !bytes.HasPrefix(data, gobPrefix) {
+			t.Errorf("expected %s to be gob, got %q", fileName, data)
+		}
+	}
+	if err := loadData(); err != nil || len(classes) != 1 || classes[0].ClassName != "Yoga" {
+		t.Errorf("expected the converted files to load, got %v: %+v", err, classes)
+	}
+
+	if err := convertStorage("json"); err != nil {
+		t.Fatalf("expected the files to convert back, got %v", err)
+	}
+	if data, _ := os.ReadFile("classes.json"); !bytes.HasPrefix(data, []byte("[")) {
+		t.Errorf("expected JSON again, got %q", data)
+	}
+	if err := convertStorage("yaml"); err == nil {
+		t.Error("expected an unknown format to be refused")
+	}
+}
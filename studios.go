@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Studio is one location of a multi-location operator. Classes name the
+// studio they are held at, so members can be sent to the nearest schedule.
+type Studio struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Address   string     `json:"address,omitempty"`
+	Latitude  float64    `json:"latitude"`
+	Longitude float64    `json:"longitude"`
+	Currency  string     `json:"currency,omitempty"` // ISO 4217 code every price at the studio is in
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+var (
+	studios  []Studio // Temp Slice to hold studio locations
+	studioId = 1      // Incremental ID for studios
+)
+
+// defaultNearbyRadiusKm is how far GET /studios/nearby looks when no radius is given
+const defaultNearbyRadiusKm = 10
+
+// earthRadiusKm is the mean radius of the Earth used for distances
+const earthRadiusKm = 6371.0
+
+// studioIndex returns the position of a studio, or -1.
+// Callers must hold the mutex.
+func studioIndex(id int) int {
+	for i, studio := range studios {
+		if studio.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkStudio validates the name and coordinates of a studio
+func checkStudio(studio Studio) *requestRejection {
+	if strings.TrimSpace(studio.Name) == "" {
+		return &requestRejection{http.StatusBadRequest, "Invalid data format, a studio needs a name"}
+	}
+	if studio.Latitude < -90 || studio.Latitude > 90 || studio.Longitude < -180 || studio.Longitude > 180 {
+		return &requestRejection{http.StatusBadRequest, "Invalid coordinates, latitude must be within ±90 and longitude within ±180"}
+	}
+	if studio.Currency != "" {
+		return checkCurrency(studio.Currency)
+	}
+	return nil
+}
+
+// distanceKm returns the great-circle distance between two points
+func distanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	dLat, dLng := toRadians(lat2-lat1), toRadians(lng2-lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// studioClasses returns the classes held at a studio that have not ended.
+// Callers must hold the mutex.
+func studioClasses(studioID int) []Class {
+	schedule := []Class{}
+	for _, class := range classes {
+		if class.StudioID == studioID && class.DeletedAt == nil && !class.EndDate.Before(today()) {
+			schedule = append(schedule, class)
+		}
+	}
+	return schedule
+}
+
+// Handler for listing and adding studios
+func studioHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		response := append([]Studio{}, studios...)
+		mutex.Unlock()
+		successResponse(w, http.StatusOK, "Studios retrieved successfully", map[string]interface{}{"studios": response})
+
+	case http.MethodPost:
+		var studio Studio
+		if err := decodeBody(r, &studio); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if rejection := checkStudio(studio); rejection != nil {
+			errorResponse(w, rejection.StatusCode, rejection.Message)
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		studio.ID = studioId
+		studio.CreatedAt = now()
+		studio.UpdatedAt = nil
+		studioId++
+		studios = append(studios, studio)
+		if err := writeDataToJsonFile("studios.json", studios); err != nil {
+			studios = studios[:len(studios)-1]
+			studioId--
+			errorResponse(w, http.StatusInternalServerError, "Failed to save studio data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "create", "studio", studio.ID, nil, studio)
+
+		successResponse(w, http.StatusCreated, "Studio created successfully", studio)
+		logData("Studio created successfully", studio)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// Handler for reading, changing and removing a studio
+func studioItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid studio id")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := studioIndex(id)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Studio not found")
+			return
+		}
+		successResponse(w, http.StatusOK, "Studio retrieved successfully", map[string]interface{}{
+			"studio":  studios[index],
+			"classes": studioClasses(id),
+		})
+
+	case http.MethodPut:
+		var request Studio
+		if err := decodeBody(r, &request); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if rejection := checkStudio(request); rejection != nil {
+			errorResponse(w, rejection.StatusCode, rejection.Message)
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := studioIndex(id)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Studio not found")
+			return
+		}
+		// Prices already set at the studio must be in its currency
+		if request.Currency != "" {
+			for _, class := range studioClasses(id) {
+				if class.Price > 0 && class.Currency != request.Currency {
+					errorResponse(w, http.StatusConflict, fmt.Sprintf("Class %s is priced in %s, change its price first", class.ClassName, class.Currency))
+					return
+				}
+			}
+		}
+		before := studios[index]
+		updatedAt := now()
+		request.ID, request.CreatedAt, request.UpdatedAt = before.ID, before.CreatedAt, &updatedAt
+		studios[index] = request
+		if err := writeDataToJsonFile("studios.json", studios); err != nil {
+			studios[index] = before
+			errorResponse(w, http.StatusInternalServerError, "Failed to save studio data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "update", "studio", id, before, request)
+
+		successResponse(w, http.StatusOK, "Studio updated successfully", request)
+		logData("Studio updated successfully", request)
+
+	case http.MethodDelete:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := studioIndex(id)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Studio not found")
+			return
+		}
+		// Classes would be left without a location
+		for _, class := range classes {
+			if class.StudioID == id && class.DeletedAt == nil {
+				errorResponse(w, http.StatusConflict, fmt.Sprintf("Studio is used by class %s, move or delete it first", class.ClassName))
+				return
+			}
+		}
+		previous, before := studios, studios[index]
+		studios = append(append([]Studio{}, studios[:index]...), studios[index+1:]...)
+		if err := writeDataToJsonFile("studios.json", studios); err != nil {
+			studios = previous
+			errorResponse(w, http.StatusInternalServerError, "Failed to save studio data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "delete", "studio", id, before, nil)
+
+		successResponse(w, http.StatusOK, "Studio deleted successfully", before)
+		logData("Studio deleted successfully", before)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// nearbyStudio is a studio within the searched radius, with its schedule
+type nearbyStudio struct {
+	Studio
+	DistanceKm float64 `json:"distanceKm"`
+	Classes    []Class `json:"classes"`
+}
+
+// Handler for finding the studios closest to a point, nearest first
+func nearbyStudiosHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	query := r.URL.Query()
+	lat, errLat := strconv.ParseFloat(query.Get("lat"), 64)
+	lng, errLng := strconv.ParseFloat(query.Get("lng"), 64)
+	if errLat != nil || errLng != nil || checkStudio(Studio{Name: "search", Latitude: lat, Longitude: lng}) != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid lat or lng")
+		return
+	}
+	radius := float64(defaultNearbyRadiusKm)
+	if value := query.Get("radius"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 {
+			errorResponse(w, http.StatusBadRequest, "Invalid radius, give a positive number of kilometres")
+			return
+		}
+		radius = parsed
+	}
+
+	mutex.Lock()
+	nearby := []nearbyStudio{}
+	for _, studio := range studios {
+		distance := distanceKm(lat, lng, studio.Latitude, studio.Longitude)
+		if distance <= radius {
+			nearby = append(nearby, nearbyStudio{Studio: studio, DistanceKm: math.Round(distance*100) / 100, Classes: studioClasses(studio.ID)})
+		}
+	}
+	mutex.Unlock()
+	sort.SliceStable(nearby, func(i, j int) bool { return nearby[i].DistanceKm < nearby[j].DistanceKm })
+
+	successResponse(w, http.StatusOK, "Nearby studios retrieved successfully", map[string]interface{}{
+		"studios":  nearby,
+		"radiusKm": radius,
+	})
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestDistanceKm verifies distances follow the great circle.
+func TestDistanceKm(t *testing.T) {
+	// London to Paris is about 344 km
+	if distance := distanceKm(51.5074, -0.1278, 48.8566, 2.3522); distance < 340 || distance > 348 {
+		t.Errorf("expected about 344 km, got %.1f", distance)
+	}
+	if distance := distanceKm(10, 10, 10, 10); distance != 0 {
+		t.Errorf("expected no distance to the same point, got %f", distance)
+	}
+}
+
+// TestNearbyStudios verifies studios are found within a radius, nearest first, with their schedules.
+func TestNearbyStudios(t *testing.T) {
+	setupTestEnvironment()
+	studios = []Studio{
+		{ID: 1, Name: "Soho", Latitude: 51.5136, Longitude: -0.1365},
+		{ID: 2, Name: "Shoreditch", Latitude: 51.5255, Longitude: -0.0754},
+		{ID: 3, Name: "Brighton", Latitude: 50.8225, Longitude: -0.1372},
+	}
+	studioId = 4
+	classes = []Class{
+		{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10, StudioID: 2},
+		{ID: 2, ClassName: "Spin", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10, StudioID: 1},
+	}
+	classId = 3
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expected       []string
+	}{
+		{"Default Radius", "lat=51.5226&lng=-0.0800", http.StatusOK, []string{"Shoreditch", "Soho"}},
+		{"Small Radius", "lat=51.5226&lng=-0.0800&radius=1", http.StatusOK, []string{"Shoreditch"}},
+		{"Wide Radius", "lat=51.5074&lng=-0.1278&radius=100", http.StatusOK, []string{"Soho", "Shoreditch", "Brighton"}},
+		{"Missing Lng", "lat=51.5", http.StatusBadRequest, nil},
+		{"Out Of Range", "lat=91&lng=0", http.StatusBadRequest, nil},
+		{"Invalid Radius", "lat=51.5&lng=0&radius=-2", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			nearbyStudiosHandler(rec, httptest.NewRequest(http.MethodGet, "/studios/nearby?"+tt.query, nil))
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+			if tt.expected == nil {
+				return
+			}
+			var response struct {
+				Data struct {
+					Studios []nearbyStudio `json:"studios"`
+				} `json:"data"`
+			}
+			json.NewDecoder(rec.Body).Decode(&response)
+			if len(response.Data.Studios) != len(tt.expected) {
+				t.Fatalf("expected %v, got %+v", tt.expected, response.Data.Studios)
+			}
+			for i, name := range tt.expected {
+				if response.Data.Studios[i].Name != name {
+					t.Errorf("expected studio %d to be %s, got %s", i, name, response.Data.Studios[i].Name)
+				}
+			}
+			if first := response.Data.Studios[0]; first.ID == 2 && (len(first.Classes) != 1 || first.Classes[0].ClassName != "Yoga") {
+				t.Errorf("expected the studio's schedule, got %+v", first.Classes)
+			}
+		})
+	}
+}
+
+// TestStudioHandlers verifies studios are validated and kept while classes use them.
+func TestStudioHandlers(t *testing.T) {
+	setupTestEnvironment()
+	for _, tt := range []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"Valid Studio", `{"name":"Soho","latitude":51.5136,"longitude":-0.1365}`, http.StatusCreated},
+		{"Missing Name", `{"latitude":51.5,"longitude":-0.1}`, http.StatusBadRequest},
+		{"Invalid Longitude", `{"name":"Nowhere","latitude":51.5,"longitude":200}`, http.StatusBadRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			studioHandler(rec, httptest.NewRequest(http.MethodPost, "/studios", bytes.NewReader([]byte(tt.body))))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	classHandler(rec, httptest.NewRequest(http.MethodPost, "/classes", bytes.NewReader([]byte(`{"className":"Yoga","startDate":"01-12-2099","endDate":"10-12-2099","capacity":5,"studioId":9}`))))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown studio to be refused, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	classHandler(rec, httptest.NewRequest(http.MethodPost, "/classes", bytes.NewReader([]byte(`{"className":"Yoga","startDate":"01-12-2099","endDate":"10-12-2099","capacity":5,"studioId":1}`))))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the class to be created, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/studios/1", nil)
+	req.SetPathValue("id", "1")
+	rec = httptest.NewRecorder()
+	studioItemHandler(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected a studio in use to be kept, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// TaxLine is the tax charged on a booking, kept apart from its price
+type TaxLine struct {
+	Name        string  `json:"name"`        // e.g. "VAT" or "Sales tax"
+	RatePercent float64 `json:"ratePercent"` // Rate applied to the price
+	Amount      int     `json:"amount"`      // In the minor units of the booking's currency
+}
+
+// TaxCalculator works out the tax on a priced booking. Operators with tax
+// rules beyond a flat rate, e.g. per region or per class, plug in their own.
+type TaxCalculator interface {
+	Calculate(booking Booking) (TaxLine, error)
+}
+
+// taxCalculator charges tax on priced bookings when configured, nil charges none
+var taxCalculator TaxCalculator
+
+// flatRateTax charges the same rate on every price, rounding to the nearest minor unit
+type flatRateTax struct {
+	name        string
+	ratePercent float64
+}
+
+// Calculate applies the flat rate to the booking's price
+func (f flatRateTax) Calculate(booking Booking) (TaxLine, error) {
+	amount := math.Round(float64(booking.Price) * f.ratePercent / 100)
+	return TaxLine{Name: f.name, RatePercent: f.ratePercent, Amount: int(amount)}, nil
+}
+
+// configureTax enables the flat rate tax when a rate is configured
+func configureTax() error {
+	taxCalculator = nil
+	if config.TaxRatePercent == 0 {
+		return nil
+	}
+	if config.TaxRatePercent < 0 || config.TaxRatePercent > 100 {
+		return fmt.Errorf("invalid taxRatePercent %v, use a rate between 0 and 100", config.TaxRatePercent)
+	}
+	taxCalculator = flatRateTax{name: config.TaxName, ratePercent: config.TaxRatePercent}
+	return nil
+}
+
+// applyTax breaks the tax out on a priced booking and sets its total. A
+// calculator failure is reported and the booking is kept untaxed, so staff can
+// correct it rather than the member losing the place.
+func applyTax(booking *Booking) {
+	booking.Tax, booking.Total = nil, booking.Price
+	if booking.Price == 0 || taxCalculator == nil {
+		return
+	}
+	line, err := taxCalculator.Calculate(*booking)
+	if err != nil {
+		reportError("tax", "Failed to calculate tax", map[string]string{"bookingId": strconv.Itoa(booking.ID), "error": err.Error()})
+		return
+	}
+	booking.Tax = &line
+	booking.Total = booking.Price + line.Amount
+}
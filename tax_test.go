@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// failingTax is a calculator whose tax service is down
+type failingTax struct{}
+
+func (failingTax) Calculate(booking Booking) (TaxLine, error) {
+	return TaxLine{}, errors.New("tax service unavailable")
+}
+
+// TestFlatRateTax verifies the flat rate rounds to the nearest minor unit.
+func TestFlatRateTax(t *testing.T) {
+	tests := []struct {
+		price    int
+		rate     float64
+		expected int
+	}{
+		{1500, 20, 300},
+		{999, 7.5, 75},
+		{1000, 0.5, 5},
+		{1, 19, 0},
+	}
+	for _, tt := range tests {
+		line, err := flatRateTax{name: "VAT", ratePercent: tt.rate}.Calculate(Booking{Price: tt.price})
+		if err != nil || line.Amount != tt.expected {
+			t.Errorf("expected %d tax on %d at %v%%, got %d (%v)", tt.expected, tt.price, tt.rate, line.Amount, err)
+		}
+	}
+
+	config.TaxRatePercent = 120
+	if err := configureTax(); err == nil {
+		t.Error("expected a rate over 100% to be refused")
+	}
+	config.TaxRatePercent = 0
+	if err := configureTax(); err != nil || taxCalculator != nil {
+		t.Errorf("expected no tax without a rate, got %v", taxCalculator)
+	}
+}
+
+// TestBookingTax verifies tax is broken out on priced bookings.
+func TestBookingTax(t *testing.T) {
+	defer func(calculator TaxCalculator) { taxCalculator = calculator }(taxCalculator)
+	defer func(send func(to, subject, body string) error) { sendEmail = send }(sendEmail)
+	var sent []string
+	sendEmail = func(to, subject, body string) error {
+		sent = append(sent, body)
+		return nil
+	}
+
+	tests := []struct {
+		name       string
+		calculator TaxCalculator
+		className  string
+		expected   string // In the response body
+		total      int
+	}{
+		{"Flat Rate", flatRateTax{name: "VAT", ratePercent: 20}, "Yoga", `"tax":"€3.00","total":"€18.00"`, 1800},
+		{"No Calculator", nil, "Yoga", `"total":"€15.00"`, 1500},
+		{"Free Class", flatRateTax{name: "VAT", ratePercent: 20}, "Stretch", `"availableSlots":4`, 0},
+		{"Calculator Fails", failingTax{}, "Yoga", `"total":"€15.00"`, 1500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestEnvironment()
+			taxCalculator = tt.calculator
+			sent = nil
+			members = []Member{{Name: "Ann", Email: "ann@example.com"}}
+			classes = []Class{
+				{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 5, Price: 1500, Currency: "EUR"},
+				{ID: 2, ClassName: "Stretch", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 5},
+			}
+			classId = 3
+
+			rec := httptest.NewRecorder()
+			bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"Ann","className":"`+tt.className+`","date":"02-12-2099","tax":{"amount":1}}`))))
+			if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), tt.expected) {
+				t.Fatalf("expected %s in the response, got %d: %s", tt.expected, rec.Code, rec.Body.String())
+			}
+			if bookings[0].Total != tt.total {
+				t.Errorf("expected a total of %d, got %d", tt.total, bookings[0].Total)
+			}
+			if tt.name == "Flat Rate" && (bookings[0].Tax == nil || bookings[0].Tax.Name != "VAT" || !strings.Contains(sent[0], "€15.00 plus €3.00 VAT, €18.00 in total")) {
+				t.Errorf("expected the tax on the booking and in the email, got %+v %v", bookings[0].Tax, sent)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// classTaxonomy holds the categories and tags classes can be filed under
+type classTaxonomy struct {
+	Categories []string `json:"categories"`
+	Tags       []string `json:"tags"`
+}
+
+// taxonomy holds the known categories and tags
+var taxonomy classTaxonomy
+
+// taxonomyList returns the list a /taxonomy/{kind} path refers to, or nil.
+// Callers must hold the mutex.
+func taxonomyList(kind string) *[]string {
+	switch kind {
+	case "categories":
+		return &taxonomy.Categories
+	case "tags":
+		return &taxonomy.Tags
+	}
+	return nil
+}
+
+// containsFold reports whether names holds name, ignoring case
+func containsFold(names []string, name string) bool {
+	return slices.ContainsFunc(names, func(candidate string) bool { return strings.EqualFold(candidate, name) })
+}
+
+// checkTaxonomy rejects a class filed under an unknown category or tag.
+// Callers must hold the mutex.
+func checkTaxonomy(newClass Class) *requestRejection {
+	if newClass.Category != "" && !containsFold(taxonomy.Categories, newClass.Category) {
+		return &requestRejection{http.StatusBadRequest, "Unknown category " + newClass.Category}
+	}
+	for _, tag := range newClass.Tags {
+		if !containsFold(taxonomy.Tags, tag) {
+			return &requestRejection{http.StatusBadRequest, "Unknown tag " + tag}
+		}
+	}
+	return nil
+}
+
+// classMatchesFilters reports whether a class is in the category and carries
+// the tag asked for, when they are given
+func classMatchesFilters(class Class, category, tag string) bool {
+	if category != "" && !strings.EqualFold(class.Category, category) {
+		return false
+	}
+	return tag == "" || containsFold(class.Tags, tag)
+}
+
+// Handler for viewing the taxonomy
+func taxonomyHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	mutex.Lock()
+	response := classTaxonomy{Categories: slices.Clone(taxonomy.Categories), Tags: slices.Clone(taxonomy.Tags)}
+	mutex.Unlock()
+	successResponse(w, http.StatusOK, "Taxonomy retrieved successfully", response)
+}
+
+// Handler for adding a category or tag
+func taxonomyKindHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	var request struct {
+		Name string `json:"name"`
+	}
+	if err := decodeBody(r, &request); err != nil || strings.TrimSpace(request.Name) == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	name := strings.ToLower(strings.TrimSpace(request.Name))
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	list := taxonomyList(r.PathValue("kind"))
+	if list == nil {
+		errorResponse(w, http.StatusNotFound, "Unknown taxonomy, use categories or tags")
+		return
+	}
+	if containsFold(*list, name) {
+		errorResponse(w, http.StatusBadRequest, name+" already exists")
+		return
+	}
+
+	*list = append(*list, name)
+	if err := writeDataToJsonFile("taxonomy.json", taxonomy); err != nil {
+		*list = (*list)[:len(*list)-1]
+		errorResponse(w, http.StatusInternalServerError, "Failed to save taxonomy data")
+		return
+	}
+	recordAudit(actorFromRequest(r), "create", "taxonomy", 0, nil, map[string]string{r.PathValue("kind"): name})
+
+	successResponse(w, http.StatusCreated, "Taxonomy updated successfully", taxonomy)
+	logData("Taxonomy updated successfully", taxonomy)
+}
+
+// Handler for removing a category or tag that no class uses
+func taxonomyItemHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is DELETE
+	if r.Method != http.MethodDelete {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	kind, name := r.PathValue("kind"), r.PathValue("name")
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	list := taxonomyList(kind)
+	if list == nil {
+		errorResponse(w, http.StatusNotFound, "Unknown taxonomy, use categories or tags")
+		return
+	}
+	index := slices.IndexFunc(*list, func(candidate string) bool { return strings.EqualFold(candidate, name) })
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, name+" not found")
+		return
+	}
+
+	// Classes filed under the name keep it valid
+	for _, class := range classes {
+		if class.DeletedAt == nil && ((kind == "categories" && strings.EqualFold(class.Category, name)) || (kind == "tags" && containsFold(class.Tags, name))) {
+			errorResponse(w, http.StatusConflict, name+" is still used by classes")
+			return
+		}
+	}
+
+	before := slices.Clone(*list)
+	*list = slices.Delete(*list, index, index+1)
+	if err := writeDataToJsonFile("taxonomy.json", taxonomy); err != nil {
+		*list = before
+		errorResponse(w, http.StatusInternalServerError, "Failed to save taxonomy data")
+		return
+	}
+	recordAudit(actorFromRequest(r), "delete", "taxonomy", 0, map[string]string{kind: before[index]}, nil)
+
+	successResponse(w, http.StatusOK, "Taxonomy updated successfully", taxonomy)
+	logData("Taxonomy updated successfully", taxonomy)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestClassTaxonomy verifies classes are filed under known categories and tags and can be browsed by them.
+func TestClassTaxonomy(t *testing.T) {
+	setupTestEnvironment()
+
+	add := func(kind, name string) int {
+		req := httptest.NewRequest(http.MethodPost, "/taxonomy/"+kind, bytes.NewReader([]byte(`{"name":"`+name+`"}`)))
+		req.SetPathValue("kind", kind)
+		rec := httptest.NewRecorder()
+		taxonomyKindHandler(rec, req)
+		return rec.Code
+	}
+	for _, entry := range [][2]string{{"categories", "Mind-Body"}, {"categories", "Cardio"}, {"tags", "strength"}, {"tags", "low-impact"}} {
+		if code := add(entry[0], entry[1]); code != http.StatusCreated {
+			t.Fatalf("expected status code %d adding %s, got %d", http.StatusCreated, entry[1], code)
+		}
+	}
+	if code := add("tags", "Strength"); code != http.StatusBadRequest {
+		t.Errorf("expected a duplicate tag to be rejected, got %d", code)
+	}
+	if code := add("levels", "beginner"); code != http.StatusNotFound {
+		t.Errorf("expected an unknown taxonomy to be rejected, got %d", code)
+	}
+
+	create := []struct {
+		name       string
+		body       string
+		statusCode int
+	}{
+		{name: "Tagged Class", body: `{"className":"Pilates","startDate":"01-12-2099","endDate":"31-12-2099","capacity":10,"category":"mind-body","tags":["strength","low-impact"]}`, statusCode: http.StatusCreated},
+		{name: "Other Category", body: `{"className":"Spin","startDate":"01-12-2099","endDate":"31-12-2099","capacity":10,"category":"cardio"}`, statusCode: http.StatusCreated},
+		{name: "Unknown Category", body: `{"className":"Boxing","startDate":"01-12-2099","endDate":"31-12-2099","capacity":10,"category":"combat"}`, statusCode: http.StatusBadRequest},
+		{name: "Unknown Tag", body: `{"className":"Boxing","startDate":"01-12-2099","endDate":"31-12-2099","capacity":10,"tags":["power"]}`, statusCode: http.StatusBadRequest},
+	}
+	for _, tt := range create {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			classHandler(rec, httptest.NewRequest(http.MethodPost, "/classes", bytes.NewReader([]byte(tt.body))))
+			if rec.Code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+		})
+	}
+
+	browse := []struct {
+		name    string
+		query   string
+		wantIDs []int
+	}{
+		{name: "By Tag", query: "?tag=strength", wantIDs: []int{1}},
+		{name: "By Category", query: "?category=Cardio", wantIDs: []int{2}},
+		{name: "No Match", query: "?category=cardio&tag=strength", wantIDs: []int{}},
+		{name: "Unfiltered", query: "", wantIDs: []int{1, 2}},
+	}
+	for _, tt := range browse {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			classHandler(rec, httptest.NewRequest(http.MethodGet, "/classes"+tt.query, nil))
+
+			var response struct {
+				Data struct {
+					Classes []Class `json:"classes"`
+				} `json:"data"`
+			}
+			json.NewDecoder(rec.Body).Decode(&response)
+			if len(response.Data.Classes) != len(tt.wantIDs) {
+				t.Fatalf("expected classes %v, got %+v", tt.wantIDs, response.Data.Classes)
+			}
+			for i, id := range tt.wantIDs {
+				if response.Data.Classes[i].ID != id {
+					t.Errorf("expected class %d at position %d, got %d", id, i, response.Data.Classes[i].ID)
+				}
+			}
+		})
+	}
+
+	// A tag in use cannot be removed.
+	req := httptest.NewRequest(http.MethodDelete, "/taxonomy/tags/strength", nil)
+	req.SetPathValue("kind", "tags")
+	req.SetPathValue("name", "strength")
+	rec := httptest.NewRecorder()
+	taxonomyItemHandler(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected status code %d, got %d", http.StatusConflict, rec.Code)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// ClassTemplate holds reusable class details
+type ClassTemplate struct {
+	ID              int    `json:"id"`
+	Name            string `json:"name"`
+	ClassName       string `json:"className"`
+	Capacity        int    `json:"capacity"`
+	DurationMinutes int    `json:"durationMinutes"`
+	Price           int    `json:"price"`              // In minor units, e.g. cents
+	Currency        string `json:"currency,omitempty"` // ISO 4217 code of the price
+}
+
+var (
+	templates  []ClassTemplate // Temp Slice to hold class templates
+	templateId = 1             // Incremental ID for class templates
+)
+
+// applyTemplate fills the details a class leaves empty from its template.
+// Callers must hold the mutex.
+func applyTemplate(newClass *Class) *requestRejection {
+	for _, template := range templates {
+		if template.ID != newClass.TemplateID {
+			continue
+		}
+		if newClass.ClassName == "" {
+			newClass.ClassName = template.ClassName
+		}
+		if newClass.Capacity == 0 {
+			newClass.Capacity = template.Capacity
+		}
+		if newClass.DurationMinutes == 0 {
+			newClass.DurationMinutes = template.DurationMinutes
+		}
+		if newClass.Price == 0 {
+			newClass.Price = template.Price
+			if newClass.Currency == "" {
+				newClass.Currency = template.Currency
+			}
+		}
+		return nil
+	}
+	return &requestRejection{http.StatusBadRequest, "Template not found"}
+}
+
+// Handler for creating and listing class templates
+func templateHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		page, err := paginationParams(r)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid pagination parameters")
+			return
+		}
+
+		mutex.Lock()
+		items, nextCursor := paginate(slices.Clone(templates), page, func(template ClassTemplate) pageCursor {
+			return pageCursor{ID: template.ID}
+		}, false)
+		response := map[string]interface{}{
+			"templates":  items,
+			"total":      len(templates),
+			"limit":      page.Limit,
+			"offset":     page.Offset,
+			"nextCursor": nextCursor,
+		}
+		mutex.Unlock()
+		successResponse(w, http.StatusOK, "Templates retrieved successfully", response)
+
+	case http.MethodPost:
+		var newTemplate ClassTemplate
+		if err := decodeBody(r, &newTemplate); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if newTemplate.Name == "" || newTemplate.ClassName == "" || newTemplate.Capacity <= 0 || newTemplate.DurationMinutes < 0 || newTemplate.Price < 0 {
+			errorResponse(w, http.StatusBadRequest, "Invalid data format")
+			return
+		}
+		if newTemplate.Currency != "" {
+			if rejection := checkCurrency(newTemplate.Currency); rejection != nil {
+				errorResponse(w, rejection.StatusCode, rejection.Message)
+				return
+			}
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		newTemplate.ID = templateId
+		templateId++
+		templates = append(templates, newTemplate)
+
+		if err := writeDataToJsonFile("templates.json", templates); err != nil {
+			templates = templates[:len(templates)-1]
+			templateId--
+			errorResponse(w, http.StatusInternalServerError, "Failed to save template data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "create", "template", newTemplate.ID, nil, newTemplate)
+
+		successResponse(w, http.StatusCreated, "Template created successfully", newTemplate)
+		logData("Template created successfully", newTemplate)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// Handler for cloning a class onto new dates
+func cloneClassHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid class id")
+		return
+	}
+	startDate, errStart := parseDate(r.URL.Query().Get("startDate"))
+	endDate, errEnd := parseDate(r.URL.Query().Get("endDate"))
+	if errStart != nil || errEnd != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid date format, use DD-MM-YYYY")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := classIndex(id)
+	if index < 0 || classes[index].DeletedAt != nil {
+		errorResponse(w, http.StatusNotFound, "Class not found")
+		return
+	}
+
+	// Copy everything but the identity and dates
+	clone := classes[index]
+	clone.ID = 0
+	clone.StartDate, clone.EndDate = startDate, endDate
+
+	if rejection := checkClass(clone); rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+	if conflict := checkRoomCapacity(clone.Room, clone.Capacity); conflict != nil {
+		roomConflictResponse(w, *conflict)
+		return
+	}
+	addClass(&clone)
+
+	audit := recordAudit(actorFromRequest(r), "clone", "class", clone.ID, classes[index], clone)
+	if err := writeDataToJsonFile("classes.json", classes); err != nil {
+		classes = classes[:len(classes)-1]
+		classId--
+		retractAudit(audit)
+		errorResponse(w, http.StatusInternalServerError, "Failed to save class data")
+		return
+	}
+	ensureSessions(clone)
+
+	successResponse(w, http.StatusCreated, "Class cloned successfully", clone)
+	logData("Class cloned successfully", clone)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// TestClassTemplates verifies classes can be created from a template.
+func TestClassTemplates(t *testing.T) {
+	setupTestEnvironment()
+
+	body := `{"name":"Morning Pilates","className":"Pilates","capacity":12,"durationMinutes":45,"price":1500}`
+	rec := httptest.NewRecorder()
+	templateHandler(rec, httptest.NewRequest(http.MethodPost, "/templates", bytes.NewReader([]byte(body))))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status code %d, got %d", http.StatusCreated, rec.Code)
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		statusCode int
+		capacity   int
+	}{
+		{name: "From Template", body: `{"templateId":1,"startDate":"01-01-2025","endDate":"31-01-2025"}`, statusCode: http.StatusCreated, capacity: 12},
+		{name: "Override Template Capacity", body: `{"templateId":1,"startDate":"01-02-2025","endDate":"28-02-2025","capacity":8}`, statusCode: http.StatusCreated, capacity: 8},
+		{name: "Unknown Template", body: `{"templateId":9,"startDate":"01-01-2025","endDate":"31-01-2025"}`, statusCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			classHandler(rec, httptest.NewRequest(http.MethodPost, "/classes", bytes.NewReader([]byte(tt.body))))
+
+			if rec.Code != tt.statusCode {
+				t.Fatalf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+			if tt.statusCode != http.StatusCreated {
+				return
+			}
+
+			var response struct {
+				Data Class `json:"data"`
+			}
+			json.NewDecoder(rec.Body).Decode(&response)
+			if response.Data.ClassName != "Pilates" || response.Data.Capacity != tt.capacity || response.Data.DurationMinutes != 45 || response.Data.Price != 1500 {
+				t.Errorf("expected the template details, got %+v", response.Data)
+			}
+		})
+	}
+}
+
+// TestCloneClassHandler verifies a class can be rolled onto new dates.
+func TestCloneClassHandler(t *testing.T) {
+	setupTestEnvironment()
+	classes = []Class{{ID: 1, ClassName: "Pilates", StartDate: testDate("01-12-2024"), EndDate: testDate("31-12-2024"), Capacity: 10, DurationMinutes: 60, Price: 2000}}
+	classId = 2
+
+	tests := []struct {
+		name       string
+		id         string
+		query      string
+		statusCode int
+	}{
+		{name: "Valid Clone", id: "1", query: "?startDate=01-01-2025&endDate=31-01-2025", statusCode: http.StatusCreated},
+		{name: "Missing Dates", id: "1", query: "", statusCode: http.StatusBadRequest},
+		{name: "End Before Start", id: "1", query: "?startDate=31-01-2025&endDate=01-01-2025", statusCode: http.StatusBadRequest},
+		{name: "Unknown Class", id: "9", query: "?startDate=01-01-2025&endDate=31-01-2025", statusCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/classes/"+tt.id+"/clone"+tt.query, nil)
+			req.SetPathValue("id", tt.id)
+			rec := httptest.NewRecorder()
+
+			cloneClassHandler(rec, req)
+
+			if rec.Code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+		})
+	}
+
+	if len(classes) != 2 {
+		t.Fatalf("expected one clone, got %+v", classes)
+	}
+	clone := classes[1]
+	if clone.ID != 2 || clone.StartDate.String() != "01-01-2025" || clone.Capacity != 10 || clone.DurationMinutes != 60 || clone.Price != 2000 {
+		t.Errorf("expected a copy on the new dates, got %+v", clone)
+	}
+
+	// A clone that cannot be saved leaves no sessions behind
+	sessions := len(classSessions)
+	os.Remove("classes.json")
+	os.Mkdir("classes.json", 0755)
+	defer os.Remove("classes.json")
+	req := httptest.NewRequest(http.MethodPost, "/classes/1/clone?startDate=01-02-2025&endDate=28-02-2025", nil)
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+	cloneClassHandler(rec, req)
+	if rec.Code != http.StatusInternalServerError || len(classes) != 2 || len(classSessions) != sessions {
+		t.Errorf("expected the clone and its sessions to be undone, got %d with %d sessions", rec.Code, len(classSessions)-sessions)
+	}
+}
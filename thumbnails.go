@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// imageSizes maps the smaller variants of an image that can be requested with
+// ?size= to the longest side they are scaled down to. "full" is the upload itself.
+var imageSizes = map[string]int{
+	"thumbnail": 160,
+	"card":      640,
+}
+
+// maxImagePixels bounds the pixels of an uploaded image, so scaling it down
+// cannot exhaust memory
+const maxImagePixels = 40_000_000
+
+// checkImageDimensions rejects data that does not decode as an image, or is
+// too large to scale
+func checkImageDimensions(data []byte) *requestRejection {
+	imageConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return &requestRejection{http.StatusUnsupportedMediaType, "Image could not be read"}
+	}
+	if imageConfig.Width*imageConfig.Height > maxImagePixels {
+		return &requestRejection{http.StatusRequestEntityTooLarge, fmt.Sprintf("Image is too large, the maximum is %d megapixels", maxImagePixels/1_000_000)}
+	}
+	return nil
+}
+
+// imageVariantKey returns the key a scaled-down variant of an image is cached under
+func imageVariantKey(key, size string) string {
+	return "variants/" + size + "/" + key
+}
+
+// scaleImage returns an image scaled down so its longest side is at most
+// longest pixels, averaging the source pixels each target pixel covers
+func scaleImage(source image.Image, longest int) image.Image {
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= longest && height <= longest {
+		return source
+	}
+	targetWidth, targetHeight := longest, max(height*longest/width, 1)
+	if height > width {
+		targetWidth, targetHeight = max(width*longest/height, 1), longest
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, targetWidth, targetHeight))
+	for y := 0; y < targetHeight; y++ {
+		top, bottom := bounds.Min.Y+y*height/targetHeight, bounds.Min.Y+(y+1)*height/targetHeight
+		for x := 0; x < targetWidth; x++ {
+			left, right := bounds.Min.X+x*width/targetWidth, bounds.Min.X+(x+1)*width/targetWidth
+			var r, g, b, a, count uint64
+			for sy := top; sy < max(bottom, top+1); sy++ {
+				for sx := left; sx < max(right, left+1); sx++ {
+					pr, pg, pb, pa := source.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					count++
+				}
+			}
+			scaled.SetRGBA(x, y, color.RGBA{uint8(r / count >> 8), uint8(g / count >> 8), uint8(b / count >> 8), uint8(a / count >> 8)})
+		}
+	}
+	return scaled
+}
+
+// imageVariant returns a scaled-down variant of a stored image in its own
+// format, making and caching it on first request. Images already small enough
+// are returned as they are.
+func imageVariant(key, size string) ([]byte, error) {
+	variantKey := imageVariantKey(key, size)
+	if data, err := blobStore.Get(variantKey); err == nil {
+		return data, nil
+	}
+	original, err := blobStore.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	source, format, err := image.Decode(bytes.NewReader(original))
+	if err != nil {
+		return nil, fmt.Errorf("decoding image %s: %w", key, err)
+	}
+	scaled := scaleImage(source, imageSizes[size])
+	if scaled == source {
+		return original, nil
+	}
+
+	var encoded bytes.Buffer
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&encoded, scaled, &jpeg.Options{Quality: 85})
+	case "gif":
+		err = gif.Encode(&encoded, scaled, nil)
+	default:
+		err = png.Encode(&encoded, scaled)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encoding image %s: %w", key, err)
+	}
+	// A failed cache write only means the variant is made again next time
+	if err := blobStore.Put(variantKey, imageContentType(key), encoded.Bytes()); err != nil {
+		fmt.Println("Error caching image variant:", err)
+	}
+	return encoded.Bytes(), nil
+}
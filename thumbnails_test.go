@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestImageVariants verifies scaled-down variants are made on first request, cached and removed with their image.
+func TestImageVariants(t *testing.T) {
+	setupTestEnvironment()
+	defer func(store BlobStore) { blobStore = store }(blobStore)
+	blobStore = diskBlobStore{dir: t.TempDir()}
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("10-12-2099"), Capacity: 5}}
+
+	photo := image.NewRGBA(image.Rect(0, 0, 800, 400))
+	for x := 0; x < 800; x++ {
+		for y := 0; y < 400; y++ {
+			photo.SetRGBA(x, y, color.RGBA{200, 100, 50, 255})
+		}
+	}
+	var upload bytes.Buffer
+	jpeg.Encode(&upload, photo, nil)
+	req := imageUploadRequest("/classes/1/image", upload.Bytes())
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+	classImageHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the image to be uploaded, got %d: %s", rec.Code, rec.Body.String())
+	}
+	key := uploadedImageKey(classes[0].Image)
+
+	serve := func(size string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, classes[0].Image+"?size="+size, nil)
+		req.SetPathValue("key", key)
+		rec := httptest.NewRecorder()
+		imageHandler(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		size           string
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{"thumbnail", 160, 80},
+		{"card", 640, 320},
+		{"full", 800, 400},
+	}
+	for _, tt := range tests {
+		rec := serve(tt.size)
+		scaled, format, err := image.Decode(rec.Body)
+		if rec.Code != http.StatusOK || err != nil || format != "jpeg" || rec.Header().Get("Content-Type") != "image/jpeg" {
+			t.Fatalf("%s: expected a JPEG, got %d %v %s", tt.size, rec.Code, err, format)
+		}
+		if bounds := scaled.Bounds(); bounds.Dx() != tt.expectedWidth || bounds.Dy() != tt.expectedHeight {
+			t.Errorf("%s: expected %dx%d, got %dx%d", tt.size, tt.expectedWidth, tt.expectedHeight, bounds.Dx(), bounds.Dy())
+		}
+		if r, _, _, _ := scaled.At(0, 0).RGBA(); r>>8 < 190 || r>>8 > 210 {
+			t.Errorf("%s: expected the colour to be kept, got red %d", tt.size, r>>8)
+		}
+	}
+	if rec := serve("huge"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown size to be refused, got %d", rec.Code)
+	}
+	if variants, _ := blobStore.List("variants/"); len(variants) != 2 {
+		t.Errorf("expected both variants to be cached, got %v", variants)
+	}
+
+	removeImage(classes[0].Image)
+	if remaining, _ := blobStore.List(""); len(remaining) != 0 {
+		t.Errorf("expected the image and its variants to be deleted, got %v", remaining)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	signingKey      []byte     // Key signing the tokens sent to members
+	signingKeyMutex sync.Mutex // Guards signingKey
+)
+
+// configureSigning reads the token signing key from TOKEN_SIGNING_KEY. Without
+// one a random key is used, so tokens do not survive a restart.
+func configureSigning() error {
+	encoded := os.Getenv("TOKEN_SIGNING_KEY")
+	if encoded == "" {
+		return nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) < 32 {
+		return fmt.Errorf("invalid TOKEN_SIGNING_KEY: use at least 32 base64 encoded bytes")
+	}
+	signingKeyMutex.Lock()
+	signingKey = key
+	signingKeyMutex.Unlock()
+	return nil
+}
+
+// tokenKey returns the signing key, generating a random one on first use
+func tokenKey() []byte {
+	signingKeyMutex.Lock()
+	defer signingKeyMutex.Unlock()
+	if signingKey == nil {
+		signingKey = make([]byte, 32)
+		rand.Read(signingKey)
+	}
+	return signingKey
+}
+
+// signedClaims is the body of a signed token
+type signedClaims struct {
+	Purpose   string            `json:"p"`
+	Subject   string            `json:"s"`
+	Data      map[string]string `json:"d,omitempty"`
+	ExpiresAt int64             `json:"x"`
+}
+
+// tokenSignature signs the encoded claims of a token
+func tokenSignature(encoded string) string {
+	mac := hmac.New(sha256.New, tokenKey())
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signToken issues a token for a purpose, e.g. email verification, that expires after ttl
+func signToken(purpose, subject string, data map[string]string, ttl time.Duration) string {
+	payload, _ := json.Marshal(signedClaims{Purpose: purpose, Subject: subject, Data: data, ExpiresAt: now().Add(ttl).Unix()})
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + tokenSignature(encoded)
+}
+
+// verifyToken checks a token's signature, purpose and expiry and returns its claims
+func verifyToken(token, purpose string) (signedClaims, error) {
+	var claims signedClaims
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(tokenSignature(encoded))) {
+		return claims, errors.New("invalid token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || json.Unmarshal(payload, &claims) != nil || claims.Purpose != purpose {
+		return signedClaims{}, errors.New("invalid token")
+	}
+	if now().Unix() >= claims.ExpiresAt {
+		return signedClaims{}, errors.New("token expired")
+	}
+	return claims, nil
+}
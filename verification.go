@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// tokenPurposeVerify marks tokens that verify a member's email address
+const tokenPurposeVerify = "verify"
+
+// sendEmail delivers an email to a member. Without a mail provider configured
+// the message is written to the API log.
+var sendEmail = func(to, subject, body string) error {
+	logData("Email sent", map[string]string{"email": to, "subject": subject, "body": body})
+	return nil
+}
+
+// sendVerificationEmail emails a member a signed link confirming their address.
+// The token names the address, so changing it invalidates earlier links.
+func sendVerificationEmail(member Member) error {
+	if !notificationAllowed(member, notifyAccount, channelEmail, now()) {
+		return nil
+	}
+	token := signToken(tokenPurposeVerify, member.Name, map[string]string{"email": member.Email}, time.Duration(config.VerificationTokenHours)*time.Hour)
+	link := config.PublicBaseURL + "/verify?token=" + url.QueryEscape(token)
+	return sendEmail(member.Email, "Verify your email address", "Confirm your email address by opening "+link)
+}
+
+// checkVerifiedEmail rejects a member's first booking until their email is
+// verified, when the studio requires it. Callers must hold the mutex.
+func checkVerifiedEmail(memberName string) *requestRejection {
+	if !config.RequireVerifiedEmail {
+		return nil
+	}
+	if index := memberIndex(memberName); (index >= 0 && members[index].EmailVerified) || !firstBooking(memberName) {
+		return nil
+	}
+	return &requestRejection{http.StatusForbidden, "Member must verify their email before their first booking"}
+}
+
+// Handler for the verification link emailed to members
+func verifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	claims, err := verifyToken(r.URL.Query().Get("token"), tokenPurposeVerify)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid or expired verification link")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := memberIndex(claims.Subject)
+	if index < 0 || members[index].Email != claims.Data["email"] {
+		errorResponse(w, http.StatusBadRequest, "Invalid or expired verification link")
+		return
+	}
+	if members[index].EmailVerified {
+		successResponse(w, http.StatusOK, "Email already verified", members[index])
+		return
+	}
+
+	before := members[index]
+	members[index].EmailVerified = true
+	if err := writeDataToJsonFile("members.json", members); err != nil {
+		members[index] = before
+		errorResponse(w, http.StatusInternalServerError, "Failed to save member data")
+		return
+	}
+	recordAudit(claims.Subject, "verify", "member", 0, before, members[index])
+
+	successResponse(w, http.StatusOK, "Email verified successfully", members[index])
+	logData("Email verified successfully", members[index])
+}
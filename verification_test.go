@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestEmailVerification verifies the emailed link marks the address verified and
+// that the first booking can be made to wait for it.
+func TestEmailVerification(t *testing.T) {
+	setupTestEnvironment()
+	defer func() { now = time.Now }()
+	config.RequireVerifiedEmail = true
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10}}
+	classId = 2
+
+	// Capture the link instead of sending it
+	var sent []string
+	defer func(send func(to, subject, body string) error) { sendEmail = send }(sendEmail)
+	sendEmail = func(to, subject, body string) error {
+		sent = append(sent, body)
+		return nil
+	}
+
+	saveProfile := func(email string) {
+		req := httptest.NewRequest(http.MethodPut, "/members/Ann", strings.NewReader(`{"email":"`+email+`"}`))
+		req.SetPathValue("name", "Ann")
+		memberProfileHandler(httptest.NewRecorder(), req)
+	}
+	tokenFromLink := func(body string) string {
+		link, _ := url.Parse(body[strings.Index(body, "http"):])
+		return link.Query().Get("token")
+	}
+	book := func() int {
+		rec := httptest.NewRecorder()
+		bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"Ann","className":"Yoga","date":"15-12-2099"}`))))
+		return rec.Code
+	}
+	verify := func(token string) int {
+		rec := httptest.NewRecorder()
+		verifyEmailHandler(rec, httptest.NewRequest(http.MethodGet, "/verify?token="+url.QueryEscape(token), nil))
+		return rec.Code
+	}
+
+	saveProfile("ann@example.com")
+	if len(sent) != 1 {
+		t.Fatalf("expected a verification email, got %d", len(sent))
+	}
+	staleToken := tokenFromLink(sent[0])
+
+	if code := book(); code != http.StatusForbidden {
+		t.Errorf("expected the first booking to wait for verification, got %d", code)
+	}
+
+	// Changing the address invalidates the earlier link
+	saveProfile("ann@example.org")
+	if code := verify(staleToken); code != http.StatusBadRequest {
+		t.Errorf("expected the stale link to be rejected, got %d", code)
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		statusCode int
+	}{
+		{name: "Tampered Token", token: tokenFromLink(sent[1]) + "x", statusCode: http.StatusBadRequest},
+		{name: "Missing Token", token: "", statusCode: http.StatusBadRequest},
+		{name: "Valid Token", token: tokenFromLink(sent[1]), statusCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := verify(tt.token); code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, code)
+			}
+		})
+	}
+
+	if !members[0].EmailVerified {
+		t.Fatalf("expected the email to be verified")
+	}
+	if code := book(); code != http.StatusCreated {
+		t.Errorf("expected the booking to succeed once verified, got %d", code)
+	}
+
+	// Links expire
+	saveProfile("ann@example.net")
+	now = func() time.Time { return time.Now().Add(time.Duration(config.VerificationTokenHours+1) * time.Hour) }
+	if code := verify(tokenFromLink(sent[len(sent)-1])); code != http.StatusBadRequest {
+		t.Errorf("expected the expired link to be rejected, got %d", code)
+	}
+}
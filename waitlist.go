@@ -0,0 +1,300 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// WaitlistEntry queues a member for a full session
+type WaitlistEntry struct {
+	ID         int       `json:"id"`
+	MemberName string    `json:"memberName"`
+	ClassName  string    `json:"className"`
+	Date       Date      `json:"date"`
+	Tier       string    `json:"tier"`
+	JoinedAt   time.Time `json:"joinedAt"`
+}
+
+// Waitlist tiers
+const (
+	waitlistTierStaff    = "staff"
+	waitlistTierPremium  = "premium"
+	waitlistTierStandard = "standard"
+)
+
+// waitlistTierRanks orders the tiers, lowest rank first
+var waitlistTierRanks = map[string]int{
+	waitlistTierStaff:    0,
+	waitlistTierPremium:  1,
+	waitlistTierStandard: 2,
+}
+
+var (
+	waitlist   []WaitlistEntry // Temp Slice to hold waitlist entries
+	waitlistId = 1             // Incremental ID for waitlist entries
+)
+
+// waitlistQueue returns the entries for a session in the order they will be
+// offered a slot: by tier, then by the time they joined, then by ID.
+// Callers must hold the mutex.
+func waitlistQueue(className string, date Date) []WaitlistEntry {
+	queue := []WaitlistEntry{}
+	for _, entry := range waitlist {
+		if entry.ClassName == className && entry.Date == date {
+			queue = append(queue, entry)
+		}
+	}
+	sort.Slice(queue, func(i, j int) bool {
+		if rankI, rankJ := waitlistTierRanks[queue[i].Tier], waitlistTierRanks[queue[j].Tier]; rankI != rankJ {
+			return rankI < rankJ
+		}
+		if !queue[i].JoinedAt.Equal(queue[j].JoinedAt) {
+			return queue[i].JoinedAt.Before(queue[j].JoinedAt)
+		}
+		return queue[i].ID < queue[j].ID
+	})
+	return queue
+}
+
+// waitlistIndex returns the position of the entry with the given ID in storage, or -1.
+// Callers must hold the mutex.
+func waitlistIndex(id int) int {
+	for i, entry := range waitlist {
+		if entry.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// promoteWaitlist books waitlisted members into a session while it has free
+// slots and returns the bookings made. Callers must hold the mutex.
+func promoteWaitlist(className string, date Date) []Booking {
+	class := findClassOn(className, date.Time)
+	if class == nil {
+		return nil
+	}
+
+	capacity := sessionCapacity(class, date)
+	session := sessionIDOn(className, date)
+	var promoted []Booking
+	var promotedEntries []WaitlistEntry
+	for _, entry := range waitlistQueue(className, date) {
+		if capacity-countBookings(session)-countHolds(session) <= 0 {
+			break
+		}
+		// Blocked members keep their place but are passed over
+		if checkBlocked(entry.MemberName) != nil {
+			continue
+		}
+		booking := Booking{MemberName: entry.MemberName, ClassName: className, Date: date}
+		addBooking(&booking)
+		index := waitlistIndex(entry.ID)
+		waitlist = append(waitlist[:index], waitlist[index+1:]...)
+		promoted = append(promoted, booking)
+		promotedEntries = append(promotedEntries, entry)
+	}
+	if len(promoted) == 0 {
+		return nil
+	}
+
+	// The events announcing the bookings are saved before the bookings
+	for _, booking := range promoted {
+		recordAudit("system", "promote", "booking", booking.ID, nil, booking)
+	}
+	if err := writeDataToJsonFile("bookings.json", bookings); err != nil {
+		fmt.Println("Error saving promoted bookings:", err)
+	}
+	if err := writeDataToJsonFile("waitlist.json", waitlist); err != nil {
+		fmt.Println("Error saving waitlist:", err)
+	}
+	recordWaitlistOutcomes(promotedEntries, waitlistPromoted)
+	for _, booking := range promoted {
+		recordBookingEvent(booking.ID, bookingEventCreated, "system", map[string]string{"source": "waitlist"})
+		notifyBooking(notifyWaitlistPromoted, booking)
+	}
+	logData("Waitlist promoted", promoted)
+	return promoted
+}
+
+// Handler for joining the waitlist of a full session
+func waitlistHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	var entry WaitlistEntry
+	if err := decodeBody(r, &entry); err != nil {
+		errorResponse(w, http.StatusBadRequest, bodyErrorMessage(err))
+		return
+	}
+	// Members signed in without a key join for themselves, in their own tier
+	if member := sessionOnlyMember(r); member != "" {
+		entry.MemberName, entry.Tier = member, ""
+	}
+	if _, ok := waitlistTierRanks[entry.Tier]; entry.Tier != "" && !ok {
+		errorResponse(w, http.StatusBadRequest, "Invalid tier, use staff, premium or standard")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if entry.MemberName == "" || entry.ClassName == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid field format")
+		return
+	}
+	// Members wait in their membership tier unless one is given
+	if entry.Tier == "" {
+		entry.Tier = waitlistTierStandard
+		if index := memberIndex(entry.MemberName); index >= 0 && members[index].Tier != "" {
+			entry.Tier = members[index].Tier
+		}
+	}
+	if rejection := checkBlocked(entry.MemberName); rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+	if entry.Date.IsZero() {
+		errorResponse(w, http.StatusBadRequest, "Invalid date format, use DD-MM-YYYY")
+		return
+	}
+	date := entry.Date.Time
+	class := findClassOn(entry.ClassName, date)
+	if class == nil {
+		errorResponse(w, http.StatusBadRequest, "Class is not available on the specified date")
+		return
+	}
+
+	if rejection := checkEligibility(class, entry.MemberName, date, false); rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+
+	// Members only wait for sessions that are actually full
+	session := sessionIDOn(entry.ClassName, entry.Date)
+	if sessionCapacity(class, entry.Date)-countBookings(session)-countHolds(session) > 0 {
+		errorResponse(w, http.StatusBadRequest, "Class has available slots, book it instead")
+		return
+	}
+	if memberHasBooking(entry.MemberName, entry.ClassName, entry.Date) {
+		errorResponse(w, http.StatusBadRequest, "Member already has a booking for this class on this date")
+		return
+	}
+	for _, waiting := range waitlistQueue(entry.ClassName, entry.Date) {
+		if waiting.MemberName == entry.MemberName {
+			errorResponse(w, http.StatusBadRequest, "Member is already on the waitlist for this class on this date")
+			return
+		}
+	}
+
+	entry.ID = waitlistId
+	entry.JoinedAt = now()
+	waitlistId++
+	waitlist = append(waitlist, entry)
+
+	if err := writeDataToJsonFile("waitlist.json", waitlist); err != nil {
+		waitlist = waitlist[:len(waitlist)-1]
+		waitlistId--
+		errorResponse(w, http.StatusInternalServerError, "Failed to save waitlist data")
+		return
+	}
+	recordAudit(actorFromRequest(r), "create", "waitlist", entry.ID, nil, entry)
+	autoScaleSession(class, entry.Date)
+
+	response := map[string]interface{}{
+		"entry":    entry,
+		"position": waitlistPosition(entry),
+	}
+	successResponse(w, http.StatusCreated, "Added to the waitlist", response)
+	logData("Added to the waitlist", response)
+}
+
+// waitlistPosition returns an entry's 1-based place in its session's queue.
+// Callers must hold the mutex.
+func waitlistPosition(entry WaitlistEntry) int {
+	for i, waiting := range waitlistQueue(entry.ClassName, entry.Date) {
+		if waiting.ID == entry.ID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Handler for leaving the waitlist
+func waitlistItemHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is DELETE
+	if r.Method != http.MethodDelete {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid waitlist id")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := waitlistIndex(id)
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Waitlist entry not found")
+		return
+	}
+	entry := waitlist[index]
+	if member := sessionOnlyMember(r); member != "" && member != entry.MemberName {
+		errorResponse(w, http.StatusForbidden, "Waitlist entry belongs to another member")
+		return
+	}
+	waitlist = append(waitlist[:index], waitlist[index+1:]...)
+
+	if err := writeDataToJsonFile("waitlist.json", waitlist); err != nil {
+		waitlist = append(waitlist[:index], append([]WaitlistEntry{entry}, waitlist[index:]...)...)
+		errorResponse(w, http.StatusInternalServerError, "Failed to save waitlist data")
+		return
+	}
+	recordAudit(actorFromRequest(r), "delete", "waitlist", id, entry, nil)
+	recordWaitlistOutcomes([]WaitlistEntry{entry}, waitlistLeft)
+
+	successResponse(w, http.StatusOK, "Removed from the waitlist", entry)
+	logData("Removed from the waitlist", entry)
+}
+
+// memberWaitlistEntry is a waitlist entry with the member's place in the queue
+type memberWaitlistEntry struct {
+	WaitlistEntry
+	Position int `json:"position"`
+}
+
+// Handler for a member's waitlist entries and their positions
+func memberWaitlistHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	memberName := r.PathValue("name")
+	if memberName == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid member name")
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	entries := []memberWaitlistEntry{}
+	for _, entry := range waitlist {
+		if entry.MemberName == memberName {
+			entries = append(entries, memberWaitlistEntry{WaitlistEntry: entry, Position: waitlistPosition(entry)})
+		}
+	}
+	successResponse(w, http.StatusOK, "Waitlist retrieved successfully", map[string]interface{}{"entries": entries})
+}
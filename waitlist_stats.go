@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// How waitlist entries end
+const (
+	waitlistPromoted  = "promoted"  // A place freed up and the member was booked
+	waitlistLeft      = "left"      // The member gave up their place
+	waitlistCancelled = "cancelled" // The session was cancelled or moved away
+)
+
+// WaitlistOutcome records how a waitlist entry ended, once it left the waitlist
+type WaitlistOutcome struct {
+	ID         int       `json:"id"`
+	EntryID    int       `json:"entryId"`
+	MemberName string    `json:"memberName"`
+	ClassName  string    `json:"className"`
+	Date       Date      `json:"date"` // DD-MM-YYYY of the session waited for
+	JoinedAt   time.Time `json:"joinedAt"`
+	EndedAt    time.Time `json:"endedAt"`
+	Outcome    string    `json:"outcome"`
+}
+
+var (
+	waitlistHistory   []WaitlistOutcome // Temp Slice to hold the outcomes of past waitlist entries
+	waitlistOutcomeId = 1               // Incremental ID for waitlist outcomes
+)
+
+// recordWaitlistOutcomes remembers how waitlist entries ended. Callers must
+// hold the mutex.
+func recordWaitlistOutcomes(entries []WaitlistEntry, outcome string) {
+	if len(entries) == 0 {
+		return
+	}
+	for _, entry := range entries {
+		waitlistHistory = append(waitlistHistory, WaitlistOutcome{
+			ID:         waitlistOutcomeId,
+			EntryID:    entry.ID,
+			MemberName: entry.MemberName,
+			ClassName:  entry.ClassName,
+			Date:       entry.Date,
+			JoinedAt:   entry.JoinedAt,
+			EndedAt:    now(),
+			Outcome:    outcome,
+		})
+		waitlistOutcomeId++
+	}
+	// A failed history write must not undo the change it describes
+	if err := writeDataToJsonFile("waitlist_history.json", waitlistHistory); err != nil {
+		fmt.Println("Error saving waitlist history:", err)
+	}
+}
+
+// waitlistConversion summarizes what became of the members waiting for a
+// class's sessions
+type waitlistConversion struct {
+	ClassName      string  `json:"className"`
+	Joined         int     `json:"joined"`
+	Promoted       int     `json:"promoted"`
+	Left           int     `json:"left"`
+	Expired        int     `json:"expired"`   // Still waiting when the session took place
+	Abandoned      int     `json:"abandoned"` // Left or expired
+	Cancelled      int     `json:"cancelled"` // Their session was cancelled, counted as neither
+	Waiting        int     `json:"waiting"`   // Still waiting for an upcoming session
+	ConversionRate float64 `json:"conversionRate"`
+	HoursToPromote float64 `json:"averageHoursToPromotion"`
+}
+
+// Handler for waitlist conversion: per class, how many waitlisted members for
+// sessions over a range of dates were promoted and how many abandoned
+func waitlistStatsHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+	from, to, rejection := statsRange(r)
+	if rejection != nil {
+		errorResponse(w, rejection.StatusCode, rejection.Message)
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	conversions := map[string]*waitlistConversion{}
+	hours := map[string]float64{}
+	conversion := func(className string, date Date) *waitlistConversion {
+		if date.Before(from) || date.After(to) {
+			return nil
+		}
+		if conversions[className] == nil {
+			conversions[className] = &waitlistConversion{ClassName: className}
+		}
+		conversions[className].Joined++
+		return conversions[className]
+	}
+	for _, outcome := range waitlistHistory {
+		total := conversion(outcome.ClassName, outcome.Date)
+		if total == nil {
+			continue
+		}
+		switch outcome.Outcome {
+		case waitlistPromoted:
+			total.Promoted++
+			hours[outcome.ClassName] += outcome.EndedAt.Sub(outcome.JoinedAt).Hours()
+		case waitlistLeft:
+			total.Left++
+		case waitlistCancelled:
+			total.Cancelled++
+		}
+	}
+	for _, entry := range waitlist {
+		total := conversion(entry.ClassName, entry.Date)
+		if total == nil {
+			continue
+		}
+		if entry.Date.Before(today()) {
+			total.Expired++
+		} else {
+			total.Waiting++
+		}
+	}
+
+	report := []waitlistConversion{}
+	totals := waitlistConversion{ClassName: "total"}
+	totalHours := 0.0
+	for className, total := range conversions {
+		total.Abandoned = total.Left + total.Expired
+		total.ConversionRate = statsRatio(total.Promoted, total.Promoted+total.Abandoned)
+		if total.Promoted > 0 {
+			total.HoursToPromote = math.Round(hours[className]/float64(total.Promoted)*10) / 10
+		}
+		report = append(report, *total)
+
+		totals.Joined += total.Joined
+		totals.Promoted += total.Promoted
+		totals.Left += total.Left
+		totals.Expired += total.Expired
+		totals.Abandoned += total.Abandoned
+		totals.Cancelled += total.Cancelled
+		totals.Waiting += total.Waiting
+		totalHours += hours[className]
+	}
+	totals.ConversionRate = statsRatio(totals.Promoted, totals.Promoted+totals.Abandoned)
+	if totals.Promoted > 0 {
+		totals.HoursToPromote = math.Round(totalHours/float64(totals.Promoted)*10) / 10
+	}
+	// Classes turning the most members away come first
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Abandoned != report[j].Abandoned {
+			return report[i].Abandoned > report[j].Abandoned
+		}
+		return report[i].ClassName < report[j].ClassName
+	})
+
+	statsResponse(w, r, "Waitlist report retrieved successfully", "classes", map[string]interface{}{
+		"from":    from.Format(dateLayout),
+		"to":      to.Format(dateLayout),
+		"classes": report,
+		"totals":  totals,
+	})
+}
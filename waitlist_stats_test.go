@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestWaitlistStats verifies promotions, departures and expired entries are counted per class.
+func TestWaitlistStats(t *testing.T) {
+	setupTestEnvironment()
+	now = func() time.Time { return time.Date(2099, 12, 10, 9, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 1}}
+	classId = 2
+	bookings = []Booking{
+		{ID: 1, MemberName: "Ann", ClassName: "Yoga", Date: testDate("12-12-2099"), Status: bookingStatusConfirmed},
+		{ID: 2, MemberName: "Ann", ClassName: "Yoga", Date: testDate("13-12-2099"), Status: bookingStatusConfirmed},
+	}
+	bookingId = 3
+	joined := now().Add(-4 * time.Hour)
+	waitlist = []WaitlistEntry{
+		{ID: 1, MemberName: "Ben", ClassName: "Yoga", Date: testDate("12-12-2099"), Tier: waitlistTierStandard, JoinedAt: joined},
+		{ID: 2, MemberName: "Cat", ClassName: "Yoga", Date: testDate("13-12-2099"), Tier: waitlistTierStandard, JoinedAt: joined},
+		{ID: 3, MemberName: "Dan", ClassName: "Yoga", Date: testDate("14-12-2099"), Tier: waitlistTierStandard, JoinedAt: joined},
+		{ID: 4, MemberName: "Eve", ClassName: "Yoga", Date: testDate("05-12-2099"), Tier: waitlistTierStandard, JoinedAt: joined},
+	}
+	waitlistId = 5
+
+	// Ann cancels so Ben is promoted, and Cat gives up
+	cancel := httptest.NewRequest(http.MethodPost, "/bookings/1/cancel", nil)
+	cancel.SetPathValue("id", "1")
+	cancelBookingHandler(httptest.NewRecorder(), cancel)
+	leave := httptest.NewRequest(http.MethodDelete, "/waitlist/2", nil)
+	leave.SetPathValue("id", "2")
+	waitlistItemHandler(httptest.NewRecorder(), leave)
+	if len(waitlistHistory) != 2 || waitlistHistory[0].Outcome != waitlistPromoted || waitlistHistory[1].Outcome != waitlistLeft {
+		t.Fatalf("expected a promotion and a departure to be recorded, got %+v", waitlistHistory)
+	}
+
+	rec := httptest.NewRecorder()
+	waitlistStatsHandler(rec, httptest.NewRequest(http.MethodGet, "/stats/waitlist?from=01-12-2099&to=31-12-2099", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the report, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response struct {
+		Data struct {
+			Classes []waitlistConversion `json:"classes"`
+			Totals  waitlistConversion   `json:"totals"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&response)
+	expected := waitlistConversion{ClassName: "Yoga", Joined: 4, Promoted: 1, Left: 1, Expired: 1, Abandoned: 2, Waiting: 1, ConversionRate: 0.33, HoursToPromote: 4}
+	if len(response.Data.Classes) != 1 || response.Data.Classes[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, response.Data.Classes)
+	}
+	if response.Data.Totals.Joined != 4 || response.Data.Totals.ConversionRate != 0.33 {
+		t.Errorf("expected the totals to match the only class, got %+v", response.Data.Totals)
+	}
+
+	rec = httptest.NewRecorder()
+	waitlistStatsHandler(rec, httptest.NewRequest(http.MethodGet, "/stats/waitlist?from=11-12-2099&to=12-12-2099", nil))
+	response.Data.Classes = nil
+	json.NewDecoder(rec.Body).Decode(&response)
+	if len(response.Data.Classes) != 1 || response.Data.Classes[0].Joined != 1 || response.Data.Classes[0].Promoted != 1 {
+		t.Errorf("expected only the session in range to count, got %+v", response.Data.Classes)
+	}
+}
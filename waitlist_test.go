@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestWaitlistPriority verifies higher tiers are queued ahead and promoted first.
+func TestWaitlistPriority(t *testing.T) {
+	setupTestEnvironment()
+	current := time.Date(2024, 12, 16, 10, 0, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+	classes = []Class{{ID: 1, ClassName: "Pilates", StartDate: testDate("01-12-2024"), EndDate: testDate("31-12-2024"), Capacity: 1}}
+	classId = 2
+	bookings = []Booking{{ID: 1, MemberName: "Booked", Date: testDate("20-12-2024"), ClassName: "Pilates", Status: bookingStatusConfirmed}}
+	bookingId = 2
+	migrateSessions()
+
+	tests := []struct {
+		name       string
+		body       string
+		statusCode int
+		position   int
+	}{
+		{name: "Standard Member", body: `{"memberName":"Ann","className":"Pilates","date":"20-12-2024"}`, statusCode: http.StatusCreated, position: 1},
+		{name: "Premium Member Goes Ahead", body: `{"memberName":"Ben","className":"Pilates","date":"20-12-2024","tier":"premium"}`, statusCode: http.StatusCreated, position: 1},
+		{name: "Staff Go First", body: `{"memberName":"Cat","className":"Pilates","date":"20-12-2024","tier":"staff"}`, statusCode: http.StatusCreated, position: 1},
+		{name: "Later Premium Member Queues Behind", body: `{"memberName":"Dan","className":"Pilates","date":"20-12-2024","tier":"premium"}`, statusCode: http.StatusCreated, position: 3},
+		{name: "Already Waiting", body: `{"memberName":"Ann","className":"Pilates","date":"20-12-2024"}`, statusCode: http.StatusBadRequest},
+		{name: "Already Booked", body: `{"memberName":"Booked","className":"Pilates","date":"20-12-2024"}`, statusCode: http.StatusBadRequest},
+		{name: "Unknown Tier", body: `{"memberName":"Eve","className":"Pilates","date":"20-12-2024","tier":"gold"}`, statusCode: http.StatusBadRequest},
+		{name: "Session Not Full", body: `{"memberName":"Eve","className":"Pilates","date":"21-12-2024"}`, statusCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current = current.Add(time.Minute)
+			rec := httptest.NewRecorder()
+			waitlistHandler(rec, httptest.NewRequest(http.MethodPost, "/waitlist", bytes.NewReader([]byte(tt.body))))
+
+			if rec.Code != tt.statusCode {
+				t.Fatalf("expected status code %d, got %d", tt.statusCode, rec.Code)
+			}
+			if tt.statusCode != http.StatusCreated {
+				return
+			}
+			var response struct {
+				Data struct {
+					Position int `json:"position"`
+				} `json:"data"`
+			}
+			json.NewDecoder(rec.Body).Decode(&response)
+			if response.Data.Position != tt.position {
+				t.Errorf("expected position %d, got %d", tt.position, response.Data.Position)
+			}
+		})
+	}
+
+	// Each member sees where they stand.
+	req := httptest.NewRequest(http.MethodGet, "/members/Ann/waitlist", nil)
+	req.SetPathValue("name", "Ann")
+	rec := httptest.NewRecorder()
+	memberWaitlistHandler(rec, req)
+	var response struct {
+		Data struct {
+			Entries []memberWaitlistEntry `json:"entries"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rec.Body).Decode(&response)
+	if len(response.Data.Entries) != 1 || response.Data.Entries[0].Position != 4 {
+		t.Errorf("expected Ann in fourth place, got %+v", response.Data.Entries)
+	}
+
+	// A cancellation hands the slot to the front of the queue.
+	req = httptest.NewRequest(http.MethodPost, "/bookings/1/cancel", nil)
+	req.SetPathValue("id", "1")
+	cancelBookingHandler(httptest.NewRecorder(), req)
+	if len(bookings) != 2 || bookings[1].MemberName != "Cat" {
+		t.Fatalf("expected Cat to be promoted, got %+v", bookings)
+	}
+	if len(waitlist) != 3 {
+		t.Errorf("expected Cat to leave the waitlist, got %+v", waitlist)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+// WaiverAcceptance records a member accepting a version of the terms of service
+type WaiverAcceptance struct {
+	ID         int       `json:"id"`
+	MemberName string    `json:"memberName"`
+	Version    string    `json:"version"`
+	AcceptedAt time.Time `json:"acceptedAt"`
+	RecordedBy string    `json:"recordedBy"`
+}
+
+var (
+	waiverAcceptances []WaiverAcceptance // Temp Slice to hold waiver acceptances
+	waiverId          = 1                // Incremental ID for waiver acceptances
+)
+
+// waiverAccepted reports whether a member has accepted a waiver version.
+// Callers must hold the mutex.
+func waiverAccepted(memberName, version string) bool {
+	for _, acceptance := range waiverAcceptances {
+		if acceptance.MemberName == memberName && acceptance.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// firstBooking reports whether a member has never booked before.
+// Callers must hold the mutex.
+func firstBooking(memberName string) bool {
+	for _, booking := range bookings {
+		if booking.MemberName == memberName {
+			return false
+		}
+	}
+	return true
+}
+
+// checkWaiver rejects a member's first booking until they accept the current
+// waiver, when one is configured. Callers must hold the mutex.
+func checkWaiver(memberName string) *requestRejection {
+	if config.WaiverVersion == "" || !firstBooking(memberName) || waiverAccepted(memberName, config.WaiverVersion) {
+		return nil
+	}
+	return &requestRejection{http.StatusForbidden, "Member must accept waiver version " + config.WaiverVersion + " before their first booking"}
+}
+
+// Handler for a member's waiver acceptances
+func memberWaiverHandler(w http.ResponseWriter, r *http.Request) {
+	memberName := r.PathValue("name")
+	if memberName == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid member name")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		accepted := []WaiverAcceptance{}
+		for _, acceptance := range waiverAcceptances {
+			if acceptance.MemberName == memberName {
+				accepted = append(accepted, acceptance)
+			}
+		}
+		mutex.Unlock()
+		response := map[string]interface{}{
+			"currentVersion": config.WaiverVersion,
+			"acceptances":    accepted,
+		}
+		successResponse(w, http.StatusOK, "Waiver acceptances retrieved successfully", response)
+
+	case http.MethodPost:
+		var request struct {
+			Version string `json:"version"`
+		}
+		if err := decodeBody(r, &request); err != nil || request.Version == "" {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		// Members can only accept the terms currently in force
+		if request.Version != config.WaiverVersion {
+			errorResponse(w, http.StatusBadRequest, "Only the current waiver version "+config.WaiverVersion+" can be accepted")
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if waiverAccepted(memberName, request.Version) {
+			errorResponse(w, http.StatusBadRequest, "Member has already accepted this waiver version")
+			return
+		}
+		acceptance := WaiverAcceptance{
+			ID:         waiverId,
+			MemberName: memberName,
+			Version:    request.Version,
+			AcceptedAt: now(),
+			RecordedBy: actorFromRequest(r),
+		}
+		waiverId++
+		waiverAcceptances = append(waiverAcceptances, acceptance)
+
+		if err := writeDataToJsonFile("waivers.json", waiverAcceptances); err != nil {
+			waiverAcceptances = waiverAcceptances[:len(waiverAcceptances)-1]
+			waiverId--
+			errorResponse(w, http.StatusInternalServerError, "Failed to save waiver data")
+			return
+		}
+		recordAudit(acceptance.RecordedBy, "accept", "waiver", acceptance.ID, nil, acceptance)
+
+		successResponse(w, http.StatusCreated, "Waiver accepted successfully", acceptance)
+		logData("Waiver accepted successfully", acceptance)
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// Handler for the waiver acceptance records kept for legal audits
+func waiversHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is GET
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	version, memberName := r.URL.Query().Get("version"), r.URL.Query().Get("memberName")
+	page, err := paginationParams(r)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid pagination parameters")
+		return
+	}
+
+	mutex.Lock()
+	matches := []WaiverAcceptance{}
+	for _, acceptance := range waiverAcceptances {
+		if (version == "" || acceptance.Version == version) && (memberName == "" || acceptance.MemberName == memberName) {
+			matches = append(matches, acceptance)
+		}
+	}
+	mutex.Unlock()
+
+	items, nextCursor := paginate(matches, page, func(acceptance WaiverAcceptance) pageCursor {
+		return pageCursor{ID: acceptance.ID}
+	}, false)
+	response := map[string]interface{}{
+		"acceptances": items,
+		"total":       len(matches),
+		"limit":       page.Limit,
+		"offset":      page.Offset,
+		"nextCursor":  nextCursor,
+	}
+	successResponse(w, http.StatusOK, "Waiver acceptances retrieved successfully", response)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestWaiverAcceptance verifies members accept the current waiver before their first booking.
+func TestWaiverAcceptance(t *testing.T) {
+	setupTestEnvironment()
+	config.WaiverVersion = "2099-1"
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10}}
+	classId = 2
+
+	book := func(member string) int {
+		rec := httptest.NewRecorder()
+		bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"`+member+`","className":"Yoga","date":"15-12-2099"}`))))
+		return rec.Code
+	}
+	accept := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/members/Ann/waiver", strings.NewReader(body))
+		req.SetPathValue("name", "Ann")
+		rec := httptest.NewRecorder()
+		memberWaiverHandler(rec, req)
+		return rec.Code
+	}
+
+	if code := book("Ann"); code != http.StatusForbidden {
+		t.Errorf("expected the first booking to need the waiver, got %d", code)
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		statusCode int
+	}{
+		{name: "Missing Version", body: `{}`, statusCode: http.StatusBadRequest},
+		{name: "Old Version", body: `{"version":"2098-1"}`, statusCode: http.StatusBadRequest},
+		{name: "Current Version", body: `{"version":"2099-1"}`, statusCode: http.StatusCreated},
+		{name: "Accepted Twice", body: `{"version":"2099-1"}`, statusCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := accept(tt.body); code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, code)
+			}
+		})
+	}
+
+	if code := book("Ann"); code != http.StatusCreated {
+		t.Errorf("expected the booking once the waiver is accepted, got %d", code)
+	}
+
+	// Members who booked before the waiver was introduced are not held back
+	bookings = append(bookings, Booking{ID: 99, MemberName: "Ben", ClassName: "Yoga", Date: testDate("01-12-2099"), Status: bookingStatusAttended})
+	if code := book("Ben"); code != http.StatusCreated {
+		t.Errorf("expected an existing member to book, got %d", code)
+	}
+
+	// The records are available for audits
+	rec := httptest.NewRecorder()
+	waiversHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/waivers?version=2099-1", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"memberName":"Ann"`) || !strings.Contains(rec.Body.String(), `"total":1`) {
+		t.Errorf("expected the acceptance in the audit records, got %s", rec.Body.String())
+	}
+}
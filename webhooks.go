@@ -0,0 +1,391 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebhookSubscription delivers domain events to an integrator's endpoint
+type WebhookSubscription struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"` // Signs every delivery, never returned after creation
+	Events    []string  `json:"events"`           // Event types delivered, empty delivers all
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// DomainEvent describes a change to a booking or class, as delivered to
+// webhooks and the event bus
+type DomainEvent struct {
+	ID       int         `json:"id"` // Audit entry the event comes from, 0 for test events
+	Type     string      `json:"type"`
+	Time     time.Time   `json:"time"`
+	EntityID int         `json:"entityId"`
+	Data     interface{} `json:"data,omitempty"`
+}
+
+// webhookTestEvent is the type of events sent by POST /admin/webhooks/{id}/test
+const webhookTestEvent = "webhook.test"
+
+// domainEvents maps audited operations, keyed "entity.action", to the event
+// types published for them
+var domainEvents = map[string]string{
+	"booking.create":      "booking.created",
+	"booking.cancel":      "booking.cancelled",
+	"booking.confirm":     "booking.confirmed",
+	"booking.expire":      "booking.expired",
+	"booking.promote":     "booking.promoted",
+	"booking.transfer":    "booking.transferred",
+	"booking.reschedule":  "booking.rescheduled",
+	"booking.check-in":    "booking.checkedIn",
+	"booking.refund":      "booking.refunded",
+	"class.create":        "class.created",
+	"class.clone":         "class.created",
+	"class.delete":        "class.deleted",
+	"class.restore":       "class.restored",
+	"class.archive":       "class.archived",
+	"class.reschedule":    "class.rescheduled",
+	"session.nearly-full": "session.nearlyFull",
+}
+
+var (
+	webhooks  []WebhookSubscription // Temp Slice to hold webhook subscriptions
+	webhookId = 1                   // Incremental ID for webhook subscriptions
+)
+
+// webhookClient delivers webhook requests
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// domainEventKnown reports whether an event type is ever published
+func domainEventKnown(eventType string) bool {
+	for _, known := range domainEvents {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookURLValid accepts absolute http and https URLs
+func webhookURLValid(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// validateWebhookEvents checks an event filter against the known event types
+func validateWebhookEvents(events []string) error {
+	for _, eventType := range events {
+		if !domainEventKnown(eventType) {
+			return fmt.Errorf("unknown event type %q", eventType)
+		}
+	}
+	return nil
+}
+
+// webhookWants reports whether a subscription receives an event type
+func webhookWants(subscription WebhookSubscription, eventType string) bool {
+	if !subscription.Active {
+		return false
+	}
+	if len(subscription.Events) == 0 {
+		return true
+	}
+	for _, wanted := range subscription.Events {
+		if wanted == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// publicWebhook strips the secret from a subscription before it is returned
+func publicWebhook(subscription WebhookSubscription) WebhookSubscription {
+	subscription.Secret = ""
+	return subscription
+}
+
+// webhookIndex returns the position of the subscription with the given ID, or -1.
+// Callers must hold the mutex.
+func webhookIndex(id int) int {
+	for i, subscription := range webhooks {
+		if subscription.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// signWebhook returns the X-Webhook-Signature value for a body
+func signWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliverWebhook POSTs an encoded event to a subscription and returns the
+// response status. Statuses outside 2xx are reported as errors.
+func deliverWebhook(subscription WebhookSubscription, eventType string, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", eventType)
+	req.Header.Set("X-Webhook-Signature", signWebhook(subscription.Secret, body))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// newWebhookSecret returns a random signing secret for subscriptions created without one
+func newWebhookSecret() string {
+	raw := make([]byte, 24)
+	rand.Read(raw)
+	return "whsec_" + hex.EncodeToString(raw)
+}
+
+// Handler for listing and creating webhook subscriptions
+func webhookHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		subscriptions := []WebhookSubscription{}
+		for _, subscription := range webhooks {
+			subscriptions = append(subscriptions, publicWebhook(subscription))
+		}
+		mutex.Unlock()
+		successResponse(w, http.StatusOK, "Webhooks retrieved successfully", map[string]interface{}{"webhooks": subscriptions})
+
+	case http.MethodPost:
+		var request struct {
+			URL    string   `json:"url"`
+			Secret string   `json:"secret"` // Generated when omitted
+			Events []string `json:"events"`
+			Active *bool    `json:"active"` // Defaults to true
+		}
+		if err := decodeBody(r, &request); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		request.URL = strings.TrimSpace(request.URL)
+		if !webhookURLValid(request.URL) {
+			errorResponse(w, http.StatusBadRequest, "Invalid url, use an absolute http or https URL")
+			return
+		}
+		if err := validateWebhookEvents(request.Events); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid events: "+err.Error())
+			return
+		}
+		if request.Secret == "" {
+			request.Secret = newWebhookSecret()
+		}
+		if request.Events == nil {
+			request.Events = []string{}
+		}
+		active := true
+		if request.Active != nil {
+			active = *request.Active
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		subscription := WebhookSubscription{
+			ID:        webhookId,
+			URL:       request.URL,
+			Secret:    request.Secret,
+			Events:    request.Events,
+			Active:    active,
+			CreatedAt: now(),
+			UpdatedAt: now(),
+		}
+		webhooks = append(webhooks, subscription)
+		if err := writeDataToJsonFile("webhooks.json", webhooks); err != nil {
+			webhooks = webhooks[:len(webhooks)-1]
+			errorResponse(w, http.StatusInternalServerError, "Failed to save webhook data")
+			return
+		}
+		webhookId++
+		recordAudit(actorFromRequest(r), "create", "webhook", subscription.ID, nil, publicWebhook(subscription))
+
+		// The secret is shown once so generated secrets can be stored by the integrator
+		successResponse(w, http.StatusCreated, "Webhook created successfully", map[string]interface{}{"webhook": publicWebhook(subscription), "secret": subscription.Secret})
+		logData("Webhook created successfully", publicWebhook(subscription))
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// Handler for reading, updating and deleting a webhook subscription
+func webhookItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid webhook id")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := webhookIndex(id)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Webhook not found")
+			return
+		}
+		successResponse(w, http.StatusOK, "Webhook retrieved successfully", publicWebhook(webhooks[index]))
+
+	case http.MethodPut:
+		// Omitted fields keep their current values
+		var request struct {
+			URL    *string   `json:"url"`
+			Secret *string   `json:"secret"`
+			Events *[]string `json:"events"`
+			Active *bool     `json:"active"`
+		}
+		if err := decodeBody(r, &request); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if request.URL != nil {
+			*request.URL = strings.TrimSpace(*request.URL)
+			if !webhookURLValid(*request.URL) {
+				errorResponse(w, http.StatusBadRequest, "Invalid url, use an absolute http or https URL")
+				return
+			}
+		}
+		if request.Secret != nil && *request.Secret == "" {
+			errorResponse(w, http.StatusBadRequest, "Secret cannot be empty")
+			return
+		}
+		if request.Events != nil {
+			if err := validateWebhookEvents(*request.Events); err != nil {
+				errorResponse(w, http.StatusBadRequest, "Invalid events: "+err.Error())
+				return
+			}
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := webhookIndex(id)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Webhook not found")
+			return
+		}
+
+		before := webhooks[index]
+		if request.URL != nil {
+			webhooks[index].URL = *request.URL
+		}
+		if request.Secret != nil {
+			webhooks[index].Secret = *request.Secret
+		}
+		if request.Events != nil {
+			webhooks[index].Events = append([]string{}, *request.Events...)
+		}
+		if request.Active != nil {
+			webhooks[index].Active = *request.Active
+		}
+		webhooks[index].UpdatedAt = now()
+		if err := writeDataToJsonFile("webhooks.json", webhooks); err != nil {
+			webhooks[index] = before
+			errorResponse(w, http.StatusInternalServerError, "Failed to save webhook data")
+			return
+		}
+		recordAudit(actorFromRequest(r), "update", "webhook", id, publicWebhook(before), publicWebhook(webhooks[index]))
+
+		successResponse(w, http.StatusOK, "Webhook updated successfully", publicWebhook(webhooks[index]))
+		logData("Webhook updated successfully", publicWebhook(webhooks[index]))
+
+	case http.MethodDelete:
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		index := webhookIndex(id)
+		if index < 0 {
+			errorResponse(w, http.StatusNotFound, "Webhook not found")
+			return
+		}
+
+		removed := webhooks[index]
+		remaining := append(append([]WebhookSubscription{}, webhooks[:index]...), webhooks[index+1:]...)
+		if err := writeDataToJsonFile("webhooks.json", remaining); err != nil {
+			errorResponse(w, http.StatusInternalServerError, "Failed to save webhook data")
+			return
+		}
+		webhooks = remaining
+		recordAudit(actorFromRequest(r), "delete", "webhook", id, publicWebhook(removed), nil)
+
+		successResponse(w, http.StatusOK, "Webhook deleted successfully", publicWebhook(removed))
+		logData("Webhook deleted successfully", publicWebhook(removed))
+
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+	}
+}
+
+// Handler for sending a signed test event to a webhook subscription
+func webhookTestHandler(w http.ResponseWriter, r *http.Request) {
+	// Ensure the request method is POST
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid webhook id")
+		return
+	}
+
+	mutex.Lock()
+	index := webhookIndex(id)
+	var subscription WebhookSubscription
+	if index >= 0 {
+		subscription = webhooks[index]
+	}
+	mutex.Unlock()
+	if index < 0 {
+		errorResponse(w, http.StatusNotFound, "Webhook not found")
+		return
+	}
+
+	// Inactive subscriptions can be tested too, so they can be checked before enabling them
+	event := DomainEvent{Type: webhookTestEvent, Time: now(), EntityID: id, Data: map[string]string{"message": "This is a test event"}}
+	body, err := json.Marshal(event)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Failed to encode test event")
+		return
+	}
+	status, err := deliverWebhook(subscription, webhookTestEvent, body)
+	if err != nil {
+		errorResponse(w, http.StatusBadGateway, "Test event delivery failed: "+err.Error())
+		return
+	}
+
+	response := map[string]interface{}{"event": event, "statusCode": status}
+	successResponse(w, http.StatusOK, "Test event delivered successfully", response)
+	logData("Test event delivered successfully", map[string]interface{}{"webhook": id, "statusCode": status})
+}
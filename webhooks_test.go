@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestWebhookSubscriptions verifies webhook subscriptions can be managed and keep their secret hidden.
+func TestWebhookSubscriptions(t *testing.T) {
+	setupTestEnvironment()
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"All Events", `{"url":"https://example.com/hooks"}`, http.StatusCreated},
+		{"Filtered", `{"url":"https://example.com/bookings","secret":"s3cret","events":["booking.created"],"active":false}`, http.StatusCreated},
+		{"Relative URL", `{"url":"/hooks"}`, http.StatusBadRequest},
+		{"Unsupported Scheme", `{"url":"ftp://example.com"}`, http.StatusBadRequest},
+		{"Unknown Event", `{"url":"https://example.com","events":["booking.deleted"]}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			webhookHandler(rec, httptest.NewRequest(http.MethodPost, "/admin/webhooks", bytes.NewReader([]byte(tt.body))))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+	if len(webhooks) != 2 || webhooks[0].Secret == "" || !webhooks[0].Active || webhooks[1].Active {
+		t.Fatalf("expected a generated secret and the active flags to be kept, got %+v", webhooks)
+	}
+
+	rec := httptest.NewRecorder()
+	webhookHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/webhooks", nil))
+	if bytes.Contains(rec.Body.Bytes(), []byte("s3cret")) || bytes.Contains(rec.Body.Bytes(), []byte(webhooks[0].Secret)) {
+		t.Errorf("expected secrets to be hidden, got %s", rec.Body.String())
+	}
+
+	// Updates only touch the fields given
+	req := httptest.NewRequest(http.MethodPut, "/admin/webhooks/2", bytes.NewReader([]byte(`{"active":true}`)))
+	req.SetPathValue("id", "2")
+	rec = httptest.NewRecorder()
+	webhookItemHandler(rec, req)
+	if rec.Code != http.StatusOK || !webhooks[1].Active || webhooks[1].Secret != "s3cret" || len(webhooks[1].Events) != 1 {
+		t.Errorf("expected only the active flag to change, got %d %+v", rec.Code, webhooks[1])
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/admin/webhooks/1", nil)
+	req.SetPathValue("id", "1")
+	rec = httptest.NewRecorder()
+	webhookItemHandler(rec, req)
+	if rec.Code != http.StatusOK || len(webhooks) != 1 || webhooks[0].ID != 2 {
+		t.Errorf("expected the subscription to be deleted, got %d %+v", rec.Code, webhooks)
+	}
+	rec = httptest.NewRecorder()
+	webhookItemHandler(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for a deleted subscription, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+// TestWebhookDelivery verifies test events and domain events are signed and filtered.
+func TestWebhookDelivery(t *testing.T) {
+	setupTestEnvironment()
+	received := make(chan *http.Request, 10)
+	bodies := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	webhooks = []WebhookSubscription{
+		{ID: 1, URL: server.URL, Secret: "s3cret", Events: []string{"booking.created"}, Active: true},
+		{ID: 2, URL: server.URL, Secret: "other", Events: []string{"class.deleted"}, Active: true},
+	}
+	webhookId = 3
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/webhooks/1/test", nil)
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+	webhookTestHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the test event to be delivered, got %d: %s", rec.Code, rec.Body.String())
+	}
+	r, body := <-received, <-bodies
+	if r.Header.Get("X-Webhook-Event") != webhookTestEvent || r.Header.Get("X-Webhook-Signature") != signWebhook("s3cret", body) {
+		t.Errorf("expected a signed test event, got headers %v", r.Header)
+	}
+
+	// Only the subscription filtering on booking.created receives the booking
+	classes = []Class{{ID: 1, ClassName: "Yoga", StartDate: testDate("01-12-2099"), EndDate: testDate("31-12-2099"), Capacity: 10}}
+	classId = 2
+	rec = httptest.NewRecorder()
+	bookingHandler(rec, httptest.NewRequest(http.MethodPost, "/bookings", bytes.NewReader([]byte(`{"memberName":"Ann","className":"Yoga","date":"15-12-2099"}`))))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected the booking to be created, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if delivered := dispatchOutbox(); delivered != 1 {
+		t.Errorf("expected one outbox record to be delivered, got %d", delivered)
+	}
+	select {
+	case r := <-received:
+		var event DomainEvent
+		json.Unmarshal(<-bodies, &event)
+		if r.Header.Get("X-Webhook-Event") != "booking.created" || event.Type != "booking.created" || event.EntityID != 1 {
+			t.Errorf("expected a booking.created event, got %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the booking to be delivered")
+	}
+	select {
+	case r := <-received:
+		t.Errorf("expected a single delivery, also got %s", r.Header.Get("X-Webhook-Event"))
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// A failing endpoint is reported to the caller
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	webhooks[1].URL = failing.URL
+	req = httptest.NewRequest(http.MethodPost, "/admin/webhooks/2/test", nil)
+	req.SetPathValue("id", "2")
+	rec = httptest.NewRecorder()
+	webhookTestHandler(rec, req)
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("expected status %d for a failing endpoint, got %d", http.StatusBadGateway, rec.Code)
+	}
+}